@@ -0,0 +1,160 @@
+// Command replay publishes JSON fixtures onto the Redis feeds SlashVibePR
+// reads, the way slack-relay and Poppit would, so the service can be driven
+// end to end without a Slack workspace:
+//
+//	go run ./cmd/replay cmd/replay/fixtures/slash-command.json
+//
+// Each fixture holds one payload or a JSON array of payloads, published in
+// order. The feed is picked from the payload (a slash command, block action,
+// message shortcut, view submission, or Poppit output) unless -kind is given.
+// Channel names and the pub/sub or streams mode come from config.yaml, as for
+// the service itself.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/handlers"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// Fixture kinds, one per feed.
+const (
+	kindSlash  = "slash"
+	kindAction = "action"
+	kindView   = "view"
+	kindPoppit = "poppit"
+)
+
+func main() {
+	kind := flag.String("kind", "", "feed to publish to: slash, action, view, or poppit (default: detect from each payload)")
+	delay := flag.Duration("delay", 0, "wait between payloads, e.g. to let a modal open before its Poppit output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: replay [-kind k] [-delay d] fixture.json... (- reads stdin)\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *kind != "" {
+		if _, ok := feeds(config.Config{})[*kind]; !ok {
+			logging.Fatal("Unknown -kind %q", *kind)
+		}
+	}
+
+	config := config.Load()
+	logging.SetLogFormat(config.LogFormat)
+	logging.SetLogLevel(config.LogLevel)
+
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: config.RedisAddr, Password: config.RedisPassword})
+	defer rdb.Close()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		logging.Fatal("Failed to connect to Redis: %v", err)
+	}
+
+	published := 0
+	for _, name := range flag.Args() {
+		payloads, err := readFixture(name)
+		if err != nil {
+			logging.Fatal("%v", err)
+		}
+		for _, payload := range payloads {
+			if published > 0 && *delay > 0 {
+				time.Sleep(*delay)
+			}
+			k := *kind
+			if k == "" {
+				if k, err = detectKind(payload); err != nil {
+					logging.Fatal("%s: %v", name, err)
+				}
+			}
+			feed := feeds(config)[k]
+			if err := handlers.Publish(ctx, rdb, feed, string(payload), config.UsesStreams()); err != nil {
+				logging.Fatal("Failed to publish %s payload from %s: %v", k, name, err)
+			}
+			logging.Info("Published %s payload from %s to %s", k, name, feed)
+			published++
+		}
+	}
+}
+
+// feeds maps each fixture kind to the channel or stream it is published on.
+func feeds(config config.Config) map[string]string {
+	return map[string]string{
+		kindSlash:  config.RedisChannel,
+		kindAction: config.RedisBlockActionsChannel,
+		kindView:   config.RedisViewSubmissionChannel,
+		kindPoppit: config.RedisPoppitOutputChannel,
+	}
+}
+
+// readFixture returns the compacted payloads in the named file, or stdin for
+// "-": the file's single JSON object, or each element of its JSON array.
+func readFixture(name string) ([][]byte, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	var raws []json.RawMessage
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", name, err)
+		}
+	} else {
+		raws = []json.RawMessage{data}
+	}
+
+	payloads := make([][]byte, 0, len(raws))
+	for _, raw := range raws {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", name, err)
+		}
+		payloads = append(payloads, buf.Bytes())
+	}
+	return payloads, nil
+}
+
+// detectKind works out which feed a payload belongs on from its fields.
+func detectKind(payload []byte) (string, error) {
+	var fields struct {
+		Type    string           `json:"type"`
+		Command *string          `json:"command"`
+		Output  *json.RawMessage `json:"output"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return "", fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	switch {
+	case fields.Type == "block_actions" || fields.Type == "message_action":
+		return kindAction, nil
+	case fields.Type == "view_submission":
+		return kindView, nil
+	case fields.Output != nil:
+		return kindPoppit, nil
+	case fields.Command != nil:
+		return kindSlash, nil
+	}
+	return "", fmt.Errorf("cannot tell which feed the payload is for; pass -kind")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixturesDetectKind(t *testing.T) {
+	want := map[string]string{
+		"slash-command.json":         kindSlash,
+		"pr-list-output.json":        kindPoppit,
+		"pr-chooser-submission.json": kindView,
+	}
+	for name, kind := range want {
+		payloads, err := readFixture(filepath.Join("fixtures", name))
+		if err != nil || len(payloads) != 1 {
+			t.Fatalf("%s: expected one payload, got %d, %v", name, len(payloads), err)
+		}
+		if got, err := detectKind(payloads[0]); err != nil || got != kind {
+			t.Errorf("%s: expected kind %s, got %q, %v", name, kind, got, err)
+		}
+	}
+}
+
+func TestReadFixtureArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flow.json")
+	data := "[\n  {\"type\": \"block_actions\"},\n  {\"command\": \"/pr\"}\n]\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	payloads, err := readFixture(path)
+	if err != nil || len(payloads) != 2 {
+		t.Fatalf("expected two payloads, got %d, %v", len(payloads), err)
+	}
+	if string(payloads[0]) != `{"type":"block_actions"}` {
+		t.Errorf("expected a compacted payload, got %s", payloads[0])
+	}
+	if kind, _ := detectKind(payloads[1]); kind != kindSlash {
+		t.Errorf("expected a slash command, got %q", kind)
+	}
+	if _, err := detectKind([]byte(`{"text":"hi"}`)); err == nil {
+		t.Error("expected an unrecognised payload to be rejected")
+	}
+}
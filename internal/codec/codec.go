@@ -0,0 +1,148 @@
+// Package codec encodes the values SlashVibePR keeps in Redis or queues for
+// other services, as JSON or, where the reader supports it, the more
+// compact MessagePack.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec names selectable in the codecs config section.
+const (
+	NameJSON        = "json"
+	NameMessagePack = "msgpack"
+)
+
+// Codec marshals values to bytes and back.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON is the default codec, understood by every counterpart.
+	JSON Codec = jsonCodec{}
+	// MessagePack encodes the same document as JSON would, field names
+	// included, in MessagePack's binary form.
+	MessagePack Codec = msgpackCodec{}
+)
+
+// Validate reports whether name is a known codec. Empty means JSON.
+func Validate(name string) error {
+	switch name {
+	case "", NameJSON, NameMessagePack:
+		return nil
+	}
+	return fmt.Errorf("unknown codec %q (want %q or %q)", name, NameJSON, NameMessagePack)
+}
+
+// Named returns the codec called name, or JSON for an empty or unknown
+// name.
+func Named(name string) Codec {
+	if name == NameMessagePack {
+		return MessagePack
+	}
+	return JSON
+}
+
+// Unmarshal decodes data into v with whichever codec wrote it, so a reader
+// keeps working while the writer's codec is switched.
+func Unmarshal(data []byte, v interface{}) error {
+	if IsMessagePack(data) {
+		return MessagePack.Unmarshal(data, v)
+	}
+	return JSON.Unmarshal(data, v)
+}
+
+// IsMessagePack reports whether data holds a MessagePack map or array. A
+// JSON document always starts with printable ASCII, whereas every
+// MessagePack map and array header has the high bit set.
+func IsMessagePack(data []byte) bool {
+	return len(data) > 0 && data[0] >= 0x80
+}
+
+// ToJSON returns data as JSON, converting it from MessagePack if need be.
+func ToJSON(data []byte) ([]byte, error) {
+	if !IsMessagePack(data) {
+		return data, nil
+	}
+	var doc interface{}
+	if err := msgpack.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid MessagePack: %w", err)
+	}
+	return json.Marshal(doc)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return NameJSON }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec goes through JSON in both directions, so values keep their
+// JSON field names and custom (un)marshalers, such as Slack blocks',
+// whichever codec carries them.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return NameMessagePack }
+
+// Marshal encodes v as JSON would, in MessagePack. v may also be JSON
+// already, as []byte or json.RawMessage.
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	switch v := v.(type) {
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(fromJSONNumbers(doc))
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	data, err := ToJSON(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// fromJSONNumbers replaces the json.Numbers in doc with int64s, or float64s
+// when they are not integers, so MessagePack encodes them as numbers.
+func fromJSONNumbers(doc interface{}) interface{} {
+	switch v := doc.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = fromJSONNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = fromJSONNumbers(value)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,70 @@
+package codec
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testMessage struct {
+	Channel string            `json:"channel"`
+	TTL     int               `json:"ttl,omitempty"`
+	Ratio   float64           `json:"ratio"`
+	Sent    time.Time         `json:"sent"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// ---- codec tests ----
+
+func TestMessagePackRoundTrip(t *testing.T) {
+	in := testMessage{Channel: "C1", TTL: 86400, Ratio: 0.5, Sent: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Meta: map[string]string{"repo": "acme/api"}}
+	data, err := MessagePack.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsMessagePack(data) {
+		t.Fatalf("expected MessagePack, got %q", data)
+	}
+	jsonData, _ := JSON.Marshal(in)
+	if len(data) >= len(jsonData) {
+		t.Errorf("expected MessagePack (%d bytes) to be smaller than JSON (%d bytes)", len(data), len(jsonData))
+	}
+
+	var out testMessage
+	if err := Unmarshal(data, &out); err != nil || out.Channel != in.Channel || out.TTL != in.TTL || out.Ratio != in.Ratio || !out.Sent.Equal(in.Sent) || out.Meta["repo"] != "acme/api" {
+		t.Errorf("round trip mismatch: %+v, %v", out, err)
+	}
+	if err := Unmarshal(jsonData, &out); err != nil || out.Channel != "C1" {
+		t.Errorf("expected JSON to be detected, got %+v, %v", out, err)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	data, _ := MessagePack.Marshal(json.RawMessage(`{"channel":"C1","ttl":60}`))
+	got, err := ToJSON(data)
+	if err != nil || string(got) != `{"channel":"C1","ttl":60}` {
+		t.Errorf("expected the JSON document back, got %s, %v", got, err)
+	}
+	plain := []byte(`{"channel":"C1"}`)
+	if got, err := ToJSON(plain); err != nil || string(got) != string(plain) {
+		t.Errorf("expected JSON unchanged, got %s, %v", got, err)
+	}
+	if _, err := ToJSON([]byte{0x81, 0xc1}); err == nil {
+		t.Error("expected malformed MessagePack to be rejected")
+	}
+}
+
+func TestValidateAndNamed(t *testing.T) {
+	for _, name := range []string{"", NameJSON, NameMessagePack} {
+		if err := Validate(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	if err := Validate("protobuf"); err == nil || !strings.Contains(err.Error(), "protobuf") {
+		t.Errorf("expected an unknown codec error, got %v", err)
+	}
+	if Named(NameMessagePack) != MessagePack || Named("") != JSON {
+		t.Error("expected Named to resolve codecs, defaulting to JSON")
+	}
+}
@@ -0,0 +1,1187 @@
+// Package config loads the service configuration from config.yaml and the
+// secrets from the environment, *_FILE files, or Vault.
+package config
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+)
+
+// Ways of consuming the Slack and Poppit feeds, selected with redis.consumer.
+const (
+	ConsumerPubSub  = "pubsub"
+	ConsumerStreams = "streams"
+)
+
+// Slack transports selectable with slack.transport.
+const (
+	TransportRelay      = "relay"
+	TransportSocketMode = "socket_mode"
+)
+
+// PR backends selectable with github.mode.
+const (
+	GitHubModePoppit = "poppit"
+	GitHubModeAPI    = "api"
+)
+
+// Merge methods selectable with github.merge_method; each is also the gh pr
+// merge flag that selects it.
+const (
+	MergeMethodMerge  = "merge"
+	MergeMethodSquash = "squash"
+	MergeMethodRebase = "rebase"
+)
+
+const (
+	// DefaultGitHubAPIURL is the GitHub REST API root used in api mode
+	// unless github.api_url says otherwise.
+	DefaultGitHubAPIURL = "https://api.github.com"
+	// DefaultMessageTTL is how long SlackLiner keeps a posted message unless
+	// slack.message_ttl says otherwise.
+	DefaultMessageTTL = 24 * time.Hour
+	// DefaultHeaderEmoji starts posted PR messages unless
+	// slack.branding.emoji says otherwise.
+	DefaultHeaderEmoji = "📋"
+	// DefaultPRLimit is how many PRs are fetched unless limits.pr_list says
+	// otherwise.
+	DefaultPRLimit = 50
+	// MaxPRLimit is the most limits.pr_list may ask for; GitHub search
+	// returns no more than 1,000 results.
+	MaxPRLimit = 1000
+	// DefaultDescriptionLength is how much of the PR description is quoted
+	// in posts unless slack.description_length says otherwise.
+	DefaultDescriptionLength = 300
+	// MaxDescriptionLength keeps the post within Slack's 3,000-character
+	// section text limit.
+	MaxDescriptionLength = 1000
+	// DefaultSessionTTL is how long a chooser's PR session lasts unless
+	// limits.session_ttl says otherwise.
+	DefaultSessionTTL = 30 * time.Minute
+
+	defaultReminderThreshold = 24 * time.Hour
+	defaultMaxReminders      = 3
+)
+
+// ValidRepoName matches GitHub repository names: alphanumerics, hyphens, underscores, and dots.
+var ValidRepoName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// ValidOwnerName matches GitHub user and organisation names: alphanumerics and hyphens.
+var ValidOwnerName = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+// ValidBaseBranch matches branch names and simple glob patterns such as
+// "release/*". Shell metacharacters are rejected since the value ends up in a
+// Poppit command line.
+var ValidBaseBranch = regexp.MustCompile(`^[a-zA-Z0-9._/*-]+$`)
+
+// RestrictableCommands are the /pr subcommands roles.restricted_commands may
+// name.
+var RestrictableCommands = map[string]bool{
+	"approve": true,
+	"audit":   true,
+	"search":  true,
+	"stats":   true,
+	"status":  true,
+	"unpost":  true,
+}
+
+// Config holds all runtime configuration for the service.
+type Config struct {
+	RedisAddr                            string
+	RedisConsumer                        string
+	RedisConsumerGroup                   string
+	RedisConsumerName                    string
+	RedisPassword                        string
+	RedisChannel                         string
+	RedisViewSubmissionChannel           string
+	RedisBlockActionsChannel             string
+	RedisBlockSuggestionsChannel         string
+	RedisBlockSuggestionResponsesChannel string
+	RedisPoppitList                      string
+	RedisPoppitOutputChannel             string
+	RedisAppHomeChannel                  string
+	RedisLinkSharedChannel               string
+	RedisReactionAddedChannel            string
+	RedisSlackLinerList                  string
+	RedisEnvelope                        bool
+	SessionCodec                         string
+	PoppitCodec                          string
+	SlackLinerCodec                      string
+	SlackBotToken                        string
+	SlackChannelID                       string
+	SlackAdminUserIDs                    []string
+	PrivilegedUserIDs                    []string
+	PrivilegedUsergroupIDs               []string
+	RestrictedCommands                   []string
+	SlackMessageTemplate                 string
+	SlackThreadDetails                   bool
+	SlackDescriptionSnippet              bool
+	SlackDescriptionLength               int
+	SlackHeaderEmoji                     string
+	SlackUsername                        string
+	SlackIconEmoji                       string
+	SlackIconURL                         string
+	SlackPreviewPosts                    bool
+	GitHubOrg                            string
+	GitHubOrgs                           []string
+	GitHubBaseBranch                     string
+	GitHubHideDrafts                     bool
+	GitHubSlackUsers                     map[string]string
+	GitHubReviewerPools                  map[string][]string
+	GitHubMode                           string
+	GitHubMergeMethod                    string
+	GitHubAPIURL                         string
+	GitHubToken                          string
+	SlackSigningSecret                   string
+	SlackAppToken                        string
+	SlackTransport                       string
+	SlackMaxAttempts                     int
+	SlackMessageTTL                      time.Duration
+	SlackChannelRoutes                   map[string]string
+	PRListLimit                          int
+	SessionTTL                           time.Duration
+	PoppitTimeout                        time.Duration
+	HTTPAddr                             string
+	HealthAddr                           string
+	PprofAddr                            string
+	GitHubAppID                          int64
+	GitHubAppInstallationID              int64
+	GitHubAppPrivateKey                  string
+	SessionKey                           []byte
+	PoppitEnvKey                         []byte
+	LogLevel                             string
+	LogFormat                            string
+	DryRun                               bool
+	ReminderInterval                     time.Duration
+	ReminderTimezone                     *time.Location
+	ReminderChannels                     map[string]ReminderChannelConfig
+	AuditRetention                       time.Duration
+	TracingEndpoint                      string
+	TracingServiceName                   string
+	TracingSampleRatio                   float64
+	DigestTimezone                       *time.Location
+	DigestSchedules                      []DigestSchedule
+	LeaderboardChannel                   string
+	LeaderboardSchedule                  CronSchedule
+	LeaderboardTimezone                  *time.Location
+	Features                             map[string]FeatureFlag
+	// Vault is set when secrets come from Vault, so main can keep its
+	// token and lease renewed.
+	Vault *vaultSecrets
+	// Simulate is set by the --dry-run flag: Slack Web API calls,
+	// SlackLiner posts, and Poppit commands are printed instead of made.
+	// It is independent of DryRun, which echoes posts back to the user.
+	Simulate bool
+}
+
+// configFile mirrors the structure of config.yaml. All fields have sensible
+// defaults so a minimal config file only needs to override what differs from
+// the defaults.
+type configFile struct {
+	Redis struct {
+		Addr string `yaml:"addr"`
+		// Consumer is how the slash command, view submission, block action,
+		// and Poppit output feeds are read: "pubsub" or "streams".
+		Consumer      string `yaml:"consumer"`
+		ConsumerGroup string `yaml:"consumer_group"`
+		// ConsumerName identifies this replica: in the group in streams
+		// mode, and in the metadata of the Poppit commands it queues. It
+		// defaults to the hostname and must be stable across restarts.
+		ConsumerName string `yaml:"consumer_name"`
+	} `yaml:"redis"`
+	Channels struct {
+		SlashCommands            string `yaml:"slash_commands"`
+		ViewSubmissions          string `yaml:"view_submissions"`
+		BlockActions             string `yaml:"block_actions"`
+		BlockSuggestions         string `yaml:"block_suggestions"`
+		BlockSuggestionResponses string `yaml:"block_suggestion_responses"`
+		PoppitOutput             string `yaml:"poppit_output"`
+		AppHome                  string `yaml:"app_home"`
+		LinkShared               string `yaml:"link_shared"`
+		ReactionAdded            string `yaml:"reaction_added"`
+	} `yaml:"channels"`
+	Lists struct {
+		PoppitCommands     string `yaml:"poppit_commands"`
+		SlackLinerMessages string `yaml:"slackliner_messages"`
+		// Envelope wraps the Poppit commands and SlackLiner messages pushed
+		// to these lists in a versioned envelope. Enable it once Poppit and
+		// SlackLiner both unwrap it.
+		Envelope bool `yaml:"envelope"`
+	} `yaml:"lists"`
+	// Codecs select how values are encoded in Redis: "json" or "msgpack".
+	// Poppit and SlackLiner should only be switched once they decode
+	// MessagePack.
+	Codecs struct {
+		Sessions   string `yaml:"sessions"`
+		Poppit     string `yaml:"poppit"`
+		SlackLiner string `yaml:"slackliner"`
+	} `yaml:"codecs"`
+	// Poppit tunes how long the loading modal waits for Poppit output.
+	Poppit struct {
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"poppit"`
+	Slack struct {
+		ChannelID  string   `yaml:"channel_id"`
+		AdminUsers []string `yaml:"admin_users"`
+		// MessageTemplate is a Go text/template for the posted PR message.
+		MessageTemplate string `yaml:"message_template"`
+		// ThreadDetails posts a threaded follow-up with the PR description.
+		ThreadDetails bool `yaml:"thread_details"`
+		// DescriptionSnippet quotes the start of the PR description, up to
+		// DescriptionLength characters, in the post itself.
+		DescriptionSnippet bool `yaml:"description_snippet"`
+		DescriptionLength  int  `yaml:"description_length"`
+		// PreviewPosts shows a preview of the post, with Post and Back
+		// buttons, after a PR is chosen instead of posting it straight away.
+		PreviewPosts bool `yaml:"preview_posts"`
+		// Transport is how Slack requests arrive: "relay" (Redis) or
+		// "socket_mode".
+		Transport string `yaml:"transport"`
+		// MaxAttempts is how many times a views.open, views.push, or
+		// views.update call is tried before giving up.
+		MaxAttempts int `yaml:"max_attempts"`
+		// MessageTTL is how long SlackLiner keeps posted messages.
+		MessageTTL time.Duration `yaml:"message_ttl"`
+		// ChannelRoutes maps repo globs to the channel their PRs are posted
+		// to, wherever /pr was run. Patterns without a slash match the repo
+		// name alone.
+		ChannelRoutes map[string]string `yaml:"channel_routes"`
+		// Branding sets the header emoji of posted PR messages and the bot
+		// name and icon SlackLiner posts every message with.
+		Branding struct {
+			Emoji     string `yaml:"emoji"`
+			Username  string `yaml:"username"`
+			IconEmoji string `yaml:"icon_emoji"`
+			IconURL   string `yaml:"icon_url"`
+		} `yaml:"branding"`
+	} `yaml:"slack"`
+	// Limits bound how many PRs are fetched and how long chooser sessions
+	// last.
+	Limits struct {
+		PRList     int           `yaml:"pr_list"`
+		SessionTTL time.Duration `yaml:"session_ttl"`
+	} `yaml:"limits"`
+	GitHub struct {
+		Org string `yaml:"org"`
+		// Orgs lists every organisation offered in the repo chooser.
+		Orgs       []string `yaml:"orgs"`
+		BaseBranch string   `yaml:"base_branch"`
+		// HideDrafts leaves draft PRs out of PR lists unless --drafts is
+		// given; otherwise drafts are listed with a marker.
+		HideDrafts bool `yaml:"hide_drafts"`
+		// SlackUsers maps GitHub logins to Slack user IDs for @mentions.
+		SlackUsers map[string]string `yaml:"slack_users"`
+		// ReviewerPools maps owner/repo to the GitHub logins suggested in
+		// rotation as reviewers for that repo's posts.
+		ReviewerPools map[string][]string `yaml:"reviewer_pools"`
+		// Mode selects the PR backend: "poppit" (default) or "api".
+		Mode string `yaml:"mode"`
+		// MergeMethod is how the Merge button merges PRs: "merge" (default),
+		// "squash" or "rebase".
+		MergeMethod string `yaml:"merge_method"`
+		// APIURL is the GitHub REST API root used in api mode.
+		APIURL string `yaml:"api_url"`
+		// App authenticates as a GitHub App installation instead of a PAT.
+		App struct {
+			ID             int64  `yaml:"id"`
+			InstallationID int64  `yaml:"installation_id"`
+			PrivateKeyPath string `yaml:"private_key_path"`
+		} `yaml:"app"`
+	} `yaml:"github"`
+	// Reminders re-ping channels about posted PRs still waiting for review.
+	Reminders struct {
+		Interval time.Duration `yaml:"interval"`
+		// Timezone is the IANA zone quiet hours are interpreted in.
+		Timezone string                           `yaml:"timezone"`
+		Channels map[string]ReminderChannelConfig `yaml:"channels"`
+	} `yaml:"reminders"`
+	// HTTP enables the direct HTTP mode, in which Slack calls the service
+	// itself instead of going through the Redis relay.
+	HTTP struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"http"`
+	// Health serves the /healthz and /readyz probes.
+	Health struct {
+		Addr string `yaml:"addr"`
+	} `yaml:"health"`
+	// Debug exposes net/http/pprof for production profiling.
+	Debug struct {
+		PprofAddr string `yaml:"pprof_addr"`
+	} `yaml:"debug"`
+	// Roles restrict privileged subcommands to listed users and usergroups.
+	Roles struct {
+		PrivilegedUsers      []string `yaml:"privileged_users"`
+		PrivilegedUsergroups []string `yaml:"privileged_usergroups"`
+		RestrictedCommands   []string `yaml:"restricted_commands"`
+	} `yaml:"roles"`
+	// Audit keeps a trail of user actions in a Redis stream.
+	Audit struct {
+		Retention time.Duration `yaml:"retention"`
+	} `yaml:"audit"`
+	// Tracing exports OpenTelemetry spans over OTLP/HTTP.
+	Tracing struct {
+		// OTLPEndpoint is the collector's traces URL; empty disables tracing.
+		OTLPEndpoint string  `yaml:"otlp_endpoint"`
+		ServiceName  string  `yaml:"service_name"`
+		SampleRatio  float64 `yaml:"sample_ratio"`
+	} `yaml:"tracing"`
+	// Digests post scheduled summaries of open PRs.
+	Digests struct {
+		// Timezone is the IANA zone cron expressions are evaluated in.
+		Timezone  string           `yaml:"timezone"`
+		Schedules []DigestSchedule `yaml:"schedules"`
+	} `yaml:"digests"`
+	// Leaderboard posts a reviewer leaderboard on a schedule.
+	Leaderboard struct {
+		// Channel receives the leaderboard; empty disables it.
+		Channel  string `yaml:"channel"`
+		Cron     string `yaml:"cron"`
+		Timezone string `yaml:"timezone"`
+	} `yaml:"leaderboard"`
+	// Secrets selects where secrets are read from: "env" (environment
+	// variables and *_FILE files) or "vault".
+	Secrets struct {
+		Provider string `yaml:"provider"`
+		Vault    struct {
+			Address string `yaml:"address"`
+			// Role is the Kubernetes auth role; unused with VAULT_TOKEN.
+			Role     string `yaml:"role"`
+			AuthPath string `yaml:"auth_path"`
+			// Path is the API path of the KV secret, e.g.
+			// secret/data/slashvibepr for KV version 2.
+			Path string `yaml:"path"`
+		} `yaml:"vault"`
+	} `yaml:"secrets"`
+	Logging struct {
+		Level string `yaml:"level"`
+		// Format is "text" or "json".
+		Format string `yaml:"format"`
+	} `yaml:"logging"`
+	// Features turn feature flags on everywhere or for some workspaces
+	// and channels, keyed by flag name.
+	Features map[string]FeatureFlag `yaml:"features"`
+	DryRun   bool                   `yaml:"dry_run"`
+}
+
+// ReminderChannelConfig tunes stale-PR reminders for one Slack channel.
+type ReminderChannelConfig struct {
+	// ThresholdHours is how long a posted PR may wait without a review before
+	// each reminder.
+	ThresholdHours int `yaml:"threshold_hours"`
+	// QuietHours is a local "HH:MM-HH:MM" window in which no reminders are
+	// sent; it may wrap past midnight.
+	QuietHours   string `yaml:"quiet_hours"`
+	MaxReminders int    `yaml:"max_reminders"`
+}
+
+// DigestSchedule posts a digest of open PRs in Repos to Channel whenever
+// the five-field Cron expression matches.
+type DigestSchedule struct {
+	Channel string   `yaml:"channel"`
+	Cron    string   `yaml:"cron"`
+	Repos   []string `yaml:"repos"`
+
+	// Schedule is Cron parsed, filled in by Load and Parse.
+	Schedule CronSchedule `yaml:"-"`
+}
+
+// defaultConfigFile returns a configFile pre-populated with built-in defaults.
+func defaultConfigFile() configFile {
+	var cf configFile
+	cf.Redis.Addr = "host.docker.internal:6379"
+	cf.Redis.Consumer = ConsumerPubSub
+	cf.Redis.ConsumerGroup = "slashvibepr"
+	cf.Redis.ConsumerName, _ = os.Hostname()
+	cf.Channels.SlashCommands = "slack-commands"
+	cf.Channels.ViewSubmissions = "slack-relay-view-submission"
+	cf.Channels.BlockActions = "slack-relay-block-actions"
+	cf.Channels.BlockSuggestions = "slack-relay-block-suggestions"
+	cf.Channels.BlockSuggestionResponses = "slack-relay-block-suggestion-responses"
+	cf.Channels.PoppitOutput = "poppit:command-output"
+	cf.Channels.AppHome = "slack-relay-app-home-opened"
+	cf.Channels.LinkShared = "slack-relay-link-shared"
+	cf.Channels.ReactionAdded = "slack-relay-reaction-added"
+	cf.Lists.PoppitCommands = "poppit:commands"
+	cf.Lists.SlackLinerMessages = "slack_messages"
+	cf.Codecs.Sessions = codec.NameJSON
+	cf.Codecs.Poppit = codec.NameJSON
+	cf.Codecs.SlackLiner = codec.NameJSON
+	cf.Logging.Level = "INFO"
+	cf.Logging.Format = logging.LogFormatText
+	cf.Slack.ThreadDetails = true
+	cf.Slack.PreviewPosts = true
+	cf.Slack.Transport = TransportRelay
+	cf.Slack.MaxAttempts = 3
+	cf.Slack.MessageTTL = DefaultMessageTTL
+	cf.Slack.Branding.Emoji = DefaultHeaderEmoji
+	cf.Slack.DescriptionLength = DefaultDescriptionLength
+	cf.Limits.PRList = DefaultPRLimit
+	cf.Limits.SessionTTL = DefaultSessionTTL
+	cf.Poppit.Timeout = 30 * time.Second
+	cf.GitHub.Mode = GitHubModePoppit
+	cf.GitHub.MergeMethod = MergeMethodMerge
+	cf.GitHub.APIURL = DefaultGitHubAPIURL
+	cf.Reminders.Interval = 15 * time.Minute
+	cf.Reminders.Timezone = "UTC"
+	cf.Digests.Timezone = "UTC"
+	cf.Leaderboard.Cron = "0 9 * * 1"
+	cf.Leaderboard.Timezone = "UTC"
+	cf.Audit.Retention = 30 * 24 * time.Hour
+	cf.Tracing.ServiceName = "slashvibepr"
+	cf.Tracing.SampleRatio = 1
+	cf.Roles.RestrictedCommands = []string{"unpost"}
+	cf.Secrets.Provider = secretsProviderEnv
+	cf.Secrets.Vault.AuthPath = "kubernetes"
+	return cf
+}
+
+// Load reads non-secret configuration from the YAML config file (default
+// path: config.yaml, overridable via CONFIG_FILE) and the secrets
+// (REDIS_PASSWORD, SLACK_BOT_TOKEN, and optionally GITHUB_TOKEN,
+// GITHUB_APP_PRIVATE_KEY, SLACK_SIGNING_SECRET, SLACK_APP_TOKEN,
+// SESSION_ENCRYPTION_KEY, or POPPIT_ENV_KEY) from environment variables or,
+// via the matching _FILE variables, from mounted files.
+func Load() Config {
+	cfgPath := Path()
+
+	cf := defaultConfigFile()
+
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		// If the config file is missing, fall back to defaults. The service
+		// will still require the two secret env vars to be set.
+		logging.Warn("Config file %q not found, using built-in defaults: %v", cfgPath, err)
+	} else if err = yaml.Unmarshal(data, &cf); err != nil {
+		logging.Fatal("Failed to parse config file %q: %v", cfgPath, err)
+	}
+
+	if err := validateGitHubMode(cf.GitHub.Mode); err != nil {
+		logging.Fatal("Invalid github.mode in %q: %v", cfgPath, err)
+	}
+	if err := validateMergeMethod(cf.GitHub.MergeMethod); err != nil {
+		logging.Fatal("Invalid github.merge_method in %q: %v", cfgPath, err)
+	}
+	if err := validateConsumer(cf); err != nil {
+		logging.Fatal("Invalid redis.consumer in %q: %v", cfgPath, err)
+	}
+	if err := validateTransport(cf.Slack.Transport); err != nil {
+		logging.Fatal("Invalid slack.transport in %q: %v", cfgPath, err)
+	}
+	if err := validateCodecs(cf); err != nil {
+		logging.Fatal("Invalid codecs in %q: %v", cfgPath, err)
+	}
+	if err := validateBranding(cf); err != nil {
+		logging.Fatal("Invalid slack.branding in %q: %v", cfgPath, err)
+	}
+	if cf.Slack.MaxAttempts < 1 {
+		logging.Fatal("Invalid slack.max_attempts in %q: must be at least 1", cfgPath)
+	}
+	if cf.Slack.MessageTTL < time.Second {
+		logging.Fatal("Invalid slack.message_ttl in %q: must be at least 1s", cfgPath)
+	}
+	if cf.Slack.DescriptionLength < 1 || cf.Slack.DescriptionLength > MaxDescriptionLength {
+		logging.Fatal("Invalid slack.description_length in %q: must be between 1 and %d", cfgPath, MaxDescriptionLength)
+	}
+	if cf.Limits.PRList < 1 || cf.Limits.PRList > MaxPRLimit {
+		logging.Fatal("Invalid limits.pr_list in %q: must be between 1 and %d", cfgPath, MaxPRLimit)
+	}
+	if cf.Limits.SessionTTL <= 0 {
+		logging.Fatal("Invalid limits.session_ttl in %q: must be positive", cfgPath)
+	}
+	if cf.Poppit.Timeout < 0 {
+		logging.Fatal("Invalid poppit.timeout in %q: must not be negative", cfgPath)
+	}
+	if err := logging.ValidateLogFormat(cf.Logging.Format); err != nil {
+		logging.Fatal("Invalid logging.format in %q: %v", cfgPath, err)
+	}
+	if cf.Tracing.SampleRatio < 0 || cf.Tracing.SampleRatio > 1 {
+		logging.Fatal("Invalid tracing.sample_ratio in %q: must be between 0 and 1", cfgPath)
+	}
+	if err := validateReminders(cf); err != nil {
+		logging.Fatal("Invalid reminders in %q: %v", cfgPath, err)
+	}
+	if err := validateRoles(cf); err != nil {
+		logging.Fatal("Invalid roles in %q: %v", cfgPath, err)
+	}
+	if cf.Audit.Retention <= 0 {
+		logging.Fatal("Invalid audit.retention in %q: must be positive", cfgPath)
+	}
+	if err := validateDigests(cf); err != nil {
+		logging.Fatal("Invalid digests in %q: %v", cfgPath, err)
+	}
+	if err := validateLeaderboard(cf); err != nil {
+		logging.Fatal("Invalid leaderboard in %q: %v", cfgPath, err)
+	}
+	if err := validateChannelRoutes(cf); err != nil {
+		logging.Fatal("Invalid slack.channel_routes in %q: %v", cfgPath, err)
+	}
+	if err := validateSecretsProvider(cf); err != nil {
+		logging.Fatal("Invalid secrets in %q: %v", cfgPath, err)
+	}
+	if err := validateFeatures(cf); err != nil {
+		logging.Fatal("Invalid features in %q: %v", cfgPath, err)
+	}
+
+	var secrets SecretsProvider = envSecrets{}
+	var vault *vaultSecrets
+	if cf.Secrets.Provider == secretsProviderVault {
+		if vault, err = newVaultSecrets(context.Background(), cf); err != nil {
+			logging.Fatal("Failed to load secrets from Vault: %v", err)
+		}
+		logging.Info("Loaded secrets from Vault at %s", cf.Secrets.Vault.Path)
+		secrets = vault
+	}
+
+	secret := func(key string) string {
+		value, err := secrets.Secret(key)
+		if err != nil {
+			logging.Fatal("Invalid %s: %v", key, err)
+		}
+		return value
+	}
+	cfg := buildConfig(cf, secret("REDIS_PASSWORD"), secret("SLACK_BOT_TOKEN"))
+	cfg.GitHubToken = secret("GITHUB_TOKEN")
+	cfg.SlackSigningSecret = secret("SLACK_SIGNING_SECRET")
+	cfg.SlackAppToken = secret("SLACK_APP_TOKEN")
+	sessionKey, err := session.ParseKey(secret("SESSION_ENCRYPTION_KEY"))
+	if err != nil {
+		logging.Fatal("Invalid SESSION_ENCRYPTION_KEY: %v", err)
+	}
+	cfg.SessionKey = sessionKey
+	poppitEnvKey, err := session.ParseKey(secret("POPPIT_ENV_KEY"))
+	if err != nil {
+		logging.Fatal("Invalid POPPIT_ENV_KEY: %v", err)
+	}
+	cfg.PoppitEnvKey = poppitEnvKey
+	cfg.Vault = vault
+	if cf.GitHub.App.ID != 0 {
+		key, err := loadGitHubAppKey(cf.GitHub.App.PrivateKeyPath, secrets)
+		if err != nil {
+			logging.Fatal("Invalid github.app configuration: %v", err)
+		}
+		cfg.GitHubAppPrivateKey = key
+	}
+	if cfg.UsesGitHubAPI() && !cfg.HasGitHubAuth() {
+		logging.Warn("github.mode is api but no GitHub credentials are configured; requests will be unauthenticated")
+	}
+	return cfg
+}
+
+// Path returns the config file Load reads: config.yaml, or CONFIG_FILE.
+func Path() string {
+	return getEnv("CONFIG_FILE", "config.yaml")
+}
+
+// Reload re-reads the config file and returns current with the settings that
+// are consulted as each interaction is handled replaced by the file's.
+// Secrets and the settings only read at startup (Redis, the feeds, listen
+// addresses, transports, codecs, logging, tracing, and the reminder, digest,
+// and leaderboard schedules) keep their current values.
+func Reload(current Config) (Config, error) {
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		return current, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return reload(current, data)
+}
+
+// reload is Reload for config file contents already read.
+func reload(current Config, data []byte) (Config, error) {
+	next, err := Parse(data, current.RedisPassword, current.SlackBotToken)
+	if err != nil {
+		return current, err
+	}
+	current.SlackChannelID = next.SlackChannelID
+	current.SlackAdminUserIDs = next.SlackAdminUserIDs
+	current.PrivilegedUserIDs = next.PrivilegedUserIDs
+	current.PrivilegedUsergroupIDs = next.PrivilegedUsergroupIDs
+	current.RestrictedCommands = next.RestrictedCommands
+	current.SlackMessageTemplate = next.SlackMessageTemplate
+	current.SlackThreadDetails = next.SlackThreadDetails
+	current.SlackDescriptionSnippet = next.SlackDescriptionSnippet
+	current.SlackDescriptionLength = next.SlackDescriptionLength
+	current.SlackHeaderEmoji = next.SlackHeaderEmoji
+	current.SlackUsername = next.SlackUsername
+	current.SlackIconEmoji = next.SlackIconEmoji
+	current.SlackIconURL = next.SlackIconURL
+	current.SlackPreviewPosts = next.SlackPreviewPosts
+	current.SlackMaxAttempts = next.SlackMaxAttempts
+	current.SlackMessageTTL = next.SlackMessageTTL
+	current.SlackChannelRoutes = next.SlackChannelRoutes
+	current.GitHubOrg = next.GitHubOrg
+	current.GitHubOrgs = next.GitHubOrgs
+	current.GitHubBaseBranch = next.GitHubBaseBranch
+	current.GitHubHideDrafts = next.GitHubHideDrafts
+	current.GitHubSlackUsers = next.GitHubSlackUsers
+	current.GitHubReviewerPools = next.GitHubReviewerPools
+	current.GitHubMergeMethod = next.GitHubMergeMethod
+	current.PRListLimit = next.PRListLimit
+	current.SessionTTL = next.SessionTTL
+	current.PoppitTimeout = next.PoppitTimeout
+	current.AuditRetention = next.AuditRetention
+	current.Features = next.Features
+	return current, nil
+}
+
+// loadGitHubAppKey returns the App private key PEM from GITHUB_APP_PRIVATE_KEY
+// (through secrets) or, failing that, the configured key file, and checks
+// that it parses.
+func loadGitHubAppKey(path string, secrets SecretsProvider) (string, error) {
+	key, err := secrets.Secret("GITHUB_APP_PRIVATE_KEY")
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		if path == "" {
+			return "", fmt.Errorf("github.app.id is set but neither GITHUB_APP_PRIVATE_KEY nor github.app.private_key_path is")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read private key: %w", err)
+		}
+		key = string(data)
+	}
+	if _, err := ParseGitHubAppKey([]byte(key)); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// validateGitHubMode reports whether mode names a supported PR backend.
+func validateGitHubMode(mode string) error {
+	switch mode {
+	case GitHubModePoppit, GitHubModeAPI:
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q (want %q or %q)", mode, GitHubModePoppit, GitHubModeAPI)
+}
+
+// validateMergeMethod reports whether method is a gh pr merge method.
+func validateMergeMethod(method string) error {
+	switch method {
+	case MergeMethodMerge, MergeMethodSquash, MergeMethodRebase:
+		return nil
+	}
+	return fmt.Errorf("unknown merge method %q (want %q, %q or %q)", method, MergeMethodMerge, MergeMethodSquash, MergeMethodRebase)
+}
+
+// validateReminders checks the reminder interval, timezone, and each
+// channel's quiet hours.
+func validateReminders(cf configFile) error {
+	if cf.Reminders.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if _, err := time.LoadLocation(cf.Reminders.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	for channel, rc := range cf.Reminders.Channels {
+		if rc.ThresholdHours < 0 || rc.MaxReminders < 0 {
+			return fmt.Errorf("channel %s: threshold_hours and max_reminders must not be negative", channel)
+		}
+		if _, _, err := ParseQuietHours(rc.QuietHours); err != nil {
+			return fmt.Errorf("channel %s: %w", channel, err)
+		}
+	}
+	return nil
+}
+
+// validateRoles checks that every restricted command can be restricted.
+func validateRoles(cf configFile) error {
+	for _, name := range cf.Roles.RestrictedCommands {
+		if !RestrictableCommands[name] {
+			return fmt.Errorf("restricted_commands: unknown command %q", name)
+		}
+	}
+	return nil
+}
+
+// validateDigests checks the digest timezone and each schedule's channel,
+// cron expression, and repos.
+func validateDigests(cf configFile) error {
+	if _, err := time.LoadLocation(cf.Digests.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	for i, d := range cf.Digests.Schedules {
+		if d.Channel == "" || len(d.Repos) == 0 {
+			return fmt.Errorf("schedule %d: channel and repos are required", i)
+		}
+		if _, err := parseCron(d.Cron); err != nil {
+			return fmt.Errorf("schedule %d: %w", i, err)
+		}
+		for _, repo := range d.Repos {
+			owner, name, ok := strings.Cut(repo, "/")
+			if !ok || !ValidOwnerName.MatchString(owner) || !ValidRepoName.MatchString(name) {
+				return fmt.Errorf("schedule %d: %q is not an owner/name repo", i, repo)
+			}
+		}
+	}
+	return nil
+}
+
+// validateLeaderboard checks the leaderboard's cron expression and timezone.
+func validateLeaderboard(cf configFile) error {
+	if _, err := parseCron(cf.Leaderboard.Cron); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(cf.Leaderboard.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	return nil
+}
+
+// validateChannelRoutes checks that every channel route has a valid glob and
+// a channel.
+func validateChannelRoutes(cf configFile) error {
+	for pattern, channel := range cf.Slack.ChannelRoutes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q: %w", pattern, err)
+		}
+		if channel == "" {
+			return fmt.Errorf("%q: missing channel", pattern)
+		}
+	}
+	return nil
+}
+
+// getSecret returns the secret in environment variable key or, when key_FILE
+// is set instead, the trimmed contents of the file it names, as mounted by
+// Docker or Kubernetes secrets. Setting both is an error.
+func getSecret(key string) (string, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return os.Getenv(key), nil
+	}
+	if os.Getenv(key) != "" {
+		return "", fmt.Errorf("both %s and %s_FILE are set", key, key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// getEnv returns the value of an environment variable or a default.
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// Parse parses YAML bytes into a configFile and merges with
+// defaults, returning the resulting Config. Secrets are taken from the
+// supplied redisPassword and slackBotToken arguments rather than from the
+// environment so that tests remain hermetic.
+func Parse(data []byte, redisPassword, slackBotToken string) (Config, error) {
+	cf := defaultConfigFile()
+
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		return Config{}, fmt.Errorf("yaml parse error: %w", err)
+	}
+
+	if err := validateGitHubMode(cf.GitHub.Mode); err != nil {
+		return Config{}, fmt.Errorf("invalid github.mode: %w", err)
+	}
+	if err := validateMergeMethod(cf.GitHub.MergeMethod); err != nil {
+		return Config{}, fmt.Errorf("invalid github.merge_method: %w", err)
+	}
+	if err := validateConsumer(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid redis.consumer: %w", err)
+	}
+	if err := validateTransport(cf.Slack.Transport); err != nil {
+		return Config{}, fmt.Errorf("invalid slack.transport: %w", err)
+	}
+	if err := validateCodecs(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid codecs: %w", err)
+	}
+	if err := validateBranding(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid slack.branding: %w", err)
+	}
+	if cf.Slack.MaxAttempts < 1 {
+		return Config{}, fmt.Errorf("invalid slack.max_attempts: must be at least 1")
+	}
+	if cf.Slack.MessageTTL < time.Second {
+		return Config{}, fmt.Errorf("invalid slack.message_ttl: must be at least 1s")
+	}
+	if cf.Slack.DescriptionLength < 1 || cf.Slack.DescriptionLength > MaxDescriptionLength {
+		return Config{}, fmt.Errorf("invalid slack.description_length: must be between 1 and %d", MaxDescriptionLength)
+	}
+	if cf.Limits.PRList < 1 || cf.Limits.PRList > MaxPRLimit {
+		return Config{}, fmt.Errorf("invalid limits.pr_list: must be between 1 and %d", MaxPRLimit)
+	}
+	if cf.Limits.SessionTTL <= 0 {
+		return Config{}, fmt.Errorf("invalid limits.session_ttl: must be positive")
+	}
+	if cf.Poppit.Timeout < 0 {
+		return Config{}, fmt.Errorf("invalid poppit.timeout: must not be negative")
+	}
+	if err := logging.ValidateLogFormat(cf.Logging.Format); err != nil {
+		return Config{}, fmt.Errorf("invalid logging.format: %w", err)
+	}
+	if cf.Tracing.SampleRatio < 0 || cf.Tracing.SampleRatio > 1 {
+		return Config{}, fmt.Errorf("invalid tracing.sample_ratio: must be between 0 and 1")
+	}
+	if err := validateReminders(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid reminders: %w", err)
+	}
+	if err := validateRoles(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid roles: %w", err)
+	}
+	if cf.Audit.Retention <= 0 {
+		return Config{}, fmt.Errorf("invalid audit.retention: must be positive")
+	}
+	if err := validateDigests(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid digests: %w", err)
+	}
+	if err := validateLeaderboard(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid leaderboard: %w", err)
+	}
+	if err := validateChannelRoutes(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid slack.channel_routes: %w", err)
+	}
+	if err := validateSecretsProvider(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid secrets: %w", err)
+	}
+	if err := validateFeatures(cf); err != nil {
+		return Config{}, fmt.Errorf("invalid features: %w", err)
+	}
+
+	return buildConfig(cf, redisPassword, slackBotToken), nil
+}
+
+// buildConfig flattens a parsed configFile and the supplied secrets into a Config.
+// When only github.orgs is set, its first entry becomes the default org.
+func buildConfig(cf configFile, redisPassword, slackBotToken string) Config {
+	if cf.GitHub.Org == "" && len(cf.GitHub.Orgs) > 0 {
+		cf.GitHub.Org = cf.GitHub.Orgs[0]
+	}
+	// The zones and cron expressions have already been validated; fall back
+	// to UTC (and skip bad schedules) for callers that skip validation.
+	timezone, err := time.LoadLocation(cf.Reminders.Timezone)
+	if err != nil {
+		timezone = time.UTC
+	}
+	digestTimezone, err := time.LoadLocation(cf.Digests.Timezone)
+	if err != nil {
+		digestTimezone = time.UTC
+	}
+	digests := make([]DigestSchedule, 0, len(cf.Digests.Schedules))
+	for _, d := range cf.Digests.Schedules {
+		if s, err := parseCron(d.Cron); err == nil {
+			d.Schedule = s
+			digests = append(digests, d)
+		}
+	}
+	leaderboardTimezone, err := time.LoadLocation(cf.Leaderboard.Timezone)
+	if err != nil {
+		leaderboardTimezone = time.UTC
+	}
+	leaderboardSchedule, err := parseCron(cf.Leaderboard.Cron)
+	if err != nil {
+		cf.Leaderboard.Channel = ""
+	}
+	return Config{
+		RedisAddr:                            cf.Redis.Addr,
+		RedisConsumer:                        cf.Redis.Consumer,
+		RedisConsumerGroup:                   cf.Redis.ConsumerGroup,
+		RedisConsumerName:                    cf.Redis.ConsumerName,
+		RedisPassword:                        redisPassword,
+		RedisChannel:                         cf.Channels.SlashCommands,
+		RedisViewSubmissionChannel:           cf.Channels.ViewSubmissions,
+		RedisBlockActionsChannel:             cf.Channels.BlockActions,
+		RedisBlockSuggestionsChannel:         cf.Channels.BlockSuggestions,
+		RedisBlockSuggestionResponsesChannel: cf.Channels.BlockSuggestionResponses,
+		RedisPoppitList:                      cf.Lists.PoppitCommands,
+		RedisPoppitOutputChannel:             cf.Channels.PoppitOutput,
+		RedisAppHomeChannel:                  cf.Channels.AppHome,
+		RedisLinkSharedChannel:               cf.Channels.LinkShared,
+		RedisReactionAddedChannel:            cf.Channels.ReactionAdded,
+		RedisSlackLinerList:                  cf.Lists.SlackLinerMessages,
+		RedisEnvelope:                        cf.Lists.Envelope,
+		SessionCodec:                         cf.Codecs.Sessions,
+		PoppitCodec:                          cf.Codecs.Poppit,
+		SlackLinerCodec:                      cf.Codecs.SlackLiner,
+		SlackBotToken:                        slackBotToken,
+		SlackChannelID:                       cf.Slack.ChannelID,
+		SlackAdminUserIDs:                    cf.Slack.AdminUsers,
+		HTTPAddr:                             cf.HTTP.Addr,
+		HealthAddr:                           cf.Health.Addr,
+		PprofAddr:                            cf.Debug.PprofAddr,
+		SlackTransport:                       cf.Slack.Transport,
+		SlackMaxAttempts:                     cf.Slack.MaxAttempts,
+		SlackMessageTTL:                      cf.Slack.MessageTTL,
+		SlackChannelRoutes:                   cf.Slack.ChannelRoutes,
+		PRListLimit:                          cf.Limits.PRList,
+		SessionTTL:                           cf.Limits.SessionTTL,
+		PoppitTimeout:                        cf.Poppit.Timeout,
+		PrivilegedUserIDs:                    cf.Roles.PrivilegedUsers,
+		PrivilegedUsergroupIDs:               cf.Roles.PrivilegedUsergroups,
+		RestrictedCommands:                   cf.Roles.RestrictedCommands,
+		SlackMessageTemplate:                 cf.Slack.MessageTemplate,
+		SlackThreadDetails:                   cf.Slack.ThreadDetails,
+		SlackDescriptionSnippet:              cf.Slack.DescriptionSnippet,
+		SlackDescriptionLength:               cf.Slack.DescriptionLength,
+		SlackHeaderEmoji:                     cf.Slack.Branding.Emoji,
+		SlackUsername:                        cf.Slack.Branding.Username,
+		SlackIconEmoji:                       cf.Slack.Branding.IconEmoji,
+		SlackIconURL:                         cf.Slack.Branding.IconURL,
+		SlackPreviewPosts:                    cf.Slack.PreviewPosts,
+		GitHubOrg:                            cf.GitHub.Org,
+		GitHubOrgs:                           cf.GitHub.Orgs,
+		GitHubBaseBranch:                     cf.GitHub.BaseBranch,
+		GitHubHideDrafts:                     cf.GitHub.HideDrafts,
+		GitHubSlackUsers:                     cf.GitHub.SlackUsers,
+		GitHubReviewerPools:                  cf.GitHub.ReviewerPools,
+		GitHubMode:                           cf.GitHub.Mode,
+		GitHubMergeMethod:                    cf.GitHub.MergeMethod,
+		GitHubAPIURL:                         cf.GitHub.APIURL,
+		GitHubAppID:                          cf.GitHub.App.ID,
+		GitHubAppInstallationID:              cf.GitHub.App.InstallationID,
+		LogLevel:                             cf.Logging.Level,
+		LogFormat:                            cf.Logging.Format,
+		DryRun:                               cf.DryRun,
+		ReminderInterval:                     cf.Reminders.Interval,
+		ReminderTimezone:                     timezone,
+		ReminderChannels:                     cf.Reminders.Channels,
+		AuditRetention:                       cf.Audit.Retention,
+		TracingEndpoint:                      cf.Tracing.OTLPEndpoint,
+		TracingServiceName:                   cf.Tracing.ServiceName,
+		TracingSampleRatio:                   cf.Tracing.SampleRatio,
+		DigestTimezone:                       digestTimezone,
+		DigestSchedules:                      digests,
+		LeaderboardChannel:                   cf.Leaderboard.Channel,
+		LeaderboardSchedule:                  leaderboardSchedule,
+		LeaderboardTimezone:                  leaderboardTimezone,
+		Features:                             cf.Features,
+	}
+}
+
+// Orgs returns the organisations offered in the repo chooser: github.orgs
+// when set, otherwise just github.org.
+func (c Config) Orgs() []string {
+	if len(c.GitHubOrgs) > 0 {
+		return c.GitHubOrgs
+	}
+	if c.GitHubOrg != "" {
+		return []string{c.GitHubOrg}
+	}
+	return nil
+}
+
+// HasOrg reports whether org is one of the configured organisations.
+func (c Config) HasOrg(org string) bool {
+	for _, o := range c.Orgs() {
+		if o == org {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConsumer checks the redis.consumer value and, for streams, the
+// consumer group and name.
+func validateConsumer(cf configFile) error {
+	switch cf.Redis.Consumer {
+	case ConsumerPubSub:
+		return nil
+	case ConsumerStreams:
+		if cf.Redis.ConsumerGroup == "" || cf.Redis.ConsumerName == "" {
+			return fmt.Errorf("consumer_group and consumer_name must be set for streams")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown consumer %q (want %q or %q)", cf.Redis.Consumer, ConsumerPubSub, ConsumerStreams)
+}
+
+// validateTransport checks the slack.transport value.
+func validateTransport(transport string) error {
+	switch transport {
+	case TransportRelay, TransportSocketMode:
+		return nil
+	}
+	return fmt.Errorf("unknown transport %q (want %q or %q)", transport, TransportRelay, TransportSocketMode)
+}
+
+// slackEmojiPattern matches an emoji shortcode such as :rocket:.
+var slackEmojiPattern = regexp.MustCompile(`^:[a-z0-9_+'-]+:$`)
+
+// validateBranding checks the bot icon: an emoji shortcode or an http(s)
+// URL, but not both.
+func validateBranding(cf configFile) error {
+	b := cf.Slack.Branding
+	if b.IconEmoji != "" && b.IconURL != "" {
+		return fmt.Errorf("set icon_emoji or icon_url, not both")
+	}
+	if b.IconEmoji != "" && !slackEmojiPattern.MatchString(b.IconEmoji) {
+		return fmt.Errorf("icon_emoji %q is not an emoji shortcode like :rocket:", b.IconEmoji)
+	}
+	if b.IconURL != "" {
+		if u, err := url.Parse(b.IconURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("icon_url %q is not an http(s) URL", b.IconURL)
+		}
+	}
+	return nil
+}
+
+// validateCodecs checks each codec in the codecs section is known.
+func validateCodecs(cf configFile) error {
+	for _, c := range []struct{ key, name string }{
+		{"sessions", cf.Codecs.Sessions},
+		{"poppit", cf.Codecs.Poppit},
+		{"slackliner", cf.Codecs.SlackLiner},
+	} {
+		if err := codec.Validate(c.name); err != nil {
+			return fmt.Errorf("%s: %w", c.key, err)
+		}
+	}
+	return nil
+}
+
+// ParseGitHubAppKey accepts the PKCS#1 keys GitHub issues as well as PKCS#8.
+func ParseGitHubAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in GitHub App private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// UsesGitHubAPI reports whether PRs are fetched from the GitHub REST API
+// rather than through Poppit, because of github.mode or the github_api
+// feature flag.
+func (c Config) UsesGitHubAPI() bool {
+	return c.GitHubMode == GitHubModeAPI || c.FeatureEnabled(FeatureGitHubAPI)
+}
+
+// PoppitFormat is how Poppit commands are written to the Poppit list.
+func (c Config) PoppitFormat() envelope.Format {
+	return envelope.Format{Wrap: c.RedisEnvelope, Codec: codec.Named(c.PoppitCodec)}
+}
+
+// SlackLinerFormat is how messages are written to the SlackLiner list.
+func (c Config) SlackLinerFormat() envelope.Format {
+	return envelope.Format{Wrap: c.RedisEnvelope, Codec: codec.Named(c.SlackLinerCodec)}
+}
+
+// UsesStreams reports whether the feeds are consumed from Redis streams
+// rather than pub/sub channels.
+func (c Config) UsesStreams() bool {
+	return c.RedisConsumer == ConsumerStreams
+}
+
+// UsesSocketMode reports whether Slack requests arrive over Socket Mode.
+func (c Config) UsesSocketMode() bool {
+	return c.SlackTransport == TransportSocketMode
+}
+
+// UsesGitHubApp reports whether GitHub App credentials are configured.
+func (c Config) UsesGitHubApp() bool {
+	return c.GitHubAppID != 0 && c.GitHubAppInstallationID != 0 && c.GitHubAppPrivateKey != ""
+}
+
+// HasGitHubAuth reports whether any GitHub credential is available.
+func (c Config) HasGitHubAuth() bool {
+	return c.UsesGitHubApp() || c.GitHubToken != ""
+}
+
+// MessageTTL returns slack.message_ttl in the whole seconds SlackLiner
+// expects.
+func (c Config) MessageTTL() int {
+	return int(c.SlackMessageTTL / time.Second)
+}
+
+// ChannelRoute returns the channel slack.channel_routes sends repo's PRs to,
+// or "" when no route matches. An exact match wins over globs, and a longer
+// glob over a shorter one; patterns without a slash match the repo name.
+func (c Config) ChannelRoute(repo string) string {
+	if channel, ok := c.SlackChannelRoutes[repo]; ok {
+		return channel
+	}
+	name := repo[strings.LastIndex(repo, "/")+1:]
+	best := ""
+	for pattern := range c.SlackChannelRoutes {
+		subject := repo
+		if !strings.Contains(pattern, "/") {
+			subject = name
+		}
+		if ok, _ := path.Match(pattern, subject); !ok {
+			continue
+		}
+		if best == "" || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return c.SlackChannelRoutes[best]
+}
+
+// Threshold returns the wait before each reminder, defaulting to a day.
+func (rc ReminderChannelConfig) Threshold() time.Duration {
+	if rc.ThresholdHours > 0 {
+		return time.Duration(rc.ThresholdHours) * time.Hour
+	}
+	return defaultReminderThreshold
+}
+
+// maxReminders returns how many reminders a post may receive.
+func (rc ReminderChannelConfig) ReminderLimit() int {
+	if rc.MaxReminders > 0 {
+		return rc.MaxReminders
+	}
+	return defaultMaxReminders
+}
+
+// ParseQuietHours parses "HH:MM-HH:MM" into minutes after midnight. An empty
+// string means no quiet hours and yields start == end.
+func ParseQuietHours(s string) (start, end int, err error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("quiet_hours %q: want HH:MM-HH:MM", s)
+	}
+	for i, part := range []string{from, to} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return 0, 0, fmt.Errorf("quiet_hours %q: want HH:MM-HH:MM", s)
+		}
+		if i == 0 {
+			start = t.Hour()*60 + t.Minute()
+		} else {
+			end = t.Hour()*60 + t.Minute()
+		}
+	}
+	return start, end, nil
+}
@@ -0,0 +1,823 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// ---- Config tests ----
+
+func TestGetEnvDefault(t *testing.T) {
+	got := getEnv("SLASHVIBEPRSTEST_NONEXISTENT_KEY_XYZ", "default-value")
+	if got != "default-value" {
+		t.Errorf("expected 'default-value', got %q", got)
+	}
+}
+
+func TestLoadConfigFromBytesDefaults(t *testing.T) {
+	// Empty YAML — should fall back to built-in defaults.
+	config, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.RedisChannel != "slack-commands" {
+		t.Errorf("unexpected RedisChannel: %q", config.RedisChannel)
+	}
+	if config.RedisPoppitList != "poppit:commands" {
+		t.Errorf("unexpected RedisPoppitList: %q", config.RedisPoppitList)
+	}
+	if config.RedisPoppitOutputChannel != "poppit:command-output" {
+		t.Errorf("unexpected RedisPoppitOutputChannel: %q", config.RedisPoppitOutputChannel)
+	}
+	if config.RedisBlockActionsChannel != "slack-relay-block-actions" {
+		t.Errorf("unexpected RedisBlockActionsChannel: %q", config.RedisBlockActionsChannel)
+	}
+	if config.LogLevel != "INFO" {
+		t.Errorf("unexpected LogLevel: %q", config.LogLevel)
+	}
+}
+
+func TestLoadConfigFromBytesFullYAML(t *testing.T) {
+	yamlData := []byte(`
+redis:
+  addr: myredis:6380
+channels:
+  slash_commands: my-commands
+  view_submissions: my-view-submissions
+  block_actions: my-block-actions
+  poppit_output: my-poppit-output
+lists:
+  poppit_commands: my-poppit-commands
+  slackliner_messages: my-slack-messages
+slack:
+  channel_id: CMYCHANNEL
+github:
+  org: my-org
+logging:
+  level: DEBUG
+`)
+
+	config, err := Parse(yamlData, "secret-pw", "xoxb-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.RedisAddr != "myredis:6380" {
+		t.Errorf("unexpected RedisAddr: %q", config.RedisAddr)
+	}
+	if config.RedisPassword != "secret-pw" {
+		t.Errorf("unexpected RedisPassword: %q", config.RedisPassword)
+	}
+	if config.SlackBotToken != "xoxb-token" {
+		t.Errorf("unexpected SlackBotToken: %q", config.SlackBotToken)
+	}
+	if config.RedisChannel != "my-commands" {
+		t.Errorf("unexpected RedisChannel: %q", config.RedisChannel)
+	}
+	if config.RedisBlockActionsChannel != "my-block-actions" {
+		t.Errorf("unexpected RedisBlockActionsChannel: %q", config.RedisBlockActionsChannel)
+	}
+	if config.SlackChannelID != "CMYCHANNEL" {
+		t.Errorf("unexpected SlackChannelID: %q", config.SlackChannelID)
+	}
+	if config.GitHubOrg != "my-org" {
+		t.Errorf("unexpected GitHubOrg: %q", config.GitHubOrg)
+	}
+	if config.LogLevel != "DEBUG" {
+		t.Errorf("unexpected LogLevel: %q", config.LogLevel)
+	}
+}
+
+func TestLoadConfigFromBytesPartialYAML(t *testing.T) {
+	// Only override a subset — other values should keep built-in defaults.
+	yamlData := []byte(`
+slack:
+  channel_id: CPARTIAL
+`)
+
+	config, err := Parse(yamlData, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.SlackChannelID != "CPARTIAL" {
+		t.Errorf("unexpected SlackChannelID: %q", config.SlackChannelID)
+	}
+	// Unset values keep defaults.
+	if config.RedisAddr != "host.docker.internal:6379" {
+		t.Errorf("unexpected RedisAddr: %q", config.RedisAddr)
+	}
+	if config.RedisChannel != "slack-commands" {
+		t.Errorf("unexpected RedisChannel: %q", config.RedisChannel)
+	}
+}
+
+func TestLoadConfigFromBytesInvalidYAML(t *testing.T) {
+	_, err := Parse([]byte("not: valid: yaml: ["), "", "")
+	if err == nil {
+		t.Error("expected error for invalid YAML, got nil")
+	}
+}
+
+// ---- Base-branch filtering tests ----
+
+func TestLoadConfigFromBytesPreviewPosts(t *testing.T) {
+	config, err := Parse([]byte("slack:\n  channel_id: C1\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.SlackPreviewPosts {
+		t.Error("expected SlackPreviewPosts to default to true")
+	}
+	config, err = Parse([]byte("slack:\n  preview_posts: false\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.SlackPreviewPosts {
+		t.Error("expected SlackPreviewPosts to be disabled")
+	}
+}
+
+func TestLoadConfigFromBytesHideDrafts(t *testing.T) {
+	config, err := Parse([]byte("github:\n  hide_drafts: true\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.GitHubHideDrafts {
+		t.Error("expected GitHubHideDrafts to be set")
+	}
+}
+
+func TestLoadConfigFromBytesBaseBranch(t *testing.T) {
+	config, err := Parse([]byte("github:\n  base_branch: main\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.GitHubBaseBranch != "main" {
+		t.Errorf("unexpected GitHubBaseBranch: %q", config.GitHubBaseBranch)
+	}
+}
+
+// ---- Message template tests ----
+
+func TestLoadConfigFromBytesMessageTemplate(t *testing.T) {
+	config, err := Parse([]byte("slack:\n  message_template: \"{{.Repo}}\"\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.SlackMessageTemplate != "{{.Repo}}" {
+		t.Errorf("unexpected SlackMessageTemplate: %q", config.SlackMessageTemplate)
+	}
+}
+
+// ---- Thread follow-up tests ----
+
+func TestLoadConfigFromBytesThreadDetailsDefault(t *testing.T) {
+	config, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.SlackThreadDetails {
+		t.Error("expected thread details to be enabled by default")
+	}
+}
+
+// ---- GitHub API mode tests ----
+
+func TestLoadConfigRejectsUnknownGitHubMode(t *testing.T) {
+	if _, err := Parse([]byte("github:\n  mode: carrier-pigeon\n"), "", ""); err == nil {
+		t.Error("expected error for unknown github.mode")
+	}
+}
+
+func TestLoadConfigMergeMethod(t *testing.T) {
+	cfg, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GitHubMergeMethod != MergeMethodMerge {
+		t.Errorf("expected default merge method %q, got %q", MergeMethodMerge, cfg.GitHubMergeMethod)
+	}
+	cfg, err = Parse([]byte("github:\n  merge_method: squash\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GitHubMergeMethod != MergeMethodSquash {
+		t.Errorf("expected merge method %q, got %q", MergeMethodSquash, cfg.GitHubMergeMethod)
+	}
+	if _, err := Parse([]byte("github:\n  merge_method: octopus\n"), "", ""); err == nil {
+		t.Error("expected error for unknown github.merge_method")
+	}
+}
+
+// ---- GitHub App authentication tests ----
+
+func TestParseGitHubAppKeyRejectsGarbage(t *testing.T) {
+	if _, err := ParseGitHubAppKey([]byte("not a key")); err == nil {
+		t.Error("expected error for non-PEM key")
+	}
+}
+
+// ---- Multi-org tests ----
+
+func TestLoadConfigOrgsDefaultsOrgToFirst(t *testing.T) {
+	cfg, err := Parse([]byte("github:\n  orgs: [acme, widgets-inc]\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GitHubOrg != "acme" {
+		t.Errorf("expected default org acme, got %q", cfg.GitHubOrg)
+	}
+	if got := cfg.Orgs(); len(got) != 2 {
+		t.Errorf("expected 2 orgs, got %v", got)
+	}
+}
+
+// ---- Reviewer roulette tests ----
+
+func TestLoadConfigFromBytesReviewerPools(t *testing.T) {
+	cfg, err := Parse([]byte("github:\n  reviewer_pools:\n    acme/api: [alice, bob]\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool := cfg.GitHubReviewerPools["acme/api"]; len(pool) != 2 || pool[1] != "bob" {
+		t.Errorf("expected [alice bob], got %v", pool)
+	}
+}
+
+// ---- Link unfurl tests ----
+
+func TestLoadConfigFromBytesLinkSharedDefault(t *testing.T) {
+	cfg, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RedisLinkSharedChannel != "slack-relay-link-shared" {
+		t.Errorf("unexpected link_shared channel: %q", cfg.RedisLinkSharedChannel)
+	}
+	if cfg.RedisReactionAddedChannel != "slack-relay-reaction-added" {
+		t.Errorf("unexpected reaction_added channel: %q", cfg.RedisReactionAddedChannel)
+	}
+}
+
+// ---- Stale PR reminder tests ----
+
+func TestLoadConfigFromBytesReminders(t *testing.T) {
+	cfg, err := Parse([]byte(`
+reminders:
+  interval: 5m
+  timezone: Europe/London
+  channels:
+    C1: {threshold_hours: 4, quiet_hours: "20:00-08:00", max_reminders: 2}
+`), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ReminderInterval != 5*time.Minute || cfg.ReminderTimezone.String() != "Europe/London" {
+		t.Errorf("unexpected interval/timezone: %s %s", cfg.ReminderInterval, cfg.ReminderTimezone)
+	}
+	if rc := cfg.ReminderChannels["C1"]; rc.Threshold() != 4*time.Hour || rc.ReminderLimit() != 2 {
+		t.Errorf("unexpected channel config: %+v", rc)
+	}
+
+	defaults, _ := Parse([]byte(""), "", "")
+	if defaults.ReminderInterval != 15*time.Minute || defaults.ReminderTimezone != time.UTC {
+		t.Errorf("unexpected defaults: %s %s", defaults.ReminderInterval, defaults.ReminderTimezone)
+	}
+}
+
+func TestLoadConfigFromBytesRejectsBadReminders(t *testing.T) {
+	for _, yaml := range []string{
+		"reminders:\n  timezone: Mars/Olympus\n",
+		"reminders:\n  interval: 0s\n",
+		"reminders:\n  channels:\n    C1: {quiet_hours: \"late\"}\n",
+	} {
+		if _, err := Parse([]byte(yaml), "", ""); err == nil {
+			t.Errorf("expected error for %q", yaml)
+		}
+	}
+}
+
+// ---- Digest tests ----
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestCronScheduleMatches(t *testing.T) {
+	// 2026-01-05 is a Monday.
+	at := func(day, h, m int) time.Time { return time.Date(2026, 1, day, h, m, 0, 0, time.UTC) }
+	cases := []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"0 9 * * 1-5", at(5, 9, 0), true},
+		{"0 9 * * 1-5", at(5, 9, 1), false},
+		{"0 9 * * 1-5", at(10, 9, 0), false}, // Saturday
+		{"*/15 * * * *", at(5, 13, 45), true},
+		{"*/15 * * * *", at(5, 13, 50), false},
+		{"0 9 * * 0", at(11, 9, 0), true}, // Sunday
+		{"0 9 * * 7", at(11, 9, 0), true},
+		{"30 8 1 * *", at(1, 8, 30), true},
+		// Both day fields restricted: either may match.
+		{"0 9 15 * 1", at(5, 9, 0), true},
+		{"0 9 15 * 1", at(6, 9, 0), false},
+		{"0 9,17 * 1 *", at(5, 17, 0), true},
+	}
+	for _, c := range cases {
+		s, err := parseCron(c.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", c.expr, err)
+		}
+		if got := s.Matches(c.t); got != c.want {
+			t.Errorf("%q at %s = %v, want %v", c.expr, c.t.Format("Mon 02 15:04"), got, c.want)
+		}
+	}
+}
+
+func TestLoadConfigFromBytesDigests(t *testing.T) {
+	cfg, err := Parse([]byte(`
+digests:
+  timezone: America/New_York
+  schedules:
+    - channel: C1
+      cron: "0 9 * * 1-5"
+      repos: [acme/api, acme/web]
+`), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.DigestSchedules) != 1 || cfg.DigestTimezone.String() != "America/New_York" {
+		t.Fatalf("unexpected digests: %+v (%s)", cfg.DigestSchedules, cfg.DigestTimezone)
+	}
+	if !cfg.DigestSchedules[0].Schedule.Matches(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Error("expected parsed schedule to match Monday 09:00")
+	}
+
+	for _, bad := range []string{
+		"digests:\n  schedules:\n    - {channel: C1, cron: \"nope\", repos: [acme/api]}\n",
+		"digests:\n  schedules:\n    - {channel: C1, cron: \"0 9 * * *\", repos: [api]}\n",
+		"digests:\n  schedules:\n    - {cron: \"0 9 * * *\", repos: [acme/api]}\n",
+	} {
+		if _, err := Parse([]byte(bad), "", ""); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoadConfigFromBytesLeaderboard(t *testing.T) {
+	cfg, err := Parse(nil, "", "")
+	if err != nil || cfg.LeaderboardChannel != "" || !cfg.LeaderboardSchedule.Matches(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a disabled Monday 09:00 leaderboard by default, got %q (%v)", cfg.LeaderboardChannel, err)
+	}
+
+	cfg, err = Parse([]byte("leaderboard:\n  channel: C1\n  cron: \"30 16 * * 5\"\n  timezone: Europe/London\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LeaderboardChannel != "C1" || cfg.LeaderboardTimezone.String() != "Europe/London" ||
+		!cfg.LeaderboardSchedule.Matches(time.Date(2026, 1, 9, 16, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected leaderboard: %q %s", cfg.LeaderboardChannel, cfg.LeaderboardTimezone)
+	}
+
+	for _, bad := range []string{
+		"leaderboard:\n  channel: C1\n  cron: nope\n",
+		"leaderboard:\n  timezone: Mars/Olympus\n",
+	} {
+		if _, err := Parse([]byte(bad), "", ""); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// ---- audit tests ----
+
+func TestLoadConfigFromBytesAuditRetention(t *testing.T) {
+	cfg, err := Parse([]byte("audit:\n  retention: 48h\n"), "", "")
+	if err != nil || cfg.AuditRetention != 48*time.Hour {
+		t.Errorf("expected 48h retention, got %v, %v", cfg.AuditRetention, err)
+	}
+	if _, err := Parse([]byte("audit:\n  retention: 0s\n"), "", ""); err == nil {
+		t.Error("expected error for zero retention")
+	}
+}
+
+// ---- role tests ----
+
+func TestLoadConfigFromBytesRoles(t *testing.T) {
+	cfg, err := Parse([]byte("roles:\n  privileged_users: [U1]\n"), "", "")
+	if err != nil || !reflect.DeepEqual(cfg.RestrictedCommands, []string{"unpost"}) || !reflect.DeepEqual(cfg.PrivilegedUserIDs, []string{"U1"}) {
+		t.Errorf("unexpected roles: %+v, %v", cfg, err)
+	}
+	if _, err := Parse([]byte("roles:\n  restricted_commands: [merge]\n"), "", ""); err == nil {
+		t.Error("expected error for unknown restricted command")
+	}
+}
+
+// ---- Socket Mode tests ----
+
+func TestLoadConfigFromBytesTransport(t *testing.T) {
+	cfg, err := Parse([]byte("slack:\n  channel_id: C1\n"), "", "")
+	if err != nil || cfg.SlackTransport != TransportRelay {
+		t.Errorf("expected relay transport by default, got %q, %v", cfg.SlackTransport, err)
+	}
+	cfg, err = Parse([]byte("slack:\n  transport: socket_mode\n"), "", "")
+	if err != nil || cfg.SlackTransport != TransportSocketMode {
+		t.Errorf("expected socket_mode transport, got %q, %v", cfg.SlackTransport, err)
+	}
+	if _, err := Parse([]byte("slack:\n  transport: carrier_pigeon\n"), "", ""); err == nil {
+		t.Error("expected error for unknown transport")
+	}
+}
+
+func TestLoadConfigFromBytesCodecs(t *testing.T) {
+	cfg, err := Parse([]byte("slack:\n  channel_id: C1\n"), "", "")
+	if err != nil || cfg.SessionCodec != "json" || cfg.PoppitFormat().Codec != codec.JSON || cfg.SlackLinerFormat().Wrap {
+		t.Errorf("expected plain JSON by default, got %q %+v, %v", cfg.SessionCodec, cfg.PoppitFormat(), err)
+	}
+	cfg, err = Parse([]byte("codecs:\n  sessions: msgpack\n  slackliner: msgpack\nlists:\n  envelope: true\n"), "", "")
+	if err != nil || cfg.SessionCodec != "msgpack" || cfg.PoppitFormat().Codec != codec.JSON ||
+		cfg.SlackLinerFormat() != (envelope.Format{Wrap: true, Codec: codec.MessagePack}) {
+		t.Errorf("unexpected codecs: %q %+v %+v, %v", cfg.SessionCodec, cfg.PoppitFormat(), cfg.SlackLinerFormat(), err)
+	}
+	if _, err := Parse([]byte("codecs:\n  poppit: protobuf\n"), "", ""); err == nil || !strings.Contains(err.Error(), "poppit") {
+		t.Errorf("expected error for unknown codec, got %v", err)
+	}
+}
+
+// ---- stream consumer tests ----
+
+func TestLoadConfigFromBytesConsumer(t *testing.T) {
+	cfg, err := Parse([]byte("redis:\n  consumer: streams\n  consumer_name: replica-1\n"), "", "")
+	if err != nil || cfg.RedisConsumer != ConsumerStreams || cfg.RedisConsumerGroup != "slashvibepr" || cfg.RedisConsumerName != "replica-1" {
+		t.Errorf("unexpected consumer config: %q %q %q, %v", cfg.RedisConsumer, cfg.RedisConsumerGroup, cfg.RedisConsumerName, err)
+	}
+	if _, err := Parse([]byte("redis:\n  consumer: kafka\n"), "", ""); err == nil {
+		t.Error("expected error for unknown consumer")
+	}
+	if _, err := Parse([]byte("redis:\n  consumer: streams\n  consumer_group: \"\"\n"), "", ""); err == nil {
+		t.Error("expected error for empty consumer group")
+	}
+}
+
+// ---- Slack retry tests ----
+
+func TestLoadConfigFromBytesSlackMaxAttempts(t *testing.T) {
+	config, err := Parse([]byte("slack:\n  max_attempts: 5\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.SlackMaxAttempts != 5 {
+		t.Errorf("expected 5 attempts, got %d", config.SlackMaxAttempts)
+	}
+	if _, err := Parse([]byte("slack:\n  max_attempts: 0\n"), "", ""); err == nil {
+		t.Error("expected max_attempts of 0 to be rejected")
+	}
+}
+
+// ---- loading watchdog tests ----
+
+func TestLoadConfigFromBytesPoppitTimeout(t *testing.T) {
+	config, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.PoppitTimeout != 30*time.Second {
+		t.Errorf("expected a 30s default, got %s", config.PoppitTimeout)
+	}
+	if _, err := Parse([]byte("poppit:\n  timeout: -1s\n"), "", ""); err == nil {
+		t.Error("expected a negative timeout to be rejected")
+	}
+}
+
+// ---- structured logging tests ----
+
+func TestLoadConfigFromBytesLogFormat(t *testing.T) {
+	config, err := Parse([]byte("logging:\n  format: json\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.LogFormat != logging.LogFormatJSON {
+		t.Errorf("expected json, got %q", config.LogFormat)
+	}
+	if _, err := Parse([]byte("logging:\n  format: xml\n"), "", ""); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+// ---- tracing tests ----
+
+func TestLoadConfigFromBytesTracing(t *testing.T) {
+	config, err := Parse([]byte("tracing:\n  otlp_endpoint: http://collector:4318/v1/traces\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.TracingEndpoint != "http://collector:4318/v1/traces" || config.TracingServiceName != "slashvibepr" || config.TracingSampleRatio != 1 {
+		t.Errorf("unexpected tracing config: %q %q %v", config.TracingEndpoint, config.TracingServiceName, config.TracingSampleRatio)
+	}
+	if _, err := Parse([]byte("tracing:\n  sample_ratio: 2\n"), "", ""); err == nil {
+		t.Error("expected a sample ratio above 1 to be rejected")
+	}
+}
+
+// ---- pprof tests ----
+
+func TestLoadConfigFromBytesPprofAddr(t *testing.T) {
+	config, err := Parse([]byte("debug:\n  pprof_addr: 127.0.0.1:6060\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.PprofAddr != "127.0.0.1:6060" {
+		t.Errorf("unexpected pprof address %q", config.PprofAddr)
+	}
+}
+
+// ---- Configurable limits tests ----
+
+func TestLoadConfigFromBytesLimits(t *testing.T) {
+	config, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.PRListLimit != 50 || config.SessionTTL != 30*time.Minute || config.MessageTTL() != 86400 {
+		t.Errorf("unexpected defaults: pr_list %d, session_ttl %s, message ttl %d", config.PRListLimit, config.SessionTTL, config.MessageTTL())
+	}
+
+	config, err = Parse([]byte("limits:\n  pr_list: 200\n  session_ttl: 2h\nslack:\n  message_ttl: 1h\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.PRListLimit != 200 || config.SessionTTL != 2*time.Hour || config.MessageTTL() != 3600 {
+		t.Errorf("unexpected limits: %+v", config)
+	}
+
+	for _, bad := range []string{
+		"limits:\n  pr_list: 0\n",
+		"limits:\n  pr_list: 1001\n",
+		"limits:\n  session_ttl: 0s\n",
+		"slack:\n  message_ttl: 500ms\n",
+	} {
+		if _, err := Parse([]byte(bad), "", ""); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+// ---- Secret file tests ----
+
+func TestGetSecret(t *testing.T) {
+	t.Setenv("SLASHVIBEPR_TEST_SECRET", "from-env")
+	if got, err := getSecret("SLASHVIBEPR_TEST_SECRET"); err != nil || got != "from-env" {
+		t.Errorf("expected the env value, got %q, %v", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("xoxb-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SLASHVIBEPR_TEST_SECRET_FILE", path)
+	if _, err := getSecret("SLASHVIBEPR_TEST_SECRET"); err == nil {
+		t.Error("expected setting both the variable and its _FILE to be rejected")
+	}
+
+	t.Setenv("SLASHVIBEPR_TEST_SECRET", "")
+	if got, err := getSecret("SLASHVIBEPR_TEST_SECRET"); err != nil || got != "xoxb-from-file" {
+		t.Errorf("expected the trimmed file contents, got %q, %v", got, err)
+	}
+
+	t.Setenv("SLASHVIBEPR_TEST_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := getSecret("SLASHVIBEPR_TEST_SECRET"); err == nil {
+		t.Error("expected a missing secret file to be an error")
+	}
+}
+
+// ---- Vault secrets tests ----
+
+func TestValidateSecretsProvider(t *testing.T) {
+	cf := defaultConfigFile()
+	if err := validateSecretsProvider(cf); err != nil {
+		t.Errorf("expected the env default to be valid, got %v", err)
+	}
+	cf.Secrets.Provider = secretsProviderVault
+	if err := validateSecretsProvider(cf); err == nil {
+		t.Error("expected vault without an address and path to be rejected")
+	}
+	cf.Secrets.Vault.Address, cf.Secrets.Vault.Path = "https://vault:8200", "secret/data/slashvibepr"
+	if err := validateSecretsProvider(cf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	cf.Secrets.Provider = "aws"
+	if err := validateSecretsProvider(cf); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+}
+
+func TestVaultSecretsKubernetesLoginAndRenewal(t *testing.T) {
+	var calls []string
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role"] != "slashvibepr" || body["jwt"] != "sa-jwt" {
+				t.Errorf("unexpected login body: %v", body)
+			}
+			fmt.Fprint(w, `{"auth":{"client_token":"s.login","lease_duration":3600,"renewable":true}}`)
+		case "/v1/secret/data/slashvibepr":
+			if r.Header.Get("X-Vault-Token") != "s.login" {
+				t.Errorf("unexpected token %q", r.Header.Get("X-Vault-Token"))
+			}
+			fmt.Fprint(w, `{"data":{"data":{"slack_bot_token":"xoxb-vault","redis_password":"hunter2"}}}`)
+		case "/v1/auth/token/renew-self":
+			fmt.Fprint(w, `{"auth":{"client_token":"s.login","lease_duration":7200,"renewable":true}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer vault.Close()
+
+	jwtPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(jwtPath, []byte("sa-jwt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "ghp-env")
+
+	cf := defaultConfigFile()
+	cf.Secrets.Provider = secretsProviderVault
+	cf.Secrets.Vault.Address, cf.Secrets.Vault.Role, cf.Secrets.Vault.Path = vault.URL, "slashvibepr", "secret/data/slashvibepr"
+	v := &vaultSecrets{address: vault.URL, role: "slashvibepr", authPath: cf.Secrets.Vault.AuthPath,
+		path: "secret/data/slashvibepr", jwtPath: jwtPath, client: vault.Client()}
+	if err := v.login(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.read(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"SLACK_BOT_TOKEN": "xoxb-vault", "REDIS_PASSWORD": "hunter2", "GITHUB_TOKEN": "ghp-env"} {
+		if got, err := v.Secret(key); err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", key, got, err, want)
+		}
+	}
+	if got := v.renewInterval(); got != 30*time.Minute {
+		t.Errorf("expected renewal at half the token TTL, got %s", got)
+	}
+	if err := v.renew(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.renewInterval(); got != time.Hour {
+		t.Errorf("expected the renewed TTL to be used, got %s", got)
+	}
+	if calls[len(calls)-1] != "POST /v1/auth/token/renew-self" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+func TestVaultSecretsRequiresRoleWithoutToken(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	v := &vaultSecrets{address: "http://127.0.0.1:1", client: http.DefaultClient}
+	if err := v.login(context.Background()); err == nil || !strings.Contains(err.Error(), "role") {
+		t.Errorf("expected a missing role error, got %v", err)
+	}
+}
+
+func TestReloadKeepsStartupSettings(t *testing.T) {
+	current, err := Parse([]byte("redis:\n  addr: redis:6379\ngithub:\n  org: acme\nslack:\n  channel_id: C123\n"), "pw", "xoxb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reload(current, []byte("redis:\n  addr: elsewhere:6379\ngithub:\n  org: widgets\n  hide_drafts: true\nslack:\n  channel_id: C456\nlimits:\n  pr_list: 80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GitHubOrg != "widgets" || !got.GitHubHideDrafts || got.SlackChannelID != "C456" || got.PRListLimit != 80 {
+		t.Errorf("expected the per-interaction settings to be reloaded, got %+v", got)
+	}
+	if got.RedisAddr != "redis:6379" || got.RedisPassword != "pw" || got.SlackBotToken != "xoxb" {
+		t.Errorf("expected startup settings and secrets to be kept, got addr=%q", got.RedisAddr)
+	}
+
+	if _, err := reload(current, []byte("limits:\n  pr_list: 0\n")); err == nil {
+		t.Error("expected an invalid file to be rejected")
+	}
+}
+
+func TestLoadConfigFromBytesFeatures(t *testing.T) {
+	cfg, err := Parse([]byte("features:\n  block_kit_posts:\n    workspaces: [T1]\n    channels: [C1]\n  github_api:\n    enabled: true\n"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flag := cfg.Feature(FeatureBlockKitPosts)
+	if cfg.FeatureEnabled(FeatureBlockKitPosts) || !flag.EnabledFor("T1", "") || !flag.EnabledFor("T2", "C1") || flag.EnabledFor("T2", "C2") {
+		t.Errorf("unexpected block_kit_posts scoping: %+v", flag)
+	}
+	if !cfg.UsesGitHubAPI() {
+		t.Error("expected the github_api feature to select the API")
+	}
+	if !cfg.FeatureEnabled(FeatureMultiSelect) {
+		t.Error("expected multi_select to default to on")
+	}
+
+	for _, bad := range []string{
+		"features:\n  teleport:\n    enabled: true\n",
+		"features:\n  multi_select:\n    workspaces: [acme]\n",
+		"features:\n  multi_select:\n    channels: [general]\n",
+	} {
+		if _, err := Parse([]byte(bad), "", ""); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestLoadConfigFromBytesBranding(t *testing.T) {
+	cfg, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SlackHeaderEmoji != DefaultHeaderEmoji || cfg.SlackUsername != "" {
+		t.Errorf("unexpected default branding: %q %q", cfg.SlackHeaderEmoji, cfg.SlackUsername)
+	}
+	cfg, err = Parse([]byte("slack:\n  branding:\n    emoji: \":rocket:\"\n    username: PR Bot\n    icon_url: https://example.com/bot.png\n"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SlackHeaderEmoji != ":rocket:" || cfg.SlackUsername != "PR Bot" || cfg.SlackIconURL != "https://example.com/bot.png" {
+		t.Errorf("unexpected branding: %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		"slack:\n  branding:\n    icon_emoji: rocket\n",
+		"slack:\n  branding:\n    icon_url: ftp://example.com/bot.png\n",
+		"slack:\n  branding:\n    icon_emoji: \":rocket:\"\n    icon_url: https://example.com/bot.png\n",
+	} {
+		if _, err := Parse([]byte(bad), "", ""); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestLoadConfigFromBytesDescriptionSnippet(t *testing.T) {
+	cfg, err := Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SlackDescriptionSnippet || cfg.SlackDescriptionLength != DefaultDescriptionLength {
+		t.Errorf("unexpected defaults: %v %d", cfg.SlackDescriptionSnippet, cfg.SlackDescriptionLength)
+	}
+	cfg, err = Parse([]byte("slack:\n  description_snippet: true\n  description_length: 500\n"), "", "")
+	if err != nil || !cfg.SlackDescriptionSnippet || cfg.SlackDescriptionLength != 500 {
+		t.Errorf("unexpected snippet settings: %v %d (%v)", cfg.SlackDescriptionSnippet, cfg.SlackDescriptionLength, err)
+	}
+	if _, err := Parse([]byte("slack:\n  description_length: 5000\n"), "", ""); err == nil {
+		t.Error("expected an over-long description_length to be rejected")
+	}
+}
+
+func TestLoadConfigFromBytesChannelRoutes(t *testing.T) {
+	cfg, err := Parse([]byte("slack:\n  channel_routes:\n    \"payments-*\": CPAY\n    \"payments-legacy\": COLD\n    \"acme/*\": CACME\n    \"acme/payments-api\": CAPI\n"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for repo, want := range map[string]string{
+		"acme/payments-api":     "CAPI",
+		"acme/payments-web":     "CPAY",
+		"other/payments-web":    "CPAY",
+		"other/payments-legacy": "COLD",
+		"acme/api":              "CACME",
+		"other/api":             "",
+	} {
+		if got := cfg.ChannelRoute(repo); got != want {
+			t.Errorf("ChannelRoute(%q) = %q, want %q", repo, got, want)
+		}
+	}
+
+	for _, bad := range []string{
+		"slack:\n  channel_routes:\n    \"payments-[\": CPAY\n",
+		"slack:\n  channel_routes:\n    \"payments-*\": \"\"\n",
+	} {
+		if _, err := Parse([]byte(bad), "", ""); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression
+// (minute hour day-of-month month day-of-week). Each field is a bitset of
+// the values it matches.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a day field starting with "*". As in classic cron, when
+	// both day fields are restricted a time matches if either one does.
+	domAny, dowAny bool
+}
+
+// cronFieldBounds are the inclusive value ranges of the five fields.
+var cronFieldBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// parseCron parses a standard five-field cron expression. Fields accept *,
+// single values, ranges (1-5), lists (1,15), and steps (*/15, 9-17/2).
+// Day-of-week 7 is Sunday, like 0.
+func parseCron(expr string) (CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return CronSchedule{}, fmt.Errorf("cron %q: want 5 fields, got %d", expr, len(fields))
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFieldBounds[i][0], cronFieldBounds[i][1])
+		if err != nil {
+			return CronSchedule{}, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return CronSchedule{
+		minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+		domAny: strings.HasPrefix(fields[2], "*"),
+		dowAny: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField turns one comma-separated cron field into a bitset.
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", part)
+				}
+			} else if hasStep {
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Matches reports whether t, truncated to the minute, is a scheduled time.
+func (s CronSchedule) Matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Feature flags that features: in config.yaml, and their Redis overrides,
+// may turn on or off.
+const (
+	// FeatureApproveMerge adds "Approve" and "Merge" buttons to posted PRs
+	// that privileged users can click to approve or merge the PR.
+	FeatureApproveMerge = "approve_merge"
+	// FeatureBlockKitPosts lays every posted PR out in Block Kit, with an
+	// "Open in GitHub" button, instead of only posts with labels.
+	FeatureBlockKitPosts = "block_kit_posts"
+	// FeatureClaimReview adds a "Claim review" button to posted PRs that
+	// requests a review from the clicker's linked GitHub login.
+	FeatureClaimReview = "claim_review"
+	// FeatureGitHubAPI fetches PRs from the GitHub REST API, as github.mode
+	// api does, where the flag is on.
+	FeatureGitHubAPI = "github_api"
+	// FeatureMultiSelect allows /pr <repo> --multi.
+	FeatureMultiSelect = "multi_select"
+	// FeatureRemindMe adds a "Remind me" menu to posted PRs that DMs the
+	// clicker about the PR later.
+	FeatureRemindMe = "remind_me"
+)
+
+// KnownFeatures are the feature flag names config.yaml may set.
+var KnownFeatures = map[string]bool{
+	FeatureApproveMerge:  true,
+	FeatureBlockKitPosts: true,
+	FeatureClaimReview:   true,
+	FeatureGitHubAPI:     true,
+	FeatureMultiSelect:   true,
+	FeatureRemindMe:      true,
+}
+
+// defaultFeatures are the flags that apply when features: in config.yaml
+// does not mention them. Features missing here default to off.
+var defaultFeatures = map[string]FeatureFlag{
+	FeatureMultiSelect: {Enabled: true},
+}
+
+// slackTeamIDPattern and slackChannelIDPattern match the workspace and
+// channel IDs a feature flag can be scoped to.
+var (
+	slackTeamIDPattern    = regexp.MustCompile(`^T[A-Z0-9]+$`)
+	slackChannelIDPattern = regexp.MustCompile(`^[CG][A-Z0-9]+$`)
+)
+
+// FeatureFlag turns a feature on everywhere, or only for some workspaces
+// (Slack team IDs) and channels.
+type FeatureFlag struct {
+	Enabled    bool     `yaml:"enabled"`
+	Workspaces []string `yaml:"workspaces"`
+	Channels   []string `yaml:"channels"`
+}
+
+// EnabledFor reports whether the flag is on for a request from team in
+// channel; either may be empty when unknown.
+func (f FeatureFlag) EnabledFor(team, channel string) bool {
+	if f.Enabled {
+		return true
+	}
+	for _, id := range f.Workspaces {
+		if id == team && team != "" {
+			return true
+		}
+	}
+	for _, id := range f.Channels {
+		if id == channel && channel != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Feature returns the named flag as configured, or its default when
+// features: does not mention it.
+func (c Config) Feature(name string) FeatureFlag {
+	if f, ok := c.Features[name]; ok {
+		return f
+	}
+	return defaultFeatures[name]
+}
+
+// FeatureEnabled reports whether the named feature is on. Handlers resolve
+// the flags for the workspace and channel of each request first, so this
+// reports the flag as it applies to that request.
+func (c Config) FeatureEnabled(name string) bool {
+	return c.Feature(name).Enabled
+}
+
+// FeatureNames returns the known feature flag names, sorted.
+func FeatureNames() []string {
+	names := make([]string, 0, len(KnownFeatures))
+	for name := range KnownFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateFeatures checks that every flag is known and scoped to valid IDs.
+func validateFeatures(cf configFile) error {
+	for name, flag := range cf.Features {
+		if !KnownFeatures[name] {
+			return fmt.Errorf("unknown feature %q", name)
+		}
+		for _, id := range flag.Workspaces {
+			if !slackTeamIDPattern.MatchString(id) {
+				return fmt.Errorf("%s: %q is not a Slack team ID", name, id)
+			}
+		}
+		for _, id := range flag.Channels {
+			if !slackChannelIDPattern.MatchString(id) {
+				return fmt.Errorf("%s: %q is not a Slack channel ID", name, id)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// Secret providers selectable with secrets.provider.
+const (
+	secretsProviderEnv   = "env"
+	secretsProviderVault = "vault"
+)
+
+const (
+	// vaultRequestTimeout bounds each call to Vault.
+	vaultRequestTimeout = 10 * time.Second
+	// vaultMinRenewInterval stops short leases from turning renewal into a
+	// busy loop.
+	vaultMinRenewInterval = 10 * time.Second
+	// defaultVaultJWTPath is where Kubernetes mounts the service account
+	// token presented to Vault's kubernetes auth method.
+	defaultVaultJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// SecretsProvider supplies the secrets load would otherwise read from
+// environment variables, keyed by the variable name (e.g. SLACK_BOT_TOKEN).
+type SecretsProvider interface {
+	Secret(key string) (string, error)
+}
+
+// envSecrets reads secrets from the environment or *_FILE files.
+type envSecrets struct{}
+
+func (envSecrets) Secret(key string) (string, error) {
+	return getSecret(key)
+}
+
+// validateSecretsProvider checks the secrets section of the config file.
+func validateSecretsProvider(cf configFile) error {
+	switch cf.Secrets.Provider {
+	case secretsProviderEnv:
+		return nil
+	case secretsProviderVault:
+		if cf.Secrets.Vault.Address == "" || cf.Secrets.Vault.Path == "" {
+			return errors.New("secrets.vault.address and secrets.vault.path are required")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown provider %q (want %q or %q)", cf.Secrets.Provider, secretsProviderEnv, secretsProviderVault)
+}
+
+// vaultSecrets reads secrets from one Vault KV secret whose fields are the
+// lower-cased variable names (slack_bot_token, redis_password, ...).
+// Secrets missing from Vault fall back to the environment. It logs in with
+// VAULT_TOKEN when set, otherwise with the Kubernetes auth method as
+// secrets.vault.role.
+type vaultSecrets struct {
+	address  string
+	role     string
+	authPath string
+	path     string
+	jwtPath  string
+	client   *http.Client
+
+	mu             sync.Mutex
+	token          string
+	tokenTTL       time.Duration
+	tokenRenewable bool
+	data           map[string]string
+	leaseID        string
+	leaseTTL       time.Duration
+}
+
+// newVaultSecrets logs in to Vault and reads the configured secret.
+func newVaultSecrets(ctx context.Context, cf configFile) (*vaultSecrets, error) {
+	v := &vaultSecrets{
+		address:  strings.TrimSuffix(cf.Secrets.Vault.Address, "/"),
+		role:     cf.Secrets.Vault.Role,
+		authPath: cf.Secrets.Vault.AuthPath,
+		path:     strings.Trim(cf.Secrets.Vault.Path, "/"),
+		jwtPath:  defaultVaultJWTPath,
+		client:   &http.Client{Timeout: vaultRequestTimeout},
+	}
+	if err := v.login(ctx); err != nil {
+		return nil, err
+	}
+	if err := v.read(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// vaultAuth is the auth block of a Vault login or renewal response.
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// login obtains a client token, from VAULT_TOKEN or the Kubernetes auth
+// method.
+func (v *vaultSecrets) login(ctx context.Context) error {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		v.mu.Lock()
+		v.token = token
+		v.mu.Unlock()
+		// A token supplied this way may be renewable; look it up so it
+		// is kept alive like a login token.
+		var resp struct {
+			Data struct {
+				TTL       int  `json:"ttl"`
+				Renewable bool `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := v.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, &resp); err != nil {
+			return fmt.Errorf("failed to look up VAULT_TOKEN: %w", err)
+		}
+		v.mu.Lock()
+		v.tokenTTL, v.tokenRenewable = time.Duration(resp.Data.TTL)*time.Second, resp.Data.Renewable
+		v.mu.Unlock()
+		return nil
+	}
+
+	if v.role == "" {
+		return errors.New("secrets.vault.role is required unless VAULT_TOKEN is set")
+	}
+	jwt, err := os.ReadFile(v.jwtPath)
+	if err != nil {
+		return fmt.Errorf("failed to read service account token: %w", err)
+	}
+	var resp struct {
+		Auth vaultAuth `json:"auth"`
+	}
+	body := map[string]string{"role": v.role, "jwt": strings.TrimSpace(string(jwt))}
+	if err := v.do(ctx, http.MethodPost, "auth/"+v.authPath+"/login", body, &resp); err != nil {
+		return fmt.Errorf("failed to log in to Vault: %w", err)
+	}
+	v.setAuth(resp.Auth)
+	return nil
+}
+
+func (v *vaultSecrets) setAuth(auth vaultAuth) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.token = auth.ClientToken
+	v.tokenTTL = time.Duration(auth.LeaseDuration) * time.Second
+	v.tokenRenewable = auth.Renewable
+}
+
+// read fetches the secret at path. KV version 2 nests the fields under a
+// second data key; version 1 does not.
+func (v *vaultSecrets) read(ctx context.Context) error {
+	var resp struct {
+		LeaseID       string                 `json:"lease_id"`
+		LeaseDuration int                    `json:"lease_duration"`
+		Renewable     bool                   `json:"renewable"`
+		Data          map[string]interface{} `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, v.path, nil, &resp); err != nil {
+		return fmt.Errorf("failed to read Vault secret %s: %w", v.path, err)
+	}
+	fields := resp.Data
+	if nested, ok := resp.Data["data"].(map[string]interface{}); ok {
+		fields = nested
+	}
+	data := make(map[string]string, len(fields))
+	for k, val := range fields {
+		if s, ok := val.(string); ok {
+			data[k] = s
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.data = data
+	v.leaseID = ""
+	if resp.Renewable {
+		v.leaseID = resp.LeaseID
+		v.leaseTTL = time.Duration(resp.LeaseDuration) * time.Second
+	}
+	return nil
+}
+
+// Secret returns the Vault field named after key, or the environment value
+// when Vault has no such field.
+func (v *vaultSecrets) Secret(key string) (string, error) {
+	v.mu.Lock()
+	value, ok := v.data[strings.ToLower(key)]
+	v.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	return getSecret(key)
+}
+
+// renewInterval is how long to wait before renewing the token or the
+// secret's lease: half the shorter of the two, or zero when neither expires.
+func (v *vaultSecrets) renewInterval() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var ttl time.Duration
+	if v.tokenRenewable && v.tokenTTL > 0 {
+		ttl = v.tokenTTL
+	}
+	if v.leaseID != "" && v.leaseTTL > 0 && (ttl == 0 || v.leaseTTL < ttl) {
+		ttl = v.leaseTTL
+	}
+	if ttl == 0 {
+		return 0
+	}
+	return max(ttl/2, vaultMinRenewInterval)
+}
+
+// renew extends the client token and, for a leased secret, its lease. A
+// token that can no longer be renewed is replaced by logging in again.
+func (v *vaultSecrets) renew(ctx context.Context) error {
+	v.mu.Lock()
+	renewToken, leaseID := v.tokenRenewable && v.tokenTTL > 0, v.leaseID
+	v.mu.Unlock()
+
+	if renewToken {
+		var resp struct {
+			Auth vaultAuth `json:"auth"`
+		}
+		if err := v.do(ctx, http.MethodPost, "auth/token/renew-self", struct{}{}, &resp); err != nil {
+			logging.WarnContext(ctx, "Error renewing Vault token, logging in again: %v", err)
+			if err := v.login(ctx); err != nil {
+				return err
+			}
+		} else {
+			v.setAuth(resp.Auth)
+		}
+	}
+	if leaseID != "" {
+		var resp struct {
+			LeaseDuration int `json:"lease_duration"`
+		}
+		if err := v.do(ctx, http.MethodPut, "sys/leases/renew", map[string]string{"lease_id": leaseID}, &resp); err != nil {
+			return fmt.Errorf("failed to renew lease of %s: %w", v.path, err)
+		}
+		v.mu.Lock()
+		v.leaseTTL = time.Duration(resp.LeaseDuration) * time.Second
+		v.mu.Unlock()
+	}
+	return nil
+}
+
+// KeepAlive renews the Vault token and secret lease until ctx is done.
+func (v *vaultSecrets) KeepAlive(ctx context.Context) {
+	for {
+		interval := v.renewInterval()
+		if interval == 0 {
+			logging.DebugContext(ctx, "Vault token and secret do not expire; no renewal needed")
+			return
+		}
+		if !sleep(ctx, interval) {
+			return
+		}
+		if err := v.renew(ctx); err != nil {
+			logging.ErrorContext(ctx, "Error renewing Vault credentials: %v", err)
+			if !sleep(ctx, vaultMinRenewInterval) {
+				return
+			}
+		}
+	}
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// do sends a request to the Vault HTTP API and decodes the JSON response.
+func (v *vaultSecrets) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, v.address+"/v1/"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build Vault request: %w", err)
+	}
+	v.mu.Lock()
+	token := v.token
+	v.mu.Unlock()
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Vault returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	return nil
+}
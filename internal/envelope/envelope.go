@@ -0,0 +1,115 @@
+// Package envelope wraps the payloads SlashVibePR queues for other services
+// in a versioned envelope, and checks the payloads it receives against the
+// JSON schemas in schemas/ before they are handled.
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+)
+
+// Version is the envelope version written, and the newest one read.
+const Version = 1
+
+// Kinds of payload this service produces.
+const (
+	KindPoppitCommand     = "poppit.command"
+	KindSlackLinerMessage = "slackliner.message"
+)
+
+// Envelope is the versioned wrapper around a payload on a Redis list or
+// feed. Kind names the payload's shape so a consumer can reject payloads
+// it does not understand.
+type Envelope struct {
+	Version int             `json:"version"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Wrap returns v in an envelope of the given kind. A []byte or
+// json.RawMessage is taken to be JSON already.
+func Wrap(kind string, v interface{}) ([]byte, error) {
+	var payload []byte
+	switch v := v.(type) {
+	case []byte:
+		payload = v
+	case json.RawMessage:
+		payload = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", kind, err)
+		}
+		payload = data
+	}
+	return json.Marshal(Envelope{Version: Version, Kind: kind, Payload: payload})
+}
+
+// Format is how payloads are written for one counterpart service.
+type Format struct {
+	// Wrap puts each payload in a versioned envelope.
+	Wrap bool
+	// Codec encodes the result; nil means JSON.
+	Codec codec.Codec
+}
+
+// Encode marshals v, a payload of the given kind, in format f. A []byte or
+// json.RawMessage is taken to be JSON already.
+func (f Format) Encode(kind string, v interface{}) ([]byte, error) {
+	if f.Wrap {
+		data, err := Wrap(kind, v)
+		if err != nil {
+			return nil, err
+		}
+		v = data
+	}
+	if f.Codec == nil || f.Codec == codec.JSON {
+		if data, ok := v.([]byte); ok {
+			return data, nil
+		}
+		return json.Marshal(v)
+	}
+	return f.Codec.Marshal(v)
+}
+
+// Open returns the payload inside data's envelope, as JSON even when data
+// is MessagePack. Payloads sent without an envelope, as the Slack relay and
+// older Poppit versions do, are returned unchanged.
+func Open(data []byte) ([]byte, error) {
+	data, err := codec.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return data, nil
+	}
+	var probe struct {
+		Version *int            `json:"version"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(trimmed, &probe); err != nil || probe.Version == nil || probe.Payload == nil {
+		return data, nil
+	}
+	if *probe.Version < 1 || *probe.Version > Version {
+		return nil, fmt.Errorf("unsupported envelope version %d", *probe.Version)
+	}
+	return probe.Payload, nil
+}
+
+// Decode opens data's envelope, if any, validates the payload against the
+// named schema, and unmarshals it into v. The error says why a payload was
+// rejected, for the dead-letter queue.
+func Decode(schema string, data []byte, v interface{}) error {
+	payload, err := Open(data)
+	if err != nil {
+		return err
+	}
+	if err := Validate(schema, payload); err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
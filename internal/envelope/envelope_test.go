@@ -0,0 +1,94 @@
+package envelope
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+)
+
+// ---- envelope tests ----
+
+func TestWrapOpen(t *testing.T) {
+	data, err := Wrap(KindSlackLinerMessage, []byte(`{"channel":"C1","text":"hi"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Version != Version || env.Kind != KindSlackLinerMessage {
+		t.Fatalf("unexpected envelope %s: %v", data, err)
+	}
+
+	payload, err := Open(data)
+	if err != nil || string(payload) != `{"channel":"C1","text":"hi"}` {
+		t.Errorf("expected the wrapped payload back, got %s: %v", payload, err)
+	}
+	bare := []byte(`{"command":"/pr"}`)
+	if payload, err := Open(bare); err != nil || string(payload) != string(bare) {
+		t.Errorf("expected a bare payload unchanged, got %s: %v", payload, err)
+	}
+	if _, err := Open([]byte(`{"version":2,"kind":"x","payload":{}}`)); err == nil || !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("expected an unsupported version error, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		schema, payload, wantErr string
+	}{
+		{SchemaSlashCommand, `{"command":"/pr","user_id":"U1","text":"api"}`, ""},
+		{SchemaSlashCommand, `{"command":"/pr"}`, `missing required field "user_id"`},
+		{SchemaSlashCommand, `{"command":5,"user_id":"U1"}`, "$.command: expected string, got integer"},
+		{SchemaSlashCommand, `[]`, "$: expected object, got array"},
+		{SchemaBlockActions, `{"type":"block_actions","user":{"id":"U1"},"actions":[{"action_id":"a"}]}`, ""},
+		{SchemaBlockActions, `{"type":"block_actions","user":{"id":"U1"},"actions":[{"value":"x"}]}`, `$.actions[0]: missing required field "action_id"`},
+		{SchemaPoppitOutput, `{"type":"t","output":"[]","exit_code":null}`, ""},
+		{SchemaPoppitOutput, `{"type":"t","exit_code":1.5}`, "$.exit_code: expected integer or null, got number"},
+		{SchemaMessageShortcut, `{"type":"shortcut","callback_id":"c","user":{"id":"U1"}}`, `"shortcut" is not one of message_action`},
+		{"nope", `{}`, `unknown schema "nope"`},
+		{SchemaAppHomeOpened, `{"type":`, "invalid JSON"},
+	}
+	for _, c := range cases {
+		err := Validate(c.schema, []byte(c.payload))
+		if c.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %s: unexpected error: %v", c.schema, c.payload, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s %s: expected error containing %q, got %v", c.schema, c.payload, c.wantErr, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var cmd struct {
+		Command string `json:"command"`
+	}
+	data, _ := Wrap("slack.slash_command", map[string]string{"command": "/pr", "user_id": "U1"})
+	if err := Decode(SchemaSlashCommand, data, &cmd); err != nil || cmd.Command != "/pr" {
+		t.Errorf("expected the enveloped command decoded, got %+v: %v", cmd, err)
+	}
+	if err := Decode(SchemaSlashCommand, []byte(`{"command":"/pr"}`), &cmd); err == nil {
+		t.Error("expected a payload missing user_id to be rejected")
+	}
+}
+
+func TestFormatEncode(t *testing.T) {
+	msg := map[string]string{"channel": "C1", "text": "hi"}
+	data, err := Format{}.Encode(KindSlackLinerMessage, msg)
+	if err != nil || string(data) != `{"channel":"C1","text":"hi"}` {
+		t.Errorf("expected bare JSON, got %s: %v", data, err)
+	}
+
+	data, err = Format{Wrap: true, Codec: codec.MessagePack}.Encode(KindSlackLinerMessage, msg)
+	if err != nil || !codec.IsMessagePack(data) {
+		t.Fatalf("expected an enveloped MessagePack payload, got %q: %v", data, err)
+	}
+	payload, err := Open(data)
+	if err != nil || string(payload) != `{"channel":"C1","text":"hi"}` {
+		t.Errorf("expected the JSON payload back, got %s: %v", payload, err)
+	}
+}
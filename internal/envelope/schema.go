@@ -0,0 +1,176 @@
+package envelope
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Schema names for the payloads this service receives.
+const (
+	SchemaSlashCommand    = "slash_command"
+	SchemaViewSubmission  = "view_submission"
+	SchemaBlockActions    = "block_actions"
+	SchemaMessageShortcut = "message_shortcut"
+	SchemaBlockSuggestion = "block_suggestion"
+	SchemaAppHomeOpened   = "app_home_opened"
+	SchemaLinkShared      = "link_shared"
+	SchemaReactionAdded   = "reaction_added"
+	SchemaPoppitOutput    = "poppit_output"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schema is the subset of JSON Schema the files in schemas/ use: type
+// (one or a list), required, properties, items, and enum.
+type schema struct {
+	Type       schemaTypes        `json:"type"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	Enum       []string           `json:"enum"`
+}
+
+// schemaTypes accepts "type" as either a string or a list of strings.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// schemas holds the parsed files in schemas/, keyed by name without the
+// .json extension.
+var schemas = loadSchemas()
+
+func loadSchemas() map[string]*schema {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(fmt.Sprintf("envelope: reading embedded schemas: %v", err))
+	}
+	loaded := make(map[string]*schema, len(entries))
+	for _, entry := range entries {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("envelope: reading schema %s: %v", entry.Name(), err))
+		}
+		var s schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			panic(fmt.Sprintf("envelope: parsing schema %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = &s
+	}
+	return loaded
+}
+
+// Validate checks the JSON payload against the named schema.
+func Validate(name string, payload []byte) error {
+	s, ok := schemas[name]
+	if !ok {
+		return fmt.Errorf("unknown schema %q", name)
+	}
+	var v interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := s.validate("$", v); err != nil {
+		return fmt.Errorf("payload does not match schema %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *schema) validate(at string, v interface{}) error {
+	if len(s.Type) > 0 && !s.allowsType(v) {
+		return fmt.Errorf("%s: expected %s, got %s", at, strings.Join(s.Type, " or "), jsonType(v))
+	}
+	if len(s.Enum) > 0 {
+		str, _ := v.(string)
+		if !contains(s.Enum, str) {
+			return fmt.Errorf("%s: %q is not one of %s", at, str, strings.Join(s.Enum, ", "))
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, field := range s.Required {
+			if _, ok := v[field]; !ok {
+				return fmt.Errorf("%s: missing required field %q", at, field)
+			}
+		}
+		// Check fields in a stable order so the reported error is too.
+		fields := make([]string, 0, len(s.Properties))
+		for field := range s.Properties {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if value, ok := v[field]; ok {
+				if err := s.Properties[field].validate(at+"."+field, value); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *schema) allowsType(v interface{}) bool {
+	actual := jsonType(v)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names v's JSON Schema type, telling integers from other numbers.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// sessionKeyPrefixes are the Redis prefixes of the short-lived modal state
+// counted and purged by /pr admin sessions: PR chooser sessions and pending
+// post previews.
+var sessionKeyPrefixes = []string{prSessionKeyPrefix, previewKeyPrefix}
+
+// adminCommand is a /pr admin subcommand. Run returns the ephemeral reply,
+// or "" when args do not fit Usage, in which case the usage is sent.
+type adminCommand struct {
+	Name  string
+	Usage []usageLine
+	Run   func(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string
+}
+
+// adminCommands returns the /pr admin subcommands in the order their usage
+// is listed. Commands without usage lines are kept as aliases.
+func adminCommands() []adminCommand {
+	return []adminCommand{
+		{Name: "grant", Usage: []usageLine{{Form: "admin grant @user", Help: "grant the privileged role"}},
+			Run: roleChange("grant")},
+		{Name: "revoke", Usage: []usageLine{{Form: "admin revoke @user", Help: "revoke the privileged role"}},
+			Run: roleChange("revoke")},
+		{Name: "list", Usage: []usageLine{{Form: "admin list", Help: "who holds the privileged role, and the restricted commands"}},
+			Run: func(ctx context.Context, rdb *redis.Client, _ SlackCommand, args []string, config config.Config) string {
+				if len(args) != 0 {
+					return ""
+				}
+				return formatPrivilegedUsers(ctx, rdb, config)
+			}},
+		{Name: "link", Usage: []usageLine{{Form: "admin link @user <github-login>", Help: "link a user's GitHub login, taking it over from whoever linked it"}},
+			Run: runAdminLink},
+		{Name: "reload", Usage: []usageLine{{Form: "admin reload", Help: "re-read config.yaml on every replica"}},
+			Run: runAdminReload},
+		{Name: "config", Usage: []usageLine{{Form: "admin config", Help: "list the runtime overrides"}},
+			Run: func(ctx context.Context, rdb *redis.Client, _ SlackCommand, args []string, _ config.Config) string {
+				if len(args) != 0 {
+					return ""
+				}
+				overrides, err := loadConfigOverrides(ctx, rdb)
+				if err != nil {
+					logging.ErrorContext(ctx, "Error listing config overrides: %v", err)
+					return ":x: Could not read the config overrides. Please try again."
+				}
+				return formatConfigOverrides(overrides)
+			}},
+		{Name: "set", Usage: []usageLine{{Form: "admin set <key> <value>", Help: "override a runtime setting"}},
+			Run: configChange("set")},
+		{Name: "unset", Usage: []usageLine{{Form: "admin unset <key>", Help: "remove a runtime override"}},
+			Run: configChange("unset")},
+		{Name: "dlq", Usage: []usageLine{
+			{Form: "admin dlq [list]", Help: "the newest dead-lettered payloads"},
+			{Form: "admin dlq replay <id>", Help: "send a dead-lettered payload back to its feed"},
+		},
+			Run: runAdminDLQ},
+		// replay is the original spelling of dlq replay.
+		{Name: "replay", Run: func(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+			return runAdminDLQ(ctx, rdb, cmd, append([]string{"replay"}, args...), config)
+		}},
+		{Name: "sessions", Usage: []usageLine{{Form: "admin sessions [purge]", Help: "count, or delete, the open chooser sessions and post previews"}},
+			Run: runAdminSessions},
+		{Name: "feature", Usage: []usageLine{
+			{Form: "admin feature", Help: "list the feature flags and their overrides"},
+			{Form: "admin feature <name> on|off|reset [#channel|team]", Help: "override a feature flag everywhere, for a workspace, or for a channel"},
+		},
+			Run: runAdminFeature},
+	}
+}
+
+// adminUsage lists the /pr admin subcommands.
+func adminUsage() string {
+	var b strings.Builder
+	b.WriteString(":warning: Usage:")
+	for _, c := range adminCommands() {
+		for _, u := range c.Usage {
+			fmt.Fprintf(&b, "\n• `/pr %s` — %s", u.Form, u.Help)
+		}
+	}
+	return b.String()
+}
+
+// handleAdminCommand implements /pr admin, which only admins may use: it
+// dispatches to the subcommand named by the first field and answers
+// ephemerally.
+func handleAdminCommand(ctx context.Context, rdb *redis.Client, cmd SlackCommand, fields []string, config config.Config) {
+	reply := adminUsage()
+	switch {
+	case !isAdmin(config, cmd.UserID):
+		reply = ":no_entry: Only admins can use `/pr admin`."
+	case len(fields) > 0:
+		for _, c := range adminCommands() {
+			if c.Name == fields[0] {
+				if text := c.Run(ctx, rdb, cmd, fields[1:], config); text != "" {
+					reply = text
+				}
+				break
+			}
+		}
+	}
+	if err := respondEphemeral(ctx, cmd.ResponseURL, reply); err != nil {
+		logging.ErrorContext(ctx, "Error responding to admin for user %s: %v", cmd.UserName, err)
+	}
+}
+
+// configChange returns the /pr admin set or unset subcommand, which stores
+// or removes a runtime override and audits the change.
+func configChange(op string) func(context.Context, *redis.Client, SlackCommand, []string, config.Config) string {
+	return func(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+		var err error
+		switch {
+		case op == "set" && len(args) == 2:
+			err = setConfigOverride(ctx, rdb, args[0], args[1], config)
+		case op == "unset" && len(args) == 1:
+			err = unsetConfigOverride(ctx, rdb, args[0])
+		default:
+			return ""
+		}
+		if err != nil {
+			logging.WarnContext(ctx, "Error running config %s of %s: %v", op, args[0], err)
+			return fmt.Sprintf(":x: %s.", capitalize(err.Error()))
+		}
+		detail := strings.Join(args, "=")
+		logging.InfoContext(ctx, "User %s ran config %s of %s", cmd.UserName, op, detail)
+		if err := recordAudit(ctx, rdb, AuditEntry{
+			Action:   op,
+			UserID:   cmd.UserID,
+			Username: cmd.UserName,
+			Detail:   detail,
+		}, config); err != nil {
+			logging.WarnContext(ctx, "Error auditing config change of %s: %v", args[0], err)
+		}
+		if op == "set" {
+			return fmt.Sprintf(":white_check_mark: `%s` is now `%s`.", args[0], args[1])
+		}
+		return fmt.Sprintf(":white_check_mark: `%s` is back to its config.yaml value.", args[0])
+	}
+}
+
+// runAdminReload implements /pr admin reload.
+func runAdminReload(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+	if len(args) != 0 {
+		return ""
+	}
+	if err := requestConfigReload(ctx, rdb, config); err != nil {
+		logging.WarnContext(ctx, "Error reloading config for user %s: %v", cmd.UserName, err)
+		return fmt.Sprintf(":x: Config not reloaded: %s.", err)
+	}
+	logging.InfoContext(ctx, "User %s requested a config reload", cmd.UserName)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   "reload",
+		UserID:   cmd.UserID,
+		Username: cmd.UserName,
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing config reload: %v", err)
+	}
+	return ":arrows_counterclockwise: Reloading `config.yaml`; each replica picks it up with its next interaction. Runtime overrides still apply on top, and Redis, feed, and schedule settings need a restart."
+}
+
+// runAdminDLQ implements /pr admin dlq [list] and /pr admin dlq replay <id>.
+func runAdminDLQ(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "list"):
+		entries, err := listDLQ(ctx, rdb, maxDLQListed)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error listing dead-letter queue: %v", err)
+			return ":x: Could not read the dead-letter queue. Please try again."
+		}
+		return formatDLQEntries(entries)
+	case len(args) == 2 && args[0] == "replay":
+		entry, err := replayDLQ(ctx, rdb, args[1], config)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error replaying dead-letter entry %s: %v", args[1], err)
+			return fmt.Sprintf(":x: %s.", capitalize(err.Error()))
+		}
+		logging.InfoContext(ctx, "User %s replayed dead-letter entry %s to %s", cmd.UserName, entry.ID, entry.Source)
+		return fmt.Sprintf(":repeat: Replayed `%s` to `%s`.", entry.ID, entry.Source)
+	}
+	return ""
+}
+
+// runAdminSessions implements /pr admin sessions [purge].
+func runAdminSessions(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+	purge := len(args) == 1 && args[0] == "purge"
+	if len(args) != 0 && !purge {
+		return ""
+	}
+	count, err := scanSessions(ctx, rdb, purge)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error scanning sessions: %v", err)
+		return ":x: Could not read the sessions. Please try again."
+	}
+	if !purge {
+		return fmt.Sprintf("There are %d open chooser sessions and post previews. Purge them with `/pr admin sessions purge`.", count)
+	}
+	logging.InfoContext(ctx, "User %s purged %d sessions", cmd.UserName, count)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   "purge_sessions",
+		UserID:   cmd.UserID,
+		Username: cmd.UserName,
+		Detail:   fmt.Sprint(count),
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing session purge: %v", err)
+	}
+	return fmt.Sprintf(":wastebasket: Purged %d sessions. Open choosers and previews will report that they have expired.", count)
+}
+
+// scanSessions counts the keys under sessionKeyPrefixes, deleting them when
+// purge is set.
+func scanSessions(ctx context.Context, rdb *redis.Client, purge bool) (int, error) {
+	count := 0
+	for _, prefix := range sessionKeyPrefixes {
+		iter := rdb.Scan(ctx, 0, prefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			if purge {
+				if err := rdb.Del(ctx, iter.Val()).Err(); err != nil {
+					return count, err
+				}
+			}
+			count++
+		}
+		if err := iter.Err(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
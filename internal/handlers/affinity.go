@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+)
+
+// poppitInstanceField is the Poppit metadata field naming the replica that
+// queued a command, so that replica alone handles the output.
+const poppitInstanceField = "instance_id"
+
+// ownsPoppitOutput reports whether this replica should handle a Poppit
+// output. On pub/sub every replica receives every output, so only the one
+// that queued the command (identified by redis.consumer_name) handles it.
+// In streams mode the consumer group already hands each output to a single
+// replica, which handles it whoever queued the command. Output without an
+// instance ID, from commands queued before an upgrade, is handled by all.
+func ownsPoppitOutput(metadata map[string]interface{}, config config.Config) bool {
+	if config.UsesStreams() {
+		return true
+	}
+	id, _ := metadata[poppitInstanceField].(string)
+	return id == "" || id == config.RedisConsumerName
+}
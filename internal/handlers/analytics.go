@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	analyticsKeyPrefix    = "slashvibepr:stats"
+	analyticsRetention    = 90 * 24 * time.Hour
+	analyticsDayFormat    = "2006-01-02"
+	analyticsTopReposSize = 5
+)
+
+// RepoCount pairs a repository with the number of times it was shared.
+type RepoCount struct {
+	Repo  string
+	Count int64
+}
+
+// UserCount pairs a Slack username with the number of PRs they shared.
+type UserCount struct {
+	User  string
+	Count int64
+}
+
+// UsageStats summarises recent posting activity for the App Home dashboard.
+type UsageStats struct {
+	PostsThisWeek    int64
+	TopRepos         []RepoCount
+	ReviewSamples    int64
+	AverageReviewSLA time.Duration
+}
+
+// analyticsPostsKey returns the daily post counter key for the given day.
+func analyticsPostsKey(day time.Time) string {
+	return fmt.Sprintf("%s:posts:%s", analyticsKeyPrefix, day.UTC().Format(analyticsDayFormat))
+}
+
+// analyticsReposKey returns the daily per-repo sorted set key for the given day.
+func analyticsReposKey(day time.Time) string {
+	return fmt.Sprintf("%s:repos:%s", analyticsKeyPrefix, day.UTC().Format(analyticsDayFormat))
+}
+
+// analyticsUsersKey returns the daily per-user sorted set key for the given day.
+func analyticsUsersKey(day time.Time) string {
+	return fmt.Sprintf("%s:users:%s", analyticsKeyPrefix, day.UTC().Format(analyticsDayFormat))
+}
+
+// analyticsRepoUsersKey returns the daily per-user sorted set key for one repo.
+func analyticsRepoUsersKey(repo string, day time.Time) string {
+	return fmt.Sprintf("%s:repo_users:%s:%s", analyticsKeyPrefix, repo, day.UTC().Format(analyticsDayFormat))
+}
+
+// analyticsReviewKey returns the daily hash holding the total and sample
+// count of the review latencies recorded on the given day.
+func analyticsReviewKey(day time.Time) string {
+	return fmt.Sprintf("%s:review_sla:%s", analyticsKeyPrefix, day.UTC().Format(analyticsDayFormat))
+}
+
+// recordPostAnalytics increments the daily post counter and the per-repo,
+// per-user, and per-repo-user scores. Keys expire after analyticsRetention so
+// the store does not grow unbounded.
+func recordPostAnalytics(ctx context.Context, rdb *redis.Client, repo, user string, at time.Time) error {
+	postsKey := analyticsPostsKey(at)
+	reposKey := analyticsReposKey(at)
+	usersKey := analyticsUsersKey(at)
+	repoUsersKey := analyticsRepoUsersKey(repo, at)
+
+	pipe := rdb.TxPipeline()
+	pipe.Incr(ctx, postsKey)
+	pipe.Expire(ctx, postsKey, analyticsRetention)
+	pipe.ZIncrBy(ctx, reposKey, 1, repo)
+	pipe.Expire(ctx, reposKey, analyticsRetention)
+	pipe.ZIncrBy(ctx, usersKey, 1, user)
+	pipe.Expire(ctx, usersKey, analyticsRetention)
+	pipe.ZIncrBy(ctx, repoUsersKey, 1, user)
+	pipe.Expire(ctx, repoUsersKey, analyticsRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record post analytics: %w", err)
+	}
+	return nil
+}
+
+// recordReviewLatency adds one review turnaround sample to the SLA average
+// of the day of at.
+func recordReviewLatency(ctx context.Context, rdb *redis.Client, latency time.Duration, at time.Time) error {
+	key := analyticsReviewKey(at)
+	pipe := rdb.TxPipeline()
+	pipe.HIncrBy(ctx, key, "total_seconds", int64(latency.Seconds()))
+	pipe.HIncrBy(ctx, key, "count", 1)
+	pipe.Expire(ctx, key, analyticsRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record review latency: %w", err)
+	}
+	return nil
+}
+
+// recordFirstReview marks the latest post of repo#number as reviewed at at.
+// The first time a post is, the time it waited since being posted is added
+// to the review SLA. Approvals from Slack, ✅ reactions, and claimed reviews
+// all count; PRs not posted recently are skipped.
+func recordFirstReview(ctx context.Context, rdb *redis.Client, repo string, number int, at time.Time) error {
+	rec, err := updatePostedPR(ctx, rdb, repo, number, func(rec *PostedPR) bool {
+		if !rec.FirstReviewAt.IsZero() {
+			return false
+		}
+		rec.FirstReviewAt = at
+		return true
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil || rec == nil {
+		return err
+	}
+	return recordReviewLatency(ctx, rdb, at.Sub(rec.PostedAt), at)
+}
+
+// loadWeeklyStats aggregates the last seven days of analytics ending at now.
+func loadWeeklyStats(ctx context.Context, rdb *redis.Client, now time.Time) (UsageStats, error) {
+	var stats UsageStats
+	repoTotals := make(map[string]int64)
+	var reviewSeconds int64
+
+	for i := 0; i < 7; i++ {
+		day := now.AddDate(0, 0, -i)
+
+		n, err := rdb.Get(ctx, analyticsPostsKey(day)).Int64()
+		if err != nil && err != redis.Nil {
+			return stats, fmt.Errorf("failed to read post counter: %w", err)
+		}
+		stats.PostsThisWeek += n
+
+		review, err := rdb.HGetAll(ctx, analyticsReviewKey(day)).Result()
+		if err != nil {
+			return stats, fmt.Errorf("failed to read review SLA: %w", err)
+		}
+		seconds, _ := strconv.ParseInt(review["total_seconds"], 10, 64)
+		count, _ := strconv.ParseInt(review["count"], 10, 64)
+		reviewSeconds += seconds
+		stats.ReviewSamples += count
+
+		scores, err := rdb.ZRangeWithScores(ctx, analyticsReposKey(day), 0, -1).Result()
+		if err != nil {
+			return stats, fmt.Errorf("failed to read repo counters: %w", err)
+		}
+		for _, z := range scores {
+			if repo, ok := z.Member.(string); ok {
+				repoTotals[repo] += int64(z.Score)
+			}
+		}
+	}
+
+	stats.TopRepos = topRepoCounts(repoTotals, analyticsTopReposSize)
+	if stats.ReviewSamples > 0 {
+		stats.AverageReviewSLA = time.Duration(reviewSeconds/stats.ReviewSamples) * time.Second
+	}
+
+	return stats, nil
+}
+
+// topRepoCounts returns the n most-shared repos, breaking ties alphabetically.
+func topRepoCounts(totals map[string]int64, n int) []RepoCount {
+	counts := make([]RepoCount, 0, len(totals))
+	for repo, count := range totals {
+		counts = append(counts, RepoCount{Repo: repo, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Repo < counts[j].Repo
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// DayCount is the number of posts on one UTC day.
+type DayCount struct {
+	Day   time.Time
+	Count int64
+}
+
+// PostingReport summarises posts over the last Days days, for all repos or,
+// when Repo is set, for one repo. TopRepos is only filled for all repos.
+type PostingReport struct {
+	Repo     string
+	Days     int
+	Total    int64
+	PerDay   []DayCount
+	TopRepos []RepoCount
+	TopUsers []UserCount
+}
+
+// loadPostingReport aggregates the daily counters of the days days ending at
+// now. PerDay is ordered oldest first.
+func loadPostingReport(ctx context.Context, rdb *redis.Client, repo string, now time.Time, days int) (PostingReport, error) {
+	report := PostingReport{Repo: repo, Days: days}
+	repoTotals := make(map[string]int64)
+	userTotals := make(map[string]int64)
+
+	addScores := func(key string, totals map[string]int64) error {
+		scores, err := rdb.ZRangeWithScores(ctx, key, 0, -1).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read counters: %w", err)
+		}
+		for _, z := range scores {
+			if member, ok := z.Member.(string); ok {
+				totals[member] += int64(z.Score)
+			}
+		}
+		return nil
+	}
+
+	for i := days - 1; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i)
+
+		var n int64
+		var err error
+		if repo == "" {
+			n, err = rdb.Get(ctx, analyticsPostsKey(day)).Int64()
+			if err == nil || err == redis.Nil {
+				err = addScores(analyticsReposKey(day), repoTotals)
+			}
+			if err == nil {
+				err = addScores(analyticsUsersKey(day), userTotals)
+			}
+		} else {
+			var score float64
+			score, err = rdb.ZScore(ctx, analyticsReposKey(day), repo).Result()
+			n = int64(score)
+			if err == nil || err == redis.Nil {
+				err = addScores(analyticsRepoUsersKey(repo, day), userTotals)
+			}
+		}
+		if err != nil && err != redis.Nil {
+			return report, fmt.Errorf("failed to read post counters: %w", err)
+		}
+
+		report.Total += n
+		report.PerDay = append(report.PerDay, DayCount{Day: day.UTC().Truncate(24 * time.Hour), Count: n})
+	}
+
+	if repo == "" {
+		report.TopRepos = topRepoCounts(repoTotals, analyticsTopReposSize)
+	}
+	// topRepoCounts ranks any member counts; it is reused here for users.
+	for _, rc := range topRepoCounts(userTotals, analyticsTopReposSize) {
+		report.TopUsers = append(report.TopUsers, UserCount{User: rc.Repo, Count: rc.Count})
+	}
+	return report, nil
+}
+
+// Kinds of review activity counted towards the reviewer leaderboard.
+const (
+	reviewActivityApprovals = "approvals"
+	reviewActivityClaims    = "claims"
+)
+
+// analyticsReviewersKey returns the daily sorted set key counting one kind
+// of review activity per Slack user ID.
+func analyticsReviewersKey(kind string, day time.Time) string {
+	return fmt.Sprintf("%s:reviewers:%s:%s", analyticsKeyPrefix, kind, day.UTC().Format(analyticsDayFormat))
+}
+
+// recordReviewActivity credits userID with one approval or claim, a
+// reviewActivity kind, on the day of at.
+func recordReviewActivity(ctx context.Context, rdb *redis.Client, kind, userID string, at time.Time) error {
+	key := analyticsReviewersKey(kind, at)
+	pipe := rdb.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, userID)
+	pipe.Expire(ctx, key, analyticsRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record review activity: %w", err)
+	}
+	return nil
+}
+
+// ReviewerScore is one reviewer's approvals and claimed reviews.
+type ReviewerScore struct {
+	UserID    string
+	Approvals int64
+	Claims    int64
+}
+
+// Total is the score the leaderboard ranks by.
+func (s ReviewerScore) Total() int64 {
+	return s.Approvals + s.Claims
+}
+
+// loadReviewerLeaderboard aggregates the review activity of the days days
+// ending at now and returns the n highest totals, breaking ties by
+// approvals and then by user ID.
+func loadReviewerLeaderboard(ctx context.Context, rdb *redis.Client, now time.Time, days, n int) ([]ReviewerScore, error) {
+	scores := make(map[string]*ReviewerScore)
+	for i := 0; i < days; i++ {
+		day := now.AddDate(0, 0, -i)
+		for _, kind := range []string{reviewActivityApprovals, reviewActivityClaims} {
+			counts, err := rdb.ZRangeWithScores(ctx, analyticsReviewersKey(kind, day), 0, -1).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read review activity: %w", err)
+			}
+			for _, z := range counts {
+				userID, ok := z.Member.(string)
+				if !ok {
+					continue
+				}
+				s := scores[userID]
+				if s == nil {
+					s = &ReviewerScore{UserID: userID}
+					scores[userID] = s
+				}
+				if kind == reviewActivityApprovals {
+					s.Approvals += int64(z.Score)
+				} else {
+					s.Claims += int64(z.Score)
+				}
+			}
+		}
+	}
+
+	ranked := make([]ReviewerScore, 0, len(scores))
+	for _, s := range scores {
+		ranked = append(ranked, *s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Total() != ranked[j].Total() {
+			return ranked[i].Total() > ranked[j].Total()
+		}
+		if ranked[i].Approvals != ranked[j].Approvals {
+			return ranked[i].Approvals > ranked[j].Approvals
+		}
+		return ranked[i].UserID < ranked[j].UserID
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked, nil
+}
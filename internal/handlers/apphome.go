@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// subscribeToAppHomeEvents subscribes to the Redis app-home channel and
+// dispatches each event to handleAppHomeOpened.
+func subscribeToAppHomeEvents(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	subscribe(ctx, rdb, config.RedisAppHomeChannel, func(payload string) {
+		handleAppHomeOpened(ctx, rdb, slackClient, payload, config)
+	})
+}
+
+// handleAppHomeOpened publishes a fresh Home tab view for the user, with
+// open PRs in their favorite repos. Admins additionally see a usage stats
+// section, recomputed on every open.
+func handleAppHomeOpened(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	config = withConfigOverrides(ctx, rdb, config)
+	var event AppHomeOpenedEvent
+	if err := envelope.Decode(envelope.SchemaAppHomeOpened, []byte(payload), &event); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling app_home_opened event: %v", err)
+		deadLetter(ctx, rdb, config.RedisAppHomeChannel, payload, err)
+		return
+	}
+
+	if event.Type != "app_home_opened" || event.Tab != "home" || event.User == "" {
+		return
+	}
+
+	refreshHome(ctx, rdb, slackClient, event.User, config)
+}
+
+// publishHomeView renders and publishes the Home tab for userID.
+func publishHomeView(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string, home homeFavorites, config config.Config) {
+	var stats *UsageStats
+	if isAdmin(config, userID) {
+		s, err := loadWeeklyStats(ctx, rdb, time.Now())
+		if err != nil {
+			logging.ErrorContext(ctx, "Error loading usage stats for App Home: %v", err)
+		} else {
+			stats = &s
+		}
+	}
+
+	if _, err := slackClient.PublishViewContext(ctx, slack.PublishViewContextRequest{UserID: userID, View: createHomeView(stats, home, userNow(ctx, rdb, slackClient, userID))}); err != nil {
+		logging.ErrorContext(ctx, "Error publishing App Home view for user %s: %v", userID, err)
+		return
+	}
+
+	logging.DebugContext(ctx, "App Home view published for user %s", userID)
+}
+
+// isAdmin reports whether the Slack user ID is listed in slack.admin_users.
+func isAdmin(config config.Config, userID string) bool {
+	for _, id := range config.SlackAdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
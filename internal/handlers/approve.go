@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const (
+	poppitPRApproveType = "slash-vibe-pr-approve"
+	// poppitPRApproveCheckType looks up a PR's author before it is approved,
+	// so users cannot approve their own PRs under the service's identity.
+	poppitPRApproveCheckType = "slash-vibe-pr-approve-check"
+	approveUsage             = ":warning: Usage: `/pr approve [--dry-run] <repo> <number> [comment]` or `/pr approve [--dry-run] <pull request URL> [comment]`"
+	// maxApproveCommentLength bounds the optional review comment.
+	maxApproveCommentLength = 1000
+)
+
+// approveArgs is the parsed form of /pr approve.
+type approveArgs struct {
+	Repo    string
+	Number  int
+	Comment string
+	DryRun  bool
+}
+
+// parseApproveArgs accepts "<repo> <number> [comment]" or
+// "<pull request URL> [comment]", optionally preceded by --dry-run. The flag
+// is only read up front, so a comment may mention it.
+func parseApproveArgs(text string, config config.Config) (approveArgs, error) {
+	text = strings.TrimSpace(text)
+	dryRun := false
+	if first, rest, _ := strings.Cut(text, " "); first == "--dry-run" {
+		text, dryRun = strings.TrimSpace(rest), true
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return approveArgs{}, fmt.Errorf("missing pull request")
+	}
+
+	target, err := parsePRArgs(fields[0], config)
+	if err != nil {
+		return approveArgs{}, err
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+	if target.Number == 0 {
+		if len(fields) < 2 {
+			return approveArgs{}, fmt.Errorf("missing pull request number")
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+		if err != nil || n <= 0 {
+			return approveArgs{}, fmt.Errorf("invalid pull request number %q", fields[1])
+		}
+		target.Number = n
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[1]))
+	}
+	if len(rest) > maxApproveCommentLength {
+		return approveArgs{}, fmt.Errorf("comment is longer than %d characters", maxApproveCommentLength)
+	}
+
+	return approveArgs{Repo: target.fullRepo(config), Number: target.Number, Comment: rest, DryRun: dryRun}, nil
+}
+
+// approveReviewBody is the review body submitted to GitHub. Approvals run
+// under the service's GitHub identity, so the body records who approved.
+func approveReviewBody(comment, username, login string) string {
+	who := "@" + username
+	if login != "" {
+		who += fmt.Sprintf(" (GitHub: %s)", login)
+	}
+	note := fmt.Sprintf("_Approved from Slack by %s via SlashVibePR._", who)
+	if comment == "" {
+		return note
+	}
+	return comment + "\n\n" + note
+}
+
+// buildPRApproveCommand returns the gh invocation approving a PR.
+func buildPRApproveCommand(repo string, number int, body string) string {
+	return fmt.Sprintf("gh pr review %d --repo %s --approve --body %s", number, repo, shellQuote(body))
+}
+
+// buildPRAuthorCommand returns the gh invocation printing a PR's author.
+func buildPRAuthorCommand(repo string, number int) string {
+	return fmt.Sprintf("gh pr view %d --repo %s --json author", number, repo)
+}
+
+// handleApproveCommand implements /pr approve: it queues a gh approval on
+// Poppit; handlePRApproveOutput reports the result. Like a post's Approve
+// button, it is only open to privileged users.
+func handleApproveCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, text string, config config.Config) {
+	reply := func(msg string) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, msg); err != nil {
+			logging.ErrorContext(ctx, "Error responding to approve for user %s: %v", cmd.UserName, err)
+		}
+	}
+
+	args, err := parseApproveArgs(text, config)
+	if err != nil {
+		reply(fmt.Sprintf(":warning: %s.\n\n%s", capitalize(err.Error()), approveUsage))
+		return
+	}
+	if !isPrivileged(ctx, rdb, slackClient, cmd.UserID, config) {
+		logging.WarnContext(ctx, "Denied approval of %s#%d to unprivileged user %s", args.Repo, args.Number, cmd.UserName)
+		reply(":no_entry: Only privileged users can approve PRs from Slack. Ask an admin to run `/pr admin grant`.")
+		return
+	}
+
+	login := lookupGitHubLogin(ctx, rdb, cmd.UserID, config)
+	body := approveReviewBody(args.Comment, cmd.UserName, login)
+	ghCmd := buildPRApproveCommand(args.Repo, args.Number, body)
+
+	inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID, DryRun: args.DryRun}
+	if isDryRun(inv, config) {
+		logging.InfoContext(ctx, "[dry-run] Approval of %s#%d by %s not queued: %s", args.Repo, args.Number, cmd.UserName, ghCmd)
+		reply(fmt.Sprintf(":test_tube: *Dry run* — would run:\n```%s```", ghCmd))
+		return
+	}
+
+	metadata := inv.metadata()
+	metadata["repo"] = args.Repo
+	metadata["number"] = args.Number
+	metadata["comment"] = args.Comment
+	metadata["response_url"] = cmd.ResponseURL
+	metadata["channel"] = cmd.ChannelID
+
+	if err := queueApproval(ctx, rdb, args.Repo, args.Number, login, metadata, config); err != nil {
+		logging.ErrorContext(ctx, "Error queueing approval of %s#%d: %v", args.Repo, args.Number, err)
+		reply(":x: Failed to queue the approval. Please try again.")
+		return
+	}
+	logging.InfoContext(ctx, "User %s requested approval of %s#%d", cmd.UserName, args.Repo, args.Number)
+}
+
+// queueApproval queues the approval of repo#number described by metadata,
+// which carries the invocation and "comment". When the approver has a linked
+// GitHub login the PR's author is looked up first, and
+// handlePRApproveCheckOutput only queues the approval if it is someone else.
+func queueApproval(ctx context.Context, rdb *redis.Client, repo string, number int, login string, metadata map[string]interface{}, config config.Config) error {
+	cmdType := poppitPRApproveType
+	comment, _ := metadata["comment"].(string)
+	ghCmd := buildPRApproveCommand(repo, number, approveReviewBody(comment, invocationFromMetadata(metadata).Username, login))
+	if login != "" {
+		cmdType, ghCmd = poppitPRApproveCheckType, buildPRAuthorCommand(repo, number)
+		metadata["login"] = login
+	}
+	return pushPoppitCommand(ctx, rdb, poppit.Command{
+		Repo:     repo,
+		Type:     cmdType,
+		Dir:      "/tmp",
+		Commands: []string{ghCmd},
+		Metadata: metadata,
+	}, config)
+}
+
+// handlePRApproveCheckOutput queues the approval held back by queueApproval
+// once the PR's author is known, unless the approver wrote the PR.
+func handlePRApproveCheckOutput(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	repo, _ := output.Metadata["repo"].(string)
+	number := 0
+	if n, ok := output.Metadata["number"].(float64); ok {
+		number = int(n)
+	}
+	login, _ := output.Metadata["login"].(string)
+	responseURL, _ := output.Metadata["response_url"].(string)
+	inv := invocationFromMetadata(output.Metadata)
+	reply := func(msg string) {
+		if err := respondEphemeral(ctx, responseURL, msg); err != nil {
+			logging.ErrorContext(ctx, "Error sending approve feedback: %v", err)
+		}
+	}
+
+	if repo == "" || number == 0 || login == "" {
+		logging.WarnContext(ctx, "Missing repo, number, or login in Poppit approve check metadata")
+		return
+	}
+
+	var pr struct {
+		Author struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	}
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Looking up the author of %s#%d failed: %s", repo, number, output.ErrorText())
+		reply(fmt.Sprintf(":x: Could not approve `%s#%d`:\n```%s```", repo, number, output.ErrorText()))
+		return
+	}
+	if err := json.Unmarshal([]byte(output.Output), &pr); err != nil || pr.Author.Login == "" {
+		logging.ErrorContext(ctx, "Unreadable author of %s#%d in Poppit output: %v", repo, number, err)
+		reply(fmt.Sprintf(":x: Could not approve `%s#%d`: its author could not be read.", repo, number))
+		return
+	}
+	if strings.EqualFold(pr.Author.Login, login) {
+		logging.WarnContext(ctx, "Refused approval of %s#%d by its author %s", repo, number, inv.Username)
+		reply(fmt.Sprintf(":no_entry: You cannot approve `%s#%d`: you opened it.", repo, number))
+		return
+	}
+
+	delete(output.Metadata, "login")
+	comment, _ := output.Metadata["comment"].(string)
+	if err := pushPoppitCommand(ctx, rdb, poppit.Command{
+		Repo:     repo,
+		Type:     poppitPRApproveType,
+		Dir:      "/tmp",
+		Commands: []string{buildPRApproveCommand(repo, number, approveReviewBody(comment, inv.Username, login))},
+		Metadata: output.Metadata,
+	}, config); err != nil {
+		logging.ErrorContext(ctx, "Error queueing approval of %s#%d: %v", repo, number, err)
+		reply(":x: Failed to queue the approval. Please try again.")
+	}
+}
+
+// handlePRApproveOutput confirms an approval to the user and annotates the
+// original post in its thread when the PR was shared recently. Approvals
+// from a post's "Approve" button also edit that post.
+func handlePRApproveOutput(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	repo, _ := output.Metadata["repo"].(string)
+	number := 0
+	if n, ok := output.Metadata["number"].(float64); ok {
+		number = int(n)
+	}
+	comment, _ := output.Metadata["comment"].(string)
+	responseURL, _ := output.Metadata["response_url"].(string)
+	channel, _ := output.Metadata["channel"].(string)
+	inv := invocationFromMetadata(output.Metadata)
+
+	if repo == "" || number == 0 {
+		logging.WarnContext(ctx, "Missing repo or number in Poppit approve metadata")
+		return
+	}
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Approval of %s#%d failed: %s", repo, number, output.ErrorText())
+		msg := fmt.Sprintf(":x: Could not approve `%s#%d`:\n```%s```", repo, number, output.ErrorText())
+		if err := respondEphemeral(ctx, responseURL, msg); err != nil {
+			logging.ErrorContext(ctx, "Error sending approve feedback: %v", err)
+		}
+		return
+	}
+
+	logging.InfoContext(ctx, "User %s approved %s#%d", inv.Username, repo, number)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   auditActionApprove,
+		UserID:   inv.UserID,
+		Username: inv.Username,
+		Repo:     repo,
+		Number:   number,
+		Channel:  channel,
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing approval of %s#%d: %v", repo, number, err)
+	}
+	if inv.UserID != "" {
+		if err := recordReviewActivity(ctx, rdb, reviewActivityApprovals, inv.UserID, time.Now()); err != nil {
+			logging.WarnContext(ctx, "Error recording approval of %s#%d for the leaderboard: %v", repo, number, err)
+		}
+	}
+	if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":white_check_mark: Approved `%s#%d`.", repo, number)); err != nil {
+		logging.ErrorContext(ctx, "Error sending approve feedback: %v", err)
+	}
+	if err := updateClickedPost(ctx, rdb, output.Metadata, fmt.Sprintf(":white_check_mark: Approved by <@%s>", inv.UserID), postApproveActionID); err != nil {
+		logging.WarnContext(ctx, "Error marking %s#%d as approved: %v", repo, number, err)
+	}
+
+	if err := recordFirstReview(ctx, rdb, repo, number, time.Now()); err != nil {
+		logging.WarnContext(ctx, "Error recording review latency for %s#%d: %v", repo, number, err)
+	}
+
+	rec, err := loadPostedPR(ctx, rdb, repo, number)
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logging.WarnContext(ctx, "Error looking up posted PR %s#%d: %v", repo, number, err)
+		}
+		return
+	}
+	if rec.ThreadKey == "" {
+		return
+	}
+	if err := annotateApproval(ctx, rdb, rec, inv.Username, comment, config); err != nil {
+		logging.WarnContext(ctx, "Error annotating approval of %s#%d: %v", repo, number, err)
+	}
+}
+
+// annotateApproval posts a threaded note under the original PR message.
+func annotateApproval(ctx context.Context, rdb *redis.Client, rec *PostedPR, username, comment string, config config.Config) error {
+	text := fmt.Sprintf(":white_check_mark: Approved by @%s from Slack", username)
+	if comment != "" {
+		text += "\n" + quoteSlackText(comment)
+	}
+	msg := SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            rec.Channel,
+		Text:               text,
+		TTL:                config.MessageTTL(),
+		ThreadKey:          rec.ThreadKey,
+		Metadata: map[string]interface{}{
+			"event_type": "pr_approved",
+			"event_payload": map[string]interface{}{
+				"pr_number":   rec.Number,
+				"repository":  rec.Repo,
+				"approved_by": username,
+			},
+		},
+	}
+	stampSlackLinerMetadata(ctx, &msg)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal approval note: %w", err)
+	}
+	if err := pushSlackLiner(ctx, rdb, config, payload); err != nil {
+		return fmt.Errorf("failed to push approval note: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// argFlag describes one --flag accepted by a command. A flag with a
+// non-empty Value takes an argument, given either as the next field or
+// after an equals sign; a flag without one is a boolean switch.
+type argFlag struct {
+	Name string
+	// Value names the flag's argument in error messages, e.g. "a branch
+	// name". Empty for boolean flags.
+	Value string
+	// Arg is the argument's placeholder in the usage text, e.g. "<branch>",
+	// and Help says what the flag does.
+	Arg  string
+	Help string
+	// Set applies the flag to the parsed arguments; value is empty for
+	// boolean flags.
+	Set func(args *prArgs, value string)
+}
+
+// prFlags are the flags accepted by /pr <repo>.
+var prFlags = []argFlag{
+	{Name: "base", Value: "a branch name", Arg: "<branch>",
+		Help: "only PRs targeting `<branch>` (globs like `release/*` work)",
+		Set:  func(a *prArgs, v string) { a.List.Base = v }},
+	{Name: "author", Value: "a GitHub login", Arg: "<login>",
+		Help: "only PRs opened by `<login>`",
+		Set:  func(a *prArgs, v string) { a.List.Author = v }},
+	{Name: "label", Value: "a label name", Arg: "<name>",
+		Help: "only PRs labelled `<name>` (repeat or comma-separate for several)",
+		Set: func(a *prArgs, v string) {
+			a.List.Labels = append(a.List.Labels, strings.Split(v, ",")...)
+		}},
+	{Name: "milestone", Value: "a milestone title", Arg: "<title>",
+		Help: "only PRs in milestone `<title>`",
+		Set:  func(a *prArgs, v string) { a.List.Milestone = v }},
+	{Name: "state", Value: "one of " + strings.Join(prStates, ", "), Arg: "<state>",
+		Help: "list recently " + codeList(prStates[1:], " or ") + " pull requests instead of open ones",
+		Set:  func(a *prArgs, v string) { a.List.State = v }},
+	{Name: "drafts", Help: "show draft pull requests (📝 marks drafts)",
+		Set: func(a *prArgs, _ string) { a.List.HideDrafts = false }},
+	{Name: "no-drafts", Help: "hide draft pull requests",
+		Set: func(a *prArgs, _ string) { a.List.HideDrafts = true }},
+	{Name: "sort", Value: "one of " + strings.Join(prSortOrders, ", "), Arg: "<order>",
+		Help: "order the list (" + codeList(prSortOrders, ", ") + "); your choice is remembered",
+		Set:  func(a *prArgs, v string) { a.List.Sort = v }},
+	{Name: "multi", Help: "pick several pull requests and post them all at once",
+		Set: func(a *prArgs, _ string) { a.Multi = true }},
+	{Name: "dry-run", Help: "preview the message without posting it",
+		Set: func(a *prArgs, _ string) { a.DryRun = true }},
+}
+
+// codeList formats values as inline code joined by sep, for usage text.
+func codeList(values []string, sep string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "`" + v + "`"
+	}
+	return strings.Join(quoted, sep)
+}
+
+// lookupFlag returns the flag called name, if any.
+func lookupFlag(flags []argFlag, name string) (argFlag, bool) {
+	for _, f := range flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return argFlag{}, false
+}
+
+// closingQuotes maps each quote that can open a quoted argument to the quote
+// that closes it. Slack clients often turn typed quotes into curly ones.
+var closingQuotes = map[rune]rune{'"': '"', '\'': '\'', '“': '”', '‘': '’'}
+
+// splitArgs splits command text into fields at whitespace, as a shell
+// would: quoted text is kept in one field without its quotes, so
+// `--milestone "v2.0 release"` gives a single milestone.
+func splitArgs(text string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField := false
+	var closing rune
+	for _, r := range text {
+		switch {
+		case closing != 0:
+			if r == closing {
+				closing = 0
+			} else {
+				field.WriteRune(r)
+			}
+		case closingQuotes[r] != 0:
+			closing, inField = closingQuotes[r], true
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if closing != 0 {
+		return nil, fmt.Errorf("missing closing %c", closing)
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// parseArgs applies the flags in fields to args and returns the remaining
+// positional fields in order. Flags may appear anywhere; "--" ends flag
+// parsing so later fields are positional even if they start with a dash.
+func parseArgs(fields []string, flags []argFlag, args *prArgs) ([]string, error) {
+	var positional []string
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "--" {
+			return append(positional, fields[i+1:]...), nil
+		}
+		if !strings.HasPrefix(field, "-") {
+			positional = append(positional, field)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(field, "--"), "=")
+		flag, ok := lookupFlag(flags, name)
+		if !ok || !strings.HasPrefix(field, "--") {
+			return nil, fmt.Errorf("unknown flag %q", field)
+		}
+		switch {
+		case flag.Value == "" && hasValue:
+			return nil, fmt.Errorf("--%s does not take a value", flag.Name)
+		case flag.Value != "" && !hasValue:
+			if i+1 >= len(fields) || strings.HasPrefix(fields[i+1], "--") {
+				return nil, fmt.Errorf("--%s requires %s", flag.Name, flag.Value)
+			}
+			i++
+			value = fields[i]
+		}
+		if flag.Value != "" && value == "" {
+			return nil, fmt.Errorf("--%s requires %s", flag.Name, flag.Value)
+		}
+		flag.Set(args, value)
+	}
+	return positional, nil
+}
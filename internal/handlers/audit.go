@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// auditStreamKey is the Redis stream every user action is appended to.
+	auditStreamKey = "slashvibepr:audit"
+	// auditScanLimit bounds how far back /pr audit searches the stream, and
+	// maxAuditEntries how many matching entries it shows.
+	auditScanLimit  = 1000
+	maxAuditEntries = 20
+
+	auditUsage = ":warning: Usage: `/pr audit <repo>`"
+)
+
+// Audited actions.
+const (
+	auditActionShare            = "share"
+	auditActionApprove          = "approve"
+	auditActionMerge            = "merge"
+	auditActionRequestReviewers = "request_reviewers"
+	auditActionUnpost           = "unpost"
+)
+
+// AuditEntry is one user action in the audit trail.
+type AuditEntry struct {
+	Action   string
+	UserID   string
+	Username string
+	Repo     string
+	Number   int
+	Channel  string
+	// Detail carries action-specific context, such as requested reviewers.
+	Detail string
+	At     time.Time
+}
+
+// recordAudit appends entry to the audit stream and trims entries older than
+// the configured retention.
+func recordAudit(ctx context.Context, rdb *redis.Client, entry AuditEntry, config config.Config) error {
+	now := time.Now()
+	args := &redis.XAddArgs{
+		Stream: auditStreamKey,
+		Values: map[string]interface{}{
+			"action":  entry.Action,
+			"user_id": entry.UserID,
+			"user":    entry.Username,
+			"repo":    entry.Repo,
+			"number":  entry.Number,
+			"channel": entry.Channel,
+			"detail":  entry.Detail,
+		},
+	}
+	if config.AuditRetention > 0 {
+		args.MinID = strconv.FormatInt(now.Add(-config.AuditRetention).UnixMilli(), 10)
+		args.Approx = true
+	}
+	if err := rdb.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// auditEntryFromMessage decodes a stream message; the entry time comes from
+// the millisecond part of the stream ID.
+func auditEntryFromMessage(msg redis.XMessage) AuditEntry {
+	str := func(field string) string {
+		s, _ := msg.Values[field].(string)
+		return s
+	}
+	entry := AuditEntry{
+		Action:   str("action"),
+		UserID:   str("user_id"),
+		Username: str("user"),
+		Repo:     str("repo"),
+		Channel:  str("channel"),
+		Detail:   str("detail"),
+	}
+	entry.Number, _ = strconv.Atoi(str("number"))
+	if ms, _, ok := strings.Cut(msg.ID, "-"); ok {
+		if n, err := strconv.ParseInt(ms, 10, 64); err == nil {
+			entry.At = time.UnixMilli(n).UTC()
+		}
+	}
+	return entry
+}
+
+// recentAuditEntries returns up to limit of the newest entries for repo.
+func recentAuditEntries(ctx context.Context, rdb *redis.Client, repo string, limit int) ([]AuditEntry, error) {
+	msgs, err := rdb.XRevRangeN(ctx, auditStreamKey, "+", "-", auditScanLimit).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit stream: %w", err)
+	}
+	var entries []AuditEntry
+	for _, msg := range msgs {
+		entry := auditEntryFromMessage(msg)
+		if !strings.EqualFold(entry.Repo, repo) {
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// formatAuditEntries renders entries, newest first, one per line, with times
+// in loc.
+func formatAuditEntries(repo string, entries []AuditEntry, loc *time.Location) string {
+	if len(entries) == 0 {
+		return fmt.Sprintf(":open_file_folder: No recent actions recorded for `%s`.", repo)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, ":ledger: *Recent actions on `%s`:*", repo)
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n• %s — @%s %s #%d", e.At.In(loc).Format("2006-01-02 15:04 MST"), e.Username, e.Action, e.Number)
+		if e.Channel != "" {
+			fmt.Fprintf(&b, " in <#%s>", e.Channel)
+		}
+		if e.Detail != "" {
+			fmt.Fprintf(&b, " (%s)", e.Detail)
+		}
+	}
+	return b.String()
+}
+
+// handleAuditCommand implements /pr audit: show admins the newest audit
+// entries for one repo.
+func handleAuditCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to audit for user %s: %v", cmd.UserName, err)
+		}
+	}
+
+	if !isAdmin(config, cmd.UserID) {
+		reply(":no_entry: Only admins can view the audit trail.")
+		return
+	}
+	if len(fields) != 1 {
+		reply(auditUsage)
+		return
+	}
+	args, err := parsePRArgs(fields[0], config)
+	if err != nil {
+		reply(fmt.Sprintf(":warning: %s.\n\n%s", capitalize(err.Error()), auditUsage))
+		return
+	}
+	repo := args.fullRepo(config)
+
+	entries, err := recentAuditEntries(ctx, rdb, repo, maxAuditEntries)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error reading audit trail for %s: %v", repo, err)
+		reply(":x: Could not read the audit trail. Please try again.")
+		return
+	}
+	reply(formatAuditEntries(repo, entries, userLocation(ctx, rdb, slackClient, cmd.UserID)))
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const (
+	poppitBoardType = "slash-vibe-pr-board"
+	boardUsage      = ":warning: Usage: `/pr board [--public]`"
+	// boardPRLimit caps the open PRs fetched across the channel's repos.
+	boardPRLimit = 100
+	// boardListedPRs is how many PRs, oldest first, the board lists.
+	boardListedPRs = 20
+	// boardFetchTimeout bounds the GitHub search in api mode.
+	boardFetchTimeout = 30 * time.Second
+)
+
+// boardRequest is a /pr board invocation, carried through Poppit metadata
+// while the open PRs are fetched.
+type boardRequest struct {
+	Channel     string
+	Public      bool
+	ResponseURL string
+	Inv         Invocation
+}
+
+// handleBoardCommand implements /pr board: a summary of the PRs shared in
+// the channel that are still open, with their age and review status. It is
+// answered ephemerally, or posted to the channel with --public.
+func handleBoardCommand(ctx context.Context, rdb *redis.Client, _ SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to board for user %s: %v", cmd.UserName, err)
+		}
+	}
+
+	req := boardRequest{
+		Channel:     cmd.ChannelID,
+		ResponseURL: cmd.ResponseURL,
+		Inv:         Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID},
+	}
+	switch {
+	case len(fields) == 1 && fields[0] == "--public":
+		req.Public = true
+	case len(fields) != 0:
+		reply(boardUsage)
+		return
+	}
+
+	posts, err := loadChannelPosts(ctx, rdb, req.Channel)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading posts in %s: %v", req.Channel, err)
+		reply(":x: Could not load the PRs shared here. Please try again.")
+		return
+	}
+	if len(posts) == 0 {
+		reply(emptyBoardText(req.Channel))
+		return
+	}
+
+	search := prSearch{Repos: boardRepos(posts), Limit: boardPRLimit}
+	if !config.UsesGitHubAPI() {
+		metadata := search.metadata()
+		for k, v := range req.Inv.metadata() {
+			metadata[k] = v
+		}
+		metadata["channel"] = req.Channel
+		metadata["public"] = req.Public
+		metadata["response_url"] = req.ResponseURL
+		if err := pushPoppitCommand(ctx, rdb, poppit.Command{
+			Type:     poppitBoardType,
+			Dir:      "/tmp",
+			Commands: []string{search.command(nil, search.limit(config))},
+			Metadata: metadata,
+		}, config); err != nil {
+			logging.ErrorContext(ctx, "Error queueing board for %s: %v", req.Channel, err)
+			reply(":x: Failed to fetch the PRs shared here. Please try again.")
+		}
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, boardFetchTimeout)
+	defer cancel()
+	prs, err := newGitHubClientFromConfig(config).searchPullRequests(fetchCtx, search.apiQuery(nil), boardPRLimit)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error fetching PRs for the board of %s: %v", req.Channel, err)
+		reply(":x: Failed to fetch the PRs shared here. Please try again.")
+		return
+	}
+	sendBoard(ctx, rdb, req, posts, prs, config)
+}
+
+// handleBoardOutput sends the board once Poppit has listed the open PRs.
+func handleBoardOutput(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	req := boardRequest{Inv: invocationFromMetadata(output.Metadata)}
+	req.Channel, _ = output.Metadata["channel"].(string)
+	req.Public, _ = output.Metadata["public"].(bool)
+	req.ResponseURL, _ = output.Metadata["response_url"].(string)
+	if req.Channel == "" {
+		logging.WarnContext(ctx, "Poppit board output is missing its channel")
+		return
+	}
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Listing board PRs for %s failed: %s", req.Channel, output.ErrorText())
+		if err := respondEphemeral(ctx, req.ResponseURL, fmt.Sprintf(":x: Could not fetch the PRs shared here:\n```%s```", output.ErrorText())); err != nil {
+			logging.ErrorContext(ctx, "Error sending board feedback: %v", err)
+		}
+		return
+	}
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &prs); err != nil {
+		logging.ErrorContext(ctx, "Error parsing board PRs for %s: %v", req.Channel, err)
+		return
+	}
+	posts, err := loadChannelPosts(ctx, rdb, req.Channel)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading posts in %s: %v", req.Channel, err)
+		return
+	}
+	sendBoard(ctx, rdb, req, posts, prs, config)
+}
+
+// boardRepos returns the distinct repos of posts.
+func boardRepos(posts []PostedPR) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, rec := range posts {
+		if !seen[rec.Repo] {
+			seen[rec.Repo] = true
+			repos = append(repos, rec.Repo)
+		}
+	}
+	return repos
+}
+
+// openBoardPosts returns the posts whose PR is among the open prs.
+func openBoardPosts(posts []PostedPR, prs []PRItem) []PostedPR {
+	open := make(map[string]bool, len(prs))
+	for _, pr := range prs {
+		open[postRef(pr.Repository.NameWithOwner, pr.Number)] = true
+	}
+	var out []PostedPR
+	for _, rec := range posts {
+		if open[postRef(rec.Repo, rec.Number)] {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// sendBoard answers a /pr board request with the still-open posts:
+// ephemerally, or as a channel message when it is public.
+func sendBoard(ctx context.Context, rdb *redis.Client, req boardRequest, posts []PostedPR, prs []PRItem, config config.Config) {
+	text := buildBoardText(req.Channel, openBoardPosts(posts, prs), time.Now())
+	if !req.Public {
+		if err := respondEphemeral(ctx, req.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error sending board to user %s: %v", req.Inv.Username, err)
+		}
+		return
+	}
+
+	msg := SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            req.Channel,
+		Text:               text,
+		TTL:                config.MessageTTL(),
+		Metadata: map[string]interface{}{
+			"event_type": "pr_board",
+			"event_payload": map[string]interface{}{
+				"channel":      req.Channel,
+				"requested_by": req.Inv.Username,
+			},
+		},
+	}
+	stampSlackLinerMetadata(ctx, &msg)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling board: %v", err)
+		return
+	}
+	if isDryRun(req.Inv, config) {
+		logging.InfoContext(ctx, "[dry-run] Board for %s not pushed: %s", req.Channel, payload)
+		if err := respondEphemeral(ctx, req.ResponseURL, ":test_tube: *Dry run* — this board would have been posted:\n\n"+text); err != nil {
+			logging.ErrorContext(ctx, "Error sending board to user %s: %v", req.Inv.Username, err)
+		}
+		return
+	}
+	if err := pushSlackLiner(ctx, rdb, config, payload); err != nil {
+		logging.ErrorContext(ctx, "Error pushing board for %s: %v", req.Channel, err)
+		return
+	}
+	logging.InfoContext(ctx, "User %s posted the board of %s", req.Inv.Username, req.Channel)
+}
+
+// emptyBoardText is the board of a channel with no open shared PRs.
+func emptyBoardText(channel string) string {
+	return fmt.Sprintf(":tada: No open PRs have been shared in <#%s> in the last %s.", channel, boardWindow())
+}
+
+// boardWindow is how far back the board looks: as long as posts are kept
+// in the posted-PR index.
+func boardWindow() string {
+	return fmt.Sprintf("%d days", int(postedPRTTL/(24*time.Hour)))
+}
+
+// buildBoardText lists open posts oldest first, each with its age and who
+// is reviewing or has reviewed it.
+func buildBoardText(channel string, posts []PostedPR, now time.Time) string {
+	if len(posts) == 0 {
+		return emptyBoardText(channel)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, ":clipboard: *Review board for <#%s>* — %d open PRs shared in the last %s", channel, len(posts), boardWindow())
+	for i, rec := range posts {
+		if i == boardListedPRs {
+			fmt.Fprintf(&b, "\n…and %d more", len(posts)-boardListedPRs)
+			break
+		}
+		link := fmt.Sprintf("%s#%d", rec.Repo, rec.Number)
+		if rec.URL != "" {
+			link = fmt.Sprintf("<%s|%s>", rec.URL, link)
+		}
+		status := rec.reviewStatus()
+		if status == "" {
+			status = "_awaiting review_"
+		}
+		fmt.Fprintf(&b, "\n• %s %s — shared %s ago by @%s · %s", link, escapeSlackText(rec.Title), slackui.FormatAge(now.Sub(rec.PostedAt)), rec.PostedBy, status)
+	}
+	return b.String()
+}
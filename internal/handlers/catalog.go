@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const (
+	repoCatalogKeyPrefix   = "slashvibepr:catalog:"
+	memberCatalogKeyPrefix = "slashvibepr:members:"
+	repoCatalogTTL         = time.Hour
+	// repoCatalogRefreshLockTTL stops concurrent suggestion requests from
+	// queueing duplicate refreshes while one is in flight.
+	repoCatalogRefreshLockTTL = time.Minute
+	// repoCatalogLimit caps how many repos (or members) are fetched per org.
+	repoCatalogLimit = 1000
+	// repoCatalogFetchTimeout keeps an inline API refresh within Slack's
+	// three-second budget for options requests.
+	repoCatalogFetchTimeout = 2 * time.Second
+
+	poppitRepoListType   = "slash-vibe-repo-list"
+	poppitMemberListType = "slash-vibe-member-list"
+)
+
+// orgCatalog describes a cached, per-org list of names and how to refresh
+// it through Poppit or the GitHub API.
+type orgCatalog struct {
+	// name is used in log and error messages, e.g. "repo".
+	name       string
+	keyPrefix  string
+	poppitType string
+	command    func(org string) string
+	parse      func(output string) ([]string, error)
+	fetch      func(ctx context.Context, c *githubClient, org string) ([]string, error)
+}
+
+// repoCatalogKind caches an org's active repository names.
+var repoCatalogKind = orgCatalog{
+	name:       "repo",
+	keyPrefix:  repoCatalogKeyPrefix,
+	poppitType: poppitRepoListType,
+	command:    buildRepoListCommand,
+	parse:      parseRepoListOutput,
+	fetch: func(ctx context.Context, c *githubClient, org string) ([]string, error) {
+		return c.listOrgRepos(ctx, org, repoCatalogLimit)
+	},
+}
+
+// memberCatalogKind caches an org's member logins for the reviewer picker.
+var memberCatalogKind = orgCatalog{
+	name:       "member",
+	keyPrefix:  memberCatalogKeyPrefix,
+	poppitType: poppitMemberListType,
+	command:    buildMemberListCommand,
+	parse:      parseMemberListOutput,
+	fetch: func(ctx context.Context, c *githubClient, org string) ([]string, error) {
+		return c.listOrgMembers(ctx, org, repoCatalogLimit)
+	},
+}
+
+// key returns the Redis key holding the cached names for org.
+func (k orgCatalog) key(org string) string {
+	return k.keyPrefix + org
+}
+
+// save caches the names for org for repoCatalogTTL.
+func (k orgCatalog) save(ctx context.Context, rdb *redis.Client, org string, names []string) error {
+	sort.Strings(names)
+	data, err := json.Marshal(names)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s catalog: %w", k.name, err)
+	}
+	if err := rdb.Set(ctx, k.key(org), data, repoCatalogTTL).Err(); err != nil {
+		return fmt.Errorf("failed to store %s catalog: %w", k.name, err)
+	}
+	return nil
+}
+
+// load returns the cached names for org. It returns redis.Nil (wrapped) when
+// the catalog has expired or was never fetched.
+func (k orgCatalog) load(ctx context.Context, rdb *redis.Client, org string) ([]string, error) {
+	data, err := rdb.Get(ctx, k.key(org)).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s catalog: %w", k.name, err)
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse %s catalog: %w", k.name, err)
+	}
+	return names, nil
+}
+
+// get returns the names for org, refreshing the cache when it is empty. In
+// api mode the refresh runs inline so the first request is answered; in
+// poppit mode a gh command is queued and this request gets no options, with
+// later keystrokes served from the filled cache.
+func (k orgCatalog) get(ctx context.Context, rdb *redis.Client, org string, config config.Config) ([]string, error) {
+	names, err := k.load(ctx, rdb, org)
+	if err == nil {
+		return names, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	locked, err := rdb.SetNX(ctx, k.key(org)+":refreshing", 1, repoCatalogRefreshLockTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take %s catalog refresh lock: %w", k.name, err)
+	}
+	if !locked {
+		return nil, nil
+	}
+
+	if !config.UsesGitHubAPI() {
+		logging.InfoContext(ctx, "%s catalog for %s is empty, queueing refresh via Poppit", k.name, org)
+		return nil, pushPoppitCommand(ctx, rdb, poppit.Command{
+			Type:     k.poppitType,
+			Dir:      "/tmp",
+			Commands: []string{k.command(org)},
+			Metadata: map[string]interface{}{"org": org},
+		}, config)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, repoCatalogFetchTimeout)
+	defer cancel()
+	names, err = k.fetch(fetchCtx, newGitHubClientFromConfig(config), org)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.save(ctx, rdb, org, names); err != nil {
+		logging.WarnContext(ctx, "Error caching %s catalog for %s: %v", k.name, org, err)
+	}
+	return names, nil
+}
+
+// handleOutput caches the names returned by the catalog's Poppit command.
+func (k orgCatalog) handleOutput(ctx context.Context, rdb *redis.Client, output poppit.Output) {
+	org, _ := output.Metadata["org"].(string)
+	if org == "" {
+		logging.WarnContext(ctx, "Poppit %s list output has no org in metadata", k.name)
+		return
+	}
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Listing %s for org %s failed: %s", k.name, org, output.ErrorText())
+		return
+	}
+
+	names, err := k.parse(output.Output)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error parsing %s list for org %s: %v", k.name, org, err)
+		return
+	}
+
+	if err := k.save(ctx, rdb, org, names); err != nil {
+		logging.ErrorContext(ctx, "Error caching %s catalog for %s: %v", k.name, org, err)
+		return
+	}
+	logging.InfoContext(ctx, "Cached %d %ss for org %s", len(names), k.name, org)
+}
+
+// repoCatalog returns the cached repos for org; see orgCatalog.get.
+func repoCatalog(ctx context.Context, rdb *redis.Client, org string, config config.Config) ([]string, error) {
+	return repoCatalogKind.get(ctx, rdb, org, config)
+}
+
+// memberCatalog returns the cached member logins for org; see orgCatalog.get.
+func memberCatalog(ctx context.Context, rdb *redis.Client, org string, config config.Config) ([]string, error) {
+	return memberCatalogKind.get(ctx, rdb, org, config)
+}
+
+// buildRepoListCommand returns the gh command listing an org's active repos.
+func buildRepoListCommand(org string) string {
+	return fmt.Sprintf("gh repo list %s --no-archived --json name --limit %d", org, repoCatalogLimit)
+}
+
+// parseRepoListOutput extracts repo names from `gh repo list --json name`.
+func parseRepoListOutput(output string) ([]string, error) {
+	var items []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &items); err != nil {
+		return nil, fmt.Errorf("failed to parse repo list JSON: %w", err)
+	}
+	repos := make([]string, 0, len(items))
+	for _, item := range items {
+		repos = append(repos, item.Name)
+	}
+	return repos, nil
+}
+
+// buildMemberListCommand returns the gh command listing an org's member
+// logins, one per line.
+func buildMemberListCommand(org string) string {
+	return fmt.Sprintf("gh api --paginate orgs/%s/members --jq '.[].login'", org)
+}
+
+// parseMemberListOutput splits member logins, one per line, dropping
+// anything that is not a valid GitHub login.
+func parseMemberListOutput(output string) ([]string, error) {
+	var logins []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if validGitHubLogin.MatchString(line) && len(logins) < repoCatalogLimit {
+			logins = append(logins, line)
+		}
+	}
+	return logins, nil
+}
+
+// filterReposByQuery returns repos containing query, case-insensitively,
+// with prefix matches first, capped at maxSuggestionOptions.
+func filterReposByQuery(repos []string, query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var prefix, contains []string
+	for _, repo := range repos {
+		name := strings.ToLower(repo)
+		switch {
+		case strings.HasPrefix(name, query):
+			prefix = append(prefix, repo)
+		case strings.Contains(name, query):
+			contains = append(contains, repo)
+		}
+	}
+	matches := append(prefix, contains...)
+	if len(matches) > maxSuggestionOptions {
+		matches = matches[:maxSuggestionOptions]
+	}
+	return matches
+}
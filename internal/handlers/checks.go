@@ -0,0 +1,86 @@
+package handlers
+
+// CheckState summarises a PR's CI status across all of its checks.
+type CheckState int
+
+const (
+	CheckStateNone CheckState = iota
+	CheckStatePassing
+	CheckStatePending
+	CheckStateFailing
+)
+
+// CheckStatus is one entry of gh's statusCheckRollup. Check runs report
+// Status/Conclusion; legacy commit statuses report State and use Context as
+// their name.
+type CheckStatus struct {
+	TypeName   string `json:"__typename"`
+	Name       string `json:"name"`
+	Context    string `json:"context"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	State      string `json:"state"`
+	// IsRequired is only known for checks fetched with the PR readiness;
+	// gh's statusCheckRollup does not report it.
+	IsRequired bool `json:"isRequired,omitempty"`
+}
+
+// name returns the check run's name, or the commit status's context.
+func (c CheckStatus) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Context
+}
+
+// state classifies a single check.
+func (c CheckStatus) state() CheckState {
+	if c.TypeName == "StatusContext" || (c.State != "" && c.Status == "") {
+		switch c.State {
+		case "SUCCESS":
+			return CheckStatePassing
+		case "FAILURE", "ERROR":
+			return CheckStateFailing
+		default:
+			return CheckStatePending
+		}
+	}
+
+	if c.Status != "" && c.Status != "COMPLETED" {
+		return CheckStatePending
+	}
+	switch c.Conclusion {
+	case "SUCCESS", "NEUTRAL", "SKIPPED":
+		return CheckStatePassing
+	case "FAILURE", "TIMED_OUT", "CANCELLED", "ACTION_REQUIRED", "STARTUP_FAILURE":
+		return CheckStateFailing
+	default:
+		return CheckStatePending
+	}
+}
+
+// checkState rolls all checks up into one state: any failure wins, then any
+// pending check, otherwise passing. PRs without checks report CheckStateNone.
+func (pr PRItem) checkState() CheckState {
+	overall := CheckStateNone
+	for _, c := range pr.StatusCheckRollup {
+		if s := c.state(); s > overall {
+			overall = s
+		}
+	}
+	return overall
+}
+
+// checkStateEmoji returns the prefix shown for a check state in the chooser.
+func checkStateEmoji(state CheckState) string {
+	switch state {
+	case CheckStatePassing:
+		return "✅"
+	case CheckStatePending:
+		return "🟡"
+	case CheckStateFailing:
+		return "❌"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const (
+	// poppitChunkSeqField and poppitChunkTotalField are the Poppit metadata
+	// fields marking one chunk of an output split across several messages:
+	// its 1-based sequence number and how many chunks there are. Every
+	// chunk carries the command ID of the command it answers.
+	poppitChunkSeqField   = "chunk_seq"
+	poppitChunkTotalField = "chunk_total"
+	// poppitChunkTTL is how long the chunks of an incomplete output are
+	// kept waiting for the rest.
+	poppitChunkTTL = 10 * time.Minute
+	// maxPoppitChunks bounds chunk_total so a bad message cannot make the
+	// buffer grow without limit.
+	maxPoppitChunks = 1000
+)
+
+// poppitChunksKey is the Redis hash buffering the chunks of one Poppit
+// output, keyed by sequence number.
+func poppitChunksKey(commandID string) string {
+	return "slashvibepr:poppit:chunks:" + commandID
+}
+
+// metadataInt reads a whole number from Poppit metadata, which arrives as a
+// JSON number or, from some producers, a string.
+func metadataInt(metadata map[string]interface{}, field string) (int, bool) {
+	switch v := metadata[field].(type) {
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// reassemblePoppitOutput buffers a chunk of a chunked Poppit output in Redis.
+// Once every chunk has arrived it returns the whole output, with the chunks'
+// Output concatenated in sequence order, and true. An output that is not
+// chunked is returned as is. It reports false while chunks are missing.
+func reassemblePoppitOutput(ctx context.Context, rdb *redis.Client, output poppit.Output) (poppit.Output, bool, error) {
+	seq, chunked := metadataInt(output.Metadata, poppitChunkSeqField)
+	total, hasTotal := metadataInt(output.Metadata, poppitChunkTotalField)
+	if !chunked && !hasTotal {
+		return output, true, nil
+	}
+	if !chunked || !hasTotal || total < 1 || total > maxPoppitChunks || seq < 1 || seq > total {
+		return output, false, fmt.Errorf("invalid chunk %v of %v", output.Metadata[poppitChunkSeqField], output.Metadata[poppitChunkTotalField])
+	}
+	commandID, _ := output.Metadata[poppitCommandIDField].(string)
+	if commandID == "" {
+		return output, false, fmt.Errorf("chunk %d of %d has no %s", seq, total, poppitCommandIDField)
+	}
+	if total == 1 {
+		return output, true, nil
+	}
+
+	chunk, err := json.Marshal(output)
+	if err != nil {
+		return output, false, fmt.Errorf("failed to marshal chunk: %w", err)
+	}
+	key := poppitChunksKey(commandID)
+	var received *redis.IntCmd
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, strconv.Itoa(seq), chunk)
+		pipe.Expire(ctx, key, poppitChunkTTL)
+		received = pipe.HLen(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return output, false, fmt.Errorf("failed to buffer chunk %d of %d: %w", seq, total, err)
+	}
+	if received.Val() < int64(total) {
+		return output, false, nil
+	}
+
+	chunks, err := rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return output, false, fmt.Errorf("failed to read buffered chunks: %w", err)
+	}
+	whole, err := joinPoppitChunks(chunks, total)
+	if err != nil {
+		return output, false, err
+	}
+	rdb.Del(ctx, key)
+	return whole, true, nil
+}
+
+// joinPoppitChunks concatenates buffered chunks, keyed by sequence number,
+// into one output. The metadata is the first chunk's without the chunk
+// fields; the exit code and stderr come from the last chunk that reports
+// them.
+func joinPoppitChunks(chunks map[string]string, total int) (poppit.Output, error) {
+	var whole poppit.Output
+	var out strings.Builder
+	for seq := 1; seq <= total; seq++ {
+		data, ok := chunks[strconv.Itoa(seq)]
+		if !ok {
+			return whole, fmt.Errorf("chunk %d of %d is missing", seq, total)
+		}
+		var chunk poppit.Output
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return whole, fmt.Errorf("failed to parse chunk %d of %d: %w", seq, total, err)
+		}
+		if seq == 1 {
+			whole = chunk
+			whole.Metadata = make(map[string]interface{}, len(chunk.Metadata))
+			for k, v := range chunk.Metadata {
+				if k != poppitChunkSeqField && k != poppitChunkTotalField {
+					whole.Metadata[k] = v
+				}
+			}
+		}
+		out.WriteString(chunk.Output)
+		if chunk.ExitCode != nil {
+			whole.ExitCode = chunk.ExitCode
+		}
+		if chunk.Stderr != "" {
+			whole.Stderr = chunk.Stderr
+		}
+	}
+	whole.Output = out.String()
+	return whole, nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const postClaimActionID = "post_claim"
+
+// claimReviewButton returns the "Claim review" button for a posted PR; its
+// value is the PR's postRef.
+func claimReviewButton(repo string, number int) *slack.ButtonBlockElement {
+	return slack.NewButtonBlockElement(postClaimActionID, postRef(repo, number),
+		slack.NewTextBlockObject(slack.PlainTextType, "🙋 Claim review", false, false))
+}
+
+// handleClaimReview requests a review on the PR from the clicker's linked
+// GitHub login and replaces the "Claim review" button on the post with a note
+// saying who claimed it.
+func handleClaimReview(ctx context.Context, rdb *redis.Client, action BlockActionPayload, value string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, action.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to review claim for user %s: %v", action.User.Username, err)
+		}
+	}
+
+	repo, number, err := parsePostRef(value)
+	if err != nil {
+		logging.WarnContext(ctx, "Ignoring review claim from user %s: %v", action.User.Username, err)
+		return
+	}
+	login := lookupGitHubLogin(ctx, rdb, action.User.ID, config)
+	if login == "" {
+		reply(":link: Link your GitHub account with `/pr whoami link <github-login>` to claim reviews.")
+		return
+	}
+
+	inv := Invocation{UserID: action.User.ID, Username: action.User.Username, TeamID: action.Team.ID}
+	if err := requestReviewers(ctx, rdb, &PRItem{Number: number}, repo, []string{login}, inv, config); err != nil {
+		logging.ErrorContext(ctx, "Error claiming review of %s#%d for user %s: %v", repo, number, action.User.Username, err)
+		reply(":x: Could not claim the review. Please try again.")
+		return
+	}
+	if isDryRun(inv, config) {
+		reply(fmt.Sprintf(":test_tube: *Dry run* — a review of %s#%d would have been requested from `%s`.", repo, number, login))
+		return
+	}
+	logging.InfoContext(ctx, "User %s claimed the review of %s#%d as %s", action.User.Username, repo, number, login)
+	if err := recordReviewActivity(ctx, rdb, reviewActivityClaims, action.User.ID, time.Now()); err != nil {
+		logging.WarnContext(ctx, "Error recording review claim of %s#%d for the leaderboard: %v", repo, number, err)
+	}
+	if err := recordFirstReview(ctx, rdb, repo, number, time.Now()); err != nil {
+		logging.WarnContext(ctx, "Error recording review latency for %s#%d: %v", repo, number, err)
+	}
+
+	if len(action.Message.Blocks.BlockSet) == 0 {
+		return
+	}
+	if err := slack.PostWebhookContext(ctx, action.ResponseURL, &slack.WebhookMessage{
+		ReplaceOriginal: true,
+		Text:            action.Message.Text,
+		Blocks: &slack.Blocks{BlockSet: annotatePostBlocks(action.Message.Blocks.BlockSet,
+			fmt.Sprintf(":raising_hand: Review claimed by <@%s>", action.User.ID), postClaimActionID)},
+	}); err != nil {
+		logging.ErrorContext(ctx, "Error marking %s#%d as claimed: %v", repo, number, err)
+	}
+}
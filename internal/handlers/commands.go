@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// usageLine is one form of a command shown in the usage text: the command
+// text after "/pr" and what it does. Example, when set, is a concrete
+// invocation listed under the examples in `/pr help`.
+type usageLine struct {
+	Form    string
+	Help    string
+	Example string
+}
+
+// subcommand is a /pr subcommand such as `/pr fav`. The registry returned by
+// prSubcommands drives both dispatch in handleSlashCommand and the usage
+// text, so the two cannot drift apart.
+type subcommand struct {
+	Name  string
+	Usage []usageLine
+	// Run handles the subcommand; text is the command text after its name,
+	// with surrounding spaces trimmed.
+	Run func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, text string, config config.Config)
+}
+
+// prForms are the forms of /pr that list or post pull requests, shown in the
+// usage text before the flags and subcommands.
+var prForms = []usageLine{
+	{Form: "", Help: "choose a repository, then a pull request"},
+	{Form: "<repo>", Help: "list open pull requests for `<repo>`", Example: "my-service"},
+	{Form: "<org>/<repo>", Help: "same, for a repository outside the default organisation", Example: "other-org/shared-lib --base release/*"},
+	{Form: "<pull request URL>", Help: "post that pull request straight away", Example: "https://github.com/my-org/my-service/pull/123"},
+}
+
+// prSubcommands returns the /pr subcommands in the order the usage text
+// lists them. It is a function rather than a variable because `help` refers
+// back to the registry.
+func prSubcommands() []subcommand {
+	return []subcommand{
+		{Name: "mine", Usage: []usageLine{{Form: "mine", Help: "choose from your own open pull requests across the organisation"}},
+			Run: func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, _ string, config config.Config) {
+				handleMineCommand(ctx, rdb, slackClient, cmd, config)
+			}},
+		{Name: "search", Usage: []usageLine{{Form: "search <query>", Help: "search pull requests with GitHub search syntax", Example: "search label:bug is:open"}},
+			Run: handleSearchCommand},
+		{Name: "status", Usage: []usageLine{{Form: "status <repo> <number>", Help: "post a compact status card for one pull request"}},
+			Run: withFields(handleStatusCommand)},
+		{Name: "unpost", Usage: []usageLine{{Form: "unpost <repo> <number>", Help: "retract a PR you shared"}},
+			Run: withFields(handleUnpostCommand)},
+		{Name: "board", Usage: []usageLine{{Form: "board [--public]", Help: "open pull requests shared in this channel, with their age and review status"}},
+			Run: withFields(handleBoardCommand)},
+		{Name: "stats", Usage: []usageLine{{Form: "stats [repo]", Help: "posting activity over the last 30 days"}},
+			Run: withFields(handleStatsCommand)},
+		{Name: "leaderboard", Usage: []usageLine{{Form: "leaderboard [--public]", Help: "reviewers with the most approvals and claimed reviews this week"}},
+			Run: withFields(handleLeaderboardCommand)},
+		{Name: "audit", Usage: []usageLine{{Form: "audit <repo>", Help: "recent actions on a repo (admins only)"}},
+			Run: withFields(handleAuditCommand)},
+		{Name: "admin", Usage: []usageLine{{Form: "admin", Help: "manage roles, config, failed payloads, and sessions (admins only); lists its commands"}},
+			Run: withFields(withoutSlack(handleAdminCommand))},
+		{Name: "approve", Usage: []usageLine{{Form: "approve [--dry-run] <repo> <number> [comment]", Help: "approve a pull request"}},
+			Run: func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, text string, config config.Config) {
+				handleApproveCommand(ctx, rdb, slackClient, cmd, text, config)
+			}},
+		{Name: "reviews", Usage: []usageLine{{Form: "reviews", Help: "pull requests waiting for your review"}},
+			Run: func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, _ string, config config.Config) {
+				handleReviewsCommand(ctx, rdb, slackClient, cmd, config)
+			}},
+		{Name: "settings", Usage: []usageLine{{Form: "settings", Help: "set your default repo, sort order, drafts setting, and posting channel"}},
+			Run: func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, _ string, config config.Config) {
+				handleSettingsCommand(ctx, rdb, slackClient, cmd, config)
+			}},
+		{Name: "fav", Usage: []usageLine{{Form: "fav", Help: "list your favorite repos (`/pr fav add <repo>`, `/pr fav rm <repo>`)", Example: "fav add my-service"}},
+			Run: withFields(withoutSlack(handleFavCommand))},
+		{Name: "whoami", Usage: []usageLine{
+			{Form: "whoami link <github-login>", Help: "link your GitHub account for @mentions"},
+			{Form: "whoami verify", Help: "finish linking once the challenge gist is published"},
+		},
+			Run: withFields(withoutSlack(handleWhoamiCommand))},
+		{Name: "help", Usage: []usageLine{{Form: "help", Help: "show this list"}},
+			Run: func(ctx context.Context, _ *redis.Client, _ SlackClient, cmd SlackCommand, _ string, _ config.Config) {
+				respondWithUsage(ctx, cmd, "")
+			}},
+	}
+}
+
+// withFields adapts a subcommand handler that takes the text as fields.
+func withFields(run func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, fields []string, config config.Config)) func(context.Context, *redis.Client, SlackClient, SlackCommand, string, config.Config) {
+	return func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, text string, config config.Config) {
+		run(ctx, rdb, slackClient, cmd, strings.Fields(text), config)
+	}
+}
+
+// withoutSlack adapts a subcommand handler that only replies through the
+// response URL and so needs no Slack client.
+func withoutSlack(run func(ctx context.Context, rdb *redis.Client, cmd SlackCommand, fields []string, config config.Config)) func(context.Context, *redis.Client, SlackClient, SlackCommand, []string, config.Config) {
+	return func(ctx context.Context, rdb *redis.Client, _ SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+		run(ctx, rdb, cmd, fields, config)
+	}
+}
+
+// parseCommand splits /pr command text into the subcommand named by its
+// first word and the text after it. ok is false when the first word is not
+// a subcommand, in which case the text is the repo form parsed by
+// parsePRArgs.
+func parseCommand(text string) (sub subcommand, rest string, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return subcommand{}, "", false
+	}
+	for _, s := range prSubcommands() {
+		if s.Name == fields[0] {
+			return s, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), fields[0])), true
+		}
+	}
+	return subcommand{}, "", false
+}
+
+// unknownSubcommand reports whether text starts with a word that is neither
+// a subcommand nor usable as a repo, i.e. a bare word followed by further
+// positional arguments, such as `/pr serach foo`. It returns that word.
+func unknownSubcommand(text string) (string, bool) {
+	fields, err := splitArgs(text)
+	if err != nil {
+		return "", false
+	}
+	positional, err := parseArgs(fields, prFlags, &prArgs{})
+	if err != nil || len(positional) < 2 || positional[0] != fields[0] || strings.Contains(fields[0], "/") {
+		return "", false
+	}
+	return fields[0], true
+}
+
+// prUsage returns the plain-text usage shown as the notification fallback of
+// the `/pr help` message, generated from prForms, prFlags, and
+// prSubcommands.
+func prUsage() string {
+	var b strings.Builder
+	b.WriteString("*Usage:*")
+	line := func(form, help string) {
+		b.WriteString("\n• `/pr")
+		if form != "" {
+			b.WriteString(" " + form)
+		}
+		b.WriteString("` — " + help)
+	}
+	for _, u := range prForms {
+		line(u.Form, u.Help)
+	}
+	for _, f := range prFlags {
+		line("<repo> --"+strings.TrimSpace(f.Name+" "+f.Arg), f.Help)
+	}
+	for _, s := range prSubcommands() {
+		for _, u := range s.Usage {
+			line(u.Form, u.Help)
+		}
+	}
+	return b.String()
+}
+
+// prUsageBlocks renders the `/pr help` message from the same registry as
+// prUsage: the forms, flags, subcommands, and examples in separate sections.
+// A non-empty notice, such as a parse error, is shown above them.
+func prUsageBlocks(notice string) []slack.Block {
+	var blocks []slack.Block
+	section := func(text string) {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+	}
+	if notice != "" {
+		section(":warning: " + notice)
+	}
+	blocks = append(blocks, slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "/pr usage", false, false)))
+
+	var forms, flags, subs, examples strings.Builder
+	line := func(b *strings.Builder, form, help string) {
+		fmt.Fprintf(b, "\n• `%s` — %s", strings.TrimSpace("/pr "+form), help)
+	}
+	example := func(u usageLine) {
+		if u.Example != "" {
+			fmt.Fprintf(&examples, "\n`/pr %s`", u.Example)
+		}
+	}
+	for _, u := range prForms {
+		line(&forms, u.Form, u.Help)
+		example(u)
+	}
+	for _, f := range prFlags {
+		line(&flags, "<repo> --"+strings.TrimSpace(f.Name+" "+f.Arg), f.Help)
+	}
+	for _, s := range prSubcommands() {
+		for _, u := range s.Usage {
+			line(&subs, u.Form, u.Help)
+			example(u)
+		}
+	}
+	section("*Pull requests*" + forms.String())
+	section("*Flags*" + flags.String())
+	section("*Subcommands*" + subs.String())
+	section("*Examples*" + examples.String())
+	return blocks
+}
+
+// respondWithUsage sends the `/pr help` message to the invoking user, with
+// notice above it when the command could not be understood.
+func respondWithUsage(ctx context.Context, cmd SlackCommand, notice string) {
+	if cmd.ResponseURL == "" {
+		return
+	}
+	text := prUsage()
+	if notice != "" {
+		text = ":warning: " + notice + "\n\n" + text
+	}
+	msg := &slack.WebhookMessage{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+		Blocks:       &slack.Blocks{BlockSet: prUsageBlocks(notice)},
+	}
+	if err := slack.PostWebhookContext(ctx, cmd.ResponseURL, msg); err != nil {
+		logging.ErrorContext(ctx, "Error sending usage to user %s: %v", cmd.UserName, err)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// serveDebug serves net/http/pprof, and the expvar gauges at /debug/vars, on
+// config.PprofAddr until ctx is done. The profiles expose internals, so the
+// address should be loopback-only or otherwise kept off the network.
+func serveDebug(ctx context.Context, config config.Config) {
+	srv := &http.Server{
+		Addr:              config.PprofAddr,
+		Handler:           debugRoutes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logging.WarnContext(ctx, "Error shutting down pprof server: %v", err)
+		}
+	}()
+
+	logging.InfoContext(ctx, "Serving pprof on %s", config.PprofAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.ErrorContext(ctx, "pprof server stopped: %v", err)
+	}
+}
+
+// debugRoutes registers the pprof handlers on their own mux rather than
+// http.DefaultServeMux, so they are never exposed by another server.
+func debugRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
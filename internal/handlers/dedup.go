@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// poppitCommandIDField is the Poppit metadata field carrying the ID
+	// stamped on each command, which its output echoes back.
+	poppitCommandIDField = "command_id"
+	// poppitHandledTTL is how long a handled command ID is remembered;
+	// duplicates arriving later than this are handled again.
+	poppitHandledTTL = 24 * time.Hour
+)
+
+// poppitHandledKey records that the output of one Poppit command was handled.
+func poppitHandledKey(commandID string) string {
+	return "slashvibepr:poppit:handled:" + commandID
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// claimPoppitOutput records the command ID in metadata with SETNX so that a
+// duplicate delivery of the same output, or a stream replay of one already
+// handled, is skipped. It reports false for a duplicate; the returned func
+// forgets the claim so the output can be handled again. Output without a
+// command ID, from commands enqueued before IDs were stamped, is always
+// handled, as is any output when Redis cannot be reached.
+func claimPoppitOutput(ctx context.Context, rdb *redis.Client, metadata map[string]interface{}) (func(), bool) {
+	id, _ := metadata[poppitCommandIDField].(string)
+	if id == "" {
+		return func() {}, true
+	}
+	key := poppitHandledKey(id)
+	ok, err := rdb.SetNX(ctx, key, 1, poppitHandledTTL).Result()
+	if err != nil {
+		logging.WarnContext(ctx, "Error recording Poppit command %s as handled, handling it anyway: %v", id, err)
+		return func() {}, true
+	}
+	release := func() {
+		if err := rdb.Del(context.WithoutCancel(ctx), key).Err(); err != nil {
+			logging.WarnContext(ctx, "Error forgetting handled Poppit command %s: %v", id, err)
+		}
+	}
+	return release, ok
+}
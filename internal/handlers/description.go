@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Markdown constructs rewritten when the PR description is quoted in a post.
+var (
+	markdownComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markdownFence     = regexp.MustCompile("(?s)```.*?```")
+	markdownImage     = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLink      = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownHTMLTag   = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	markdownHeading   = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+`)
+	markdownCheckbox  = regexp.MustCompile(`(?m)^([ \t]*)[-*+] \[[ xX]\] `)
+	markdownQuote     = regexp.MustCompile(`(?m)^[ \t]{0,3}>[ \t]?`)
+	markdownRule      = regexp.MustCompile(`(?m)^[ \t]{0,3}[-*_]([ \t]*[-*_]){2,}[ \t]*$`)
+	markdownBold      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`)
+	markdownBlankRuns = regexp.MustCompile(`\n{3,}`)
+)
+
+// descriptionSnippet turns the start of a PR description from GitHub
+// markdown into text for a mrkdwn quote: comments left by PR templates, code
+// blocks, images, and HTML are dropped, links keep their text, headings and
+// checkboxes lose their markers, and bold becomes mrkdwn bold. The result is
+// cut to at most maxLen characters at a word boundary. It is not escaped;
+// quoteSlackText does that.
+func descriptionSnippet(body string, maxLen int) string {
+	text := strings.ReplaceAll(body, "\r\n", "\n")
+	text = markdownComment.ReplaceAllString(text, "")
+	text = markdownFence.ReplaceAllString(text, "")
+	text = markdownImage.ReplaceAllString(text, "")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownHTMLTag.ReplaceAllString(text, "")
+	text = markdownRule.ReplaceAllString(text, "")
+	text = markdownHeading.ReplaceAllString(text, "")
+	text = markdownCheckbox.ReplaceAllString(text, "$1- ")
+	text = markdownQuote.ReplaceAllString(text, "")
+	text = markdownBold.ReplaceAllString(text, "*$1*")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	text = markdownBlankRuns.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	text = strings.TrimSpace(text)
+
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+	cut := maxLen
+	for i := maxLen; i > maxLen/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
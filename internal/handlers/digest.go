@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const (
+	poppitDigestType = "slash-vibe-digest"
+	// digestClaimKeyPrefix marks a schedule as run for a given minute so
+	// only one replica posts each digest, even across a leader handover.
+	digestClaimKeyPrefix = "slashvibepr:digest:"
+	digestClaimTTL       = 2 * time.Hour
+	// digestPRLimit caps the PRs fetched per digest (one search page).
+	digestPRLimit = 100
+	// digestListedPRs is how many PRs, oldest first, are linked in a digest.
+	digestListedPRs = 15
+	// digestFetchTimeout bounds the inline API search in api mode.
+	digestFetchTimeout = 30 * time.Second
+)
+
+// runDigests checks the digest schedules at the top of every minute until
+// ctx is cancelled, skipping minutes while this replica is not the leader.
+// It does nothing when no schedules are configured.
+func runDigests(ctx context.Context, rdb *redis.Client, leader *leaderElection, config config.Config) {
+	if len(config.DigestSchedules) == 0 {
+		return
+	}
+	logging.InfoContext(ctx, "PR digests enabled with %d schedule(s)", len(config.DigestSchedules))
+
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case t := <-timer.C:
+			if leader.IsLeader() {
+				runDueDigests(ctx, rdb, t.In(config.DigestTimezone).Truncate(time.Minute), config)
+			}
+		}
+	}
+}
+
+// runDueDigests starts every schedule matching minute that no other replica
+// has claimed.
+func runDueDigests(ctx context.Context, rdb *redis.Client, minute time.Time, config config.Config) {
+	config = withConfigOverrides(ctx, rdb, config)
+	for i, d := range config.DigestSchedules {
+		if !d.Schedule.Matches(minute) {
+			continue
+		}
+		claim := fmt.Sprintf("%s%d:%d", digestClaimKeyPrefix, i, minute.Unix())
+		ok, err := rdb.SetNX(ctx, claim, 1, digestClaimTTL).Result()
+		if err != nil {
+			logging.ErrorContext(ctx, "Error claiming digest for %s: %v", d.Channel, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		startDigest(ctx, rdb, d, config)
+	}
+}
+
+// startDigest fetches the open PRs for a schedule: inline in api mode, or
+// through a queued `gh search prs` handled by handleDigestOutput.
+func startDigest(ctx context.Context, rdb *redis.Client, d config.DigestSchedule, config config.Config) {
+	search := prSearch{Repos: d.Repos, Limit: digestPRLimit}
+	if !config.UsesGitHubAPI() {
+		metadata := search.metadata()
+		metadata["channel"] = d.Channel
+		err := pushPoppitCommand(ctx, rdb, poppit.Command{
+			Type:     poppitDigestType,
+			Dir:      "/tmp",
+			Commands: []string{search.command(nil, search.limit(config))},
+			Metadata: metadata,
+		}, config)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error queueing digest for %s: %v", d.Channel, err)
+		}
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, digestFetchTimeout)
+	defer cancel()
+	prs, err := newGitHubClientFromConfig(config).searchPullRequests(fetchCtx, search.apiQuery(nil), digestPRLimit)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error fetching PRs for digest in %s: %v", d.Channel, err)
+		return
+	}
+	postDigest(ctx, rdb, d.Channel, d.Repos, prs, config)
+}
+
+// handleDigestOutput posts the digest for PRs found by Poppit.
+func handleDigestOutput(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	channel, _ := output.Metadata["channel"].(string)
+	search := prSearchFromMetadata(output.Metadata)
+	if channel == "" || len(search.Repos) == 0 {
+		logging.WarnContext(ctx, "Poppit digest output is missing its channel or repos")
+		return
+	}
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Listing digest PRs for %s failed: %s", channel, output.ErrorText())
+		return
+	}
+
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &prs); err != nil {
+		logging.ErrorContext(ctx, "Error parsing digest PRs for %s: %v", channel, err)
+		return
+	}
+	postDigest(ctx, rdb, channel, search.Repos, prs, config)
+}
+
+// postDigest pushes the digest message to SlackLiner, or logs it in dry-run mode.
+func postDigest(ctx context.Context, rdb *redis.Client, channel string, repos []string, prs []PRItem, config config.Config) {
+	msg := buildDigestMessage(channel, repos, prs, time.Now().In(config.DigestTimezone), config)
+	stampSlackLinerMetadata(ctx, &msg)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling digest: %v", err)
+		return
+	}
+	if config.DryRun {
+		logging.InfoContext(ctx, "[dry-run] Digest for %s not pushed: %s", channel, payload)
+		return
+	}
+	if err := pushSlackLiner(ctx, rdb, config, payload); err != nil {
+		logging.ErrorContext(ctx, "Error pushing digest for %s: %v", channel, err)
+		return
+	}
+	logging.InfoContext(ctx, "Posted digest of %d open PRs to %s", len(prs), channel)
+}
+
+// buildDigestMessage summarises open PRs: a count per repo, the oldest PR,
+// and links to the oldest digestListedPRs. Dates are shown in now's timezone,
+// digests.timezone.
+func buildDigestMessage(channel string, repos []string, prs []PRItem, now time.Time, config config.Config) SlackLinerMessage {
+	sorted := make([]PRItem, len(prs))
+	copy(sorted, prs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	counts := make(map[string]int, len(repos))
+	for _, pr := range sorted {
+		counts[pr.repoOr("")]++
+	}
+
+	var b strings.Builder
+	total := fmt.Sprintf("%d", len(sorted))
+	if len(sorted) >= digestPRLimit {
+		total += "+"
+	}
+	fmt.Fprintf(&b, ":newspaper: *Open PR digest* — %s open across %d repos\n", total, len(repos))
+	for _, repo := range repos {
+		fmt.Fprintf(&b, "• `%s`: %d\n", repo, counts[repo])
+	}
+
+	if len(sorted) == 0 {
+		b.WriteString("\n:tada: Nothing waiting.")
+	} else {
+		oldest := sorted[0]
+		fmt.Fprintf(&b, "\n*Oldest:* %s (opened %s)\n", digestPRLink(oldest), slackui.FormatOpened(oldest.CreatedAt, now))
+		for i, pr := range sorted {
+			if i == digestListedPRs {
+				fmt.Fprintf(&b, "…and %d more\n", len(sorted)-digestListedPRs)
+				break
+			}
+			fmt.Fprintf(&b, "• %s — %s, %s\n", digestPRLink(pr), pr.Author.Login, slackui.FormatAge(now.Sub(pr.CreatedAt)))
+		}
+	}
+
+	return SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            channel,
+		Text:               strings.TrimRight(b.String(), "\n"),
+		TTL:                config.MessageTTL(),
+		Metadata: map[string]interface{}{
+			"event_type": "pr_digest_posted",
+			"event_payload": map[string]interface{}{
+				"repositories": repos,
+				"open_count":   len(sorted),
+				"counts":       counts,
+			},
+		},
+	}
+}
+
+// digestPRLink renders "<url|repo#n> title" for a digest line.
+func digestPRLink(pr PRItem) string {
+	return fmt.Sprintf("<%s|%s#%d> %s", pr.URL, pr.repoOr(""), pr.Number, escapeSlackText(pr.Title))
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// dlqStreamKey is the Redis stream failed payloads are parked in, capped
+	// at roughly dlqMaxLen entries.
+	dlqStreamKey = "slashvibepr:dlq"
+	dlqMaxLen    = 1000
+	// maxDLQListed is how many entries /pr admin dlq shows, and
+	// dlqPreviewLength how much of each payload.
+	maxDLQListed     = 10
+	dlqPreviewLength = 120
+)
+
+// deadLetter parks a payload that could not be handled, with the feed it
+// came from and why, so an admin can inspect and replay it.
+func deadLetter(ctx context.Context, rdb *redis.Client, source, payload string, cause error) {
+	err := rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: dlqStreamKey,
+		MaxLen: dlqMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"source":  source,
+			"payload": payload,
+			"error":   cause.Error(),
+		},
+	}).Err()
+	if err != nil {
+		logging.ErrorContext(ctx, "Error dead-lettering payload from %s: %v", source, err)
+		return
+	}
+	logging.WarnContext(ctx, "Dead-lettered payload from %s: %v", source, cause)
+}
+
+// handleRecovering runs handle, dead-lettering the payload if it panics.
+func handleRecovering(ctx context.Context, rdb *redis.Client, source, payload string, handle func(payload string)) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.ErrorContext(ctx, "Panic handling payload from %s: %v", source, r)
+			deadLetter(ctx, rdb, source, payload, fmt.Errorf("panic: %v", r))
+		}
+	}()
+	handle(payload)
+}
+
+// dlqEntry is one dead-lettered payload.
+type dlqEntry struct {
+	ID      string
+	Source  string
+	Payload string
+	Error   string
+}
+
+func dlqEntryFromMessage(msg redis.XMessage) dlqEntry {
+	str := func(field string) string {
+		s, _ := msg.Values[field].(string)
+		return s
+	}
+	return dlqEntry{ID: msg.ID, Source: str("source"), Payload: str("payload"), Error: str("error")}
+}
+
+// formatDLQEntries renders entries, newest first, with truncated payloads.
+func formatDLQEntries(entries []dlqEntry) string {
+	if len(entries) == 0 {
+		return ":white_check_mark: The dead-letter queue is empty."
+	}
+	var b strings.Builder
+	b.WriteString(":rotating_light: *Dead-lettered payloads* (replay with `/pr admin replay <id>`):")
+	for _, e := range entries {
+		preview := e.Payload
+		if len(preview) > dlqPreviewLength {
+			preview = preview[:dlqPreviewLength] + "…"
+		}
+		fmt.Fprintf(&b, "\n• `%s` from `%s`: %s\n```%s```", e.ID, e.Source, e.Error, preview)
+	}
+	return b.String()
+}
+
+// listDLQ returns the newest dead-lettered payloads.
+func listDLQ(ctx context.Context, rdb *redis.Client, limit int64) ([]dlqEntry, error) {
+	msgs, err := rdb.XRevRangeN(ctx, dlqStreamKey, "+", "-", limit).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter queue: %w", err)
+	}
+	entries := make([]dlqEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		entries = append(entries, dlqEntryFromMessage(msg))
+	}
+	return entries, nil
+}
+
+// replayableSources returns the feeds a dead-lettered payload can be sent
+// back to, and whether each is read as a stream.
+func replayableSources(config config.Config) map[string]bool {
+	streams := config.UsesStreams()
+	return map[string]bool{
+		config.RedisChannel:                 streams,
+		config.RedisViewSubmissionChannel:   streams,
+		config.RedisBlockActionsChannel:     streams,
+		config.RedisPoppitOutputChannel:     streams,
+		config.RedisBlockSuggestionsChannel: false,
+		config.RedisAppHomeChannel:          false,
+		config.RedisLinkSharedChannel:       false,
+		config.RedisReactionAddedChannel:    false,
+	}
+}
+
+// replayDLQ republishes one dead-lettered payload to the feed it came from
+// and removes it from the queue.
+func replayDLQ(ctx context.Context, rdb *redis.Client, id string, config config.Config) (dlqEntry, error) {
+	msgs, err := rdb.XRange(ctx, dlqStreamKey, id, id).Result()
+	if err != nil {
+		return dlqEntry{}, fmt.Errorf("failed to read dead-letter entry: %w", err)
+	}
+	if len(msgs) == 0 {
+		return dlqEntry{}, fmt.Errorf("no dead-letter entry %s", id)
+	}
+	entry := dlqEntryFromMessage(msgs[0])
+
+	stream, ok := replayableSources(config)[entry.Source]
+	if !ok {
+		return entry, fmt.Errorf("cannot replay to unknown source %q", entry.Source)
+	}
+	if err := Publish(ctx, rdb, entry.Source, entry.Payload, stream); err != nil {
+		return entry, fmt.Errorf("failed to replay dead-letter entry: %w", err)
+	}
+	if err := rdb.XDel(ctx, dlqStreamKey, id).Err(); err != nil {
+		return entry, fmt.Errorf("failed to remove replayed entry: %w", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	favoritesKeyPrefix = "slashvibepr:favorites:"
+	// maxFavorites matches the number of buttons one actions block can hold.
+	maxFavorites = 25
+
+	favBlockID       = "fav_block"
+	favRepoActionID  = "fav_repo"
+	favUsage         = ":warning: Usage: `/pr fav`, `/pr fav add <repo>`, or `/pr fav rm <repo>`"
+	favEmptyReply    = "You have no favorite repos yet. Add one with `/pr fav add <repo>`."
+	favListIntroText = "*Your favorite repos* — pick one to list its open pull requests:"
+)
+
+// favoritesKey returns the Redis set holding a user's favorite repos.
+func favoritesKey(userID string) string {
+	return favoritesKeyPrefix + userID
+}
+
+// loadFavorites returns the user's favorite repos ("owner/name"), sorted.
+func loadFavorites(ctx context.Context, rdb *redis.Client, userID string) ([]string, error) {
+	repos, err := rdb.SMembers(ctx, favoritesKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load favorites: %w", err)
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// handleFavCommand implements /pr fav, /pr fav add <repo>, and /pr fav rm <repo>.
+func handleFavCommand(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) {
+	if len(args) == 0 {
+		respondWithFavorites(ctx, rdb, cmd)
+		return
+	}
+
+	var reply string
+	if len(args) != 2 || (args[0] != "add" && args[0] != "rm") {
+		reply = favUsage
+	} else if parsed, err := parsePRArgs(args[1], config); err != nil || parsed.Repo == "" || parsed.Number != 0 {
+		reply = fmt.Sprintf(":warning: `%s` is not a valid repository.", args[1])
+	} else {
+		reply = updateFavorite(ctx, rdb, cmd, args[0], parsed.fullRepo(config))
+	}
+
+	if err := respondEphemeral(ctx, cmd.ResponseURL, reply); err != nil {
+		logging.ErrorContext(ctx, "Error responding to fav for user %s: %v", cmd.UserName, err)
+	}
+}
+
+// updateFavorite adds or removes repo and returns the reply for the user.
+func updateFavorite(ctx context.Context, rdb *redis.Client, cmd SlackCommand, op, repo string) string {
+	key := favoritesKey(cmd.UserID)
+
+	if op == "rm" {
+		removed, err := rdb.SRem(ctx, key, repo).Result()
+		if err != nil {
+			logging.ErrorContext(ctx, "Error removing favorite for user %s: %v", cmd.UserName, err)
+			return ":x: Failed to update your favorites. Please try again."
+		}
+		if removed == 0 {
+			return fmt.Sprintf("`%s` is not one of your favorites.", repo)
+		}
+		logging.InfoContext(ctx, "User %s removed favorite %s", cmd.UserName, repo)
+		return fmt.Sprintf(":white_check_mark: Removed `%s` from your favorites.", repo)
+	}
+
+	count, err := rdb.SCard(ctx, key).Result()
+	if err != nil {
+		logging.ErrorContext(ctx, "Error counting favorites for user %s: %v", cmd.UserName, err)
+		return ":x: Failed to update your favorites. Please try again."
+	}
+	if count >= maxFavorites {
+		return fmt.Sprintf(":warning: You already have %d favorites. Remove one with `/pr fav rm <repo>` first.", maxFavorites)
+	}
+	if err := rdb.SAdd(ctx, key, repo).Err(); err != nil {
+		logging.ErrorContext(ctx, "Error adding favorite for user %s: %v", cmd.UserName, err)
+		return ":x: Failed to update your favorites. Please try again."
+	}
+	logging.InfoContext(ctx, "User %s added favorite %s", cmd.UserName, repo)
+	return fmt.Sprintf(":star: Added `%s` to your favorites.", repo)
+}
+
+// respondWithFavorites lists the user's favorites as buttons that open the
+// PR chooser for that repo.
+func respondWithFavorites(ctx context.Context, rdb *redis.Client, cmd SlackCommand) {
+	repos, err := loadFavorites(ctx, rdb, cmd.UserID)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading favorites for user %s: %v", cmd.UserName, err)
+		if err := respondEphemeral(ctx, cmd.ResponseURL, ":x: Failed to load your favorites. Please try again."); err != nil {
+			logging.ErrorContext(ctx, "Error responding to fav for user %s: %v", cmd.UserName, err)
+		}
+		return
+	}
+
+	msg := &slack.WebhookMessage{ResponseType: slack.ResponseTypeEphemeral, Text: favEmptyReply}
+	if len(repos) > 0 {
+		msg.Text = favListIntroText
+		msg.Blocks = &slack.Blocks{BlockSet: favoritesBlocks(repos)}
+	}
+	if cmd.ResponseURL == "" {
+		return
+	}
+	if err := slack.PostWebhookContext(ctx, cmd.ResponseURL, msg); err != nil {
+		logging.ErrorContext(ctx, "Error responding to fav for user %s: %v", cmd.UserName, err)
+	}
+}
+
+// favoritesBlocks renders the favorites list: an intro line and one button
+// per repo, each carrying the full repo path as its value.
+func favoritesBlocks(repos []string) []slack.Block {
+	buttons := make([]slack.BlockElement, 0, len(repos))
+	for i, repo := range repos {
+		label := repo
+		if _, name, ok := strings.Cut(repo, "/"); ok {
+			label = name
+		}
+		buttons = append(buttons, slack.NewButtonBlockElement(
+			// Action IDs must be unique within a block.
+			fmt.Sprintf("%s_%d", favRepoActionID, i), repo,
+			slack.NewTextBlockObject(slack.PlainTextType, label, false, false),
+		))
+	}
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, favListIntroText, false, false), nil, nil),
+		slack.NewActionBlock(favBlockID, buttons...),
+	}
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// featureKeyPrefix prefixes the Redis hash of overrides for one feature
+	// flag. Its fields are "all", "team:<id>", and "channel:<id>", each "on"
+	// or "off".
+	featureKeyPrefix = "slashvibepr:feature:"
+
+	// The flags are aliased for handlers, where the config parameter
+	// shadows the config package.
+	featureApproveMerge  = config.FeatureApproveMerge
+	featureBlockKitPosts = config.FeatureBlockKitPosts
+	featureClaimReview   = config.FeatureClaimReview
+	featureMultiSelect   = config.FeatureMultiSelect
+	featureRemindMe      = config.FeatureRemindMe
+)
+
+// featureTargetPattern matches the scope of /pr admin feature: a channel
+// mention (<#C123|name> or <#C123>) or bare channel ID, or a team ID.
+var featureTargetPattern = regexp.MustCompile(`^(?:<#([CG][A-Z0-9]+)(?:\|[^>]*)?>|([CG][A-Z0-9]+)|(T[A-Z0-9]+))$`)
+
+// featureScope is where a request comes from: its Slack workspace and the
+// channel it concerns. Either may be empty when unknown.
+type featureScope struct {
+	Team    string
+	Channel string
+}
+
+// featureKey returns the Redis hash holding name's overrides.
+func featureKey(name string) string {
+	return featureKeyPrefix + name
+}
+
+// fields returns the override fields that apply to the scope, most specific
+// first.
+func (s featureScope) fields() []string {
+	var fields []string
+	if s.Channel != "" {
+		fields = append(fields, "channel:"+s.Channel)
+	}
+	if s.Team != "" {
+		fields = append(fields, "team:"+s.Team)
+	}
+	return append(fields, "all")
+}
+
+// withFeatureFlags returns config with every known feature flag resolved for
+// scope, so config.FeatureEnabled answers for this request. A Redis override
+// for the channel wins over one for the workspace, which wins over one for
+// all workspaces, which wins over features: in config.yaml. When Redis
+// cannot be read the flags are resolved from config.yaml alone.
+func withFeatureFlags(ctx context.Context, rdb *redis.Client, scope featureScope, cfg config.Config) config.Config {
+	names := config.FeatureNames()
+	overrides := make([]map[string]string, len(names))
+	if rdb != nil {
+		pipe := rdb.Pipeline()
+		cmds := make([]*redis.MapStringStringCmd, len(names))
+		for i, name := range names {
+			cmds[i] = pipe.HGetAll(ctx, featureKey(name))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			logging.WarnContext(ctx, "Error reading feature flag overrides: %v", err)
+		} else {
+			for i, cmd := range cmds {
+				overrides[i] = cmd.Val()
+			}
+		}
+	}
+
+	resolved := make(map[string]config.FeatureFlag, len(names))
+	for i, name := range names {
+		enabled := cfg.Feature(name).EnabledFor(scope.Team, scope.Channel)
+		for _, field := range scope.fields() {
+			if v, ok := overrides[i][field]; ok {
+				enabled = v == "on"
+				break
+			}
+		}
+		resolved[name] = config.FeatureFlag{Enabled: enabled}
+	}
+	cfg.Features = resolved
+	return cfg
+}
+
+// parseFeatureTarget parses the optional scope of /pr admin feature into its
+// override field: a channel, a team, or nothing for every workspace.
+func parseFeatureTarget(args []string) (string, bool) {
+	switch len(args) {
+	case 0:
+		return "all", true
+	case 1:
+		m := featureTargetPattern.FindStringSubmatch(args[0])
+		switch {
+		case m == nil:
+			return "", false
+		case m[3] != "":
+			return "team:" + m[3], true
+		}
+		return "channel:" + m[1] + m[2], true
+	}
+	return "", false
+}
+
+// runAdminFeature implements /pr admin feature, which lists the feature
+// flags, and /pr admin feature <name> on|off|reset [<#channel>|<team>],
+// which overrides or clears a flag for every workspace, one workspace, or
+// one channel.
+func runAdminFeature(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, cfg config.Config) string {
+	if len(args) == 0 {
+		return formatFeatureFlags(ctx, rdb, cfg)
+	}
+	if len(args) < 2 {
+		return ""
+	}
+	name, op := args[0], args[1]
+	if !config.KnownFeatures[name] {
+		return fmt.Sprintf(":x: Unknown feature `%s`. Known features: %s.", name, codeList(config.FeatureNames(), ", "))
+	}
+	field, ok := parseFeatureTarget(args[2:])
+	if !ok {
+		return ""
+	}
+
+	var err error
+	switch op {
+	case "on", "off":
+		err = rdb.HSet(ctx, featureKey(name), field, op).Err()
+	case "reset":
+		err = rdb.HDel(ctx, featureKey(name), field).Err()
+	default:
+		return ""
+	}
+	if err != nil {
+		logging.ErrorContext(ctx, "Error setting feature %s %s for %s: %v", name, op, field, err)
+		return ":x: Could not update the feature flag. Please try again."
+	}
+
+	detail := fmt.Sprintf("%s %s %s", name, op, field)
+	logging.InfoContext(ctx, "User %s set feature %s", cmd.UserName, detail)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   "feature",
+		UserID:   cmd.UserID,
+		Username: cmd.UserName,
+		Detail:   detail,
+	}, cfg); err != nil {
+		logging.WarnContext(ctx, "Error auditing feature change of %s: %v", name, err)
+	}
+	if op == "reset" {
+		return fmt.Sprintf(":white_check_mark: `%s` for %s is back to its config.yaml value.", name, describeFeatureField(field))
+	}
+	return fmt.Sprintf(":white_check_mark: `%s` is now %s for %s.", name, op, describeFeatureField(field))
+}
+
+// describeFeatureField renders an override field for replies.
+func describeFeatureField(field string) string {
+	switch {
+	case strings.HasPrefix(field, "channel:"):
+		return "<#" + strings.TrimPrefix(field, "channel:") + ">"
+	case strings.HasPrefix(field, "team:"):
+		return "workspace `" + strings.TrimPrefix(field, "team:") + "`"
+	}
+	return "every workspace"
+}
+
+// formatFeatureFlags lists each feature flag's config.yaml setting and its
+// Redis overrides.
+func formatFeatureFlags(ctx context.Context, rdb *redis.Client, cfg config.Config) string {
+	var b strings.Builder
+	b.WriteString("*Feature flags*")
+	for _, name := range config.FeatureNames() {
+		flag := cfg.Feature(name)
+		state := "off"
+		switch {
+		case flag.Enabled:
+			state = "on"
+		case len(flag.Workspaces) > 0 || len(flag.Channels) > 0:
+			state = "on for " + strings.Join(append(append([]string{}, flag.Workspaces...), flag.Channels...), ", ")
+		}
+		fmt.Fprintf(&b, "\n• `%s` — %s in config.yaml", name, state)
+
+		overrides, err := rdb.HGetAll(ctx, featureKey(name)).Result()
+		if err != nil {
+			logging.ErrorContext(ctx, "Error reading overrides of feature %s: %v", name, err)
+			return ":x: Could not read the feature flags. Please try again."
+		}
+		fields := make([]string, 0, len(overrides))
+		for field := range overrides {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Fprintf(&b, "; %s for %s", overrides[field], describeFeatureField(field))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+)
+
+// githubClient is a minimal GitHub REST client covering the pull request
+// endpoints used in github.mode: api.
+type githubClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	// tokenFunc, when set, supplies the token per request instead of token.
+	tokenFunc func(ctx context.Context) (string, error)
+}
+
+// newGitHubClient returns a client for the given API root. token may be empty
+// for public repositories, at the cost of a much lower rate limit.
+func newGitHubClient(baseURL, token string) *githubClient {
+	if baseURL == "" {
+		baseURL = config.DefaultGitHubAPIURL
+	}
+	return &githubClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// newGitHubClientFromConfig returns a client authenticated with whichever
+// credential the config provides, see githubAuthToken.
+func newGitHubClientFromConfig(config config.Config) *githubClient {
+	c := newGitHubClient(config.GitHubAPIURL, config.GitHubToken)
+	if config.UsesGitHubApp() {
+		c.tokenFunc = func(ctx context.Context) (string, error) {
+			return githubAuthToken(ctx, config)
+		}
+	}
+	return c
+}
+
+// githubPullRequest is the subset of the REST pull request object we read.
+// The REST API does not expose check rollups or the review decision, so
+// those fields stay empty on PRItems fetched in API mode.
+type githubPullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	Body    string `json:"body"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Head struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	State        string     `json:"state"`
+	MergedAt     *time.Time `json:"merged_at"`
+	Draft        bool       `json:"draft"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Additions    int        `json:"additions"`
+	Deletions    int        `json:"deletions"`
+	ChangedFiles int        `json:"changed_files"`
+	// Mergeable and MergeableState are only returned when fetching a single
+	// PR; Mergeable is null while GitHub is still computing it.
+	Mergeable      *bool  `json:"mergeable"`
+	MergeableState string `json:"mergeable_state"`
+	Labels         []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	RequestedReviewers []struct {
+		Login string `json:"login"`
+	} `json:"requested_reviewers"`
+	RequestedTeams []struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"requested_teams"`
+}
+
+// toPRItem converts a REST pull request into the shape produced by gh CLI.
+func (p githubPullRequest) toPRItem() PRItem {
+	pr := PRItem{
+		Number:       p.Number,
+		Title:        p.Title,
+		URL:          p.HTMLURL,
+		HeadRefName:  p.Head.Ref,
+		BaseRefName:  p.Base.Ref,
+		CreatedAt:    p.CreatedAt,
+		Body:         p.Body,
+		Additions:    p.Additions,
+		Deletions:    p.Deletions,
+		ChangedFiles: p.ChangedFiles,
+		IsDraft:      p.Draft,
+		Labels:       p.Labels,
+		Milestone:    p.Milestone,
+		// REST reports the same states as GraphQL, in lower case.
+		MergeStateStatus: strings.ToUpper(p.MergeableState),
+		State:            strings.ToUpper(p.State),
+	}
+	if p.MergedAt != nil {
+		pr.State = "MERGED"
+	}
+	if p.Mergeable != nil {
+		pr.Mergeable = "CONFLICTING"
+		if *p.Mergeable {
+			pr.Mergeable = "MERGEABLE"
+		}
+	}
+	pr.Author.Login = p.User.Login
+	for _, u := range p.RequestedReviewers {
+		pr.ReviewRequests = append(pr.ReviewRequests, ReviewRequest{TypeName: "User", Login: u.Login})
+	}
+	for _, t := range p.RequestedTeams {
+		pr.ReviewRequests = append(pr.ReviewRequests, ReviewRequest{TypeName: "Team", Name: t.Name, Slug: t.Slug})
+	}
+	return pr
+}
+
+// githubMaxPerPage is the largest page size the REST API accepts.
+const githubMaxPerPage = 100
+
+// listPullRequests returns up to limit PRs for repo ("owner/name"),
+// following pagination. A literal opts.Base is passed to GitHub; glob
+// patterns are left to filterPRsByBase, and since the pulls API cannot filter
+// by author, label, or draft status, those options are left to filterPRs.
+// opts.Sort maps to the sort and direction parameters.
+func (c *githubClient) listPullRequests(ctx context.Context, repo string, opts PRListOptions, limit int) ([]PRItem, error) {
+	q := url.Values{}
+	q.Set("state", "open")
+	if opts.State == "closed" || opts.State == "merged" {
+		// The pulls API has no merged state; merged PRs are picked out of
+		// the closed ones by filterPRs.
+		q.Set("state", "closed")
+	}
+	q.Set("per_page", strconv.Itoa(min(limit, githubMaxPerPage)))
+	if opts.Base != "" && !opts.basePattern() {
+		q.Set("base", opts.Base)
+	}
+	if opts.Sort != "" {
+		field, direction := splitPRSort(opts.Sort)
+		q.Set("sort", field)
+		q.Set("direction", direction)
+	}
+
+	var prs []PRItem
+	for page := 1; len(prs) < limit; page++ {
+		q.Set("page", strconv.Itoa(page))
+		var raw []githubPullRequest
+		if err := c.get(ctx, "/repos/"+repo+"/pulls?"+q.Encode(), &raw); err != nil {
+			return nil, err
+		}
+		for _, p := range raw {
+			if len(prs) < limit {
+				prs = append(prs, p.toPRItem())
+			}
+		}
+		if len(raw) < min(limit, githubMaxPerPage) {
+			break
+		}
+	}
+	return prs, nil
+}
+
+// getPullRequest returns a single PR by number.
+func (c *githubClient) getPullRequest(ctx context.Context, repo string, number int) (*PRItem, error) {
+	var raw githubPullRequest
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), &raw); err != nil {
+		return nil, err
+	}
+	pr := raw.toPRItem()
+	return &pr, nil
+}
+
+// reviewStatus reports whether the PR is still open and how many reviews
+// (of any kind) it has received, up to 100.
+func (c *githubClient) reviewStatus(ctx context.Context, repo string, number int) (open bool, reviews int, err error) {
+	var pr githubPullRequest
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), &pr); err != nil {
+		return false, 0, err
+	}
+	var raw []struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=100", repo, number), &raw); err != nil {
+		return false, 0, err
+	}
+	return pr.State == "open", len(raw), nil
+}
+
+// searchPullRequests runs an issue search (query should include is:pr) and
+// returns up to limit results, following pagination. Search hits lack branch
+// names, so only the repository, author, and summary fields are populated.
+func (c *githubClient) searchPullRequests(ctx context.Context, query string, limit int) ([]PRItem, error) {
+	q := url.Values{}
+	q.Set("q", query)
+	q.Set("per_page", strconv.Itoa(min(limit, githubMaxPerPage)))
+
+	var prs []PRItem
+	for page := 1; len(prs) < limit; page++ {
+		q.Set("page", strconv.Itoa(page))
+		var raw struct {
+			Items []struct {
+				githubPullRequest
+				RepositoryURL string `json:"repository_url"`
+			} `json:"items"`
+		}
+		if err := c.get(ctx, "/search/issues?"+q.Encode(), &raw); err != nil {
+			return nil, err
+		}
+		for _, item := range raw.Items {
+			if len(prs) == limit {
+				break
+			}
+			pr := item.toPRItem()
+			// repository_url is {api}/repos/{owner}/{name}.
+			if _, repo, ok := strings.Cut(item.RepositoryURL, "/repos/"); ok {
+				pr.Repository.NameWithOwner = repo
+			}
+			prs = append(prs, pr)
+		}
+		if len(raw.Items) < min(limit, githubMaxPerPage) {
+			break
+		}
+	}
+	return prs, nil
+}
+
+// listOrgRepos returns the names of up to limit non-archived repos in org,
+// following pagination.
+func (c *githubClient) listOrgRepos(ctx context.Context, org string, limit int) ([]string, error) {
+	var names []string
+	for page := 1; len(names) < limit; page++ {
+		var raw []struct {
+			Name     string `json:"name"`
+			Archived bool   `json:"archived"`
+		}
+		path := fmt.Sprintf("/orgs/%s/repos?per_page=100&sort=pushed&page=%d", url.PathEscape(org), page)
+		if err := c.get(ctx, path, &raw); err != nil {
+			return nil, err
+		}
+		for _, r := range raw {
+			if !r.Archived && len(names) < limit {
+				names = append(names, r.Name)
+			}
+		}
+		if len(raw) < 100 {
+			break
+		}
+	}
+	return names, nil
+}
+
+// listOrgMembers returns up to limit member logins of org.
+func (c *githubClient) listOrgMembers(ctx context.Context, org string, limit int) ([]string, error) {
+	var logins []string
+	for page := 1; len(logins) < limit; page++ {
+		var raw []struct {
+			Login string `json:"login"`
+		}
+		path := fmt.Sprintf("/orgs/%s/members?per_page=100&page=%d", url.PathEscape(org), page)
+		if err := c.get(ctx, path, &raw); err != nil {
+			return nil, err
+		}
+		for _, m := range raw {
+			if len(logins) < limit {
+				logins = append(logins, m.Login)
+			}
+		}
+		if len(raw) < 100 {
+			break
+		}
+	}
+	return logins, nil
+}
+
+// graphqlURL returns the GraphQL endpoint matching the REST root:
+// https://api.github.com/graphql, or /api/graphql on GitHub Enterprise Server.
+func (c *githubClient) graphqlURL() string {
+	if root, ok := strings.CutSuffix(c.baseURL, "/api/v3"); ok {
+		return root + "/api/graphql"
+	}
+	return c.baseURL + "/graphql"
+}
+
+// graphql runs a GraphQL query and decodes its data into out.
+func (c *githubClient) graphql(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		return fmt.Errorf("failed to marshal GraphQL request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.graphqlURL(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build GraphQL request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.do(req, &envelope); err != nil {
+		return err
+	}
+	if len(envelope.Errors) > 0 {
+		return fmt.Errorf("GraphQL error: %s", envelope.Errors[0].Message)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode GraphQL data: %w", err)
+	}
+	return nil
+}
+
+// get performs an authenticated GET against the API and decodes the JSON body.
+func (c *githubClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build GitHub request: %w", err)
+	}
+	return c.do(req, out)
+}
+
+// do sends an authenticated request and decodes the JSON response body.
+func (c *githubClient) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	token := c.token
+	if c.tokenFunc != nil {
+		var err error
+		if token, err = c.tokenFunc(req.Context()); err != nil {
+			return fmt.Errorf("failed to obtain GitHub token: %w", err)
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GitHub API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// githubAppJWTLifetime stays under GitHub's ten-minute maximum.
+	githubAppJWTLifetime = 9 * time.Minute
+	// githubAppTokenRefreshMargin renews installation tokens this long
+	// before they expire so in-flight requests never carry a stale token.
+	githubAppTokenRefreshMargin = 5 * time.Minute
+	// poppitSealedEnvTTL is how long Poppit accepts the token sealed into a
+	// command. It matches the refresh margin, so the token outlives it.
+	poppitSealedEnvTTL = githubAppTokenRefreshMargin
+)
+
+// githubAppTokenSource mints and caches installation access tokens for a
+// GitHub App. Tokens last an hour and are refreshed on demand.
+type githubAppTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	apiURL         string
+	now            func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// newGitHubAppTokenSource parses the PEM-encoded App private key.
+func newGitHubAppTokenSource(appID, installationID int64, privateKeyPEM, apiURL string) (*githubAppTokenSource, error) {
+	key, err := config.ParseGitHubAppKey([]byte(privateKeyPEM))
+	if err != nil {
+		return nil, err
+	}
+	return &githubAppTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		apiURL:         apiURL,
+		now:            time.Now,
+	}, nil
+}
+
+// Token returns a valid installation token, exchanging a fresh App JWT when
+// the cached token is missing or close to expiry.
+func (s *githubAppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(githubAppTokenRefreshMargin).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	jwt, err := s.appJWT()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/app/installations/%d/access_tokens", newGitHubClient(s.apiURL, "").baseURL, s.installationID), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build installation token request: %w", err)
+	}
+	var resp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := newGitHubClient(s.apiURL, jwt).do(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+
+	s.token, s.expiresAt = resp.Token, resp.ExpiresAt
+	logging.DebugContext(ctx, "Minted GitHub App installation token (expires %s)", s.expiresAt.Format(time.RFC3339))
+	return s.token, nil
+}
+
+// appJWT signs the short-lived RS256 JWT that authenticates as the App.
+func (s *githubAppTokenSource) appJWT() (string, error) {
+	now := s.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		// Backdate to tolerate clock drift between us and GitHub.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JWT claims: %w", err)
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+var (
+	githubAppSourcesMu sync.Mutex
+	githubAppSources   = map[int64]*githubAppTokenSource{}
+)
+
+// githubAppSource returns the process-wide token source for the configured
+// installation so the cached token is shared across requests.
+func githubAppSource(config config.Config) (*githubAppTokenSource, error) {
+	githubAppSourcesMu.Lock()
+	defer githubAppSourcesMu.Unlock()
+
+	if s, ok := githubAppSources[config.GitHubAppInstallationID]; ok {
+		return s, nil
+	}
+	s, err := newGitHubAppTokenSource(config.GitHubAppID, config.GitHubAppInstallationID, config.GitHubAppPrivateKey, config.GitHubAPIURL)
+	if err != nil {
+		return nil, err
+	}
+	githubAppSources[config.GitHubAppInstallationID] = s
+	return s, nil
+}
+
+// githubAuthToken returns the token to use for GitHub calls: an installation
+// token when a GitHub App is configured, otherwise GITHUB_TOKEN.
+func githubAuthToken(ctx context.Context, config config.Config) (string, error) {
+	if !config.UsesGitHubApp() {
+		return config.GitHubToken, nil
+	}
+	s, err := githubAppSource(config)
+	if err != nil {
+		return "", err
+	}
+	return s.Token(ctx)
+}
@@ -0,0 +1,1338 @@
+// Package handlers implements the /pr slash command and the Slack
+// interactions, Poppit replies, and scheduled jobs that follow from it.
+// Service wires them to Redis and Slack.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+// The patterns config validates GitHub names with also check /pr arguments.
+var (
+	validOwnerName  = config.ValidOwnerName
+	validRepoName   = config.ValidRepoName
+	validBaseBranch = config.ValidBaseBranch
+)
+
+// maxLabelLength is the longest label name GitHub allows, in characters.
+const maxLabelLength = 50
+
+// maxMilestoneLength bounds the --milestone title.
+const maxMilestoneLength = 100
+
+// prJSONFields is the --json field list requested from gh for every PR fetch;
+// it must stay in sync with the fields of PRItem.
+const prJSONFields = "number,title,author,url,headRefName,baseRefName,createdAt,body,additions,deletions,changedFiles,isDraft,state,labels,statusCheckRollup,reviewDecision,reviewRequests,milestone,projectItems"
+
+const poppitPRListType = "slash-vibe-pr-list"
+
+// subscribeToSlashCommands subscribes to the Redis slash-commands channel and
+// dispatches any /pr command to handleSlashCommand.
+func subscribeToSlashCommands(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	consume(ctx, rdb, config.RedisChannel, func(payload string) {
+		handleSlashCommand(ctx, rdb, slackClient, payload, config)
+	}, config)
+}
+
+// handleSlashCommand processes a raw slash command payload. Only /pr is handled;
+// all other commands are silently ignored.
+// Subcommands (e.g. /pr fav) are dispatched through prSubcommands. If a repo
+// name is supplied as the command text (e.g. /pr myrepo), the repo chooser
+// modal is skipped and the PR chooser is loaded directly.
+func handleSlashCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	config = withConfigOverrides(ctx, rdb, config)
+	var cmd SlackCommand
+	if err := envelope.Decode(envelope.SchemaSlashCommand, []byte(payload), &cmd); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling slash command: %v", err)
+		deadLetter(ctx, rdb, config.RedisChannel, payload, err)
+		return
+	}
+	ctx = logging.WithLogAttrs(ctx, "user", cmd.UserName)
+
+	if cmd.Command != "/pr" {
+		return
+	}
+	prefs, err := loadUserPreferences(ctx, rdb, cmd.UserID)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading preferences for user %s: %v", cmd.UserID, err)
+	}
+	config = withFeatureFlags(ctx, rdb, featureScope{Team: cmd.TeamID, Channel: cmd.ChannelID}, prefs.apply(config))
+	ctx, span := startSpan(ctx, "slash_command", trace.SpanKindServer, attribute.String("slack.user_id", cmd.UserID))
+	defer span.End()
+
+	logging.InfoContext(ctx, "Received /pr command from user %s", cmd.UserName)
+
+	if sub, text, ok := parseCommand(cmd.Text); ok {
+		if authorizeCommand(ctx, rdb, slackClient, cmd, sub.Name, config) {
+			sub.Run(ctx, rdb, slackClient, cmd, text, config)
+		}
+		return
+	}
+
+	args, err := parsePRArgs(cmd.Text, config)
+	if err != nil {
+		logging.WarnContext(ctx, "Invalid /pr arguments from user %s: %v", cmd.UserName, err)
+		notice := capitalize(err.Error()) + "."
+		if word, ok := unknownSubcommand(cmd.Text); ok {
+			notice = fmt.Sprintf("Unknown subcommand `%s`.", word)
+		}
+		respondWithUsage(ctx, cmd, notice)
+		return
+	}
+	if args.Number != 0 {
+		// PR URL provided — skip both modals and post that PR directly.
+		inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID, DryRun: args.DryRun}
+		repo := args.fullRepo(config)
+		logging.InfoContext(ctx, "PR URL provided, fetching %s#%d directly", repo, args.Number)
+		if err := newPRSource(rdb, slackClient, config).ViewPR(ctx, repo, args.Number, cmd.ResponseURL, inv); err != nil {
+			logging.ErrorContext(ctx, "Error sending Poppit command for %s#%d: %v", repo, args.Number, err)
+		}
+		return
+	}
+	if args.Multi && !config.FeatureEnabled(featureMultiSelect) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, ":warning: `--multi` is not available here yet."); err != nil {
+			logging.ErrorContext(ctx, "Error responding to user %s: %v", cmd.UserName, err)
+		}
+		return
+	}
+	if args.Repo != "" {
+		// Repo name provided — skip the repo chooser and load PRs directly.
+		repo := args.fullRepo(config)
+		logging.InfoContext(ctx, "Repo argument provided, skipping repo chooser: %s", repo)
+
+		if err := recordRecentRepo(ctx, rdb, cmd.UserID, repo, time.Now()); err != nil {
+			logging.WarnContext(ctx, "Error recording recent repo for user %s: %v", cmd.UserID, err)
+		}
+
+		loadingModal := slackui.LoadingModal()
+		viewResp, err := openView(ctx, slackClient, cmd.TriggerID, loadingModal, config)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error opening loading modal: %v", err)
+			return
+		}
+
+		inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID, DryRun: args.DryRun, Multi: args.Multi}
+		applyPRSort(ctx, rdb, cmd.UserID, &args.List)
+		if err := newPRSource(rdb, slackClient, config).ListPRs(ctx, repo, viewResp.ID, inv, args.List); err != nil {
+			logging.ErrorContext(ctx, "Error sending Poppit command for repo %s: %v", repo, err)
+		}
+		return
+	}
+
+	modal := slackui.RepoChooserModal(config.Orgs(), config.GitHubOrg, prefs.DefaultRepo)
+	var viewResp *slack.ViewResponse
+	if viewResp, err = openView(ctx, slackClient, cmd.TriggerID, modal, config); err != nil {
+		logging.ErrorContext(ctx, "Error opening repo chooser modal: %v", err)
+		return
+	}
+
+	logging.DebugContext(ctx, "Repo chooser modal opened successfully with view_id: %s", viewResp.ID)
+}
+
+// parsePRArgs splits the /pr command text into an optional repo name, list
+// options, and invocation flags. The accepted form is
+//
+//	[<org>/]<repo> [--base <branch>] [--author <login>] [--label <name>]...
+//	    [--milestone <title>] [--sort <order>] [--state <state>]
+//	    [--drafts|--no-drafts] [--multi] [--dry-run]
+//
+// The text is split by splitArgs, so values may be quoted, and flags are
+// parsed by parseArgs against prFlags. When no --base flag is given the
+// configured default base branch applies, and drafts are hidden or shown as
+// github.hide_drafts says unless --drafts or --no-drafts is given. A GitHub
+// pull request URL may be given in place of the repo, in which case Number
+// is set.
+func parsePRArgs(text string, config config.Config) (prArgs, error) {
+	args := prArgs{List: PRListOptions{Base: config.GitHubBaseBranch, HideDrafts: config.GitHubHideDrafts}}
+
+	fields, err := splitArgs(text)
+	if err != nil {
+		return prArgs{}, err
+	}
+	positional, err := parseArgs(fields, prFlags, &args)
+	if err != nil {
+		return prArgs{}, err
+	}
+	for _, field := range positional {
+		switch {
+		case args.Repo == "" && prURLPattern.MatchString(field):
+			m := prURLPattern.FindStringSubmatch(field)
+			args.Org, args.Repo = m[1], m[2]
+			args.Number, _ = strconv.Atoi(m[3])
+		case args.Repo == "":
+			args.Repo = field
+		default:
+			return prArgs{}, fmt.Errorf("unexpected argument %q", field)
+		}
+	}
+
+	if owner, name, ok := strings.Cut(args.Repo, "/"); ok {
+		if !validOwnerName.MatchString(owner) {
+			return prArgs{}, fmt.Errorf("invalid organisation name %q", owner)
+		}
+		if name == "" {
+			return prArgs{}, fmt.Errorf("missing repo name in %q", args.Repo)
+		}
+		args.Org, args.Repo = owner, name
+	}
+	if args.Repo != "" && !validRepoName.MatchString(args.Repo) {
+		return prArgs{}, fmt.Errorf("invalid repo name %q", args.Repo)
+	}
+	if args.List.Base != "" && !validBaseBranch.MatchString(args.List.Base) {
+		return prArgs{}, fmt.Errorf("invalid base branch %q", args.List.Base)
+	}
+	if args.List.Author != "" && !validOwnerName.MatchString(args.List.Author) {
+		return prArgs{}, fmt.Errorf("invalid author %q", args.List.Author)
+	}
+	for _, label := range args.List.Labels {
+		if label == "" || utf8.RuneCountInString(label) > maxLabelLength {
+			return prArgs{}, fmt.Errorf("invalid label %q", label)
+		}
+	}
+	if m := args.List.Milestone; m != "" && (utf8.RuneCountInString(m) > maxMilestoneLength || strings.Contains(m, `"`)) {
+		return prArgs{}, fmt.Errorf("invalid milestone %q", m)
+	}
+	if args.List.State == "open" {
+		args.List.State = ""
+	}
+	if args.List.State != "" && !validPRState(args.List.State) {
+		return prArgs{}, fmt.Errorf("invalid state %q (want one of %s)", args.List.State, strings.Join(prStates, ", "))
+	}
+	if args.List.Sort != "" && !validPRSort(args.List.Sort) {
+		return prArgs{}, fmt.Errorf("invalid sort order %q (want one of %s)", args.List.Sort, strings.Join(prSortOrders, ", "))
+	}
+
+	return args, nil
+}
+
+// subscribeToViewSubmissions subscribes to the Redis view-submission channel and
+// routes each submission to the appropriate handler based on callback_id.
+func subscribeToViewSubmissions(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	consume(ctx, rdb, config.RedisViewSubmissionChannel, func(payload string) {
+		handleViewSubmission(ctx, rdb, slackClient, payload, config)
+	}, config)
+}
+
+// handleViewSubmission decodes a view submission and routes it by callback_id.
+func handleViewSubmission(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	config = withConfigOverrides(ctx, rdb, config)
+	var submission ViewSubmission
+	if err := envelope.Decode(envelope.SchemaViewSubmission, []byte(payload), &submission); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling view submission: %v", err)
+		deadLetter(ctx, rdb, config.RedisViewSubmissionChannel, payload, err)
+		return
+	}
+	ctx = logging.WithLogAttrs(ctx, "user", submission.User.Username, "view_id", submission.View.ID)
+
+	switch submission.View.CallbackID {
+	case slackui.PRModalCallbackID:
+		config = withUserPreferences(ctx, rdb, submission.User.ID, config)
+		scope := featureScope{Team: submission.Team.ID, Channel: config.SlackChannelID}
+		handlePRSelection(ctx, rdb, slackClient, submission, withFeatureFlags(ctx, rdb, scope, config))
+	case prefsCallbackID:
+		handlePreferencesSubmission(ctx, rdb, submission, config)
+	}
+}
+
+// subscribeToBlockActions subscribes to the Redis block-actions channel and
+// dispatches each event to handleBlockAction.
+func subscribeToBlockActions(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	consume(ctx, rdb, config.RedisBlockActionsChannel, func(payload string) {
+		handleBlockAction(ctx, rdb, slackClient, payload, config)
+	}, config)
+}
+
+// handleBlockAction processes a block_actions event from the repo-chooser modal.
+// When the user selects a repository from the external select, this opens a
+// loading modal using the fresh trigger_id and sends the Poppit PR list command.
+func handleBlockAction(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	config = withConfigOverrides(ctx, rdb, config)
+	var action BlockActionPayload
+	if err := envelope.Decode(envelope.SchemaBlockActions, []byte(payload), &action); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling block action: %v", err)
+		deadLetter(ctx, rdb, config.RedisBlockActionsChannel, payload, err)
+		return
+	}
+	ctx = logging.WithLogAttrs(ctx, "user", action.User.Username, "view_id", action.View.ID)
+	config = withUserPreferences(ctx, rdb, action.User.ID, config)
+	config = withFeatureFlags(ctx, rdb, featureScope{Team: action.Team.ID, Channel: config.SlackChannelID}, config)
+
+	if action.Type == messageShortcutType {
+		handleMessageShortcut(ctx, rdb, slackClient, payload, config)
+		return
+	}
+
+	if len(action.Actions) == 0 {
+		logging.WarnContext(ctx, "Block action payload has no actions")
+		return
+	}
+
+	// Only handle org and repo selection actions from the repo chooser modal.
+	first := action.Actions[0]
+	ctx, span := startSpan(ctx, "block_action", trace.SpanKindServer,
+		attribute.String("slack.user_id", action.User.ID), attribute.String("slack.action_id", first.ActionID))
+	defer span.End()
+	if first.ActionID == slackui.OrgSelectActionID && first.BlockID == slackui.OrgBlockID {
+		handleOrgSelection(ctx, rdb, slackClient, action.View.ID, action.View.PrivateMetadata, first.SelectedOption.Value, config)
+		return
+	}
+	if first.ActionID == slackui.AuthorSelectActionID && first.BlockID == slackui.AuthorBlockID {
+		handleAuthorFilter(ctx, rdb, slackClient, action.View.ID, action.View.PrivateMetadata, first.SelectedOption.Value, config)
+		return
+	}
+	if first.ActionID == slackui.LabelSelectActionID && first.BlockID == slackui.LabelBlockID {
+		labels := make([]string, 0, len(first.SelectedOptions))
+		for _, opt := range first.SelectedOptions {
+			labels = append(labels, opt.Value)
+		}
+		handleLabelFilter(ctx, rdb, slackClient, action.View.ID, action.View.PrivateMetadata, labels, config)
+		return
+	}
+	if first.ActionID == reviewPostActionID && strings.HasPrefix(first.BlockID, reviewBlockIDPrefix) {
+		handleReviewPost(ctx, rdb, slackClient, action, first.Value, config)
+		return
+	}
+	if first.ActionID == homePostActionID && strings.HasPrefix(first.BlockID, homeBlockIDPrefix) {
+		handleHomePost(ctx, rdb, slackClient, action, first.Value, config)
+		return
+	}
+	if first.ActionID == previewPostActionID && first.BlockID == previewBlockID {
+		handlePreviewPost(ctx, rdb, slackClient, action, first.Value, config)
+		return
+	}
+	if first.ActionID == previewBackActionID && first.BlockID == previewBlockID {
+		handlePreviewBack(ctx, rdb, slackClient, action, first.Value, config)
+		return
+	}
+	if first.ActionID == loadingRetryActionID && first.BlockID == loadingRetryBlockID {
+		handleLoadingRetry(ctx, rdb, slackClient, action, config)
+		return
+	}
+	if first.ActionID == postRemindActionID && first.BlockID == postActionsBlockID {
+		handleRemindMe(ctx, rdb, action, first.SelectedOption.Value, config)
+		return
+	}
+	if first.ActionID == postClaimActionID && first.BlockID == postActionsBlockID {
+		handleClaimReview(ctx, rdb, action, first.Value, config)
+		return
+	}
+	if (first.ActionID == postApproveActionID || first.ActionID == postMergeActionID) && first.BlockID == postActionsBlockID {
+		handleApproveMergeClick(ctx, rdb, slackClient, action, first.ActionID, first.Value, config)
+		return
+	}
+	if strings.HasPrefix(first.ActionID, favRepoActionID) && first.BlockID == favBlockID {
+		handleFavoriteSelection(ctx, rdb, slackClient, action, first.Value, config)
+		return
+	}
+
+	var repo string
+	switch {
+	case first.ActionID == slackui.DefaultRepoActionID && first.BlockID == slackui.DefaultRepoBlockID:
+		// The default repo button carries the full owner/name path.
+		repo = first.Value
+	case first.ActionID == slackui.SlashVibeIssueActionID && first.BlockID == slackui.RepoBlockID:
+		repoName := first.SelectedOption.Value
+		if repoName == "" {
+			logging.WarnContext(ctx, "Block action for repo selection has empty value")
+			return
+		}
+		repo = chooserRepoPath(action.View.PrivateMetadata, repoName, config)
+	default:
+		return
+	}
+	logging.InfoContext(ctx, "User %s selected repo via block action: %s", action.User.Username, repo)
+
+	if err := recordRecentRepo(ctx, rdb, action.User.ID, repo, time.Now()); err != nil {
+		logging.WarnContext(ctx, "Error recording recent repo for user %s: %v", action.User.ID, err)
+	}
+
+	loadingModal := slackui.LoadingModal()
+	viewResp, err := pushView(ctx, slackClient, action.TriggerID, loadingModal, config)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error pushing loading modal from block action: %v", err)
+		return
+	}
+
+	logging.DebugContext(ctx, "Loading modal opened from block action with view_id: %s", viewResp.ID)
+
+	inv := Invocation{UserID: action.User.ID, Username: action.User.Username, TeamID: action.Team.ID}
+	opts := PRListOptions{Base: config.GitHubBaseBranch, HideDrafts: config.GitHubHideDrafts, Sort: loadPRSort(ctx, rdb, action.User.ID)}
+	if err := newPRSource(rdb, slackClient, config).ListPRs(ctx, repo, viewResp.ID, inv, opts); err != nil {
+		logging.ErrorContext(ctx, "Error sending Poppit command for repo %s: %v", repo, err)
+	}
+}
+
+// buildPRListCommand returns the gh invocation that lists PRs for repo: open
+// ones unless opts.State asks for closed or merged ones.
+// An exact base branch is passed through as --base; glob patterns are left to
+// filterPRsByBase since gh has no wildcard support for base branches, and
+// limit should come from PRListOptions.fetchLimit so enough are listed. An
+// author is passed through as --author and each label as a quoted --label,
+// and a milestone and sort order become milestone: and sort: search
+// qualifiers.
+func buildPRListCommand(repo string, opts PRListOptions, limit int) string {
+	cmd := fmt.Sprintf(
+		"gh pr list --repo %s --json %s --limit %d",
+		repo, prJSONFields, limit,
+	)
+	if opts.Base != "" && !opts.basePattern() {
+		cmd += " --base " + opts.Base
+	}
+	if opts.State != "" {
+		cmd += " --state " + opts.State
+	}
+	if opts.Author != "" {
+		cmd += " --author " + opts.Author
+	}
+	for _, label := range opts.Labels {
+		cmd += " --label " + shellQuote(label)
+	}
+	switch {
+	case opts.Milestone != "":
+		search := fmt.Sprintf("milestone:%q", opts.Milestone)
+		if opts.Sort != "" {
+			search += " sort:" + opts.Sort
+		}
+		cmd += " --search " + shellQuote(search)
+	case opts.Sort != "":
+		cmd += fmt.Sprintf(" --search \"sort:%s\"", opts.Sort)
+	}
+	return cmd
+}
+
+// filterPRsByBase returns the PRs whose base branch matches the given pattern.
+// An empty pattern matches everything.
+func filterPRsByBase(prs []PRItem, base string) []PRItem {
+	if base == "" {
+		return prs
+	}
+	filtered := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if ok, _ := path.Match(base, pr.BaseRefName); ok {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// filterPRsByAuthor returns the PRs opened by author, compared
+// case-insensitively as GitHub logins are. An empty author matches everything.
+func filterPRsByAuthor(prs []PRItem, author string) []PRItem {
+	if author == "" {
+		return prs
+	}
+	filtered := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if strings.EqualFold(pr.Author.Login, author) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// prAuthors returns the distinct authors of prs in alphabetical order, for
+// the PR chooser's author filter.
+func prAuthors(prs []PRItem) []string {
+	seen := make(map[string]bool, len(prs))
+	var authors []string
+	for _, pr := range prs {
+		if login := pr.Author.Login; login != "" && !seen[login] {
+			seen[login] = true
+			authors = append(authors, login)
+		}
+	}
+	sort.Strings(authors)
+	return authors
+}
+
+// filterPRsByLabels returns the PRs carrying every label in labels, as gh's
+// repeated --label does. No labels matches everything.
+func filterPRsByLabels(prs []PRItem, labels []string) []PRItem {
+	if len(labels) == 0 {
+		return prs
+	}
+	filtered := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if hasAllLabels(pr, labels) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// filterPRsByMilestone returns the PRs in milestone, compared
+// case-insensitively. An empty milestone matches everything.
+func filterPRsByMilestone(prs []PRItem, milestone string) []PRItem {
+	if milestone == "" {
+		return prs
+	}
+	filtered := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if pr.Milestone != nil && strings.EqualFold(pr.Milestone.Title, milestone) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// hasAllLabels reports whether pr carries each of labels, compared
+// case-insensitively as GitHub label names are.
+func hasAllLabels(pr PRItem, labels []string) bool {
+	for _, want := range labels {
+		found := false
+		for _, have := range pr.labelNames() {
+			if strings.EqualFold(have, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// prLabels returns the distinct labels on prs in alphabetical order, for the
+// PR chooser's label filter.
+func prLabels(prs []PRItem) []string {
+	seen := map[string]bool{}
+	var labels []string
+	for _, pr := range prs {
+		for _, name := range pr.labelNames() {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				labels = append(labels, name)
+			}
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// filterPRsByDraft returns prs without their drafts when hide is set.
+func filterPRsByDraft(prs []PRItem, hide bool) []PRItem {
+	if !hide {
+		return prs
+	}
+	filtered := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if !pr.IsDraft {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// filterPRsMerged returns the merged PRs among prs.
+func filterPRsMerged(prs []PRItem) []PRItem {
+	filtered := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if pr.State == "MERGED" {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// validPRState reports whether state is one of prStates.
+func validPRState(state string) bool {
+	for _, s := range prStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPRs applies the base, author, label, milestone, and draft filters of
+// opts to prs, and keeps only merged PRs for --state merged.
+func filterPRs(prs []PRItem, opts PRListOptions) []PRItem {
+	prs = filterPRsByLabels(filterPRsByAuthor(filterPRsByBase(prs, opts.Base), opts.Author), opts.Labels)
+	prs = filterPRsByMilestone(prs, opts.Milestone)
+	if opts.State == "merged" {
+		prs = filterPRsMerged(prs)
+	}
+	return filterPRsByDraft(prs, opts.HideDrafts)
+}
+
+// describePRFilters renders the list filters for "No <state> pull requests..."
+// messages, e.g. " targeting `main` by `alice` labelled `backend`".
+func describePRFilters(opts PRListOptions) string {
+	var desc string
+	if opts.Base != "" {
+		desc += fmt.Sprintf(" targeting `%s`", opts.Base)
+	}
+	if opts.Author != "" {
+		desc += fmt.Sprintf(" by `%s`", opts.Author)
+	}
+	if len(opts.Labels) > 0 {
+		desc += fmt.Sprintf(" labelled `%s`", strings.Join(opts.Labels, "`, `"))
+	}
+	if opts.Milestone != "" {
+		desc += fmt.Sprintf(" in milestone `%s`", opts.Milestone)
+	}
+	if opts.HideDrafts {
+		desc += " (drafts hidden)"
+	}
+	return desc
+}
+
+// handleFavoriteSelection opens the PR chooser for a repo picked from the
+// /pr fav list. The buttons live in a message rather than a modal, so the
+// loading modal is opened rather than pushed.
+func handleFavoriteSelection(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, repo string, config config.Config) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || !validOwnerName.MatchString(owner) || !validRepoName.MatchString(name) {
+		logging.WarnContext(ctx, "Ignoring favorite with invalid repo %q", repo)
+		return
+	}
+	logging.InfoContext(ctx, "User %s opened favorite repo %s", action.User.Username, repo)
+
+	if err := recordRecentRepo(ctx, rdb, action.User.ID, repo, time.Now()); err != nil {
+		logging.WarnContext(ctx, "Error recording recent repo for user %s: %v", action.User.ID, err)
+	}
+
+	viewResp, err := openView(ctx, slackClient, action.TriggerID, slackui.LoadingModal(), config)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error opening loading modal from favorite: %v", err)
+		return
+	}
+
+	inv := Invocation{UserID: action.User.ID, Username: action.User.Username, TeamID: action.Team.ID}
+	opts := PRListOptions{Base: config.GitHubBaseBranch, HideDrafts: config.GitHubHideDrafts, Sort: loadPRSort(ctx, rdb, action.User.ID)}
+	if err := newPRSource(rdb, slackClient, config).ListPRs(ctx, repo, viewResp.ID, inv, opts); err != nil {
+		logging.ErrorContext(ctx, "Error sending Poppit command for repo %s: %v", repo, err)
+	}
+}
+
+// handleOrgSelection re-renders the repo chooser with the newly selected org
+// stored in its private metadata, keeping the default repo button.
+func handleOrgSelection(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID, privateMetadata, org string, config config.Config) {
+	if !config.HasOrg(org) {
+		logging.WarnContext(ctx, "Ignoring selection of unconfigured org %q", org)
+		return
+	}
+	var meta slackui.RepoChooserMetadata
+	_ = session.Open(privateMetadata, &meta)
+	if _, err := updateView(ctx, rdb, slackClient, slackui.RepoChooserModal(config.Orgs(), org, meta.DefaultRepo), viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating repo chooser for org %s: %v", org, err)
+	}
+}
+
+// handleChooserFilter applies a change to an open PR chooser's author or
+// label filter. The filters are stored in the PR session, which the chooser's
+// suggestions are served from, and the modal is re-rendered so its header
+// shows the active filters and the new count.
+func handleChooserFilter(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID, privateMetadata string, apply func(*PRModalPrivateMetadata), config config.Config) {
+	prSession, err := loadPRSession(ctx, rdb, viewID)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading PR session for view_id %s: %v", viewID, err)
+		return
+	}
+	apply(&prSession)
+	if err := savePRSession(ctx, rdb, viewID, prSession, config); err != nil {
+		logging.ErrorContext(ctx, "Error saving PR session for view_id %s: %v", viewID, err)
+		return
+	}
+
+	modal := slackui.PRChooserModal(len(prSession.chooserPRs()), prSession.Repo, prSession.chooserFilters(), prSession.Multi, prSession.destination(), config.SlackMessageTTL, privateMetadata)
+	if _, err := updateView(ctx, rdb, slackClient, modal, viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating PR chooser filters (author %q, labels %q): %v", prSession.Author, prSession.Labels, err)
+	}
+}
+
+// handleAuthorFilter narrows an open PR chooser to one author's PRs, or
+// widens it again when "Anyone" is picked.
+func handleAuthorFilter(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID, privateMetadata, author string, config config.Config) {
+	if author == slackui.AnyAuthorValue {
+		author = ""
+	}
+	handleChooserFilter(ctx, rdb, slackClient, viewID, privateMetadata, func(s *PRModalPrivateMetadata) { s.Author = author }, config)
+}
+
+// handleLabelFilter narrows an open PR chooser to PRs carrying every
+// selected label; clearing the selection shows all of them again.
+func handleLabelFilter(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID, privateMetadata string, labels []string, config config.Config) {
+	handleChooserFilter(ctx, rdb, slackClient, viewID, privateMetadata, func(s *PRModalPrivateMetadata) { s.Labels = labels }, config)
+}
+
+// chooserRepoPath builds the owner/name path for a repo picked in the repo
+// chooser. Values that already carry an org (a combined org/repo option) are
+// used as-is; otherwise the org from the chooser's metadata is prepended,
+// falling back to the default org.
+func chooserRepoPath(privateMetadata, repoName string, config config.Config) string {
+	if strings.Contains(repoName, "/") {
+		return repoName
+	}
+	org := config.GitHubOrg
+	var meta slackui.RepoChooserMetadata
+	if err := session.Open(privateMetadata, &meta); err == nil && config.HasOrg(meta.Org) {
+		org = meta.Org
+	}
+	return org + "/" + repoName
+}
+
+// sendPRListCommand pushes a Poppit command to list open PRs for the given repo.
+// The view_id is passed in metadata so handlePoppitOutput can update the correct modal.
+// The invocation is carried alongside so the output handler knows who asked
+// and whether the eventual post is a dry run.
+func sendPRListCommand(ctx context.Context, queue poppit.Queue, repo, viewID string, inv Invocation, opts PRListOptions, config config.Config) error {
+	cmd := buildPRListCommand(repo, opts, opts.fetchLimit(config.PRListLimit))
+
+	metadata := inv.metadata()
+	metadata["view_id"] = viewID
+	metadata["repo"] = repo
+	metadata["base"] = opts.Base
+	metadata["author"] = opts.Author
+	metadata["labels"] = strings.Join(opts.Labels, ",")
+	metadata["milestone"] = opts.Milestone
+	metadata["hide_drafts"] = opts.HideDrafts
+	metadata["state"] = opts.State
+
+	poppitCmd := poppit.Command{
+		Repo:     repo,
+		Branch:   "",
+		Type:     poppitPRListType,
+		Dir:      "/tmp",
+		Commands: []string{cmd},
+		Metadata: metadata,
+	}
+
+	return pushPoppitCommand(ctx, queue, poppitCmd, config)
+}
+
+// pushPoppitCommand enqueues a command on the Poppit list. Read-only commands
+// such as PR listings always run; commands with side effects must check
+// isDryRun before calling this. Under the --dry-run flag every command is
+// printed instead, as SlackLiner posts are. When a GitHub App and
+// POPPIT_ENV_KEY are configured, an installation token is handed to gh as a
+// GH_TOKEN sealed for this command alone and expiring after
+// poppitSealedEnvTTL; otherwise gh runs with the Poppit host's own login.
+// Each command is stamped with a fresh ID and this replica's name, so its
+// output is handled once, by this replica.
+func pushPoppitCommand(ctx context.Context, queue poppit.Queue, poppitCmd poppit.Command, config config.Config) (err error) {
+	ctx, span := startSpan(ctx, "poppit_enqueue "+poppitCmd.Type, trace.SpanKindProducer, attribute.String("github.repo", poppitCmd.Repo))
+	defer func() { endSpan(span, err) }()
+
+	if poppitCmd.Metadata == nil {
+		poppitCmd.Metadata = map[string]interface{}{}
+	}
+	if id := logging.RequestIDFrom(ctx); id != "" {
+		poppitCmd.Metadata[logging.RequestIDField] = id
+	}
+	commandID, err := randomHex(16)
+	if err != nil {
+		return fmt.Errorf("failed to generate Poppit command ID: %w", err)
+	}
+	poppitCmd.Metadata[poppitCommandIDField] = commandID
+	if config.UsesGitHubApp() && len(config.PoppitEnvKey) > 0 {
+		token, err := githubAuthToken(ctx, config)
+		if err != nil {
+			return fmt.Errorf("failed to obtain GitHub App token for Poppit: %w", err)
+		}
+		env := map[string]string{"GH_TOKEN": token}
+		if poppitCmd.SealedEnv, err = poppit.SealEnv(config.PoppitEnvKey, commandID, env, time.Now().Add(poppitSealedEnvTTL)); err != nil {
+			return fmt.Errorf("failed to seal GitHub App token for Poppit: %w", err)
+		}
+	}
+	if config.RedisConsumerName != "" {
+		poppitCmd.Metadata[poppitInstanceField] = config.RedisConsumerName
+	}
+	injectTraceContext(ctx, poppitCmd.Metadata)
+
+	if config.Simulate {
+		printDryRun(ctx, "Poppit "+config.RedisPoppitList, poppitCmd)
+		return nil
+	}
+	return poppit.Push(ctx, queue, config.RedisPoppitList, poppitCmd, config.PoppitFormat())
+}
+
+// handlePRSelection processes the PR-chooser modal submission:
+//  1. Looks up PR details stored in Redis by the view ID.
+//  2. Opens a preview of the post when slack.preview_posts is enabled,
+//     queues it as a scheduled post when a time was picked under "Post
+//     later", or otherwise posts each selected PR to the configured Slack
+//     channel via SlackLiner.
+//
+// Both the single and multi-select variants of the chooser are handled.
+func handlePRSelection(ctx context.Context, rdb *redis.Client, slackClient SlackClient, submission ViewSubmission, config config.Config) {
+	prNumbers := extractSelectedValues(submission.View.State.Values, slackui.PRBlockID, slackui.PRSelectActionID)
+	if len(prNumbers) == 0 {
+		logging.WarnContext(ctx, "PR selection submission has empty PR number")
+		return
+	}
+
+	// Parse private_metadata to get the repo name.
+	var meta PRModalPrivateMetadata
+	if err := session.Open(submission.View.PrivateMetadata, &meta); err != nil {
+		logging.ErrorContext(ctx, "Error parsing private metadata: %v", err)
+		return
+	}
+	// Continue the trace of the command that opened the chooser.
+	ctx = logging.WithRequestID(ctx, meta.RequestID)
+	ctx, span := startSpan(withTraceCarrier(ctx, meta.Trace), "pr_selection", trace.SpanKindServer,
+		attribute.String("slack.user_id", submission.User.ID), attribute.String("github.repo", meta.Repo))
+	defer span.End()
+
+	// The PR list lives in the session; older modals still embed it.
+	prs := meta.PRs
+	if len(prs) == 0 {
+		prSession, err := loadPRSession(ctx, rdb, submission.View.ID)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error loading PR session for view_id %s: %v", submission.View.ID, err)
+			return
+		}
+		prs = prSession.PRs
+		ctx = logging.WithRequestID(ctx, prSession.RequestID)
+	}
+
+	inv := Invocation{
+		UserID:   submission.User.ID,
+		Username: submission.User.Username,
+		TeamID:   submission.Team.ID,
+		DryRun:   meta.DryRun,
+		Channel:  selectedChannel(submission.View.State.Values, meta.Channel),
+	}
+	post := PostOptions{
+		Note: strings.TrimSpace(extractTextValue(submission.View.State.Values, slackui.NoteBlockID, slackui.NoteInputActionID)),
+	}
+	reviewers := selectedReviewers(submission.View.State.Values)
+	postAt := selectedPostAt(submission.View.State.Values)
+	ttl := extractTextValue(submission.View.State.Values, slackui.MessageTTLBlockID, slackui.MessageTTLActionID)
+	config = withMessageTTL(ctx, ttl, config)
+
+	// Keep the selected PRs in the order chosen.
+	var selected []PRItem
+	for _, prNumber := range prNumbers {
+		selectedPR := findPR(prs, prNumber)
+		if selectedPR == nil {
+			logging.WarnContext(ctx, "Could not find PR #%s in session data", prNumber)
+			continue
+		}
+		logging.InfoContext(ctx, "User %s selected PR #%d from %s", submission.User.Username, selectedPR.Number, selectedPR.repoOr(meta.Repo))
+		selected = append(selected, *selectedPR)
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	if config.SlackPreviewPosts && submission.TriggerID != "" {
+		pending := pendingPost{
+			Repo:            meta.Repo,
+			PRs:             selected,
+			Note:            post.Note,
+			Reviewers:       reviewers,
+			DryRun:          meta.DryRun,
+			PostAt:          postAt,
+			TTL:             ttl,
+			Channel:         inv.Channel,
+			ChooserViewID:   submission.View.ID,
+			ChooserMetadata: submission.View.PrivateMetadata,
+			RequestID:       logging.RequestIDFrom(ctx),
+			Trace:           traceCarrier(ctx),
+		}
+		if err := openPostPreview(ctx, rdb, slackClient, submission.TriggerID, pending, inv, config); err != nil {
+			logging.ErrorContext(ctx, "Error opening post preview: %v", err)
+		}
+		return
+	}
+
+	if isScheduled(postAt) {
+		job := newScheduledPost(ctx, meta.Repo, selected, post.Note, reviewers, inv, config)
+		job.TTL = ttl
+		if err := schedulePost(ctx, rdb, slackClient, job, postAt); err != nil {
+			logging.ErrorContext(ctx, "Error scheduling post for user %s: %v", submission.User.Username, err)
+		}
+		return
+	}
+	postSelectedPRs(ctx, rdb, slackClient, selected, meta.Repo, inv, post, reviewers, config)
+}
+
+// postSelectedPRs shares each of prs in order and requests the chosen
+// reviewers on it, returning how many were posted. repo is used for PRs
+// that do not carry their own.
+func postSelectedPRs(ctx context.Context, rdb *redis.Client, slackClient SlackClient, prs []PRItem, repo string, inv Invocation, post PostOptions, reviewers []string, config config.Config) int {
+	posted := 0
+	for i := range prs {
+		selectedPR := &prs[i]
+		prRepo := selectedPR.repoOr(repo)
+
+		if err := sharePR(ctx, rdb, slackClient, selectedPR, prRepo, inv, post, config); err != nil {
+			logging.ErrorContext(ctx, "Error posting PR to Slack: %v", err)
+			continue
+		}
+		posted++
+
+		logging.InfoContext(ctx, "PR #%d from %s posted to Slack channel", selectedPR.Number, prRepo)
+
+		if err := requestReviewers(ctx, rdb, selectedPR, prRepo, reviewers, inv, withPostChannel(prRepo, inv, config)); err != nil {
+			logging.ErrorContext(ctx, "Error requesting reviewers for PR #%d from %s: %v", selectedPR.Number, prRepo, err)
+		}
+	}
+	return posted
+}
+
+// withMessageTTL returns config with slack.message_ttl replaced by ttl, the
+// whole seconds picked in the chooser's lifetime select; "0" keeps posts
+// until they are deleted. An empty or invalid ttl leaves config alone.
+func withMessageTTL(ctx context.Context, ttl string, config config.Config) config.Config {
+	if ttl == "" {
+		return config
+	}
+	seconds, err := strconv.Atoi(ttl)
+	if err != nil || seconds < 0 {
+		logging.WarnContext(ctx, "Ignoring invalid message TTL %q", ttl)
+		return config
+	}
+	config.SlackMessageTTL = time.Duration(seconds) * time.Second
+	return config
+}
+
+// findPR returns the PR whose number matches the given option value, or nil.
+func findPR(prs []PRItem, prNumber string) *PRItem {
+	for i := range prs {
+		if prs[i].optionValue() == prNumber {
+			return &prs[i]
+		}
+	}
+	return nil
+}
+
+// sharePR delivers the PR message for an invocation to the channel
+// postChannel picks. In dry-run mode the final SlackLinerMessage is logged
+// and echoed back to the user ephemerally, in config's own channel, instead
+// of being pushed to SlackLiner.
+func sharePR(ctx context.Context, rdb *redis.Client, slackClient SlackClient, pr *PRItem, repo string, inv Invocation, post PostOptions, config config.Config) error {
+	echoChannel := config.SlackChannelID
+	config = withPostChannel(repo, inv, config)
+	post = preparePost(ctx, rdb, pr, repo, inv, post, !isDryRun(inv, config), config)
+
+	if !isDryRun(inv, config) {
+		return postPRToSlack(ctx, rdb, pr, repo, inv.Username, post, config)
+	}
+
+	msgs := buildPRMessages(pr, repo, inv.Username, post, config)
+	payload, err := json.MarshalIndent(msgs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal SlackLiner message: %w", err)
+	}
+
+	logging.InfoContext(ctx, "[dry-run] SlackLiner messages for PR #%d from %s not pushed: %s", pr.Number, repo, payload)
+
+	if inv.UserID == "" {
+		return nil
+	}
+	text := fmt.Sprintf(":test_tube: *Dry run* — these messages would have been pushed to SlackLiner:\n```%s```", payload)
+	if _, err := slackClient.PostEphemeralContext(ctx, echoChannel, inv.UserID, slack.MsgOptionText(text, false)); err != nil {
+		return fmt.Errorf("failed to echo dry-run message: %w", err)
+	}
+	return nil
+}
+
+// preparePost enriches pr and resolves the details of post that are looked
+// up just before the message is built. advance moves the repo's reviewer
+// rotation on; a preview peeks at the next reviewer without consuming it.
+func preparePost(ctx context.Context, rdb *redis.Client, pr *PRItem, repo string, inv Invocation, post PostOptions, advance bool, config config.Config) PostOptions {
+	enrichPRReadiness(ctx, pr, repo, config)
+	post.AuthorSlackID = lookupSlackUserID(ctx, rdb, pr.Author.Login, config)
+	post.ThreadKey = newThreadKey(repo, pr.Number)
+	post.PostedByID = inv.UserID
+	post.SuggestedReviewer = suggestReviewer(ctx, rdb, repo, pr.Author.Login, advance, config)
+	post.SuggestedReviewerSlackID = lookupSlackUserID(ctx, rdb, post.SuggestedReviewer, config)
+	for _, r := range pr.ReviewRequests {
+		if r.Login == "" {
+			continue
+		}
+		if id := lookupSlackUserID(ctx, rdb, r.Login, config); id != "" {
+			if post.ReviewerSlackIDs == nil {
+				post.ReviewerSlackIDs = map[string]string{}
+			}
+			post.ReviewerSlackIDs[r.Login] = id
+		}
+	}
+	return post
+}
+
+// isDryRun reports whether side effects should be suppressed, either because
+// the service runs with dry_run enabled or the user passed --dry-run.
+func isDryRun(inv Invocation, config config.Config) bool {
+	return config.DryRun || inv.DryRun
+}
+
+// postPRToSlack pushes the formatted PR message, and its threaded details
+// follow-up when enabled, to the SlackLiner Redis list. Both are pushed in a
+// single RPUSH so the follow-up can never precede its parent.
+func postPRToSlack(ctx context.Context, rdb *redis.Client, pr *PRItem, repo, postedBy string, post PostOptions, config config.Config) (err error) {
+	ctx, span := startSpan(ctx, "slackliner_post", trace.SpanKindProducer,
+		attribute.String("github.repo", repo), attribute.Int("github.pr_number", pr.Number))
+	defer func() { endSpan(span, err) }()
+
+	msgs := buildPRMessages(pr, repo, postedBy, post, config)
+
+	payloads := make([]interface{}, 0, len(msgs))
+	for _, msg := range msgs {
+		stampSlackLinerMetadata(ctx, &msg)
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to marshal SlackLiner message: %w", err)
+		}
+		payloads = append(payloads, payload)
+	}
+
+	if err := pushSlackLiner(ctx, rdb, config, payloads...); err != nil {
+		return fmt.Errorf("failed to push message to SlackLiner list: %w", err)
+	}
+
+	now := time.Now()
+	if err := recordPostAnalytics(ctx, rdb, repo, postedBy, now); err != nil {
+		logging.WarnContext(ctx, "Error recording analytics for PR #%d from %s: %v", pr.Number, repo, err)
+	}
+	if err := recordPostedPR(ctx, rdb, PostedPR{
+		Repo:       repo,
+		Number:     pr.Number,
+		Title:      pr.Title,
+		URL:        pr.URL,
+		Channel:    config.SlackChannelID,
+		ThreadKey:  post.ThreadKey,
+		PostedBy:   postedBy,
+		PostedByID: post.PostedByID,
+		PostedAt:   now,
+	}); err != nil {
+		logging.WarnContext(ctx, "Error indexing posted PR #%d from %s: %v", pr.Number, repo, err)
+	}
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   auditActionShare,
+		UserID:   post.PostedByID,
+		Username: postedBy,
+		Repo:     repo,
+		Number:   pr.Number,
+		Channel:  config.SlackChannelID,
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing post of PR #%d from %s: %v", pr.Number, repo, err)
+	}
+
+	return nil
+}
+
+// subscribeToPoppitOutput subscribes to the Poppit command-output channel and
+// handles PR list results.
+func subscribeToPoppitOutput(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	consume(ctx, rdb, config.RedisPoppitOutputChannel, func(payload string) {
+		handlePoppitOutput(ctx, rdb, slackClient, payload, config)
+	}, config)
+}
+
+// handlePoppitOutput decodes a Poppit output event and routes it by type.
+// For slash-vibe-pr-list it:
+//  1. Parses the PR list from stdout.
+//  2. Stores the PRs in Redis keyed by the view ID.
+//  3. Updates the loading modal to display the PR chooser.
+//
+// slash-vibe-pr-view results (from a pasted PR URL) are posted directly.
+// Output split into chunks is buffered until every chunk has arrived.
+func handlePoppitOutput(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	config = withConfigOverrides(ctx, rdb, config)
+	var output poppit.Output
+	if err := envelope.Decode(envelope.SchemaPoppitOutput, []byte(payload), &output); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling Poppit output: %v", err)
+		deadLetter(ctx, rdb, config.RedisPoppitOutputChannel, payload, err)
+		return
+	}
+	ctx = logging.WithMetadataRequestID(ctx, output.Metadata)
+	username, _ := output.Metadata["username"].(string)
+	repo, _ := output.Metadata["repo"].(string)
+	viewID, _ := output.Metadata["view_id"].(string)
+	ctx = logging.WithLogAttrs(ctx, "poppit_type", output.Type, "user", username, "repo", repo, "view_id", viewID)
+	ctx, span := startSpan(traceContextFromMetadata(ctx, output.Metadata), "poppit_output "+output.Type, trace.SpanKindConsumer,
+		attribute.String("github.repo", repo))
+	defer span.End()
+
+	if !ownsPoppitOutput(output.Metadata, config) {
+		logging.DebugContext(ctx, "Skipping Poppit output for another instance")
+		return
+	}
+	output, complete, err := reassemblePoppitOutput(ctx, rdb, output)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error reassembling chunked Poppit output: %v", err)
+		deadLetter(ctx, rdb, config.RedisPoppitOutputChannel, payload, err)
+		return
+	}
+	if !complete {
+		logging.DebugContext(ctx, "Buffered Poppit output chunk %v of %v", output.Metadata[poppitChunkSeqField], output.Metadata[poppitChunkTotalField])
+		return
+	}
+	release, ok := claimPoppitOutput(ctx, rdb, output.Metadata)
+	if !ok {
+		logging.InfoContext(ctx, "Skipping duplicate Poppit output")
+		return
+	}
+	// A payload that panics is dead-lettered; forget the claim so that
+	// replaying it from the dead-letter queue is not skipped as a duplicate.
+	defer func() {
+		if r := recover(); r != nil {
+			release()
+			panic(r)
+		}
+	}()
+
+	switch output.Type {
+	case poppitPRListType:
+		handlePRListOutput(ctx, rdb, slackClient, output, config)
+	case poppitPRSearchType:
+		handlePRSearchOutput(ctx, rdb, slackClient, output, config)
+	case poppitPRApproveCheckType:
+		handlePRApproveCheckOutput(ctx, rdb, output, config)
+	case poppitPRApproveType:
+		handlePRApproveOutput(ctx, rdb, output, config)
+	case poppitPRMergeType:
+		handlePRMergeOutput(ctx, rdb, output, config)
+	case poppitLinkVerifyType:
+		verifyPendingLink(ctx, rdb, output, config)
+	case poppitPRAddReviewersType:
+		handleAddReviewersOutput(ctx, slackClient, output, config)
+	case poppitPRViewType:
+		handlePRViewOutput(ctx, rdb, slackClient, output, config)
+	case poppitDigestType:
+		handleDigestOutput(ctx, rdb, output, config)
+	case poppitBoardType:
+		handleBoardOutput(ctx, rdb, output, config)
+	case poppitPRReminderType:
+		handlePRReminderOutput(ctx, rdb, output, config)
+	case poppitHomePRsType:
+		handleHomePRsOutput(ctx, rdb, slackClient, output, config)
+	case poppitRepoListType:
+		repoCatalogKind.handleOutput(ctx, rdb, output)
+	case poppitMemberListType:
+		memberCatalogKind.handleOutput(ctx, rdb, output)
+	}
+}
+
+// handlePRListOutput handles the result of a PR list command.
+func handlePRListOutput(ctx context.Context, rdb *redis.Client, slackClient SlackClient, output poppit.Output, config config.Config) {
+	logging.DebugContext(ctx, "Received Poppit PR list output")
+
+	metadata := output.Metadata
+	if metadata == nil {
+		logging.WarnContext(ctx, "No metadata in Poppit PR list output")
+		return
+	}
+
+	viewID, _ := metadata["view_id"].(string)
+	repo, _ := metadata["repo"].(string)
+	inv := invocationFromMetadata(metadata)
+	var opts PRListOptions
+	opts.Base, _ = metadata["base"].(string)
+	opts.Author, _ = metadata["author"].(string)
+	opts.HideDrafts, _ = metadata["hide_drafts"].(bool)
+	opts.State, _ = metadata["state"].(string)
+	opts.Milestone, _ = metadata["milestone"].(string)
+	if labels, _ := metadata["labels"].(string); labels != "" {
+		opts.Labels = strings.Split(labels, ",")
+	}
+
+	if viewID == "" || repo == "" {
+		logging.WarnContext(ctx, "Missing view_id or repo in Poppit output metadata")
+		return
+	}
+	disarmLoadingWatchdog(ctx, rdb, viewID)
+
+	if msg, failed := poppit.Failure(output); failed {
+		logging.ErrorContext(ctx, "Listing PRs for repo %s failed: %s", repo, output.ErrorText())
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, msg, config)
+		return
+	}
+
+	// Parse the PR list from Poppit stdout.
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &prs); err != nil {
+		logging.ErrorContext(ctx, "Error parsing PR list JSON for repo %s: %v", repo, err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, poppit.DescribeGHError(output.Output, "Failed to parse the pull request list. Please try again."), config)
+		return
+	}
+
+	presentPRList(ctx, rdb, slackClient, viewID, repo, opts, inv, prs, config)
+}
+
+// presentPRList turns a fetched PR list into the next modal state: an error
+// when nothing matches, an auto-post for a single PR, or the PR chooser.
+// It is shared by every PRSource.
+func presentPRList(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID, repo string, opts PRListOptions, inv Invocation, prs []PRItem, config config.Config) {
+	username := inv.Username
+	// A base pattern is matched here, so PRs past the fetch limit may match
+	// too; the user is told when the list was cut off.
+	truncated := opts.basePattern() && len(prs) >= opts.fetchLimit(config.PRListLimit)
+	prs = filterPRs(prs, opts)
+
+	if len(prs) == 0 {
+		logging.InfoContext(ctx, "No %s PRs found for repo %s (base: %q, author: %q, labels: %q, user: %s)", opts.stateLabel(), repo, opts.Base, opts.Author, opts.Labels, username)
+		text := fmt.Sprintf("No %s pull requests%s found for `%s`.", opts.stateLabel(), describePRFilters(opts), repo)
+		if truncated {
+			text += " " + slackui.TruncatedBaseNote(opts.Base)
+		}
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, text, config)
+		return
+	}
+
+	logging.InfoContext(ctx, "Found %d %s PRs for repo %s (user: %s)", len(prs), opts.stateLabel(), repo, username)
+
+	// Short-circuit: when exactly one PR is available, post it directly without
+	// showing the chooser modal.
+	if len(prs) == 1 {
+		logging.InfoContext(ctx, "Single PR found for repo %s, auto-posting PR #%d (user: %s)", repo, prs[0].Number, username)
+		if err := sharePR(ctx, rdb, slackClient, &prs[0], prs[0].repoOr(repo), inv, PostOptions{}, config); err != nil {
+			logging.ErrorContext(ctx, "Error auto-posting single PR to Slack: %v", err)
+			updateModalWithErrorByID(ctx, rdb, slackClient, viewID, "Failed to post the pull request. Please try again.", config)
+			return
+		}
+		if _, err := updateView(ctx, rdb, slackClient, slackui.AutoPostedModal(repo, prs[0].Number, prs[0].Title), viewID, config); err != nil {
+			logging.ErrorContext(ctx, "Error updating modal after auto-posting PR: %v", err)
+		}
+		logging.DebugContext(ctx, "Single PR #%d auto-posted and modal updated for view_id: %s", prs[0].Number, viewID)
+		return
+	}
+
+	// The chooser starts on the repo's routed channel, or the user's.
+	destination := chooserDestination(repo, withUserPreferences(ctx, rdb, inv.UserID, config))
+
+	// Store the PR list as a session keyed by view ID so the external select
+	// can serve filtered options and the submission can resolve the choice.
+	prSession := PRModalPrivateMetadata{Repo: repo, PRs: prs, DryRun: inv.DryRun, Multi: inv.Multi, Base: opts.Base, Author: opts.Author, Labels: opts.Labels, State: opts.State, Truncated: truncated, Channel: destination.Channel, Routed: destination.Routed}
+	if err := savePRSession(ctx, rdb, viewID, prSession, config); err != nil {
+		logging.ErrorContext(ctx, "Error saving PR session for view_id %s: %v", viewID, err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, "Failed to prepare the pull request list. Please try again.", config)
+		return
+	}
+
+	// private_metadata carries only the small, non-list fields.
+	meta := PRModalPrivateMetadata{Repo: repo, DryRun: inv.DryRun, Multi: inv.Multi, Channel: destination.Channel, RequestID: logging.RequestIDFrom(ctx), Trace: traceCarrier(ctx)}
+	sealedMeta, err := session.Seal(meta)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling PR modal metadata: %v", err)
+		return
+	}
+
+	// Replace the loading modal with the PR chooser.
+	// Use empty hash to skip Slack's optimistic lock check, avoiding stale hash issues.
+	prModal := slackui.PRChooserModal(len(prs), repo, prSession.chooserFilters(), inv.Multi, destination, config.SlackMessageTTL, sealedMeta)
+	if _, err := updateView(ctx, rdb, slackClient, prModal, viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating modal with PR list: %v", err)
+		return
+	}
+
+	logging.DebugContext(ctx, "PR chooser modal updated successfully for view_id: %s", viewID)
+}
+
+// respondEphemeral sends a message visible only to the invoking user via the
+// slash command's response_url. It is a no-op when no response_url is known.
+func respondEphemeral(ctx context.Context, responseURL, text string) error {
+	if responseURL == "" {
+		return nil
+	}
+	return slack.PostWebhookContext(ctx, responseURL, &slack.WebhookMessage{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+	})
+}
+
+// capitalize upper-cases the first letter of s, for turning error strings into
+// user-facing sentences.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// updateModalWithErrorByID replaces the current modal content with an error message.
+// It uses an empty hash to skip Slack's optimistic lock check, avoiding stale hash issues.
+func updateModalWithErrorByID(ctx context.Context, rdb *redis.Client, slackClient SlackViews, viewID, message string, config config.Config) {
+	if _, err := updateView(ctx, rdb, slackClient, slackui.ErrorModal(message), viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating modal with error message: %v", err)
+	}
+}
+
+// extractSelectedValues returns every selected value for a given
+// blockID/actionID. It handles multi-selects (selected_options) as well as the
+// single-value inputs understood by extractTextValue.
+func extractSelectedValues(values map[string]map[string]interface{}, blockID, actionID string) []string {
+	if actionMap, ok := values[blockID][actionID].(map[string]interface{}); ok {
+		if selected, ok := actionMap["selected_options"].([]interface{}); ok {
+			result := make([]string, 0, len(selected))
+			for _, opt := range selected {
+				if optMap, ok := opt.(map[string]interface{}); ok {
+					if value, ok := optMap["value"].(string); ok && value != "" {
+						result = append(result, value)
+					}
+				}
+			}
+			return result
+		}
+	}
+
+	if value := extractTextValue(values, blockID, actionID); value != "" {
+		return []string{value}
+	}
+	return nil
+}
+
+// extractTextValue returns the string value for a given blockID/actionID from
+// a Slack view state. It handles both plain-text inputs and static selects.
+func extractTextValue(values map[string]map[string]interface{}, blockID, actionID string) string {
+	block, ok := values[blockID]
+	if !ok {
+		return ""
+	}
+
+	action, ok := block[actionID]
+	if !ok {
+		return ""
+	}
+
+	actionMap, ok := action.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	// Static select: selected_option.value
+	if selectedOption, ok := actionMap["selected_option"].(map[string]interface{}); ok {
+		if value, ok := selectedOption["value"].(string); ok {
+			return value
+		}
+	}
+
+	// Conversations select: selected_conversation
+	if value, ok := actionMap["selected_conversation"].(string); ok {
+		return value
+	}
+
+	// Plain text input: value
+	if value, ok := actionMap["value"].(string); ok {
+		return value
+	}
+
+	return ""
+}
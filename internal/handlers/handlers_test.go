@@ -0,0 +1,5942 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+	"github.com/its-the-vibe/SlashVibePR/internal/version"
+)
+
+// assertNoPanic runs fn and fails the test if fn panics.
+func assertNoPanic(t *testing.T, label string, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("%s caused a panic: %v", label, r)
+		}
+	}()
+	fn()
+}
+
+// unreachableRedis returns a client whose commands fail fast with a
+// connection error, for exercising paths that tolerate Redis failures.
+func unreachableRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		MaxRetries:  -1,
+		DialTimeout: 50 * time.Millisecond,
+	})
+}
+
+// fakeViewID is the view ID fakeSlack gives every view it opens or pushes.
+const fakeViewID = "V-fake"
+
+// slackCall is one Slack Web API call recorded by fakeSlack.
+type slackCall struct {
+	Method  string // Web API method, e.g. "views.open"
+	View    slack.ModalViewRequest
+	ViewID  string
+	Channel string
+	User    string
+	Text    string
+}
+
+// fakeSlack is a SlackClient that records calls instead of reaching Slack.
+// Every call fails with err when it is set.
+type fakeSlack struct {
+	mu          sync.Mutex
+	calls       []slackCall
+	err         error
+	groups      map[string][]string
+	timezones   map[string]string
+	userLookups int
+	// history is what conversations.history returns.
+	history []slack.Message
+}
+
+func (f *fakeSlack) record(call slackCall) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return f.err
+}
+
+// recorded returns a copy of the calls made so far.
+func (f *fakeSlack) recorded() []slackCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]slackCall(nil), f.calls...)
+}
+
+// methods returns the Web API method of each call made so far.
+func (f *fakeSlack) methods() []string {
+	var methods []string
+	for _, c := range f.recorded() {
+		methods = append(methods, c.Method)
+	}
+	return methods
+}
+
+func (f *fakeSlack) viewResponse(err error) (*slack.ViewResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &slack.ViewResponse{View: slack.View{ID: fakeViewID}}, nil
+}
+
+func (f *fakeSlack) OpenViewContext(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	return f.viewResponse(f.record(slackCall{Method: "views.open", View: view}))
+}
+
+func (f *fakeSlack) PushViewContext(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	return f.viewResponse(f.record(slackCall{Method: "views.push", View: view}))
+}
+
+func (f *fakeSlack) UpdateViewContext(ctx context.Context, view slack.ModalViewRequest, externalID, hash, viewID string) (*slack.ViewResponse, error) {
+	return f.viewResponse(f.record(slackCall{Method: "views.update", View: view, ViewID: viewID}))
+}
+
+func (f *fakeSlack) PostEphemeralContext(ctx context.Context, channelID, userID string, options ...slack.MsgOption) (string, error) {
+	_, values, _ := slack.UnsafeApplyMsgOptions("", channelID, "", options...)
+	return "", f.record(slackCall{Method: "chat.postEphemeral", Channel: channelID, User: userID, Text: values.Get("text")})
+}
+
+func (f *fakeSlack) PublishViewContext(ctx context.Context, req slack.PublishViewContextRequest) (*slack.ViewResponse, error) {
+	return f.viewResponse(f.record(slackCall{Method: "views.publish", User: req.UserID}))
+}
+
+func (f *fakeSlack) UnfurlMessageContext(ctx context.Context, channelID, timestamp string, unfurls map[string]slack.Attachment, options ...slack.MsgOption) (string, string, string, error) {
+	return "", "", "", f.record(slackCall{Method: "chat.unfurl", Channel: channelID})
+}
+
+func (f *fakeSlack) GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	return &slack.GetConversationHistoryResponse{Messages: f.history}, f.record(slackCall{Method: "conversations.history", Channel: params.ChannelID})
+}
+
+func (f *fakeSlack) UpdateMessageContext(ctx context.Context, channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error) {
+	return channelID, timestamp, "", f.record(slackCall{Method: "chat.update", Channel: channelID})
+}
+
+func (f *fakeSlack) GetUserGroupMembersContext(ctx context.Context, userGroup string, options ...slack.GetUserGroupMembersOption) ([]string, error) {
+	return f.groups[userGroup], f.record(slackCall{Method: "usergroups.users.list"})
+}
+
+// GetUserInfoContext answers from f.timezones. Lookups are counted rather
+// than recorded, so flows showing dates keep their expected Slack calls.
+func (f *fakeSlack) GetUserInfoContext(ctx context.Context, user string) (*slack.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.userLookups++
+	return &slack.User{ID: user, TZ: f.timezones[user]}, nil
+}
+
+// assertSlackCalls fails the test unless the fake saw exactly want methods,
+// in order.
+func assertSlackCalls(t *testing.T, label string, f *fakeSlack, want ...string) {
+	t.Helper()
+	if got := f.methods(); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: expected Slack calls %v, got %v", label, want, got)
+	}
+}
+
+// newTestRedis starts an in-memory Redis that lives as long as the test and
+// returns it with a client connected to it.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+// testConfig returns the default config for org acme and channel C123, with
+// the loading watchdog off so no timer outlives the test.
+func testConfig(t *testing.T) config.Config {
+	t.Helper()
+	config, err := config.Parse([]byte("github:\n  org: acme\nslack:\n  channel_id: C123\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected config error: %v", err)
+	}
+	config.PoppitTimeout = 0
+	return config
+}
+
+// popPoppitCommand removes and returns the oldest command queued for Poppit.
+func popPoppitCommand(t *testing.T, mr *miniredis.Miniredis, config config.Config) poppit.Command {
+	t.Helper()
+	data, err := mr.Lpop(config.RedisPoppitList)
+	if err != nil {
+		t.Fatalf("expected a queued Poppit command: %v", err)
+	}
+	var cmd poppit.Command
+	if err := json.Unmarshal([]byte(data), &cmd); err != nil {
+		t.Fatalf("invalid Poppit command %q: %v", data, err)
+	}
+	return cmd
+}
+
+// slackLinerPosts returns the messages queued for SlackLiner.
+func slackLinerPosts(t *testing.T, mr *miniredis.Miniredis, config config.Config) []SlackLinerMessage {
+	t.Helper()
+	items, _ := mr.List(config.RedisSlackLinerList)
+	msgs := make([]SlackLinerMessage, len(items))
+	for i, item := range items {
+		if err := json.Unmarshal([]byte(item), &msgs[i]); err != nil {
+			t.Fatalf("invalid SlackLiner message %q: %v", item, err)
+		}
+	}
+	return msgs
+}
+
+// ---- Modal creation tests ----
+
+func TestPRChooserModalStructure(t *testing.T) {
+	prs := []PRItem{
+		{Number: 1, Title: "Fix bug"},
+		{Number: 2, Title: "Add feature"},
+	}
+	modal := slackui.PRChooserModal(len(prs), "org/repo", slackui.PRChooserFilters{}, false, slackui.PRChooserDestination{}, config.DefaultMessageTTL, `{"repo":"org/repo"}`)
+
+	if modal.Type != slack.VTModal {
+		t.Errorf("expected modal type 'modal', got %q", modal.Type)
+	}
+	if modal.CallbackID != slackui.PRModalCallbackID {
+		t.Errorf("expected callback_id %q, got %q", slackui.PRModalCallbackID, modal.CallbackID)
+	}
+	if modal.Submit == nil || modal.Submit.Text != "Post to Channel" {
+		t.Errorf("expected submit button labelled 'Post to Channel'")
+	}
+	if modal.PrivateMetadata != `{"repo":"org/repo"}` {
+		t.Errorf("unexpected private_metadata: %q", modal.PrivateMetadata)
+	}
+	if len(modal.Blocks.BlockSet) != 7 {
+		t.Errorf("expected 7 blocks, got %d", len(modal.Blocks.BlockSet))
+	}
+}
+
+func TestPRChooserModalUsesExternalSelect(t *testing.T) {
+	prs := []PRItem{
+		{Number: 42, Title: "My PR"},
+		{Number: 100, Title: "Another PR"},
+	}
+	modal := slackui.PRChooserModal(len(prs), "org/repo", slackui.PRChooserFilters{}, false, slackui.PRChooserDestination{}, config.DefaultMessageTTL, "")
+
+	inputBlock, ok := modal.Blocks.BlockSet[1].(*slack.InputBlock)
+	if !ok {
+		t.Fatal("expected second block to be an InputBlock")
+	}
+
+	selectEl, ok := inputBlock.Element.(*slack.SelectBlockElement)
+	if !ok {
+		t.Fatal("expected element to be SelectBlockElement")
+	}
+	if selectEl.Type != slack.OptTypeExternal {
+		t.Errorf("expected external select type, got %q", selectEl.Type)
+	}
+	if selectEl.ActionID != slackui.PRSelectActionID {
+		t.Errorf("expected action_id %q, got %q", slackui.PRSelectActionID, selectEl.ActionID)
+	}
+	if selectEl.MinQueryLength == nil || *selectEl.MinQueryLength != 0 {
+		t.Error("expected min_query_length 0 so options load before typing")
+	}
+}
+
+func TestPROptions(t *testing.T) {
+	options := prOptions([]PRItem{
+		{Number: 42, Title: "My PR"},
+		{Number: 100, Title: "Another PR"},
+	}, time.Now())
+	if len(options) != 2 {
+		t.Fatalf("expected 2 options, got %d", len(options))
+	}
+	if options[0].Value != "42" {
+		t.Errorf("expected first option value '42', got %q", options[0].Value)
+	}
+	if options[1].Value != "100" {
+		t.Errorf("expected second option value '100', got %q", options[1].Value)
+	}
+}
+
+func TestPROptionsTitleTruncation(t *testing.T) {
+	longTitle := make([]byte, 100)
+	for i := range longTitle {
+		longTitle[i] = 'a'
+	}
+	options := prOptions([]PRItem{{Number: 1, Title: string(longTitle)}}, time.Now())
+
+	if len(options[0].Text.Text) > 75 {
+		t.Errorf("option text should be truncated to at most 75 chars, got %d", len(options[0].Text.Text))
+	}
+}
+
+// ---- extractTextValue tests ----
+
+func TestExtractTextValuePlainInput(t *testing.T) {
+	values := map[string]map[string]interface{}{
+		"repo_block": {
+			"repo_input": map[string]interface{}{
+				"type":  "plain_text_input",
+				"value": "org/repo",
+			},
+		},
+	}
+	got := extractTextValue(values, "repo_block", "repo_input")
+	if got != "org/repo" {
+		t.Errorf("expected 'org/repo', got %q", got)
+	}
+}
+
+func TestExtractTextValueStaticSelect(t *testing.T) {
+	values := map[string]map[string]interface{}{
+		"pr_block": {
+			"pr_select": map[string]interface{}{
+				"type": "static_select",
+				"selected_option": map[string]interface{}{
+					"value": "42",
+				},
+			},
+		},
+	}
+	got := extractTextValue(values, "pr_block", "pr_select")
+	if got != "42" {
+		t.Errorf("expected '42', got %q", got)
+	}
+}
+
+func TestExtractTextValueMissingBlock(t *testing.T) {
+	values := map[string]map[string]interface{}{}
+	got := extractTextValue(values, "missing_block", "action")
+	if got != "" {
+		t.Errorf("expected empty string, got %q", got)
+	}
+}
+
+func TestExtractTextValueNullInput(t *testing.T) {
+	values := map[string]map[string]interface{}{
+		"repo_block": {
+			"repo_input": map[string]interface{}{
+				"type":  "plain_text_input",
+				"value": nil,
+			},
+		},
+	}
+	got := extractTextValue(values, "repo_block", "repo_input")
+	if got != "" {
+		t.Errorf("expected empty string for null value, got %q", got)
+	}
+}
+
+// ---- PR list JSON parsing tests ----
+
+func TestParsePRListJSON(t *testing.T) {
+	raw := `[
+		{"number": 1, "title": "Fix bug", "author": {"login": "alice"}, "url": "https://github.com/org/repo/pull/1", "headRefName": "fix/bug"},
+		{"number": 2, "title": "Add feature", "author": {"login": "bob"}, "url": "https://github.com/org/repo/pull/2", "headRefName": "feat/feature"}
+	]`
+
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(raw), &prs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(prs) != 2 {
+		t.Fatalf("expected 2 PRs, got %d", len(prs))
+	}
+	if prs[0].Number != 1 || prs[0].Title != "Fix bug" || prs[0].Author.Login != "alice" {
+		t.Errorf("unexpected PR[0]: %+v", prs[0])
+	}
+	if prs[1].Number != 2 || prs[1].Author.Login != "bob" {
+		t.Errorf("unexpected PR[1]: %+v", prs[1])
+	}
+}
+
+func TestParsePRListEmptyJSON(t *testing.T) {
+	var prs []PRItem
+	if err := json.Unmarshal([]byte("[]"), &prs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(prs) != 0 {
+		t.Errorf("expected 0 PRs, got %d", len(prs))
+	}
+}
+
+// ---- handleSlashCommand filtering tests ----
+
+func TestHandleSlashCommandIgnoresNonPR(t *testing.T) {
+	commands := []string{"/issue", "/deploy", "/help", ""}
+
+	// Non-/pr commands are ignored without calling Slack.
+	for _, cmd := range commands {
+		payload, _ := json.Marshal(SlackCommand{Command: cmd, TriggerID: "tid"})
+		fake := &fakeSlack{}
+		handleSlashCommand(context.Background(), nil, fake, string(payload), config.Config{})
+		assertSlackCalls(t, fmt.Sprintf("command %q", cmd), fake)
+	}
+}
+
+func TestHandleSlashCommandWithRepoArgSkipsRepoChooser(t *testing.T) {
+	// When a repo argument is provided, handleSlashCommand opens the loading
+	// modal (not the repo chooser) while the PRs are fetched.
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "myrepo", TriggerID: "tid"})
+	assertShowsView(t, "repo arg provided", "views.open", slackui.LoadingModal(), func(fake *fakeSlack) {
+		handleSlashCommand(context.Background(), unreachableRedis(), fake, string(payload), config.Config{GitHubOrg: "my-org"})
+	})
+}
+
+// assertShowsView runs fn with a fakeSlack and fails the test unless fn made
+// exactly one Slack call, method, showing want.
+func assertShowsView(t *testing.T, label, method string, want slack.ModalViewRequest, fn func(fake *fakeSlack)) {
+	t.Helper()
+	fake := &fakeSlack{}
+	fn(fake)
+	assertSlackCalls(t, label, fake, method)
+	if calls := fake.recorded(); len(calls) == 1 && !reflect.DeepEqual(calls[0].View, want) {
+		t.Errorf("%s: showed %q, want %q", label, calls[0].View.Title.Text, want.Title.Text)
+	}
+}
+
+func TestHandleSlashCommandWithoutRepoArgOpensRepoChooser(t *testing.T) {
+	// When no repo argument is provided, handleSlashCommand opens the repo
+	// chooser modal.
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "", TriggerID: "tid"})
+	assertShowsView(t, "no repo arg", "views.open", slackui.RepoChooserModal(nil, "", ""), func(fake *fakeSlack) {
+		handleSlashCommand(context.Background(), nil, fake, string(payload), config.Config{})
+	})
+}
+
+func TestHandleSlashCommandInvalidRepoArgIsIgnored(t *testing.T) {
+	// An invalid repo arg (e.g. containing slashes or shell metacharacters) should
+	// be rejected silently — the function should return without touching the Slack client.
+	invalidArgs := []string{"org/repo/extra", "repo; rm -rf /", "repo name", "../etc", "bad_org/repo"}
+	for _, arg := range invalidArgs {
+		payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: arg, TriggerID: "tid"})
+		fake := &fakeSlack{}
+		handleSlashCommand(context.Background(), nil, fake, string(payload), config.Config{GitHubOrg: "my-org"})
+		assertSlackCalls(t, fmt.Sprintf("invalid repo arg %q", arg), fake)
+	}
+}
+
+func TestHandleSlashCommandWhitespaceOnlyTextOpensRepoChooser(t *testing.T) {
+	// Whitespace-only text should be treated as no repo argument.
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "   ", TriggerID: "tid"})
+	assertShowsView(t, "whitespace-only text", "views.open", slackui.RepoChooserModal(nil, "", ""), func(fake *fakeSlack) {
+		handleSlashCommand(context.Background(), nil, fake, string(payload), config.Config{})
+	})
+}
+
+// ---- SlackLinerMessage serialisation test ----
+
+func TestSlackLinerMessageSerialization(t *testing.T) {
+	pr := &PRItem{Number: 7, Title: "My PR", URL: "https://github.com/org/repo/pull/7"}
+	pr.Author.Login = "carol"
+
+	config := config.Config{SlackChannelID: "C12345", RedisSlackLinerList: "slack_messages"}
+
+	msg := SlackLinerMessage{
+		Channel: config.SlackChannelID,
+		Text:    fmt.Sprintf("📋 PR #%d: %s", pr.Number, pr.Title),
+		TTL:     86400,
+		Metadata: map[string]interface{}{
+			"event_type": "pr_posted",
+			"event_payload": map[string]interface{}{
+				"pr_number":  pr.Number,
+				"repository": "org/repo",
+				"pr_url":     pr.URL,
+				"author":     pr.Author.Login,
+			},
+		},
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal SlackLinerMessage: %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+
+	if out["channel"] != "C12345" {
+		t.Errorf("unexpected channel: %v", out["channel"])
+	}
+	if out["ttl"].(float64) != 86400 {
+		t.Errorf("unexpected ttl: %v", out["ttl"])
+	}
+}
+
+func TestPostPRToSlackMetadataIncludesBranch(t *testing.T) {
+	pr := &PRItem{
+		Number:      42,
+		Title:       "Fix bug",
+		URL:         "https://github.com/example/repo/pull/42",
+		HeadRefName: "feature/fix-bug",
+	}
+	pr.Author.Login = "octocat"
+
+	msg := SlackLinerMessage{
+		Channel: "C12345",
+		Text:    "test",
+		TTL:     86400,
+		Metadata: map[string]interface{}{
+			"event_type": "pr_posted",
+			"event_payload": map[string]interface{}{
+				"pr_number":  pr.Number,
+				"repository": "example/repo",
+				"pr_url":     pr.URL,
+				"author":     pr.Author.Login,
+				"title":      pr.Title,
+				"posted_by":  "alice",
+				"branch":     pr.HeadRefName,
+			},
+		},
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to marshal SlackLinerMessage: %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+
+	metadata, ok := out["metadata"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected metadata to be a map")
+	}
+	payload, ok := metadata["event_payload"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected event_payload to be a map")
+	}
+	if payload["branch"] != "feature/fix-bug" {
+		t.Errorf("expected branch 'feature/fix-bug', got %v", payload["branch"])
+	}
+}
+
+// ---- BlockActionPayload parsing tests ----
+
+func TestBlockActionPayloadParsing(t *testing.T) {
+	raw := `{
+		"type": "block_actions",
+		"trigger_id": "tid123",
+		"user": {"id": "U001", "username": "alice"},
+		"view": {"id": "V001"},
+		"actions": [{
+			"action_id": "SlashVibeIssue",
+			"block_id": "repo_block",
+			"type": "external_select",
+			"selected_option": {"value": "my-repo"}
+		}]
+	}`
+
+	var p BlockActionPayload
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if p.TriggerID != "tid123" {
+		t.Errorf("expected trigger_id 'tid123', got %q", p.TriggerID)
+	}
+	if p.User.Username != "alice" {
+		t.Errorf("expected username 'alice', got %q", p.User.Username)
+	}
+	if len(p.Actions) != 1 {
+		t.Fatalf("expected 1 action, got %d", len(p.Actions))
+	}
+	if p.Actions[0].ActionID != "SlashVibeIssue" {
+		t.Errorf("expected action_id 'SlashVibeIssue', got %q", p.Actions[0].ActionID)
+	}
+	if p.Actions[0].SelectedOption.Value != "my-repo" {
+		t.Errorf("expected selected value 'my-repo', got %q", p.Actions[0].SelectedOption.Value)
+	}
+}
+
+func TestHandleBlockActionIgnoresUnknownActionID(t *testing.T) {
+	// A block action with a different action_id should be silently ignored.
+	payload, _ := json.Marshal(BlockActionPayload{
+		Type:      "block_actions",
+		TriggerID: "tid",
+		Actions: []struct {
+			ActionID       string `json:"action_id"`
+			BlockID        string `json:"block_id"`
+			Type           string `json:"type"`
+			Value          string `json:"value"`
+			SelectedOption struct {
+				Value string `json:"value"`
+			} `json:"selected_option"`
+			SelectedOptions []struct {
+				Value string `json:"value"`
+			} `json:"selected_options"`
+		}{{ActionID: "other_action", SelectedOption: struct {
+			Value string `json:"value"`
+		}{Value: "some-value"}}},
+	})
+
+	assertNoPanic(t, "unknown action_id", func() {
+		handleBlockAction(context.Background(), nil, nil, string(payload), config.Config{GitHubOrg: "my-org"})
+	})
+}
+
+func TestHandleBlockActionEmptyValueIsIgnored(t *testing.T) {
+	// A repo selection action with empty value should be silently ignored.
+	payload, _ := json.Marshal(BlockActionPayload{
+		Type:      "block_actions",
+		TriggerID: "tid",
+		Actions: []struct {
+			ActionID       string `json:"action_id"`
+			BlockID        string `json:"block_id"`
+			Type           string `json:"type"`
+			Value          string `json:"value"`
+			SelectedOption struct {
+				Value string `json:"value"`
+			} `json:"selected_option"`
+			SelectedOptions []struct {
+				Value string `json:"value"`
+			} `json:"selected_options"`
+		}{{ActionID: slackui.SlashVibeIssueActionID, SelectedOption: struct {
+			Value string `json:"value"`
+		}{Value: ""}}},
+	})
+
+	assertNoPanic(t, "empty repo value", func() {
+		handleBlockAction(context.Background(), nil, nil, string(payload), config.Config{GitHubOrg: "my-org"})
+	})
+}
+
+func TestHandleBlockActionWithRepoOpensLoadingModal(t *testing.T) {
+	// Choosing a repo pushes the loading modal while the PRs are fetched.
+	payload, _ := json.Marshal(BlockActionPayload{
+		Type:      "block_actions",
+		TriggerID: "tid",
+		User: struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		}{Username: "alice"},
+		Actions: []struct {
+			ActionID       string `json:"action_id"`
+			BlockID        string `json:"block_id"`
+			Type           string `json:"type"`
+			Value          string `json:"value"`
+			SelectedOption struct {
+				Value string `json:"value"`
+			} `json:"selected_option"`
+			SelectedOptions []struct {
+				Value string `json:"value"`
+			} `json:"selected_options"`
+		}{{ActionID: slackui.SlashVibeIssueActionID, BlockID: slackui.RepoBlockID, SelectedOption: struct {
+			Value string `json:"value"`
+		}{Value: "my-repo"}}},
+	})
+
+	assertShowsView(t, "valid repo block action", "views.push", slackui.LoadingModal(), func(fake *fakeSlack) {
+		handleBlockAction(context.Background(), unreachableRedis(), fake, string(payload), config.Config{GitHubOrg: "my-org"})
+	})
+}
+
+// ---- Logger tests ----
+
+func TestLogFunctionsDoNotPanic(t *testing.T) {
+	logging.SetLogLevel("DEBUG")
+	assertNoPanic(t, "Debug", func() { logging.Debug("test %d", 1) })
+	assertNoPanic(t, "Info", func() { logging.Info("test %d", 1) })
+	assertNoPanic(t, "Warn", func() { logging.Warn("test %d", 1) })
+	assertNoPanic(t, "Error", func() { logging.Error("test %d", 1) })
+	logging.SetLogLevel("INFO")
+}
+
+// ---- handleViewSubmission tests ----
+
+func TestHandleViewSubmissionUnknownCallbackID(t *testing.T) {
+	// A view submission with an unknown callback_id should be silently ignored.
+	submission := ViewSubmission{
+		Type: "view_submission",
+		View: struct {
+			ID              string `json:"id"`
+			Hash            string `json:"hash"`
+			CallbackID      string `json:"callback_id"`
+			PrivateMetadata string `json:"private_metadata"`
+			State           struct {
+				Values map[string]map[string]interface{} `json:"values"`
+			} `json:"state"`
+		}{
+			CallbackID: "unknown_callback",
+		},
+	}
+	payload, _ := json.Marshal(submission)
+	assertNoPanic(t, "unknown callback_id", func() {
+		handleViewSubmission(context.Background(), nil, nil, string(payload), config.Config{})
+	})
+}
+
+func TestHandleViewSubmissionInvalidJSON(t *testing.T) {
+	// Invalid JSON should be dead-lettered (tolerating Redis failures) without a panic.
+	assertNoPanic(t, "invalid JSON", func() {
+		handleViewSubmission(context.Background(), unreachableRedis(), nil, "{invalid}", config.Config{})
+	})
+}
+
+// ---- handlePoppitOutput tests ----
+
+func TestHandlePoppitOutputWrongType(t *testing.T) {
+	// A Poppit output with a different type should be silently ignored.
+	output := poppit.Output{
+		Type:   "some-other-type",
+		Output: "[]",
+	}
+	payload, _ := json.Marshal(output)
+	assertNoPanic(t, "wrong poppit type", func() {
+		handlePoppitOutput(context.Background(), nil, nil, string(payload), config.Config{})
+	})
+}
+
+func TestHandlePoppitOutputInvalidJSON(t *testing.T) {
+	// Invalid JSON should be dead-lettered (tolerating Redis failures) without a panic.
+	assertNoPanic(t, "invalid JSON", func() {
+		handlePoppitOutput(context.Background(), unreachableRedis(), nil, "{invalid}", config.Config{})
+	})
+}
+
+func TestHandlePoppitOutputNoMetadata(t *testing.T) {
+	// A slash-vibe-pr-list output with no metadata should warn and return without panic.
+	output := poppit.Output{
+		Type:   poppitPRListType,
+		Output: "[]",
+	}
+	payload, _ := json.Marshal(output)
+	assertNoPanic(t, "no metadata", func() {
+		handlePoppitOutput(context.Background(), nil, nil, string(payload), config.Config{})
+	})
+}
+
+func TestHandlePoppitOutputSinglePRShortCircuitsModal(t *testing.T) {
+	// When exactly one PR is returned, handlePoppitOutput posts it and shows
+	// the auto-posted modal instead of the chooser. A dry run with no user to
+	// echo to posts nothing, so Redis is not needed.
+	pr := PRItem{Number: 7, Title: "Only PR"}
+	pr.Author.Login = "alice"
+	pr.URL = "https://github.com/org/repo/pull/7"
+	prJSON, _ := json.Marshal([]PRItem{pr})
+
+	output := poppit.Output{
+		Type:   poppitPRListType,
+		Output: string(prJSON),
+		Metadata: map[string]interface{}{
+			"view_id":  "V123",
+			"repo":     "org/repo",
+			"username": "alice",
+			"dry_run":  true,
+		},
+	}
+	payload, _ := json.Marshal(output)
+
+	fake := &fakeSlack{}
+	handlePoppitOutput(context.Background(), unreachableRedis(), fake, string(payload), config.Config{})
+	assertSlackCalls(t, "single PR auto-post path", fake, "views.update")
+	if calls := fake.recorded(); len(calls) == 1 {
+		if calls[0].ViewID != "V123" || !reflect.DeepEqual(calls[0].View, slackui.AutoPostedModal("org/repo", pr.Number, pr.Title)) {
+			t.Errorf("expected the auto-posted modal on V123, got %q on %s", calls[0].View.Title.Text, calls[0].ViewID)
+		}
+	}
+}
+
+func TestHandlePoppitOutputMultiplePRsShowsChooser(t *testing.T) {
+	// When more than one PR is returned, handlePoppitOutput stores a PR
+	// session for the chooser. Redis is unreachable here, so the modal is
+	// updated with the session error, confirming the chooser path is reached.
+	prs := []PRItem{
+		{Number: 1, Title: "First PR"},
+		{Number: 2, Title: "Second PR"},
+	}
+	prJSON, _ := json.Marshal(prs)
+
+	output := poppit.Output{
+		Type:   poppitPRListType,
+		Output: string(prJSON),
+		Metadata: map[string]interface{}{
+			"view_id":  "V456",
+			"repo":     "org/repo",
+			"username": "bob",
+		},
+	}
+	payload, _ := json.Marshal(output)
+
+	fake := &fakeSlack{}
+	handlePoppitOutput(context.Background(), unreachableRedis(), fake, string(payload), config.Config{})
+	assertSlackCalls(t, "multiple PRs chooser path", fake, "views.update")
+	want := slackui.ErrorModal("Failed to prepare the pull request list. Please try again.")
+	if calls := fake.recorded(); len(calls) == 1 && !reflect.DeepEqual(calls[0].View, want) {
+		t.Errorf("expected the session error modal, got %+v", calls[0].View)
+	}
+}
+
+// ---- createAutoPostedModal tests ----
+
+func TestAutoPostedModalStructure(t *testing.T) {
+	pr := &PRItem{Number: 42, Title: "My feature"}
+	modal := slackui.AutoPostedModal("org/repo", pr.Number, pr.Title)
+
+	if modal.Type != slack.VTModal {
+		t.Errorf("expected modal type 'modal', got %q", modal.Type)
+	}
+	if modal.Submit != nil {
+		t.Error("auto-posted modal should not have a submit button")
+	}
+	if modal.Close == nil || modal.Close.Text != "Close" {
+		t.Errorf("expected close button labelled 'Close'")
+	}
+	if len(modal.Blocks.BlockSet) != 1 {
+		t.Errorf("expected 1 block, got %d", len(modal.Blocks.BlockSet))
+	}
+}
+
+func TestAutoPostedModalContent(t *testing.T) {
+	pr := &PRItem{Number: 42, Title: "My feature"}
+	modal := slackui.AutoPostedModal("org/repo", pr.Number, pr.Title)
+
+	section, ok := modal.Blocks.BlockSet[0].(*slack.SectionBlock)
+	if !ok {
+		t.Fatal("expected first block to be a SectionBlock")
+	}
+	if section.Text == nil {
+		t.Fatal("expected section text to be non-nil")
+	}
+	text := section.Text.Text
+	if !strings.Contains(text, "42") {
+		t.Errorf("expected PR number 42 in modal text, got %q", text)
+	}
+	if !strings.Contains(text, "org/repo") {
+		t.Errorf("expected repo name in modal text, got %q", text)
+	}
+}
+
+// ---- PRModalPrivateMetadata serialisation ----
+
+func TestPRModalPrivateMetadataRoundtrip(t *testing.T) {
+	meta := PRModalPrivateMetadata{Repo: "my-org/my-repo"}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var out PRModalPrivateMetadata
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if out.Repo != "my-org/my-repo" {
+		t.Errorf("expected 'my-org/my-repo', got %q", out.Repo)
+	}
+}
+
+// ---- Base-branch filtering tests ----
+
+func TestParsePRArgsBaseFlag(t *testing.T) {
+	cases := []struct {
+		text     string
+		repo     string
+		base     string
+		wantErr  bool
+		defaults string
+	}{
+		{text: "", repo: "", base: ""},
+		{text: "myrepo", repo: "myrepo", base: ""},
+		{text: "myrepo --base main", repo: "myrepo", base: "main"},
+		{text: "myrepo --base=release/*", repo: "myrepo", base: "release/*"},
+		{text: "myrepo", repo: "myrepo", base: "develop", defaults: "develop"},
+		{text: "myrepo --base main", repo: "myrepo", base: "main", defaults: "develop"},
+		{text: "myrepo --base", wantErr: true},
+		{text: "myrepo --base ma;in", wantErr: true},
+		{text: "myrepo --unknown x", wantErr: true},
+		{text: "myrepo extra", wantErr: true},
+	}
+	for _, tc := range cases {
+		args, err := parsePRArgs(tc.text, config.Config{GitHubBaseBranch: tc.defaults})
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parsePRArgs(%q): expected error, got nil", tc.text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePRArgs(%q): unexpected error: %v", tc.text, err)
+			continue
+		}
+		if args.Repo != tc.repo || args.List.Base != tc.base {
+			t.Errorf("parsePRArgs(%q) = (%q, %q), want (%q, %q)", tc.text, args.Repo, args.List.Base, tc.repo, tc.base)
+		}
+	}
+}
+
+func TestBuildPRListCommandBase(t *testing.T) {
+	cmd := buildPRListCommand("org/repo", PRListOptions{Base: "main"}, config.DefaultPRLimit)
+	if !strings.HasSuffix(cmd, " --base main") {
+		t.Errorf("expected exact base to be passed via --base, got %q", cmd)
+	}
+
+	cmd = buildPRListCommand("org/repo", PRListOptions{Base: "release/*"}, config.DefaultPRLimit)
+	if strings.Contains(cmd, "--base") {
+		t.Errorf("glob base must not be passed to gh, got %q", cmd)
+	}
+	if !strings.Contains(cmd, "baseRefName") {
+		t.Errorf("expected baseRefName in --json fields, got %q", cmd)
+	}
+
+	// A glob is matched after the limit, so more PRs are listed for it.
+	opts := PRListOptions{Base: "release/*"}
+	if got := opts.fetchLimit(config.DefaultPRLimit); got != baseGlobListLimit {
+		t.Errorf("expected a glob base to raise the limit to %d, got %d", baseGlobListLimit, got)
+	}
+	if got := (PRListOptions{Base: "main"}).fetchLimit(config.DefaultPRLimit); got != config.DefaultPRLimit {
+		t.Errorf("expected an exact base to keep the limit, got %d", got)
+	}
+}
+
+func TestPRListSort(t *testing.T) {
+	for text, want := range map[string]string{"myrepo --sort updated-asc": "updated-asc", "myrepo --sort=created-desc": "created-desc", "myrepo": ""} {
+		args, err := parsePRArgs(text, config.Config{})
+		if err != nil || args.List.Sort != want {
+			t.Errorf("parsePRArgs(%q): expected sort %q, got %q, %v", text, want, args.List.Sort, err)
+		}
+	}
+	for _, text := range []string{"myrepo --sort", "myrepo --sort popularity", "myrepo --sort=created"} {
+		if _, err := parsePRArgs(text, config.Config{}); err == nil {
+			t.Errorf("parsePRArgs(%q): expected error", text)
+		}
+	}
+
+	cmd := buildPRListCommand("org/repo", PRListOptions{Base: "main", Sort: "updated-desc"}, config.DefaultPRLimit)
+	if !strings.HasSuffix(cmd, ` --base main --search "sort:updated-desc"`) {
+		t.Errorf("expected the sort as a search qualifier, got %q", cmd)
+	}
+
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	opts := PRListOptions{}
+	applyPRSort(ctx, rdb, "U1", &opts)
+	if opts.Sort != "" {
+		t.Errorf("expected gh's default order without a saved choice, got %q", opts.Sort)
+	}
+	opts.Sort = "created-asc"
+	applyPRSort(ctx, rdb, "U1", &opts)
+	if got := loadPRSort(ctx, rdb, "U1"); got != "created-asc" {
+		t.Errorf("expected the explicit sort to be remembered, got %q", got)
+	}
+	opts = PRListOptions{}
+	applyPRSort(ctx, rdb, "U1", &opts)
+	if opts.Sort != "created-asc" {
+		t.Errorf("expected the remembered sort to apply, got %q", opts.Sort)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	cases := []struct {
+		text, wantErr string
+	}{
+		{"myrepo --multi=yes", "--multi does not take a value"},
+		{"myrepo --author", "--author requires a GitHub login"},
+		{"myrepo --author --multi", "--author requires a GitHub login"},
+		{"myrepo --author=", "--author requires a GitHub login"},
+		{"myrepo -m", `unknown flag "-m"`},
+		{"myrepo --colour red", `unknown flag "--colour"`},
+	}
+	for _, c := range cases {
+		if _, err := parsePRArgs(c.text, config.Config{}); err == nil || err.Error() != c.wantErr {
+			t.Errorf("parsePRArgs(%q): expected error %q, got %v", c.text, c.wantErr, err)
+		}
+	}
+
+	var args prArgs
+	positional, err := parseArgs(strings.Fields("--multi a -- --b"), prFlags, &args)
+	if err != nil || !reflect.DeepEqual(positional, []string{"a", "--b"}) || !args.Multi {
+		t.Errorf("expected -- to end flag parsing, got %q, %+v, %v", positional, args, err)
+	}
+}
+
+func TestPRListAuthor(t *testing.T) {
+	args, err := parsePRArgs("--author alice myrepo --base main", config.Config{})
+	if err != nil || args.Repo != "myrepo" || args.List.Author != "alice" || args.List.Base != "main" {
+		t.Fatalf("unexpected args %+v: %v", args, err)
+	}
+	if _, err := parsePRArgs("myrepo --author=al;ice", config.Config{}); err == nil {
+		t.Error("expected an invalid author to be rejected")
+	}
+
+	cmd := buildPRListCommand("org/repo", PRListOptions{Author: "alice"}, config.DefaultPRLimit)
+	if !strings.HasSuffix(cmd, " --author alice") {
+		t.Errorf("expected --author in the gh command, got %q", cmd)
+	}
+
+	prs := []PRItem{{Number: 1}, {Number: 2}, {Number: 3}}
+	prs[0].Author.Login = "bob"
+	prs[1].Author.Login = "Alice"
+	prs[2].Author.Login = "bob"
+	if got := filterPRsByAuthor(prs, "alice"); len(got) != 1 || got[0].Number != 2 {
+		t.Errorf("expected only Alice's PR, got %+v", got)
+	}
+	if got := prAuthors(prs); !reflect.DeepEqual(got, []string{"Alice", "bob"}) {
+		t.Errorf("expected distinct sorted authors, got %q", got)
+	}
+	if got := describePRFilters(PRListOptions{Base: "main", Author: "bob"}); got != " targeting `main` by `bob`" {
+		t.Errorf("unexpected filter description %q", got)
+	}
+}
+
+func TestPRListLabels(t *testing.T) {
+	args, err := parsePRArgs("myrepo --label backend --label=ui,needs-review", config.Config{})
+	if err != nil || !reflect.DeepEqual(args.List.Labels, []string{"backend", "ui", "needs-review"}) {
+		t.Fatalf("unexpected labels %q: %v", args.List.Labels, err)
+	}
+	for _, text := range []string{"myrepo --label a,,b", "myrepo --label " + strings.Repeat("x", maxLabelLength+1)} {
+		if _, err := parsePRArgs(text, config.Config{}); err == nil {
+			t.Errorf("parsePRArgs(%q): expected error", text)
+		}
+	}
+
+	cmd := buildPRListCommand("org/repo", PRListOptions{Labels: []string{"backend", "it's"}}, config.DefaultPRLimit)
+	if !strings.HasSuffix(cmd, ` --label 'backend' --label 'it'\''s'`) {
+		t.Errorf("expected quoted --label flags, got %q", cmd)
+	}
+
+	prs := make([]PRItem, 3)
+	for i, labels := range [][]string{{"backend"}, {"Backend", "ui"}, {"ui"}} {
+		prs[i].Number = i + 1
+		for _, name := range labels {
+			prs[i].Labels = append(prs[i].Labels, struct {
+				Name string `json:"name"`
+			}{Name: name})
+		}
+	}
+	if got := filterPRsByLabels(prs, []string{"backend", "UI"}); len(got) != 1 || got[0].Number != 2 {
+		t.Errorf("expected only the PR with both labels, got %+v", got)
+	}
+	if got := prLabels(prs); !reflect.DeepEqual(got, []string{"Backend", "backend", "ui"}) {
+		t.Errorf("unexpected distinct labels %q", got)
+	}
+	if got := describePRFilters(PRListOptions{Labels: []string{"a", "b"}}); got != " labelled `a`, `b`" {
+		t.Errorf("unexpected filter description %q", got)
+	}
+
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	if err := savePRSession(ctx, rdb, "V1", PRModalPrivateMetadata{Repo: "org/repo", PRs: prs}, config.Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fake := &fakeSlack{}
+	handleLabelFilter(ctx, rdb, fake, "V1", "meta", []string{"ui"}, config.Config{})
+	assertSlackCalls(t, "label filter", fake, "views.update")
+	session, _ := loadPRSession(ctx, rdb, "V1")
+	if len(session.chooserPRs()) != 2 {
+		t.Errorf("expected the chooser narrowed to the 2 ui PRs, got %+v", session.chooserPRs())
+	}
+	if calls := fake.recorded(); len(calls) == 1 && !strings.Contains(calls[0].View.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text, "labels: `ui`") {
+		t.Errorf("expected the label filter in the chooser header, got %+v", calls[0].View.Blocks.BlockSet[0])
+	}
+}
+
+func TestPRListDrafts(t *testing.T) {
+	cases := []struct {
+		text string
+		hide bool
+		want bool
+	}{
+		{"myrepo", false, false},
+		{"myrepo", true, true},
+		{"myrepo --drafts", true, false},
+		{"myrepo --no-drafts", false, true},
+	}
+	for _, c := range cases {
+		args, err := parsePRArgs(c.text, config.Config{GitHubHideDrafts: c.hide})
+		if err != nil || args.List.HideDrafts != c.want {
+			t.Errorf("parsePRArgs(%q) with hide_drafts %v: expected HideDrafts %v, got %v, %v", c.text, c.hide, c.want, args.List.HideDrafts, err)
+		}
+	}
+
+	prs := []PRItem{{Number: 1, Title: "Ready"}, {Number: 2, Title: "WIP", IsDraft: true}}
+	if got := filterPRs(prs, PRListOptions{HideDrafts: true}); len(got) != 1 || got[0].Number != 1 {
+		t.Errorf("expected the draft to be hidden, got %+v", got)
+	}
+	if got := filterPRs(prs, PRListOptions{}); len(got) != 2 {
+		t.Errorf("expected drafts to be listed by default, got %+v", got)
+	}
+	if got := prOptionText(prs[1]); got != "📝 #2: WIP" {
+		t.Errorf("expected the draft marker, got %q", got)
+	}
+	if got := describePRFilters(PRListOptions{HideDrafts: true}); got != " (drafts hidden)" {
+		t.Errorf("unexpected filter description %q", got)
+	}
+}
+
+func TestPRListState(t *testing.T) {
+	for text, want := range map[string]string{"myrepo --state merged": "merged", "myrepo --state=closed": "closed", "myrepo --state open": "", "myrepo": ""} {
+		args, err := parsePRArgs(text, config.Config{})
+		if err != nil || args.List.State != want {
+			t.Errorf("parsePRArgs(%q): expected state %q, got %q, %v", text, want, args.List.State, err)
+		}
+	}
+	if _, err := parsePRArgs("myrepo --state draft", config.Config{}); err == nil {
+		t.Error("expected an unknown state to be rejected")
+	}
+
+	cmd := buildPRListCommand("org/repo", PRListOptions{State: "merged"}, config.DefaultPRLimit)
+	if !strings.HasSuffix(cmd, " --state merged") {
+		t.Errorf("expected --state in the gh command, got %q", cmd)
+	}
+	if cmd := buildPRListCommand("org/repo", PRListOptions{}, config.DefaultPRLimit); strings.Contains(cmd, "--state") {
+		t.Errorf("expected gh's default open state, got %q", cmd)
+	}
+
+	pr := &PRItem{Number: 7, Title: "Ship it", State: "MERGED"}
+	msg := buildPRMessage(pr, "org/repo", "alice", PostOptions{}, config.Config{})
+	if !strings.HasPrefix(msg.Text, "📋 *Merged pull request shared by @alice*") {
+		t.Errorf("expected merged wording, got %q", msg.Text)
+	}
+	pr.State = "OPEN"
+	if msg := buildPRMessage(pr, "org/repo", "alice", PostOptions{}, config.Config{}); !strings.HasPrefix(msg.Text, "📋 *Pull Request shared by @alice*") {
+		t.Errorf("expected the usual wording for open PRs, got %q", msg.Text)
+	}
+}
+
+func TestHandleAuthorFilter(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	prs := []PRItem{{Number: 1}, {Number: 2}, {Number: 3}}
+	prs[0].Author.Login = "alice"
+	prs[1].Author.Login = "bob"
+	prs[2].Author.Login = "bob"
+	if err := savePRSession(ctx, rdb, "V1", PRModalPrivateMetadata{Repo: "org/repo", PRs: prs}, config.Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fake := &fakeSlack{}
+	handleAuthorFilter(ctx, rdb, fake, "V1", "meta", "bob", config.Config{})
+	assertSlackCalls(t, "author filter", fake, "views.update")
+	want := slackui.PRChooserModal(2, "org/repo", slackui.PRChooserFilters{Author: "bob", Authors: []string{"alice", "bob"}}, false, slackui.PRChooserDestination{}, 0, "meta")
+	if calls := fake.recorded(); len(calls) == 1 && !reflect.DeepEqual(calls[0].View, want) {
+		t.Errorf("expected the chooser narrowed to bob, got %+v", calls[0].View)
+	}
+	var suggestion BlockSuggestionPayload
+	suggestion.View.ID = "V1"
+	options, _ := prSuggestions(ctx, rdb, suggestion)
+	if len(options) != 2 {
+		t.Errorf("expected suggestions narrowed to bob's 2 PRs, got %d", len(options))
+	}
+
+	handleAuthorFilter(ctx, rdb, &fakeSlack{}, "V1", "meta", slackui.AnyAuthorValue, config.Config{})
+	if session, _ := loadPRSession(ctx, rdb, "V1"); session.Author != "" {
+		t.Errorf("expected Anyone to clear the author filter, got %q", session.Author)
+	}
+}
+
+func TestFilterPRsByBase(t *testing.T) {
+	prs := []PRItem{
+		{Number: 1, BaseRefName: "main"},
+		{Number: 2, BaseRefName: "release/1.x"},
+		{Number: 3, BaseRefName: "release/2.x"},
+	}
+
+	if got := filterPRsByBase(prs, ""); len(got) != 3 {
+		t.Errorf("empty pattern should keep all PRs, got %d", len(got))
+	}
+	got := filterPRsByBase(prs, "release/*")
+	if len(got) != 2 || got[0].Number != 2 || got[1].Number != 3 {
+		t.Errorf("unexpected glob filter result: %+v", got)
+	}
+	if got := filterPRsByBase(prs, "main"); len(got) != 1 || got[0].Number != 1 {
+		t.Errorf("unexpected exact filter result: %+v", got)
+	}
+}
+
+func TestParsePRArgsQuotedValues(t *testing.T) {
+	args, err := parsePRArgs(`myrepo --milestone "v2.0 release" --label 'needs review' --label=“good first issue”`, config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Repo != "myrepo" || args.List.Milestone != "v2.0 release" {
+		t.Errorf("expected the quoted milestone in one piece, got %+v", args)
+	}
+	if want := []string{"needs review", "good first issue"}; !slices.Equal(args.List.Labels, want) {
+		t.Errorf("expected labels %q, got %q", want, args.List.Labels)
+	}
+	cmd := buildPRListCommand("org/myrepo", args.List, config.DefaultPRLimit)
+	if !strings.Contains(cmd, `--label 'needs review'`) || !strings.Contains(cmd, `milestone:"v2.0 release"`) {
+		t.Errorf("expected the multi-word values to reach gh intact, got %q", cmd)
+	}
+	if word, ok := unknownSubcommand(`myrepo --milestone "v2.0 release"`); ok {
+		t.Errorf("expected the quoted milestone not to read as a subcommand, got %q", word)
+	}
+	if _, err := parsePRArgs(`myrepo --milestone "v2.0 release`, config.Config{}); err == nil {
+		t.Error("expected an unterminated quote to be rejected")
+	}
+}
+
+func TestPRListMilestone(t *testing.T) {
+	args, err := parsePRArgs("myrepo --milestone v2.4", config.Config{})
+	if err != nil || args.List.Milestone != "v2.4" {
+		t.Fatalf("expected milestone v2.4, got %q, %v", args.List.Milestone, err)
+	}
+	if _, err := parsePRArgs(`myrepo --milestone a"b`, config.Config{}); err == nil {
+		t.Error("expected a milestone with a double quote to be rejected")
+	}
+
+	cmd := buildPRListCommand("org/repo", PRListOptions{Milestone: "v2.4", Sort: "created-asc"}, config.DefaultPRLimit)
+	if !strings.HasSuffix(cmd, ` --search 'milestone:"v2.4" sort:created-asc'`) {
+		t.Errorf("expected the milestone as a search qualifier, got %q", cmd)
+	}
+
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(`[{"number":1,"milestone":{"title":"V2.4"},"projectItems":[{"title":"Roadmap"}]},{"number":2,"milestone":null},{"number":3,"milestone":{"title":"v3"}}]`), &prs); err != nil {
+		t.Fatal(err)
+	}
+	if got := filterPRs(prs, PRListOptions{Milestone: "v2.4"}); len(got) != 1 || got[0].Number != 1 {
+		t.Errorf("unexpected milestone filter result: %+v", got)
+	}
+	if got := describePRFilters(PRListOptions{Milestone: "v2.4"}); got != " in milestone `v2.4`" {
+		t.Errorf("unexpected filter description: %q", got)
+	}
+
+	msg := buildPRMessage(&prs[0], "org/repo", "dave", PostOptions{}, config.Config{})
+	if len(msg.Blocks) != 2 {
+		t.Fatalf("expected a context block, got %d blocks", len(msg.Blocks))
+	}
+	line := msg.Blocks[1].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject).Text
+	if line != ":dart: V2.4  ·  :card_index_dividers: Roadmap" {
+		t.Errorf("unexpected context: %q", line)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["milestone"] != "V2.4" || fmt.Sprint(payload["projects"]) != "[Roadmap]" {
+		t.Errorf("unexpected milestone metadata: %v", payload)
+	}
+}
+
+// ---- App Home usage dashboard tests ----
+
+func TestTopRepoCountsOrdering(t *testing.T) {
+	totals := map[string]int64{"org/b": 3, "org/a": 3, "org/c": 5, "org/d": 1}
+	got := topRepoCounts(totals, 3)
+
+	want := []string{"org/c", "org/a", "org/b"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d repos, got %d", len(want), len(got))
+	}
+	for i, repo := range want {
+		if got[i].Repo != repo {
+			t.Errorf("position %d: expected %q, got %q", i, repo, got[i].Repo)
+		}
+	}
+}
+
+func TestCreateHomeViewHidesStatsForNonAdmins(t *testing.T) {
+	view := createHomeView(nil, homeFavorites{}, time.Now())
+	if view.Type != slack.VTHomeTab {
+		t.Errorf("expected home view type, got %q", view.Type)
+	}
+	if len(view.Blocks.BlockSet) != 3 {
+		t.Errorf("expected guide and favorites blocks without stats, got %d", len(view.Blocks.BlockSet))
+	}
+}
+
+func TestCreateHomeViewShowsStatsForAdmins(t *testing.T) {
+	stats := UsageStats{
+		PostsThisWeek:    12,
+		TopRepos:         []RepoCount{{Repo: "org/api", Count: 7}},
+		ReviewSamples:    4,
+		AverageReviewSLA: 90 * time.Minute,
+	}
+	view := createHomeView(&stats, homeFavorites{}, time.Now())
+	if len(view.Blocks.BlockSet) != 5 {
+		t.Fatalf("expected 5 blocks with stats, got %d", len(view.Blocks.BlockSet))
+	}
+	text := view.Blocks.BlockSet[4].(*slack.SectionBlock).Text.Text
+	for _, want := range []string{"12", "org/api", "1h30m0s"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in stats text, got %q", want, text)
+		}
+	}
+}
+
+func TestRecordFirstReviewFeedsWeeklySLA(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	now := time.Now()
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Channel: "C1", PostedAt: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	// Only the first review of a post counts, and PRs not posted are skipped.
+	for _, number := range []int{12, 12, 99} {
+		if err := recordFirstReview(ctx, rdb, "acme/api", number, now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := recordReviewLatency(ctx, rdb, 100*time.Hour, now.AddDate(0, 0, -8)); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := loadWeeklyStats(ctx, rdb, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ReviewSamples != 1 || stats.AverageReviewSLA != 2*time.Hour {
+		t.Errorf("expected one review this week after 2h, got %d after %s", stats.ReviewSamples, stats.AverageReviewSLA)
+	}
+	if ttl := mr.TTL(analyticsReviewKey(now)); ttl <= 0 || ttl > analyticsRetention {
+		t.Errorf("expected the review SLA to expire like the other counters, got TTL %s", ttl)
+	}
+}
+
+func TestHandleAppHomeOpenedIgnoresOtherTabs(t *testing.T) {
+	payload, _ := json.Marshal(AppHomeOpenedEvent{Type: "app_home_opened", User: "U1", Tab: "messages"})
+	assertNoPanic(t, "messages tab", func() {
+		handleAppHomeOpened(context.Background(), nil, nil, string(payload), config.Config{})
+	})
+}
+
+func TestIsAdmin(t *testing.T) {
+	config := config.Config{SlackAdminUserIDs: []string{"U1", "U2"}}
+	if !isAdmin(config, "U2") {
+		t.Error("expected U2 to be an admin")
+	}
+	if isAdmin(config, "U3") {
+		t.Error("expected U3 not to be an admin")
+	}
+}
+
+// ---- Dry-run tests ----
+
+func TestParsePRArgsDryRun(t *testing.T) {
+	args, err := parsePRArgs("myrepo --dry-run", config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !args.DryRun || args.Repo != "myrepo" {
+		t.Errorf("unexpected args: %+v", args)
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	if isDryRun(Invocation{}, config.Config{}) {
+		t.Error("expected dry run to be off by default")
+	}
+	if !isDryRun(Invocation{DryRun: true}, config.Config{}) {
+		t.Error("expected per-invocation dry run to be honoured")
+	}
+	if !isDryRun(Invocation{}, config.Config{DryRun: true}) {
+		t.Error("expected global dry run to be honoured")
+	}
+}
+
+func TestInvocationMetadataRoundtrip(t *testing.T) {
+	inv := Invocation{UserID: "U1", Username: "alice", DryRun: true, Status: true, ThreadKey: "k1"}
+
+	data, _ := json.Marshal(inv.metadata())
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if got := invocationFromMetadata(m); got != inv {
+		t.Errorf("expected %+v, got %+v", inv, got)
+	}
+}
+
+func TestSharePRDryRunDoesNotPush(t *testing.T) {
+	// With dry run enabled and no user ID to echo to, sharePR must not push to
+	// SlackLiner or touch Slack. The Redis client points at a closed port, so
+	// a push would surface as an error.
+	rdb := unreachableRedis()
+	defer rdb.Close()
+
+	pr := &PRItem{Number: 1, Title: "Dry"}
+	fake := &fakeSlack{}
+	if err := sharePR(context.Background(), rdb, fake, pr, "org/repo", Invocation{DryRun: true}, PostOptions{}, config.Config{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	assertSlackCalls(t, "dry-run share", fake)
+}
+
+func TestBuildPRMessage(t *testing.T) {
+	pr := &PRItem{Number: 9, Title: "Nine", URL: "https://github.com/org/repo/pull/9", HeadRefName: "feat/nine"}
+	pr.Author.Login = "dave"
+
+	msg := buildPRMessage(pr, "org/repo", "erin", PostOptions{}, config.Config{SlackChannelID: "C1"})
+	if msg.Channel != "C1" {
+		t.Errorf("unexpected channel: %q", msg.Channel)
+	}
+	if !strings.Contains(msg.Text, "PR #9") || !strings.Contains(msg.Text, "@erin") {
+		t.Errorf("unexpected text: %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["branch"] != "feat/nine" {
+		t.Errorf("unexpected branch: %v", payload["branch"])
+	}
+}
+
+// ---- Ephemeral feedback tests ----
+
+func TestRespondEphemeralNoURLIsNoop(t *testing.T) {
+	if err := respondEphemeral(context.Background(), "", "hello"); err != nil {
+		t.Errorf("expected nil error for empty response_url, got %v", err)
+	}
+}
+
+func TestHandleSlashCommandInvalidArgSendsUsage(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "repo; rm -rf /", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{})
+
+	if got.ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("expected ephemeral response, got %q", got.ResponseType)
+	}
+	if !strings.Contains(got.Text, "Usage") {
+		t.Errorf("expected usage text in response, got %q", got.Text)
+	}
+}
+
+func TestHandleSlashCommandHelpSendsUsage(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "help", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{})
+
+	if got.Text != prUsage() {
+		t.Errorf("expected usage text, got %q", got.Text)
+	}
+	if len(got.Blocks.BlockSet) == 0 {
+		t.Error("expected usage blocks")
+	}
+}
+
+func TestHandleSlashCommandUnknownSubcommandSendsUsage(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "serach is:open", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{})
+
+	if !strings.HasPrefix(got.Text, ":warning: Unknown subcommand `serach`.") {
+		t.Errorf("expected unknown subcommand notice, got %q", got.Text)
+	}
+}
+
+func TestUnknownSubcommand(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"serach is:open", "serach"},
+		{"api --base main", ""},
+		{"api", ""},
+		{"--base main api extra", ""},
+		{"acme/api extra", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := unknownSubcommand(tt.text); got != tt.want {
+			t.Errorf("unknownSubcommand(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPRUsageBlocks(t *testing.T) {
+	blocks := prUsageBlocks("Bad flag.")
+	raw, _ := json.Marshal(blocks)
+	for _, want := range []string{":warning: Bad flag.", "*Flags*", "*Subcommands*", "`/pr search label:bug is:open`"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("usage blocks missing %q", want)
+		}
+	}
+}
+
+func TestPRUsageListsEveryFlagAndSubcommand(t *testing.T) {
+	usage := prUsage()
+	for _, f := range prFlags {
+		if !strings.Contains(usage, "--"+f.Name) {
+			t.Errorf("usage is missing --%s", f.Name)
+		}
+	}
+	for _, s := range prSubcommands() {
+		if !strings.Contains(usage, "`/pr "+s.Name) {
+			t.Errorf("usage is missing /pr %s", s.Name)
+		}
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantName string
+		wantRest string
+		wantOK   bool
+	}{
+		{"  search  is:open author:me ", "search", "is:open author:me", true},
+		{"fav add api", "fav", "add api", true},
+		{"help", "help", "", true},
+		{"api --base main", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		sub, rest, ok := parseCommand(tt.text)
+		if ok != tt.wantOK || sub.Name != tt.wantName || rest != tt.wantRest {
+			t.Errorf("parseCommand(%q) = %q, %q, %v; want %q, %q, %v", tt.text, sub.Name, rest, ok, tt.wantName, tt.wantRest, tt.wantOK)
+		}
+	}
+}
+
+// ---- org/repo argument tests ----
+
+func TestParsePRArgsFullRepoPath(t *testing.T) {
+	args, err := parsePRArgs("other-org/their.repo", config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Org != "other-org" || args.Repo != "their.repo" {
+		t.Errorf("unexpected args: %+v", args)
+	}
+	if got := args.fullRepo(config.Config{GitHubOrg: "my-org"}); got != "other-org/their.repo" {
+		t.Errorf("expected explicit org to bypass config, got %q", got)
+	}
+}
+
+func TestParsePRArgsRejectsEmptyRepoPart(t *testing.T) {
+	// "myorg/" must not fall through to the repo chooser as if no repo
+	// were given.
+	if _, err := parsePRArgs("myorg/", config.Config{}); err == nil {
+		t.Error("expected an error for an org path without a repo")
+	}
+}
+
+func TestPRArgsFullRepoDefaultsToConfiguredOrg(t *testing.T) {
+	args, err := parsePRArgs("myrepo", config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := args.fullRepo(config.Config{GitHubOrg: "my-org"}); got != "my-org/myrepo" {
+		t.Errorf("expected configured org prefix, got %q", got)
+	}
+}
+
+func TestHandleSlashCommandWithFullRepoPathSkipsRepoChooser(t *testing.T) {
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "other-org/repo", TriggerID: "tid"})
+	assertShowsView(t, "org/repo arg provided", "views.open", slackui.LoadingModal(), func(fake *fakeSlack) {
+		handleSlashCommand(context.Background(), unreachableRedis(), fake, string(payload), config.Config{GitHubOrg: "my-org"})
+	})
+}
+
+// ---- PR URL argument tests ----
+
+func TestParsePRArgsPRURL(t *testing.T) {
+	cases := []string{
+		"https://github.com/acme/widgets/pull/123",
+		"<https://github.com/acme/widgets/pull/123>",
+		"<https://github.com/acme/widgets/pull/123/files|acme/widgets#123>",
+	}
+	for _, text := range cases {
+		args, err := parsePRArgs(text, config.Config{GitHubOrg: "my-org"})
+		if err != nil {
+			t.Errorf("parsePRArgs(%q): unexpected error: %v", text, err)
+			continue
+		}
+		if args.Number != 123 || args.fullRepo(config.Config{GitHubOrg: "my-org"}) != "acme/widgets" {
+			t.Errorf("parsePRArgs(%q): unexpected args %+v", text, args)
+		}
+	}
+}
+
+func TestParsePRArgsRejectsNonGitHubURL(t *testing.T) {
+	if _, err := parsePRArgs("https://example.com/acme/widgets/pull/1", config.Config{}); err == nil {
+		t.Error("expected error for non-GitHub URL")
+	}
+}
+
+func TestBuildPRViewCommand(t *testing.T) {
+	got := buildPRViewCommand("acme/widgets", 42)
+	if !strings.HasPrefix(got, "gh pr view 42 --repo acme/widgets --json ") {
+		t.Errorf("unexpected command: %q", got)
+	}
+}
+
+func TestHandlePoppitOutputPRViewPostsDirectly(t *testing.T) {
+	// A PR view result goes straight to SlackLiner without any modal.
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	output := poppit.Output{
+		Type:     poppitPRViewType,
+		Output:   `{"number": 5, "title": "Direct", "url": "https://github.com/acme/widgets/pull/5"}`,
+		Metadata: map[string]interface{}{"repo": "acme/widgets", "username": "alice"},
+	}
+	payload, _ := json.Marshal(output)
+	fake := &fakeSlack{}
+	handlePoppitOutput(context.Background(), rdb, fake, string(payload), config)
+
+	assertSlackCalls(t, "PR view post path", fake)
+	if posts := slackLinerPosts(t, mr, config); len(posts) == 0 || posts[0].Channel != "C123" {
+		t.Errorf("expected a post to C123, got %+v", posts)
+	}
+}
+
+func TestHandlePoppitOutputPRViewInvalidJSON(t *testing.T) {
+	output := poppit.Output{
+		Type:     poppitPRViewType,
+		Output:   "not json",
+		Metadata: map[string]interface{}{"repo": "acme/widgets"},
+	}
+	payload, _ := json.Marshal(output)
+	assertNoPanic(t, "invalid PR view output", func() {
+		handlePoppitOutput(context.Background(), nil, nil, string(payload), config.Config{})
+	})
+}
+
+// ---- PR typeahead tests ----
+
+func TestFilterPRsByQuery(t *testing.T) {
+	prs := []PRItem{
+		{Number: 12, Title: "Fix login bug"},
+		{Number: 34, Title: "Add Dark Mode"},
+		{Number: 123, Title: "Refactor logger"},
+	}
+
+	if got := filterPRsByQuery(prs, ""); len(got) != 3 {
+		t.Errorf("empty query should match all PRs, got %d", len(got))
+	}
+	if got := filterPRsByQuery(prs, "dark"); len(got) != 1 || got[0].Number != 34 {
+		t.Errorf("expected case-insensitive title match, got %+v", got)
+	}
+	if got := filterPRsByQuery(prs, "#12"); len(got) != 2 {
+		t.Errorf("expected number substring match for 12 and 123, got %+v", got)
+	}
+	if got := filterPRsByQuery(prs, "log"); len(got) != 2 {
+		t.Errorf("expected 2 matches for 'log', got %+v", got)
+	}
+}
+
+func TestFilterPRsByQueryCapsResults(t *testing.T) {
+	prs := make([]PRItem, maxSuggestionOptions+20)
+	for i := range prs {
+		prs[i] = PRItem{Number: i + 1, Title: "PR"}
+	}
+	if got := filterPRsByQuery(prs, ""); len(got) != maxSuggestionOptions {
+		t.Errorf("expected results capped at %d, got %d", maxSuggestionOptions, len(got))
+	}
+}
+
+func TestHandleBlockSuggestionIgnoresOtherActions(t *testing.T) {
+	payload, _ := json.Marshal(BlockSuggestionPayload{Type: "block_suggestion", ActionID: "other_action"})
+	assertNoPanic(t, "unknown suggestion", func() {
+		handleBlockSuggestion(context.Background(), nil, string(payload), config.Config{})
+	})
+}
+
+func TestBlockSuggestionResponseSerialization(t *testing.T) {
+	resp := BlockSuggestionResponse{
+		ActionTS:        "123.456",
+		ViewID:          "V1",
+		OptionsResponse: slack.OptionsResponse{Options: prOptions([]PRItem{{Number: 7, Title: "Seven"}}, time.Now())},
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if out["action_ts"] != "123.456" {
+		t.Errorf("unexpected action_ts: %v", out["action_ts"])
+	}
+	options, ok := out["options"].([]interface{})
+	if !ok || len(options) != 1 {
+		t.Errorf("expected options to be inlined at top level, got %v", out["options"])
+	}
+}
+
+func TestPRSessionKey(t *testing.T) {
+	if got := prSessionKey("V123"); got != "slashvibepr:session:V123" {
+		t.Errorf("unexpected session key: %q", got)
+	}
+}
+
+// ---- PR option description tests ----
+
+func TestPROptionDescription(t *testing.T) {
+	now := time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC)
+	pr := PRItem{HeadRefName: "fix/bug", CreatedAt: now.Add(-3 * 24 * time.Hour)}
+	pr.Author.Login = "alice"
+
+	if got := prOptionDescription(pr, now); got != "by alice · fix/bug · opened May 1, 3d ago" {
+		t.Errorf("unexpected description: %q", got)
+	}
+	if got := prOptionDescription(PRItem{HeadRefName: "main"}, now); got != "main" {
+		t.Errorf("expected missing parts to be omitted, got %q", got)
+	}
+}
+
+func TestPROptionsIncludeDescription(t *testing.T) {
+	pr := PRItem{Number: 1, Title: "A", HeadRefName: "feat/a"}
+	pr.Author.Login = "bob"
+	options := prOptions([]PRItem{pr}, time.Now())
+	if options[0].Description == nil || !strings.Contains(options[0].Description.Text, "by bob") {
+		t.Errorf("expected description with author, got %+v", options[0].Description)
+	}
+}
+
+// ---- Multi-select tests ----
+
+func TestExtractSelectedValuesMulti(t *testing.T) {
+	values := map[string]map[string]interface{}{
+		slackui.PRBlockID: {
+			slackui.PRSelectActionID: map[string]interface{}{
+				"type": "multi_external_select",
+				"selected_options": []interface{}{
+					map[string]interface{}{"value": "3"},
+					map[string]interface{}{"value": "1"},
+				},
+			},
+		},
+	}
+	got := extractSelectedValues(values, slackui.PRBlockID, slackui.PRSelectActionID)
+	if len(got) != 2 || got[0] != "3" || got[1] != "1" {
+		t.Errorf("unexpected values: %v", got)
+	}
+}
+
+func TestExtractSelectedValuesSingle(t *testing.T) {
+	values := map[string]map[string]interface{}{
+		slackui.PRBlockID: {
+			slackui.PRSelectActionID: map[string]interface{}{
+				"selected_option": map[string]interface{}{"value": "42"},
+			},
+		},
+	}
+	got := extractSelectedValues(values, slackui.PRBlockID, slackui.PRSelectActionID)
+	if len(got) != 1 || got[0] != "42" {
+		t.Errorf("unexpected values: %v", got)
+	}
+	if got := extractSelectedValues(values, "missing", slackui.PRSelectActionID); got != nil {
+		t.Errorf("expected nil for missing block, got %v", got)
+	}
+}
+
+func TestParsePRArgsMulti(t *testing.T) {
+	args, err := parsePRArgs("myrepo --multi", config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !args.Multi {
+		t.Error("expected Multi to be set")
+	}
+}
+
+func TestFindPR(t *testing.T) {
+	prs := []PRItem{{Number: 1}, {Number: 2}}
+	if pr := findPR(prs, "2"); pr == nil || pr.Number != 2 {
+		t.Errorf("expected PR #2, got %+v", pr)
+	}
+	if pr := findPR(prs, "3"); pr != nil {
+		t.Errorf("expected nil for unknown PR, got %+v", pr)
+	}
+}
+
+// ---- Note field tests ----
+
+func TestBuildPRMessageWithNote(t *testing.T) {
+	pr := &PRItem{Number: 3, Title: "Three"}
+	msg := buildPRMessage(pr, "org/repo", "erin", PostOptions{Note: "Needs eyes <today>\nsecond line"}, config.Config{})
+
+	if !strings.Contains(msg.Text, "> Needs eyes &lt;today&gt;\n> second line") {
+		t.Errorf("expected escaped, quoted note in text, got %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["note"] != "Needs eyes <today>\nsecond line" {
+		t.Errorf("expected raw note in metadata, got %v", payload["note"])
+	}
+}
+
+func TestBuildPRMessageWithoutNote(t *testing.T) {
+	msg := buildPRMessage(&PRItem{Number: 3}, "org/repo", "erin", PostOptions{}, config.Config{})
+	if strings.Contains(msg.Text, "Note:") {
+		t.Errorf("expected no note section, got %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if _, ok := payload["note"]; ok {
+		t.Error("expected no note key in metadata")
+	}
+}
+
+// ---- Message template tests ----
+
+func TestDefaultMessageTemplateMatchesOriginalLayout(t *testing.T) {
+	pr := &PRItem{Number: 7, Title: "My PR", URL: "https://github.com/org/repo/pull/7"}
+	pr.Author.Login = "carol"
+
+	msg := buildPRMessage(pr, "org/repo", "dave", PostOptions{}, config.Config{})
+	want := "📋 *Pull Request shared by @dave*\n\n" +
+		"*Repository:* org/repo\n" +
+		"*PR #7:* My PR\n" +
+		"*Author:* carol\n" +
+		"*Link:* <https://github.com/org/repo/pull/7|View PR>"
+	if msg.Text != want {
+		t.Errorf("unexpected default text:\n%s\nwant:\n%s", msg.Text, want)
+	}
+}
+
+func TestCustomMessageTemplate(t *testing.T) {
+	pr := &PRItem{Number: 7, Title: "My PR", HeadRefName: "feat/x"}
+	config := config.Config{SlackMessageTemplate: `:rocket: {{upper .Repo}}#{{.PR.Number}} ({{.PR.HeadRefName}}) by {{.PostedBy}}`}
+
+	msg := buildPRMessage(pr, "org/repo", "dave", PostOptions{}, config)
+	if msg.Text != ":rocket: ORG/REPO#7 (feat/x) by dave" {
+		t.Errorf("unexpected custom text: %q", msg.Text)
+	}
+}
+
+func TestMessageTemplateRuntimeErrorFallsBackToDefault(t *testing.T) {
+	// Indexing a missing field fails at execution time, not parse time.
+	config := config.Config{SlackMessageTemplate: `{{.PR.Nope}}`}
+	msg := buildPRMessage(&PRItem{Number: 1}, "org/repo", "dave", PostOptions{}, config)
+	if !strings.Contains(msg.Text, "Pull Request shared by @dave") {
+		t.Errorf("expected default layout on template error, got %q", msg.Text)
+	}
+}
+
+func TestBuildPRMessageBranding(t *testing.T) {
+	cfg := config.Config{SlackHeaderEmoji: ":rocket:", SlackUsername: "PR Bot", SlackIconEmoji: ":robot_face:"}
+	msg := buildPRMessage(&PRItem{Number: 1, Title: "Fix"}, "acme/api", "dave", PostOptions{ThreadKey: "k"}, cfg)
+	if !strings.HasPrefix(msg.Text, ":rocket: *Pull Request shared by @dave*") {
+		t.Errorf("expected the configured emoji, got %q", msg.Text)
+	}
+	data, _ := json.Marshal(msg)
+	for _, want := range []string{`"username":"PR Bot"`, `"icon_emoji":":robot_face:"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in %s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "icon_url") {
+		t.Errorf("expected an unset icon_url to be omitted, got %s", data)
+	}
+	if details := buildPRDetailsMessage(&PRItem{Number: 1}, "acme/api", "k", cfg); details.Username != "PR Bot" {
+		t.Errorf("expected the threaded details to be branded, got %+v", details.SlackLinerBranding)
+	}
+}
+
+func TestBuildPRMessageDiffStats(t *testing.T) {
+	pr := &PRItem{Number: 1, Title: "Fix", Additions: 120, Deletions: 45, ChangedFiles: 7}
+	msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{}, config.Config{})
+	if !strings.Contains(msg.Text, "*Changes:* +120 −45 across 7 files") {
+		t.Errorf("expected diff stats in %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["additions"] != 120 || payload["deletions"] != 45 || payload["changed_files"] != 7 {
+		t.Errorf("unexpected diff stats metadata: %v", payload)
+	}
+	if got := prOptionDescription(*pr, time.Now()); got != "+120 −45 across 7 files" {
+		t.Errorf("unexpected description: %q", got)
+	}
+
+	if got := (PRItem{Additions: 1, ChangedFiles: 1}).diffStats(); got != "+1 −0 across 1 file" {
+		t.Errorf("unexpected singular stats: %q", got)
+	}
+	if msg := buildPRMessage(&PRItem{Number: 2}, "acme/api", "dave", PostOptions{}, config.Config{}); strings.Contains(msg.Text, "Changes") {
+		t.Errorf("expected no diff stats when not fetched, got %q", msg.Text)
+	}
+}
+
+func TestDescriptionSnippet(t *testing.T) {
+	body := "<!-- Describe your change -->\r\n## Summary\r\n\r\nFixes the **login** bug, see [the issue](https://github.com/acme/api/issues/1).\r\n\r\n\r\n![screenshot](https://img/x.png)\r\n```go\r\nfmt.Println()\r\n```\r\n- [x] Tested <b>locally</b>\r\n---\r\n> quoted"
+	want := "Summary\n\nFixes the *login* bug, see the issue.\n\n- Tested locally\n\nquoted"
+	if got := descriptionSnippet(body, 300); got != want {
+		t.Errorf("descriptionSnippet:\n got %q\nwant %q", got, want)
+	}
+	if got := descriptionSnippet("one two three four five", 12); got != "one two…" {
+		t.Errorf("expected a cut at a word boundary, got %q", got)
+	}
+}
+
+func TestBuildPRMessageDescriptionSnippet(t *testing.T) {
+	pr := &PRItem{Number: 1, Title: "Fix", Body: "Handles x < y & z"}
+	if msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{}, config.Config{}); strings.Contains(msg.Text, "Handles") {
+		t.Errorf("expected no snippet while disabled, got %q", msg.Text)
+	}
+	cfg := config.Config{SlackDescriptionSnippet: true, SlackDescriptionLength: 300}
+	msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{Note: "Please look"}, cfg)
+	if !strings.Contains(msg.Text, "|View PR>\n\n> Handles x &lt; y &amp; z\n\n*Note:*") {
+		t.Errorf("expected an escaped quoted snippet before the note, got %q", msg.Text)
+	}
+}
+
+func TestCheckConfigRejectsInvalidTemplate(t *testing.T) {
+	if err := CheckConfig(config.Config{SlackMessageTemplate: "{{.PR.Number"}); err == nil {
+		t.Error("expected error for unparseable message template")
+	}
+	if err := CheckConfig(config.Config{SlackMessageTemplate: "{{.Repo}}"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// ---- GitHub-to-Slack user mapping tests ----
+
+func TestBuildPRMessageMentionsMappedAuthor(t *testing.T) {
+	pr := &PRItem{Number: 1}
+	pr.Author.Login = "octocat"
+
+	msg := buildPRMessage(pr, "org/repo", "dave", PostOptions{AuthorSlackID: "U123"}, config.Config{})
+	if !strings.Contains(msg.Text, "*Author:* <@U123>") {
+		t.Errorf("expected Slack mention for mapped author, got %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["author_slack_id"] != "U123" || payload["author"] != "octocat" {
+		t.Errorf("unexpected author metadata: %v", payload)
+	}
+}
+
+func TestLookupSlackUserIDFallsBackToConfig(t *testing.T) {
+	rdb := unreachableRedis()
+	defer rdb.Close()
+
+	config := config.Config{GitHubSlackUsers: map[string]string{"OctoCat": "U999"}}
+	if got := lookupSlackUserID(context.Background(), rdb, "octocat", config); got != "U999" {
+		t.Errorf("expected case-insensitive config mapping, got %q", got)
+	}
+	if got := lookupSlackUserID(context.Background(), rdb, "someone", config); got != "" {
+		t.Errorf("expected no mapping, got %q", got)
+	}
+	if got := lookupGitHubLogin(context.Background(), rdb, "U999", config); got != "OctoCat" {
+		t.Errorf("expected reverse config mapping, got %q", got)
+	}
+}
+
+func TestValidGitHubLogin(t *testing.T) {
+	for _, login := range []string{"octocat", "a", "some-user", "User123"} {
+		if !validGitHubLogin.MatchString(login) {
+			t.Errorf("expected %q to be valid", login)
+		}
+	}
+	for _, login := range []string{"-leading", "trailing-", "double--hyphen", "has space", "semi;colon", ""} {
+		if validGitHubLogin.MatchString(login) {
+			t.Errorf("expected %q to be invalid", login)
+		}
+	}
+}
+
+func TestHandleSlashCommandWhoamiInvalidLogin(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "whoami link -bad", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{})
+
+	if !strings.Contains(got.Text, "not a valid GitHub login") {
+		t.Errorf("expected validation message, got %q", got.Text)
+	}
+}
+
+func TestLinkGitHubLoginRefusesTakenLogin(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.GitHubSlackUsers = map[string]string{"hubot": "U9"}
+
+	if err := linkGitHubLogin(ctx, rdb, "U1", "octocat", false, cfg); err != nil {
+		t.Fatal(err)
+	}
+	var taken *loginTakenError
+	if err := linkGitHubLogin(ctx, rdb, "U2", "OctoCat", false, cfg); !errors.As(err, &taken) || taken.Owner != "U1" {
+		t.Fatalf("expected the login to be refused as U1's, got %v", err)
+	}
+	if got := lookupGitHubLogin(ctx, rdb, "U1", cfg); got != "octocat" {
+		t.Errorf("expected U1 to keep the login, got %q", got)
+	}
+	if err := linkGitHubLogin(ctx, rdb, "U1", "octocat", false, cfg); err != nil {
+		t.Errorf("expected relinking your own login to succeed, got %v", err)
+	}
+
+	if err := linkGitHubLogin(ctx, rdb, "U2", "octocat", true, cfg); err != nil {
+		t.Fatalf("expected a forced link to succeed, got %v", err)
+	}
+	if got := lookupSlackUserID(ctx, rdb, "octocat", cfg); got != "U2" {
+		t.Errorf("expected the login to map to U2, got %q", got)
+	}
+	if got := lookupGitHubLogin(ctx, rdb, "U1", cfg); got != "" {
+		t.Errorf("expected U1 to be unlinked, got %q", got)
+	}
+
+	if err := linkGitHubLogin(ctx, rdb, "U2", "hubot", true, cfg); !errors.As(err, &taken) || !taken.FromConfig {
+		t.Errorf("expected a login from github.slack_users to be refused, got %v", err)
+	}
+}
+
+func TestWhoamiLinkNeedsGistVerification(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		got = append(got, msg.Text)
+	}))
+	defer srv.Close()
+	cmd := SlackCommand{UserID: "U1", UserName: "dave", ResponseURL: srv.URL}
+
+	handleWhoamiCommand(ctx, rdb, cmd, []string{"link", "octocat"}, cfg)
+	if login := lookupGitHubLogin(ctx, rdb, "U1", cfg); login != "" {
+		t.Fatalf("expected the link to wait for verification, got %q", login)
+	}
+	pending, err := loadPendingLink(ctx, rdb, "U1")
+	if err != nil || pending == nil || len(got) != 1 || !strings.Contains(got[0], pending.Code) {
+		t.Fatalf("expected a challenge code in the reply, got %q, %+v, %v", got, pending, err)
+	}
+	if ttl := mr.TTL(pendingLinkKey("U1")); ttl <= 0 || ttl > pendingLinkTTL {
+		t.Errorf("expected the pending link to expire, got TTL %s", ttl)
+	}
+
+	handleWhoamiCommand(ctx, rdb, cmd, []string{"verify"}, cfg)
+	queued := popPoppitCommand(t, mr, cfg)
+	if queued.Type != poppitLinkVerifyType || queued.Commands[0] != buildGistDescriptionsCommand("octocat") {
+		t.Fatalf("unexpected verification command: %+v", queued)
+	}
+	metadata := map[string]interface{}{}
+	for k, v := range queued.Metadata {
+		metadata[k] = v
+	}
+
+	ok := 0
+	// A gist carrying some other code, e.g. forged in the metadata, is not enough.
+	verifyPendingLink(ctx, rdb, poppit.Output{Type: poppitLinkVerifyType, Output: `["notes", "slashvibepr-0000"]`, ExitCode: &ok, Metadata: metadata}, cfg)
+	if lookupGitHubLogin(ctx, rdb, "U1", cfg) != "" || !strings.Contains(got[len(got)-1], "None of the public gists") {
+		t.Fatalf("expected the link to stay pending, got %q", got[len(got)-1])
+	}
+
+	gists, _ := json.Marshal([]string{"notes", pending.Code})
+	verifyPendingLink(ctx, rdb, poppit.Output{Type: poppitLinkVerifyType, Output: string(gists), ExitCode: &ok, Metadata: metadata}, cfg)
+	if login := lookupGitHubLogin(ctx, rdb, "U1", cfg); login != "octocat" {
+		t.Errorf("expected the verified link to be active, got %q", login)
+	}
+	if mr.Exists(pendingLinkKey("U1")) {
+		t.Error("expected the pending link to be removed")
+	}
+}
+
+func TestWhoamiLinkRefusesTakenLogin(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	if err := linkGitHubLogin(ctx, rdb, "U1", "octocat", false, cfg); err != nil {
+		t.Fatal(err)
+	}
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	handleWhoamiCommand(ctx, rdb, SlackCommand{UserID: "U2", UserName: "erin", ResponseURL: srv.URL}, []string{"link", "octocat"}, cfg)
+	if !strings.Contains(got.Text, "already linked to <@U1>") || mr.Exists(pendingLinkKey("U2")) {
+		t.Errorf("expected the login to be refused without a challenge, got %q", got.Text)
+	}
+}
+
+func TestHandleAdminCommandLink(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.SlackAdminUserIDs = []string{"UADMIN"}
+	if err := linkGitHubLogin(ctx, rdb, "U1", "octocat", false, cfg); err != nil {
+		t.Fatal(err)
+	}
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	handleAdminCommand(ctx, rdb, SlackCommand{UserID: "UADMIN", UserName: "root", ResponseURL: srv.URL}, []string{"link", "<@U2>", "octocat"}, cfg)
+	if !strings.Contains(got.Text, "<@U2> is now linked") {
+		t.Errorf("unexpected reply: %q", got.Text)
+	}
+	if lookupGitHubLogin(ctx, rdb, "U2", cfg) != "octocat" || lookupGitHubLogin(ctx, rdb, "U1", cfg) != "" {
+		t.Error("expected the login to move from U1 to U2")
+	}
+}
+
+// ---- Thread follow-up tests ----
+
+func TestBuildPRMessagesAddsThreadedDetails(t *testing.T) {
+	pr := withLabels(PRItem{Number: 4, Title: "Four", Body: "Fixes <thing>", ChangedFiles: 7}, "bug")
+
+	msgs := buildPRMessages(&pr, "org/repo", "dave", PostOptions{ThreadKey: "k1"}, config.Config{SlackThreadDetails: true})
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Key != "k1" || msgs[0].ThreadKey != "" {
+		t.Errorf("main message should register key k1, got key=%q thread_key=%q", msgs[0].Key, msgs[0].ThreadKey)
+	}
+	if msgs[1].ThreadKey != "k1" {
+		t.Errorf("details message should thread under k1, got %q", msgs[1].ThreadKey)
+	}
+	for _, want := range []string{"*Changed files:* 7", "*Labels:* bug", "> Fixes &lt;thing&gt;"} {
+		if !strings.Contains(msgs[1].Text, want) {
+			t.Errorf("expected %q in details text, got %q", want, msgs[1].Text)
+		}
+	}
+}
+
+func TestBuildPRMessagesDetailsDisabled(t *testing.T) {
+	msgs := buildPRMessages(&PRItem{Number: 4}, "org/repo", "dave", PostOptions{ThreadKey: "k1"}, config.Config{})
+	if len(msgs) != 1 {
+		t.Errorf("expected only the main message when thread details are off, got %d", len(msgs))
+	}
+}
+
+func TestBuildPRDetailsMessageTruncatesBody(t *testing.T) {
+	pr := &PRItem{Body: strings.Repeat("é", maxDetailsBodyLength+10)}
+	msg := buildPRDetailsMessage(pr, "org/repo", "k", config.Config{})
+	if !strings.HasSuffix(msg.Text, "…") {
+		t.Errorf("expected truncated body to end with an ellipsis")
+	}
+	if strings.Count(msg.Text, "é") != maxDetailsBodyLength {
+		t.Errorf("expected body truncated to %d runes", maxDetailsBodyLength)
+	}
+}
+
+// ---- CI check status tests ----
+
+func TestPRCheckState(t *testing.T) {
+	cases := []struct {
+		name   string
+		checks []CheckStatus
+		want   CheckState
+	}{
+		{"no checks", nil, CheckStateNone},
+		{"all passing", []CheckStatus{
+			{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "SUCCESS"},
+			{TypeName: "StatusContext", State: "SUCCESS"},
+			{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "SKIPPED"},
+		}, CheckStatePassing},
+		{"one running", []CheckStatus{
+			{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "SUCCESS"},
+			{TypeName: "CheckRun", Status: "IN_PROGRESS"},
+		}, CheckStatePending},
+		{"failure beats pending", []CheckStatus{
+			{TypeName: "CheckRun", Status: "QUEUED"},
+			{TypeName: "StatusContext", State: "ERROR"},
+		}, CheckStateFailing},
+		{"timed out", []CheckStatus{
+			{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "TIMED_OUT"},
+		}, CheckStateFailing},
+	}
+	for _, tc := range cases {
+		pr := PRItem{StatusCheckRollup: tc.checks}
+		if got := pr.checkState(); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestPROptionTextStatusPrefix(t *testing.T) {
+	pr := PRItem{Number: 5, Title: "Five", StatusCheckRollup: []CheckStatus{
+		{TypeName: "CheckRun", Status: "COMPLETED", Conclusion: "FAILURE"},
+	}}
+	if got := prOptionText(pr); got != "❌ #5: Five" {
+		t.Errorf("unexpected option text: %q", got)
+	}
+	if got := prOptionText(PRItem{Number: 5, Title: "Five"}); got != "#5: Five" {
+		t.Errorf("expected no prefix without checks, got %q", got)
+	}
+}
+
+func TestParseStatusCheckRollupJSON(t *testing.T) {
+	raw := `[{"number": 1, "statusCheckRollup": [
+		{"__typename": "CheckRun", "name": "build", "status": "COMPLETED", "conclusion": "SUCCESS"},
+		{"__typename": "StatusContext", "context": "ci/legacy", "state": "PENDING"}
+	]}]`
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(raw), &prs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prs[0].StatusCheckRollup) != 2 || prs[0].checkState() != CheckStatePending {
+		t.Errorf("unexpected rollup: %+v", prs[0].StatusCheckRollup)
+	}
+}
+
+// ---- Review decision tests ----
+
+func TestPROptionDescriptionIncludesReviewDecision(t *testing.T) {
+	now := time.Now()
+	pr := PRItem{HeadRefName: "fix/bug", ReviewDecision: "CHANGES_REQUESTED"}
+	pr.Author.Login = "alice"
+
+	if got := prOptionDescription(pr, now); got != "by alice · changes requested · fix/bug" {
+		t.Errorf("unexpected description: %q", got)
+	}
+}
+
+func TestBuildPRMessageIncludesReviewDecision(t *testing.T) {
+	msg := buildPRMessage(&PRItem{Number: 1, ReviewDecision: "APPROVED"}, "org/repo", "dave", PostOptions{}, config.Config{})
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["review_decision"] != "APPROVED" {
+		t.Errorf("expected review_decision in metadata, got %v", payload["review_decision"])
+	}
+}
+
+// ---- Label tests ----
+
+// withLabels returns pr with the given label names attached.
+func withLabels(pr PRItem, names ...string) PRItem {
+	for _, name := range names {
+		pr.Labels = append(pr.Labels, struct {
+			Name string `json:"name"`
+		}{Name: name})
+	}
+	return pr
+}
+
+func TestPROptionDescriptionIncludesLabels(t *testing.T) {
+	pr := withLabels(PRItem{HeadRefName: "fix/bug"}, "bug", "ui")
+	if got := prOptionDescription(pr, time.Now()); got != "fix/bug · bug, ui" {
+		t.Errorf("unexpected description: %q", got)
+	}
+}
+
+func TestBuildPRMessageLabelsContextBlock(t *testing.T) {
+	pr := withLabels(PRItem{Number: 1, Title: "One"}, "backend", "needs-review")
+	msg := buildPRMessage(&pr, "org/repo", "dave", PostOptions{}, config.Config{})
+
+	if len(msg.Blocks) != 2 {
+		t.Fatalf("expected section + context blocks, got %d", len(msg.Blocks))
+	}
+	context, ok := msg.Blocks[1].(*slack.ContextBlock)
+	if !ok {
+		t.Fatalf("expected second block to be a ContextBlock, got %T", msg.Blocks[1])
+	}
+	text := context.ContextElements.Elements[0].(*slack.TextBlockObject).Text
+	if !strings.Contains(text, "backend, needs-review") {
+		t.Errorf("unexpected context text: %q", text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if labels, ok := payload["labels"].([]string); !ok || len(labels) != 2 {
+		t.Errorf("expected labels in metadata, got %v", payload["labels"])
+	}
+}
+
+func TestBuildPRMessageWithoutLabelsHasNoBlocks(t *testing.T) {
+	msg := buildPRMessage(&PRItem{Number: 1}, "org/repo", "dave", PostOptions{}, config.Config{})
+	if msg.Blocks != nil {
+		t.Errorf("expected no blocks without labels, got %d", len(msg.Blocks))
+	}
+}
+
+// ---- GitHub API mode tests ----
+
+func TestLoadConfigGitHubModeDefaultsToPoppit(t *testing.T) {
+	cfg, err := config.Parse([]byte(""), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GitHubMode != config.GitHubModePoppit {
+		t.Errorf("expected default mode %q, got %q", config.GitHubModePoppit, cfg.GitHubMode)
+	}
+	if _, ok := newPRSource(nil, nil, cfg).(*poppitPRSource); !ok {
+		t.Error("expected poppit source by default")
+	}
+}
+
+func TestLoadConfigGitHubModeAPI(t *testing.T) {
+	cfg, err := config.Parse([]byte("github:\n  mode: api\n"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := newPRSource(nil, nil, cfg).(*githubAPIPRSource); !ok {
+		t.Error("expected GitHub API source for mode api")
+	}
+}
+
+func TestGitHubClientListPullRequests(t *testing.T) {
+	var gotPath, gotQuery, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		fmt.Fprint(w, `[{"number":7,"title":"Add thing","html_url":"https://github.com/org/repo/pull/7",
+			"user":{"login":"octocat"},"head":{"ref":"feature"},"base":{"ref":"main"},
+			"created_at":"2024-01-02T03:04:05Z","labels":[{"name":"bug"}]}]`)
+	}))
+	defer srv.Close()
+
+	prs, err := newGitHubClient(srv.URL, "tok").listPullRequests(context.Background(), "org/repo", PRListOptions{Base: "main"}, 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPath != "/repos/org/repo/pulls" {
+		t.Errorf("unexpected path: %q", gotPath)
+	}
+	if !strings.Contains(gotQuery, "base=main") || !strings.Contains(gotQuery, "state=open") {
+		t.Errorf("unexpected query: %q", gotQuery)
+	}
+	if gotAuth != "Bearer tok" {
+		t.Errorf("unexpected auth header: %q", gotAuth)
+	}
+	if len(prs) != 1 {
+		t.Fatalf("expected 1 PR, got %d", len(prs))
+	}
+	pr := prs[0]
+	if pr.Number != 7 || pr.Author.Login != "octocat" || pr.HeadRefName != "feature" || pr.BaseRefName != "main" {
+		t.Errorf("unexpected PR mapping: %+v", pr)
+	}
+	if got := pr.labelNames(); len(got) != 1 || got[0] != "bug" {
+		t.Errorf("unexpected labels: %v", got)
+	}
+}
+
+func TestGitHubClientListPullRequestsLeavesGlobBaseToFilter(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		fmt.Fprint(w, `[]`)
+	}))
+	defer srv.Close()
+
+	if _, err := newGitHubClient(srv.URL, "").listPullRequests(context.Background(), "org/repo", PRListOptions{Base: "release/*"}, 50); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(gotQuery, "base=") {
+		t.Errorf("glob base should not be sent to GitHub, got query %q", gotQuery)
+	}
+}
+
+func TestGitHubClientListPullRequestsSort(t *testing.T) {
+	var gotQuery url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		fmt.Fprint(w, `[]`)
+	}))
+	defer srv.Close()
+
+	if _, err := newGitHubClient(srv.URL, "").listPullRequests(context.Background(), "org/repo", PRListOptions{Sort: "updated-asc"}, 50); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery.Get("sort") != "updated" || gotQuery.Get("direction") != "asc" {
+		t.Errorf("expected sort=updated&direction=asc, got %q", gotQuery.Encode())
+	}
+}
+
+func TestGitHubClientListPullRequestsMerged(t *testing.T) {
+	var gotQuery url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		fmt.Fprint(w, `[{"number":1,"state":"closed"},{"number":2,"state":"closed","merged_at":"2026-01-02T03:04:05Z"}]`)
+	}))
+	defer srv.Close()
+
+	opts := PRListOptions{State: "merged"}
+	prs, err := newGitHubClient(srv.URL, "").listPullRequests(context.Background(), "org/repo", opts, 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery.Get("state") != "closed" {
+		t.Errorf("expected state=closed, got %q", gotQuery.Encode())
+	}
+	if got := filterPRs(prs, opts); len(got) != 1 || got[0].Number != 2 || got[0].State != "MERGED" {
+		t.Errorf("expected only the merged PR, got %+v", got)
+	}
+}
+
+func TestGitHubClientGetPullRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if _, err := newGitHubClient(srv.URL, "").getPullRequest(context.Background(), "org/repo", 1); err == nil {
+		t.Error("expected error for 404 response")
+	}
+}
+
+// ---- PR readiness tests ----
+
+func TestFetchPRReadiness(t *testing.T) {
+	var gotPath string
+	var gotVars map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		var req struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		gotVars = req.Variables
+		fmt.Fprint(w, `{"data":{"repository":{"pullRequest":{
+			"mergeStateStatus":"BLOCKED",
+			"reviewThreads":{"totalCount":3,"nodes":[{"isResolved":true},{"isResolved":false},{"isResolved":false}]},
+			"commits":{"nodes":[{"commit":{"checkSuites":{"nodes":[
+				{"status":"COMPLETED","conclusion":"SUCCESS","app":{"name":"GitHub Actions"}},
+				{"status":"IN_PROGRESS","conclusion":null,"app":{"name":"CircleCI"}}]},
+				"statusCheckRollup":{"contexts":{"nodes":[
+				{"__typename":"CheckRun","name":"build","status":"COMPLETED","conclusion":"FAILURE","isRequired":true},
+				{"__typename":"StatusContext","context":"ci/lint","state":"ERROR","isRequired":false}]}}}}]}}}}}`)
+	}))
+	defer srv.Close()
+
+	r, err := newGitHubClient(srv.URL, "tok").fetchPRReadiness(context.Background(), "org/repo", 12)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPath != "/graphql" {
+		t.Errorf("unexpected GraphQL path: %q", gotPath)
+	}
+	if gotVars["owner"] != "org" || gotVars["name"] != "repo" || gotVars["number"] != float64(12) {
+		t.Errorf("unexpected variables: %v", gotVars)
+	}
+	if r.MergeStateStatus != "BLOCKED" || r.ReviewThreads != 3 || r.UnresolvedThreads != 2 {
+		t.Errorf("unexpected readiness: %+v", r)
+	}
+	if len(r.CheckSuites) != 2 || r.checkState() != CheckStatePending {
+		t.Errorf("expected two suites rolling up to pending, got %+v", r.CheckSuites)
+	}
+	if len(r.Checks) != 2 || !r.Checks[0].IsRequired || r.Checks[1].name() != "ci/lint" {
+		t.Errorf("unexpected checks: %+v", r.Checks)
+	}
+}
+
+func TestBuildPRMessageFailingRequiredChecks(t *testing.T) {
+	pr := PRItem{Number: 1, Readiness: &PRReadiness{Checks: []CheckStatus{
+		{TypeName: "CheckRun", Name: "build", Status: "COMPLETED", Conclusion: "FAILURE", IsRequired: true},
+		{TypeName: "CheckRun", Name: "flaky-e2e", Status: "COMPLETED", Conclusion: "FAILURE"},
+		{TypeName: "StatusContext", Context: "ci/lint", State: "ERROR", IsRequired: true},
+		{TypeName: "CheckRun", Name: "test", Status: "COMPLETED", Conclusion: "SUCCESS", IsRequired: true},
+	}}}
+	msg := buildPRMessage(&pr, "org/repo", "dave", PostOptions{}, config.Config{})
+	if len(msg.Blocks) != 2 {
+		t.Fatalf("expected a checks context block, got %d blocks", len(msg.Blocks))
+	}
+	line := msg.Blocks[1].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject).Text
+	if line != ":x: Failing required checks: build, ci/lint" {
+		t.Errorf("unexpected checks context: %q", line)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if fmt.Sprint(payload["failing_checks"]) != "[build ci/lint]" || payload["failing_checks_required"] != true {
+		t.Errorf("unexpected failing checks metadata: %v", payload)
+	}
+
+	pr = PRItem{Number: 2, StatusCheckRollup: []CheckStatus{{Name: "build", Status: "COMPLETED", Conclusion: "FAILURE"}}}
+	if got := failingChecksContext(&pr); got != ":x: Failing checks: build" {
+		t.Errorf("expected the rollup fallback, got %q", got)
+	}
+	if got := failingChecksContext(&PRItem{}); got != "" {
+		t.Errorf("expected no context without failing checks, got %q", got)
+	}
+}
+
+func TestFetchPRReadinessGraphQLError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":null,"errors":[{"message":"Could not resolve to a Repository"}]}`)
+	}))
+	defer srv.Close()
+
+	if _, err := newGitHubClient(srv.URL, "tok").fetchPRReadiness(context.Background(), "org/missing", 1); err == nil {
+		t.Error("expected GraphQL error to be returned")
+	}
+}
+
+func TestGitHubClientGraphQLURLForEnterprise(t *testing.T) {
+	c := newGitHubClient("https://ghe.example.com/api/v3", "")
+	if got := c.graphqlURL(); got != "https://ghe.example.com/api/graphql" {
+		t.Errorf("unexpected GHES GraphQL URL: %q", got)
+	}
+}
+
+func TestBuildPRMessageIncludesReadiness(t *testing.T) {
+	pr := PRItem{Number: 1, Title: "One", Readiness: &PRReadiness{
+		MergeStateStatus:  "CLEAN",
+		ReviewThreads:     2,
+		UnresolvedThreads: 0,
+		CheckSuites:       []CheckStatus{{Name: "GitHub Actions", Status: "COMPLETED", Conclusion: "SUCCESS"}},
+	}}
+	msg := buildPRMessage(&pr, "org/repo", "dave", PostOptions{}, config.Config{})
+
+	if !strings.Contains(msg.Text, "*Readiness:* :large_green_circle: ready to merge · checks ✅ · 0/2 threads unresolved") {
+		t.Errorf("expected readiness line, got %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["merge_state_status"] != "CLEAN" {
+		t.Errorf("expected merge_state_status in metadata, got %v", payload["merge_state_status"])
+	}
+}
+
+func TestBuildPRMessageWithoutReadiness(t *testing.T) {
+	msg := buildPRMessage(&PRItem{Number: 1}, "org/repo", "dave", PostOptions{}, config.Config{})
+	if strings.Contains(msg.Text, "Readiness") {
+		t.Errorf("expected no readiness line, got %q", msg.Text)
+	}
+}
+
+func TestBuildPRMessageFlagsConflicts(t *testing.T) {
+	for name, pr := range map[string]PRItem{
+		"pr view":   {Number: 1, Mergeable: "CONFLICTING"},
+		"readiness": {Number: 1, Readiness: &PRReadiness{Mergeable: "CONFLICTING", MergeStateStatus: "DIRTY"}},
+	} {
+		msg := buildPRMessage(&pr, "org/repo", "dave", PostOptions{}, config.Config{})
+		if !strings.Contains(msg.Text, ":warning: *Has conflicts*") {
+			t.Errorf("%s: expected a conflicts warning, got %q", name, msg.Text)
+		}
+		payload := msg.Metadata["event_payload"].(map[string]interface{})
+		if payload["mergeable"] != "CONFLICTING" || payload["has_conflicts"] != true {
+			t.Errorf("%s: unexpected mergeability metadata: %v", name, payload)
+		}
+	}
+
+	msg := buildPRMessage(&PRItem{Number: 2, Mergeable: "MERGEABLE"}, "org/repo", "dave", PostOptions{}, config.Config{})
+	if strings.Contains(msg.Text, "conflicts") || msg.Metadata["event_payload"].(map[string]interface{})["has_conflicts"] != false {
+		t.Errorf("expected no conflicts for a mergeable PR, got %q", msg.Text)
+	}
+	msg = buildPRMessage(&PRItem{Number: 3}, "org/repo", "dave", PostOptions{}, config.Config{})
+	if _, ok := msg.Metadata["event_payload"].(map[string]interface{})["has_conflicts"]; ok {
+		t.Error("expected no has_conflicts when mergeability was not fetched")
+	}
+}
+
+// ---- GitHub App authentication tests ----
+
+func testGitHubAppKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestGitHubAppTokenSourceCachesAndRefreshes(t *testing.T) {
+	var calls int
+	var gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":"2030-01-01T01:00:00Z"}`, calls)
+	}))
+	defer srv.Close()
+
+	s, err := newGitHubAppTokenSource(42, 99, testGitHubAppKeyPEM(t), srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	tok, err := s.Token(context.Background())
+	if err != nil || tok != "ghs_1" {
+		t.Fatalf("expected ghs_1, got %q (err %v)", tok, err)
+	}
+	if gotPath != "/app/installations/99/access_tokens" {
+		t.Errorf("unexpected path: %q", gotPath)
+	}
+	if parts := strings.Split(strings.TrimPrefix(gotAuth, "Bearer "), "."); len(parts) != 3 {
+		t.Errorf("expected a JWT bearer token, got %q", gotAuth)
+	}
+
+	if tok, _ := s.Token(context.Background()); tok != "ghs_1" || calls != 1 {
+		t.Errorf("expected cached token, got %q after %d calls", tok, calls)
+	}
+
+	now = now.Add(56 * time.Minute)
+	if tok, _ := s.Token(context.Background()); tok != "ghs_2" || calls != 2 {
+		t.Errorf("expected refreshed token near expiry, got %q after %d calls", tok, calls)
+	}
+}
+
+func TestGitHubAuthTokenFallsBackToPAT(t *testing.T) {
+	tok, err := githubAuthToken(context.Background(), config.Config{GitHubToken: "pat"})
+	if err != nil || tok != "pat" {
+		t.Errorf("expected PAT, got %q (err %v)", tok, err)
+	}
+	if (config.Config{GitHubAppID: 1, GitHubAppInstallationID: 2}).UsesGitHubApp() {
+		t.Error("App auth should require a private key")
+	}
+}
+
+func TestPushPoppitCommandCarriesNoGitHubAppToken(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token":"ghs_secret","expires_at":"2030-01-01T01:00:00Z"}`)
+	}))
+	defer srv.Close()
+
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	config.GitHubAppID, config.GitHubAppInstallationID = 42, 99
+	config.GitHubAppPrivateKey = testGitHubAppKeyPEM(t)
+	config.GitHubAPIURL = srv.URL
+
+	if err := pushPoppitCommand(context.Background(), rdb, poppit.Command{Type: poppitPRListType, Commands: []string{"gh pr list"}}, config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items, _ := mr.List(config.RedisPoppitList)
+	if len(items) != 1 || strings.Contains(items[0], "ghs_secret") || strings.Contains(items[0], "GH_TOKEN") {
+		t.Errorf("expected no GitHub token in the Poppit command, got %v", items)
+	}
+	if calls != 0 {
+		t.Errorf("expected no installation token to be fetched, got %d calls", calls)
+	}
+}
+
+func TestPushPoppitCommandSealsGitHubAppToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"token":"ghs_sealed","expires_at":"2030-01-01T01:00:00Z"}`)
+	}))
+	defer srv.Close()
+
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	config.GitHubAppID, config.GitHubAppInstallationID = 43, 99
+	config.GitHubAppPrivateKey = testGitHubAppKeyPEM(t)
+	config.GitHubAPIURL = srv.URL
+	config.PoppitEnvKey = []byte(strings.Repeat("p", 32))
+
+	if err := pushPoppitCommand(context.Background(), rdb, poppit.Command{Type: poppitPRListType, Commands: []string{"gh pr list"}}, config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items, _ := mr.List(config.RedisPoppitList)
+	if len(items) != 1 || strings.Contains(items[0], "ghs_sealed") {
+		t.Fatalf("expected the token to be sealed, got %v", items)
+	}
+	var cmd poppit.Command
+	if err := json.Unmarshal([]byte(items[0]), &cmd); err != nil {
+		t.Fatal(err)
+	}
+	commandID, _ := cmd.Metadata[poppitCommandIDField].(string)
+	env, err := poppit.OpenEnv(config.PoppitEnvKey, commandID, cmd.SealedEnv, time.Now())
+	if err != nil || env["GH_TOKEN"] != "ghs_sealed" {
+		t.Errorf("expected Poppit to open GH_TOKEN for the command, got %v, %v", env, err)
+	}
+	if _, err := poppit.OpenEnv(config.PoppitEnvKey, commandID, cmd.SealedEnv, time.Now().Add(poppitSealedEnvTTL)); err == nil {
+		t.Error("expected the sealed token to expire")
+	}
+}
+
+// ---- Multi-org tests ----
+
+func TestChooserRepoPath(t *testing.T) {
+	config := config.Config{GitHubOrg: "acme", GitHubOrgs: []string{"acme", "widgets-inc"}}
+	cases := []struct {
+		meta, repo, want string
+	}{
+		{"", "api", "acme/api"},
+		{`{"org":"widgets-inc"}`, "api", "widgets-inc/api"},
+		{`{"org":"evil-corp"}`, "api", "acme/api"},
+		{`{"org":"acme"}`, "widgets-inc/web", "widgets-inc/web"},
+	}
+	for _, c := range cases {
+		if got := chooserRepoPath(c.meta, c.repo, config); got != c.want {
+			t.Errorf("chooserRepoPath(%q, %q) = %q, want %q", c.meta, c.repo, got, c.want)
+		}
+	}
+}
+
+func TestHandleBlockActionOrgSelectionUpdatesView(t *testing.T) {
+	payload := `{"type":"block_actions","view":{"id":"V1"},"actions":[{"action_id":"org_select","block_id":"org_block","selected_option":{"value":"widgets-inc"}}]}`
+	config := config.Config{GitHubOrg: "acme", GitHubOrgs: []string{"acme", "widgets-inc"}}
+
+	fake := &fakeSlack{}
+	handleBlockAction(context.Background(), nil, fake, payload, config)
+	assertSlackCalls(t, "configured org re-renders chooser", fake, "views.update")
+	want := slackui.RepoChooserModal(config.Orgs(), "widgets-inc", "")
+	if calls := fake.recorded(); len(calls) == 1 && (calls[0].ViewID != "V1" || !reflect.DeepEqual(calls[0].View, want)) {
+		t.Errorf("expected the chooser for widgets-inc on V1, got %+v", calls[0])
+	}
+
+	unknown := strings.Replace(payload, "widgets-inc", "evil-corp", 1)
+	fake = &fakeSlack{}
+	handleBlockAction(context.Background(), nil, fake, unknown, config)
+	assertSlackCalls(t, "unconfigured org is ignored", fake)
+}
+
+// ---- Repo catalog tests ----
+
+func TestHandleBlockSuggestionServesRepoOptions(t *testing.T) {
+	// An empty catalog is refreshed through Poppit while the suggestion is
+	// answered; a cached one is served.
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	payload, _ := json.Marshal(BlockSuggestionPayload{Type: "block_suggestion", ActionID: slackui.SlashVibeIssueActionID})
+	handleBlockSuggestion(context.Background(), rdb, string(payload), config)
+	if cmd := popPoppitCommand(t, mr, config); cmd.Type != poppitRepoListType || cmd.Metadata["org"] != "acme" {
+		t.Errorf("expected a repo catalog refresh for acme, got %+v", cmd)
+	}
+}
+
+func TestFilterReposByQueryRanksPrefixMatchesFirst(t *testing.T) {
+	repos := []string{"api-gateway", "billing-api", "web", "API-docs"}
+	got := filterReposByQuery(repos, "api")
+	want := []string{"api-gateway", "API-docs", "billing-api"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := filterReposByQuery(repos, ""); len(got) != len(repos) {
+		t.Errorf("empty query should match all repos, got %v", got)
+	}
+}
+
+func TestBuildRepoListCommand(t *testing.T) {
+	if got := buildRepoListCommand("acme"); got != "gh repo list acme --no-archived --json name --limit 1000" {
+		t.Errorf("unexpected command: %q", got)
+	}
+}
+
+func TestGitHubClientListOrgReposPaginates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/acme/repos" {
+			t.Errorf("unexpected path: %q", r.URL.Path)
+		}
+		if r.URL.Query().Get("page") == "1" {
+			repos := make([]string, 100)
+			for i := range repos {
+				repos[i] = fmt.Sprintf(`{"name":"r%d","archived":%t}`, i, i == 0)
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(repos, ","))
+			return
+		}
+		fmt.Fprint(w, `[{"name":"last"}]`)
+	}))
+	defer srv.Close()
+
+	repos, err := newGitHubClient(srv.URL, "").listOrgRepos(context.Background(), "acme", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 100 || repos[0] != "r1" || repos[99] != "last" {
+		t.Errorf("expected 100 unarchived repos across two pages, got %d (%v...)", len(repos), repos[:2])
+	}
+}
+
+// ---- Recent repo tests ----
+
+func TestRankRecentFirst(t *testing.T) {
+	repos := []string{"api", "billing", "docs", "web"}
+	got := rankRecentFirst(repos, []string{"web", "gone", "billing"})
+	want := []string{"web", "billing", "api", "docs"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRankRecentFirstSkipsRecentsFilteredOut(t *testing.T) {
+	// A recent repo that does not match the typed query must not reappear.
+	got := rankRecentFirst([]string{"api"}, []string{"web"})
+	if len(got) != 1 || got[0] != "api" {
+		t.Errorf("expected only the matching repo, got %v", got)
+	}
+}
+
+func TestRecentReposSkipAnonymousUsers(t *testing.T) {
+	assertNoPanic(t, "empty user ID", func() {
+		if err := recordRecentRepo(context.Background(), nil, "", "acme/api", time.Now()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if repos, err := loadRecentRepos(context.Background(), nil, "", "acme"); err != nil || repos != nil {
+			t.Errorf("expected no recent repos, got %v (err %v)", repos, err)
+		}
+	})
+}
+
+// ---- Favorite repo tests ----
+
+func TestHandleSlashCommandFavValidation(t *testing.T) {
+	cases := map[string]string{
+		"fav add":                               "Usage",
+		"fav frob repo":                         "Usage",
+		"fav add repo;rm":                       "not a valid repository",
+		"fav add https://github.com/o/r/pull/1": "not a valid repository",
+	}
+	for text, want := range cases {
+		var got slack.WebhookMessage
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}))
+
+		payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: text, ResponseURL: srv.URL})
+		handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{GitHubOrg: "acme"})
+		srv.Close()
+
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("%q: expected reply containing %q, got %q", text, want, got.Text)
+		}
+	}
+}
+
+func TestHandleSlashCommandFavAddReachesRedis(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "fav add api", UserID: "U1"})
+	handleSlashCommand(context.Background(), rdb, &fakeSlack{}, string(payload), testConfig(t))
+	if ok, _ := mr.SIsMember(favoritesKey("U1"), "acme/api"); !ok {
+		t.Error("expected acme/api to be stored as a favorite")
+	}
+}
+
+func TestFavoritesBlocks(t *testing.T) {
+	blocks := favoritesBlocks([]string{"acme/api", "acme/web"})
+	if len(blocks) != 2 {
+		t.Fatalf("expected section + actions blocks, got %d", len(blocks))
+	}
+	actions, ok := blocks[1].(*slack.ActionBlock)
+	if !ok || actions.BlockID != favBlockID {
+		t.Fatalf("expected favorites action block, got %T", blocks[1])
+	}
+	seen := map[string]bool{}
+	for _, el := range actions.Elements.ElementSet {
+		btn := el.(*slack.ButtonBlockElement)
+		if seen[btn.ActionID] {
+			t.Errorf("duplicate action_id %q", btn.ActionID)
+		}
+		seen[btn.ActionID] = true
+	}
+	if btn := actions.Elements.ElementSet[1].(*slack.ButtonBlockElement); btn.Value != "acme/web" || btn.Text.Text != "web" {
+		t.Errorf("unexpected button: value %q text %q", btn.Value, btn.Text.Text)
+	}
+}
+
+func TestHandleBlockActionFavoriteOpensLoadingModal(t *testing.T) {
+	payload := `{"type":"block_actions","trigger_id":"tid","actions":[{"action_id":"fav_repo_0","block_id":"fav_block","type":"button","value":"acme/api"}]}`
+	assertShowsView(t, "favorite button", "views.open", slackui.LoadingModal(), func(fake *fakeSlack) {
+		handleBlockAction(context.Background(), unreachableRedis(), fake, payload, config.Config{})
+	})
+
+	invalid := strings.Replace(payload, "acme/api", "acme/api;rm", 1)
+	fake := &fakeSlack{}
+	handleBlockAction(context.Background(), nil, fake, invalid, config.Config{})
+	assertSlackCalls(t, "invalid favorite", fake)
+}
+
+// ---- /pr mine tests ----
+
+func TestPRSearchCommand(t *testing.T) {
+	got := prSearch{Author: "octocat"}.command([]string{"acme", "widgets-inc"}, config.DefaultPRLimit)
+	want := "gh search prs --state open --author octocat --owner acme --owner widgets-inc --json " + prSearchJSONFields + " --limit 50"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPRSearchAPIQuery(t *testing.T) {
+	if got := (prSearch{Author: "octocat"}).apiQuery([]string{"acme"}); got != "is:pr is:open author:octocat org:acme" {
+		t.Errorf("unexpected query: %q", got)
+	}
+}
+
+func TestHandleSlashCommandMineRequiresLinkedLogin(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "mine", UserID: "U1", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), unreachableRedis(), nil, string(payload), config.Config{})
+
+	if !strings.Contains(got.Text, "whoami link") {
+		t.Errorf("expected link instructions, got %q", got.Text)
+	}
+}
+
+func TestCrossRepoPROptionValues(t *testing.T) {
+	pr := PRItem{Number: 12, Title: "Mine"}
+	pr.Repository.NameWithOwner = "acme/api"
+	other := PRItem{Number: 12, Title: "Other"}
+	other.Repository.NameWithOwner = "acme/web"
+	prs := []PRItem{pr, other}
+
+	opts := prOptions(prs, time.Now())
+	if opts[0].Value != "acme/api#12" || opts[1].Value != "acme/web#12" {
+		t.Errorf("unexpected option values: %q, %q", opts[0].Value, opts[1].Value)
+	}
+	if found := findPR(prs, "acme/web#12"); found == nil || found.Title != "Other" {
+		t.Errorf("expected to resolve acme/web#12, got %+v", found)
+	}
+	if got := pr.repoOr("author:octocat"); got != "acme/api" {
+		t.Errorf("expected PR's own repo, got %q", got)
+	}
+	if !strings.HasPrefix(prOptionDescription(pr, time.Now()), "acme/api") {
+		t.Errorf("expected repo in description, got %q", prOptionDescription(pr, time.Now()))
+	}
+}
+
+func TestGitHubClientSearchPullRequests(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("q")
+		fmt.Fprint(w, `{"items":[{"number":3,"title":"Fix","html_url":"https://github.com/acme/api/pull/3",
+			"user":{"login":"octocat"},"repository_url":"https://api.github.com/repos/acme/api"}]}`)
+	}))
+	defer srv.Close()
+
+	prs, err := newGitHubClient(srv.URL, "").searchPullRequests(context.Background(), "is:pr author:octocat", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != "is:pr author:octocat" {
+		t.Errorf("unexpected search query: %q", gotQuery)
+	}
+	if len(prs) != 1 || prs[0].Repository.NameWithOwner != "acme/api" || prs[0].Author.Login != "octocat" {
+		t.Errorf("unexpected search results: %+v", prs)
+	}
+}
+
+// ---- /pr reviews tests ----
+
+func TestPRSearchReviewRequested(t *testing.T) {
+	search := prSearch{ReviewRequested: "octocat"}
+	if got := search.command(nil, config.DefaultPRLimit); !strings.Contains(got, "--review-requested octocat") {
+		t.Errorf("unexpected command: %q", got)
+	}
+	if got := search.apiQuery(nil); got != "is:pr is:open review-requested:octocat" {
+		t.Errorf("unexpected query: %q", got)
+	}
+	if got := prSearchFromMetadata(search.metadata()); !reflect.DeepEqual(got, search) {
+		t.Errorf("metadata round trip: got %+v", got)
+	}
+}
+
+func TestCreateReviewQueueModal(t *testing.T) {
+	a := PRItem{Number: 1, Title: "First", URL: "https://github.com/acme/api/pull/1"}
+	a.Repository.NameWithOwner = "acme/api"
+	b := PRItem{Number: 2, Title: "Second", URL: "https://github.com/acme/web/pull/2"}
+	b.Repository.NameWithOwner = "acme/web"
+
+	modal := createReviewQueueModal(PRModalPrivateMetadata{PRs: []PRItem{a, b}, Posted: []string{"acme/web#2"}}, time.Now())
+	if len(modal.Blocks.BlockSet) != 6 {
+		t.Fatalf("expected header + 2 blocks per PR + posted note, got %d", len(modal.Blocks.BlockSet))
+	}
+
+	first := modal.Blocks.BlockSet[2].(*slack.ActionBlock)
+	if len(first.Elements.ElementSet) != 2 {
+		t.Fatalf("expected post and open buttons, got %d", len(first.Elements.ElementSet))
+	}
+	post := first.Elements.ElementSet[0].(*slack.ButtonBlockElement)
+	if post.ActionID != reviewPostActionID || post.Value != "acme/api#1" {
+		t.Errorf("unexpected post button: %+v", post)
+	}
+	if open := first.Elements.ElementSet[1].(*slack.ButtonBlockElement); open.URL != a.URL {
+		t.Errorf("expected open button to link to the PR, got %q", open.URL)
+	}
+
+	second := modal.Blocks.BlockSet[4].(*slack.ActionBlock)
+	if len(second.Elements.ElementSet) != 1 {
+		t.Errorf("posted PR should only offer Open in GitHub, got %d buttons", len(second.Elements.ElementSet))
+	}
+}
+
+func TestHandleBlockActionReviewPostLoadsSession(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	pr := PRItem{Number: 1, Title: "Review me"}
+	pr.Repository.NameWithOwner = "acme/api"
+	if err := savePRSession(context.Background(), rdb, "V1", PRModalPrivateMetadata{PRs: []PRItem{pr}}, config); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `{"type":"block_actions","view":{"id":"V1"},"actions":[{"action_id":"review_post","block_id":"review_0","type":"button","value":"acme/api#1"}]}`
+	fake := &fakeSlack{}
+	handleBlockAction(context.Background(), rdb, fake, payload, config)
+
+	if posts := slackLinerPosts(t, mr, config); len(posts) == 0 {
+		t.Error("expected the PR to be posted")
+	}
+	assertSlackCalls(t, "review post", fake, "views.update")
+	session, err := loadPRSession(context.Background(), rdb, "V1")
+	if err != nil || len(session.Posted) != 1 || session.Posted[0] != "acme/api#1" {
+		t.Errorf("expected the session to record the post, got %+v, %v", session.Posted, err)
+	}
+}
+
+// ---- /pr search tests ----
+
+func TestParseSearchQuery(t *testing.T) {
+	cases := map[string]string{
+		` "label:bug is:open repo:org/x" `: "label:bug is:open repo:org/x",
+		`“label:bug”`:                      "label:bug",
+		`label:"good first issue"`:         `label:"good first issue"`,
+		`fix typo`:                         "fix typo",
+	}
+	for in, want := range cases {
+		got, err := parseSearchQuery(in)
+		if err != nil || got != want {
+			t.Errorf("parseSearchQuery(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", `""`, strings.Repeat("a", maxSearchQueryLength+1)} {
+		if _, err := parseSearchQuery(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestPRSearchFreeTextCommandQuotesQuery(t *testing.T) {
+	got := prSearch{Query: "label:bug it's; rm -rf /"}.command([]string{"acme"}, config.DefaultPRLimit)
+	want := "gh search prs --owner acme --json " + prSearchJSONFields + ` --limit 50 -- 'label:bug it'\''s; rm -rf /'`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPRSearchFreeTextKeepsExplicitScope(t *testing.T) {
+	search := prSearch{Query: "label:bug repo:org/x"}
+	if got := search.command([]string{"acme"}, config.DefaultPRLimit); strings.Contains(got, "--owner") {
+		t.Errorf("explicit repo: qualifier should not be widened with --owner, got %q", got)
+	}
+	if got := search.apiQuery([]string{"acme"}); got != "is:pr label:bug repo:org/x" {
+		t.Errorf("unexpected API query: %q", got)
+	}
+}
+
+func TestHandleSlashCommandSearchWithoutQuery(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "search", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{})
+
+	if !strings.Contains(got.Text, "needs a query") {
+		t.Errorf("expected usage hint, got %q", got.Text)
+	}
+}
+
+// ---- /pr status tests ----
+
+func TestParsePRRefArgs(t *testing.T) {
+	config := config.Config{GitHubOrg: "acme"}
+	for _, fields := range [][]string{{"api", "12"}, {"acme/api", "#12"}, {"https://github.com/acme/api/pull/12"}} {
+		args, err := parsePRRefArgs(fields, config)
+		if err != nil || args.Number != 12 || args.fullRepo(config) != "acme/api" {
+			t.Errorf("parsePRRefArgs(%v) = %+v, %v", fields, args, err)
+		}
+	}
+	for _, fields := range [][]string{nil, {"api"}, {"api", "twelve"}, {"api", "1", "2"}, {"api;rm", "1"}} {
+		if _, err := parsePRRefArgs(fields, config); err == nil {
+			t.Errorf("expected error for %v", fields)
+		}
+	}
+}
+
+func TestBuildPRStatusMessage(t *testing.T) {
+	pr := PRItem{
+		Number:           12,
+		Title:            "Add <thing>",
+		URL:              "https://github.com/acme/api/pull/12",
+		ReviewDecision:   "APPROVED",
+		MergeStateStatus: "BLOCKED",
+		StatusCheckRollup: []CheckStatus{
+			{Status: "COMPLETED", Conclusion: "SUCCESS"},
+			{Status: "COMPLETED", Conclusion: "FAILURE"},
+			{Status: "IN_PROGRESS"},
+		},
+	}
+	msg := buildPRStatusMessage(&pr, "acme/api", "dave", config.Config{SlackChannelID: "C1"})
+
+	for _, want := range []string{
+		"*<https://github.com/acme/api/pull/12|acme/api#12>* Add &lt;thing&gt;",
+		"*Checks:* ❌ 1 failing, 1 pending, 1 passing",
+		"*Review:* approved",
+		"*Merge:* :red_circle: blocked",
+	} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("expected %q in status card:\n%s", want, msg.Text)
+		}
+	}
+	if msg.Metadata["event_type"] != "pr_status_posted" {
+		t.Errorf("unexpected event type: %v", msg.Metadata["event_type"])
+	}
+}
+
+func TestBuildPRStatusMessageWithoutChecks(t *testing.T) {
+	msg := buildPRStatusMessage(&PRItem{Number: 1}, "acme/api", "dave", config.Config{})
+	if !strings.Contains(msg.Text, "*Checks:* none") || !strings.Contains(msg.Text, "*Merge:* unknown") {
+		t.Errorf("unexpected status card: %s", msg.Text)
+	}
+}
+
+func TestInvocationStatusRoundTrip(t *testing.T) {
+	inv := Invocation{UserID: "U1", Status: true}
+	if got := invocationFromMetadata(inv.metadata()); !got.Status {
+		t.Error("expected status flag to survive Poppit metadata")
+	}
+}
+
+// ---- /pr approve tests ----
+
+func TestParseApproveArgs(t *testing.T) {
+	config := config.Config{GitHubOrg: "acme"}
+	cases := []struct {
+		text string
+		want approveArgs
+	}{
+		{"api 12", approveArgs{Repo: "acme/api", Number: 12}},
+		{"acme/api #12 LGTM, ship it", approveArgs{Repo: "acme/api", Number: 12, Comment: "LGTM, ship it"}},
+		{"https://github.com/acme/api/pull/12 nice", approveArgs{Repo: "acme/api", Number: 12, Comment: "nice"}},
+	}
+	for _, c := range cases {
+		got, err := parseApproveArgs(c.text, config)
+		if err != nil || got != c.want {
+			t.Errorf("parseApproveArgs(%q) = %+v, %v; want %+v", c.text, got, err, c.want)
+		}
+	}
+	for _, bad := range []string{"", "api", "api twelve", "api;rm 1", "--force 1"} {
+		if _, err := parseApproveArgs(bad, config); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestBuildPRApproveCommandRecordsApprover(t *testing.T) {
+	body := approveReviewBody("it's fine", "dave", "octodave")
+	got := buildPRApproveCommand("acme/api", 12, body)
+	if !strings.HasPrefix(got, "gh pr review 12 --repo acme/api --approve --body 'it'\\''s fine") {
+		t.Errorf("unexpected command: %q", got)
+	}
+	if !strings.Contains(body, "@dave (GitHub: octodave)") {
+		t.Errorf("expected audit note in body, got %q", body)
+	}
+}
+
+func TestParseApproveArgsDryRun(t *testing.T) {
+	cfg := config.Config{GitHubOrg: "acme"}
+	args, err := parseApproveArgs("--dry-run api 12 ship it --dry-run", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !args.DryRun || args.Repo != "acme/api" || args.Number != 12 || args.Comment != "ship it --dry-run" {
+		t.Errorf("unexpected args: %+v", args)
+	}
+	if args, _ := parseApproveArgs("api 12 --dry-run", cfg); args.DryRun {
+		t.Error("expected --dry-run in the comment to be kept as text")
+	}
+}
+
+func TestHandlePRApproveOutputTrustsExitCode(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		got = append(got, msg.Text)
+	}))
+	defer srv.Close()
+
+	meta := map[string]interface{}{"repo": "acme/api", "number": float64(12), "response_url": srv.URL, "user_id": "U1", "username": "dave"}
+	ok, failed := 0, 1
+	// gh succeeded even though the comment mentions an error.
+	handlePRApproveOutput(ctx, rdb, poppit.Output{Type: poppitPRApproveType, Output: "Fixed the error handling", ExitCode: &ok, Metadata: meta}, testConfig(t))
+	handlePRApproveOutput(ctx, rdb, poppit.Output{Type: poppitPRApproveType, Output: "GraphQL: Can not approve your own pull request", ExitCode: &failed, Metadata: meta}, testConfig(t))
+
+	if len(got) != 2 || !strings.Contains(got[0], "Approved") || !strings.Contains(got[1], "Could not approve") {
+		t.Errorf("expected success then failure from the exit codes, got %q", got)
+	}
+}
+
+func TestHandleSlashCommandApproveDryRun(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "approve --dry-run api 12", UserID: "U1", UserName: "dave", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), unreachableRedis(), nil, string(payload), config.Config{GitHubOrg: "acme", PrivilegedUserIDs: []string{"U1"}})
+
+	if !strings.Contains(got.Text, "gh pr review 12 --repo acme/api --approve") {
+		t.Errorf("expected dry-run echo of the gh command, got %q", got.Text)
+	}
+}
+
+func TestHandleSlashCommandApproveNeedsPrivilege(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig(t)
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "approve api 12", UserID: "U9", UserName: "erin", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), rdb, &fakeSlack{}, string(payload), cfg)
+	if !strings.Contains(got.Text, "Only privileged users") {
+		t.Errorf("expected a privilege error, got %q", got.Text)
+	}
+	if mr.Exists(cfg.RedisPoppitList) {
+		t.Error("expected no approval from an unprivileged user")
+	}
+}
+
+func TestApproveRefusesOwnPR(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	var got []slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		got = append(got, msg)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig(t)
+	cfg.PrivilegedUserIDs = []string{"U1"}
+	rdb.HSet(ctx, slackToGitHubKey, "U1", "octodave")
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "approve api 12 lgtm", UserID: "U1", UserName: "dave", ResponseURL: srv.URL})
+	handleSlashCommand(ctx, rdb, &fakeSlack{}, string(payload), cfg)
+
+	check := popPoppitCommand(t, mr, cfg)
+	if check.Type != poppitPRApproveCheckType || check.Commands[0] != "gh pr view 12 --repo acme/api --json author" {
+		t.Fatalf("expected the author to be looked up first, got %s %q", check.Type, check.Commands)
+	}
+	var metadata map[string]interface{}
+	data, _ := json.Marshal(check.Metadata)
+	_ = json.Unmarshal(data, &metadata)
+
+	handlePRApproveCheckOutput(ctx, rdb, poppit.Output{Type: poppitPRApproveCheckType, Metadata: metadata, Output: `{"author":{"login":"OctoDave"}}`}, cfg)
+	if mr.Exists(cfg.RedisPoppitList) {
+		t.Fatal("expected no approval of the approver's own PR")
+	}
+	if len(got) != 1 || !strings.Contains(got[0].Text, "you opened it") {
+		t.Fatalf("expected a refusal, got %+v", got)
+	}
+
+	handlePRApproveCheckOutput(ctx, rdb, poppit.Output{Type: poppitPRApproveCheckType, Metadata: metadata, Output: `{"author":{"login":"alice"}}`}, cfg)
+	approve := popPoppitCommand(t, mr, cfg)
+	if approve.Type != poppitPRApproveType || !strings.HasPrefix(approve.Commands[0], "gh pr review 12 --repo acme/api --approve --body 'lgtm") {
+		t.Errorf("expected the approval to be queued, got %s %q", approve.Type, approve.Commands)
+	}
+	if _, ok := approve.Metadata["login"]; ok {
+		t.Error("expected the login to be dropped from the approval's metadata")
+	}
+}
+
+func TestPostedPRKey(t *testing.T) {
+	if got := postedPRKey("acme/api", 12); got != "slashvibepr:posted:acme/api#12" {
+		t.Errorf("unexpected key: %q", got)
+	}
+}
+
+// ---- Reviewer request tests ----
+
+func TestSelectedReviewers(t *testing.T) {
+	values := map[string]map[string]interface{}{
+		slackui.ReviewerBlockID: {
+			slackui.ReviewerSelectActionID: map[string]interface{}{
+				"selected_options": []interface{}{
+					map[string]interface{}{"value": "alice"},
+					map[string]interface{}{"value": "bob; rm -rf /"},
+					map[string]interface{}{"value": "carol"},
+				},
+			},
+		},
+	}
+	got := selectedReviewers(values)
+	if len(got) != 2 || got[0] != "alice" || got[1] != "carol" {
+		t.Errorf("expected [alice carol], got %v", got)
+	}
+	if got := selectedReviewers(nil); len(got) != 0 {
+		t.Errorf("expected no reviewers, got %v", got)
+	}
+}
+
+func TestBuildAddReviewersCommand(t *testing.T) {
+	got := buildAddReviewersCommand("acme/api", 7, []string{"alice", "carol"})
+	if got != "gh pr edit 7 --repo acme/api --add-reviewer alice,carol" {
+		t.Errorf("unexpected command: %q", got)
+	}
+}
+
+func TestRequestReviewersDryRunSkipsPoppit(t *testing.T) {
+	pr := &PRItem{Number: 7}
+	err := requestReviewers(context.Background(), unreachableRedis(), pr, "acme/api", []string{"alice"}, Invocation{DryRun: true}, config.Config{})
+	if err != nil {
+		t.Errorf("dry run should not touch Redis, got %v", err)
+	}
+}
+
+func TestReviewerOrg(t *testing.T) {
+	cfg := config.Config{GitHubOrg: "acme"}
+	if got := reviewerOrg(`{"repo":"widgets-inc/api"}`, cfg); got != "widgets-inc" {
+		t.Errorf("expected owner of modal repo, got %q", got)
+	}
+	if got := reviewerOrg(`{"repo":"author:alice"}`, cfg); got != "acme" {
+		t.Errorf("expected default org for searches, got %q", got)
+	}
+}
+
+func TestParseMemberListOutput(t *testing.T) {
+	got, err := parseMemberListOutput("alice\nbob\n\nnot a login\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Errorf("expected [alice bob], got %v", got)
+	}
+}
+
+func TestAddReviewersFailed(t *testing.T) {
+	if addReviewersFailed("https://github.com/acme/error-tracker/pull/7\n", 7) {
+		t.Error("expected PR URL output to count as success")
+	}
+	if !addReviewersFailed("could not request reviewer: 'zed' not found", 7) {
+		t.Error("expected error output to count as failure")
+	}
+}
+
+func TestGitHubClientListOrgMembers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/acme/members" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `[{"login":"alice"},{"login":"bob"}]`)
+	}))
+	defer srv.Close()
+
+	logins, err := newGitHubClient(srv.URL, "").listOrgMembers(context.Background(), "acme", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logins) != 2 || logins[0] != "alice" {
+		t.Errorf("expected [alice bob], got %v", logins)
+	}
+}
+
+// ---- Reviewer roulette tests ----
+
+func TestSuggestReviewerWithoutPool(t *testing.T) {
+	cfg := config.Config{GitHubReviewerPools: map[string][]string{"acme/api": {"alice"}}}
+	if got := suggestReviewer(context.Background(), nil, "acme/web", "zed", true, cfg); got != "" {
+		t.Errorf("expected no suggestion for repo without a pool, got %q", got)
+	}
+	// The author is never suggested, so a pool of only the author is empty.
+	if got := suggestReviewer(context.Background(), nil, "acme/api", "Alice", true, cfg); got != "" {
+		t.Errorf("expected no suggestion when the author is the whole pool, got %q", got)
+	}
+}
+
+func TestSuggestReviewerRedisErrorSkipsSuggestion(t *testing.T) {
+	cfg := config.Config{GitHubReviewerPools: map[string][]string{"acme/api": {"alice", "bob"}}}
+	if got := suggestReviewer(context.Background(), unreachableRedis(), "acme/api", "zed", true, cfg); got != "" {
+		t.Errorf("expected no suggestion when Redis is down, got %q", got)
+	}
+}
+
+func TestBuildPRMessageSuggestedReviewer(t *testing.T) {
+	pr := &PRItem{Number: 3, Title: "T"}
+	msg := buildPRMessage(pr, "acme/api", "erin", PostOptions{SuggestedReviewer: "bob"}, config.Config{})
+	if !strings.Contains(msg.Text, "*Suggested reviewer:* @bob") {
+		t.Errorf("expected suggested reviewer line, got %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["suggested_reviewer"] != "bob" {
+		t.Errorf("expected suggested_reviewer in metadata, got %v", payload["suggested_reviewer"])
+	}
+
+	msg = buildPRMessage(pr, "acme/api", "erin", PostOptions{SuggestedReviewer: "bob", SuggestedReviewerSlackID: "U2"}, config.Config{})
+	if !strings.Contains(msg.Text, "*Suggested reviewer:* <@U2>") {
+		t.Errorf("expected Slack mention for mapped reviewer, got %q", msg.Text)
+	}
+
+	msg = buildPRMessage(pr, "acme/api", "erin", PostOptions{}, config.Config{})
+	if strings.Contains(msg.Text, "Suggested reviewer") {
+		t.Errorf("expected no reviewer line without a pool, got %q", msg.Text)
+	}
+}
+
+func TestBuildPRMessageRequestedReviewers(t *testing.T) {
+	var pr PRItem
+	if err := json.Unmarshal([]byte(`{"number":4,"title":"T","reviewRequests":[{"__typename":"User","login":"alice"},{"__typename":"User","login":"bob"},{"__typename":"Team","name":"Backend","slug":"backend"}]}`), &pr); err != nil {
+		t.Fatal(err)
+	}
+	msg := buildPRMessage(&pr, "acme/api", "erin", PostOptions{ReviewerSlackIDs: map[string]string{"alice": "U1"}}, config.Config{})
+	if !strings.Contains(msg.Text, "*Reviewers:* <@U1>, @bob, @backend") {
+		t.Errorf("expected reviewers line, got %q", msg.Text)
+	}
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if got := fmt.Sprint(payload["reviewers"]); got != "[alice bob backend]" {
+		t.Errorf("unexpected reviewers metadata: %s", got)
+	}
+
+	msg = buildPRMessage(&PRItem{Number: 5}, "acme/api", "erin", PostOptions{}, config.Config{})
+	if strings.Contains(msg.Text, "Reviewers") || msg.Metadata["event_payload"].(map[string]interface{})["reviewers"] != nil {
+		t.Errorf("expected no reviewers without review requests, got %q", msg.Text)
+	}
+}
+
+// ---- Refresh status shortcut tests ----
+
+func refreshShortcut(eventType string, payload map[string]interface{}) MessageShortcutPayload {
+	var s MessageShortcutPayload
+	s.Type = messageShortcutType
+	s.CallbackID = refreshStatusCallbackID
+	s.Message.Metadata.EventType = eventType
+	s.Message.Metadata.EventPayload = payload
+	return s
+}
+
+func TestShortcutPRRef(t *testing.T) {
+	s := refreshShortcut("pr_posted", map[string]interface{}{
+		"repository": "acme/api", "pr_number": float64(42), "thread_key": "k1",
+	})
+	repo, number, key, ok := shortcutPRRef(s)
+	if !ok || repo != "acme/api" || number != 42 || key != "k1" {
+		t.Errorf("unexpected ref: %q %d %q %v", repo, number, key, ok)
+	}
+
+	for _, bad := range []MessageShortcutPayload{
+		refreshShortcut("", nil),
+		refreshShortcut("pr_approved", map[string]interface{}{"repository": "acme/api", "pr_number": float64(1)}),
+		refreshShortcut("pr_posted", map[string]interface{}{"repository": "acme/api; rm", "pr_number": float64(1)}),
+		refreshShortcut("pr_posted", map[string]interface{}{"repository": "acme/api"}),
+	} {
+		if _, _, _, ok := shortcutPRRef(bad); ok {
+			t.Errorf("expected %+v to be rejected", bad.Message.Metadata)
+		}
+	}
+}
+
+func TestRefreshShortcutOnForeignMessageReplies(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	s := refreshShortcut("", nil)
+	s.ResponseURL = srv.URL
+	payload, _ := json.Marshal(s)
+	handleBlockAction(context.Background(), nil, nil, string(payload), config.Config{})
+
+	if !strings.Contains(got.Text, "not a pull request") {
+		t.Errorf("expected explanation in response, got %q", got.Text)
+	}
+}
+
+func TestRefreshShortcutQueuesThreadedStatus(t *testing.T) {
+	// With a thread key in the metadata no posted-PR lookup is needed; the
+	// status fetch is queued via Poppit straight away.
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	s := refreshShortcut("pr_posted", map[string]interface{}{
+		"repository": "acme/api", "pr_number": float64(42), "thread_key": "k1",
+	})
+	payload, _ := json.Marshal(s)
+	handleBlockAction(context.Background(), rdb, &fakeSlack{}, string(payload), config)
+
+	cmd := popPoppitCommand(t, mr, config)
+	if cmd.Type != poppitPRViewType || cmd.Repo != "acme/api" || cmd.Metadata["thread_key"] != "k1" {
+		t.Errorf("expected a threaded PR view of acme/api, got %+v", cmd)
+	}
+}
+
+func TestBuildPRMessageRecordsThreadKey(t *testing.T) {
+	msg := buildPRMessage(&PRItem{Number: 3}, "acme/api", "erin", PostOptions{ThreadKey: "k1"}, config.Config{})
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if payload["thread_key"] != "k1" {
+		t.Errorf("expected thread_key in metadata, got %v", payload["thread_key"])
+	}
+}
+
+// ---- App Home favorites tests ----
+
+func TestFavoritePRBlocks(t *testing.T) {
+	hint := favoritePRBlocks(homeFavorites{}, time.Now())
+	if text := hint[1].(*slack.SectionBlock).Text.Text; !strings.Contains(text, "/pr fav add") {
+		t.Errorf("expected favorites hint without favorites, got %q", text)
+	}
+	loading := favoritePRBlocks(homeFavorites{Repos: []string{"acme/api"}, Loading: true}, time.Now())
+	if text := loading[1].(*slack.SectionBlock).Text.Text; !strings.Contains(text, "Loading") {
+		t.Errorf("expected loading note, got %q", text)
+	}
+
+	pr := PRItem{Number: 7, Title: "Fix <thing>", URL: "https://github.com/acme/api/pull/7"}
+	pr.Repository.NameWithOwner = "acme/api"
+	blocks := favoritePRBlocks(homeFavorites{Repos: []string{"acme/api"}, PRs: []PRItem{pr}}, time.Now())
+	if len(blocks) != 3 {
+		t.Fatalf("expected divider, heading and one PR, got %d blocks", len(blocks))
+	}
+	section := blocks[2].(*slack.SectionBlock)
+	if section.BlockID != homeBlockIDPrefix+"0" || !strings.Contains(section.Text.Text, "Fix &lt;thing&gt;") {
+		t.Errorf("unexpected PR section: %+v", section)
+	}
+	button := section.Accessory.ButtonElement
+	if button == nil || button.ActionID != homePostActionID || button.Value != "acme/api#7" {
+		t.Errorf("expected post button for acme/api#7, got %+v", button)
+	}
+}
+
+func TestPRSearchRepos(t *testing.T) {
+	s := prSearch{Repos: []string{"acme/api", "acme/web"}}
+	want := "gh search prs --state open --repo acme/api --repo acme/web --json " + prSearchJSONFields + " --limit 50"
+	if got := s.command([]string{"acme"}, config.DefaultPRLimit); got != want {
+		t.Errorf("unexpected command:\n got %q\nwant %q", got, want)
+	}
+	if got := s.apiQuery([]string{"acme"}); got != "is:pr is:open repo:acme/api repo:acme/web" {
+		t.Errorf("unexpected API query: %q", got)
+	}
+
+	data, _ := json.Marshal(s.metadata())
+	var m map[string]interface{}
+	_ = json.Unmarshal(data, &m)
+	if got := prSearchFromMetadata(m); len(got.Repos) != 2 || got.Repos[1] != "acme/web" {
+		t.Errorf("expected repos to round-trip, got %+v", got)
+	}
+}
+
+func TestHandleBlockActionRoutesHomePost(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	pr := PRItem{Number: 7, Title: "From home"}
+	pr.Repository.NameWithOwner = "acme/api"
+	if err := savePRSession(context.Background(), rdb, homeSessionID("U1"), PRModalPrivateMetadata{PRs: []PRItem{pr}}, config); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `{"type":"block_actions","user":{"id":"U1"},"actions":[{"action_id":"home_post","block_id":"home_pr_0","value":"acme/api#7"}]}`
+	handleBlockAction(context.Background(), rdb, &fakeSlack{}, payload, config)
+	if posts := slackLinerPosts(t, mr, config); len(posts) == 0 {
+		t.Error("expected the PR to be posted")
+	}
+}
+
+// ---- Link unfurl tests ----
+
+func TestInvocationMetadataRoundtripUnfurl(t *testing.T) {
+	inv := Invocation{UserID: "U1", Unfurl: LinkUnfurl{Channel: "C1", MessageTS: "1.2", URL: "https://github.com/acme/api/pull/7"}}
+	data, _ := json.Marshal(inv.metadata())
+	var m map[string]interface{}
+	_ = json.Unmarshal(data, &m)
+	if got := invocationFromMetadata(m); got != inv {
+		t.Errorf("expected %+v, got %+v", inv, got)
+	}
+}
+
+func TestHandleLinkSharedSkipsOtherLinks(t *testing.T) {
+	// Composer links, non-PR links, and PRs outside the configured orgs never
+	// reach the PR source, so the nil clients are not touched.
+	cfg := config.Config{GitHubOrg: "acme"}
+	for _, payload := range []string{
+		`{"type":"link_shared","channel":"C1","message_ts":"1.2","source":"composer","links":[{"url":"https://github.com/acme/api/pull/7"}]}`,
+		`{"type":"link_shared","channel":"C1","message_ts":"1.2","links":[{"url":"https://github.com/acme/api/issues/7"}]}`,
+		`{"type":"link_shared","channel":"C1","message_ts":"1.2","links":[{"url":"https://github.com/elsewhere/api/pull/7"}]}`,
+	} {
+		assertNoPanic(t, payload, func() {
+			handleLinkShared(context.Background(), nil, nil, payload, cfg)
+		})
+	}
+}
+
+func TestHandleLinkSharedFetchesPR(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	payload := `{"type":"link_shared","channel":"C1","message_ts":"1.2","links":[{"url":"https://github.com/acme/api/pull/7"}]}`
+	handleLinkShared(context.Background(), rdb, &fakeSlack{}, payload, config)
+
+	cmd := popPoppitCommand(t, mr, config)
+	if cmd.Type != poppitPRViewType || cmd.Repo != "acme/api" || cmd.Metadata["unfurl_ts"] != "1.2" {
+		t.Errorf("expected a PR view for the unfurl, got %+v", cmd)
+	}
+}
+
+func TestBuildPRUnfurl(t *testing.T) {
+	pr := &PRItem{Number: 7, Title: "Fix", URL: "https://github.com/acme/api/pull/7", ReviewDecision: "APPROVED"}
+	pr.Author.Login = "octocat"
+	att := buildPRUnfurl(pr, "acme/api", time.Now())
+	if len(att.Blocks.BlockSet) != 2 {
+		t.Fatalf("expected card and context blocks, got %d", len(att.Blocks.BlockSet))
+	}
+	text := att.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text
+	if !strings.Contains(text, "acme/api#7") || !strings.Contains(text, "*Review:*") {
+		t.Errorf("unexpected card text: %q", text)
+	}
+}
+
+// ---- Stale PR reminder tests ----
+
+func TestInQuietHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 5, h, m, 0, 0, time.UTC) }
+	cases := []struct {
+		window string
+		t      time.Time
+		want   bool
+	}{
+		{"", at(3, 0), false},
+		{"20:00-08:00", at(23, 0), true},
+		{"20:00-08:00", at(7, 59), true},
+		{"20:00-08:00", at(8, 0), false},
+		{"12:00-13:00", at(12, 30), true},
+		{"12:00-13:00", at(13, 30), false},
+	}
+	for _, c := range cases {
+		if got := inQuietHours(c.window, c.t); got != c.want {
+			t.Errorf("inQuietHours(%q, %s) = %v, want %v", c.window, c.t.Format("15:04"), got, c.want)
+		}
+	}
+}
+
+func TestReminderDue(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	rc := config.ReminderChannelConfig{ThresholdHours: 4, MaxReminders: 2}
+	rec := PostedPR{PostedAt: now.Add(-5 * time.Hour)}
+
+	if !reminderDue(rec, rc, now) {
+		t.Error("expected reminder after threshold")
+	}
+	if reminderDue(PostedPR{PostedAt: now.Add(-time.Hour)}, rc, now) {
+		t.Error("expected no reminder before threshold")
+	}
+	reminded := rec
+	reminded.Reminders, reminded.LastRemindedAt = 1, now.Add(-time.Hour)
+	if reminderDue(reminded, rc, now) {
+		t.Error("expected threshold to restart after a reminder")
+	}
+	reminded.Reminders = 2
+	reminded.LastRemindedAt = now.Add(-10 * time.Hour)
+	if reminderDue(reminded, rc, now) {
+		t.Error("expected no reminder past max_reminders")
+	}
+	if reminderDue(PostedPR{PostedAt: rec.PostedAt, RemindersDone: true}, rc, now) {
+		t.Error("expected no reminder once reviewed")
+	}
+	quiet := config.ReminderChannelConfig{ThresholdHours: 4, QuietHours: "11:00-13:00"}
+	if reminderDue(rec, quiet, now) {
+		t.Error("expected no reminder during quiet hours")
+	}
+}
+
+func TestBuildReminderMessage(t *testing.T) {
+	now := time.Now()
+	rec := PostedPR{Repo: "acme/api", Number: 7, URL: "https://github.com/acme/api/pull/7", Channel: "C1", ThreadKey: "k1", PostedAt: now.Add(-26 * time.Hour), Reminders: 1}
+	msg := buildReminderMessage(rec, now, config.Config{SlackMessageTTL: config.DefaultMessageTTL})
+	if msg.Channel != "C1" || msg.ThreadKey != "k1" {
+		t.Errorf("expected threaded reminder in the post's channel, got %+v", msg)
+	}
+	if !strings.Contains(msg.Text, "acme/api#7") || !strings.Contains(msg.Text, "1d") {
+		t.Errorf("unexpected reminder text: %q", msg.Text)
+	}
+}
+
+// ---- Digest tests ----
+
+func TestBuildDigestMessage(t *testing.T) {
+	now := time.Now()
+	mk := func(repo string, n int, age time.Duration) PRItem {
+		pr := PRItem{Number: n, Title: "PR", URL: fmt.Sprintf("https://github.com/%s/pull/%d", repo, n), CreatedAt: now.Add(-age)}
+		pr.Repository.NameWithOwner = repo
+		pr.Author.Login = "octocat"
+		return pr
+	}
+	prs := []PRItem{mk("acme/api", 2, time.Hour), mk("acme/api", 1, 72*time.Hour)}
+	msg := buildDigestMessage("C1", []string{"acme/api", "acme/web"}, prs, now, config.Config{SlackMessageTTL: config.DefaultMessageTTL})
+
+	if msg.Channel != "C1" {
+		t.Errorf("expected digest in C1, got %q", msg.Channel)
+	}
+	for _, want := range []string{"2 open across 2 repos", "`acme/api`: 2", "`acme/web`: 0", "*Oldest:* <https://github.com/acme/api/pull/1|acme/api#1>", "3d ago"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("expected %q in digest, got:\n%s", want, msg.Text)
+		}
+	}
+
+	empty := buildDigestMessage("C1", []string{"acme/api"}, nil, now, config.Config{SlackMessageTTL: config.DefaultMessageTTL})
+	if !strings.Contains(empty.Text, "Nothing waiting") {
+		t.Errorf("expected empty digest note, got %q", empty.Text)
+	}
+}
+
+// ---- leader election tests ----
+
+func TestLeaderElection(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	a, _ := newLeaderElection(rdb, config.Config{RedisConsumerName: "replica-1"})
+	b, _ := newLeaderElection(rdb, config.Config{RedisConsumerName: "replica-1"})
+
+	a.heartbeat(ctx)
+	b.heartbeat(ctx)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("expected only the first replica to lead, got %v and %v", a.IsLeader(), b.IsLeader())
+	}
+	a.heartbeat(ctx)
+	if !a.IsLeader() {
+		t.Error("expected the leader to renew its leadership")
+	}
+
+	a.resign(ctx)
+	b.heartbeat(ctx)
+	if a.IsLeader() || !b.IsLeader() {
+		t.Fatalf("expected the second replica to take over, got %v and %v", a.IsLeader(), b.IsLeader())
+	}
+
+	// b's key expired and a took it; b must notice at its next heartbeat.
+	mr.Set(leaderKey, a.id)
+	b.heartbeat(ctx)
+	if b.IsLeader() {
+		t.Error("expected a replica whose key was taken to step down")
+	}
+}
+
+func TestLeaderElectionRedisError(t *testing.T) {
+	l, _ := newLeaderElection(unreachableRedis(), config.Config{})
+	l.leader.Store(true)
+	l.heartbeat(context.Background())
+	if l.IsLeader() {
+		t.Error("expected to step down when Redis is unreachable")
+	}
+	if !(*leaderElection)(nil).IsLeader() {
+		t.Error("expected a nil election to lead")
+	}
+}
+
+// ---- unpost tests ----
+
+func TestCanUnpost(t *testing.T) {
+	rec := &PostedPR{PostedByID: "U1"}
+	if !canUnpost(rec, "U1", false) || !canUnpost(rec, "U2", true) {
+		t.Error("expected poster and privileged users to be allowed")
+	}
+	if canUnpost(rec, "U2", false) {
+		t.Error("expected other users to be refused")
+	}
+	if canUnpost(&PostedPR{}, "", false) {
+		t.Error("expected posts without a poster ID to need a privileged user")
+	}
+}
+
+func TestBuildUnpostMessage(t *testing.T) {
+	msg := buildUnpostMessage(&PostedPR{Channel: "C1", ThreadKey: "slashvibepr:thread:acme/api#1:abc"})
+	data, _ := json.Marshal(msg)
+	if !strings.Contains(string(data), `"action":"delete"`) || msg.Channel != "C1" || msg.Key != "slashvibepr:thread:acme/api#1:abc" {
+		t.Errorf("unexpected unpost message: %s", data)
+	}
+}
+
+func TestHandleSlashCommandUnpostWithoutArgsSendsUsage(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "unpost", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{GitHubOrg: "acme"})
+
+	if !strings.Contains(got.Text, "/pr unpost") {
+		t.Errorf("expected unpost usage, got %q", got.Text)
+	}
+}
+
+// ---- audit tests ----
+
+func TestAuditEntryFromMessage(t *testing.T) {
+	entry := auditEntryFromMessage(redis.XMessage{
+		ID: "1700000000000-0",
+		Values: map[string]interface{}{
+			"action": "share", "user_id": "U1", "user": "alice",
+			"repo": "acme/api", "number": "12", "channel": "C1", "detail": "",
+		},
+	})
+	if entry.Action != "share" || entry.Username != "alice" || entry.Number != 12 || entry.Channel != "C1" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if !entry.At.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("expected time from stream ID, got %v", entry.At)
+	}
+}
+
+func TestFormatAuditEntries(t *testing.T) {
+	if got := formatAuditEntries("acme/api", nil, time.UTC); !strings.Contains(got, "No recent actions") {
+		t.Errorf("unexpected empty text: %q", got)
+	}
+	at := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	got := formatAuditEntries("acme/api", []AuditEntry{
+		{Action: "request_reviewers", Username: "alice", Number: 12, Channel: "C1", Detail: "bob", At: at},
+	}, time.UTC)
+	for _, want := range []string{"2026-01-02 15:04 UTC", "@alice request_reviewers #12", "<#C1>", "(bob)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
+
+func TestHandleAuditCommandRequiresAdmin(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	handleAuditCommand(context.Background(), nil, nil, SlackCommand{UserID: "U2", ResponseURL: srv.URL}, []string{"api"}, config.Config{SlackAdminUserIDs: []string{"U1"}})
+	if !strings.Contains(got.Text, "Only admins") {
+		t.Errorf("expected admin refusal, got %q", got.Text)
+	}
+}
+
+// ---- usage stats tests ----
+
+func TestFormatDailyChart(t *testing.T) {
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	perDay := []DayCount{{Day: day.AddDate(0, 0, -2), Count: 9}, {Day: day.AddDate(0, 0, -1), Count: 1}, {Day: day, Count: 4}}
+	got := formatDailyChart(perDay, 2)
+	if strings.Contains(got, "Sat 01-03") {
+		t.Errorf("expected only the last 2 days, got %q", got)
+	}
+	lines := strings.Split(strings.Trim(got, "`"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "Mon 01-05 "+strings.Repeat("█", statsBarWidth)) || !strings.Contains(lines[0], "█ ") {
+		t.Errorf("unexpected chart: %q", got)
+	}
+}
+
+func TestCreateStatsModal(t *testing.T) {
+	report := PostingReport{
+		Days:     30,
+		Total:    5,
+		TopRepos: []RepoCount{{Repo: "acme/api", Count: 4}},
+		TopUsers: []UserCount{{User: "alice", Count: 5}},
+	}
+	modal := createStatsModal(report)
+	if len(modal.Blocks.BlockSet) != 4 {
+		t.Fatalf("expected 4 blocks for all repos, got %d", len(modal.Blocks.BlockSet))
+	}
+	if text := modal.Blocks.BlockSet[2].(*slack.SectionBlock).Text.Text; !strings.Contains(text, "1. `acme/api` — 4") {
+		t.Errorf("unexpected repo ranking: %q", text)
+	}
+
+	report.Repo = "acme/api"
+	modal = createStatsModal(report)
+	if len(modal.Blocks.BlockSet) != 3 {
+		t.Fatalf("expected 3 blocks for one repo, got %d", len(modal.Blocks.BlockSet))
+	}
+	if text := modal.Blocks.BlockSet[2].(*slack.SectionBlock).Text.Text; !strings.Contains(text, "@alice") {
+		t.Errorf("unexpected user ranking: %q", text)
+	}
+}
+
+func TestHandleSlashCommandStatsTooManyArgsSendsUsage(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "stats api web", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), nil, nil, string(payload), config.Config{})
+	if !strings.Contains(got.Text, "/pr stats") {
+		t.Errorf("expected stats usage, got %q", got.Text)
+	}
+}
+
+// ---- role tests ----
+
+func TestParseSlackUserRef(t *testing.T) {
+	for ref, want := range map[string]string{"<@U123|alice>": "U123", "<@W9>": "W9", "U42": "U42"} {
+		if got, ok := parseSlackUserRef(ref); !ok || got != want {
+			t.Errorf("parseSlackUserRef(%q) = %q, %v", ref, got, ok)
+		}
+	}
+	for _, ref := range []string{"alice", "<#C1>", "@U1", ""} {
+		if _, ok := parseSlackUserRef(ref); ok {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
+
+func TestIsPrivilegedFromConfig(t *testing.T) {
+	config := config.Config{SlackAdminUserIDs: []string{"UADMIN"}, PrivilegedUserIDs: []string{"U1"}}
+	// Admins and configured users are recognised without touching Redis.
+	if !isPrivileged(context.Background(), nil, nil, "UADMIN", config) || !isPrivileged(context.Background(), nil, nil, "U1", config) {
+		t.Error("expected admin and configured user to be privileged")
+	}
+	if isPrivileged(context.Background(), unreachableRedis(), nil, "U2", config) {
+		t.Error("expected unknown user to be denied when Redis is unreachable")
+	}
+}
+
+func TestHandleSlashCommandRefusesRestrictedCommand(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "unpost api 1", UserID: "U2", ResponseURL: srv.URL})
+	handleSlashCommand(context.Background(), unreachableRedis(), nil, string(payload), config.Config{RestrictedCommands: []string{"unpost"}})
+	if !strings.Contains(got.Text, "restricted") {
+		t.Errorf("expected restriction notice, got %q", got.Text)
+	}
+}
+
+// ---- admin command tests ----
+
+// adminReply runs /pr admin with fields as an admin and returns the reply.
+func adminReply(t *testing.T, rdb *redis.Client, fields ...string) string {
+	t.Helper()
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	config := testConfig(t)
+	config.SlackAdminUserIDs = []string{"UADMIN"}
+	handleAdminCommand(context.Background(), rdb, SlackCommand{UserID: "UADMIN", UserName: "admin", ResponseURL: srv.URL}, fields, config)
+	return got.Text
+}
+
+func TestHandleAdminCommandUsage(t *testing.T) {
+	for _, fields := range [][]string{nil, {"bogus"}, {"sessions", "everything"}, {"set", "github.org"}} {
+		if got := adminReply(t, nil, fields...); got != adminUsage() {
+			t.Errorf("/pr admin %v: expected usage, got %q", fields, got)
+		}
+	}
+	for _, c := range adminCommands() {
+		if c.Name != "replay" && !strings.Contains(adminUsage(), "`/pr admin "+c.Name) {
+			t.Errorf("usage is missing /pr admin %s", c.Name)
+		}
+	}
+}
+
+func TestHandleAdminCommandSessions(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	mr.Set(prSessionKey("V1"), "x")
+	mr.Set(previewKey("abc"), "x")
+	mr.Set(favoritesKey("U1"), "x")
+
+	if got := adminReply(t, rdb, "sessions"); !strings.Contains(got, "There are 2 open") {
+		t.Errorf("unexpected count reply: %q", got)
+	}
+	if got := adminReply(t, rdb, "sessions", "purge"); !strings.Contains(got, "Purged 2 sessions") {
+		t.Errorf("unexpected purge reply: %q", got)
+	}
+	if mr.Exists(prSessionKey("V1")) || mr.Exists(previewKey("abc")) || !mr.Exists(favoritesKey("U1")) {
+		t.Error("expected only the session keys to be purged")
+	}
+}
+
+func TestHandleAdminCommandDLQAlias(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	list := adminReply(t, rdb, "dlq", "list")
+	if got := adminReply(t, rdb, "dlq"); got != list {
+		t.Errorf("expected `dlq` to list like `dlq list`, got %q and %q", got, list)
+	}
+	if got, want := adminReply(t, rdb, "replay", "1-0"), adminReply(t, rdb, "dlq", "replay", "1-0"); got != want || !strings.HasPrefix(got, ":x:") {
+		t.Errorf("expected `replay` to behave like `dlq replay`, got %q and %q", got, want)
+	}
+}
+
+func TestAdminReloadRereadsConfigFile(t *testing.T) {
+	t.Cleanup(func() {
+		reloadedConfig.generation, reloadedConfig.config = 0, nil
+	})
+	_, rdb := newTestRedis(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("CONFIG_FILE", path)
+	config := testConfig(t)
+
+	if err := os.WriteFile(path, []byte("limits:\n  pr_list: 0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := adminReply(t, rdb, "reload"); !strings.Contains(got, "Config not reloaded") {
+		t.Errorf("expected an invalid file to be refused, got %q", got)
+	}
+	if got := withConfigOverrides(context.Background(), rdb, config); got.GitHubOrg != "acme" {
+		t.Errorf("expected no reload after a refused one, got org %q", got.GitHubOrg)
+	}
+
+	if err := os.WriteFile(path, []byte("github:\n  org: widgets\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := adminReply(t, rdb, "reload"); !strings.Contains(got, "Reloading") {
+		t.Errorf("unexpected reload reply: %q", got)
+	}
+	got := withConfigOverrides(context.Background(), rdb, config)
+	if got.GitHubOrg != "widgets" || got.SlackChannelID != "" {
+		t.Errorf("expected config.yaml to be reloaded, got org %q channel %q", got.GitHubOrg, got.SlackChannelID)
+	}
+	if got.RedisPoppitList != config.RedisPoppitList {
+		t.Errorf("expected startup settings to be kept")
+	}
+}
+
+// assertTeamScopeReachesFlags sends "/pr myrepo --multi" from team T1,
+// where multi_select is switched off, through send, and checks that the
+// team's override applied.
+func assertTeamScopeReachesFlags(t *testing.T, send func(rdb *redis.Client, cfg config.Config, responseURL string)) {
+	t.Helper()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.Features = map[string]config.FeatureFlag{config.FeatureMultiSelect: {Enabled: true}}
+	rdb.HSet(context.Background(), featureKey(featureMultiSelect), "team:T1", "off")
+	replies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		replies <- msg.Text
+	}))
+	defer srv.Close()
+
+	send(rdb, cfg, srv.URL)
+	select {
+	case text := <-replies:
+		if !strings.Contains(text, "not available here") {
+			t.Errorf("expected --multi to be refused for team T1, got %q", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected --multi to be refused for team T1")
+	}
+}
+
+// ---- direct HTTP mode tests ----
+
+// signedSlackRequest builds a request signed the way Slack signs them.
+func signedSlackRequest(path, body, secret string) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestSlackHTTPServerRejectsBadSignature(t *testing.T) {
+	s := &slackHTTPServer{ctx: context.Background(), config: config.Config{SlackSigningSecret: "secret"}}
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, signedSlackRequest("/slack/commands", "command=%2Fpr", "wrong"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestSlackHTTPServerURLVerification(t *testing.T) {
+	s := &slackHTTPServer{ctx: context.Background(), config: config.Config{SlackSigningSecret: "secret"}}
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, signedSlackRequest("/slack/events", `{"type":"url_verification","challenge":"abc"}`, "secret"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"challenge":"abc"`) {
+		t.Errorf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSlackHTTPServerAnswersUnknownSuggestionWithEmptyOptions(t *testing.T) {
+	s := &slackHTTPServer{ctx: context.Background(), config: config.Config{SlackSigningSecret: "secret"}}
+	body := "payload=" + url.QueryEscape(`{"type":"block_suggestion","action_id":"unknown"}`)
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, signedSlackRequest("/slack/interactivity", body, "secret"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"options":[]`) {
+		t.Errorf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSlackHTTPServerCommandCarriesTeam(t *testing.T) {
+	assertTeamScopeReachesFlags(t, func(rdb *redis.Client, cfg config.Config, responseURL string) {
+		cfg.SlackSigningSecret = "secret"
+		s := &slackHTTPServer{ctx: context.Background(), rdb: rdb, slackClient: &fakeSlack{}, config: cfg}
+		body := url.Values{"command": {"/pr"}, "text": {"myrepo --multi"}, "team_id": {"T1"}, "user_id": {"U1"}, "response_url": {responseURL}}.Encode()
+		s.routes().ServeHTTP(httptest.NewRecorder(), signedSlackRequest("/slack/commands", body, "secret"))
+	})
+}
+
+// ---- Socket Mode tests ----
+
+func TestHandleSocketModeEventAcksSlashCommand(t *testing.T) {
+	client := socketmode.New(slack.New("xoxb-test"))
+	evt := socketmode.Event{
+		Type:    socketmode.EventTypeSlashCommand,
+		Data:    slack.SlashCommand{Command: "/other"},
+		Request: &socketmode.Request{EnvelopeID: "env-1"},
+	}
+	assertNoPanic(t, "slash command envelope", func() {
+		handleSocketModeEvent(context.Background(), nil, nil, client, evt, config.Config{})
+	})
+}
+
+func TestHandleSocketModeEventCommandCarriesTeam(t *testing.T) {
+	assertTeamScopeReachesFlags(t, func(rdb *redis.Client, cfg config.Config, responseURL string) {
+		evt := socketmode.Event{
+			Type:    socketmode.EventTypeSlashCommand,
+			Data:    slack.SlashCommand{Command: "/pr", Text: "myrepo --multi", TeamID: "T1", UserID: "U1", ResponseURL: responseURL},
+			Request: &socketmode.Request{EnvelopeID: "env-1"},
+		}
+		handleSocketModeEvent(context.Background(), rdb, &fakeSlack{}, socketmode.New(slack.New("xoxb-test")), evt, cfg)
+	})
+}
+
+// ---- stream consumer tests ----
+
+func TestConsumeStreamRetriesUntilCancelled(t *testing.T) {
+	config := config.Config{RedisConsumer: config.ConsumerStreams, RedisConsumerGroup: "g", RedisConsumerName: "c"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		consume(ctx, unreachableRedis(), "s", func(string) { t.Error("unexpected payload") }, config)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected consume to stop retrying once ctx is done")
+	}
+	if ctx.Err() == nil {
+		t.Error("expected consume to keep retrying while Redis is unreachable")
+	}
+}
+
+// ---- dead-letter queue tests ----
+
+func TestHandleRecoveringContainsPanics(t *testing.T) {
+	assertNoPanic(t, "panicking handler", func() {
+		handleRecovering(context.Background(), unreachableRedis(), "slack-commands", "{}", func(string) {
+			panic("boom")
+		})
+	})
+}
+
+func TestFormatDLQEntries(t *testing.T) {
+	if got := formatDLQEntries(nil); !strings.Contains(got, "empty") {
+		t.Errorf("unexpected empty text: %q", got)
+	}
+	got := formatDLQEntries([]dlqEntry{{ID: "1-0", Source: "slack-commands", Payload: strings.Repeat("x", 200), Error: "bad json"}})
+	if !strings.Contains(got, "`1-0` from `slack-commands`: bad json") || strings.Contains(got, strings.Repeat("x", dlqPreviewLength+1)) {
+		t.Errorf("unexpected DLQ text: %q", got)
+	}
+}
+
+func TestHandleSlashCommandRejectsMalformedPayload(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	ctx := context.Background()
+
+	handleSlashCommand(ctx, rdb, &fakeSlack{}, `{"command":"/pr","user_id":42}`, config)
+	entries, err := listDLQ(ctx, rdb, 10)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one dead-lettered payload, got %v: %v", entries, err)
+	}
+	if !strings.Contains(entries[0].Error, "$.user_id: expected string") {
+		t.Errorf("expected the schema violation as the reason, got %q", entries[0].Error)
+	}
+
+	// An enveloped command is unwrapped and handled, and with lists.envelope
+	// set the Poppit command it queues is enveloped too.
+	config.RedisEnvelope = true
+	payload, _ := envelope.Wrap("slack.slash_command", SlackCommand{Command: "/pr", Text: "api", TriggerID: "tid", UserID: "U1"})
+	handleSlashCommand(ctx, rdb, &fakeSlack{}, string(payload), config)
+	data, err := mr.Lpop(config.RedisPoppitList)
+	if err != nil {
+		t.Fatalf("expected a queued Poppit command: %v", err)
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal([]byte(data), &env); err != nil || env.Version != envelope.Version || env.Kind != envelope.KindPoppitCommand {
+		t.Errorf("expected an enveloped Poppit command, got %s", data)
+	}
+}
+
+func TestReplayableSources(t *testing.T) {
+	config := config.Config{RedisChannel: "cmds", RedisAppHomeChannel: "home", RedisConsumer: config.ConsumerStreams}
+	sources := replayableSources(config)
+	if stream, ok := sources["cmds"]; !ok || !stream {
+		t.Error("expected slash commands to replay to a stream in streams mode")
+	}
+	if stream, ok := sources["home"]; !ok || stream {
+		t.Error("expected App Home events to replay over pub/sub")
+	}
+	if _, ok := sources["other"]; ok {
+		t.Error("expected unknown sources not to be replayable")
+	}
+}
+
+// ---- Slack retry tests ----
+
+func TestSlackRetryDelay(t *testing.T) {
+	if delay, ok := slackRetryDelay(&slack.RateLimitedError{RetryAfter: 3 * time.Second}, time.Second); !ok || delay != 3*time.Second {
+		t.Errorf("expected rate limits to wait Retry-After, got %s, %v", delay, ok)
+	}
+	if delay, ok := slackRetryDelay(slack.StatusCodeError{Code: http.StatusServiceUnavailable}, time.Second); !ok || delay != time.Second {
+		t.Errorf("expected 503s to back off, got %s, %v", delay, ok)
+	}
+	if _, ok := slackRetryDelay(errors.New("internal_error"), time.Second); !ok {
+		t.Error("expected internal_error to be retried")
+	}
+	if _, ok := slackRetryDelay(errors.New("expired_trigger_id"), time.Second); ok {
+		t.Error("expected expired_trigger_id not to be retried")
+	}
+}
+
+func TestWithSlackRetry(t *testing.T) {
+	config := config.Config{SlackMaxAttempts: 2}
+	calls := 0
+	err := withSlackRetry(context.Background(), "views.open", config, func() error {
+		calls++
+		return &slack.RateLimitedError{RetryAfter: time.Millisecond}
+	})
+	if err == nil || calls != 2 {
+		t.Errorf("expected 2 attempts ending in an error, got %d, %v", calls, err)
+	}
+
+	calls = 0
+	err = withSlackRetry(context.Background(), "views.open", config, func() error {
+		calls++
+		return errors.New("invalid_arguments")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected a permanent error not to be retried, got %d calls", calls)
+	}
+}
+
+// ---- Poppit dedup tests ----
+
+func TestClaimPoppitOutput(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	metadata := map[string]interface{}{poppitCommandIDField: "c1"}
+
+	release, ok := claimPoppitOutput(ctx, rdb, metadata)
+	if !ok {
+		t.Fatal("expected the first delivery to be handled")
+	}
+	if _, ok := claimPoppitOutput(ctx, rdb, metadata); ok {
+		t.Error("expected a duplicate delivery to be skipped")
+	}
+	release()
+	if _, ok := claimPoppitOutput(ctx, rdb, metadata); !ok {
+		t.Error("expected a released output to be handled again")
+	}
+	if _, ok := claimPoppitOutput(ctx, nil, nil); !ok {
+		t.Error("expected output without a command ID to be handled")
+	}
+}
+
+func TestReassemblePoppitOutput(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	code := 0
+	chunk := func(seq int, out string) poppit.Output {
+		o := poppit.Output{Type: poppitPRListType, Output: out, Metadata: map[string]interface{}{
+			poppitCommandIDField: "c1", "view_id": "V1", poppitChunkSeqField: float64(seq), poppitChunkTotalField: float64(3),
+		}}
+		if seq == 3 {
+			o.ExitCode = &code
+		}
+		return o
+	}
+
+	// Chunks may arrive out of order.
+	for _, seq := range []int{2, 3} {
+		if _, complete, err := reassemblePoppitOutput(ctx, rdb, chunk(seq, map[int]string{2: `{"b":2},`, 3: `{"c":3}]`}[seq])); err != nil || complete {
+			t.Fatalf("chunk %d: expected it to be buffered, got complete=%v, %v", seq, complete, err)
+		}
+	}
+	if ttl := mr.TTL(poppitChunksKey("c1")); ttl != poppitChunkTTL {
+		t.Errorf("expected the buffer to expire after %s, got %s", poppitChunkTTL, ttl)
+	}
+	whole, complete, err := reassemblePoppitOutput(ctx, rdb, chunk(1, `[{"a":1},`))
+	if err != nil || !complete {
+		t.Fatalf("expected the output to be complete, got %v, %v", complete, err)
+	}
+	if whole.Output != `[{"a":1},{"b":2},{"c":3}]` || whole.ExitCode == nil || whole.Metadata["view_id"] != "V1" {
+		t.Errorf("unexpected reassembled output: %+v", whole)
+	}
+	if _, ok := whole.Metadata[poppitChunkSeqField]; ok {
+		t.Error("expected the chunk fields to be dropped from the metadata")
+	}
+	if mr.Exists(poppitChunksKey("c1")) {
+		t.Error("expected the chunk buffer to be deleted")
+	}
+
+	plain := poppit.Output{Output: "[]"}
+	if got, complete, err := reassemblePoppitOutput(ctx, nil, plain); err != nil || !complete || got.Output != "[]" {
+		t.Errorf("expected an unchunked output to pass through, got %+v, %v, %v", got, complete, err)
+	}
+	bad := chunk(4, "x")
+	if _, _, err := reassemblePoppitOutput(ctx, rdb, bad); err == nil {
+		t.Error("expected a chunk past the total to be rejected")
+	}
+	delete(bad.Metadata, poppitCommandIDField)
+	bad.Metadata[poppitChunkSeqField] = float64(1)
+	if _, _, err := reassemblePoppitOutput(ctx, rdb, bad); err == nil {
+		t.Error("expected a chunk without a command ID to be rejected")
+	}
+}
+
+func TestOwnsPoppitOutput(t *testing.T) {
+	cfg := config.Config{RedisConsumer: config.ConsumerPubSub, RedisConsumerName: "replica-1"}
+	if !ownsPoppitOutput(map[string]interface{}{poppitInstanceField: "replica-1"}, cfg) {
+		t.Error("expected this replica to handle its own output")
+	}
+	if ownsPoppitOutput(map[string]interface{}{poppitInstanceField: "replica-2"}, cfg) {
+		t.Error("expected output for another replica to be skipped")
+	}
+	if !ownsPoppitOutput(nil, cfg) {
+		t.Error("expected output without an instance ID to be handled")
+	}
+	cfg.RedisConsumer = config.ConsumerStreams
+	if !ownsPoppitOutput(map[string]interface{}{poppitInstanceField: "replica-2"}, cfg) {
+		t.Error("expected streams mode to handle output delivered to this replica")
+	}
+}
+
+// ---- view lock tests ----
+
+func TestLockViewSerializes(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	unlock, err := lockView(ctx, rdb, "V1")
+	if err != nil {
+		t.Fatalf("unexpected lock error: %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := lockView(ctx, rdb, "V1")
+		if err != nil {
+			t.Errorf("unexpected second lock error: %v", err)
+		} else {
+			second()
+		}
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected the second update to wait for the lock")
+	case <-time.After(3 * viewLockPoll):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected the second update to take the released lock")
+	}
+}
+
+func TestLockViewUnlockKeepsOthersLock(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	unlock, err := lockView(context.Background(), rdb, "V1")
+	if err != nil {
+		t.Fatalf("unexpected lock error: %v", err)
+	}
+	// The lock expired and another replica took it.
+	mr.Set(viewLockKey("V1"), "other")
+	unlock()
+	if got, _ := mr.Get(viewLockKey("V1")); got != "other" {
+		t.Errorf("expected another replica's lock to survive, got %q", got)
+	}
+}
+
+func TestLockViewRedisError(t *testing.T) {
+	unlock, err := lockView(context.Background(), unreachableRedis(), "V1")
+	if err != nil || unlock == nil {
+		t.Fatalf("expected to proceed without the lock, got %v", err)
+	}
+	unlock()
+}
+
+func TestLockViewCanceled(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	if _, err := lockView(context.Background(), rdb, "V1"); err != nil {
+		t.Fatalf("unexpected lock error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*viewLockPoll)
+	defer cancel()
+	if _, err := lockView(ctx, rdb, "V1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+}
+
+// ---- dry-run simulation tests ----
+
+func TestSimulatedSlack(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	config.Simulate = true
+	sim := &simulatedSlack{}
+	ctx := context.Background()
+	var out bytes.Buffer
+	dryRunOutput = &out
+	t.Cleanup(func() { dryRunOutput = os.Stdout })
+
+	cmdPayload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "api", TriggerID: "tid", UserID: "U1", UserName: "alice"})
+	handleSlashCommand(ctx, rdb, sim, string(cmdPayload), config)
+	if mr.Exists(config.RedisPoppitList) {
+		t.Error("expected nothing to be pushed to Poppit")
+	}
+
+	if err := pushSlackLiner(ctx, rdb, config, []byte(`{"channel":"C123"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mr.Exists(config.RedisSlackLinerList) {
+		t.Error("expected nothing to be pushed to SlackLiner")
+	}
+	if _, err := sim.PostEphemeralContext(ctx, "C1", "U1", slack.MsgOptionText("hi", false)); err != nil {
+		t.Errorf("unexpected ephemeral error: %v", err)
+	}
+	for _, want := range []string{"--- views.open\n", `"trigger_id": "tid"`, "--- Poppit", `"view_id": "V-DRYRUN-1"`, "--- SlackLiner", "--- chat.postEphemeral\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the dry-run output, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// ---- loading watchdog tests ----
+
+func TestCreateLoadingTimeoutModal(t *testing.T) {
+	req := loadingRequest{Search: &prSearch{Author: "octocat"}, Inv: Invocation{UserID: "U1"}}
+	modal := createLoadingTimeoutModal(req)
+
+	var got loadingRequest
+	if err := json.Unmarshal([]byte(modal.PrivateMetadata), &got); err != nil {
+		t.Fatalf("unexpected metadata error: %v", err)
+	}
+	if got.Search == nil || got.Search.Author != "octocat" || got.Repo != "" {
+		t.Errorf("unexpected retry request: %+v", got)
+	}
+	blocks := modal.Blocks.BlockSet
+	actions, ok := blocks[len(blocks)-1].(*slack.ActionBlock)
+	if !ok || actions.BlockID != loadingRetryBlockID {
+		t.Fatalf("expected a retry action block, got %#v", blocks[len(blocks)-1])
+	}
+	if button, ok := actions.Elements.ElementSet[0].(*slack.ButtonBlockElement); !ok || button.ActionID != loadingRetryActionID {
+		t.Errorf("expected a retry button, got %#v", actions.Elements.ElementSet[0])
+	}
+}
+
+func TestArmLoadingWatchdogDisabled(t *testing.T) {
+	// With no timeout the watchdog must not touch Redis at all.
+	assertNoPanic(t, "disabled watchdog", func() {
+		armLoadingWatchdog(context.Background(), nil, nil, "V1", loadingRequest{Repo: "org/repo"}, config.Config{})
+	})
+}
+
+func TestFireLoadingWatchdogRedisError(t *testing.T) {
+	assertNoPanic(t, "unreachable Redis", func() {
+		fireLoadingWatchdog(context.Background(), unreachableRedis(), nil, "V1", loadingRequest{Repo: "org/repo"}, config.Config{})
+	})
+}
+
+func TestHandleLoadingRetryInvalidMetadata(t *testing.T) {
+	action := BlockActionPayload{}
+	action.View.PrivateMetadata = "{}"
+	assertNoPanic(t, "retry without a request", func() {
+		handleLoadingRetry(context.Background(), nil, nil, action, config.Config{})
+	})
+}
+
+// ---- request ID tests ----
+
+func TestStampSlackLinerRequestID(t *testing.T) {
+	ctx := logging.WithRequestID(context.Background(), "abc123")
+	msg := buildPRStatusMessage(&PRItem{Number: 1, Title: "t", URL: "https://github.com/org/repo/pull/1"}, "org/repo", "alice", config.Config{})
+	stampSlackLinerMetadata(ctx, &msg)
+	if got := msg.Metadata["event_payload"].(map[string]interface{})[logging.RequestIDField]; got != "abc123" {
+		t.Errorf("expected the request ID in the event payload, got %v", got)
+	}
+
+	del := SlackLinerMessage{Action: "delete"}
+	stampSlackLinerMetadata(ctx, &del)
+	if del.Metadata != nil {
+		t.Error("expected messages without metadata to be left alone")
+	}
+}
+
+// ---- tracing tests ----
+
+func TestTraceContextRoundTripsThroughMetadata(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx, span := startSpan(context.Background(), "slash_command", trace.SpanKindServer)
+	metadata := map[string]interface{}{}
+	injectTraceContext(ctx, metadata)
+	endSpan(span, errors.New("boom"))
+
+	// Round-trip through JSON as Poppit would.
+	data, _ := json.Marshal(metadata)
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, child := startSpan(traceContextFromMetadata(context.Background(), decoded), "poppit_output", trace.SpanKindConsumer)
+	child.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("expected the failed span to have an error status, got %v", spans[0].Status())
+	}
+	if spans[1].Parent().SpanID() != spans[0].SpanContext().SpanID() {
+		t.Error("expected the output span to continue the command's trace")
+	}
+}
+
+func TestInjectTraceContextWithoutTracing(t *testing.T) {
+	metadata := map[string]interface{}{}
+	injectTraceContext(context.Background(), metadata)
+	if len(metadata) != 0 {
+		t.Errorf("expected nothing to be propagated, got %v", metadata)
+	}
+}
+
+func TestSetupTracingDisabled(t *testing.T) {
+	shutdown, err := SetupTracing(context.Background(), config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+}
+
+// ---- health check tests ----
+
+func TestFeedTrackerWaiting(t *testing.T) {
+	tracker := &feedTracker{feeds: map[string]bool{}}
+	tracker.set("b", false)
+	tracker.set("a", false)
+	tracker.set("c", true)
+	if got := tracker.waiting(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("unexpected waiting feeds: %v", got)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	h := &healthServer{feeds: &feedTracker{feeds: map[string]bool{}}}
+	rec := httptest.NewRecorder()
+	h.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ok"`) {
+		t.Errorf("unexpected healthz response: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReadyzReportsFailedChecks(t *testing.T) {
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"user_id":"UBOT"}`)
+	}))
+	defer slackAPI.Close()
+
+	tracker := &feedTracker{feeds: map[string]bool{}}
+	tracker.set("slack-commands", false)
+	h := &healthServer{
+		rdb:         unreachableRedis(),
+		slackClient: slack.New("xoxb-test", slack.OptionAPIURL(slackAPI.URL+"/")),
+		feeds:       tracker,
+	}
+	rec := httptest.NewRecorder()
+	h.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unexpected body %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || body.Status != "unavailable" {
+		t.Errorf("expected 503 unavailable, got %d %q", rec.Code, body.Status)
+	}
+	if body.Checks["redis"] == "ok" || body.Checks["slack"] != "ok" || !strings.Contains(body.Checks["subscriptions"], "slack-commands") {
+		t.Errorf("unexpected checks: %v", body.Checks)
+	}
+}
+
+// ---- pprof tests ----
+
+func TestDebugRoutesServeProfiles(t *testing.T) {
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/heap"} {
+		rec := httptest.NewRecorder()
+		debugRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, rec.Code)
+		}
+	}
+}
+
+// ---- Redis reconnect tests ----
+
+func TestSubscribeRetriesUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		subscribe(ctx, unreachableRedis(), "reconnect-test", func(string) { t.Error("unexpected payload") })
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected subscribe to stop retrying once ctx is done")
+	}
+	if ctx.Err() == nil {
+		t.Error("expected subscribe to keep retrying while Redis is unreachable")
+	}
+	if got := feeds.gauge.Get("reconnect-test"); got == nil || got.String() != "0" {
+		t.Errorf("expected the subscription gauge to read 0, got %v", got)
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	if !sleepContext(context.Background(), time.Millisecond) {
+		t.Error("expected the sleep to complete")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if sleepContext(ctx, time.Hour) {
+		t.Error("expected a cancelled sleep to report false")
+	}
+}
+
+// ---- Session sealing tests ----
+
+func withTestSessionKey(t *testing.T) {
+	t.Helper()
+	if err := session.SetKey([]byte(strings.Repeat("k", 32))); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.SetKey(nil) })
+}
+
+func TestRepoChooserMetadataSealed(t *testing.T) {
+	withTestSessionKey(t)
+	config := config.Config{GitHubOrg: "acme", GitHubOrgs: []string{"acme", "widgets"}}
+	modal := slackui.RepoChooserModal(config.Orgs(), "widgets", "")
+	if strings.Contains(modal.PrivateMetadata, "widgets") {
+		t.Errorf("expected sealed private_metadata, got %q", modal.PrivateMetadata)
+	}
+	if got := chooserRepoPath(modal.PrivateMetadata, "api", config); got != "widgets/api" {
+		t.Errorf("expected widgets/api, got %s", got)
+	}
+}
+
+// ---- Configurable limits tests ----
+
+func TestPRMessagesUseConfiguredTTL(t *testing.T) {
+	pr := &PRItem{Number: 1, Title: "T", URL: "https://github.com/org/repo/pull/1"}
+	config := config.Config{SlackChannelID: "C1", SlackMessageTTL: 2 * time.Hour, SlackThreadDetails: true}
+	for _, msg := range buildPRMessages(pr, "org/repo", "alice", PostOptions{ThreadKey: "k"}, config) {
+		if msg.TTL != 7200 {
+			t.Errorf("expected a 7200s TTL, got %d", msg.TTL)
+		}
+	}
+}
+
+func TestGitHubClientListPullRequestsPaginates(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.Query().Get("page"))
+		if r.URL.Query().Get("per_page") != "100" {
+			t.Errorf("expected full pages, got per_page=%s", r.URL.Query().Get("per_page"))
+		}
+		prs := make([]string, 100)
+		for i := range prs {
+			prs[i] = fmt.Sprintf(`{"number":%d}`, i+1)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(prs, ","))
+	}))
+	defer srv.Close()
+
+	prs, err := newGitHubClient(srv.URL, "").listPullRequests(context.Background(), "org/repo", PRListOptions{}, 150)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prs) != 150 || strings.Join(pages, ",") != "1,2" {
+		t.Errorf("expected 150 PRs from pages 1 and 2, got %d from %v", len(prs), pages)
+	}
+}
+
+// ---- Validate tests ----
+
+func TestMissingSettings(t *testing.T) {
+	if got := MissingSettings(config.Config{SlackBotToken: "xoxb", SlackChannelID: "C1"}); len(got) != 0 {
+		t.Errorf("expected nothing missing, got %v", got)
+	}
+	got := MissingSettings(config.Config{SlackTransport: config.TransportSocketMode, HTTPAddr: ":8080"})
+	if len(got) != 4 || !strings.Contains(got[0], "SLACK_BOT_TOKEN") || !strings.Contains(got[3], "SLACK_SIGNING_SECRET") {
+		t.Errorf("unexpected missing settings: %v", got)
+	}
+	if got := MissingSettings(config.Config{SlackChannelID: "C1", Simulate: true}); len(got) != 0 {
+		t.Errorf("expected --dry-run not to need a bot token, got %v", got)
+	}
+}
+
+func TestRunValidationReport(t *testing.T) {
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth.test":
+			fmt.Fprint(w, `{"ok":true,"user":"slashvibepr","team":"Acme"}`)
+		case "/conversations.info":
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+		default:
+			t.Errorf("unexpected Slack call %s", r.URL.Path)
+		}
+	}))
+	defer slackAPI.Close()
+
+	config := config.Config{SlackBotToken: "xoxb-test", SlackChannelID: "C404", RedisAddr: "127.0.0.1:1"}
+	var out strings.Builder
+	ok := RunValidation(context.Background(), unreachableRedis(), slack.New("xoxb-test", slack.OptionAPIURL(slackAPI.URL+"/")), config, &out)
+	if ok {
+		t.Error("expected validation to fail")
+	}
+	report := out.String()
+	for _, want := range []string{"ok    required settings", "FAIL  redis", "ok    slack auth: slashvibepr in Acme", "FAIL  slack channel C404: channel_not_found"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in report:\n%s", want, report)
+		}
+	}
+}
+
+// ---- Version tests ----
+
+func TestBuildInfoReported(t *testing.T) {
+	want := version.Get().Version
+
+	rec := httptest.NewRecorder()
+	(&healthServer{feeds: &feedTracker{feeds: map[string]bool{}}}).routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if !strings.Contains(rec.Body.String(), `"version":"`+want+`"`) {
+		t.Errorf("expected the version in /healthz, got %s", rec.Body.String())
+	}
+
+	msg := SlackLinerMessage{Metadata: map[string]interface{}{"event_payload": map[string]interface{}{}}}
+	stampSlackLinerMetadata(context.Background(), &msg)
+	payload := msg.Metadata["event_payload"].(map[string]interface{})
+	if b, ok := payload[buildField].(version.Info); !ok || b.Version != want {
+		t.Errorf("expected the build in the message metadata, got %v", payload[buildField])
+	}
+}
+
+// ---- Config override tests ----
+
+func TestConfigOverridesApply(t *testing.T) {
+	want := config.Config{GitHubOrg: "widgets", GitHubBaseBranch: "release/*", SlackChannelID: "C0NEW", SlackMessageTTL: time.Hour,
+		PRListLimit: 200, SessionTTL: 2 * time.Hour, DryRun: true}
+	config := config.Config{GitHubOrg: "acme", PRListLimit: 50}
+	for key, value := range map[string]string{
+		"github.org":         "widgets",
+		"github.base_branch": "release/*",
+		"slack.channel_id":   "C0NEW",
+		"slack.message_ttl":  "1h",
+		"limits.pr_list":     "200",
+		"limits.session_ttl": "2h",
+		"dry_run":            "true",
+	} {
+		if err := configOverrides[key](&config, value); err != nil {
+			t.Errorf("unexpected error applying %s=%s: %v", key, value, err)
+		}
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v", config, want)
+	}
+
+	for key, value := range map[string]string{
+		"github.org":         "not/an/org",
+		"github.base_branch": "bad branch",
+		"slack.channel_id":   "general",
+		"slack.message_ttl":  "10ms",
+		"limits.pr_list":     "5000",
+		"limits.session_ttl": "-1m",
+		"dry_run":            "maybe",
+	} {
+		if err := configOverrides[key](&config, value); err == nil {
+			t.Errorf("expected %s=%s to be rejected", key, value)
+		}
+	}
+}
+
+func TestConfigOverrideOrgMustBeListed(t *testing.T) {
+	config := config.Config{GitHubOrg: "acme", GitHubOrgs: []string{"acme", "widgets"}}
+	if err := configOverrides["github.org"](&config, "other"); err == nil {
+		t.Error("expected an org outside github.orgs to be rejected")
+	}
+	if err := configOverrides["github.org"](&config, "widgets"); err != nil || config.GitHubOrg != "widgets" {
+		t.Errorf("expected widgets to be accepted, got %v", err)
+	}
+}
+
+func TestSetConfigOverrideValidatesBeforeStoring(t *testing.T) {
+	rdb := unreachableRedis()
+	if err := setConfigOverride(context.Background(), rdb, "redis.addr", "x", config.Config{}); err == nil || !strings.Contains(err.Error(), "cannot be overridden") {
+		t.Errorf("expected an unknown key to be rejected, got %v", err)
+	}
+	if err := setConfigOverride(context.Background(), rdb, "limits.pr_list", "0", config.Config{}); err == nil || strings.Contains(err.Error(), "store") {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if err := setConfigOverride(context.Background(), rdb, "limits.pr_list", "80", config.Config{}); err == nil || !strings.Contains(err.Error(), "store") {
+		t.Errorf("expected a storage error from unreachable Redis, got %v", err)
+	}
+}
+
+func TestWithConfigOverridesKeepsConfigWhenRedisFails(t *testing.T) {
+	config := config.Config{GitHubOrg: "acme", PRListLimit: 50}
+	if got := withConfigOverrides(context.Background(), nil, config); !reflect.DeepEqual(got, config) {
+		t.Errorf("expected config unchanged without Redis, got %+v", got)
+	}
+	if got := withConfigOverrides(context.Background(), unreachableRedis(), config); !reflect.DeepEqual(got, config) {
+		t.Errorf("expected config unchanged when Redis is unreachable, got %+v", got)
+	}
+}
+
+func TestFormatConfigOverrides(t *testing.T) {
+	if got := formatConfigOverrides(nil); !strings.Contains(got, "No config overrides") || !strings.Contains(got, "limits.pr_list") {
+		t.Errorf("unexpected empty listing: %q", got)
+	}
+	got := formatConfigOverrides(map[string]string{"limits.pr_list": "200", "github.org": "widgets"})
+	if !strings.Contains(got, "• `github.org` = `widgets`\n• `limits.pr_list` = `200`") {
+		t.Errorf("unexpected listing: %q", got)
+	}
+}
+
+// ---- Integration tests ----
+
+func TestIntegrationSlashCommandToPost(t *testing.T) {
+	// Runs /pr through the whole Poppit round trip against an in-memory
+	// Redis, playing Poppit's part by echoing the queued command's metadata.
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	fake := &fakeSlack{}
+	ctx := context.Background()
+
+	cmdPayload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "api", TriggerID: "tid", UserID: "U1", UserName: "alice"})
+	handleSlashCommand(ctx, rdb, fake, string(cmdPayload), config)
+	assertSlackCalls(t, "slash command", fake, "views.open")
+
+	cmd := popPoppitCommand(t, mr, config)
+	if cmd.Type != poppitPRListType || cmd.Repo != "acme/api" || cmd.Metadata["view_id"] != fakeViewID {
+		t.Fatalf("unexpected Poppit command: %+v", cmd)
+	}
+
+	prs := []PRItem{{Number: 1, Title: "First PR"}, {Number: 2, Title: "Second PR"}}
+	prsJSON, _ := json.Marshal(prs)
+	outputPayload, _ := json.Marshal(poppit.Output{Type: cmd.Type, Output: string(prsJSON), Metadata: cmd.Metadata})
+	handlePoppitOutput(ctx, rdb, fake, string(outputPayload), config)
+	assertSlackCalls(t, "Poppit output", fake, "views.open", "views.update")
+
+	chooser := fake.recorded()[1]
+	if chooser.ViewID != fakeViewID || chooser.View.CallbackID != slackui.PRModalCallbackID {
+		t.Fatalf("expected the PR chooser on %s, got %q on %s", fakeViewID, chooser.View.CallbackID, chooser.ViewID)
+	}
+	if !mr.Exists(prSessionKey(fakeViewID)) {
+		t.Fatal("expected the PR session to be stored")
+	}
+	if mr.Exists(loadingPendingKey(fakeViewID)) {
+		t.Error("expected no pending loading watchdog")
+	}
+
+	var submission ViewSubmission
+	submission.Type = "view_submission"
+	submission.View.ID = fakeViewID
+	submission.View.CallbackID = slackui.PRModalCallbackID
+	submission.View.PrivateMetadata = chooser.View.PrivateMetadata
+	submission.View.State.Values = map[string]map[string]interface{}{
+		slackui.PRBlockID: {slackui.PRSelectActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": prs[1].optionValue()}}},
+	}
+	submission.User.ID, submission.User.Username = "U1", "alice"
+	submission.TriggerID = "tid2"
+	submissionPayload, _ := json.Marshal(submission)
+	handleViewSubmission(ctx, rdb, fake, string(submissionPayload), config)
+
+	assertSlackCalls(t, "submission", fake, "views.open", "views.update", "views.open")
+	if posts := slackLinerPosts(t, mr, config); len(posts) != 0 {
+		t.Fatalf("expected nothing posted before the preview is confirmed, got %+v", posts)
+	}
+	token := previewToken(t, fake.recorded()[2].View)
+
+	postPayload := fmt.Sprintf(`{"type":"block_actions","view":{"id":%q},"user":{"id":"U1","username":"alice"},"actions":[{"action_id":%q,"block_id":%q,"type":"button","value":%q}]}`,
+		fakeViewID, previewPostActionID, previewBlockID, token)
+	handleBlockAction(ctx, rdb, fake, postPayload, config)
+	// A double click must not post the PR twice.
+	handleBlockAction(ctx, rdb, fake, postPayload, config)
+
+	posts := slackLinerPosts(t, mr, config)
+	if len(posts) != 2 || posts[0].Channel != "C123" || !strings.Contains(posts[0].Text, "Second PR") {
+		t.Fatalf("expected Second PR to be posted to C123 once with its details, got %+v", posts)
+	}
+	assertSlackCalls(t, "post", fake, "views.open", "views.update", "views.open", "views.update", "views.update")
+}
+
+func TestIntegrationSinglePRIsAutoPosted(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	fake := &fakeSlack{}
+	ctx := context.Background()
+
+	cmdPayload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "acme/web", TriggerID: "tid", UserID: "U1", UserName: "alice"})
+	handleSlashCommand(ctx, rdb, fake, string(cmdPayload), config)
+	cmd := popPoppitCommand(t, mr, config)
+
+	pr := PRItem{Number: 9, Title: "Only PR", URL: "https://github.com/acme/web/pull/9"}
+	prsJSON, _ := json.Marshal([]PRItem{pr})
+	outputPayload, _ := json.Marshal(poppit.Output{Type: cmd.Type, Output: string(prsJSON), Metadata: cmd.Metadata})
+	handlePoppitOutput(ctx, rdb, fake, string(outputPayload), config)
+	// A duplicate delivery of the same output must not post the PR again.
+	handlePoppitOutput(ctx, rdb, fake, string(outputPayload), config)
+
+	assertSlackCalls(t, "auto-post", fake, "views.open", "views.update")
+	if got := fake.recorded()[1].View; !reflect.DeepEqual(got, slackui.AutoPostedModal("acme/web", pr.Number, pr.Title)) {
+		t.Errorf("expected the auto-posted modal, got %q", got.Title.Text)
+	}
+	if posts := slackLinerPosts(t, mr, config); len(posts) != 2 || !strings.Contains(posts[0].Text, "Only PR") {
+		t.Errorf("expected Only PR to be posted once with its details, got %+v", posts)
+	}
+	if mr.Exists(prSessionKey(fakeViewID)) {
+		t.Error("a single PR should not need a session")
+	}
+}
+
+// ---- post preview tests ----
+
+// previewToken returns the pending-post token carried by a preview's Post
+// button.
+func previewToken(t *testing.T, view slack.ModalViewRequest) string {
+	t.Helper()
+	if view.CallbackID != previewCallbackID {
+		t.Fatalf("expected the post preview, got %q", view.CallbackID)
+	}
+	blocks := view.Blocks.BlockSet
+	actions, ok := blocks[len(blocks)-1].(*slack.ActionBlock)
+	if !ok || actions.BlockID != previewBlockID || len(actions.Elements.ElementSet) != 2 {
+		t.Fatalf("expected Post and Back buttons at the end of the preview, got %+v", blocks[len(blocks)-1])
+	}
+	post := actions.Elements.ElementSet[0].(*slack.ButtonBlockElement)
+	if post.ActionID != previewPostActionID || post.Value == "" {
+		t.Fatalf("unexpected Post button: %+v", post)
+	}
+	return post.Value
+}
+
+func TestCreatePostPreviewModal(t *testing.T) {
+	config := testConfig(t)
+	msgs := []SlackLinerMessage{{Channel: config.SlackChannelID, Text: "📋 *Pull Request shared by @alice*"}}
+
+	modal := createPostPreviewModal(msgs, "tok", "", false, config)
+	if token := previewToken(t, modal); token != "tok" {
+		t.Errorf("expected the token on the Post button, got %q", token)
+	}
+	intro := modal.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text
+	if !strings.Contains(intro, "<#C123>") || !strings.Contains(intro, slackui.FormatAge(config.SlackMessageTTL)) {
+		t.Errorf("expected the channel and TTL in the intro, got %q", intro)
+	}
+	if strings.Contains(intro, "Dry run") {
+		t.Errorf("unexpected dry-run note: %q", intro)
+	}
+	if got := modal.Blocks.BlockSet[2].(*slack.SectionBlock).Text.Text; got != msgs[0].Text {
+		t.Errorf("expected the message text verbatim, got %q", got)
+	}
+
+	long := []SlackLinerMessage{{Text: strings.Repeat("a", maxPreviewTextLength+10)}}
+	modal = createPostPreviewModal(long, "tok", "", true, config)
+	if got := modal.Blocks.BlockSet[2].(*slack.SectionBlock).Text.Text; len([]rune(got)) != maxPreviewTextLength {
+		t.Errorf("expected the text truncated to %d runes, got %d", maxPreviewTextLength, len([]rune(got)))
+	}
+	if intro := modal.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text; !strings.Contains(intro, "Dry run") {
+		t.Errorf("expected a dry-run note, got %q", intro)
+	}
+}
+
+func TestPRSelectionWithoutPreviewPostsDirectly(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	config.SlackPreviewPosts = false
+	pr := PRItem{Number: 3, Title: "Direct"}
+	if err := savePRSession(context.Background(), rdb, "V1", PRModalPrivateMetadata{Repo: "acme/api", PRs: []PRItem{pr}}, config); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := session.Seal(PRModalPrivateMetadata{Repo: "acme/api"})
+
+	var submission ViewSubmission
+	submission.TriggerID = "tid"
+	submission.View.ID = "V1"
+	submission.View.PrivateMetadata = meta
+	submission.View.State.Values = map[string]map[string]interface{}{
+		slackui.PRBlockID: {slackui.PRSelectActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": pr.optionValue()}}},
+	}
+	fake := &fakeSlack{}
+	handlePRSelection(context.Background(), rdb, fake, submission, config)
+
+	assertSlackCalls(t, "direct post", fake)
+	if posts := slackLinerPosts(t, mr, config); len(posts) == 0 || !strings.Contains(posts[0].Text, "Direct") {
+		t.Errorf("expected the PR to be posted straight away, got %+v", posts)
+	}
+}
+
+func TestPRSelectionAppliesChosenTTL(t *testing.T) {
+	for _, c := range []struct {
+		ttl  string
+		want int
+	}{{"3600", 3600}, {"0", 0}, {"", int(config.DefaultMessageTTL / time.Second)}} {
+		mr, rdb := newTestRedis(t)
+		cfg := testConfig(t)
+		cfg.SlackPreviewPosts = false
+		pr := PRItem{Number: 3, Title: "FYI"}
+		if err := savePRSession(context.Background(), rdb, "V1", PRModalPrivateMetadata{Repo: "acme/api", PRs: []PRItem{pr}}, cfg); err != nil {
+			t.Fatal(err)
+		}
+		meta, _ := session.Seal(PRModalPrivateMetadata{Repo: "acme/api"})
+
+		var submission ViewSubmission
+		submission.View.ID = "V1"
+		submission.View.PrivateMetadata = meta
+		submission.View.State.Values = map[string]map[string]interface{}{
+			slackui.PRBlockID: {slackui.PRSelectActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": pr.optionValue()}}},
+		}
+		if c.ttl != "" {
+			submission.View.State.Values[slackui.MessageTTLBlockID] = map[string]interface{}{
+				slackui.MessageTTLActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": c.ttl}},
+			}
+		}
+		handlePRSelection(context.Background(), rdb, &fakeSlack{}, submission, cfg)
+
+		posts := slackLinerPosts(t, mr, cfg)
+		if len(posts) == 0 {
+			t.Fatalf("ttl %q: expected the PR to be posted", c.ttl)
+		}
+		for _, post := range posts {
+			if post.TTL != c.want {
+				t.Errorf("ttl %q: expected TTL %d, got %d", c.ttl, c.want, post.TTL)
+			}
+		}
+	}
+}
+
+func TestPRSelectionRoutesToChannel(t *testing.T) {
+	for _, c := range []struct {
+		name, picked, want string
+	}{
+		{"routed", "", "CPAY"},
+		{"kept on the routed channel", "CPAY", "CPAY"},
+		{"overridden", "COTHER", "COTHER"},
+	} {
+		mr, rdb := newTestRedis(t)
+		cfg := testConfig(t)
+		cfg.SlackPreviewPosts = false
+		cfg.SlackChannelRoutes = map[string]string{"payments-*": "CPAY"}
+		pr := PRItem{Number: 7, Title: "Refund fees"}
+		if err := savePRSession(context.Background(), rdb, "V1", PRModalPrivateMetadata{Repo: "acme/payments-api", PRs: []PRItem{pr}}, cfg); err != nil {
+			t.Fatal(err)
+		}
+		meta, _ := session.Seal(PRModalPrivateMetadata{Repo: "acme/payments-api", Channel: "CPAY"})
+
+		var submission ViewSubmission
+		submission.View.ID = "V1"
+		submission.View.PrivateMetadata = meta
+		submission.View.State.Values = map[string]map[string]interface{}{
+			slackui.PRBlockID: {slackui.PRSelectActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": pr.optionValue()}}},
+		}
+		if c.picked != "" {
+			submission.View.State.Values[slackui.ChannelBlockID] = map[string]interface{}{
+				slackui.ChannelActionID: map[string]interface{}{"selected_conversation": c.picked},
+			}
+		}
+		handlePRSelection(context.Background(), rdb, &fakeSlack{}, submission, cfg)
+
+		posts := slackLinerPosts(t, mr, cfg)
+		if len(posts) == 0 {
+			t.Fatalf("%s: expected the PR to be posted", c.name)
+		}
+		for _, post := range posts {
+			if post.Channel != c.want {
+				t.Errorf("%s: expected the post in %s, got %s", c.name, c.want, post.Channel)
+			}
+		}
+	}
+}
+
+func TestPostChannel(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.SlackChannelRoutes = map[string]string{"payments-*": "CPAY"}
+	if got := postChannel("acme/payments-api", Invocation{}, cfg); got != "CPAY" {
+		t.Errorf("expected the routed channel, got %q", got)
+	}
+	if got := postChannel("acme/api", Invocation{}, cfg); got != cfg.SlackChannelID {
+		t.Errorf("expected the default channel, got %q", got)
+	}
+	if got := postChannel("acme/payments-api", Invocation{Channel: "COTHER"}, cfg); got != "COTHER" {
+		t.Errorf("expected the picked channel, got %q", got)
+	}
+	a, b := PRItem{Number: 1}, PRItem{Number: 2}
+	a.Repository.NameWithOwner, b.Repository.NameWithOwner = "acme/payments-api", "acme/api"
+	if got := channelList(postChannels([]PRItem{a, b}, "", Invocation{}, cfg)); got != "<#CPAY> and <#"+cfg.SlackChannelID+">" {
+		t.Errorf("unexpected channels: %q", got)
+	}
+}
+
+func TestPRSelectionScheduledPostsLater(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	config.SlackPreviewPosts = false
+	ctx := context.Background()
+	pr := PRItem{Number: 3, Title: "After standup"}
+	if err := savePRSession(ctx, rdb, "V1", PRModalPrivateMetadata{Repo: "acme/api", PRs: []PRItem{pr}}, config); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := session.Seal(PRModalPrivateMetadata{Repo: "acme/api"})
+	postAt := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+
+	var submission ViewSubmission
+	submission.View.ID = "V1"
+	submission.View.PrivateMetadata = meta
+	submission.View.State.Values = map[string]map[string]interface{}{
+		slackui.PRBlockID:       {slackui.PRSelectActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": pr.optionValue()}}},
+		slackui.ScheduleBlockID: {slackui.ScheduleActionID: map[string]interface{}{"selected_date_time": float64(postAt.Unix())}},
+	}
+	submission.User.ID, submission.User.Username = "U1", "alice"
+	fake := &fakeSlack{timezones: map[string]string{"U1": "Europe/London"}}
+	handlePRSelection(ctx, rdb, fake, submission, config)
+
+	if posts := slackLinerPosts(t, mr, config); len(posts) != 0 {
+		t.Fatalf("expected nothing posted before the scheduled time, got %+v", posts)
+	}
+	calls := fake.recorded()
+	if len(calls) != 1 || calls[0].Method != "chat.postEphemeral" || calls[0].Channel != "C123" ||
+		!strings.Contains(calls[0].Text, "*PR #3: After standup* will be posted to <#C123> on ") {
+		t.Fatalf("expected an ephemeral confirmation, got %+v", calls)
+	}
+	if due, _ := rdb.ZRangeWithScores(ctx, delayedJobsKey, 0, -1).Result(); len(due) != 1 || int64(due[0].Score) != postAt.Unix() {
+		t.Fatalf("expected one job due at %v, got %+v", postAt, due)
+	}
+
+	runDueJobs(ctx, rdb, fake, postAt.Add(-time.Minute), config)
+	if posts := slackLinerPosts(t, mr, config); len(posts) != 0 {
+		t.Fatalf("expected nothing posted early, got %+v", posts)
+	}
+	runDueJobs(ctx, rdb, fake, postAt, config)
+	posts := slackLinerPosts(t, mr, config)
+	if len(posts) == 0 || posts[0].Channel != "C123" || !strings.Contains(posts[0].Text, "After standup") {
+		t.Errorf("expected the PR to be posted once due, got %+v", posts)
+	}
+	if rec, err := loadPostedPR(ctx, rdb, "acme/api", 3); err != nil || rec.PostedByID != "U1" {
+		t.Errorf("expected the scheduled post to be indexed as alice's, got %+v (%v)", rec, err)
+	}
+}
+
+func TestScheduledPostJobSealed(t *testing.T) {
+	withTestSessionKey(t)
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	ctx := context.Background()
+	postAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	job := scheduledPost{Repo: "acme/secret", PRs: []PRItem{{Number: 3, Title: "Private title"}}, Channel: "C123", UserID: "U1", Username: "alice"}
+	if err := scheduleJob(ctx, rdb, scheduledPostJobType, postAt, job); err != nil {
+		t.Fatal(err)
+	}
+
+	members, _ := rdb.ZRange(ctx, delayedJobsKey, 0, -1).Result()
+	if len(members) != 1 || strings.Contains(members[0], "Private title") || !strings.HasPrefix(members[0], "sealed:") {
+		t.Fatalf("expected one sealed job, got %q", members)
+	}
+	runDueJobs(ctx, rdb, &fakeSlack{}, postAt, config)
+	if posts := slackLinerPosts(t, mr, config); len(posts) == 0 || !strings.Contains(posts[0].Text, "Private title") {
+		t.Errorf("expected the sealed job to be opened and posted, got %+v", posts)
+	}
+}
+
+func TestHandlePreviewBack(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	ctx := context.Background()
+	prs := []PRItem{{Number: 1, Title: "First"}, {Number: 2, Title: "Second"}}
+	if err := savePRSession(ctx, rdb, "V1", PRModalPrivateMetadata{Repo: "acme/api", PRs: prs}, config); err != nil {
+		t.Fatal(err)
+	}
+	pending := pendingPost{Repo: "acme/api", PRs: prs[:1], ChooserViewID: "V1", ChooserMetadata: "meta"}
+	fake := &fakeSlack{}
+	if err := openPostPreview(ctx, rdb, fake, "tid", pending, Invocation{UserID: "U1", Username: "alice"}, config); err != nil {
+		t.Fatal(err)
+	}
+	token := previewToken(t, fake.recorded()[0].View)
+
+	action := BlockActionPayload{}
+	action.View.ID = "V2"
+	handlePreviewBack(ctx, rdb, fake, action, token, config)
+
+	assertSlackCalls(t, "back", fake, "views.open", "views.update")
+	back := fake.recorded()[1]
+	if back.ViewID != "V2" || back.View.CallbackID != slackui.PRModalCallbackID || back.View.PrivateMetadata != "meta" {
+		t.Errorf("expected the chooser on V2 with its metadata, got %q on %s", back.View.CallbackID, back.ViewID)
+	}
+	if _, err := loadPRSession(ctx, rdb, "V2"); err != nil {
+		t.Errorf("expected the PR session copied to the preview's view: %v", err)
+	}
+	if mr.Exists(previewKey(token)) {
+		t.Error("expected the pending post to be discarded")
+	}
+	if posts := slackLinerPosts(t, mr, config); len(posts) != 0 {
+		t.Errorf("expected nothing posted, got %+v", posts)
+	}
+}
+
+func TestHandlePreviewPostExpired(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	config := testConfig(t)
+	action := BlockActionPayload{}
+	action.View.ID = "V2"
+	fake := &fakeSlack{}
+	handlePreviewPost(context.Background(), rdb, fake, action, "gone", config)
+
+	assertSlackCalls(t, "expired preview", fake, "views.update")
+	if posts := slackLinerPosts(t, mr, config); len(posts) != 0 {
+		t.Errorf("expected nothing posted, got %+v", posts)
+	}
+}
+
+// ---- preferences tests ----
+
+func TestUserPreferencesRoundTrip(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	mr.Set(prSortKey("U1"), "created-asc")
+
+	hide := true
+	want := UserPreferences{DefaultRepo: "acme/api", Sort: "updated-desc", HideDrafts: &hide, Channel: "C999"}
+	if err := saveUserPreferences(ctx, rdb, "U1", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadUserPreferences(ctx, rdb, "U1")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("loadUserPreferences = %+v, %v; want %+v", got, err, want)
+	}
+	if sort := loadPRSort(ctx, rdb, "U1"); sort != "updated-desc" {
+		t.Errorf("expected the preferred sort to replace the remembered one, got %q", sort)
+	}
+
+	config := withUserPreferences(ctx, rdb, "U1", testConfig(t))
+	if !config.GitHubHideDrafts || config.SlackChannelID != "C999" {
+		t.Errorf("preferences not applied to config: hide_drafts=%v channel=%q", config.GitHubHideDrafts, config.SlackChannelID)
+	}
+
+	if err := saveUserPreferences(ctx, rdb, "U1", UserPreferences{}); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(userPrefsKey("U1")) {
+		t.Error("expected empty preferences to clear the hash")
+	}
+}
+
+func TestHandlePreferencesSubmission(t *testing.T) {
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+	config := testConfig(t)
+
+	submit := func(repo string) {
+		var submission ViewSubmission
+		submission.User.ID = "U1"
+		submission.View.PrivateMetadata, _ = session.Seal(preferencesMetadata{ResponseURL: srv.URL})
+		submission.View.State.Values = map[string]map[string]interface{}{
+			prefsRepoBlockID:    {prefsRepoActionID: map[string]interface{}{"value": repo}},
+			prefsSortBlockID:    {prefsSortActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": prefsDefaultValue}}},
+			prefsDraftsBlockID:  {prefsDraftsActionID: map[string]interface{}{"selected_option": map[string]interface{}{"value": prefsDraftsShowValue}}},
+			prefsChannelBlockID: {prefsChannelActionID: map[string]interface{}{"selected_conversation": "C777"}},
+		}
+		handlePreferencesSubmission(ctx, rdb, submission, config)
+	}
+
+	submit("api")
+	prefs, _ := loadUserPreferences(ctx, rdb, "U1")
+	if prefs.DefaultRepo != "acme/api" || prefs.Sort != "" || prefs.HideDrafts == nil || *prefs.HideDrafts || prefs.Channel != "C777" {
+		t.Errorf("unexpected saved preferences: %+v", prefs)
+	}
+	if got.Text != prefsSavedReply {
+		t.Errorf("expected confirmation, got %q", got.Text)
+	}
+
+	submit("repo; rm -rf /")
+	if prefs, _ := loadUserPreferences(ctx, rdb, "U1"); prefs.DefaultRepo != "acme/api" {
+		t.Errorf("expected invalid repo to leave preferences alone, got %+v", prefs)
+	}
+	if !strings.Contains(got.Text, "not a valid repository") {
+		t.Errorf("expected invalid repo warning, got %q", got.Text)
+	}
+}
+
+func TestHandleSlashCommandSettingsOpensModal(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "settings", TriggerID: "tid", UserID: "U1"})
+	fake := &fakeSlack{}
+	handleSlashCommand(context.Background(), rdb, fake, string(payload), testConfig(t))
+	assertSlackCalls(t, "settings", fake, "views.open")
+	if calls := fake.recorded(); len(calls) == 1 && calls[0].View.CallbackID != prefsCallbackID {
+		t.Errorf("expected the preferences modal, got %q", calls[0].View.CallbackID)
+	}
+}
+
+func TestHandleSlashCommandOffersDefaultRepo(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	config := testConfig(t)
+	if err := saveUserPreferences(context.Background(), rdb, "U1", UserPreferences{DefaultRepo: "acme/api"}); err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", TriggerID: "tid", UserID: "U1"})
+	assertShowsView(t, "default repo", "views.open", slackui.RepoChooserModal(config.Orgs(), "acme", "acme/api"), func(fake *fakeSlack) {
+		handleSlashCommand(context.Background(), rdb, fake, string(payload), config)
+	})
+}
+
+// ---- feature flag tests ----
+
+func TestWithFeatureFlagsPrecedence(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.Features = map[string]config.FeatureFlag{config.FeatureBlockKitPosts: {Workspaces: []string{"T1"}}}
+
+	enabled := func(team, channel string) bool {
+		return withFeatureFlags(ctx, rdb, featureScope{Team: team, Channel: channel}, cfg).FeatureEnabled(featureBlockKitPosts)
+	}
+	if !enabled("T1", "C1") || enabled("T2", "C1") {
+		t.Error("expected config.yaml to enable block_kit_posts for T1 only")
+	}
+	if !withFeatureFlags(ctx, nil, featureScope{}, cfg).FeatureEnabled(featureMultiSelect) {
+		t.Error("expected multi_select to stay on by default without Redis")
+	}
+
+	rdb.HSet(ctx, featureKey(featureBlockKitPosts), "all", "on", "team:T1", "off", "channel:C1", "on")
+	if !enabled("T2", "") || enabled("T1", "C2") || !enabled("T1", "C1") {
+		t.Error("expected channel overrides to beat workspace ones, which beat all")
+	}
+}
+
+func TestHandleAdminFeature(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	for _, fields := range [][]string{{"feature", "multi_select"}, {"feature", "multi_select", "maybe"}, {"feature", "multi_select", "on", "general"}} {
+		if got := adminReply(t, rdb, fields...); got != adminUsage() {
+			t.Errorf("/pr admin %v: expected usage, got %q", fields, got)
+		}
+	}
+	if got := adminReply(t, rdb, "feature", "teleport", "on"); !strings.Contains(got, "Unknown feature") {
+		t.Errorf("expected an unknown feature to be refused, got %q", got)
+	}
+
+	if got := adminReply(t, rdb, "feature", "multi_select", "off", "<#C9|general>"); !strings.Contains(got, "now off for <#C9>") {
+		t.Errorf("unexpected reply: %q", got)
+	}
+	adminReply(t, rdb, "feature", "block_kit_posts", "on", "T1")
+	if v := rdb.HGet(ctx, featureKey(featureMultiSelect), "channel:C9").Val(); v != "off" {
+		t.Errorf("expected the channel override to be stored, got %q", v)
+	}
+	list := adminReply(t, rdb, "feature")
+	if !strings.Contains(list, "`multi_select` — on in config.yaml; off for <#C9>") || !strings.Contains(list, "on for workspace `T1`") {
+		t.Errorf("unexpected feature list: %q", list)
+	}
+
+	adminReply(t, rdb, "feature", "multi_select", "reset", "C9")
+	if n := rdb.HLen(ctx, featureKey(featureMultiSelect)).Val(); n != 0 {
+		t.Errorf("expected reset to remove the override, %d left", n)
+	}
+}
+
+func TestHandleSlashCommandMultiNeedsFeature(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	rdb.HSet(ctx, featureKey(featureMultiSelect), "channel:C1", "off")
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload, _ := json.Marshal(SlackCommand{Command: "/pr", Text: "api --multi", TriggerID: "tid", UserID: "U1", ChannelID: "C1", ResponseURL: srv.URL})
+	fake := &fakeSlack{}
+	handleSlashCommand(ctx, rdb, fake, string(payload), testConfig(t))
+	assertSlackCalls(t, "multi disabled", fake)
+	if !strings.Contains(got.Text, "--multi") {
+		t.Errorf("expected --multi to be refused, got %q", got.Text)
+	}
+}
+
+func TestBuildPRMessageBlockKitPosts(t *testing.T) {
+	pr := &PRItem{Number: 1, Title: "Fix", URL: "https://github.com/acme/api/pull/1"}
+	if msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{}, config.Config{}); msg.Blocks != nil {
+		t.Errorf("expected a plain text post without the feature, got %d blocks", len(msg.Blocks))
+	}
+	cfg := config.Config{Features: map[string]config.FeatureFlag{config.FeatureBlockKitPosts: {Enabled: true}}}
+	msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{}, cfg)
+	if len(msg.Blocks) != 1 {
+		t.Fatalf("expected one section block, got %d", len(msg.Blocks))
+	}
+	section, ok := msg.Blocks[0].(*slack.SectionBlock)
+	if !ok || section.Accessory == nil || section.Accessory.ButtonElement == nil || section.Accessory.ButtonElement.URL != pr.URL {
+		t.Errorf("expected an Open in GitHub button, got %+v", msg.Blocks[0])
+	}
+}
+
+// ---- user info tests ----
+
+func TestLoadUserInfoCachesProfile(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	fake := &fakeSlack{timezones: map[string]string{"U1": "Asia/Tokyo"}}
+
+	for i := 0; i < 2; i++ {
+		if loc := userLocation(ctx, rdb, fake, "U1"); loc.String() != "Asia/Tokyo" {
+			t.Errorf("expected the profile timezone, got %s", loc)
+		}
+	}
+	if fake.userLookups != 1 {
+		t.Errorf("expected one users.info call, got %d", fake.userLookups)
+	}
+	if ttl := mr.TTL(userInfoKey("U1")); ttl != userInfoTTL {
+		t.Errorf("expected the profile cached for %s, got %s", userInfoTTL, ttl)
+	}
+	if loc := userLocation(ctx, rdb, nil, "U1"); loc.String() != "Asia/Tokyo" {
+		t.Errorf("expected the cache without Slack, got %s", loc)
+	}
+}
+
+func TestUserLocationFallsBackToUTC(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	fake := &fakeSlack{timezones: map[string]string{"U2": "Mars/Olympus_Mons"}}
+	for _, user := range []string{"U1", "U2"} {
+		if loc := userLocation(ctx, rdb, fake, user); loc != time.UTC {
+			t.Errorf("%s: expected UTC, got %s", user, loc)
+		}
+	}
+	if loc := userLocation(ctx, rdb, nil, "U3"); loc != time.UTC {
+		t.Errorf("expected UTC without a cached profile, got %s", loc)
+	}
+}
+
+func TestFormatAuditEntriesUsesTimezone(t *testing.T) {
+	at := time.Date(2026, 1, 2, 23, 30, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := formatAuditEntries("acme/api", []AuditEntry{{Action: "post", Username: "alice", Number: 1, At: at}}, tokyo)
+	if !strings.Contains(got, "2026-01-03 08:30 JST") {
+		t.Errorf("expected the entry in JST, got %q", got)
+	}
+}
+
+// ---- Remind me tests ----
+
+func TestBuildPRMessageRemindMeMenu(t *testing.T) {
+	pr := &PRItem{Number: 12, Title: "Fix"}
+	if msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{}, config.Config{}); msg.Blocks != nil {
+		t.Errorf("expected no menu without the feature, got %d blocks", len(msg.Blocks))
+	}
+	cfg := config.Config{Features: map[string]config.FeatureFlag{config.FeatureRemindMe: {Enabled: true}}}
+	msg := buildPRMessage(pr, "acme/api", "dave", PostOptions{}, cfg)
+	actions, ok := msg.Blocks[len(msg.Blocks)-1].(*slack.ActionBlock)
+	if !ok || actions.BlockID != postActionsBlockID || len(actions.Elements.ElementSet) != 1 {
+		t.Fatalf("expected a post actions block, got %+v", msg.Blocks)
+	}
+	menu := actions.Elements.ElementSet[0].(*slack.SelectBlockElement)
+	if menu.ActionID != postRemindActionID || len(menu.Options) != 3 || menu.Options[1].Value != "4h|acme/api#12" {
+		t.Errorf("unexpected Remind me menu: %+v", menu)
+	}
+}
+
+func TestHandleBlockActionRemindMeSchedulesDM(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Title: "Fix <it>", URL: "https://github.com/acme/api/pull/12", PostedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	payload := fmt.Sprintf(`{"type":"block_actions","response_url":%q,"user":{"id":"U1","username":"dave"},"actions":[{"action_id":"post_remind","block_id":"post_actions","type":"static_select","selected_option":{"value":"4h|acme/api#12"}}]}`, srv.URL)
+	handleBlockAction(ctx, rdb, &fakeSlack{}, payload, cfg)
+	if !strings.Contains(got.Text, "about <https://github.com/acme/api/pull/12|acme/api#12> in 4 hours") {
+		t.Errorf("unexpected confirmation: %q", got.Text)
+	}
+
+	runDueJobs(ctx, rdb, &fakeSlack{}, time.Now().Add(3*time.Hour), cfg)
+	if posts := slackLinerPosts(t, mr, cfg); len(posts) != 0 {
+		t.Fatalf("expected no DM before the reminder is due, got %d", len(posts))
+	}
+	runDueJobs(ctx, rdb, &fakeSlack{}, time.Now().Add(5*time.Hour), cfg)
+	posts := slackLinerPosts(t, mr, cfg)
+	if len(posts) != 1 || posts[0].Channel != "U1" || !strings.HasSuffix(posts[0].Text, "acme/api#12>: Fix &lt;it&gt;") {
+		t.Fatalf("expected one reminder DM to U1, got %+v", posts)
+	}
+	if n, _ := rdb.ZCard(ctx, delayedJobsKey).Result(); n != 0 {
+		t.Errorf("expected the job to be claimed, %d left", n)
+	}
+}
+
+func TestParseRemindMeValue(t *testing.T) {
+	for _, value := range []string{"", "4h", "2h|acme/api#1", "1h|acme/api", "1d|acme/api#x", "1d|#3"} {
+		if _, _, _, err := parseRemindMeValue(value); err == nil {
+			t.Errorf("parseRemindMeValue(%q): expected an error", value)
+		}
+	}
+}
+
+// ---- Claim review tests ----
+
+func TestHandleBlockActionClaimReview(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.Features = map[string]config.FeatureFlag{config.FeatureClaimReview: {Enabled: true}, config.FeatureRemindMe: {Enabled: true}}
+	if err := linkGitHubLogin(ctx, rdb, "U1", "octocat", false, cfg); err != nil {
+		t.Fatal(err)
+	}
+	var got []slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		got = append(got, msg)
+	}))
+	defer srv.Close()
+
+	post := buildPRMessage(&PRItem{Number: 12, Title: "Fix"}, "acme/api", "dave", PostOptions{}, cfg)
+	blocks, _ := json.Marshal(post.Blocks)
+	payload := fmt.Sprintf(`{"type":"block_actions","response_url":%q,"user":{"id":"U1","username":"dave"},"message":{"text":"PR","blocks":%s},"actions":[{"action_id":"post_claim","block_id":"post_actions","type":"button","value":"acme/api#12"}]}`, srv.URL, blocks)
+	handleBlockAction(ctx, rdb, &fakeSlack{}, payload, cfg)
+
+	cmd := popPoppitCommand(t, mr, cfg)
+	if cmd.Commands[0] != "gh pr edit 12 --repo acme/api --add-reviewer octocat" {
+		t.Errorf("unexpected command: %q", cmd.Commands[0])
+	}
+	if len(got) != 1 || !got[0].ReplaceOriginal || got[0].Blocks == nil {
+		t.Fatalf("expected the post to be replaced, got %+v", got)
+	}
+	updated := got[0].Blocks.BlockSet
+	line := updated[1].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject).Text
+	if line != ":raising_hand: Review claimed by <@U1>" {
+		t.Errorf("unexpected claim note: %q", line)
+	}
+	actions := updated[2].(*slack.ActionBlock).Elements.ElementSet
+	if len(actions) != 1 || actions[0].(*slack.SelectBlockElement).ActionID != postRemindActionID {
+		t.Errorf("expected only the Remind me menu to remain, got %+v", actions)
+	}
+	if n, _ := rdb.ZScore(ctx, analyticsReviewersKey(reviewActivityClaims, time.Now()), "U1").Result(); n != 1 {
+		t.Errorf("expected the claim to count towards the leaderboard, got %v", n)
+	}
+}
+
+func TestHandleBlockActionClaimReviewNeedsLinkedLogin(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig(t)
+	payload := fmt.Sprintf(`{"type":"block_actions","response_url":%q,"user":{"id":"U9","username":"erin"},"actions":[{"action_id":"post_claim","block_id":"post_actions","type":"button","value":"acme/api#12"}]}`, srv.URL)
+	handleBlockAction(context.Background(), rdb, &fakeSlack{}, payload, cfg)
+	if !strings.Contains(got.Text, "/pr whoami link") {
+		t.Errorf("expected a prompt to link GitHub, got %q", got.Text)
+	}
+	if mr.Exists(cfg.RedisPoppitList) {
+		t.Error("expected no reviewer request without a linked login")
+	}
+}
+
+func TestHandleBlockActionMergeEditsPost(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.PrivilegedUserIDs = []string{"U1"}
+	cfg.Features = map[string]config.FeatureFlag{config.FeatureClaimReview: {Enabled: true}, config.FeatureApproveMerge: {Enabled: true}}
+	var got []slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		got = append(got, msg)
+	}))
+	defer srv.Close()
+
+	post := buildPRMessage(&PRItem{Number: 12, Title: "Fix"}, "acme/api", "dave", PostOptions{}, cfg)
+	blocks, _ := json.Marshal(post.Blocks)
+	payload := fmt.Sprintf(`{"type":"block_actions","response_url":%q,"user":{"id":"U1","username":"dave"},"channel":{"id":"C1"},"message":{"text":"PR","blocks":%s},"actions":[{"action_id":"post_merge","block_id":"post_actions","type":"button","value":"acme/api#12"}]}`, srv.URL, blocks)
+	handleBlockAction(ctx, rdb, &fakeSlack{}, payload, cfg)
+
+	cmd := popPoppitCommand(t, mr, cfg)
+	if cmd.Type != poppitPRMergeType || cmd.Commands[0] != "gh pr merge 12 --repo acme/api --merge" {
+		t.Fatalf("unexpected command: %s %q", cmd.Type, cmd.Commands)
+	}
+	var metadata map[string]interface{}
+	data, _ := json.Marshal(cmd.Metadata)
+	_ = json.Unmarshal(data, &metadata)
+	handlePRMergeOutput(ctx, rdb, poppit.Output{Type: poppitPRMergeType, Metadata: metadata, Output: "✓ Merged pull request #12 (Fix)"}, cfg)
+
+	if len(got) != 2 || !got[0].ReplaceOriginal || got[0].Blocks == nil {
+		t.Fatalf("expected the post to be replaced, got %+v", got)
+	}
+	updated := got[0].Blocks.BlockSet
+	if len(updated) != 2 {
+		t.Fatalf("expected the actions block to be dropped, got %d blocks", len(updated))
+	}
+	line := updated[1].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject).Text
+	if line != ":twisted_rightwards_arrows: Merged by <@U1>" {
+		t.Errorf("unexpected merge note: %q", line)
+	}
+	if !strings.Contains(got[1].Text, "Merged `acme/api#12`") {
+		t.Errorf("unexpected merge confirmation: %q", got[1].Text)
+	}
+}
+
+func TestHandleBlockActionApproveNeedsPrivilege(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig(t)
+	payload := fmt.Sprintf(`{"type":"block_actions","response_url":%q,"user":{"id":"U9","username":"erin"},"actions":[{"action_id":"post_approve","block_id":"post_actions","type":"button","value":"acme/api#12"}]}`, srv.URL)
+	handleBlockAction(context.Background(), rdb, &fakeSlack{}, payload, cfg)
+	if !strings.Contains(got.Text, "Only privileged users") {
+		t.Errorf("expected a privilege error, got %q", got.Text)
+	}
+	if mr.Exists(cfg.RedisPoppitList) {
+		t.Error("expected no approval from an unprivileged user")
+	}
+}
+
+func TestHandleReactionAddedTracksReviews(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Channel: "C1", PostedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	slackClient := &fakeSlack{history: []slack.Message{{Msg: slack.Msg{
+		Timestamp: "1.2",
+		Text:      "PR",
+		Metadata: slack.SlackMetadata{EventType: "pr_posted", EventPayload: map[string]interface{}{
+			"repository": "acme/api",
+			"pr_number":  float64(12),
+		}},
+	}}}}
+
+	for _, reaction := range []struct{ user, name string }{
+		{"U1", "eyes"}, {"U2", "eyes"}, {"U1", "white_check_mark"}, {"U2", "eyes"}, {"U3", "thumbsup"},
+	} {
+		payload := fmt.Sprintf(`{"type":"reaction_added","user":%q,"reaction":%q,"item":{"type":"message","channel":"C1","ts":"1.2"}}`, reaction.user, reaction.name)
+		handleReactionAdded(ctx, rdb, slackClient, payload, cfg)
+	}
+
+	rec, err := loadPostedPR(ctx, rdb, "acme/api", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec.Reviewing, []string{"U2"}) || !reflect.DeepEqual(rec.Reviewed, []string{"U1"}) {
+		t.Errorf("unexpected review state: reviewing %v, reviewed %v", rec.Reviewing, rec.Reviewed)
+	}
+	if got := strings.Count(strings.Join(slackClient.methods(), ","), "chat.update"); got != 3 {
+		t.Errorf("expected 3 message updates, got %d", got)
+	}
+	if status := rec.reviewStatus(); status != ":eyes: Reviewing: <@U2> · :white_check_mark: Reviewed by <@U1>" {
+		t.Errorf("unexpected review status: %q", status)
+	}
+	if stats, err := loadWeeklyStats(ctx, rdb, time.Now()); err != nil || stats.ReviewSamples != 1 {
+		t.Errorf("expected the ✅ to count towards the review SLA, got %d samples, %v", stats.ReviewSamples, err)
+	}
+}
+
+func TestHandleReactionAddedIgnoresOlderPosts(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Channel: "C1", ThreadKey: "new", PostedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	post := func(ts, threadKey string) *fakeSlack {
+		return &fakeSlack{history: []slack.Message{{Msg: slack.Msg{
+			Timestamp: ts,
+			Text:      "PR",
+			Metadata: slack.SlackMetadata{EventType: "pr_posted", EventPayload: map[string]interface{}{
+				"repository": "acme/api",
+				"pr_number":  float64(12),
+				"thread_key": threadKey,
+			}},
+		}}}}
+	}
+	react := func(slackClient *fakeSlack, user, ts string) {
+		payload := fmt.Sprintf(`{"type":"reaction_added","user":%q,"reaction":"eyes","item":{"type":"message","channel":"C1","ts":%q}}`, user, ts)
+		handleReactionAdded(ctx, rdb, slackClient, payload, cfg)
+	}
+
+	older := post("1.1", "old")
+	react(older, "U1", "1.1")
+	latest := post("1.2", "new")
+	react(latest, "U2", "1.2")
+
+	rec, err := loadPostedPR(ctx, rdb, "acme/api", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.MessageTS != "1.2" || !reflect.DeepEqual(rec.Reviewing, []string{"U2"}) {
+		t.Errorf("expected only the latest post to be tracked, got ts %q, reviewing %v", rec.MessageTS, rec.Reviewing)
+	}
+	if slices.Contains(older.methods(), "chat.update") {
+		t.Error("expected the older post to be left alone")
+	}
+}
+
+func TestHandleReactionAddedConcurrentReactions(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Channel: "C1", PostedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	slackClient := &fakeSlack{history: []slack.Message{{Msg: slack.Msg{
+		Timestamp: "1.2",
+		Text:      "PR",
+		Metadata: slack.SlackMetadata{EventType: "pr_posted", EventPayload: map[string]interface{}{
+			"repository": "acme/api",
+			"pr_number":  float64(12),
+		}},
+	}}}}
+
+	// Two reactions race with a reminder claim on the same post.
+	var wg sync.WaitGroup
+	for _, user := range []string{"U1", "U2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload := fmt.Sprintf(`{"type":"reaction_added","user":%q,"reaction":"eyes","item":{"type":"message","channel":"C1","ts":"1.2"}}`, user)
+			handleReactionAdded(ctx, rdb, slackClient, payload, cfg)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := updatePostedPR(ctx, rdb, "acme/api", 12, func(rec *PostedPR) bool {
+			rec.Reminders++
+			return true
+		}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	rec, err := loadPostedPR(ctx, rdb, "acme/api", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(rec.Reviewing)
+	if !reflect.DeepEqual(rec.Reviewing, []string{"U1", "U2"}) || rec.Reminders != 1 {
+		t.Errorf("expected every update to be kept, got reviewing %v, reminders %d", rec.Reviewing, rec.Reminders)
+	}
+}
+
+func TestReviewStatusBlocksReplacesFooter(t *testing.T) {
+	blocks := reviewStatusBlocks(nil, "PR", "old")
+	blocks = reviewStatusBlocks(blocks, "PR", "new")
+	if len(blocks) != 2 {
+		t.Fatalf("expected a section and one footer, got %d blocks", len(blocks))
+	}
+	footer := blocks[1].(*slack.ContextBlock)
+	if footer.BlockID != reviewStatusBlockID || footer.ContextElements.Elements[0].(*slack.TextBlockObject).Text != "new" {
+		t.Errorf("unexpected footer: %+v", footer)
+	}
+}
+
+func TestHandleReactionAddedIgnoresOtherMessages(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	slackClient := &fakeSlack{history: []slack.Message{{Msg: slack.Msg{Timestamp: "1.2", Text: "lunch?"}}}}
+	payload := `{"type":"reaction_added","user":"U1","reaction":"eyes","item":{"type":"message","channel":"C1","ts":"1.2"}}`
+	handleReactionAdded(context.Background(), rdb, slackClient, payload, testConfig(t))
+	if methods := slackClient.methods(); !reflect.DeepEqual(methods, []string{"conversations.history"}) {
+		t.Errorf("expected only the message to be read, got %v", methods)
+	}
+}
+
+func TestHandleBoardCommandListsOpenPosts(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	now := time.Now()
+	for _, rec := range []PostedPR{
+		{Repo: "acme/api", Number: 12, Title: "Fix", Channel: "C1", PostedBy: "dave", PostedAt: now.Add(-3 * time.Hour), Reviewing: []string{"U2"}},
+		{Repo: "acme/api", Number: 13, Title: "Merged already", Channel: "C1", PostedBy: "dave", PostedAt: now.Add(-2 * time.Hour)},
+		{Repo: "acme/web", Number: 4, Title: "Elsewhere", Channel: "C2", PostedBy: "erin", PostedAt: now},
+	} {
+		if err := recordPostedPR(ctx, rdb, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got slack.WebhookMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	handleBoardCommand(ctx, rdb, &fakeSlack{}, SlackCommand{UserID: "U1", UserName: "dave", ChannelID: "C1", ResponseURL: srv.URL}, nil, cfg)
+	cmd := popPoppitCommand(t, mr, cfg)
+	if !strings.Contains(cmd.Commands[0], "--repo acme/api") || strings.Contains(cmd.Commands[0], "acme/web") {
+		t.Fatalf("unexpected command: %q", cmd.Commands[0])
+	}
+
+	var metadata map[string]interface{}
+	data, _ := json.Marshal(cmd.Metadata)
+	_ = json.Unmarshal(data, &metadata)
+	output := `[{"number":12,"title":"Fix","repository":{"nameWithOwner":"acme/api"}}]`
+	handleBoardOutput(ctx, rdb, poppit.Output{Type: poppitBoardType, Metadata: metadata, Output: output}, cfg)
+
+	if !strings.Contains(got.Text, "1 open PRs") || !strings.Contains(got.Text, "acme/api#12 Fix — shared 3h ago by @dave · :eyes: Reviewing: <@U2>") {
+		t.Errorf("unexpected board: %q", got.Text)
+	}
+	if strings.Contains(got.Text, "#13") {
+		t.Errorf("expected the closed PR to be left off the board: %q", got.Text)
+	}
+}
+
+func TestLoadChannelPostsDropsMovedPosts(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	now := time.Now()
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Channel: "C1", PostedAt: now.Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordPostedPR(ctx, rdb, PostedPR{Repo: "acme/api", Number: 12, Channel: "C2", PostedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	if posts, err := loadChannelPosts(ctx, rdb, "C1"); err != nil || len(posts) != 0 {
+		t.Errorf("expected no posts left in C1, got %v (%v)", posts, err)
+	}
+	if n, _ := rdb.ZCard(ctx, postedChannelIndexKey("C1")).Result(); n != 0 {
+		t.Errorf("expected the moved post to be pruned from the C1 index, %d left", n)
+	}
+	if posts, err := loadChannelPosts(ctx, rdb, "C2"); err != nil || len(posts) != 1 {
+		t.Errorf("expected the post in C2, got %v (%v)", posts, err)
+	}
+}
+
+// ---- leaderboard tests ----
+
+func TestLoadReviewerLeaderboard(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	now := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	for _, a := range []struct {
+		kind, user string
+		at         time.Time
+	}{
+		{reviewActivityApprovals, "U1", now},
+		{reviewActivityClaims, "U1", now.AddDate(0, 0, -2)},
+		{reviewActivityClaims, "U2", now},
+		{reviewActivityClaims, "U2", now.AddDate(0, 0, -1)},
+		{reviewActivityApprovals, "U3", now},
+		{reviewActivityApprovals, "U3", now.AddDate(0, 0, -7)}, // outside the window
+	} {
+		if err := recordReviewActivity(ctx, rdb, a.kind, a.user, a.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scores, err := loadReviewerLeaderboard(ctx, rdb, now, 7, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ReviewerScore{{UserID: "U1", Approvals: 1, Claims: 1}, {UserID: "U2", Claims: 2}, {UserID: "U3", Approvals: 1}}
+	if !reflect.DeepEqual(scores, want) {
+		t.Errorf("got %+v, want %+v", scores, want)
+	}
+
+	text := buildLeaderboardText(scores)
+	for _, line := range []string{
+		"1. :first_place_medal: <@U1> — 2 reviews (1 approved, 1 claimed)",
+		"3. :third_place_medal: <@U3> — 1 review (1 approved, 0 claimed)",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("expected %q in leaderboard:\n%s", line, text)
+		}
+	}
+}
+
+func TestRunDueLeaderboardPostsOnce(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	cfg := testConfig(t)
+	cfg.LeaderboardChannel = "C9"
+	monday := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	if err := recordReviewActivity(ctx, rdb, reviewActivityApprovals, "U1", monday); err != nil {
+		t.Fatal(err)
+	}
+
+	runDueLeaderboard(ctx, rdb, monday.Add(time.Minute), cfg)
+	runDueLeaderboard(ctx, rdb, monday, cfg)
+	runDueLeaderboard(ctx, rdb, monday, cfg)
+
+	posts := slackLinerPosts(t, mr, cfg)
+	if len(posts) != 1 || posts[0].Channel != "C9" || !strings.Contains(posts[0].Text, "<@U1>") {
+		t.Fatalf("expected one leaderboard in C9, got %+v", posts)
+	}
+	if posts[0].Metadata["event_type"] != "pr_leaderboard" {
+		t.Errorf("unexpected metadata: %v", posts[0].Metadata)
+	}
+}
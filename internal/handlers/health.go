@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/version"
+)
+
+const (
+	// readinessCheckTimeout bounds each dependency check behind /readyz.
+	readinessCheckTimeout = 2 * time.Second
+	// slackAuthCacheTTL is how long a successful auth.test is trusted, so
+	// frequent probes do not spend Slack rate limit.
+	slackAuthCacheTTL = time.Minute
+)
+
+// feedTracker records which Redis feeds are currently subscribed to, for
+// /readyz. Feeds register themselves when they start consuming.
+type feedTracker struct {
+	mu    sync.Mutex
+	feeds map[string]bool
+	gauge *expvar.Map
+}
+
+// feeds is the process-wide feed tracker. Its state is also published as
+// the expvar gauge map slashvibepr_subscriptions (1 subscribed, 0 not),
+// served at /debug/vars by the health and pprof servers.
+var feeds = &feedTracker{feeds: map[string]bool{}, gauge: expvar.NewMap("slashvibepr_subscriptions")}
+
+// set records whether the named feed is subscribed.
+func (t *feedTracker) set(name string, up bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.feeds[name] = up
+	if t.gauge != nil {
+		v := new(expvar.Int)
+		if up {
+			v.Set(1)
+		}
+		t.gauge.Set(name, v)
+	}
+}
+
+// waiting returns the registered feeds not yet subscribed, sorted.
+func (t *feedTracker) waiting() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var names []string
+	for name, up := range t.feeds {
+		if !up {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// healthServer answers Kubernetes liveness and readiness probes.
+type healthServer struct {
+	rdb         *redis.Client
+	slackClient *slack.Client
+	feeds       *feedTracker
+
+	mu             sync.Mutex
+	slackCheckedAt time.Time
+}
+
+// serveHealth serves /healthz and /readyz on config.HealthAddr until ctx is
+// done.
+func serveHealth(ctx context.Context, rdb *redis.Client, slackClient *slack.Client, config config.Config) {
+	h := &healthServer{rdb: rdb, slackClient: slackClient, feeds: feeds}
+	srv := &http.Server{
+		Addr:              config.HealthAddr,
+		Handler:           h.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logging.WarnContext(ctx, "Error shutting down health server: %v", err)
+		}
+	}()
+
+	logging.InfoContext(ctx, "Serving health checks on %s", config.HealthAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.ErrorContext(ctx, "Health server stopped: %v", err)
+	}
+}
+
+// routes returns the HTTP handler for the probe endpoints.
+func (h *healthServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.handleHealthz)
+	mux.HandleFunc("GET /readyz", h.handleReadyz)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return mux
+}
+
+// handleHealthz reports that the process is up, and which build it runs.
+func (h *healthServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"status": "ok", "build": version.Get()})
+}
+
+// handleReadyz reports whether Redis answers a ping, the Slack token passes
+// auth.test, and every Redis feed is subscribed. Each check is listed with
+// "ok" or the reason it failed.
+func (h *healthServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	checks := map[string]string{"redis": "ok", "slack": "ok", "subscriptions": "ok"}
+	ready := true
+	if err := h.rdb.Ping(ctx).Err(); err != nil {
+		checks["redis"], ready = err.Error(), false
+	}
+	if err := h.checkSlack(ctx); err != nil {
+		checks["slack"], ready = err.Error(), false
+	}
+	if waiting := h.feeds.waiting(); len(waiting) > 0 {
+		checks["subscriptions"], ready = "waiting for "+strings.Join(waiting, ", "), false
+	}
+
+	status := "ok"
+	if !ready {
+		status = "unavailable"
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, map[string]interface{}{"status": status, "checks": checks, "build": version.Get()})
+}
+
+// checkSlack runs auth.test unless one succeeded within slackAuthCacheTTL.
+func (h *healthServer) checkSlack(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.slackCheckedAt) < slackAuthCacheTTL {
+		return nil
+	}
+	if _, err := h.slackClient.AuthTestContext(ctx); err != nil {
+		return err
+	}
+	h.slackCheckedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const (
+	poppitHomePRsType = "slash-vibe-home-prs"
+	homePostActionID  = "home_post"
+	homeBlockIDPrefix = "home_pr_"
+	// maxHomePRs keeps the Home tab short and well under Slack's block limit.
+	maxHomePRs = 20
+	// homeFetchTimeout bounds the inline API fetch made when the tab opens.
+	homeFetchTimeout = 5 * time.Second
+)
+
+// homeFavorites is the App Home section listing open PRs in the user's
+// favorite repos.
+type homeFavorites struct {
+	Repos []string
+	PRs   []PRItem
+	// Loading is set while a Poppit search for the PRs is in flight.
+	Loading bool
+}
+
+// homeSessionID is the PR session ID holding a user's App Home PRs, so the
+// "Post to channel" buttons can find the PR they refer to.
+func homeSessionID(userID string) string {
+	return "home:" + userID
+}
+
+// refreshHome publishes the user's Home tab with open PRs for their
+// favorite repos. In api mode the PRs are fetched inline; in poppit mode the
+// tab is published with a loading note and republished by
+// handleHomePRsOutput once the search returns.
+func refreshHome(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string, config config.Config) {
+	repos, err := loadFavorites(ctx, rdb, userID)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading favorites for App Home of user %s: %v", userID, err)
+	}
+	home := homeFavorites{Repos: repos}
+
+	if len(repos) > 0 {
+		search := prSearch{Repos: repos}
+		if config.UsesGitHubAPI() {
+			fetchCtx, cancel := context.WithTimeout(ctx, homeFetchTimeout)
+			prs, err := newGitHubClientFromConfig(config).searchPullRequests(fetchCtx, search.apiQuery(nil), maxHomePRs)
+			cancel()
+			if err != nil {
+				logging.ErrorContext(ctx, "Error fetching favorite repo PRs for user %s: %v", userID, err)
+			} else {
+				home.PRs = saveHomePRs(ctx, rdb, userID, prs, config)
+			}
+		} else {
+			home.Loading = true
+			if err := sendHomePRsCommand(ctx, rdb, userID, search, config); err != nil {
+				logging.ErrorContext(ctx, "Error queueing favorite repo PRs for user %s: %v", userID, err)
+				home.Loading = false
+			}
+		}
+	}
+
+	publishHomeView(ctx, rdb, slackClient, userID, home, config)
+}
+
+// sendHomePRsCommand queues the favorites search on Poppit.
+func sendHomePRsCommand(ctx context.Context, rdb *redis.Client, userID string, search prSearch, config config.Config) error {
+	metadata := search.metadata()
+	metadata["user_id"] = userID
+	return pushPoppitCommand(ctx, rdb, poppit.Command{
+		Type:     poppitHomePRsType,
+		Dir:      "/tmp",
+		Commands: []string{search.command(nil, search.limit(config))},
+		Metadata: metadata,
+	}, config)
+}
+
+// handleHomePRsOutput republishes the Home tab with the PRs found by Poppit.
+func handleHomePRsOutput(ctx context.Context, rdb *redis.Client, slackClient SlackClient, output poppit.Output, config config.Config) {
+	userID, _ := output.Metadata["user_id"].(string)
+	if userID == "" {
+		logging.WarnContext(ctx, "Missing user_id in Poppit App Home output metadata")
+		return
+	}
+	search := prSearchFromMetadata(output.Metadata)
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Listing App Home PRs for user %s failed: %s", userID, output.ErrorText())
+		return
+	}
+
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &prs); err != nil {
+		logging.ErrorContext(ctx, "Error parsing App Home PR JSON for user %s: %v", userID, err)
+		return
+	}
+	home := homeFavorites{Repos: search.Repos, PRs: saveHomePRs(ctx, rdb, userID, prs, config)}
+	publishHomeView(ctx, rdb, slackClient, userID, home, config)
+}
+
+// saveHomePRs trims prs to maxHomePRs and keeps them in the user's home
+// session. The trimmed list is returned for rendering.
+func saveHomePRs(ctx context.Context, rdb *redis.Client, userID string, prs []PRItem, config config.Config) []PRItem {
+	if len(prs) > maxHomePRs {
+		prs = prs[:maxHomePRs]
+	}
+	if err := savePRSession(ctx, rdb, homeSessionID(userID), PRModalPrivateMetadata{PRs: prs}, config); err != nil {
+		logging.WarnContext(ctx, "Error saving App Home session for user %s: %v", userID, err)
+	}
+	return prs
+}
+
+// handleHomePost posts a PR from the App Home list to the channel.
+func handleHomePost(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, value string, config config.Config) {
+	userID := action.User.ID
+	session, err := loadPRSession(ctx, rdb, homeSessionID(userID))
+	if err != nil {
+		logging.WarnContext(ctx, "App Home session for user %s expired, refreshing: %v", userID, err)
+		refreshHome(ctx, rdb, slackClient, userID, config)
+		return
+	}
+	pr := findPR(session.PRs, value)
+	if pr == nil {
+		logging.WarnContext(ctx, "Could not find PR %s in App Home session", value)
+		return
+	}
+
+	repo := pr.repoOr("")
+	inv := Invocation{UserID: userID, Username: action.User.Username, TeamID: action.Team.ID}
+	if err := sharePR(ctx, rdb, slackClient, pr, repo, inv, PostOptions{}, config); err != nil {
+		logging.ErrorContext(ctx, "Error posting PR from App Home: %v", err)
+		return
+	}
+	logging.InfoContext(ctx, "User %s posted PR #%d from %s via App Home", action.User.Username, pr.Number, repo)
+}
+
+// favoritePRBlocks renders the favorites section of the Home tab: a header
+// followed by one section per PR with a "Post to channel" button.
+func favoritePRBlocks(home homeFavorites, now time.Time) []slack.Block {
+	heading := "*Open PRs in your favorite repos*\n"
+	switch {
+	case len(home.Repos) == 0:
+		heading += "Add favorites with `/pr fav add <repo>` to see their open pull requests here."
+	case home.Loading:
+		heading += "_Loading…_"
+	case len(home.PRs) == 0:
+		heading += "No open pull requests."
+	default:
+		heading += fmt.Sprintf("%d open in %d repos.", len(home.PRs), len(home.Repos))
+	}
+
+	blocks := []slack.Block{
+		slack.NewDividerBlock(),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, heading, false, false), nil, nil),
+	}
+	for i, pr := range home.PRs {
+		text := fmt.Sprintf("*<%s|%s>*\n%s", pr.URL, escapeSlackText(pr.Title), escapeSlackText(prOptionDescription(pr, now)))
+		post := slack.NewButtonBlockElement(homePostActionID, pr.optionValue(), slack.NewTextBlockObject(slack.PlainTextType, "Post to channel", false, false))
+		section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, slack.NewAccessory(post))
+		section.BlockID = fmt.Sprintf("%s%d", homeBlockIDPrefix, i)
+		blocks = append(blocks, section)
+	}
+	return blocks
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// maxSlackRequestBody bounds the size of a request body read from Slack.
+const maxSlackRequestBody = 1 << 20
+
+// slackHTTPServer receives slash commands, interactivity payloads, and
+// events straight from Slack over HTTP.
+type slackHTTPServer struct {
+	ctx         context.Context
+	rdb         *redis.Client
+	slackClient SlackClient
+	config      config.Config
+}
+
+// serveHTTP runs the direct HTTP mode on config.HTTPAddr until ctx is done.
+func serveHTTP(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	s := &slackHTTPServer{ctx: ctx, rdb: rdb, slackClient: slackClient, config: config}
+	srv := &http.Server{
+		Addr:              config.HTTPAddr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logging.WarnContext(ctx, "Error shutting down HTTP server: %v", err)
+		}
+	}()
+
+	logging.InfoContext(ctx, "Listening for Slack requests on %s", config.HTTPAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.ErrorContext(ctx, "HTTP server stopped: %v", err)
+	}
+}
+
+// routes returns the HTTP handler for the Slack endpoints.
+func (s *slackHTTPServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /slack/commands", s.verified(s.handleCommand))
+	mux.HandleFunc("POST /slack/interactivity", s.verified(s.handleInteractivity))
+	mux.HandleFunc("POST /slack/events", s.verified(s.handleEvent))
+	return mux
+}
+
+// verified reads the request body and rejects it unless its X-Slack-Signature
+// matches the signing secret and its timestamp is recent.
+func (s *slackHTTPServer) verified(next func(http.ResponseWriter, []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sv, err := slack.NewSecretsVerifier(r.Header, s.config.SlackSigningSecret)
+		if err != nil {
+			logging.Warn("Rejected Slack request to %s: %v", r.URL.Path, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(io.TeeReader(http.MaxBytesReader(w, r.Body, maxSlackRequestBody), &sv))
+		if err != nil {
+			http.Error(w, "unreadable body", http.StatusBadRequest)
+			return
+		}
+		if err := sv.Ensure(); err != nil {
+			logging.Warn("Rejected Slack request to %s: %v", r.URL.Path, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		next(w, body)
+	}
+}
+
+// handleCommand converts a form-encoded slash command into the relay's JSON
+// shape and routes it.
+func (s *slackHTTPServer) handleCommand(w http.ResponseWriter, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	routeSlashCommand(s.ctx, s.rdb, s.slackClient, SlackCommand{
+		Command:     form.Get("command"),
+		Text:        form.Get("text"),
+		ResponseURL: form.Get("response_url"),
+		TriggerID:   form.Get("trigger_id"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		ChannelID:   form.Get("channel_id"),
+		TeamID:      form.Get("team_id"),
+	}, s.config)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleInteractivity routes the form-encoded interactivity payload, writing
+// the synchronous response for external select options.
+func (s *slackHTTPServer) handleInteractivity(w http.ResponseWriter, body []byte) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	if resp := routeInteraction(s.ctx, s.rdb, s.slackClient, form.Get("payload"), s.config); resp != nil {
+		writeJSON(w, resp)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleEvent answers the Events API URL verification and routes callbacks.
+func (s *slackHTTPServer) handleEvent(w http.ResponseWriter, body []byte) {
+	var verification struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(body, &verification); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	if verification.Type == "url_verification" {
+		writeJSON(w, map[string]string{"challenge": verification.Challenge})
+		return
+	}
+	routeEvent(s.ctx, s.rdb, s.slackClient, body, s.config)
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeJSON writes v as a JSON response body.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Error("Error writing HTTP response: %v", err)
+	}
+}
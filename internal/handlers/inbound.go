@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// The direct transports (HTTP and Socket Mode) receive Slack payloads without
+// the relay and feed them to the same handlers the Redis subscriptions use.
+// Handlers run in the background so Slack is acknowledged within its
+// three-second deadline.
+
+// routeSlashCommand hands a slash command received directly from Slack to
+// handleSlashCommand in the relay's JSON shape.
+func routeSlashCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, config config.Config) {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling slash command: %v", err)
+		return
+	}
+	go handleSlashCommand(ctx, rdb, slackClient, string(payload), config)
+}
+
+// routeInteraction routes an interactivity payload by type. Options requests
+// for external selects are answered synchronously: the returned value is the
+// response body, and is nil for every other type.
+func routeInteraction(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) interface{} {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling interaction payload: %v", err)
+		return nil
+	}
+
+	switch envelope.Type {
+	case "block_suggestion":
+		var suggestion BlockSuggestionPayload
+		if err := json.Unmarshal([]byte(payload), &suggestion); err != nil {
+			logging.ErrorContext(ctx, "Error unmarshaling block suggestion: %v", err)
+			return nil
+		}
+		// slack.OptionsResponse omits empty options, but Slack expects the
+		// field even when nothing matches.
+		options, _ := suggestOptions(ctx, rdb, suggestion, config)
+		if options == nil {
+			options = []*slack.OptionBlockObject{}
+		}
+		return map[string]interface{}{"options": options}
+	case "view_submission":
+		go handleViewSubmission(ctx, rdb, slackClient, payload, config)
+	case "block_actions", messageShortcutType:
+		go handleBlockAction(ctx, rdb, slackClient, payload, config)
+	default:
+		logging.DebugContext(ctx, "Ignoring interaction payload of type %q", envelope.Type)
+	}
+	return nil
+}
+
+// routeEvent routes the app_home_opened, link_shared, and reaction_added
+// events of an Events API event_callback envelope.
+func routeEvent(ctx context.Context, rdb *redis.Client, slackClient SlackClient, envelope []byte, config config.Config) {
+	var callback struct {
+		Type  string          `json:"type"`
+		Event json.RawMessage `json:"event"`
+	}
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(envelope, &callback); err != nil || callback.Type != "event_callback" {
+		return
+	}
+	if err := json.Unmarshal(callback.Event, &event); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling event: %v", err)
+		return
+	}
+
+	switch event.Type {
+	case "app_home_opened":
+		go handleAppHomeOpened(ctx, rdb, slackClient, string(callback.Event), config)
+	case "link_shared":
+		go handleLinkShared(ctx, rdb, slackClient, string(callback.Event), config)
+	case "reaction_added":
+		go handleReactionAdded(ctx, rdb, slackClient, string(callback.Event), config)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+)
+
+const (
+	// delayedJobsKey is a sorted set of delayedJobs, sealed like PR
+	// sessions since payloads carry PR titles and bodies, scored by when
+	// they are due.
+	delayedJobsKey = "slashvibepr:jobs"
+	// delayedJobsInterval is how often each replica looks for due jobs.
+	delayedJobsInterval = 30 * time.Second
+	// maxDueJobs bounds the jobs one replica claims per tick.
+	maxDueJobs = 100
+)
+
+// delayedJob is one entry of the delayed-job queue: Payload is handed to the
+// handler registered for Type in delayedJobHandlers once Due has passed.
+type delayedJob struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Due     time.Time       `json:"due"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// delayedJobHandlers runs each type of delayed job.
+var delayedJobHandlers = map[string]func(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload json.RawMessage, config config.Config) error{
+	remindMeJobType:      runRemindMeJob,
+	scheduledPostJobType: runScheduledPostJob,
+}
+
+// scheduleJob queues payload to be run by the jobType handler at due.
+func scheduleJob(ctx context.Context, rdb *redis.Client, jobType string, due time.Time, payload interface{}) error {
+	id, err := randomHex(8)
+	if err != nil {
+		return fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+	job, err := session.Seal(delayedJob{ID: id, Type: jobType, Due: due, Payload: data})
+	if err != nil {
+		return fmt.Errorf("failed to seal job: %w", err)
+	}
+	if err := rdb.ZAdd(ctx, delayedJobsKey, redis.Z{Score: float64(due.Unix()), Member: job}).Err(); err != nil {
+		return fmt.Errorf("failed to schedule job: %w", err)
+	}
+	return nil
+}
+
+// claimDueJobs removes and returns up to maxDueJobs jobs due by now. Each
+// job is claimed by whichever replica removes it from the set first, so
+// every replica may poll without running a job twice.
+func claimDueJobs(ctx context.Context, rdb *redis.Client, now time.Time) ([]delayedJob, error) {
+	members, err := rdb.ZRangeByScore(ctx, delayedJobsKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: maxDueJobs,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read due jobs: %w", err)
+	}
+	var jobs []delayedJob
+	for _, member := range members {
+		removed, err := rdb.ZRem(ctx, delayedJobsKey, member).Result()
+		if err != nil {
+			return jobs, fmt.Errorf("failed to claim job: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+		var job delayedJob
+		if err := session.Open(member, &job); err != nil {
+			logging.WarnContext(ctx, "Dropping unreadable delayed job: %v", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// runDueJobs claims and runs the jobs due by now. A failed job is logged and
+// not retried.
+func runDueJobs(ctx context.Context, rdb *redis.Client, slackClient SlackClient, now time.Time, config config.Config) {
+	jobs, err := claimDueJobs(ctx, rdb, now)
+	if err != nil {
+		logging.WarnContext(ctx, "Error claiming delayed jobs: %v", err)
+	}
+	for _, job := range jobs {
+		run, ok := delayedJobHandlers[job.Type]
+		if !ok {
+			logging.WarnContext(ctx, "Dropping delayed job %s of unknown type %q", job.ID, job.Type)
+			continue
+		}
+		if err := run(ctx, rdb, slackClient, job.Payload, config); err != nil {
+			logging.ErrorContext(ctx, "Error running delayed %s job %s: %v", job.Type, job.ID, err)
+		}
+	}
+}
+
+// runDelayedJobs polls the delayed-job queue every delayedJobsInterval until
+// ctx is cancelled.
+func runDelayedJobs(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	ticker := time.NewTicker(delayedJobsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			runDueJobs(ctx, rdb, slackClient, now, config)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// leaderKey holds the ID of the replica running the scheduled jobs.
+	leaderKey = "slashvibepr:leader"
+	// leaderTTL is how long leadership lasts without a heartbeat, and so
+	// how soon another replica takes over from one that died.
+	leaderTTL = 15 * time.Second
+	// leaderHeartbeat is how often the leader renews leaderKey and the
+	// other replicas try to take it.
+	leaderHeartbeat = 5 * time.Second
+)
+
+// renewLeaderScript extends the leader key only while it still names the
+// caller, so a replica that lost leadership cannot extend its successor's.
+var renewLeaderScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// leaderElection tracks whether this replica is the one that runs the
+// scheduled jobs (stale PR reminders and digests).
+type leaderElection struct {
+	rdb    *redis.Client
+	id     string
+	leader atomic.Bool
+}
+
+// newLeaderElection returns an election for this replica, identified by its
+// redis.consumer_name plus a random suffix so two replicas sharing a name
+// never both believe they lead.
+func newLeaderElection(rdb *redis.Client, config config.Config) (*leaderElection, error) {
+	suffix, err := randomHex(4)
+	if err != nil {
+		return nil, err
+	}
+	id := suffix
+	if config.RedisConsumerName != "" {
+		id = config.RedisConsumerName + "-" + suffix
+	}
+	return &leaderElection{rdb: rdb, id: id}, nil
+}
+
+// IsLeader reports whether this replica currently holds leadership. A nil
+// election always leads, for single-replica callers and tests.
+func (l *leaderElection) IsLeader() bool {
+	return l == nil || l.leader.Load()
+}
+
+// run campaigns until ctx is done: the leader renews leaderKey every
+// leaderHeartbeat, the others try to take it with SET NX. Leadership is given
+// up on shutdown so another replica takes over at its next heartbeat.
+func (l *leaderElection) run(ctx context.Context) {
+	ticker := time.NewTicker(leaderHeartbeat)
+	defer ticker.Stop()
+	for {
+		l.heartbeat(ctx)
+		select {
+		case <-ctx.Done():
+			l.resign(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// heartbeat renews leadership if held, or tries to take it otherwise.
+func (l *leaderElection) heartbeat(ctx context.Context) {
+	was := l.leader.Load()
+	var is bool
+	var err error
+	if was {
+		var renewed int64
+		renewed, err = renewLeaderScript.Run(ctx, l.rdb, []string{leaderKey}, l.id, leaderTTL.Milliseconds()).Int64()
+		is = renewed == 1
+	} else {
+		is, err = l.rdb.SetNX(ctx, leaderKey, l.id, leaderTTL).Result()
+	}
+	if err != nil {
+		// Without Redis nobody can confirm leadership; step down rather than
+		// risk running the jobs twice.
+		logging.WarnContext(ctx, "Error in leader election heartbeat: %v", err)
+		is = false
+	}
+	l.leader.Store(is)
+	switch {
+	case is && !was:
+		logging.InfoContext(ctx, "Became leader (%s) for scheduled jobs", l.id)
+	case !is && was:
+		logging.WarnContext(ctx, "Lost leadership (%s) for scheduled jobs", l.id)
+	}
+}
+
+// resign releases leaderKey if this replica still holds it.
+func (l *leaderElection) resign(ctx context.Context) {
+	if !l.leader.Swap(false) {
+		return
+	}
+	if err := deleteIfOwnerScript.Run(ctx, l.rdb, []string{leaderKey}, l.id).Err(); err != nil {
+		logging.WarnContext(ctx, "Error resigning leadership: %v", err)
+	}
+}
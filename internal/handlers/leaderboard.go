@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	leaderboardUsage = ":warning: Usage: `/pr leaderboard [--public]`"
+	// leaderboardDays is the window the leaderboard ranks reviewers over.
+	leaderboardDays = 7
+	// leaderboardSize is how many reviewers the leaderboard lists.
+	leaderboardSize = 10
+	// leaderboardClaimKeyPrefix marks the scheduled leaderboard as posted
+	// for a given minute so only one replica posts it.
+	leaderboardClaimKeyPrefix = "slashvibepr:leaderboard:"
+	leaderboardClaimTTL       = 2 * time.Hour
+)
+
+// leaderboardMedals decorate the top three reviewers.
+var leaderboardMedals = []string{":first_place_medal:", ":second_place_medal:", ":third_place_medal:"}
+
+// handleLeaderboardCommand implements /pr leaderboard: the reviewers with
+// the most approvals and claimed reviews over the last leaderboardDays days,
+// answered ephemerally or posted to the channel with --public.
+func handleLeaderboardCommand(ctx context.Context, rdb *redis.Client, _ SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to leaderboard for user %s: %v", cmd.UserName, err)
+		}
+	}
+
+	public := false
+	switch {
+	case len(fields) == 1 && fields[0] == "--public":
+		public = true
+	case len(fields) != 0:
+		reply(leaderboardUsage)
+		return
+	}
+
+	scores, err := loadReviewerLeaderboard(ctx, rdb, time.Now(), leaderboardDays, leaderboardSize)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading reviewer leaderboard: %v", err)
+		reply(":x: Could not load the leaderboard. Please try again.")
+		return
+	}
+	text := buildLeaderboardText(scores)
+	if !public {
+		reply(text)
+		return
+	}
+
+	inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID}
+	if isDryRun(inv, config) {
+		logging.InfoContext(ctx, "[dry-run] Leaderboard for %s not pushed", cmd.ChannelID)
+		reply(":test_tube: *Dry run* — this leaderboard would have been posted:\n\n" + text)
+		return
+	}
+	if err := postLeaderboard(ctx, rdb, cmd.ChannelID, text, cmd.UserName, config); err != nil {
+		logging.ErrorContext(ctx, "Error pushing leaderboard for %s: %v", cmd.ChannelID, err)
+		reply(":x: Failed to post the leaderboard. Please try again.")
+		return
+	}
+	logging.InfoContext(ctx, "User %s posted the leaderboard to %s", cmd.UserName, cmd.ChannelID)
+}
+
+// runLeaderboard posts the leaderboard to leaderboard.channel whenever its
+// cron expression matches, checking at the top of every minute until ctx is
+// cancelled and skipping minutes while this replica is not the leader. It
+// does nothing when no channel is configured.
+func runLeaderboard(ctx context.Context, rdb *redis.Client, leader *leaderElection, config config.Config) {
+	if config.LeaderboardChannel == "" {
+		return
+	}
+	logging.InfoContext(ctx, "Reviewer leaderboard enabled for %s", config.LeaderboardChannel)
+
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case t := <-timer.C:
+			if leader.IsLeader() {
+				runDueLeaderboard(ctx, rdb, t.In(config.LeaderboardTimezone).Truncate(time.Minute), config)
+			}
+		}
+	}
+}
+
+// runDueLeaderboard posts the leaderboard when minute matches its schedule
+// and no other replica has claimed that minute.
+func runDueLeaderboard(ctx context.Context, rdb *redis.Client, minute time.Time, config config.Config) {
+	if !config.LeaderboardSchedule.Matches(minute) {
+		return
+	}
+	ok, err := rdb.SetNX(ctx, fmt.Sprintf("%s%d", leaderboardClaimKeyPrefix, minute.Unix()), 1, leaderboardClaimTTL).Result()
+	if err != nil {
+		logging.ErrorContext(ctx, "Error claiming leaderboard for %s: %v", config.LeaderboardChannel, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	config = withConfigOverrides(ctx, rdb, config)
+	scores, err := loadReviewerLeaderboard(ctx, rdb, minute, leaderboardDays, leaderboardSize)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading reviewer leaderboard: %v", err)
+		return
+	}
+	text := buildLeaderboardText(scores)
+	if config.DryRun {
+		logging.InfoContext(ctx, "[dry-run] Leaderboard for %s not pushed: %s", config.LeaderboardChannel, text)
+		return
+	}
+	if err := postLeaderboard(ctx, rdb, config.LeaderboardChannel, text, "", config); err != nil {
+		logging.ErrorContext(ctx, "Error pushing leaderboard for %s: %v", config.LeaderboardChannel, err)
+		return
+	}
+	logging.InfoContext(ctx, "Posted reviewer leaderboard of %d reviewers to %s", len(scores), config.LeaderboardChannel)
+}
+
+// postLeaderboard pushes the leaderboard text to channel through SlackLiner.
+// requestedBy is empty for the scheduled leaderboard.
+func postLeaderboard(ctx context.Context, rdb *redis.Client, channel, text, requestedBy string, config config.Config) error {
+	eventPayload := map[string]interface{}{"days": leaderboardDays}
+	if requestedBy != "" {
+		eventPayload["requested_by"] = requestedBy
+	}
+	msg := SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            channel,
+		Text:               text,
+		TTL:                config.MessageTTL(),
+		Metadata: map[string]interface{}{
+			"event_type":    "pr_leaderboard",
+			"event_payload": eventPayload,
+		},
+	}
+	stampSlackLinerMetadata(ctx, &msg)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal leaderboard: %w", err)
+	}
+	return pushSlackLiner(ctx, rdb, config, payload)
+}
+
+// buildLeaderboardText ranks reviewers by approvals plus claimed reviews,
+// e.g. "1. :first_place_medal: <@U1> — 5 reviews (3 approved, 2 claimed)".
+func buildLeaderboardText(scores []ReviewerScore) string {
+	if len(scores) == 0 {
+		return fmt.Sprintf(":seedling: No reviews approved or claimed in the last %d days yet. Pick one up from `/pr board`!", leaderboardDays)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, ":trophy: *Reviewer leaderboard* — the last %d days", leaderboardDays)
+	for i, s := range scores {
+		medal := ""
+		if i < len(leaderboardMedals) {
+			medal = leaderboardMedals[i] + " "
+		}
+		noun := "reviews"
+		if s.Total() == 1 {
+			noun = "review"
+		}
+		fmt.Fprintf(&b, "\n%d. %s<@%s> — %d %s (%d approved, %d claimed)", i+1, medal, s.UserID, s.Total(), noun, s.Approvals, s.Claims)
+	}
+	b.WriteString("\nThanks for reviewing! :raised_hands:")
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+)
+
+const (
+	poppitPRMergeType   = "slash-vibe-pr-merge"
+	postApproveActionID = "post_approve"
+	postMergeActionID   = "post_merge"
+	// clickedPostKeyPrefix holds the post an Approve or Merge button was
+	// clicked on until the gh result comes back and the post is edited.
+	clickedPostKeyPrefix = "slashvibepr:clicked-post:"
+	// clickedPostTTL is how long Slack honours a block action's
+	// response_url, past which the post can no longer be edited.
+	clickedPostTTL = 30 * time.Minute
+)
+
+// clickedPost is the message an Approve or Merge button was clicked on.
+// Blocks holds the JSON-encoded slack.Blocks.
+type clickedPost struct {
+	Text   string `json:"text"`
+	Blocks []byte `json:"blocks"`
+}
+
+// clickedPostKey returns the Redis key holding a clicked post.
+func clickedPostKey(token string) string {
+	return clickedPostKeyPrefix + token
+}
+
+// approveButton returns the "Approve" button for a posted PR; its value is
+// the PR's postRef.
+func approveButton(repo string, number int) *slack.ButtonBlockElement {
+	return slack.NewButtonBlockElement(postApproveActionID, postRef(repo, number),
+		slack.NewTextBlockObject(slack.PlainTextType, "✅ Approve", false, false)).
+		WithStyle(slack.StylePrimary).
+		WithConfirm(slack.NewConfirmationBlockObject(
+			slack.NewTextBlockObject(slack.PlainTextType, "Approve this PR?", false, false),
+			slack.NewTextBlockObject(slack.MarkdownType,
+				fmt.Sprintf("This submits an approving review of *%s#%d* on GitHub, noting that you approved it from Slack.", repo, number), false, false),
+			slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false),
+			slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		))
+}
+
+// mergeButton returns the "Merge" button for a posted PR; its value is the
+// PR's postRef.
+func mergeButton(repo string, number int, method string) *slack.ButtonBlockElement {
+	return slack.NewButtonBlockElement(postMergeActionID, postRef(repo, number),
+		slack.NewTextBlockObject(slack.PlainTextType, "🔀 Merge", false, false)).
+		WithStyle(slack.StyleDanger).
+		WithConfirm(slack.NewConfirmationBlockObject(
+			slack.NewTextBlockObject(slack.PlainTextType, "Merge this PR?", false, false),
+			slack.NewTextBlockObject(slack.MarkdownType,
+				fmt.Sprintf("This merges *%s#%d* into its base branch (`%s`). It cannot be undone from Slack.", repo, number, method), false, false),
+			slack.NewTextBlockObject(slack.PlainTextType, "Merge", false, false),
+			slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		).WithStyle(slack.StyleDanger))
+}
+
+// buildPRMergeCommand returns the gh invocation merging a PR with method,
+// one of the config.MergeMethod values.
+func buildPRMergeCommand(repo string, number int, method string) string {
+	return fmt.Sprintf("gh pr merge %d --repo %s --%s", number, repo, method)
+}
+
+// handleApproveMergeClick queues the gh approval or merge behind a posted
+// PR's "Approve" or "Merge" button. Only privileged users may click them;
+// handlePRApproveOutput and handlePRMergeOutput report the result and edit
+// the post.
+func handleApproveMergeClick(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, actionID, value string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, action.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to %s for user %s: %v", actionID, action.User.Username, err)
+		}
+	}
+
+	repo, number, err := parsePostRef(value)
+	if err != nil {
+		logging.WarnContext(ctx, "Ignoring %s from user %s: %v", actionID, action.User.Username, err)
+		return
+	}
+	if !isPrivileged(ctx, rdb, slackClient, action.User.ID, config) {
+		logging.WarnContext(ctx, "Denied %s of %s#%d to unprivileged user %s", actionID, repo, number, action.User.Username)
+		reply(":no_entry: Only privileged users can approve or merge PRs from Slack. Ask an admin to run `/pr admin grant`.")
+		return
+	}
+
+	inv := Invocation{UserID: action.User.ID, Username: action.User.Username, TeamID: action.Team.ID}
+	login := lookupGitHubLogin(ctx, rdb, action.User.ID, config)
+	cmdType, verb := poppitPRApproveType, "approval"
+	ghCmd := buildPRApproveCommand(repo, number, approveReviewBody("", action.User.Username, login))
+	if actionID == postMergeActionID {
+		cmdType, verb = poppitPRMergeType, "merge"
+		ghCmd = buildPRMergeCommand(repo, number, config.GitHubMergeMethod)
+	}
+
+	if isDryRun(inv, config) {
+		logging.InfoContext(ctx, "[dry-run] %s of %s#%d by %s not queued: %s", capitalize(verb), repo, number, action.User.Username, ghCmd)
+		reply(fmt.Sprintf(":test_tube: *Dry run* — would run:\n```%s```", ghCmd))
+		return
+	}
+
+	metadata := inv.metadata()
+	metadata["repo"] = repo
+	metadata["number"] = number
+	metadata["response_url"] = action.ResponseURL
+	metadata["channel"] = action.Channel.ID
+	if token, err := saveClickedPost(ctx, rdb, action); err != nil {
+		logging.WarnContext(ctx, "Error saving post of %s#%d, it will not be updated: %v", repo, number, err)
+	} else if token != "" {
+		metadata["post_token"] = token
+	}
+
+	if cmdType == poppitPRApproveType {
+		err = queueApproval(ctx, rdb, repo, number, login, metadata, config)
+	} else {
+		err = pushPoppitCommand(ctx, rdb, poppit.Command{
+			Repo:     repo,
+			Type:     cmdType,
+			Dir:      "/tmp",
+			Commands: []string{ghCmd},
+			Metadata: metadata,
+		}, config)
+	}
+	if err != nil {
+		logging.ErrorContext(ctx, "Error queueing %s of %s#%d: %v", verb, repo, number, err)
+		reply(fmt.Sprintf(":x: Failed to queue the %s. Please try again.", verb))
+		return
+	}
+	logging.InfoContext(ctx, "User %s requested %s of %s#%d from its post", action.User.Username, verb, repo, number)
+}
+
+// saveClickedPost stores the message a button was clicked on under a random
+// token, returning "" when the message has no blocks to edit.
+func saveClickedPost(ctx context.Context, rdb *redis.Client, action BlockActionPayload) (string, error) {
+	if len(action.Message.Blocks.BlockSet) == 0 {
+		return "", nil
+	}
+	blocks, err := json.Marshal(action.Message.Blocks)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal blocks: %w", err)
+	}
+	token, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	if err := session.Save(ctx, rdb, clickedPostKey(token), clickedPost{Text: action.Message.Text, Blocks: blocks}, clickedPostTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// updateClickedPost replaces the post saved under the post_token in
+// metadata with a copy annotated with note and without the remove actions.
+// Outputs without a token leave the post alone.
+func updateClickedPost(ctx context.Context, rdb *redis.Client, metadata map[string]interface{}, note string, remove ...string) error {
+	token, _ := metadata["post_token"].(string)
+	responseURL, _ := metadata["response_url"].(string)
+	if token == "" || responseURL == "" {
+		return nil
+	}
+	var post clickedPost
+	if err := session.Load(ctx, rdb, clickedPostKey(token), &post); err != nil {
+		return fmt.Errorf("failed to load post: %w", err)
+	}
+	var blocks slack.Blocks
+	if err := json.Unmarshal(post.Blocks, &blocks); err != nil {
+		return fmt.Errorf("failed to parse post blocks: %w", err)
+	}
+	if err := slack.PostWebhookContext(ctx, responseURL, &slack.WebhookMessage{
+		ReplaceOriginal: true,
+		Text:            post.Text,
+		Blocks:          &slack.Blocks{BlockSet: annotatePostBlocks(blocks.BlockSet, note, remove...)},
+	}); err != nil {
+		return fmt.Errorf("failed to update post: %w", err)
+	}
+	if err := rdb.Del(ctx, clickedPostKey(token)).Err(); err != nil {
+		logging.WarnContext(ctx, "Error deleting clicked post %s: %v", token, err)
+	}
+	return nil
+}
+
+// handlePRMergeOutput confirms a merge to the user who clicked "Merge" and
+// replaces the post's actions with a note saying who merged it.
+func handlePRMergeOutput(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	repo, _ := output.Metadata["repo"].(string)
+	number := 0
+	if n, ok := output.Metadata["number"].(float64); ok {
+		number = int(n)
+	}
+	responseURL, _ := output.Metadata["response_url"].(string)
+	channel, _ := output.Metadata["channel"].(string)
+	inv := invocationFromMetadata(output.Metadata)
+
+	if repo == "" || number == 0 {
+		logging.WarnContext(ctx, "Missing repo or number in Poppit merge metadata")
+		return
+	}
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Merge of %s#%d failed: %s", repo, number, output.ErrorText())
+		msg := fmt.Sprintf(":x: Could not merge `%s#%d`:\n```%s```", repo, number, output.ErrorText())
+		if err := respondEphemeral(ctx, responseURL, msg); err != nil {
+			logging.ErrorContext(ctx, "Error sending merge feedback: %v", err)
+		}
+		return
+	}
+
+	logging.InfoContext(ctx, "User %s merged %s#%d", inv.Username, repo, number)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   auditActionMerge,
+		UserID:   inv.UserID,
+		Username: inv.Username,
+		Repo:     repo,
+		Number:   number,
+		Channel:  channel,
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing merge of %s#%d: %v", repo, number, err)
+	}
+	if err := updateClickedPost(ctx, rdb, output.Metadata, fmt.Sprintf(":twisted_rightwards_arrows: Merged by <@%s>", inv.UserID),
+		postApproveActionID, postMergeActionID, postClaimActionID, postRemindActionID); err != nil {
+		logging.WarnContext(ctx, "Error marking %s#%d as merged: %v", repo, number, err)
+	}
+	if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":twisted_rightwards_arrows: Merged `%s#%d`.", repo, number)); err != nil {
+		logging.ErrorContext(ctx, "Error sending merge feedback: %v", err)
+	}
+}
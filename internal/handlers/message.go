@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/version"
+)
+
+// defaultMessageTemplate reproduces the original hardcoded PR message and is
+// used whenever slack.message_template is not set.
+const defaultMessageTemplate = `{{.Emoji}} *{{if .Status}}{{.Status}} pull request{{else}}Pull Request{{end}} shared by @{{.PostedBy}}*
+
+*Repository:* {{.Repo}}
+*PR #{{.PR.Number}}:* {{.PR.Title}}
+*Author:* {{.Author}}{{if .DiffStats}}
+*Changes:* {{.DiffStats}}{{end}}{{if .Readiness}}
+*Readiness:* {{.Readiness}}{{end}}{{if .Conflicts}}
+:warning: *Has conflicts* — the author needs to rebase first{{end}}{{if .SuggestedReviewer}}
+*Suggested reviewer:* {{.SuggestedReviewer}}{{end}}{{if .Reviewers}}
+*Reviewers:* {{.Reviewers}}{{end}}
+*Link:* <{{.PR.URL}}|View PR>{{if .Description}}
+
+{{quote .Description}}{{end}}{{if .Note}}
+
+*Note:*
+{{quote .Note}}{{end}}`
+
+// defaultHeaderEmoji starts the default layout when slack.branding.emoji is
+// unset.
+const defaultHeaderEmoji = config.DefaultHeaderEmoji
+
+// postOpenActionID identifies the "Open in GitHub" button on posts laid out
+// by the block_kit_posts feature.
+const postOpenActionID = "post_open"
+
+// messageTemplateFuncs are available to message templates in addition to the
+// text/template builtins.
+var messageTemplateFuncs = template.FuncMap{
+	"quote":  quoteSlackText,
+	"escape": escapeSlackText,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+}
+
+// MessageTemplateData is the value message templates are executed against.
+type MessageTemplateData struct {
+	// Emoji is slack.branding.emoji, which starts the default layout.
+	Emoji    string
+	PR       *PRItem
+	Repo     string
+	PostedBy string
+	Note     string
+	// Author is a Slack mention of the PR author when a user mapping exists,
+	// otherwise their GitHub login.
+	Author string
+	// Readiness summarises merge state, checks, and review threads when
+	// they were fetched; empty otherwise.
+	Readiness string
+	// SuggestedReviewer is a Slack mention (or @login) of the reviewer picked
+	// from the repo's reviewer pool; empty when no pool is configured.
+	SuggestedReviewer string
+	// Reviewers lists the requested reviewers, e.g. "<@U123>, @bob,
+	// @backend-team"; empty when no review was requested.
+	Reviewers string
+	// Description is the start of the PR description when
+	// slack.description_snippet is on, as plain text; empty otherwise.
+	Description string
+	// DiffStats is e.g. "+120 −45 across 7 files"; empty when the size of
+	// the change was not fetched.
+	DiffStats string
+	// Conflicts is set when GitHub reports merge conflicts with the base
+	// branch.
+	Conflicts bool
+	// Status is "Merged" or "Closed" for PRs that are no longer open, and
+	// empty otherwise.
+	Status string
+}
+
+// prStatus returns the Status of pr for message templates.
+func prStatus(pr *PRItem) string {
+	switch pr.State {
+	case "MERGED":
+		return "Merged"
+	case "CLOSED":
+		return "Closed"
+	default:
+		return ""
+	}
+}
+
+// parseMessageTemplate compiles a message template, falling back to the
+// default layout when text is empty.
+func parseMessageTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultMessageTemplate
+	}
+	return template.New("message").Funcs(messageTemplateFuncs).Parse(text)
+}
+
+// renderMessageText executes the configured message template. If the
+// template fails at runtime the default layout is used so a bad template
+// never blocks a post.
+func renderMessageText(data MessageTemplateData, config config.Config) string {
+	text, err := executeMessageTemplate(config.SlackMessageTemplate, data)
+	if err != nil {
+		logging.Warn("Error rendering message template, using default: %v", err)
+		text, _ = executeMessageTemplate("", data)
+	}
+	return text
+}
+
+// executeMessageTemplate parses and executes a single template.
+func executeMessageTemplate(text string, data MessageTemplateData) (string, error) {
+	tmpl, err := parseMessageTemplate(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse message template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute message template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// buildPRMessage formats the SlackLiner message announcing a shared PR.
+// An optional note from the poster is quoted below the PR details.
+func buildPRMessage(pr *PRItem, repo, postedBy string, post PostOptions, config config.Config) SlackLinerMessage {
+	messageText := renderMessageText(MessageTemplateData{
+		Emoji:             headerEmoji(config),
+		PR:                pr,
+		Repo:              repo,
+		PostedBy:          postedBy,
+		Note:              post.Note,
+		Author:            authorDisplay(pr, post.AuthorSlackID),
+		Readiness:         readinessSummary(pr),
+		DiffStats:         pr.diffStats(),
+		Description:       postedDescription(pr, config),
+		SuggestedReviewer: reviewerDisplay(post.SuggestedReviewer, post.SuggestedReviewerSlackID),
+		Reviewers:         requestedReviewersDisplay(pr, post.ReviewerSlackIDs),
+		Conflicts:         pr.hasConflicts(),
+		Status:            prStatus(pr),
+	}, config)
+
+	payload := map[string]interface{}{
+		"pr_number":  pr.Number,
+		"repository": repo,
+		"pr_url":     pr.URL,
+		"author":     pr.Author.Login,
+		"title":      pr.Title,
+		"posted_by":  postedBy,
+		"branch":     pr.HeadRefName,
+	}
+	if post.Note != "" {
+		payload["note"] = post.Note
+	}
+	if post.AuthorSlackID != "" {
+		payload["author_slack_id"] = post.AuthorSlackID
+	}
+	if post.ThreadKey != "" {
+		payload["thread_key"] = post.ThreadKey
+	}
+	if post.SuggestedReviewer != "" {
+		payload["suggested_reviewer"] = post.SuggestedReviewer
+	}
+	if pr.ReviewDecision != "" {
+		payload["review_decision"] = pr.ReviewDecision
+	}
+	if pr.State != "" {
+		payload["state"] = pr.State
+	}
+	if mergeable := pr.mergeable(); mergeable != "" {
+		payload["mergeable"] = mergeable
+	}
+	if status := pr.mergeStateStatus(); status != "" {
+		payload["merge_state_status"] = status
+	}
+	if pr.mergeable() != "" || pr.mergeStateStatus() != "" {
+		payload["has_conflicts"] = pr.hasConflicts()
+	}
+	if pr.diffStats() != "" {
+		payload["additions"] = pr.Additions
+		payload["deletions"] = pr.Deletions
+		payload["changed_files"] = pr.ChangedFiles
+	}
+	labels := pr.labelNames()
+	if len(labels) > 0 {
+		payload["labels"] = labels
+	}
+	if reviewers := pr.requestedReviewers(); len(reviewers) > 0 {
+		payload["reviewers"] = reviewers
+	}
+	if failing, required := pr.failingChecks(); len(failing) > 0 {
+		payload["failing_checks"] = failing
+		payload["failing_checks_required"] = required
+	}
+	if milestone := pr.milestoneTitle(); milestone != "" {
+		payload["milestone"] = milestone
+	}
+	projects := pr.projectTitles()
+	if len(projects) > 0 {
+		payload["projects"] = projects
+	}
+	if r := pr.Readiness; r != nil {
+		payload["review_threads"] = r.ReviewThreads
+		payload["unresolved_review_threads"] = r.UnresolvedThreads
+		conclusions := make(map[string]string, len(r.CheckSuites))
+		for _, s := range r.CheckSuites {
+			conclusions[s.Name] = s.Conclusion
+		}
+		payload["check_suites"] = conclusions
+	}
+
+	return SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            config.SlackChannelID,
+		Text:               messageText,
+		TTL:                config.MessageTTL(),
+		Key:                post.ThreadKey,
+		Blocks: prMessageBlocks(messageText, pr.URL, config.FeatureEnabled(featureBlockKitPosts), postActions(pr, repo, config),
+			prContext(labels, pr.milestoneTitle(), projects), failingChecksContext(pr)),
+		Metadata: map[string]interface{}{
+			"event_type":    "pr_posted",
+			"event_payload": payload,
+		},
+	}
+}
+
+// prContext returns the mrkdwn context line listing the PR's labels,
+// milestone, and projects, or "" when it has none of them.
+func prContext(labels []string, milestone string, projects []string) string {
+	var parts []string
+	if len(labels) > 0 {
+		parts = append(parts, ":label: "+escapeSlackText(strings.Join(labels, ", ")))
+	}
+	if milestone != "" {
+		parts = append(parts, ":dart: "+escapeSlackText(milestone))
+	}
+	if len(projects) > 0 {
+		parts = append(parts, ":card_index_dividers: "+escapeSlackText(strings.Join(projects, ", ")))
+	}
+	return strings.Join(parts, "  ·  ")
+}
+
+// prMessageBlocks lays the message out as a section followed by a context
+// block for each non-empty line of contextLines, such as the one built by
+// prContext, and an actions block holding actions, if any. Without context
+// lines or actions no blocks are needed and the plain text is posted as
+// before, unless the block_kit_posts feature is on, which always uses blocks
+// and adds an "Open in GitHub" button.
+func prMessageBlocks(text, url string, blockKit bool, actions []slack.BlockElement, contextLines ...string) []slack.Block {
+	var contexts []slack.Block
+	for _, line := range contextLines {
+		if line != "" {
+			contexts = append(contexts, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, line, false, false)))
+		}
+	}
+	if len(contexts) == 0 && len(actions) == 0 && !blockKit {
+		return nil
+	}
+	section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+	if blockKit && url != "" {
+		button := slack.NewButtonBlockElement(postOpenActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Open in GitHub", false, false))
+		button.URL = url
+		section.Accessory = slack.NewAccessory(button)
+	}
+	blocks := append([]slack.Block{section}, contexts...)
+	if len(actions) > 0 {
+		blocks = append(blocks, slack.NewActionBlock(postActionsBlockID, actions...))
+	}
+	return blocks
+}
+
+// postActions returns the interactive elements shown under a posted PR, as
+// enabled by feature flags.
+func postActions(pr *PRItem, repo string, config config.Config) []slack.BlockElement {
+	if pr.Number == 0 {
+		return nil
+	}
+	var actions []slack.BlockElement
+	// Only open PRs can still be reviewed.
+	if config.FeatureEnabled(featureClaimReview) && prStatus(pr) == "" {
+		actions = append(actions, claimReviewButton(repo, pr.Number))
+	}
+	if config.FeatureEnabled(featureApproveMerge) && prStatus(pr) == "" {
+		actions = append(actions, approveButton(repo, pr.Number), mergeButton(repo, pr.Number, config.GitHubMergeMethod))
+	}
+	if config.FeatureEnabled(featureRemindMe) {
+		actions = append(actions, remindMeSelect(repo, pr.Number))
+	}
+	return actions
+}
+
+// annotatePostBlocks returns a posted PR's blocks with the elements whose
+// action IDs are in remove taken out of the actions block, and a context
+// block holding note in its place.
+func annotatePostBlocks(blocks []slack.Block, note string, remove ...string) []slack.Block {
+	annotation := slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, note, false, false))
+	out := make([]slack.Block, 0, len(blocks)+1)
+	for _, block := range blocks {
+		actions, ok := block.(*slack.ActionBlock)
+		if !ok || actions.BlockID != postActionsBlockID {
+			out = append(out, block)
+			continue
+		}
+		var kept []slack.BlockElement
+		for _, element := range actions.Elements.ElementSet {
+			if !slices.Contains(remove, blockElementActionID(element)) {
+				kept = append(kept, element)
+			}
+		}
+		out = append(out, annotation)
+		annotation = nil
+		if len(kept) > 0 {
+			out = append(out, slack.NewActionBlock(postActionsBlockID, kept...))
+		}
+	}
+	if annotation != nil {
+		out = append(out, annotation)
+	}
+	return out
+}
+
+// blockElementActionID returns the action ID of the interactive elements
+// posted PRs carry, or "" for any other element.
+func blockElementActionID(element slack.BlockElement) string {
+	switch e := element.(type) {
+	case *slack.ButtonBlockElement:
+		return e.ActionID
+	case *slack.SelectBlockElement:
+		return e.ActionID
+	}
+	return ""
+}
+
+// postedDescription returns the description snippet for pr, or "" when
+// slack.description_snippet is off.
+func postedDescription(pr *PRItem, config config.Config) string {
+	if !config.SlackDescriptionSnippet {
+		return ""
+	}
+	return descriptionSnippet(pr.Body, config.SlackDescriptionLength)
+}
+
+// headerEmoji returns slack.branding.emoji, or the default when unset.
+func headerEmoji(config config.Config) string {
+	if config.SlackHeaderEmoji == "" {
+		return defaultHeaderEmoji
+	}
+	return config.SlackHeaderEmoji
+}
+
+// messageBranding returns the bot name and icon from slack.branding.
+func messageBranding(config config.Config) SlackLinerBranding {
+	return SlackLinerBranding{
+		Username:  config.SlackUsername,
+		IconEmoji: config.SlackIconEmoji,
+		IconURL:   config.SlackIconURL,
+	}
+}
+
+// maxDetailsBodyLength caps the PR description quoted in the thread follow-up.
+const maxDetailsBodyLength = 2500
+
+// buildPRMessages returns the main PR message followed, when
+// slack.thread_details is enabled and the post has a thread key, by a threaded
+// reply carrying the PR description, changed-files count, and labels.
+func buildPRMessages(pr *PRItem, repo, postedBy string, post PostOptions, config config.Config) []SlackLinerMessage {
+	msgs := []SlackLinerMessage{buildPRMessage(pr, repo, postedBy, post, config)}
+	if config.SlackThreadDetails && post.ThreadKey != "" {
+		msgs = append(msgs, buildPRDetailsMessage(pr, repo, post.ThreadKey, config))
+	}
+	return msgs
+}
+
+// buildPRDetailsMessage formats the threaded follow-up for a posted PR.
+func buildPRDetailsMessage(pr *PRItem, repo, threadKey string, config config.Config) SlackLinerMessage {
+	var b strings.Builder
+	b.WriteString("*Details*\n")
+	fmt.Fprintf(&b, "*Changed files:* %d\n", pr.ChangedFiles)
+
+	b.WriteString("*Labels:* ")
+	if names := pr.labelNames(); len(names) > 0 {
+		b.WriteString(escapeSlackText(strings.Join(names, ", ")))
+	} else {
+		b.WriteString("_none_")
+	}
+
+	body := strings.TrimSpace(pr.Body)
+	if body == "" {
+		b.WriteString("\n\n_No description provided._")
+	} else {
+		if runes := []rune(body); len(runes) > maxDetailsBodyLength {
+			body = string(runes[:maxDetailsBodyLength]) + "…"
+		}
+		b.WriteString("\n\n*Description:*\n")
+		b.WriteString(quoteSlackText(body))
+	}
+
+	return SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            config.SlackChannelID,
+		Text:               b.String(),
+		TTL:                config.MessageTTL(),
+		ThreadKey:          threadKey,
+		Metadata: map[string]interface{}{
+			"event_type": "pr_details_posted",
+			"event_payload": map[string]interface{}{
+				"pr_number":  pr.Number,
+				"repository": repo,
+			},
+		},
+	}
+}
+
+// newThreadKey returns a unique key identifying one posted PR message so
+// follow-ups can be threaded beneath it.
+func newThreadKey(repo string, number int) string {
+	return fmt.Sprintf("slashvibepr:%s#%d:%d", repo, number, time.Now().UnixNano())
+}
+
+// readinessSummary returns the readiness line for pr, or "" when none was fetched.
+func readinessSummary(pr *PRItem) string {
+	if pr.Readiness == nil {
+		return ""
+	}
+	return pr.Readiness.summary()
+}
+
+// authorDisplay renders the PR author as a Slack mention when mapped.
+func authorDisplay(pr *PRItem, slackID string) string {
+	if slackID != "" {
+		return fmt.Sprintf("<@%s>", slackID)
+	}
+	return pr.Author.Login
+}
+
+// requestedReviewersDisplay lists the PR's requested reviewers, each a Slack
+// mention when mapped in slackIDs and otherwise @login or @team-slug.
+func requestedReviewersDisplay(pr *PRItem, slackIDs map[string]string) string {
+	reviewers := pr.requestedReviewers()
+	for i, login := range reviewers {
+		reviewers[i] = reviewerDisplay(login, slackIDs[login])
+	}
+	return strings.Join(reviewers, ", ")
+}
+
+// escapeSlackText escapes the three characters mrkdwn treats as control
+// sequences in user-supplied text.
+func escapeSlackText(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// quoteSlackText escapes user-supplied text for mrkdwn and renders it as a
+// block quote, one "> " prefix per line.
+func quoteSlackText(text string) string {
+	return "> " + strings.ReplaceAll(escapeSlackText(text), "\n", "\n> ")
+}
+
+// buildField is the SlackLiner metadata field naming the build that posted a
+// message.
+const buildField = "build"
+
+// stampSlackLinerMetadata adds the request ID and trace context in ctx, and
+// the build that posted it, to the message's metadata event payload.
+// Messages without metadata are left alone.
+func stampSlackLinerMetadata(ctx context.Context, msg *SlackLinerMessage) {
+	payload, ok := msg.Metadata["event_payload"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if id := logging.RequestIDFrom(ctx); id != "" {
+		payload[logging.RequestIDField] = id
+	}
+	injectTraceContext(ctx, payload)
+	payload[buildField] = version.Get()
+}
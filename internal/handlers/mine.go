@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+// handleMineCommand implements /pr mine: it resolves the caller's GitHub
+// login and opens the PR chooser with their open PRs across the configured
+// organisations.
+func handleMineCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, config config.Config) {
+	login := linkedGitHubLogin(ctx, rdb, cmd, config)
+	if login == "" {
+		return
+	}
+	openPRSearch(ctx, rdb, slackClient, cmd, prSearch{Author: login}, config)
+}
+
+// linkedGitHubLogin returns the caller's linked GitHub login, telling them
+// how to link one and returning "" when there is none.
+func linkedGitHubLogin(ctx context.Context, rdb *redis.Client, cmd SlackCommand, config config.Config) string {
+	login := lookupGitHubLogin(ctx, rdb, cmd.UserID, config)
+	if login != "" && !validGitHubLogin.MatchString(login) {
+		logging.WarnContext(ctx, "Ignoring invalid GitHub login %q mapped to user %s", login, cmd.UserID)
+		login = ""
+	}
+	if login == "" {
+		reply := "You have not linked a GitHub account yet. Use `/pr whoami link <github-login>` first."
+		if err := respondEphemeral(ctx, cmd.ResponseURL, reply); err != nil {
+			logging.ErrorContext(ctx, "Error responding to user %s: %v", cmd.UserName, err)
+		}
+	}
+	return login
+}
+
+// openPRSearch opens the loading modal and starts a cross-repo search.
+func openPRSearch(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, search prSearch, config config.Config) {
+	viewResp, err := openView(ctx, slackClient, cmd.TriggerID, slackui.LoadingModal(), config)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error opening loading modal: %v", err)
+		return
+	}
+
+	logging.InfoContext(ctx, "Searching open PRs (%s) for user %s", search.label(), cmd.UserName)
+	inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID}
+	if err := newPRSource(rdb, slackClient, config).SearchPRs(ctx, search, viewResp.ID, inv); err != nil {
+		logging.ErrorContext(ctx, "Error searching PRs (%s): %v", search.label(), err)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// configOverridePrefix namespaces the runtime overrides set with
+// /pr admin set; each key is the prefix plus the config.yaml name.
+const configOverridePrefix = "slashvibepr:config:"
+
+// slackChannelIDPattern matches a public or private channel ID.
+var slackChannelIDPattern = regexp.MustCompile(`^[CG][A-Z0-9]+$`)
+
+// configOverrides are the settings that may be changed at runtime, keyed by
+// their config.yaml name. Each applies a value to c, rejecting it when
+// invalid.
+var configOverrides = map[string]func(c *config.Config, value string) error{
+	"github.org": func(c *config.Config, value string) error {
+		if !validOwnerName.MatchString(value) {
+			return fmt.Errorf("%q is not a valid organisation name", value)
+		}
+		if len(c.GitHubOrgs) > 0 && !c.HasOrg(value) {
+			return fmt.Errorf("%q is not listed in github.orgs", value)
+		}
+		c.GitHubOrg = value
+		return nil
+	},
+	"github.base_branch": func(c *config.Config, value string) error {
+		if !validBaseBranch.MatchString(value) {
+			return fmt.Errorf("%q is not a valid branch name", value)
+		}
+		c.GitHubBaseBranch = value
+		return nil
+	},
+	"github.hide_drafts": func(c *config.Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		c.GitHubHideDrafts = b
+		return nil
+	},
+	"slack.preview_posts": func(c *config.Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		c.SlackPreviewPosts = b
+		return nil
+	},
+	"slack.channel_id": func(c *config.Config, value string) error {
+		if !slackChannelIDPattern.MatchString(value) {
+			return fmt.Errorf("%q is not a Slack channel ID", value)
+		}
+		c.SlackChannelID = value
+		return nil
+	},
+	"slack.message_ttl": func(c *config.Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < time.Second {
+			return fmt.Errorf("%q is not a duration of at least 1s", value)
+		}
+		c.SlackMessageTTL = d
+		return nil
+	},
+	"limits.pr_list": func(c *config.Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > config.MaxPRLimit {
+			return fmt.Errorf("%q is not a number between 1 and %d", value, config.MaxPRLimit)
+		}
+		c.PRListLimit = n
+		return nil
+	},
+	"limits.session_ttl": func(c *config.Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%q is not a positive duration", value)
+		}
+		c.SessionTTL = d
+		return nil
+	},
+	"dry_run": func(c *config.Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		c.DryRun = b
+		return nil
+	},
+}
+
+// configOverrideKeys returns the overridable setting names, sorted.
+func configOverrideKeys() []string {
+	keys := make([]string, 0, len(configOverrides))
+	for k := range configOverrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loadConfigOverrides returns the overrides stored in Redis, by setting name.
+func loadConfigOverrides(ctx context.Context, rdb *redis.Client) (map[string]string, error) {
+	keys := configOverrideKeys()
+	redisKeys := make([]string, len(keys))
+	for i, k := range keys {
+		redisKeys[i] = configOverridePrefix + k
+	}
+	values, err := rdb.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config overrides: %w", err)
+	}
+	overrides := make(map[string]string)
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			overrides[keys[i]] = s
+		}
+	}
+	return overrides, nil
+}
+
+// withConfigOverrides returns config with the runtime overrides in Redis
+// applied on top of config.yaml, as last reloaded with /pr admin reload. It
+// is called as each interaction or scheduled job is handled, so a change
+// takes effect without a restart. When Redis cannot be read, or a stored
+// value is invalid, the YAML value stays in place.
+func withConfigOverrides(ctx context.Context, rdb *redis.Client, config config.Config) config.Config {
+	if rdb == nil {
+		return config
+	}
+	config = withReloadedConfig(ctx, rdb, config)
+	overrides, err := loadConfigOverrides(ctx, rdb)
+	if err != nil {
+		logging.WarnContext(ctx, "%v", err)
+		return config
+	}
+	for key, value := range overrides {
+		if err := configOverrides[key](&config, value); err != nil {
+			logging.WarnContext(ctx, "Ignoring config override %s: %v", key, err)
+		}
+	}
+	return config
+}
+
+// setConfigOverride validates value against config and stores it as the
+// override for key.
+func setConfigOverride(ctx context.Context, rdb *redis.Client, key, value string, config config.Config) error {
+	apply, ok := configOverrides[key]
+	if !ok {
+		return fmt.Errorf("%s cannot be overridden (try %s)", key, strings.Join(configOverrideKeys(), ", "))
+	}
+	if err := apply(&config, value); err != nil {
+		return err
+	}
+	if err := rdb.Set(ctx, configOverridePrefix+key, value, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store override: %w", err)
+	}
+	return nil
+}
+
+// unsetConfigOverride removes the override for key, restoring the
+// config.yaml value.
+func unsetConfigOverride(ctx context.Context, rdb *redis.Client, key string) error {
+	if _, ok := configOverrides[key]; !ok {
+		return fmt.Errorf("%s cannot be overridden", key)
+	}
+	n, err := rdb.Del(ctx, configOverridePrefix+key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to remove override: %w", err)
+	}
+	if n == 0 {
+		return errors.New(key + " is not overridden")
+	}
+	return nil
+}
+
+// formatConfigOverrides lists the active overrides for /pr admin config.
+func formatConfigOverrides(overrides map[string]string) string {
+	if len(overrides) == 0 {
+		return fmt.Sprintf("No config overrides are set. Settings that can be overridden: %s.", strings.Join(configOverrideKeys(), ", "))
+	}
+	var b strings.Builder
+	b.WriteString("*Config overrides* (remove with `/pr admin unset <key>`):")
+	for _, key := range configOverrideKeys() {
+		if value, ok := overrides[key]; ok {
+			fmt.Fprintf(&b, "\n• `%s` = `%s`", key, value)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	postedPRKeyPrefix = "slashvibepr:posted:"
+	// postedPRIndexKey is a sorted set of posted PR keys scored by post time,
+	// so jobs can scan recent posts without a Redis KEYS walk.
+	postedPRIndexKey = "slashvibepr:posted_index"
+	// postedChannelIndexPrefix prefixes a per-channel sorted set of the posted
+	// PR keys shared in that channel, scored like postedPRIndexKey.
+	postedChannelIndexPrefix = "slashvibepr:posted_channel:"
+	postedPRTTL              = 7 * 24 * time.Hour
+)
+
+// PostedPR records where and when a PR was last shared, so later actions
+// (approvals, reminders, reactions) can find and annotate the original post.
+type PostedPR struct {
+	Repo       string    `json:"repo"`
+	Number     int       `json:"number"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	Channel    string    `json:"channel"`
+	ThreadKey  string    `json:"thread_key"`
+	PostedBy   string    `json:"posted_by"`
+	PostedByID string    `json:"posted_by_id,omitempty"`
+	PostedAt   time.Time `json:"posted_at"`
+	// Reminders counts stale-PR reminders sent for this post, the last at
+	// LastRemindedAt. RemindersDone stops them once the PR is reviewed or
+	// closed.
+	Reminders      int       `json:"reminders,omitempty"`
+	LastRemindedAt time.Time `json:"last_reminded_at,omitempty"`
+	RemindersDone  bool      `json:"reminders_done,omitempty"`
+	// Reviewing and Reviewed are the Slack users who reacted to the post
+	// with 👀 and ✅, in the order they did.
+	Reviewing []string `json:"reviewing,omitempty"`
+	Reviewed  []string `json:"reviewed,omitempty"`
+	// FirstReviewAt is when the post was first approved, ✅-reacted, or
+	// claimed, so each post counts towards the review SLA once.
+	FirstReviewAt time.Time `json:"first_review_at,omitempty"`
+	// MessageTS is the Slack timestamp of the post. SlackLiner posts it
+	// after the post is recorded, so it is learned from the first review
+	// reaction on the message carrying ThreadKey.
+	MessageTS string `json:"message_ts,omitempty"`
+}
+
+// postedPRKey returns the Redis key for the latest post of repo#number.
+func postedPRKey(repo string, number int) string {
+	return fmt.Sprintf("%s%s#%d", postedPRKeyPrefix, repo, number)
+}
+
+// postedChannelIndexKey returns the index of the PRs posted in channel.
+func postedChannelIndexKey(channel string) string {
+	return postedChannelIndexPrefix + channel
+}
+
+// postRef identifies a posted PR in the values of its buttons and menus, as
+// "<repo>#<number>".
+func postRef(repo string, number int) string {
+	return fmt.Sprintf("%s#%d", repo, number)
+}
+
+// parsePostRef is the inverse of postRef.
+func parsePostRef(ref string) (repo string, number int, err error) {
+	repo, num, ok := strings.Cut(ref, "#")
+	number, convErr := strconv.Atoi(num)
+	if !ok || repo == "" || convErr != nil || number <= 0 {
+		return "", 0, fmt.Errorf("invalid pull request %q", ref)
+	}
+	return repo, number, nil
+}
+
+// recordPostedPR stores rec in the posted-PR index and its channel's index,
+// replacing any earlier post of the same PR.
+func recordPostedPR(ctx context.Context, rdb *redis.Client, rec PostedPR) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal posted PR: %w", err)
+	}
+	key := postedPRKey(rec.Repo, rec.Number)
+	expired := fmt.Sprintf("%d", rec.PostedAt.Add(-postedPRTTL).Unix())
+
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, key, data, postedPRTTL)
+	pipe.ZAdd(ctx, postedPRIndexKey, redis.Z{Score: float64(rec.PostedAt.Unix()), Member: key})
+	pipe.ZRemRangeByScore(ctx, postedPRIndexKey, "-inf", expired)
+	if rec.Channel != "" {
+		channelKey := postedChannelIndexKey(rec.Channel)
+		pipe.ZAdd(ctx, channelKey, redis.Z{Score: float64(rec.PostedAt.Unix()), Member: key})
+		pipe.ZRemRangeByScore(ctx, channelKey, "-inf", expired)
+		pipe.Expire(ctx, channelKey, postedPRTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record posted PR: %w", err)
+	}
+	return nil
+}
+
+// loadPostedPR returns the latest post of repo#number. It returns redis.Nil
+// (wrapped) when the PR has not been posted recently.
+func loadPostedPR(ctx context.Context, rdb *redis.Client, repo string, number int) (*PostedPR, error) {
+	return loadPostedPRByKey(ctx, rdb, postedPRKey(repo, number))
+}
+
+// loadPostedPRByKey is loadPostedPR for a key taken from the posted-PR index.
+func loadPostedPRByKey(ctx context.Context, rdb *redis.Client, key string) (*PostedPR, error) {
+	data, err := rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posted PR: %w", err)
+	}
+	var rec PostedPR
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse posted PR: %w", err)
+	}
+	return &rec, nil
+}
+
+// maxPostedPRRetries bounds how often updatePostedPR starts over when
+// another writer changes the post between its read and its write.
+const maxPostedPRRetries = 10
+
+// updatePostedPR applies change to the latest post of repo#number and saves
+// it without extending its TTL or moving it in the index. The read and the
+// write run under WATCH and start over when another writer gets in between,
+// so concurrent updates, such as review reactions and reminder claims, are
+// never lost; change may therefore run more than once. It reports whether
+// it changed the post, and nothing is written when it did not, in which case
+// the returned post is nil. Like loadPostedPR, it returns redis.Nil
+// (wrapped) when the PR has not been posted recently.
+func updatePostedPR(ctx context.Context, rdb *redis.Client, repo string, number int, change func(*PostedPR) bool) (*PostedPR, error) {
+	key := postedPRKey(repo, number)
+	var updated *PostedPR
+	update := func(tx *redis.Tx) error {
+		updated = nil
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			return fmt.Errorf("failed to load posted PR: %w", err)
+		}
+		var rec PostedPR
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("failed to parse posted PR: %w", err)
+		}
+		if !change(&rec) {
+			return nil
+		}
+		if data, err = json.Marshal(rec); err != nil {
+			return fmt.Errorf("failed to marshal posted PR: %w", err)
+		}
+		if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, redis.KeepTTL)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to update posted PR: %w", err)
+		}
+		updated = &rec
+		return nil
+	}
+	for range maxPostedPRRetries {
+		if err := rdb.Watch(ctx, update, key); !errors.Is(err, redis.TxFailedErr) {
+			return updated, err
+		}
+	}
+	return nil, fmt.Errorf("failed to update posted PR: %w", redis.TxFailedErr)
+}
+
+// deletePostedPR drops a post from the posted-PR index.
+func deletePostedPR(ctx context.Context, rdb *redis.Client, repo string, number int) error {
+	key := postedPRKey(repo, number)
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.ZRem(ctx, postedPRIndexKey, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete posted PR: %w", err)
+	}
+	return nil
+}
+
+// loadChannelPosts returns the PRs whose latest post is in channel, oldest
+// first. Index entries for posts that have expired, been retracted, or been
+// shared again elsewhere are dropped as they are found.
+func loadChannelPosts(ctx context.Context, rdb *redis.Client, channel string) ([]PostedPR, error) {
+	indexKey := postedChannelIndexKey(channel)
+	keys, err := rdb.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channel index: %w", err)
+	}
+	var recs []PostedPR
+	for _, key := range keys {
+		rec, err := loadPostedPRByKey(ctx, rdb, key)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		if rec == nil || rec.Channel != channel {
+			if err := rdb.ZRem(ctx, indexKey, key).Err(); err != nil {
+				logging.WarnContext(ctx, "Error pruning %s from %s: %v", key, indexKey, err)
+			}
+			continue
+		}
+		recs = append(recs, *rec)
+	}
+	return recs, nil
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+)
+
+const (
+	userPrefsKeyPrefix = "slashvibepr:prefs:"
+
+	prefsCallbackID        = "pr_preferences_modal"
+	prefsRepoBlockID       = "prefs_repo_block"
+	prefsRepoActionID      = "prefs_repo"
+	prefsSortBlockID       = "prefs_sort_block"
+	prefsSortActionID      = "prefs_sort"
+	prefsDraftsBlockID     = "prefs_drafts_block"
+	prefsDraftsActionID    = "prefs_drafts"
+	prefsChannelBlockID    = "prefs_channel_block"
+	prefsChannelActionID   = "prefs_channel"
+	prefsDefaultValue      = "default"
+	prefsDraftsShowValue   = "show"
+	prefsDraftsHideValue   = "hide"
+	prefsSavedReply        = ":gear: Saved your `/pr` preferences."
+	prefsSaveFailedReply   = ":x: Failed to save your preferences. Please try again."
+	prefsInvalidRepoFormat = ":warning: `%s` is not a valid repository, so your preferences were not saved."
+)
+
+// UserPreferences are a user's own defaults for /pr, set in the
+// `/pr settings` modal. Empty fields fall back to the workspace config.
+type UserPreferences struct {
+	// DefaultRepo ("owner/name") is offered as a button in the repo chooser.
+	DefaultRepo string
+	// Sort is the PR list order used when no --sort has been remembered.
+	Sort string
+	// HideDrafts, when set, overrides github.hide_drafts.
+	HideDrafts *bool
+	// Channel, when set, replaces slack.channel_id as where PRs are posted.
+	Channel string
+}
+
+// preferencesMetadata is sealed into the settings modal's private_metadata
+// so the submission can confirm through the command's response_url.
+type preferencesMetadata struct {
+	ResponseURL string `json:"response_url,omitempty"`
+}
+
+// userPrefsKey returns the Redis hash holding a user's preferences.
+func userPrefsKey(userID string) string {
+	return userPrefsKeyPrefix + userID
+}
+
+// loadUserPreferences returns the preferences stored for userID. Values that
+// are no longer valid are dropped.
+func loadUserPreferences(ctx context.Context, rdb *redis.Client, userID string) (UserPreferences, error) {
+	var prefs UserPreferences
+	if rdb == nil || userID == "" {
+		return prefs, nil
+	}
+	fields, err := rdb.HGetAll(ctx, userPrefsKey(userID)).Result()
+	if err != nil {
+		return prefs, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	prefs.DefaultRepo = fields["default_repo"]
+	if validPRSort(fields["sort"]) {
+		prefs.Sort = fields["sort"]
+	}
+	if b, err := strconv.ParseBool(fields["hide_drafts"]); err == nil {
+		prefs.HideDrafts = &b
+	}
+	if slackChannelIDPattern.MatchString(fields["channel"]) {
+		prefs.Channel = fields["channel"]
+	}
+	return prefs, nil
+}
+
+// saveUserPreferences replaces the preferences stored for userID.
+func saveUserPreferences(ctx context.Context, rdb *redis.Client, userID string, prefs UserPreferences) error {
+	fields := map[string]interface{}{}
+	if prefs.DefaultRepo != "" {
+		fields["default_repo"] = prefs.DefaultRepo
+	}
+	if prefs.Sort != "" {
+		fields["sort"] = prefs.Sort
+	}
+	if prefs.HideDrafts != nil {
+		fields["hide_drafts"] = strconv.FormatBool(*prefs.HideDrafts)
+	}
+	if prefs.Channel != "" {
+		fields["channel"] = prefs.Channel
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, userPrefsKey(userID))
+	if len(fields) > 0 {
+		pipe.HSet(ctx, userPrefsKey(userID), fields)
+	}
+	// A newly chosen sort order should win over the last --sort remembered.
+	pipe.Del(ctx, prSortKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
+
+// apply returns config with the user's preferences layered on top.
+func (p UserPreferences) apply(config config.Config) config.Config {
+	if p.HideDrafts != nil {
+		config.GitHubHideDrafts = *p.HideDrafts
+	}
+	if p.Channel != "" {
+		config.SlackChannelID = p.Channel
+	}
+	return config
+}
+
+// withUserPreferences returns config with userID's preferences applied, so
+// their drafts setting and posting channel hold for everything they do.
+// When the preferences cannot be read the config is returned unchanged.
+func withUserPreferences(ctx context.Context, rdb *redis.Client, userID string, config config.Config) config.Config {
+	prefs, err := loadUserPreferences(ctx, rdb, userID)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading preferences for user %s: %v", userID, err)
+		return config
+	}
+	return prefs.apply(config)
+}
+
+// handleSettingsCommand implements /pr settings by opening the preferences
+// modal filled in with the user's current preferences.
+func handleSettingsCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, config config.Config) {
+	prefs, err := loadUserPreferences(ctx, rdb, cmd.UserID)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading preferences for user %s: %v", cmd.UserName, err)
+		if err := respondEphemeral(ctx, cmd.ResponseURL, ":x: Failed to load your preferences. Please try again."); err != nil {
+			logging.ErrorContext(ctx, "Error responding to settings for user %s: %v", cmd.UserName, err)
+		}
+		return
+	}
+	metadata, err := session.Seal(preferencesMetadata{ResponseURL: cmd.ResponseURL})
+	if err != nil {
+		logging.ErrorContext(ctx, "Error sealing preferences metadata: %v", err)
+		return
+	}
+	if _, err := openView(ctx, slackClient, cmd.TriggerID, createPreferencesModal(prefs, metadata), config); err != nil {
+		logging.ErrorContext(ctx, "Error opening preferences modal: %v", err)
+	}
+}
+
+// createPreferencesModal renders the preferences form with prefs preselected.
+// Every input is optional; leaving one empty restores the workspace default.
+func createPreferencesModal(prefs UserPreferences, privateMetadata string) slack.ModalViewRequest {
+	text := func(s string) *slack.TextBlockObject {
+		return slack.NewTextBlockObject(slack.PlainTextType, s, false, false)
+	}
+	option := func(value, label string) *slack.OptionBlockObject {
+		return slack.NewOptionBlockObject(value, text(label), nil)
+	}
+
+	repo := slack.NewPlainTextInputBlockElement(text("e.g. my-service or other-org/shared-lib"), prefsRepoActionID)
+	repo.InitialValue = prefs.DefaultRepo
+	repoInput := slack.NewInputBlock(prefsRepoBlockID, text("Default repository"), text("Offered as a shortcut in the repo chooser."), repo)
+	repoInput.Optional = true
+
+	sortOptions := []*slack.OptionBlockObject{option(prefsDefaultValue, "GitHub's default")}
+	for _, s := range prSortOrders {
+		sortOptions = append(sortOptions, option(s, s))
+	}
+	sort := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, text("Sort order"), prefsSortActionID, sortOptions...)
+	sort.InitialOption = sortOptions[0]
+	for _, opt := range sortOptions {
+		if opt.Value == prefs.Sort {
+			sort.InitialOption = opt
+		}
+	}
+	sortInput := slack.NewInputBlock(prefsSortBlockID, text("Preferred sort order"), nil, sort)
+
+	draftOptions := []*slack.OptionBlockObject{
+		option(prefsDefaultValue, "Workspace default"),
+		option(prefsDraftsShowValue, "Show drafts"),
+		option(prefsDraftsHideValue, "Hide drafts"),
+	}
+	drafts := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, text("Drafts"), prefsDraftsActionID, draftOptions...)
+	drafts.InitialOption = draftOptions[0]
+	if prefs.HideDrafts != nil {
+		drafts.InitialOption = draftOptions[1]
+		if *prefs.HideDrafts {
+			drafts.InitialOption = draftOptions[2]
+		}
+	}
+	draftsInput := slack.NewInputBlock(prefsDraftsBlockID, text("Draft pull requests"), nil, drafts)
+
+	channel := slack.NewOptionsSelectBlockElement(slack.OptTypeConversations, text("Workspace default channel"), prefsChannelActionID)
+	channel.InitialConversation = prefs.Channel
+	channel.Filter = &slack.SelectBlockElementFilter{Include: []string{"public", "private"}}
+	channelInput := slack.NewInputBlock(prefsChannelBlockID, text("Post pull requests to"), text("The app must be a member of the channel."), channel)
+	channelInput.Optional = true
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      prefsCallbackID,
+		PrivateMetadata: privateMetadata,
+		Title:           text("Your /pr Settings"),
+		Submit:          text("Save"),
+		Close:           text("Cancel"),
+		Blocks:          slack.Blocks{BlockSet: []slack.Block{repoInput, sortInput, draftsInput, channelInput}},
+	}
+}
+
+// handlePreferencesSubmission stores the preferences submitted in the
+// settings modal and confirms through the command's response_url.
+func handlePreferencesSubmission(ctx context.Context, rdb *redis.Client, submission ViewSubmission, config config.Config) {
+	var meta preferencesMetadata
+	if err := session.Open(submission.View.PrivateMetadata, &meta); err != nil {
+		logging.WarnContext(ctx, "Error parsing preferences metadata: %v", err)
+	}
+	values := submission.View.State.Values
+
+	var prefs UserPreferences
+	reply := prefsSavedReply
+	if repo := strings.TrimSpace(extractTextValue(values, prefsRepoBlockID, prefsRepoActionID)); repo != "" {
+		parsed, err := parsePRArgs(repo, config)
+		if err != nil || parsed.Repo == "" || parsed.Number != 0 {
+			reply = fmt.Sprintf(prefsInvalidRepoFormat, repo)
+		}
+		prefs.DefaultRepo = parsed.fullRepo(config)
+	}
+	if s := extractTextValue(values, prefsSortBlockID, prefsSortActionID); validPRSort(s) {
+		prefs.Sort = s
+	}
+	switch extractTextValue(values, prefsDraftsBlockID, prefsDraftsActionID) {
+	case prefsDraftsShowValue:
+		hide := false
+		prefs.HideDrafts = &hide
+	case prefsDraftsHideValue:
+		hide := true
+		prefs.HideDrafts = &hide
+	}
+	if c := extractTextValue(values, prefsChannelBlockID, prefsChannelActionID); slackChannelIDPattern.MatchString(c) {
+		prefs.Channel = c
+	}
+
+	if reply == prefsSavedReply {
+		if err := saveUserPreferences(ctx, rdb, submission.User.ID, prefs); err != nil {
+			logging.ErrorContext(ctx, "Error saving preferences for user %s: %v", submission.User.Username, err)
+			reply = prefsSaveFailedReply
+		} else {
+			logging.InfoContext(ctx, "User %s saved their preferences", submission.User.Username)
+		}
+	}
+	if err := respondEphemeral(ctx, meta.ResponseURL, reply); err != nil {
+		logging.ErrorContext(ctx, "Error confirming preferences for user %s: %v", submission.User.Username, err)
+	}
+}
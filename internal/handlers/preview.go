@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const (
+	previewCallbackID    = "pr_post_preview_modal"
+	previewBlockID       = "preview_actions"
+	previewPostActionID  = "preview_post"
+	previewBackActionID  = "preview_back"
+	previewKeyPrefix     = "slashvibepr:preview:"
+	maxPreviewTextLength = 3000
+)
+
+// pendingPost is a chooser submission held back until the user confirms the
+// preview. It is stored under a random token carried in the preview's
+// buttons.
+type pendingPost struct {
+	Repo      string   `json:"repo"`
+	PRs       []PRItem `json:"prs"`
+	Note      string   `json:"note,omitempty"`
+	Reviewers []string `json:"reviewers,omitempty"`
+	DryRun    bool     `json:"dry_run,omitempty"`
+	// PostAt is the time picked under "Post later"; zero to post now.
+	PostAt time.Time `json:"post_at,omitzero"`
+	// TTL is the lifetime picked in the chooser, in whole seconds; empty
+	// for slack.message_ttl.
+	TTL string `json:"ttl,omitempty"`
+	// Channel is the channel picked in the chooser; empty to post each PR
+	// where it is routed.
+	Channel string `json:"channel,omitempty"`
+	// ChooserViewID and ChooserMetadata let "Back" reopen the chooser from
+	// the PR session it was served from.
+	ChooserViewID   string            `json:"chooser_view_id"`
+	ChooserMetadata string            `json:"chooser_metadata"`
+	RequestID       string            `json:"request_id,omitempty"`
+	Trace           map[string]string `json:"trace,omitempty"`
+}
+
+// previewKey returns the Redis key holding a pending post.
+func previewKey(token string) string {
+	return previewKeyPrefix + token
+}
+
+// openPostPreview stores the chooser submission as a pending post and opens a
+// modal showing the messages exactly as they would be posted, with the
+// channel and TTL, and "Post" and "Back" buttons. Nothing is posted until
+// the user confirms.
+func openPostPreview(ctx context.Context, rdb *redis.Client, slackClient SlackClient, triggerID string, pending pendingPost, inv Invocation, config config.Config) error {
+	token, err := randomHex(16)
+	if err != nil {
+		return fmt.Errorf("failed to generate preview token: %w", err)
+	}
+	if err := session.Save(ctx, rdb, previewKey(token), pending, config.SessionTTL); err != nil {
+		return fmt.Errorf("failed to save pending post: %w", err)
+	}
+
+	var msgs []SlackLinerMessage
+	for i := range pending.PRs {
+		pr := &pending.PRs[i]
+		repo := pr.repoOr(pending.Repo)
+		prConfig := withPostChannel(repo, inv, config)
+		post := preparePost(ctx, rdb, pr, repo, inv, PostOptions{Note: pending.Note}, false, prConfig)
+		msgs = append(msgs, buildPRMessage(pr, repo, inv.Username, post, prConfig))
+	}
+	when := ""
+	if isScheduled(pending.PostAt) {
+		when = formatScheduledTime(ctx, rdb, slackClient, inv.UserID, pending.PostAt)
+	}
+	if _, err := openView(ctx, slackClient, triggerID, createPostPreviewModal(msgs, token, when, pending.DryRun || config.DryRun, config), config); err != nil {
+		return fmt.Errorf("failed to open post preview: %w", err)
+	}
+	return nil
+}
+
+// createPostPreviewModal renders msgs as Slack will show them, preceded by
+// the channels they will go to and followed by the confirm and back buttons.
+// when, e.g. "on Mon Jan 5 at 09:30 GMT", is set for a scheduled post.
+func createPostPreviewModal(msgs []SlackLinerMessage, token, when string, dryRun bool, config config.Config) slack.ModalViewRequest {
+	var channels []string
+	for _, msg := range msgs {
+		if !slices.Contains(channels, msg.Channel) {
+			channels = append(channels, msg.Channel)
+		}
+	}
+	intro := "This will be posted to " + channelList(channels)
+	if when != "" {
+		intro += " " + when
+	}
+	if config.SlackMessageTTL > 0 {
+		intro += fmt.Sprintf(" and removed after %s", slackui.FormatAge(config.SlackMessageTTL))
+	} else {
+		intro += " and kept until deleted"
+	}
+	intro += "."
+	if config.SlackThreadDetails {
+		intro += " A details reply is threaded under each message."
+	}
+	if dryRun {
+		intro += "\n:test_tube: *Dry run* — posting only echoes the messages back to you."
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, intro, false, false), nil, nil),
+	}
+	for _, msg := range msgs {
+		blocks = append(blocks, slack.NewDividerBlock())
+		if len(msg.Blocks) > 0 {
+			blocks = append(blocks, msg.Blocks...)
+			continue
+		}
+		text := msg.Text
+		if runes := []rune(text); len(runes) > maxPreviewTextLength {
+			text = string(runes[:maxPreviewTextLength-1]) + "…"
+		}
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+	}
+
+	label := "Post"
+	if when != "" {
+		label = "Schedule"
+	}
+	post := slack.NewButtonBlockElement(previewPostActionID, token, slack.NewTextBlockObject(slack.PlainTextType, label, false, false))
+	post.Style = slack.StylePrimary
+	back := slack.NewButtonBlockElement(previewBackActionID, token, slack.NewTextBlockObject(slack.PlainTextType, "Back", false, false))
+	blocks = append(blocks, slack.NewDividerBlock(), slack.NewActionBlock(previewBlockID, post, back))
+
+	return slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: previewCallbackID,
+		Title:      slack.NewTextBlockObject(slack.PlainTextType, "Preview Post", false, false),
+		Close:      slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		Blocks:     slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// claimPendingPost loads and deletes a pending post, so a double-clicked
+// "Post" posts once. It returns redis.Nil (wrapped) when the preview has
+// expired or was already used.
+func claimPendingPost(ctx context.Context, rdb *redis.Client, token string) (pendingPost, error) {
+	var pending pendingPost
+	if err := session.Load(ctx, rdb, previewKey(token), &pending); err != nil {
+		return pending, fmt.Errorf("failed to load pending post: %w", err)
+	}
+	deleted, err := rdb.Del(ctx, previewKey(token)).Result()
+	if err != nil {
+		return pending, fmt.Errorf("failed to claim pending post: %w", err)
+	}
+	if deleted == 0 {
+		return pending, fmt.Errorf("pending post already claimed: %w", redis.Nil)
+	}
+	return pending, nil
+}
+
+// handlePreviewPost posts the PRs of a confirmed preview and replaces the
+// preview with a confirmation.
+func handlePreviewPost(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, token string, config config.Config) {
+	pending, err := claimPendingPost(ctx, rdb, token)
+	if err != nil {
+		logging.WarnContext(ctx, "Error claiming pending post for view_id %s: %v", action.View.ID, err)
+		if errors.Is(err, redis.Nil) {
+			updateModalWithErrorByID(ctx, rdb, slackClient, action.View.ID, "This preview has expired or was already posted. Run `/pr` again.", config)
+		}
+		return
+	}
+	ctx = withTraceCarrier(logging.WithRequestID(ctx, pending.RequestID), pending.Trace)
+	config = withMessageTTL(ctx, pending.TTL, config)
+
+	inv := Invocation{UserID: action.User.ID, Username: action.User.Username, TeamID: action.Team.ID, DryRun: pending.DryRun, Channel: pending.Channel}
+	if isScheduled(pending.PostAt) {
+		job := newScheduledPost(ctx, pending.Repo, pending.PRs, pending.Note, pending.Reviewers, inv, config)
+		job.TTL = pending.TTL
+		if err := schedulePost(ctx, rdb, slackClient, job, pending.PostAt); err != nil {
+			logging.ErrorContext(ctx, "Error scheduling post for user %s: %v", action.User.Username, err)
+			updateModalWithErrorByID(ctx, rdb, slackClient, action.View.ID, "Could not schedule the post. Please try again.", config)
+			return
+		}
+		confirmation := slack.ModalViewRequest{
+			Type:   slack.VTModal,
+			Title:  slack.NewTextBlockObject(slack.PlainTextType, "PR Scheduled", false, false),
+			Close:  slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false),
+			Blocks: slack.Blocks{BlockSet: []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, ":calendar: "+scheduledPostText(job, formatScheduledTime(ctx, rdb, slackClient, inv.UserID, pending.PostAt)), false, false), nil, nil)}},
+		}
+		if _, err := updateView(ctx, rdb, slackClient, confirmation, action.View.ID, config); err != nil {
+			logging.ErrorContext(ctx, "Error updating preview after scheduling: %v", err)
+		}
+		return
+	}
+	posted := postSelectedPRs(ctx, rdb, slackClient, pending.PRs, pending.Repo, inv, PostOptions{Note: pending.Note}, pending.Reviewers, config)
+
+	where := channelList(postChannels(pending.PRs, pending.Repo, inv, config))
+	text := fmt.Sprintf(":white_check_mark: Posted %d of %d pull requests to %s.", posted, len(pending.PRs), where)
+	if posted == len(pending.PRs) && posted == 1 {
+		text = fmt.Sprintf(":white_check_mark: *PR #%d: %s* has been posted to %s.", pending.PRs[0].Number, pending.PRs[0].Title, where)
+	}
+	confirmation := slack.ModalViewRequest{
+		Type:   slack.VTModal,
+		Title:  slack.NewTextBlockObject(slack.PlainTextType, "PR Posted", false, false),
+		Close:  slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)}},
+	}
+	if _, err := updateView(ctx, rdb, slackClient, confirmation, action.View.ID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating preview after posting: %v", err)
+	}
+}
+
+// handlePreviewBack returns from the preview to the PR chooser it came from.
+// The chooser's PR session is copied to the preview's view, which becomes
+// the chooser; the note and reviewers have to be entered again.
+func handlePreviewBack(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, token string, config config.Config) {
+	pending, err := claimPendingPost(ctx, rdb, token)
+	if err != nil {
+		logging.WarnContext(ctx, "Error claiming pending post for view_id %s: %v", action.View.ID, err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, action.View.ID, "This preview has expired. Run `/pr` again.", config)
+		return
+	}
+	prSession, err := loadPRSession(ctx, rdb, pending.ChooserViewID)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading PR session for view_id %s: %v", pending.ChooserViewID, err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, action.View.ID, "The pull request list has expired. Run `/pr` again.", config)
+		return
+	}
+	if err := savePRSession(ctx, rdb, action.View.ID, prSession, config); err != nil {
+		logging.ErrorContext(ctx, "Error saving PR session for view_id %s: %v", action.View.ID, err)
+		return
+	}
+
+	modal := slackui.PRChooserModal(len(prSession.chooserPRs()), prSession.Repo, prSession.chooserFilters(), prSession.Multi, prSession.destination(), config.SlackMessageTTL, pending.ChooserMetadata)
+	if _, err := updateView(ctx, rdb, slackClient, modal, action.View.ID, config); err != nil {
+		logging.ErrorContext(ctx, "Error returning to the PR chooser: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// PRSource fetches pull requests for the slash command and block action
+// flows. Implementations deliver their results through presentPRList and
+// presentPRView, either synchronously or once an asynchronous reply arrives.
+type PRSource interface {
+	// ListPRs fetches the open PRs for repo and drives the modal identified
+	// by viewID to its next state.
+	ListPRs(ctx context.Context, repo, viewID string, inv Invocation, opts PRListOptions) error
+	// ViewPR fetches a single PR and posts it, reporting the outcome to
+	// responseURL.
+	ViewPR(ctx context.Context, repo string, number int, responseURL string, inv Invocation) error
+	// SearchPRs runs a cross-repo search over the configured organisations
+	// and drives the modal identified by viewID.
+	SearchPRs(ctx context.Context, search prSearch, viewID string, inv Invocation) error
+}
+
+// newPRSource returns the PRSource selected by github.mode.
+func newPRSource(rdb *redis.Client, slackClient SlackClient, config config.Config) PRSource {
+	if config.UsesGitHubAPI() {
+		return &githubAPIPRSource{
+			rdb:         rdb,
+			slackClient: slackClient,
+			client:      newGitHubClientFromConfig(config),
+			config:      config,
+		}
+	}
+	return &poppitPRSource{rdb: rdb, slackClient: slackClient, config: config}
+}
+
+// poppitPRSource queues gh CLI commands on Poppit; results are handled by
+// handlePoppitOutput when they arrive on the output channel. Modal fetches
+// arm a watchdog in case the output never comes.
+type poppitPRSource struct {
+	rdb         *redis.Client
+	slackClient SlackClient
+	config      config.Config
+}
+
+func (s *poppitPRSource) ListPRs(ctx context.Context, repo, viewID string, inv Invocation, opts PRListOptions) error {
+	armLoadingWatchdog(ctx, s.rdb, s.slackClient, viewID, loadingRequest{Repo: repo, List: opts, Inv: inv}, s.config)
+	return sendPRListCommand(ctx, s.rdb, repo, viewID, inv, opts, s.config)
+}
+
+func (s *poppitPRSource) ViewPR(ctx context.Context, repo string, number int, responseURL string, inv Invocation) error {
+	return sendPRViewCommand(ctx, s.rdb, repo, number, responseURL, inv, s.config)
+}
+
+func (s *poppitPRSource) SearchPRs(ctx context.Context, search prSearch, viewID string, inv Invocation) error {
+	armLoadingWatchdog(ctx, s.rdb, s.slackClient, viewID, loadingRequest{Search: &search, Inv: inv}, s.config)
+	return sendPRSearchCommand(ctx, s.rdb, search, viewID, inv, s.config)
+}
+
+// githubAPIPRSource calls the GitHub REST API in-process. Requests run in a
+// background goroutine so the Slack trigger_id is not held up by GitHub.
+type githubAPIPRSource struct {
+	rdb         *redis.Client
+	slackClient SlackClient
+	client      *githubClient
+	config      config.Config
+}
+
+// githubAPITimeout bounds a single background fetch from the GitHub API.
+const githubAPITimeout = 30 * time.Second
+
+func (s *githubAPIPRSource) ListPRs(ctx context.Context, repo, viewID string, inv Invocation, opts PRListOptions) error {
+	go func() {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), githubAPITimeout)
+		defer cancel()
+		s.listPRs(fetchCtx, repo, viewID, inv, opts)
+	}()
+	return nil
+}
+
+func (s *githubAPIPRSource) listPRs(ctx context.Context, repo, viewID string, inv Invocation, opts PRListOptions) {
+	prs, err := s.client.listPullRequests(ctx, repo, opts, opts.fetchLimit(s.config.PRListLimit))
+	if err != nil {
+		logging.ErrorContext(ctx, "Error listing PRs for %s from GitHub API: %v", repo, err)
+		updateModalWithErrorByID(ctx, s.rdb, s.slackClient, viewID, "Failed to fetch pull requests. Please try again.", s.config)
+		return
+	}
+	presentPRList(ctx, s.rdb, s.slackClient, viewID, repo, opts, inv, prs, s.config)
+}
+
+func (s *githubAPIPRSource) ViewPR(ctx context.Context, repo string, number int, responseURL string, inv Invocation) error {
+	go func() {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), githubAPITimeout)
+		defer cancel()
+		s.viewPR(fetchCtx, repo, number, responseURL, inv)
+	}()
+	return nil
+}
+
+func (s *githubAPIPRSource) viewPR(ctx context.Context, repo string, number int, responseURL string, inv Invocation) {
+	pr, err := s.client.getPullRequest(ctx, repo, number)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error fetching PR #%d for %s from GitHub API: %v", number, repo, err)
+		if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":x: Could not load that pull request from `%s`.", repo)); err != nil {
+			logging.ErrorContext(ctx, "Error sending PR view feedback: %v", err)
+		}
+		return
+	}
+	presentPRView(ctx, s.rdb, s.slackClient, repo, responseURL, inv, pr, s.config)
+}
+
+func (s *githubAPIPRSource) SearchPRs(ctx context.Context, search prSearch, viewID string, inv Invocation) error {
+	go func() {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), githubAPITimeout)
+		defer cancel()
+		s.searchPRs(fetchCtx, search, viewID, inv)
+	}()
+	return nil
+}
+
+func (s *githubAPIPRSource) searchPRs(ctx context.Context, search prSearch, viewID string, inv Invocation) {
+	prs, err := s.client.searchPullRequests(ctx, search.apiQuery(s.config.Orgs()), search.limit(s.config))
+	if err != nil {
+		logging.ErrorContext(ctx, "Error searching PRs (%s) from GitHub API: %v", search.label(), err)
+		updateModalWithErrorByID(ctx, s.rdb, s.slackClient, viewID, "Failed to search pull requests. Please try again.", s.config)
+		return
+	}
+	presentPRSearch(ctx, s.rdb, s.slackClient, viewID, search, inv, prs, s.config)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const poppitPRViewType = "slash-vibe-pr-view"
+
+// prViewJSONFields extends prJSONFields with mergeability, which is too slow
+// for gh to compute across a whole PR list.
+const prViewJSONFields = prJSONFields + ",mergeable,mergeStateStatus"
+
+// prURLPattern matches a GitHub pull request URL, optionally wrapped in the
+// angle brackets Slack adds around links. Submatches are owner, repo, number.
+var prURLPattern = regexp.MustCompile(`^<?https://github\.com/([a-zA-Z0-9-]+)/([a-zA-Z0-9._-]+)/pull/([0-9]+)(?:[/?#][^\s|>]*)?(?:\|[^>]*)?>?$`)
+
+// buildPRViewCommand returns the gh invocation that fetches a single PR.
+func buildPRViewCommand(repo string, number int) string {
+	return fmt.Sprintf(
+		"gh pr view %d --repo %s --json %s",
+		number, repo, prViewJSONFields,
+	)
+}
+
+// sendPRViewCommand pushes a Poppit command to fetch one PR's details. The
+// response_url is carried in metadata so the user can be told the outcome.
+func sendPRViewCommand(ctx context.Context, rdb *redis.Client, repo string, number int, responseURL string, inv Invocation, config config.Config) error {
+	metadata := inv.metadata()
+	metadata["repo"] = repo
+	metadata["response_url"] = responseURL
+
+	return pushPoppitCommand(ctx, rdb, poppit.Command{
+		Repo:     repo,
+		Branch:   "",
+		Type:     poppitPRViewType,
+		Dir:      "/tmp",
+		Commands: []string{buildPRViewCommand(repo, number)},
+		Metadata: metadata,
+	}, config)
+}
+
+// handlePRViewOutput posts the single PR fetched for a pasted URL and
+// confirms the result to the user via response_url.
+func handlePRViewOutput(ctx context.Context, rdb *redis.Client, slackClient SlackClient, output poppit.Output, config config.Config) {
+	logging.DebugContext(ctx, "Received Poppit PR view output")
+
+	metadata := output.Metadata
+	if metadata == nil {
+		logging.WarnContext(ctx, "No metadata in Poppit PR view output")
+		return
+	}
+
+	repo, _ := metadata["repo"].(string)
+	responseURL, _ := metadata["response_url"].(string)
+	inv := invocationFromMetadata(metadata)
+	config = withUserPreferences(ctx, rdb, inv.UserID, config)
+	config = withFeatureFlags(ctx, rdb, featureScope{Team: inv.TeamID, Channel: config.SlackChannelID}, config)
+
+	if repo == "" {
+		logging.WarnContext(ctx, "Missing repo in Poppit PR view metadata")
+		return
+	}
+
+	if msg, failed := poppit.Failure(output); failed {
+		logging.ErrorContext(ctx, "Viewing a PR in repo %s failed: %s", repo, output.ErrorText())
+		if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":x: Could not load that pull request from `%s`: %s", repo, msg)); err != nil {
+			logging.ErrorContext(ctx, "Error sending PR view feedback: %v", err)
+		}
+		return
+	}
+
+	var pr PRItem
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &pr); err != nil || pr.Number == 0 {
+		logging.ErrorContext(ctx, "Error parsing PR view JSON for repo %s: %v", repo, err)
+		if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":x: Could not load that pull request from `%s`.", repo)); err != nil {
+			logging.ErrorContext(ctx, "Error sending PR view feedback: %v", err)
+		}
+		return
+	}
+
+	presentPRView(ctx, rdb, slackClient, repo, responseURL, inv, &pr, config)
+}
+
+// presentPRView posts a single fetched PR and tells the user the outcome.
+// It is shared by every PRSource.
+func presentPRView(ctx context.Context, rdb *redis.Client, slackClient SlackClient, repo, responseURL string, inv Invocation, pr *PRItem, config config.Config) {
+	if inv.Status {
+		presentPRStatus(ctx, rdb, slackClient, repo, responseURL, inv, pr, config)
+		return
+	}
+	if inv.Unfurl.URL != "" {
+		presentPRUnfurl(ctx, slackClient, repo, inv, pr, config)
+		return
+	}
+	if err := sharePR(ctx, rdb, slackClient, pr, repo, inv, PostOptions{}, config); err != nil {
+		logging.ErrorContext(ctx, "Error posting PR to Slack: %v", err)
+		if err := respondEphemeral(ctx, responseURL, "Failed to post the pull request. Please try again."); err != nil {
+			logging.ErrorContext(ctx, "Error sending PR view feedback: %v", err)
+		}
+		return
+	}
+
+	logging.InfoContext(ctx, "PR #%d from %s posted to Slack channel via URL (user: %s)", pr.Number, repo, inv.Username)
+	if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":white_check_mark: *PR #%d: %s* has been posted to the channel.", pr.Number, pr.Title)); err != nil {
+		logging.ErrorContext(ctx, "Error sending PR view feedback: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// reviewStatusBlockID identifies the footer listing who is reviewing a
+// posted PR and who has reviewed it.
+const reviewStatusBlockID = "review_status"
+
+// Review reactions: 👀 marks the reactor as reviewing a posted PR, ✅ as
+// having reviewed it.
+const (
+	reactionReviewing = "eyes"
+	reactionReviewed  = "white_check_mark"
+)
+
+// subscribeToReactionAdded subscribes to the Redis reaction-added channel and
+// dispatches each event to handleReactionAdded.
+func subscribeToReactionAdded(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	subscribe(ctx, rdb, config.RedisReactionAddedChannel, func(payload string) {
+		handleReactionAdded(ctx, rdb, slackClient, payload, config)
+	})
+}
+
+// handleReactionAdded tracks reviews of posted PRs: a 👀 or ✅ on the
+// latest post of a PR is recorded in the posted-PR index and the post's
+// footer is edited to list who is reviewing and who has reviewed it. Other
+// reactions, and reactions on anything but a PR post, are ignored.
+func handleReactionAdded(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	config = withConfigOverrides(ctx, rdb, config)
+	var event ReactionAddedEvent
+	if err := envelope.Decode(envelope.SchemaReactionAdded, []byte(payload), &event); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling reaction_added event: %v", err)
+		deadLetter(ctx, rdb, config.RedisReactionAddedChannel, payload, err)
+		return
+	}
+	if event.Type != "reaction_added" || event.User == "" || event.Item.Type != "message" ||
+		(event.Reaction != reactionReviewing && event.Reaction != reactionReviewed) {
+		return
+	}
+
+	msg, err := fetchMessage(ctx, slackClient, event.Item.Channel, event.Item.TS)
+	if err != nil {
+		logging.WarnContext(ctx, "Error reading message %s in %s: %v", event.Item.TS, event.Item.Channel, err)
+		return
+	}
+	if msg == nil || msg.Metadata.EventType != "pr_posted" {
+		return
+	}
+	repo, _ := msg.Metadata.EventPayload["repository"].(string)
+	number, _ := msg.Metadata.EventPayload["pr_number"].(float64)
+	threadKey, _ := msg.Metadata.EventPayload["thread_key"].(string)
+	if config.DryRun {
+		logging.InfoContext(ctx, "[dry-run] Review reaction %s on %s#%d by %s not recorded", event.Reaction, repo, int(number), event.User)
+		return
+	}
+
+	var marked bool
+	rec, err := updatePostedPR(ctx, rdb, repo, int(number), func(rec *PostedPR) bool {
+		marked = false
+		// Only the latest post of a PR is tracked; reactions on older posts
+		// would otherwise edit a footer nobody is looking at.
+		if !rec.isPost(event.Item.Channel, event.Item.TS, threadKey) {
+			return false
+		}
+		learned := rec.MessageTS == ""
+		rec.MessageTS = event.Item.TS
+		marked = rec.markReview(event.User, event.Reaction == reactionReviewed)
+		return marked || learned
+	})
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logging.ErrorContext(ctx, "Error recording review reaction on %s#%d: %v", repo, int(number), err)
+		}
+		return
+	}
+	if !marked {
+		return
+	}
+	logging.InfoContext(ctx, "User %s reacted :%s: to %s#%d", event.User, event.Reaction, repo, rec.Number)
+	if event.Reaction == reactionReviewed {
+		if err := recordFirstReview(ctx, rdb, repo, rec.Number, time.Now()); err != nil {
+			logging.WarnContext(ctx, "Error recording review latency for %s#%d: %v", repo, rec.Number, err)
+		}
+	}
+
+	blocks := reviewStatusBlocks(msg.Blocks.BlockSet, msg.Text, rec.reviewStatus())
+	if _, _, _, err := slackClient.UpdateMessageContext(ctx, event.Item.Channel, event.Item.TS,
+		slack.MsgOptionText(msg.Text, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionMetadata(msg.Metadata),
+	); err != nil {
+		logging.ErrorContext(ctx, "Error updating review status of %s#%d: %v", repo, rec.Number, err)
+	}
+}
+
+// fetchMessage returns the message at ts in channel, with its metadata, or
+// nil when there is none.
+func fetchMessage(ctx context.Context, slackClient SlackClient, channel, ts string) (*slack.Message, error) {
+	resp, err := slackClient.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+		ChannelID:          channel,
+		Latest:             ts,
+		Inclusive:          true,
+		Limit:              1,
+		IncludeAllMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Messages) == 0 || resp.Messages[0].Timestamp != ts {
+		return nil, nil
+	}
+	return &resp.Messages[0], nil
+}
+
+// isPost reports whether the message at ts in channel, carrying threadKey in
+// its metadata, is this post. Until the post's timestamp is known it is
+// recognised by its thread key; posts recorded without one by their channel.
+func (rec *PostedPR) isPost(channel, ts, threadKey string) bool {
+	switch {
+	case rec.Channel != channel:
+		return false
+	case rec.MessageTS != "":
+		return rec.MessageTS == ts
+	default:
+		return rec.ThreadKey == "" || rec.ThreadKey == threadKey
+	}
+}
+
+// markReview records userID as reviewing the PR, or as having reviewed it
+// when reviewed is set, and reports whether that changed anything. A user
+// who has reviewed the PR is no longer listed as reviewing it.
+func (rec *PostedPR) markReview(userID string, reviewed bool) bool {
+	if slices.Contains(rec.Reviewed, userID) {
+		return false
+	}
+	if !reviewed {
+		if slices.Contains(rec.Reviewing, userID) {
+			return false
+		}
+		rec.Reviewing = append(rec.Reviewing, userID)
+		return true
+	}
+	rec.Reviewing = slices.DeleteFunc(rec.Reviewing, func(id string) bool { return id == userID })
+	rec.Reviewed = append(rec.Reviewed, userID)
+	return true
+}
+
+// reviewStatus renders the review footer, e.g. ":eyes: Reviewing: <@U1> ·
+// :white_check_mark: Reviewed by <@U2>"; empty when nobody has reacted.
+func (rec *PostedPR) reviewStatus() string {
+	mentions := func(ids []string) string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = fmt.Sprintf("<@%s>", id)
+		}
+		return strings.Join(out, ", ")
+	}
+	var parts []string
+	if len(rec.Reviewing) > 0 {
+		parts = append(parts, ":eyes: Reviewing: "+mentions(rec.Reviewing))
+	}
+	if len(rec.Reviewed) > 0 {
+		parts = append(parts, ":white_check_mark: Reviewed by "+mentions(rec.Reviewed))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// reviewStatusBlocks returns a post's blocks with its review footer replaced
+// by status. Plain-text posts are turned into a section block first so the
+// footer can follow them.
+func reviewStatusBlocks(blocks []slack.Block, text, status string) []slack.Block {
+	out := make([]slack.Block, 0, len(blocks)+2)
+	for _, block := range blocks {
+		if footer, ok := block.(*slack.ContextBlock); ok && footer.BlockID == reviewStatusBlockID {
+			continue
+		}
+		out = append(out, block)
+	}
+	if len(out) == 0 {
+		out = append(out, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+	}
+	if status != "" {
+		out = append(out, slack.NewContextBlock(reviewStatusBlockID, slack.NewTextBlockObject(slack.MarkdownType, status, false, false)))
+	}
+	return out
+}
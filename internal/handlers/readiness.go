@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// PRReadiness is the extra merge-readiness detail fetched over GraphQL just
+// before a PR is posted. gh's list output does not carry it.
+type PRReadiness struct {
+	// MergeStateStatus is GitHub's mergeStateStatus: CLEAN, BLOCKED, BEHIND,
+	// DIRTY, DRAFT, HAS_HOOKS, UNSTABLE, or UNKNOWN.
+	MergeStateStatus string `json:"mergeStateStatus"`
+	// Mergeable is MERGEABLE, CONFLICTING, or UNKNOWN while GitHub is still
+	// computing it.
+	Mergeable         string        `json:"mergeable,omitempty"`
+	ReviewThreads     int           `json:"reviewThreads"`
+	UnresolvedThreads int           `json:"unresolvedThreads"`
+	CheckSuites       []CheckStatus `json:"checkSuites"`
+	// Checks are the individual check runs and commit statuses on the head
+	// commit, marked with whether branch protection requires them.
+	Checks []CheckStatus `json:"checks,omitempty"`
+}
+
+// prReadinessQuery fetches mergeability, merge state, review threads, and the
+// check suites and individual checks on the PR's head commit.
+const prReadinessQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      mergeable
+      mergeStateStatus
+      reviewThreads(first: 100) { totalCount nodes { isResolved } }
+      commits(last: 1) { nodes { commit {
+        checkSuites(first: 50) { nodes { status conclusion app { name } } }
+        statusCheckRollup { contexts(first: 100) { nodes {
+          __typename
+          ... on CheckRun { name status conclusion isRequired(pullRequestNumber: $number) }
+          ... on StatusContext { context state isRequired(pullRequestNumber: $number) }
+        } } }
+      } } }
+    }
+  }
+}`
+
+// prReadinessResponse mirrors the data shape returned by prReadinessQuery.
+type prReadinessResponse struct {
+	Repository struct {
+		PullRequest *struct {
+			Mergeable        string `json:"mergeable"`
+			MergeStateStatus string `json:"mergeStateStatus"`
+			ReviewThreads    struct {
+				TotalCount int `json:"totalCount"`
+				Nodes      []struct {
+					IsResolved bool `json:"isResolved"`
+				} `json:"nodes"`
+			} `json:"reviewThreads"`
+			Commits struct {
+				Nodes []struct {
+					Commit struct {
+						CheckSuites struct {
+							Nodes []struct {
+								Status     string `json:"status"`
+								Conclusion string `json:"conclusion"`
+								App        struct {
+									Name string `json:"name"`
+								} `json:"app"`
+							} `json:"nodes"`
+						} `json:"checkSuites"`
+						StatusCheckRollup *struct {
+							Contexts struct {
+								Nodes []CheckStatus `json:"nodes"`
+							} `json:"contexts"`
+						} `json:"statusCheckRollup"`
+					} `json:"commit"`
+				} `json:"nodes"`
+			} `json:"commits"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+// fetchPRReadiness queries GraphQL for the readiness of one PR.
+func (c *githubClient) fetchPRReadiness(ctx context.Context, repo string, number int) (*PRReadiness, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository %q", repo)
+	}
+
+	var resp prReadinessResponse
+	vars := map[string]interface{}{"owner": owner, "name": name, "number": number}
+	if err := c.graphql(ctx, prReadinessQuery, vars, &resp); err != nil {
+		return nil, err
+	}
+	pr := resp.Repository.PullRequest
+	if pr == nil {
+		return nil, fmt.Errorf("pull request %s#%d not found", repo, number)
+	}
+
+	r := &PRReadiness{
+		Mergeable:        pr.Mergeable,
+		MergeStateStatus: pr.MergeStateStatus,
+		ReviewThreads:    pr.ReviewThreads.TotalCount,
+	}
+	for _, t := range pr.ReviewThreads.Nodes {
+		if !t.IsResolved {
+			r.UnresolvedThreads++
+		}
+	}
+	for _, commit := range pr.Commits.Nodes {
+		for _, s := range commit.Commit.CheckSuites.Nodes {
+			r.CheckSuites = append(r.CheckSuites, CheckStatus{
+				TypeName:   "CheckSuite",
+				Name:       s.App.Name,
+				Status:     s.Status,
+				Conclusion: s.Conclusion,
+			})
+		}
+		if rollup := commit.Commit.StatusCheckRollup; rollup != nil {
+			r.Checks = append(r.Checks, rollup.Contexts.Nodes...)
+		}
+	}
+	return r, nil
+}
+
+// enrichPRReadiness attaches GraphQL readiness to pr when GitHub credentials
+// are configured. Failures are logged and the PR is posted without it.
+func enrichPRReadiness(ctx context.Context, pr *PRItem, repo string, config config.Config) {
+	if !config.HasGitHubAuth() || pr.Readiness != nil {
+		return
+	}
+	r, err := newGitHubClientFromConfig(config).fetchPRReadiness(ctx, repo, pr.Number)
+	if err != nil {
+		logging.WarnContext(ctx, "Could not fetch readiness for PR #%d from %s: %v", pr.Number, repo, err)
+		return
+	}
+	pr.Readiness = r
+}
+
+// checkState rolls the check suites up the same way as statusCheckRollup.
+func (r *PRReadiness) checkState() CheckState {
+	return PRItem{StatusCheckRollup: r.CheckSuites}.checkState()
+}
+
+// failingChecks returns the names of the PR's failing checks. When the
+// readiness lookup said which checks are required, only failing required
+// checks are returned and required is true; otherwise every failing check in
+// gh's statusCheckRollup is returned.
+func (pr *PRItem) failingChecks() (names []string, required bool) {
+	checks := pr.StatusCheckRollup
+	if pr.Readiness != nil && len(pr.Readiness.Checks) > 0 {
+		checks, required = pr.Readiness.Checks, true
+	}
+	for _, c := range checks {
+		if c.state() == CheckStateFailing && (c.IsRequired || !required) {
+			names = append(names, c.name())
+		}
+	}
+	return names, required
+}
+
+// failingChecksContext renders the failing checks as a context line, e.g.
+// ":x: Failing required checks: build, lint", or "" when none are failing.
+func failingChecksContext(pr *PRItem) string {
+	names, required := pr.failingChecks()
+	if len(names) == 0 {
+		return ""
+	}
+	label := "Failing checks"
+	if required {
+		label = "Failing required checks"
+	}
+	return fmt.Sprintf(":x: %s: %s", label, escapeSlackText(strings.Join(names, ", ")))
+}
+
+// mergeStateLabels maps mergeStateStatus values to human-readable text.
+var mergeStateLabels = map[string]string{
+	"CLEAN":     ":large_green_circle: ready to merge",
+	"HAS_HOOKS": ":large_green_circle: ready to merge (with hooks)",
+	"UNSTABLE":  ":large_yellow_circle: mergeable, non-required checks failing",
+	"BLOCKED":   ":red_circle: blocked",
+	"BEHIND":    ":large_yellow_circle: behind base branch",
+	"DIRTY":     ":red_circle: merge conflicts",
+	"DRAFT":     ":white_circle: draft",
+}
+
+// summary renders readiness as one mrkdwn line, e.g.
+// ":large_green_circle: ready to merge · checks ✅ · 1/3 threads unresolved".
+func (r *PRReadiness) summary() string {
+	var parts []string
+	if label, ok := mergeStateLabels[r.MergeStateStatus]; ok {
+		parts = append(parts, label)
+	}
+	if emoji := checkStateEmoji(r.checkState()); emoji != "" {
+		parts = append(parts, "checks "+emoji)
+	}
+	if r.ReviewThreads > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d threads unresolved", r.UnresolvedThreads, r.ReviewThreads))
+	}
+	return strings.Join(parts, " · ")
+}
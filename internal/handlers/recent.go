@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	recentReposKeyPrefix = "slashvibepr:recent:"
+	// recentReposShown is how many recent repos are surfaced first.
+	recentReposShown = 5
+	// recentReposKept bounds the sorted set so it does not grow forever.
+	recentReposKept = 20
+	recentReposTTL  = 30 * 24 * time.Hour
+)
+
+// recentReposKey returns the sorted set of repos a user has selected, scored
+// by when they last selected each one.
+func recentReposKey(userID string) string {
+	return recentReposKeyPrefix + userID
+}
+
+// recordRecentRepo marks repo ("owner/name") as just used by userID.
+func recordRecentRepo(ctx context.Context, rdb *redis.Client, userID, repo string, at time.Time) error {
+	if userID == "" {
+		return nil
+	}
+	key := recentReposKey(userID)
+	pipe := rdb.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.Unix()), Member: repo})
+	pipe.ZRemRangeByRank(ctx, key, 0, -recentReposKept-1)
+	pipe.Expire(ctx, key, recentReposTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record recent repo: %w", err)
+	}
+	return nil
+}
+
+// loadRecentRepos returns the names of the user's most recently used repos
+// in org, newest first.
+func loadRecentRepos(ctx context.Context, rdb *redis.Client, userID, org string) ([]string, error) {
+	if userID == "" {
+		return nil, nil
+	}
+	repos, err := rdb.ZRevRange(ctx, recentReposKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recent repos: %w", err)
+	}
+	var names []string
+	for _, repo := range repos {
+		if name, ok := strings.CutPrefix(repo, org+"/"); ok {
+			names = append(names, name)
+			if len(names) == recentReposShown {
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// rankRecentFirst moves the recent repos present in repos to the front,
+// keeping recency order, followed by the rest in their original order.
+func rankRecentFirst(repos, recent []string) []string {
+	present := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		present[repo] = true
+	}
+	ranked := make([]string, 0, len(repos))
+	seen := make(map[string]bool, len(recent))
+	for _, repo := range recent {
+		if present[repo] && !seen[repo] {
+			ranked = append(ranked, repo)
+			seen[repo] = true
+		}
+	}
+	for _, repo := range repos {
+		if !seen[repo] {
+			ranked = append(ranked, repo)
+		}
+	}
+	return ranked
+}
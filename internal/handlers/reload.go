@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// configReloadKey counts the /pr admin reload requests. Each replica
+// re-reads config.yaml when it sees the count change.
+const configReloadKey = "slashvibepr:config-reload"
+
+// reloadedConfig is this replica's copy of config.yaml as last reloaded, and
+// the reload count it was read for.
+var reloadedConfig struct {
+	sync.Mutex
+	generation int64
+	config     *config.Config
+}
+
+// withReloadedConfig returns config with the settings config.Reload refreshes
+// taken from config.yaml as of the latest /pr admin reload, re-reading the
+// file when a reload was requested since it was last read. Without a
+// reload, or when Redis or the file cannot be read, config is returned with
+// the last successfully reloaded settings, if any.
+func withReloadedConfig(ctx context.Context, rdb *redis.Client, base config.Config) config.Config {
+	generation, err := rdb.Get(ctx, configReloadKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		logging.WarnContext(ctx, "Error reading config reload count: %v", err)
+	}
+
+	reloadedConfig.Lock()
+	defer reloadedConfig.Unlock()
+	if err == nil && generation != reloadedConfig.generation {
+		next, err := config.Reload(base)
+		if err != nil {
+			logging.WarnContext(ctx, "Error reloading config: %v", err)
+		} else {
+			logging.InfoContext(ctx, "Reloaded %s (reload %d)", config.Path(), generation)
+			reloadedConfig.config = &next
+		}
+		reloadedConfig.generation = generation
+	}
+	if reloadedConfig.config == nil {
+		return base
+	}
+	return *reloadedConfig.config
+}
+
+// requestConfigReload checks that config.yaml still parses and asks every
+// replica to re-read it.
+func requestConfigReload(ctx context.Context, rdb *redis.Client, current config.Config) error {
+	if _, err := config.Reload(current); err != nil {
+		return err
+	}
+	if err := rdb.Incr(ctx, configReloadKey).Err(); err != nil {
+		return fmt.Errorf("failed to request reload: %w", err)
+	}
+	return nil
+}
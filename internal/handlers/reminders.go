@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const (
+	poppitPRReminderType = "slash-vibe-pr-reminder"
+	// reminderLockKey stops replicas from scanning the index in the same
+	// tick should two briefly both lead during a handover.
+	reminderLockKey = "slashvibepr:reminders:lock"
+
+	// reminderCheckTimeout bounds the GitHub lookups for one PR in api mode.
+	reminderCheckTimeout = 10 * time.Second
+)
+
+// inQuietHours reports whether t falls in the quiet window, which may wrap
+// past midnight (e.g. 20:00-08:00).
+func inQuietHours(window string, t time.Time) bool {
+	start, end, err := config.ParseQuietHours(window)
+	if err != nil || start == end {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// reminderDue reports whether a post should be re-pinged at now: it has
+// waited the channel's threshold since it was posted or last reminded, has
+// reminders left, and now is outside quiet hours.
+func reminderDue(rec PostedPR, rc config.ReminderChannelConfig, now time.Time) bool {
+	if rec.RemindersDone || rec.Reminders >= rc.ReminderLimit() {
+		return false
+	}
+	since := rec.PostedAt
+	if !rec.LastRemindedAt.IsZero() {
+		since = rec.LastRemindedAt
+	}
+	if now.Sub(since) < rc.Threshold() {
+		return false
+	}
+	return !inQuietHours(rc.QuietHours, now)
+}
+
+// runStaleReminders scans the posted-PR index every reminders.interval until
+// ctx is cancelled, skipping ticks while this replica is not the leader. It
+// does nothing when no channel has reminders configured.
+func runStaleReminders(ctx context.Context, rdb *redis.Client, leader *leaderElection, config config.Config) {
+	if len(config.ReminderChannels) == 0 {
+		return
+	}
+	logging.InfoContext(ctx, "Stale PR reminders enabled for %d channel(s), checking every %s", len(config.ReminderChannels), config.ReminderInterval)
+
+	ticker := time.NewTicker(config.ReminderInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if leader.IsLeader() {
+				scanStaleReminders(ctx, rdb, time.Now().In(config.ReminderTimezone), config)
+			}
+		}
+	}
+}
+
+// scanStaleReminders checks every indexed post whose reminder is due. Each
+// due post is claimed (its count bumped) before GitHub is asked whether it is
+// still open and unreviewed, so a slow check is never repeated by the next
+// tick.
+func scanStaleReminders(ctx context.Context, rdb *redis.Client, now time.Time, config config.Config) {
+	config = withConfigOverrides(ctx, rdb, config)
+	locked, err := rdb.SetNX(ctx, reminderLockKey, 1, config.ReminderInterval/2).Result()
+	if err != nil {
+		logging.ErrorContext(ctx, "Error taking reminder lock: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	keys, err := rdb.ZRange(ctx, postedPRIndexKey, 0, -1).Result()
+	if err != nil {
+		logging.ErrorContext(ctx, "Error reading posted-PR index: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		rec, err := loadPostedPRByKey(ctx, rdb, key)
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				logging.WarnContext(ctx, "Error loading %s: %v", key, err)
+			}
+			continue
+		}
+		rc, ok := config.ReminderChannels[rec.Channel]
+		if !ok || !reminderDue(*rec, rc, now) {
+			continue
+		}
+
+		rec, err = updatePostedPR(ctx, rdb, rec.Repo, rec.Number, func(rec *PostedPR) bool {
+			if rc, ok := config.ReminderChannels[rec.Channel]; !ok || !reminderDue(*rec, rc, now) {
+				return false
+			}
+			rec.Reminders++
+			rec.LastRemindedAt = now
+			return true
+		})
+		if err != nil {
+			logging.ErrorContext(ctx, "Error claiming reminder for %s: %v", key, err)
+			continue
+		}
+		if rec != nil {
+			checkStalePR(ctx, rdb, *rec, config)
+		}
+	}
+}
+
+// checkStalePR asks GitHub whether the post's PR is still open without any
+// review: inline in api mode, or through a queued `gh pr view` whose output
+// is handled by handlePRReminderOutput.
+func checkStalePR(ctx context.Context, rdb *redis.Client, rec PostedPR, config config.Config) {
+	if !config.UsesGitHubAPI() {
+		err := pushPoppitCommand(ctx, rdb, poppit.Command{
+			Repo:     rec.Repo,
+			Type:     poppitPRReminderType,
+			Dir:      "/tmp",
+			Commands: []string{fmt.Sprintf("gh pr view %d --repo %s --json state,reviews", rec.Number, rec.Repo)},
+			Metadata: map[string]interface{}{"repo": rec.Repo, "number": rec.Number},
+		}, config)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error queueing reminder check for %s#%d: %v", rec.Repo, rec.Number, err)
+		}
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, reminderCheckTimeout)
+	defer cancel()
+	open, reviews, err := newGitHubClientFromConfig(config).reviewStatus(checkCtx, rec.Repo, rec.Number)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error checking %s#%d for a reminder: %v", rec.Repo, rec.Number, err)
+		return
+	}
+	remindOrStop(ctx, rdb, rec, open && reviews == 0, config)
+}
+
+// handlePRReminderOutput finishes a reminder check queued on Poppit.
+func handlePRReminderOutput(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	repo, _ := output.Metadata["repo"].(string)
+	n, _ := output.Metadata["number"].(float64)
+	rec, err := loadPostedPR(ctx, rdb, repo, int(n))
+	if err != nil {
+		logging.WarnContext(ctx, "Reminder check for %s#%d has no posted PR: %v", repo, int(n), err)
+		return
+	}
+
+	if output.Failed() {
+		logging.ErrorContext(ctx, "Reminder check for %s#%d failed: %s", repo, int(n), output.ErrorText())
+		return
+	}
+
+	var view struct {
+		State   string            `json:"state"`
+		Reviews []json.RawMessage `json:"reviews"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &view); err != nil {
+		logging.ErrorContext(ctx, "Error parsing reminder check for %s#%d: %v", repo, int(n), err)
+		return
+	}
+	remindOrStop(ctx, rdb, *rec, view.State == "OPEN" && len(view.Reviews) == 0, config)
+}
+
+// remindOrStop posts the reminder for a PR still waiting for review, or
+// stops further reminders once it has been reviewed or closed.
+func remindOrStop(ctx context.Context, rdb *redis.Client, rec PostedPR, waiting bool, config config.Config) {
+	if !waiting {
+		if _, err := updatePostedPR(ctx, rdb, rec.Repo, rec.Number, func(rec *PostedPR) bool {
+			rec.RemindersDone = true
+			return true
+		}); err != nil {
+			logging.WarnContext(ctx, "Error stopping reminders for %s#%d: %v", rec.Repo, rec.Number, err)
+		}
+		return
+	}
+
+	msg := buildReminderMessage(rec, time.Now(), config)
+	stampSlackLinerMetadata(ctx, &msg)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling reminder: %v", err)
+		return
+	}
+	if config.DryRun {
+		logging.InfoContext(ctx, "[dry-run] Reminder for %s#%d not pushed: %s", rec.Repo, rec.Number, payload)
+		return
+	}
+	if err := pushSlackLiner(ctx, rdb, config, payload); err != nil {
+		logging.ErrorContext(ctx, "Error pushing reminder for %s#%d: %v", rec.Repo, rec.Number, err)
+		return
+	}
+	logging.InfoContext(ctx, "Sent reminder %d for %s#%d", rec.Reminders, rec.Repo, rec.Number)
+}
+
+// buildReminderMessage formats the threaded reminder for a stale post.
+func buildReminderMessage(rec PostedPR, now time.Time, config config.Config) SlackLinerMessage {
+	text := fmt.Sprintf(":alarm_clock: <%s|%s#%d> has been waiting %s for a review.",
+		rec.URL, rec.Repo, rec.Number, slackui.FormatAge(now.Sub(rec.PostedAt)))
+	return SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            rec.Channel,
+		Text:               text,
+		TTL:                config.MessageTTL(),
+		ThreadKey:          rec.ThreadKey,
+		Metadata: map[string]interface{}{
+			"event_type": "pr_reminder",
+			"event_payload": map[string]interface{}{
+				"pr_number":  rec.Number,
+				"repository": rec.Repo,
+				"reminder":   rec.Reminders,
+			},
+		},
+	}
+}
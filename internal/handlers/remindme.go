@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// postActionsBlockID identifies the actions block on posted PRs.
+	postActionsBlockID = "post_actions"
+	postRemindActionID = "post_remind"
+	remindMeJobType    = "remind_me"
+)
+
+// remindMeIntervals are the delays offered by the "Remind me" menu.
+var remindMeIntervals = []struct {
+	Value string
+	Label string
+	Delay time.Duration
+}{
+	{"1h", "In 1 hour", time.Hour},
+	{"4h", "In 4 hours", 4 * time.Hour},
+	{"1d", "In 1 day", 24 * time.Hour},
+}
+
+// remindMeJob is the delayed job that DMs UserID about a posted PR.
+type remindMeJob struct {
+	UserID string `json:"user_id"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	Title  string `json:"title,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// remindMeSelect returns the "Remind me" menu for a posted PR. Each option's
+// value is "<interval>|<repo>#<number>", so the click needs no other state.
+func remindMeSelect(repo string, number int) *slack.SelectBlockElement {
+	options := make([]*slack.OptionBlockObject, 0, len(remindMeIntervals))
+	for _, interval := range remindMeIntervals {
+		options = append(options, slack.NewOptionBlockObject(
+			interval.Value+"|"+postRef(repo, number),
+			slack.NewTextBlockObject(slack.PlainTextType, interval.Label, false, false),
+			nil,
+		))
+	}
+	return slack.NewOptionsSelectBlockElement(slack.OptTypeStatic,
+		slack.NewTextBlockObject(slack.PlainTextType, "⏰ Remind me", false, false),
+		postRemindActionID, options...)
+}
+
+// parseRemindMeValue splits a "Remind me" option value into its delay and PR.
+func parseRemindMeValue(value string) (delay time.Duration, repo string, number int, err error) {
+	interval, ref, _ := strings.Cut(value, "|")
+	repo, number, err = parsePostRef(ref)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	for _, i := range remindMeIntervals {
+		if i.Value == interval {
+			return i.Delay, repo, number, nil
+		}
+	}
+	return 0, "", 0, fmt.Errorf("invalid interval %q", interval)
+}
+
+// handleRemindMe schedules the DM picked from a posted PR's "Remind me" menu
+// and confirms it to the clicker.
+func handleRemindMe(ctx context.Context, rdb *redis.Client, action BlockActionPayload, value string, config config.Config) {
+	delay, repo, number, err := parseRemindMeValue(value)
+	if err != nil {
+		logging.WarnContext(ctx, "Ignoring Remind me from user %s: %v", action.User.Username, err)
+		return
+	}
+
+	job := remindMeJob{UserID: action.User.ID, Repo: repo, Number: number}
+	if rec, err := loadPostedPR(ctx, rdb, repo, number); err == nil {
+		job.Title, job.URL = rec.Title, rec.URL
+	} else if !errors.Is(err, redis.Nil) {
+		logging.WarnContext(ctx, "Error loading posted PR %s#%d: %v", repo, number, err)
+	}
+
+	reply := fmt.Sprintf(":alarm_clock: I'll remind you about %s %s.", remindMeLink(job), strings.ToLower(remindMeLabel(delay)))
+	if err := scheduleJob(ctx, rdb, remindMeJobType, time.Now().Add(delay), job); err != nil {
+		logging.ErrorContext(ctx, "Error scheduling reminder of %s#%d for user %s: %v", repo, number, action.User.Username, err)
+		reply = ":x: Could not set the reminder. Please try again."
+	} else {
+		logging.InfoContext(ctx, "User %s asked to be reminded about %s#%d in %s", action.User.Username, repo, number, delay)
+	}
+	if err := respondEphemeral(ctx, action.ResponseURL, reply); err != nil {
+		logging.ErrorContext(ctx, "Error confirming reminder for user %s: %v", action.User.Username, err)
+	}
+}
+
+// remindMeLabel returns the menu label of delay, e.g. "In 4 hours".
+func remindMeLabel(delay time.Duration) string {
+	for _, i := range remindMeIntervals {
+		if i.Delay == delay {
+			return i.Label
+		}
+	}
+	return delay.String()
+}
+
+// remindMeLink renders the PR as a link when its URL is known.
+func remindMeLink(job remindMeJob) string {
+	if job.URL == "" {
+		return fmt.Sprintf("%s#%d", job.Repo, job.Number)
+	}
+	return fmt.Sprintf("<%s|%s#%d>", job.URL, job.Repo, job.Number)
+}
+
+// runRemindMeJob DMs the user who asked to be reminded about a PR.
+func runRemindMeJob(ctx context.Context, rdb *redis.Client, _ SlackClient, payload json.RawMessage, config config.Config) error {
+	var job remindMeJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to parse reminder: %w", err)
+	}
+	msg := buildRemindMeMessage(job, config)
+	stampSlackLinerMetadata(ctx, &msg)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reminder: %w", err)
+	}
+	if config.DryRun {
+		logging.InfoContext(ctx, "[dry-run] Reminder DM for %s#%d not pushed: %s", job.Repo, job.Number, data)
+		return nil
+	}
+	if err := pushSlackLiner(ctx, rdb, config, data); err != nil {
+		return fmt.Errorf("failed to push reminder: %w", err)
+	}
+	logging.InfoContext(ctx, "Sent reminder DM for %s#%d to %s", job.Repo, job.Number, job.UserID)
+	return nil
+}
+
+// buildRemindMeMessage formats the reminder DM. Posting to a user ID
+// delivers the message in the user's DM with the app.
+func buildRemindMeMessage(job remindMeJob, config config.Config) SlackLinerMessage {
+	text := fmt.Sprintf(":alarm_clock: You asked to be reminded about %s", remindMeLink(job))
+	if job.Title != "" {
+		text += ": " + escapeSlackText(job.Title)
+	}
+	return SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            job.UserID,
+		Text:               text,
+		Metadata: map[string]interface{}{
+			"event_type": "pr_remind_me",
+			"event_payload": map[string]interface{}{
+				"pr_number":  job.Number,
+				"repository": job.Repo,
+				"user_id":    job.UserID,
+			},
+		},
+	}
+}
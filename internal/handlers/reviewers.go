@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const poppitPRAddReviewersType = "slash-vibe-pr-add-reviewers"
+
+// reviewerOrg returns the org whose members are offered as reviewers for a
+// PR chooser: the owner of the modal's repo, or the default org for
+// cross-repo searches.
+func reviewerOrg(privateMetadata string, config config.Config) string {
+	var meta PRModalPrivateMetadata
+	if err := session.Open(privateMetadata, &meta); err == nil {
+		if owner, _, ok := strings.Cut(meta.Repo, "/"); ok && validOwnerName.MatchString(owner) {
+			return owner
+		}
+	}
+	return config.GitHubOrg
+}
+
+// reviewerSuggestions answers the PR chooser's reviewer select from the
+// cached member catalog of the PR's org.
+func reviewerSuggestions(ctx context.Context, rdb *redis.Client, suggestion BlockSuggestionPayload, config config.Config) []*slack.OptionBlockObject {
+	org := reviewerOrg(suggestion.View.PrivateMetadata, config)
+
+	members, err := memberCatalog(ctx, rdb, org, config)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading member catalog for %s: %v", org, err)
+	}
+
+	matches := filterReposByQuery(members, suggestion.Value)
+	options := make([]*slack.OptionBlockObject, 0, len(matches))
+	for _, login := range matches {
+		options = append(options, slack.NewOptionBlockObject(login, slack.NewTextBlockObject(slack.PlainTextType, login, false, false), nil))
+	}
+
+	logging.DebugContext(ctx, "Serving %d reviewer suggestions for query %q in org %s", len(options), suggestion.Value, org)
+	return options
+}
+
+// selectedReviewers returns the valid GitHub logins chosen in the reviewer
+// select, capped at maxRequestedReviewers.
+func selectedReviewers(values map[string]map[string]interface{}) []string {
+	var logins []string
+	for _, login := range extractSelectedValues(values, slackui.ReviewerBlockID, slackui.ReviewerSelectActionID) {
+		if !validGitHubLogin.MatchString(login) {
+			logging.Warn("Ignoring invalid reviewer login %q", login)
+			continue
+		}
+		if len(logins) < slackui.MaxRequestedReviewers {
+			logins = append(logins, login)
+		}
+	}
+	return logins
+}
+
+// buildAddReviewersCommand returns the gh command requesting reviews.
+func buildAddReviewersCommand(repo string, number int, reviewers []string) string {
+	return fmt.Sprintf("gh pr edit %d --repo %s --add-reviewer %s", number, repo, strings.Join(reviewers, ","))
+}
+
+// requestReviewers asks Poppit to request reviews on a posted PR. In dry-run
+// mode the command is only logged.
+func requestReviewers(ctx context.Context, rdb *redis.Client, pr *PRItem, repo string, reviewers []string, inv Invocation, config config.Config) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	command := buildAddReviewersCommand(repo, pr.Number, reviewers)
+	if isDryRun(inv, config) {
+		logging.InfoContext(ctx, "[dry-run] Reviewer request for PR #%d from %s not queued: %s", pr.Number, repo, command)
+		return nil
+	}
+
+	metadata := inv.metadata()
+	metadata["repo"] = repo
+	metadata["number"] = pr.Number
+	metadata["reviewers"] = strings.Join(reviewers, ",")
+	if err := pushPoppitCommand(ctx, rdb, poppit.Command{
+		Repo:     repo,
+		Type:     poppitPRAddReviewersType,
+		Dir:      "/tmp",
+		Commands: []string{command},
+		Metadata: metadata,
+	}, config); err != nil {
+		return fmt.Errorf("failed to request reviewers: %w", err)
+	}
+
+	logging.InfoContext(ctx, "Requested reviews from %s on PR #%d in %s", strings.Join(reviewers, ", "), pr.Number, repo)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   auditActionRequestReviewers,
+		UserID:   inv.UserID,
+		Username: inv.Username,
+		Repo:     repo,
+		Number:   pr.Number,
+		Channel:  config.SlackChannelID,
+		Detail:   strings.Join(reviewers, ", "),
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing reviewer request on PR #%d in %s: %v", pr.Number, repo, err)
+	}
+	return nil
+}
+
+// addReviewersFailed reports whether `gh pr edit` failed. On success gh
+// prints the PR's URL; anything else is an error message.
+func addReviewersFailed(output string, number int) bool {
+	return !strings.Contains(output, fmt.Sprintf("/pull/%d", number))
+}
+
+// handleAddReviewersOutput tells the poster when a reviewer request failed;
+// successful requests are only logged.
+func handleAddReviewersOutput(ctx context.Context, slackClient SlackClient, output poppit.Output, config config.Config) {
+	repo, _ := output.Metadata["repo"].(string)
+	reviewers, _ := output.Metadata["reviewers"].(string)
+	number := 0
+	if n, ok := output.Metadata["number"].(float64); ok {
+		number = int(n)
+	}
+	inv := invocationFromMetadata(output.Metadata)
+
+	if !output.Failed() && !addReviewersFailed(output.Output, number) {
+		logging.InfoContext(ctx, "Reviews requested from %s on PR #%d in %s", reviewers, number, repo)
+		return
+	}
+
+	logging.WarnContext(ctx, "Requesting reviewers on PR #%d in %s failed: %s", number, repo, output.ErrorText())
+	if inv.UserID == "" {
+		return
+	}
+	text := fmt.Sprintf(":warning: Could not request reviews from %s on %s#%d:\n```%s```",
+		reviewers, repo, number, output.ErrorText())
+	if _, err := slackClient.PostEphemeralContext(ctx, config.SlackChannelID, inv.UserID, slack.MsgOptionText(text, false)); err != nil {
+		logging.ErrorContext(ctx, "Error notifying user %s of reviewer request failure: %v", inv.UserID, err)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	reviewQueueCallbackID = "pr_review_queue_modal"
+	reviewPostActionID    = "review_post"
+	reviewOpenActionID    = "review_open"
+	reviewBlockIDPrefix   = "review_"
+	// maxReviewQueuePRs keeps the modal under Slack's 100-block limit (one
+	// header plus two blocks per PR).
+	maxReviewQueuePRs = 30
+)
+
+// handleReviewsCommand implements /pr reviews: open PRs where the caller is
+// a requested reviewer, each with "Post to channel" and "Open in GitHub".
+func handleReviewsCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, config config.Config) {
+	login := linkedGitHubLogin(ctx, rdb, cmd, config)
+	if login == "" {
+		return
+	}
+	openPRSearch(ctx, rdb, slackClient, cmd, prSearch{ReviewRequested: login}, config)
+}
+
+// presentReviewQueue replaces the loading modal with the review queue. The
+// PRs are kept in the view's PR session so the per-PR buttons can find them.
+func presentReviewQueue(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID, userID string, search prSearch, prs []PRItem, config config.Config) {
+	if len(prs) == 0 {
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, ":tada: No pull requests are waiting for your review.", config)
+		return
+	}
+	if len(prs) > maxReviewQueuePRs {
+		prs = prs[:maxReviewQueuePRs]
+	}
+
+	prSession := PRModalPrivateMetadata{Repo: search.label(), PRs: prs}
+	if err := savePRSession(ctx, rdb, viewID, prSession, config); err != nil {
+		logging.ErrorContext(ctx, "Error saving review queue session for view_id %s: %v", viewID, err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, "Failed to prepare the review queue. Please try again.", config)
+		return
+	}
+
+	if _, err := updateView(ctx, rdb, slackClient, createReviewQueueModal(prSession, userNow(ctx, rdb, slackClient, userID)), viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating modal with review queue: %v", err)
+	}
+}
+
+// createReviewQueueModal lists each PR with its own actions block. PRs the
+// user already posted from this modal show a confirmation instead of the
+// post button. Dates are shown in now's timezone.
+func createReviewQueueModal(prSession PRModalPrivateMetadata, now time.Time) slack.ModalViewRequest {
+	posted := make(map[string]bool, len(prSession.Posted))
+	for _, v := range prSession.Posted {
+		posted[v] = true
+	}
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType,
+			fmt.Sprintf("*%d pull requests* are waiting for your review.", len(prSession.PRs)), false, false), nil, nil),
+	}
+	for i, pr := range prSession.PRs {
+		value := pr.optionValue()
+		text := fmt.Sprintf("*<%s|%s>*\n%s", pr.URL, escapeSlackText(pr.Title), escapeSlackText(prOptionDescription(pr, now)))
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+
+		open := slack.NewButtonBlockElement(reviewOpenActionID, value, slack.NewTextBlockObject(slack.PlainTextType, "Open in GitHub", false, false))
+		open.URL = pr.URL
+		blockID := fmt.Sprintf("%s%d", reviewBlockIDPrefix, i)
+		if posted[value] {
+			blocks = append(blocks, slack.NewActionBlock(blockID, open))
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, ":white_check_mark: Posted to the channel", false, false)))
+			continue
+		}
+		post := slack.NewButtonBlockElement(reviewPostActionID, value, slack.NewTextBlockObject(slack.PlainTextType, "Post to channel", false, false))
+		post.Style = slack.StylePrimary
+		blocks = append(blocks, slack.NewActionBlock(blockID, post, open))
+	}
+
+	return slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: reviewQueueCallbackID,
+		Title:      slack.NewTextBlockObject(slack.PlainTextType, "Awaiting Your Review", false, false),
+		Close:      slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false),
+		Blocks:     slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// handleReviewPost posts one PR from the review queue and marks it posted.
+func handleReviewPost(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, value string, config config.Config) {
+	prSession, err := loadPRSession(ctx, rdb, action.View.ID)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading review queue session for view_id %s: %v", action.View.ID, err)
+		return
+	}
+	pr := findPR(prSession.PRs, value)
+	if pr == nil {
+		logging.WarnContext(ctx, "Could not find PR %s in review queue session", value)
+		return
+	}
+
+	repo := pr.repoOr(prSession.Repo)
+	inv := Invocation{UserID: action.User.ID, Username: action.User.Username, TeamID: action.Team.ID}
+	if err := sharePR(ctx, rdb, slackClient, pr, repo, inv, PostOptions{}, config); err != nil {
+		logging.ErrorContext(ctx, "Error posting PR from review queue: %v", err)
+		return
+	}
+	logging.InfoContext(ctx, "User %s posted PR #%d from %s via review queue", action.User.Username, pr.Number, repo)
+
+	prSession.Posted = append(prSession.Posted, value)
+	if err := savePRSession(ctx, rdb, action.View.ID, prSession, config); err != nil {
+		logging.WarnContext(ctx, "Error saving review queue session for view_id %s: %v", action.View.ID, err)
+	}
+	if _, err := updateView(ctx, rdb, slackClient, createReviewQueueModal(prSession, userNow(ctx, rdb, slackClient, action.User.ID)), action.View.ID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating review queue modal: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// privilegedUsersKey is the Redis set of Slack user IDs granted the
+// privileged role with /pr admin grant, on top of roles.privileged_users.
+const privilegedUsersKey = "slashvibepr:roles:privileged"
+
+// slackUserRefPattern matches a user mention as escaped by Slack
+// (<@U123|name> or <@U123>) or a bare user ID.
+var slackUserRefPattern = regexp.MustCompile(`^(?:<@([UW][A-Z0-9]+)(?:\|[^>]*)?>|([UW][A-Z0-9]+))$`)
+
+// parseSlackUserRef returns the user ID in a mention or bare ID.
+func parseSlackUserRef(ref string) (string, bool) {
+	m := slackUserRefPattern.FindStringSubmatch(ref)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + m[2], true
+}
+
+// isRestrictedCommand reports whether the subcommand is listed in
+// roles.restricted_commands.
+func isRestrictedCommand(name string, config config.Config) bool {
+	for _, c := range config.RestrictedCommands {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivileged reports whether userID holds the privileged role: an admin, a
+// user listed in roles.privileged_users or granted in Redis, or a member of a
+// usergroup in roles.privileged_usergroups. Lookup errors deny access.
+func isPrivileged(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string, config config.Config) bool {
+	if userID == "" {
+		return false
+	}
+	if isAdmin(config, userID) {
+		return true
+	}
+	for _, id := range config.PrivilegedUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	granted, err := rdb.SIsMember(ctx, privilegedUsersKey, userID).Result()
+	if err != nil {
+		logging.WarnContext(ctx, "Error checking privileged role of %s: %v", userID, err)
+	} else if granted {
+		return true
+	}
+	if slackClient == nil {
+		return false
+	}
+	for _, group := range config.PrivilegedUsergroupIDs {
+		members, err := slackClient.GetUserGroupMembersContext(ctx, group)
+		if err != nil {
+			logging.WarnContext(ctx, "Error listing members of usergroup %s: %v", group, err)
+			continue
+		}
+		for _, id := range members {
+			if id == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorizeCommand refuses a restricted subcommand to users without the
+// privileged role, telling them ephemerally. It reports whether the command
+// may proceed.
+func authorizeCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, name string, config config.Config) bool {
+	if !isRestrictedCommand(name, config) || isPrivileged(ctx, rdb, slackClient, cmd.UserID, config) {
+		return true
+	}
+	logging.WarnContext(ctx, "User %s was refused restricted command /pr %s", cmd.UserName, name)
+	if err := respondEphemeral(ctx, cmd.ResponseURL, fmt.Sprintf(":no_entry: `/pr %s` is restricted to privileged users.", name)); err != nil {
+		logging.ErrorContext(ctx, "Error responding to restricted command for user %s: %v", cmd.UserName, err)
+	}
+	return false
+}
+
+// roleChange returns the /pr admin grant or revoke subcommand, which adds
+// or removes a user from the privileged role kept in Redis.
+func roleChange(op string) func(context.Context, *redis.Client, SlackCommand, []string, config.Config) string {
+	return func(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+		if len(args) != 1 {
+			return ""
+		}
+		userID, ok := parseSlackUserRef(args[0])
+		if !ok {
+			return fmt.Sprintf(":warning: %q is not a Slack user.\n\n%s", args[0], adminUsage())
+		}
+
+		var err error
+		if op == "grant" {
+			err = rdb.SAdd(ctx, privilegedUsersKey, userID).Err()
+		} else {
+			err = rdb.SRem(ctx, privilegedUsersKey, userID).Err()
+		}
+		if err != nil {
+			logging.ErrorContext(ctx, "Error updating privileged role of %s: %v", userID, err)
+			return ":x: Could not update the role. Please try again."
+		}
+
+		logging.InfoContext(ctx, "User %s ran %s of the privileged role for %s", cmd.UserName, op, userID)
+		if err := recordAudit(ctx, rdb, AuditEntry{
+			Action:   op,
+			UserID:   cmd.UserID,
+			Username: cmd.UserName,
+			Detail:   userID,
+		}, config); err != nil {
+			logging.WarnContext(ctx, "Error auditing role change for %s: %v", userID, err)
+		}
+		if op == "grant" {
+			return fmt.Sprintf(":white_check_mark: <@%s> is now privileged.", userID)
+		}
+		return fmt.Sprintf(":white_check_mark: <@%s> is no longer privileged (roles from config are unaffected).", userID)
+	}
+}
+
+// formatPrivilegedUsers lists the privileged role's holders by source.
+func formatPrivilegedUsers(ctx context.Context, rdb *redis.Client, config config.Config) string {
+	mentions := func(ids []string, prefix string) string {
+		if len(ids) == 0 {
+			return "_none_"
+		}
+		parts := make([]string, 0, len(ids))
+		for _, id := range ids {
+			parts = append(parts, fmt.Sprintf("<%s%s>", prefix, id))
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	granted, err := rdb.SMembers(ctx, privilegedUsersKey).Result()
+	if err != nil {
+		logging.WarnContext(ctx, "Error listing privileged users: %v", err)
+	}
+	sort.Strings(granted)
+
+	var b strings.Builder
+	b.WriteString("*Privileged role*\n")
+	fmt.Fprintf(&b, "• Admins: %s\n", mentions(config.SlackAdminUserIDs, "@"))
+	fmt.Fprintf(&b, "• From config: %s\n", mentions(config.PrivilegedUserIDs, "@"))
+	fmt.Fprintf(&b, "• Usergroups: %s\n", mentions(config.PrivilegedUsergroupIDs, "!subteam^"))
+	fmt.Fprintf(&b, "• Granted: %s\n", mentions(granted, "@"))
+	fmt.Fprintf(&b, "Restricted commands: %s", strings.Join(config.RestrictedCommands, ", "))
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const rouletteKeyPrefix = "slashvibepr:roulette:"
+
+// rouletteKey returns the Redis counter tracking the rotation for repo.
+func rouletteKey(repo string) string {
+	return rouletteKeyPrefix + repo
+}
+
+// suggestReviewer picks the next reviewer for repo from
+// github.reviewer_pools, skipping the PR author, and returns "" when the repo
+// has no pool. A per-repo counter makes the rotation fair across posts and
+// replicas; advance=false peeks at the next pick without consuming it, as
+// dry runs must not move the rotation.
+func suggestReviewer(ctx context.Context, rdb *redis.Client, repo, author string, advance bool, config config.Config) string {
+	var candidates []string
+	for _, login := range config.GitHubReviewerPools[repo] {
+		if !strings.EqualFold(login, author) {
+			candidates = append(candidates, login)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	var n int64
+	var err error
+	if advance {
+		n, err = rdb.Incr(ctx, rouletteKey(repo)).Result()
+	} else {
+		n, err = rdb.Get(ctx, rouletteKey(repo)).Int64()
+		if errors.Is(err, redis.Nil) {
+			err = nil
+		}
+		n++
+	}
+	if err != nil {
+		logging.WarnContext(ctx, "Error advancing reviewer rotation for %s: %v", repo, err)
+		return ""
+	}
+	return candidates[(n-1)%int64(len(candidates))]
+}
+
+// reviewerDisplay renders a suggested reviewer as a Slack mention when
+// mapped, otherwise as @login.
+func reviewerDisplay(login, slackID string) string {
+	switch {
+	case login == "":
+		return ""
+	case slackID != "":
+		return "<@" + slackID + ">"
+	}
+	return "@" + login
+}
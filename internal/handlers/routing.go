@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+// postChannel returns the channel repo's PRs are posted to for inv: the
+// channel picked in the chooser, else the one slack.channel_routes routes
+// repo to, else config's channel.
+func postChannel(repo string, inv Invocation, config config.Config) string {
+	if inv.Channel != "" {
+		return inv.Channel
+	}
+	if channel := config.ChannelRoute(repo); channel != "" {
+		return channel
+	}
+	return config.SlackChannelID
+}
+
+// withPostChannel returns config with its channel set to postChannel, so
+// everything built from it for repo's PR goes to the routed channel.
+func withPostChannel(repo string, inv Invocation, config config.Config) config.Config {
+	config.SlackChannelID = postChannel(repo, inv, config)
+	return config
+}
+
+// postChannels returns the distinct channels prs are posted to for inv, in
+// order. repo is used for PRs that do not carry their own.
+func postChannels(prs []PRItem, repo string, inv Invocation, config config.Config) []string {
+	var channels []string
+	for i := range prs {
+		if channel := postChannel(prs[i].repoOr(repo), inv, config); !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// channelList mentions channels, e.g. "<#C1>, <#C2> and <#C3>".
+func channelList(channels []string) string {
+	mentions := make([]string, len(channels))
+	for i, channel := range channels {
+		mentions[i] = fmt.Sprintf("<#%s>", channel)
+	}
+	if len(mentions) < 2 {
+		return strings.Join(mentions, "")
+	}
+	return strings.Join(mentions[:len(mentions)-1], ", ") + " and " + mentions[len(mentions)-1]
+}
+
+// chooserDestination returns the channel the chooser for repo starts on: the
+// routed channel when slack.channel_routes has one, else config's channel.
+func chooserDestination(repo string, config config.Config) slackui.PRChooserDestination {
+	if channel := config.ChannelRoute(repo); channel != "" {
+		return slackui.PRChooserDestination{Channel: channel, Routed: true}
+	}
+	return slackui.PRChooserDestination{Channel: config.SlackChannelID}
+}
+
+// selectedChannel returns the channel picked under "Post to" in the chooser
+// when it differs from initial, the channel the chooser started on, or ""
+// to keep routing each PR as usual.
+func selectedChannel(values map[string]map[string]interface{}, initial string) string {
+	channel := extractTextValue(values, slackui.ChannelBlockID, slackui.ChannelActionID)
+	if channel == initial {
+		return ""
+	}
+	return channel
+}
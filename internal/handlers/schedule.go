@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const (
+	scheduledPostJobType = "scheduled_post"
+	// maxScheduleAhead is how far ahead a post may be scheduled.
+	maxScheduleAhead = 30 * 24 * time.Hour
+)
+
+// scheduledPost is the delayed job posting PRs picked in the chooser at the
+// time picked under "Post later". Channel is where the chooser would have
+// posted them, so later changes to the user's settings do not move the post.
+// PostTo pins the channel every PR goes to when they share one; when they
+// are routed to different channels it is empty and each PR is routed as it
+// is posted.
+type scheduledPost struct {
+	Repo      string   `json:"repo"`
+	PRs       []PRItem `json:"prs"`
+	Note      string   `json:"note,omitempty"`
+	Reviewers []string `json:"reviewers,omitempty"`
+	Channel   string   `json:"channel"`
+	PostTo    string   `json:"post_to,omitempty"`
+	UserID    string   `json:"user_id"`
+	Username  string   `json:"username"`
+	TeamID    string   `json:"team_id,omitempty"`
+	DryRun    bool     `json:"dry_run,omitempty"`
+	RequestID string   `json:"request_id,omitempty"`
+	// TTL is the lifetime picked in the chooser, as in pendingPost.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// selectedPostAt returns the time picked under "Post later" in the chooser,
+// or the zero time when none was picked.
+func selectedPostAt(values map[string]map[string]interface{}) time.Time {
+	action, _ := values[slackui.ScheduleBlockID][slackui.ScheduleActionID].(map[string]interface{})
+	if at, ok := action["selected_date_time"].(float64); ok && at > 0 {
+		return time.Unix(int64(at), 0)
+	}
+	return time.Time{}
+}
+
+// isScheduled reports whether at is a time to post later rather than now.
+// Times already past are posted straight away.
+func isScheduled(at time.Time) bool {
+	return !at.IsZero() && at.After(time.Now())
+}
+
+// schedulePost queues job to be posted at at and confirms it to the user
+// ephemerally, with the time in their Slack timezone.
+func schedulePost(ctx context.Context, rdb *redis.Client, slackClient SlackClient, job scheduledPost, at time.Time) error {
+	if at.Sub(time.Now()) > maxScheduleAhead {
+		return notifyUser(ctx, slackClient, job, fmt.Sprintf(":warning: Posts can be scheduled up to %d days ahead. Nothing was scheduled; run `/pr` again to pick an earlier time.", int(maxScheduleAhead.Hours()/24)))
+	}
+	if err := scheduleJob(ctx, rdb, scheduledPostJobType, at, job); err != nil {
+		return err
+	}
+	logging.InfoContext(ctx, "User %s scheduled %d PRs from %s for %s", job.Username, len(job.PRs), job.Repo, at.UTC().Format(time.RFC3339))
+	return notifyUser(ctx, slackClient, job, ":calendar: "+scheduledPostText(job, formatScheduledTime(ctx, rdb, slackClient, job.UserID, at)))
+}
+
+// newScheduledPost returns the scheduled post of prs picked by inv, to be
+// posted where config routes them.
+func newScheduledPost(ctx context.Context, repo string, prs []PRItem, note string, reviewers []string, inv Invocation, config config.Config) scheduledPost {
+	postTo := inv.Channel
+	if channels := postChannels(prs, repo, inv, config); len(channels) == 1 {
+		postTo = channels[0]
+	}
+	return scheduledPost{
+		Repo:      repo,
+		PRs:       prs,
+		Note:      note,
+		Reviewers: reviewers,
+		Channel:   config.SlackChannelID,
+		PostTo:    postTo,
+		UserID:    inv.UserID,
+		Username:  inv.Username,
+		TeamID:    inv.TeamID,
+		DryRun:    inv.DryRun,
+		RequestID: logging.RequestIDFrom(ctx),
+	}
+}
+
+// scheduledPostText describes what will be posted where, e.g. "PR #12: Fix
+// will be posted to <#C1> on Mon Jan 5 at 09:30 GMT."
+func scheduledPostText(job scheduledPost, when string) string {
+	what := fmt.Sprintf("%d pull requests", len(job.PRs))
+	if len(job.PRs) == 1 {
+		what = fmt.Sprintf("*PR #%d: %s*", job.PRs[0].Number, escapeSlackText(job.PRs[0].Title))
+	}
+	where := "the channels their repos are routed to"
+	if job.PostTo != "" {
+		where = fmt.Sprintf("<#%s>", job.PostTo)
+	}
+	return fmt.Sprintf("%s will be posted to %s %s.", what, where, when)
+}
+
+// formatScheduledTime renders at in userID's Slack timezone, e.g. "on Mon
+// Jan 5 at 09:30 GMT".
+func formatScheduledTime(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string, at time.Time) string {
+	return at.In(userLocation(ctx, rdb, slackClient, userID)).Format("on Mon Jan 2 at 15:04 MST")
+}
+
+// notifyUser sends text to the user who scheduled job, visible only to them
+// in the channel the post goes to.
+func notifyUser(ctx context.Context, slackClient SlackClient, job scheduledPost, text string) error {
+	if job.UserID == "" {
+		return nil
+	}
+	if _, err := slackClient.PostEphemeralContext(ctx, job.Channel, job.UserID, slack.MsgOptionText(text, false)); err != nil {
+		return fmt.Errorf("failed to notify user: %w", err)
+	}
+	return nil
+}
+
+// runScheduledPostJob posts the PRs of a scheduled post, resolving readiness,
+// mentions, and the suggested reviewer as of now, and requests the chosen
+// reviewers.
+func runScheduledPostJob(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload json.RawMessage, config config.Config) error {
+	var job scheduledPost
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("failed to parse scheduled post: %w", err)
+	}
+	ctx = logging.WithRequestID(ctx, job.RequestID)
+
+	config = withConfigOverrides(ctx, rdb, config)
+	config = withUserPreferences(ctx, rdb, job.UserID, config)
+	config.SlackChannelID = job.Channel
+	config = withMessageTTL(ctx, job.TTL, config)
+	config = withFeatureFlags(ctx, rdb, featureScope{Team: job.TeamID, Channel: job.Channel}, config)
+
+	inv := Invocation{UserID: job.UserID, Username: job.Username, TeamID: job.TeamID, DryRun: job.DryRun, Channel: job.PostTo}
+	posted := postSelectedPRs(ctx, rdb, slackClient, job.PRs, job.Repo, inv, PostOptions{Note: job.Note}, job.Reviewers, config)
+	if posted == 0 {
+		if err := notifyUser(ctx, slackClient, job, ":x: Your scheduled post could not be sent. Please post it again with `/pr`."); err != nil {
+			logging.WarnContext(ctx, "Error telling %s about a failed scheduled post: %v", job.Username, err)
+		}
+		return fmt.Errorf("none of the %d scheduled PRs from %s were posted", len(job.PRs), job.Repo)
+	}
+	logging.InfoContext(ctx, "Posted %d of %d PRs scheduled by %s to %s", posted, len(job.PRs), job.Username, strings.Join(postChannels(job.PRs, job.Repo, inv, config), ", "))
+	return nil
+}
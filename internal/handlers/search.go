@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const (
+	poppitPRSearchType = "slash-vibe-pr-search"
+	// prSearchJSONFields are the --json fields gh search prs supports that
+	// map onto PRItem; branch, check, and review fields are unavailable.
+	prSearchJSONFields = "number,title,author,url,repository,createdAt,body,labels"
+)
+
+// maxSearchQueryLength bounds free-text queries passed to GitHub search.
+const maxSearchQueryLength = 256
+
+// scopedSearchQualifiers already restrict a query to an owner or repo, so
+// the configured organisations are not added on top of them.
+var scopedSearchQualifiers = []string{"repo:", "org:", "user:"}
+
+// prSearch describes a cross-repo pull request search. Exactly one of the
+// fields is expected to be set.
+type prSearch struct {
+	// Author lists open PRs opened by this GitHub login.
+	Author string
+	// ReviewRequested lists open PRs awaiting review from this login.
+	ReviewRequested string
+	// Query is free-text GitHub search syntax from /pr search, such as
+	// "label:bug repo:org/x". It is not limited to open PRs.
+	Query string
+	// Repos lists open PRs across these owner/name repos, as shown on the
+	// App Home for a user's favorites.
+	Repos []string
+	// Limit caps the results; zero means limits.pr_list.
+	Limit int
+}
+
+// limit returns the search's result cap.
+func (s prSearch) limit(config config.Config) int {
+	if s.Limit > 0 {
+		return s.Limit
+	}
+	return config.PRListLimit
+}
+
+// label names the search in modal headers and logs, where a single repo
+// would otherwise appear.
+func (s prSearch) label() string {
+	switch {
+	case s.Query != "":
+		return s.Query
+	case s.ReviewRequested != "":
+		return "review-requested:" + s.ReviewRequested
+	case len(s.Repos) > 0:
+		return "repo:" + strings.Join(s.Repos, ",")
+	default:
+		return "author:" + s.Author
+	}
+}
+
+// scopeOrgs returns the organisations to restrict the search to: all
+// configured orgs unless the search names its own repos or a free-text query
+// already names its own scope.
+func (s prSearch) scopeOrgs(orgs []string) []string {
+	if len(s.Repos) > 0 {
+		return nil
+	}
+	for _, term := range strings.Fields(s.Query) {
+		for _, q := range scopedSearchQualifiers {
+			if strings.HasPrefix(strings.TrimPrefix(term, "-"), q) {
+				return nil
+			}
+		}
+	}
+	return orgs
+}
+
+// command returns the gh command running the search for up to limit PRs,
+// scoped to the given organisations when any are configured.
+func (s prSearch) command(orgs []string, limit int) string {
+	cmd := "gh search prs"
+	if s.Query == "" {
+		cmd += " --state open"
+	}
+	if s.Author != "" {
+		cmd += " --author " + s.Author
+	}
+	if s.ReviewRequested != "" {
+		cmd += " --review-requested " + s.ReviewRequested
+	}
+	for _, repo := range s.Repos {
+		cmd += " --repo " + repo
+	}
+	for _, org := range s.scopeOrgs(orgs) {
+		cmd += " --owner " + org
+	}
+	cmd = fmt.Sprintf("%s --json %s --limit %d", cmd, prSearchJSONFields, limit)
+	if s.Query != "" {
+		// Everything after -- is query text, so it must come last.
+		cmd += " -- " + shellQuote(s.Query)
+	}
+	return cmd
+}
+
+// apiQuery returns the GitHub search query equivalent of command.
+func (s prSearch) apiQuery(orgs []string) string {
+	terms := []string{"is:pr"}
+	if s.Query != "" {
+		terms = append(terms, s.Query)
+	} else {
+		terms = append(terms, "is:open")
+	}
+	if s.Author != "" {
+		terms = append(terms, "author:"+s.Author)
+	}
+	if s.ReviewRequested != "" {
+		terms = append(terms, "review-requested:"+s.ReviewRequested)
+	}
+	for _, repo := range s.Repos {
+		terms = append(terms, "repo:"+repo)
+	}
+	for _, org := range s.scopeOrgs(orgs) {
+		terms = append(terms, "org:"+org)
+	}
+	return strings.Join(terms, " ")
+}
+
+// metadata returns the search as Poppit metadata fields.
+func (s prSearch) metadata() map[string]interface{} {
+	return map[string]interface{}{
+		"search_author":           s.Author,
+		"search_review_requested": s.ReviewRequested,
+		"search_query":            s.Query,
+		"search_repos":            strings.Join(s.Repos, ","),
+	}
+}
+
+// prSearchFromMetadata rebuilds a search from Poppit metadata.
+func prSearchFromMetadata(m map[string]interface{}) prSearch {
+	var s prSearch
+	s.Author, _ = m["search_author"].(string)
+	s.ReviewRequested, _ = m["search_review_requested"].(string)
+	s.Query, _ = m["search_query"].(string)
+	if repos, _ := m["search_repos"].(string); repos != "" {
+		s.Repos = strings.Split(repos, ",")
+	}
+	return s
+}
+
+// parseSearchQuery normalises the text after /pr search: surrounding straight
+// or curly quotes (Slack converts the former to the latter) are removed and
+// the length and characters are checked.
+func parseSearchQuery(text string) (string, error) {
+	query := strings.TrimSpace(text)
+	for _, pair := range []string{`""`, "“”", "''", "‘’"} {
+		open, close := string([]rune(pair)[0]), string([]rune(pair)[1])
+		if len(query) >= len(open)+len(close) && strings.HasPrefix(query, open) && strings.HasSuffix(query, close) {
+			query = strings.TrimSpace(query[len(open) : len(query)-len(close)])
+			break
+		}
+	}
+	switch {
+	case query == "":
+		return "", fmt.Errorf("search needs a query, e.g. `/pr search \"label:bug repo:org/x\"`")
+	case len(query) > maxSearchQueryLength:
+		return "", fmt.Errorf("search query is longer than %d characters", maxSearchQueryLength)
+	case strings.ContainsAny(query, "\n\r\x00"):
+		return "", fmt.Errorf("search query must be a single line")
+	}
+	return query, nil
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell, so free text can be
+// passed to a Poppit command line as one literal argument.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// handleSearchCommand implements /pr search <query>.
+func handleSearchCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, text string, config config.Config) {
+	query, err := parseSearchQuery(text)
+	if err != nil {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, ":warning: "+capitalize(err.Error())+"."); err != nil {
+			logging.ErrorContext(ctx, "Error responding to search for user %s: %v", cmd.UserName, err)
+		}
+		return
+	}
+	openPRSearch(ctx, rdb, slackClient, cmd, prSearch{Query: query}, config)
+}
+
+// sendPRSearchCommand queues a search on Poppit; the output is handled by
+// handlePRSearchOutput.
+func sendPRSearchCommand(ctx context.Context, rdb *redis.Client, search prSearch, viewID string, inv Invocation, config config.Config) error {
+	metadata := inv.metadata()
+	for k, v := range search.metadata() {
+		metadata[k] = v
+	}
+	metadata["view_id"] = viewID
+
+	return pushPoppitCommand(ctx, rdb, poppit.Command{
+		Type:     poppitPRSearchType,
+		Dir:      "/tmp",
+		Commands: []string{search.command(config.Orgs(), search.limit(config))},
+		Metadata: metadata,
+	}, config)
+}
+
+// handlePRSearchOutput parses a Poppit search result and presents it.
+func handlePRSearchOutput(ctx context.Context, rdb *redis.Client, slackClient SlackClient, output poppit.Output, config config.Config) {
+	viewID, _ := output.Metadata["view_id"].(string)
+	if viewID == "" {
+		logging.WarnContext(ctx, "Missing view_id in Poppit search output metadata")
+		return
+	}
+	disarmLoadingWatchdog(ctx, rdb, viewID)
+	search := prSearchFromMetadata(output.Metadata)
+
+	if msg, failed := poppit.Failure(output); failed {
+		logging.ErrorContext(ctx, "Searching PRs (%s) failed: %s", search.label(), output.ErrorText())
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, msg, config)
+		return
+	}
+
+	var prs []PRItem
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.Output)), &prs); err != nil {
+		logging.ErrorContext(ctx, "Error parsing PR search JSON for %s: %v", search.label(), err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, poppit.DescribeGHError(output.Output, "Failed to parse the search results. Please try again."), config)
+		return
+	}
+
+	presentPRSearch(ctx, rdb, slackClient, viewID, search, invocationFromMetadata(output.Metadata), prs, config)
+}
+
+// presentPRSearch shows search results: the review queue for review
+// requests, otherwise the regular PR chooser. It is shared by every PRSource.
+func presentPRSearch(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID string, search prSearch, inv Invocation, prs []PRItem, config config.Config) {
+	if search.ReviewRequested != "" {
+		presentReviewQueue(ctx, rdb, slackClient, viewID, inv.UserID, search, prs, config)
+		return
+	}
+	presentPRList(ctx, rdb, slackClient, viewID, search.label(), PRListOptions{}, inv, prs, config)
+}
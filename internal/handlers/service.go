@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// Service runs the interaction handlers and background jobs against one
+// Redis client, Slack client, and config.
+type Service struct {
+	rdb         *redis.Client
+	slackClient *slack.Client
+	config      config.Config
+}
+
+// NewService returns a Service; slackClient must carry the app-level token
+// when slack.transport is socket_mode.
+func NewService(rdb *redis.Client, slackClient *slack.Client, config config.Config) *Service {
+	return &Service{rdb: rdb, slackClient: slackClient, config: config}
+}
+
+// Start launches the HTTP, health, and debug servers that are configured,
+// the feed consumers, and the scheduled jobs, which only the elected leader
+// among the replicas runs. They run until ctx is done.
+func (s *Service) Start(ctx context.Context) {
+	rdb, config := s.rdb, s.config
+	var slackClient SlackClient = s.slackClient
+	if config.Simulate {
+		logging.InfoContext(ctx, "[dry-run] Slack calls, SlackLiner posts, and Poppit commands are printed, not sent")
+		slackClient = &simulatedSlack{}
+	}
+	if config.UsesGitHubApp() && len(config.PoppitEnvKey) == 0 {
+		logging.WarnContext(ctx, "POPPIT_ENV_KEY is not set, so GitHub App tokens are not passed to Poppit: gh runs with the Poppit host's own login")
+	}
+
+	if config.HTTPAddr != "" {
+		go serveHTTP(ctx, rdb, slackClient, config)
+	}
+	if config.HealthAddr != "" {
+		go serveHealth(ctx, rdb, s.slackClient, config)
+	}
+	if config.PprofAddr != "" {
+		go serveDebug(ctx, config)
+	}
+
+	go subscribeToSlashCommands(ctx, rdb, slackClient, config)
+	go subscribeToViewSubmissions(ctx, rdb, slackClient, config)
+	go subscribeToBlockActions(ctx, rdb, slackClient, config)
+	go subscribeToBlockSuggestions(ctx, rdb, config)
+	go subscribeToPoppitOutput(ctx, rdb, slackClient, config)
+	go subscribeToAppHomeEvents(ctx, rdb, slackClient, config)
+	go subscribeToLinkShared(ctx, rdb, slackClient, config)
+	go subscribeToReactionAdded(ctx, rdb, slackClient, config)
+	go runDelayedJobs(ctx, rdb, slackClient, config)
+	if config.UsesSocketMode() {
+		go runSocketMode(ctx, rdb, s.slackClient, slackClient, config)
+	}
+	if len(config.ReminderChannels) > 0 || len(config.DigestSchedules) > 0 || config.LeaderboardChannel != "" {
+		leader, err := newLeaderElection(rdb, config)
+		if err != nil {
+			logging.ErrorContext(ctx, "Error starting leader election, scheduled jobs disabled: %v", err)
+			return
+		}
+		go leader.run(ctx)
+		go runStaleReminders(ctx, rdb, leader, config)
+		go runDigests(ctx, rdb, leader, config)
+		go runLeaderboard(ctx, rdb, leader, config)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const prSessionKeyPrefix = "slashvibepr:session:"
+
+// prSessionKey returns the Redis key holding the PR session for a modal view.
+func prSessionKey(viewID string) string {
+	return prSessionKeyPrefix + viewID
+}
+
+// savePRSession stores the PR list shown in a chooser modal, keyed by view ID,
+// so suggestion requests and the final submission can look PRs up without
+// squeezing the whole list into private_metadata. The request ID in ctx is
+// kept with it so the submission continues the same trace. The stored value
+// is encrypted when SESSION_ENCRYPTION_KEY is set, and expires after
+// limits.session_ttl.
+func savePRSession(ctx context.Context, store session.Store, viewID string, prSession PRModalPrivateMetadata, config config.Config) error {
+	if prSession.RequestID == "" {
+		prSession.RequestID = logging.RequestIDFrom(ctx)
+	}
+	if err := session.Save(ctx, store, prSessionKey(viewID), prSession, config.SessionTTL); err != nil {
+		return fmt.Errorf("failed to save PR session: %w", err)
+	}
+	return nil
+}
+
+// loadPRSession fetches the PR session for a modal view. It returns
+// redis.Nil (wrapped) when the session has expired or never existed.
+func loadPRSession(ctx context.Context, store session.Store, viewID string) (PRModalPrivateMetadata, error) {
+	var prSession PRModalPrivateMetadata
+	if err := session.Load(ctx, store, prSessionKey(viewID), &prSession); err != nil {
+		return prSession, fmt.Errorf("failed to load PR session: %w", err)
+	}
+	return prSession, nil
+}
+
+// chooserPRs returns the session's PRs narrowed by the chooser's author and
+// label filters.
+func (m PRModalPrivateMetadata) chooserPRs() []PRItem {
+	return filterPRsByLabels(filterPRsByAuthor(m.PRs, m.Author), m.Labels)
+}
+
+// chooserFilters describes the session's filters for PRChooserModal. The
+// author and label filters offer every author and label in the full list,
+// so a narrowed chooser can be widened again.
+func (m PRModalPrivateMetadata) chooserFilters() slackui.PRChooserFilters {
+	return slackui.PRChooserFilters{
+		State:           m.State,
+		Base:            m.Base,
+		Truncated:       m.Truncated,
+		Author:          m.Author,
+		Authors:         prAuthors(m.PRs),
+		Labels:          m.Labels,
+		AvailableLabels: prLabels(m.PRs),
+	}
+}
+
+// destination returns the channel the chooser started on.
+func (m PRModalPrivateMetadata) destination() slackui.PRChooserDestination {
+	return slackui.PRChooserDestination{Channel: m.Channel, Routed: m.Routed}
+}
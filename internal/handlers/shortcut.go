@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	messageShortcutType = "message_action"
+	// refreshStatusCallbackID is the callback ID of the "Refresh PR status"
+	// message shortcut configured in the Slack app.
+	refreshStatusCallbackID = "refresh_pr_status"
+)
+
+// handleMessageShortcut routes message shortcuts by callback ID.
+func handleMessageShortcut(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	var shortcut MessageShortcutPayload
+	if err := envelope.Decode(envelope.SchemaMessageShortcut, []byte(payload), &shortcut); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling message shortcut: %v", err)
+		deadLetter(ctx, rdb, config.RedisBlockActionsChannel, payload, err)
+		return
+	}
+
+	switch shortcut.CallbackID {
+	case refreshStatusCallbackID:
+		handleRefreshStatusShortcut(ctx, rdb, slackClient, shortcut, config)
+	default:
+		logging.DebugContext(ctx, "Ignoring message shortcut %q", shortcut.CallbackID)
+	}
+}
+
+// shortcutPRRef extracts the repo, PR number, and thread key from the
+// metadata of a message posted by SlashVibePR. ok is false for any other
+// message.
+func shortcutPRRef(shortcut MessageShortcutPayload) (repo string, number int, threadKey string, ok bool) {
+	meta := shortcut.Message.Metadata
+	switch meta.EventType {
+	case "pr_posted", "pr_status_posted":
+	default:
+		return "", 0, "", false
+	}
+	repo, _ = meta.EventPayload["repository"].(string)
+	n, _ := meta.EventPayload["pr_number"].(float64)
+	threadKey, _ = meta.EventPayload["thread_key"].(string)
+
+	owner, name, found := strings.Cut(repo, "/")
+	if !found || !validOwnerName.MatchString(owner) || !validRepoName.MatchString(name) || n <= 0 {
+		return "", 0, "", false
+	}
+	return repo, int(n), threadKey, true
+}
+
+// handleRefreshStatusShortcut re-fetches the PR a posted message refers to
+// and posts a fresh status card in the message's thread. Messages posted
+// before thread keys were recorded in their metadata fall back to the
+// posted-PR index.
+func handleRefreshStatusShortcut(ctx context.Context, rdb *redis.Client, slackClient SlackClient, shortcut MessageShortcutPayload, config config.Config) {
+	repo, number, threadKey, ok := shortcutPRRef(shortcut)
+	if !ok {
+		if err := respondEphemeral(ctx, shortcut.ResponseURL, ":warning: This message is not a pull request posted by SlashVibePR."); err != nil {
+			logging.ErrorContext(ctx, "Error responding to shortcut for user %s: %v", shortcut.User.Username, err)
+		}
+		return
+	}
+
+	if threadKey == "" {
+		if rec, err := loadPostedPR(ctx, rdb, repo, number); err == nil {
+			threadKey = rec.ThreadKey
+		} else {
+			logging.WarnContext(ctx, "No thread key for %s#%d, posting status unthreaded: %v", repo, number, err)
+		}
+	}
+
+	logging.InfoContext(ctx, "Refreshing status of %s#%d for user %s", repo, number, shortcut.User.Username)
+	inv := Invocation{UserID: shortcut.User.ID, Username: shortcut.User.Username, Status: true, ThreadKey: threadKey}
+	if err := newPRSource(rdb, slackClient, config).ViewPR(ctx, repo, number, shortcut.ResponseURL, inv); err != nil {
+		logging.ErrorContext(ctx, "Error fetching %s#%d for status refresh: %v", repo, number, err)
+	}
+}
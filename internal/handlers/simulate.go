@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// dryRunOutput receives the request bodies printed under --dry-run, each
+// written whole under dryRunMu so concurrent handlers do not interleave.
+var (
+	dryRunOutput io.Writer = os.Stdout
+	dryRunMu     sync.Mutex
+)
+
+// printDryRun prints one request that --dry-run kept from being sent: a
+// header naming it, then its body as indented JSON, ready to paste into
+// Block Kit Builder. MessagePack bodies are printed as JSON too.
+func printDryRun(ctx context.Context, what string, body interface{}) {
+	var data []byte
+	var err error
+	if raw, ok := body.([]byte); ok {
+		var buf bytes.Buffer
+		if raw, err = codec.ToJSON(raw); err == nil {
+			err = json.Indent(&buf, raw, "", "  ")
+		}
+		data = buf.Bytes()
+	} else {
+		data, err = json.MarshalIndent(body, "", "  ")
+	}
+	if err != nil {
+		logging.WarnContext(ctx, "[dry-run] %s: failed to format request: %v", what, err)
+		return
+	}
+	logging.InfoContext(ctx, "[dry-run] %s not sent", what)
+	dryRunMu.Lock()
+	defer dryRunMu.Unlock()
+	fmt.Fprintf(dryRunOutput, "--- %s\n%s\n", what, data)
+}
+
+// pushSlackLiner queues payloads on the SlackLiner list in one RPUSH,
+// written in the SlackLiner format (see lists.envelope and
+// codecs.slackliner). With --dry-run they are printed instead.
+func pushSlackLiner(ctx context.Context, rdb *redis.Client, config config.Config, payloads ...interface{}) error {
+	format := config.SlackLinerFormat()
+	encoded := make([]interface{}, 0, len(payloads))
+	for _, payload := range payloads {
+		data, err := format.Encode(envelope.KindSlackLinerMessage, payload)
+		if err != nil {
+			return err
+		}
+		encoded = append(encoded, data)
+	}
+	payloads = encoded
+	if config.Simulate {
+		for _, payload := range payloads {
+			printDryRun(ctx, "SlackLiner "+config.RedisSlackLinerList, payload)
+		}
+		return nil
+	}
+	return rdb.RPush(ctx, config.RedisSlackLinerList, payloads...).Err()
+}
+
+// simulatedSlack stands in for Slack under --dry-run: it prints the body of
+// every Web API request and answers as if it succeeded.
+type simulatedSlack struct {
+	views atomic.Int64
+}
+
+// viewResponse returns a response for view, giving opened views an ID of
+// their own so later updates can be followed in the log.
+func (s *simulatedSlack) viewResponse(view slack.ModalViewRequest, viewID string) *slack.ViewResponse {
+	if viewID == "" {
+		viewID = fmt.Sprintf("V-DRYRUN-%d", s.views.Add(1))
+	}
+	return &slack.ViewResponse{View: slack.View{
+		ID:              viewID,
+		Type:            view.Type,
+		CallbackID:      view.CallbackID,
+		PrivateMetadata: view.PrivateMetadata,
+	}}
+}
+
+func (s *simulatedSlack) OpenViewContext(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	printDryRun(ctx, "views.open", map[string]interface{}{"trigger_id": triggerID, "view": view})
+	return s.viewResponse(view, ""), nil
+}
+
+func (s *simulatedSlack) PushViewContext(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error) {
+	printDryRun(ctx, "views.push", map[string]interface{}{"trigger_id": triggerID, "view": view})
+	return s.viewResponse(view, ""), nil
+}
+
+func (s *simulatedSlack) UpdateViewContext(ctx context.Context, view slack.ModalViewRequest, externalID, hash, viewID string) (*slack.ViewResponse, error) {
+	printDryRun(ctx, "views.update", map[string]interface{}{"view_id": viewID, "external_id": externalID, "hash": hash, "view": view})
+	return s.viewResponse(view, viewID), nil
+}
+
+func (s *simulatedSlack) PostEphemeralContext(ctx context.Context, channelID, userID string, options ...slack.MsgOption) (string, error) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", append(options, slack.MsgOptionUser(userID))...)
+	if err != nil {
+		return "", err
+	}
+	body := map[string]string{}
+	for key := range values {
+		if key != "token" {
+			body[key] = values.Get(key)
+		}
+	}
+	printDryRun(ctx, "chat.postEphemeral", body)
+	return "", nil
+}
+
+func (s *simulatedSlack) PublishViewContext(ctx context.Context, req slack.PublishViewContextRequest) (*slack.ViewResponse, error) {
+	printDryRun(ctx, "views.publish", req)
+	return &slack.ViewResponse{}, nil
+}
+
+func (s *simulatedSlack) UnfurlMessageContext(ctx context.Context, channelID, timestamp string, unfurls map[string]slack.Attachment, options ...slack.MsgOption) (string, string, string, error) {
+	printDryRun(ctx, "chat.unfurl", map[string]interface{}{"channel": channelID, "ts": timestamp, "unfurls": unfurls})
+	return channelID, timestamp, "", nil
+}
+
+// GetConversationHistoryContext reports every channel as empty: without
+// Slack there are no posted messages to read.
+func (s *simulatedSlack) GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	logging.InfoContext(ctx, "[dry-run] conversations.history %s: treating as empty", params.ChannelID)
+	return &slack.GetConversationHistoryResponse{}, nil
+}
+
+func (s *simulatedSlack) UpdateMessageContext(ctx context.Context, channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", append(options, slack.MsgOptionUpdate(timestamp))...)
+	if err != nil {
+		return "", "", "", err
+	}
+	body := map[string]string{}
+	for key := range values {
+		if key != "token" {
+			body[key] = values.Get(key)
+		}
+	}
+	printDryRun(ctx, "chat.update", body)
+	return channelID, timestamp, "", nil
+}
+
+// GetUserGroupMembersContext reports every usergroup as empty: without Slack
+// there is no membership to look up.
+func (s *simulatedSlack) GetUserGroupMembersContext(ctx context.Context, userGroup string, options ...slack.GetUserGroupMembersOption) ([]string, error) {
+	logging.InfoContext(ctx, "[dry-run] usergroups.users.list %s: treating as empty", userGroup)
+	return nil, nil
+}
+
+// GetUserInfoContext reports a profile without a timezone, so times are
+// shown in UTC.
+func (s *simulatedSlack) GetUserInfoContext(ctx context.Context, user string) (*slack.User, error) {
+	logging.InfoContext(ctx, "[dry-run] users.info %s: treating as UTC", user)
+	return &slack.User{ID: user}, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+// prOptions converts PRs into select options, truncating long titles to fit
+// Slack's 75-character option text limit. Options are prefixed with the PR's
+// CI status and carry a description with the author, branch, and age to tell
+// similarly titled PRs apart. Dates are shown in now's timezone.
+func prOptions(prs []PRItem, now time.Time) []*slack.OptionBlockObject {
+	options := make([]*slack.OptionBlockObject, 0, len(prs))
+	for _, pr := range prs {
+		options = append(options, &slack.OptionBlockObject{
+			Text: &slack.TextBlockObject{
+				Type: slack.PlainTextType,
+				Text: slackui.TruncateOptionText(prOptionText(pr)),
+			},
+			Description: &slack.TextBlockObject{
+				Type: slack.PlainTextType,
+				Text: slackui.TruncateOptionText(prOptionDescription(pr, now)),
+			},
+			Value: pr.optionValue(),
+		})
+	}
+	return options
+}
+
+// prOptionText returns "✅ #12: Title", omitting the status prefix for PRs
+// without checks. Drafts are marked "📝 #12: Title".
+func prOptionText(pr PRItem) string {
+	text := fmt.Sprintf("#%d: %s", pr.Number, pr.Title)
+	if pr.IsDraft {
+		text = "📝 " + text
+	}
+	if emoji := checkStateEmoji(pr.checkState()); emoji != "" {
+		text = emoji + " " + text
+	}
+	return text
+}
+
+// prOptionDescription returns e.g.
+// "by alice · approved · fix/bug · +120 −45 across 7 files · opened May 1,
+// 3d ago · bug, ui", omitting any part whose data is missing. The date is in now's timezone.
+func prOptionDescription(pr PRItem, now time.Time) string {
+	var parts []string
+	if pr.Repository.NameWithOwner != "" {
+		parts = append(parts, pr.Repository.NameWithOwner)
+	}
+	if pr.Author.Login != "" {
+		parts = append(parts, "by "+pr.Author.Login)
+	}
+	if review := slackui.ReviewDecisionLabel(pr.ReviewDecision); review != "" {
+		parts = append(parts, review)
+	}
+	if pr.HeadRefName != "" {
+		parts = append(parts, pr.HeadRefName)
+	}
+	if stats := pr.diffStats(); stats != "" {
+		parts = append(parts, stats)
+	}
+	if !pr.CreatedAt.IsZero() {
+		parts = append(parts, "opened "+slackui.FormatOpened(pr.CreatedAt, now))
+	}
+	if labels := pr.labelNames(); len(labels) > 0 {
+		parts = append(parts, strings.Join(labels, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// createHomeView returns the App Home tab: a short guide, the open PRs in
+// the user's favorite repos, and, when stats is non-nil (admins only), a
+// usage section with this week's activity. Dates are shown in now's timezone.
+func createHomeView(stats *UsageStats, favorites homeFavorites, now time.Time) slack.HomeTabViewRequest {
+	blocks := []slack.Block{
+		&slack.SectionBlock{
+			Type: slack.MBTSection,
+			Text: &slack.TextBlockObject{
+				Type: slack.MarkdownType,
+				Text: "*SlashVibePR*\nUse `/pr` in any channel to browse and share open pull requests.",
+			},
+		},
+	}
+	blocks = append(blocks, favoritePRBlocks(favorites, now)...)
+
+	if stats != nil {
+		blocks = append(blocks, slack.NewDividerBlock(), &slack.SectionBlock{
+			Type: slack.MBTSection,
+			Text: &slack.TextBlockObject{
+				Type: slack.MarkdownType,
+				Text: formatUsageStats(*stats),
+			},
+		})
+	}
+
+	return slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// formatUsageStats renders the admin stats section as mrkdwn.
+func formatUsageStats(stats UsageStats) string {
+	var b strings.Builder
+	b.WriteString("*Usage this week*\n")
+	fmt.Fprintf(&b, "• PRs posted: *%d*\n", stats.PostsThisWeek)
+
+	b.WriteString("• Top repos: ")
+	if len(stats.TopRepos) == 0 {
+		b.WriteString("_none yet_")
+	} else {
+		parts := make([]string, 0, len(stats.TopRepos))
+		for _, rc := range stats.TopRepos {
+			parts = append(parts, fmt.Sprintf("`%s` (%d)", rc.Repo, rc.Count))
+		}
+		b.WriteString(strings.Join(parts, ", "))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("• Average review SLA: ")
+	if stats.ReviewSamples == 0 {
+		b.WriteString("_no reviews tracked yet_")
+	} else {
+		fmt.Fprintf(&b, "*%s* (%d reviews)", stats.AverageReviewSLA.Round(time.Minute), stats.ReviewSamples)
+	}
+
+	return b.String()
+}
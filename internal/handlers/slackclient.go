@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/slack-go/slack"
+)
+
+// SlackViews is the part of the Slack Web API used to show modals and
+// ephemeral messages. *slack.Client implements it; tests use a fake that
+// records the calls.
+type SlackViews interface {
+	OpenViewContext(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error)
+	PushViewContext(ctx context.Context, triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error)
+	UpdateViewContext(ctx context.Context, view slack.ModalViewRequest, externalID, hash, viewID string) (*slack.ViewResponse, error)
+	PostEphemeralContext(ctx context.Context, channelID, userID string, options ...slack.MsgOption) (string, error)
+}
+
+// SlackClient is the Slack Web API as used by the handlers: SlackViews plus
+// publishing the App Home, unfurling links, reading and editing posted
+// messages, resolving usergroups, and looking up user profiles.
+type SlackClient interface {
+	SlackViews
+	PublishViewContext(ctx context.Context, req slack.PublishViewContextRequest) (*slack.ViewResponse, error)
+	UnfurlMessageContext(ctx context.Context, channelID, timestamp string, unfurls map[string]slack.Attachment, options ...slack.MsgOption) (string, string, string, error)
+	GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error)
+	UpdateMessageContext(ctx context.Context, channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error)
+	GetUserGroupMembersContext(ctx context.Context, userGroup string, options ...slack.GetUserGroupMembersOption) ([]string, error)
+	GetUserInfoContext(ctx context.Context, user string) (*slack.User, error)
+}
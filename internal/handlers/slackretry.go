@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// slackRetryBaseDelay is the first backoff between attempts; it doubles
+	// on each retry up to slackRetryMaxDelay. Trigger IDs expire after three
+	// seconds, so backoffs stay short.
+	slackRetryBaseDelay = 250 * time.Millisecond
+	slackRetryMaxDelay  = 2 * time.Second
+)
+
+// transientSlackErrors are Slack API error codes worth retrying.
+var transientSlackErrors = map[string]bool{
+	"internal_error":      true,
+	"fatal_error":         true,
+	"request_timeout":     true,
+	"service_unavailable": true,
+	"ratelimited":         true,
+}
+
+// slackRetryDelay reports whether err is transient and, if so, how long to
+// wait before the next attempt: Slack's Retry-After for rate limits,
+// otherwise backoff.
+func slackRetryDelay(err error, backoff time.Duration) (time.Duration, bool) {
+	var rateLimited *slack.RateLimitedError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.RetryAfter, true
+	}
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) && retryable.Retryable() {
+		return backoff, true
+	}
+	var apiErr slack.SlackErrorResponse
+	if errors.As(err, &apiErr) && transientSlackErrors[apiErr.Err] {
+		return backoff, true
+	}
+	if transientSlackErrors[err.Error()] {
+		return backoff, true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return backoff, true
+	}
+	return 0, false
+}
+
+// withSlackRetry runs call up to config.SlackMaxAttempts times, retrying
+// transient failures with exponential backoff and honouring Retry-After.
+func withSlackRetry(ctx context.Context, op string, config config.Config, call func() error) (err error) {
+	ctx, span := startSpan(ctx, "slack "+op, trace.SpanKindClient)
+	defer func() { endSpan(span, err) }()
+
+	attempts := config.SlackMaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := slackRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err = call()
+		if err == nil || attempt == attempts {
+			return err
+		}
+		delay, ok := slackRetryDelay(err, backoff)
+		if !ok {
+			return err
+		}
+		logging.WarnContext(ctx, "Slack %s failed (attempt %d/%d), retrying in %s: %v", op, attempt, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, slackRetryMaxDelay)
+	}
+}
+
+// openView is OpenViewContext with retries.
+func openView(ctx context.Context, slackClient SlackViews, triggerID string, view slack.ModalViewRequest, config config.Config) (*slack.ViewResponse, error) {
+	var resp *slack.ViewResponse
+	err := withSlackRetry(ctx, "views.open", config, func() error {
+		var err error
+		resp, err = slackClient.OpenViewContext(ctx, triggerID, view)
+		return err
+	})
+	return resp, err
+}
+
+// pushView is PushViewContext with retries.
+func pushView(ctx context.Context, slackClient SlackViews, triggerID string, view slack.ModalViewRequest, config config.Config) (*slack.ViewResponse, error) {
+	var resp *slack.ViewResponse
+	err := withSlackRetry(ctx, "views.push", config, func() error {
+		var err error
+		resp, err = slackClient.PushViewContext(ctx, triggerID, view)
+		return err
+	})
+	return resp, err
+}
+
+// updateView is UpdateViewContext (by view ID) with retries, holding the
+// view's lock so concurrent updates from other replicas are serialized.
+func updateView(ctx context.Context, rdb *redis.Client, slackClient SlackViews, view slack.ModalViewRequest, viewID string, config config.Config) (*slack.ViewResponse, error) {
+	unlock, err := lockView(ctx, rdb, viewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock view %s: %w", viewID, err)
+	}
+	defer unlock()
+
+	var resp *slack.ViewResponse
+	err = withSlackRetry(ctx, "views.update", config, func() error {
+		var err error
+		resp, err = slackClient.UpdateViewContext(ctx, view, "", "", viewID)
+		return err
+	})
+	return resp, err
+}
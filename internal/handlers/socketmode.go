@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// runSocketMode receives slash commands, interactions, and events over a
+// Socket Mode WebSocket until ctx is done, handling them with slackClient.
+// conn must carry the app-level token.
+func runSocketMode(ctx context.Context, rdb *redis.Client, conn *slack.Client, slackClient SlackClient, config config.Config) {
+	client := socketmode.New(conn)
+	go func() {
+		if err := client.RunContext(ctx); err != nil && ctx.Err() == nil {
+			logging.ErrorContext(ctx, "Socket Mode connection stopped: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt := <-client.Events:
+			handleSocketModeEvent(ctx, rdb, slackClient, client, evt, config)
+		}
+	}
+}
+
+// handleSocketModeEvent acknowledges one Socket Mode envelope and routes its
+// payload like the HTTP mode does.
+func handleSocketModeEvent(ctx context.Context, rdb *redis.Client, slackClient SlackClient, client *socketmode.Client, evt socketmode.Event, config config.Config) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		logging.InfoContext(ctx, "Connecting to Slack with Socket Mode")
+		return
+	case socketmode.EventTypeConnected:
+		logging.InfoContext(ctx, "Connected to Slack with Socket Mode")
+		return
+	case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth:
+		logging.ErrorContext(ctx, "Socket Mode connection error: %v", evt.Data)
+		return
+	case socketmode.EventTypeHello, socketmode.EventTypeDisconnect:
+		return
+	}
+	if evt.Request == nil {
+		return
+	}
+
+	var resp interface{}
+	switch evt.Type {
+	case socketmode.EventTypeSlashCommand:
+		if cmd, ok := evt.Data.(slack.SlashCommand); ok {
+			routeSlashCommand(ctx, rdb, slackClient, SlackCommand{
+				Command:     cmd.Command,
+				Text:        cmd.Text,
+				ResponseURL: cmd.ResponseURL,
+				TriggerID:   cmd.TriggerID,
+				UserID:      cmd.UserID,
+				UserName:    cmd.UserName,
+				ChannelID:   cmd.ChannelID,
+				TeamID:      cmd.TeamID,
+			}, config)
+		}
+	case socketmode.EventTypeInteractive:
+		resp = routeInteraction(ctx, rdb, slackClient, string(evt.Request.Payload), config)
+	case socketmode.EventTypeEventsAPI:
+		routeEvent(ctx, rdb, slackClient, evt.Request.Payload, config)
+	default:
+		logging.DebugContext(ctx, "Ignoring Socket Mode event of type %q", evt.Type)
+	}
+
+	var err error
+	if resp != nil {
+		err = client.Ack(*evt.Request, resp)
+	} else {
+		err = client.Ack(*evt.Request)
+	}
+	if err != nil {
+		logging.ErrorContext(ctx, "Error acknowledging Socket Mode envelope %s: %v", evt.Request.EnvelopeID, err)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	prSortKeyPrefix = "slashvibepr:sort:"
+	// prSortTTL is how long a user's last --sort choice is remembered.
+	prSortTTL = 90 * 24 * time.Hour
+)
+
+// prSortOrders are the values --sort accepts, as gh's sort: search
+// qualifier spells them: the field to sort on, then the direction.
+var prSortOrders = []string{"created-desc", "created-asc", "updated-desc", "updated-asc"}
+
+// validPRSort reports whether sort is one of prSortOrders.
+func validPRSort(sort string) bool {
+	for _, s := range prSortOrders {
+		if s == sort {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPRSort returns the field and direction of a valid sort order.
+func splitPRSort(sort string) (field, direction string) {
+	field, direction, _ = strings.Cut(sort, "-")
+	return field, direction
+}
+
+// prSortKey returns the key remembering the user's last sort order.
+func prSortKey(userID string) string {
+	return prSortKeyPrefix + userID
+}
+
+// savePRSort remembers sort as the user's preferred PR list order.
+func savePRSort(ctx context.Context, rdb *redis.Client, userID, sort string) error {
+	if userID == "" {
+		return nil
+	}
+	if err := rdb.Set(ctx, prSortKey(userID), sort, prSortTTL).Err(); err != nil {
+		return fmt.Errorf("failed to save sort order: %w", err)
+	}
+	return nil
+}
+
+// loadPRSort returns the user's remembered sort order, falling back to the
+// one in their preferences, or "" for gh's default order. Unreadable or
+// stale values are ignored.
+func loadPRSort(ctx context.Context, rdb *redis.Client, userID string) string {
+	if userID == "" {
+		return ""
+	}
+	sort, err := rdb.Get(ctx, prSortKey(userID)).Result()
+	if err == nil && validPRSort(sort) {
+		return sort
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		logging.WarnContext(ctx, "Error loading sort order for user %s: %v", userID, err)
+	}
+	prefs, err := loadUserPreferences(ctx, rdb, userID)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading preferences for user %s: %v", userID, err)
+		return ""
+	}
+	return prefs.Sort
+}
+
+// applyPRSort fills in opts.Sort for a PR list requested by userID: an
+// explicit --sort is remembered for next time, otherwise the remembered
+// order is used.
+func applyPRSort(ctx context.Context, rdb *redis.Client, userID string, opts *PRListOptions) {
+	if opts.Sort != "" {
+		if err := savePRSort(ctx, rdb, userID, opts.Sort); err != nil {
+			logging.WarnContext(ctx, "Error saving sort order for user %s: %v", userID, err)
+		}
+		return
+	}
+	opts.Sort = loadPRSort(ctx, rdb, userID)
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// statsReportDays is the window /pr stats reports on, and statsChartDays
+	// how many of its most recent days get a bar in the daily chart.
+	statsReportDays = 30
+	statsChartDays  = 14
+	statsBarWidth   = 20
+
+	statsCallbackID = "stats_report"
+	statsUsage      = ":warning: Usage: `/pr stats` or `/pr stats <repo>`"
+)
+
+// handleStatsCommand implements /pr stats: open a modal with posting activity
+// over the last statsReportDays days, for every repo or for one.
+func handleStatsCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to stats for user %s: %v", cmd.UserName, err)
+		}
+	}
+
+	repo := ""
+	if len(fields) > 1 {
+		reply(statsUsage)
+		return
+	}
+	if len(fields) == 1 {
+		args, err := parsePRArgs(fields[0], config)
+		if err != nil {
+			reply(fmt.Sprintf(":warning: %s.\n\n%s", capitalize(err.Error()), statsUsage))
+			return
+		}
+		repo = args.fullRepo(config)
+	}
+
+	report, err := loadPostingReport(ctx, rdb, repo, time.Now(), statsReportDays)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading posting report for %q: %v", repo, err)
+		reply(":x: Could not load the usage stats. Please try again.")
+		return
+	}
+
+	if _, err := openView(ctx, slackClient, cmd.TriggerID, createStatsModal(report), config); err != nil {
+		logging.ErrorContext(ctx, "Error opening stats modal for user %s: %v", cmd.UserName, err)
+	}
+}
+
+// createStatsModal renders a PostingReport as a read-only modal.
+func createStatsModal(report PostingReport) slack.ModalViewRequest {
+	scope := "all repositories"
+	if report.Repo != "" {
+		scope = fmt.Sprintf("`%s`", report.Repo)
+	}
+	section := func(text string) slack.Block {
+		return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+	}
+
+	blocks := []slack.Block{
+		section(fmt.Sprintf("*%d PRs* shared from %s in the last %d days.", report.Total, scope, report.Days)),
+		section("*Daily posts*\n" + formatDailyChart(report.PerDay, statsChartDays)),
+	}
+	if report.Repo == "" {
+		blocks = append(blocks, section("*Most shared repos*\n"+formatRankedCounts(repoCountPairs(report.TopRepos))))
+	}
+	blocks = append(blocks, section("*Top posters*\n"+formatRankedCounts(userCountPairs(report.TopUsers))))
+
+	return slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: statsCallbackID,
+		Title:      slack.NewTextBlockObject(slack.PlainTextType, "PR Usage Stats", false, false),
+		Close:      slack.NewTextBlockObject(slack.PlainTextType, "Close", false, false),
+		Blocks:     slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// formatDailyChart draws a monospace bar per day for the last n days of
+// perDay, scaled to the busiest day.
+func formatDailyChart(perDay []DayCount, n int) string {
+	if len(perDay) > n {
+		perDay = perDay[len(perDay)-n:]
+	}
+	var peak int64
+	for _, d := range perDay {
+		if d.Count > peak {
+			peak = d.Count
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("```")
+	for i, d := range perDay {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		width := 0
+		if peak > 0 {
+			width = int(d.Count * statsBarWidth / peak)
+		}
+		if width == 0 && d.Count > 0 {
+			width = 1
+		}
+		fmt.Fprintf(&b, "%s %-*s %d", d.Day.Format("Mon 01-02"), statsBarWidth, strings.Repeat("█", width), d.Count)
+	}
+	b.WriteString("```")
+	return b.String()
+}
+
+// countPair is a ranked name and count, as shown in the stats modal.
+type countPair struct {
+	Name  string
+	Count int64
+}
+
+func repoCountPairs(counts []RepoCount) []countPair {
+	pairs := make([]countPair, 0, len(counts))
+	for _, c := range counts {
+		pairs = append(pairs, countPair{Name: "`" + c.Repo + "`", Count: c.Count})
+	}
+	return pairs
+}
+
+func userCountPairs(counts []UserCount) []countPair {
+	pairs := make([]countPair, 0, len(counts))
+	for _, c := range counts {
+		pairs = append(pairs, countPair{Name: "@" + c.User, Count: c.Count})
+	}
+	return pairs
+}
+
+// formatRankedCounts renders a numbered list, or a placeholder when empty.
+func formatRankedCounts(pairs []countPair) string {
+	if len(pairs) == 0 {
+		return "_none yet_"
+	}
+	lines := make([]string, 0, len(pairs))
+	for i, p := range pairs {
+		lines = append(lines, fmt.Sprintf("%d. %s — %d", i+1, p.Name, p.Count))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const statusUsage = ":warning: Usage: `/pr status <repo> <number>` or `/pr status <pull request URL>`"
+
+// parsePRRefArgs accepts "<repo> <number>" or a single PR URL, as taken by
+// /pr status and /pr unpost.
+func parsePRRefArgs(fields []string, config config.Config) (prArgs, error) {
+	switch len(fields) {
+	case 1:
+		args, err := parsePRArgs(fields[0], config)
+		if err != nil {
+			return prArgs{}, err
+		}
+		if args.Number == 0 {
+			return prArgs{}, fmt.Errorf("missing pull request number")
+		}
+		return args, nil
+	case 2:
+		args, err := parsePRArgs(fields[0], config)
+		if err != nil {
+			return prArgs{}, err
+		}
+		number, err := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+		if err != nil || number <= 0 || args.Number != 0 {
+			return prArgs{}, fmt.Errorf("invalid pull request number %q", fields[1])
+		}
+		args.Number = number
+		return args, nil
+	default:
+		return prArgs{}, fmt.Errorf("expected a repo and a pull request number")
+	}
+}
+
+// handleStatusCommand implements /pr status: fetch one PR and post a compact
+// status card to the channel, without any modal.
+func handleStatusCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+	args, err := parsePRRefArgs(fields, config)
+	if err != nil {
+		text := fmt.Sprintf(":warning: %s.\n\n%s", capitalize(err.Error()), statusUsage)
+		if err := respondEphemeral(ctx, cmd.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to status for user %s: %v", cmd.UserName, err)
+		}
+		return
+	}
+
+	repo := args.fullRepo(config)
+	logging.InfoContext(ctx, "Fetching status of %s#%d for user %s", repo, args.Number, cmd.UserName)
+	inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID, Status: true}
+	if err := newPRSource(rdb, slackClient, config).ViewPR(ctx, repo, args.Number, cmd.ResponseURL, inv); err != nil {
+		logging.ErrorContext(ctx, "Error sending Poppit command for %s#%d: %v", repo, args.Number, err)
+	}
+}
+
+// presentPRStatus posts the status card for a fetched PR, threaded under an
+// earlier post when the invocation carries a thread key, and confirms to the
+// user via responseURL.
+func presentPRStatus(ctx context.Context, rdb *redis.Client, slackClient SlackClient, repo, responseURL string, inv Invocation, pr *PRItem, config config.Config) {
+	enrichPRReadiness(ctx, pr, repo, config)
+	msg := buildPRStatusMessage(pr, repo, inv.Username, config)
+	if inv.ThreadKey != "" {
+		msg.ThreadKey = inv.ThreadKey
+		msg.Metadata["event_payload"].(map[string]interface{})["thread_key"] = inv.ThreadKey
+	}
+	stampSlackLinerMetadata(ctx, &msg)
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling status card: %v", err)
+		return
+	}
+
+	if isDryRun(inv, config) {
+		logging.InfoContext(ctx, "[dry-run] Status card for PR #%d from %s not pushed: %s", pr.Number, repo, payload)
+		if err := respondEphemeral(ctx, responseURL, fmt.Sprintf(":test_tube: *Dry run* — this status card would have been posted:\n%s", msg.Text)); err != nil {
+			logging.ErrorContext(ctx, "Error sending status feedback: %v", err)
+		}
+		return
+	}
+
+	if err := pushSlackLiner(ctx, rdb, config, payload); err != nil {
+		logging.ErrorContext(ctx, "Error pushing status card to SlackLiner: %v", err)
+		if err := respondEphemeral(ctx, responseURL, "Failed to post the status card. Please try again."); err != nil {
+			logging.ErrorContext(ctx, "Error sending status feedback: %v", err)
+		}
+		return
+	}
+	logging.InfoContext(ctx, "Status card for PR #%d from %s posted (user: %s)", pr.Number, repo, inv.Username)
+}
+
+// buildPRStatusMessage formats the compact status card for a PR.
+func buildPRStatusMessage(pr *PRItem, repo, postedBy string, config config.Config) SlackLinerMessage {
+	text := prStatusText(pr, repo)
+
+	return SlackLinerMessage{
+		SlackLinerBranding: messageBranding(config),
+		Channel:            config.SlackChannelID,
+		Text:               text,
+		TTL:                config.MessageTTL(),
+		Blocks: []slack.Block{
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+				fmt.Sprintf("Status requested by @%s", postedBy), false, false)),
+		},
+		Metadata: map[string]interface{}{
+			"event_type": "pr_status_posted",
+			"event_payload": map[string]interface{}{
+				"pr_number":          pr.Number,
+				"repository":         repo,
+				"pr_url":             pr.URL,
+				"posted_by":          postedBy,
+				"review_decision":    pr.ReviewDecision,
+				"merge_state_status": pr.mergeStateStatus(),
+			},
+		},
+	}
+}
+
+// prStatusText is the body of the status card: title, checks, review
+// decision, and mergeability.
+func prStatusText(pr *PRItem, repo string) string {
+	return strings.Join([]string{
+		fmt.Sprintf("*<%s|%s#%d>* %s", pr.URL, repo, pr.Number, escapeSlackText(pr.Title)),
+		"*Checks:* " + checksSummary(pr),
+		"*Review:* " + orDefault(slackui.ReviewDecisionLabel(pr.ReviewDecision), "no review required"),
+		"*Merge:* " + orDefault(mergeStateSummary(pr), "unknown"),
+	}, "\n")
+}
+
+// checksSummary counts the PR's checks by state, e.g. "❌ 1 failing, 4 passing".
+func checksSummary(pr *PRItem) string {
+	checks := pr.StatusCheckRollup
+	if len(checks) == 0 && pr.Readiness != nil {
+		checks = pr.Readiness.CheckSuites
+	}
+	if len(checks) == 0 {
+		return "none"
+	}
+
+	counts := map[CheckState]int{}
+	for _, c := range checks {
+		counts[c.state()]++
+	}
+	var parts []string
+	for _, s := range []struct {
+		state CheckState
+		word  string
+	}{{CheckStateFailing, "failing"}, {CheckStatePending, "pending"}, {CheckStatePassing, "passing"}} {
+		if n := counts[s.state]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, s.word))
+		}
+	}
+	return checkStateEmoji(PRItem{StatusCheckRollup: checks}.checkState()) + " " + strings.Join(parts, ", ")
+}
+
+// mergeStateStatus prefers the GraphQL readiness value over gh's field.
+func (pr *PRItem) mergeStateStatus() string {
+	if pr.Readiness != nil && pr.Readiness.MergeStateStatus != "" {
+		return pr.Readiness.MergeStateStatus
+	}
+	return pr.MergeStateStatus
+}
+
+// mergeable prefers the GraphQL readiness value over gh's field.
+func (pr *PRItem) mergeable() string {
+	if pr.Readiness != nil && pr.Readiness.Mergeable != "" {
+		return pr.Readiness.Mergeable
+	}
+	return pr.Mergeable
+}
+
+// hasConflicts reports whether GitHub found merge conflicts with the base
+// branch. It is false when mergeability was not fetched.
+func (pr *PRItem) hasConflicts() bool {
+	return pr.mergeable() == "CONFLICTING" || pr.mergeStateStatus() == "DIRTY"
+}
+
+// mergeStateSummary describes whether the PR can be merged.
+func mergeStateSummary(pr *PRItem) string {
+	if label, ok := mergeStateLabels[pr.mergeStateStatus()]; ok {
+		return label
+	}
+	if pr.mergeable() == "CONFLICTING" {
+		return mergeStateLabels["DIRTY"]
+	}
+	return ""
+}
+
+// orDefault returns s, or fallback when s is empty.
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
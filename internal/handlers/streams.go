@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// streamPayloadField is the stream entry field carrying the JSON payload
+	// that would otherwise be the pub/sub message.
+	streamPayloadField = "payload"
+	// streamBlock is how long one XREADGROUP waits for new entries.
+	streamBlock = 5 * time.Second
+	// streamClaimIdle is how long an entry must sit unacknowledged with
+	// another consumer before this one claims it at startup.
+	streamClaimIdle = time.Minute
+	streamReadCount = 10
+)
+
+const (
+	// reconnectBaseDelay is the first wait before resubscribing to a feed
+	// after a Redis error; it doubles on each failure up to
+	// reconnectMaxDelay.
+	reconnectBaseDelay = 500 * time.Millisecond
+	reconnectMaxDelay  = 30 * time.Second
+	// pubsubPingInterval is how long a pub/sub connection may stay silent
+	// before it is pinged; a ping unanswered for as long again counts as a
+	// dropped connection.
+	pubsubPingInterval = 30 * time.Second
+)
+
+// Publish sends payload to a feed the way its producer would: with XADD in
+// the payload field when the feed is read as a stream, or PUBLISH otherwise.
+// It replays dead-lettered payloads and drives the service from cmd/replay.
+func Publish(ctx context.Context, rdb *redis.Client, feed, payload string, stream bool) error {
+	if stream {
+		return rdb.XAdd(ctx, &redis.XAddArgs{Stream: feed, Values: map[string]interface{}{streamPayloadField: payload}}).Err()
+	}
+	return rdb.Publish(ctx, feed, payload).Err()
+}
+
+// consume delivers each payload published under name to handle until ctx is
+// done: from the pub/sub channel name, or, with redis.consumer set to
+// streams, from the stream name through the configured consumer group.
+func consume(ctx context.Context, rdb *redis.Client, name string, handle func(payload string), config config.Config) {
+	if config.UsesStreams() {
+		consumeStream(ctx, rdb, name, handle, config)
+		return
+	}
+	subscribe(ctx, rdb, name, handle)
+}
+
+// subscribe delivers each message on the pub/sub channel to handle until ctx
+// is done. Payloads whose handler panics are dead-lettered. When the
+// connection drops, or stops answering pings, the channel is resubscribed on
+// a fresh connection with exponential backoff.
+func subscribe(ctx context.Context, rdb *redis.Client, channel string, handle func(payload string)) {
+	feeds.set(channel, false)
+	defer feeds.set(channel, false)
+
+	backoff := reconnectBaseDelay
+	for {
+		subscribed, err := subscribeOnce(ctx, rdb, channel, handle)
+		feeds.set(channel, false)
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			backoff = reconnectBaseDelay
+		}
+		logging.WarnContext(ctx, "Lost subscription to Redis channel %s, resubscribing in %s: %v", channel, backoff, err)
+		if !sleepContext(ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, reconnectMaxDelay)
+	}
+}
+
+// subscribeOnce consumes channel on one pub/sub connection until it fails or
+// ctx is done. It reports whether the subscription was ever confirmed.
+func subscribeOnce(ctx context.Context, rdb *redis.Client, channel string, handle func(payload string)) (bool, error) {
+	pubsub := rdb.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	subscribed, pinged := false, false
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, pubsubPingInterval)
+		if err != nil {
+			var netErr net.Error
+			if ctx.Err() == nil && !pinged && errors.As(err, &netErr) && netErr.Timeout() {
+				// Quiet channel: check the connection is still alive.
+				if err := pubsub.Ping(ctx); err != nil {
+					return subscribed, err
+				}
+				pinged = true
+				continue
+			}
+			return subscribed, err
+		}
+		pinged = false
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			logging.InfoContext(ctx, "Subscribed to Redis channel: %s", channel)
+			subscribed = true
+			feeds.set(channel, true)
+		case *redis.Message:
+			handleRecovering(ctx, rdb, channel, msg.Payload, handle)
+		}
+	}
+}
+
+// sleepContext waits for d, reporting false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// consumeStream reads stream through the consumer group, acknowledging each
+// entry once handle returns (or panics, in which case it is dead-lettered).
+// On every (re)connect it claims entries abandoned by other consumers and
+// replays its own unacknowledged ones, so interactions received while the
+// service was restarting or cut off from Redis are not lost. Redis errors are
+// retried with exponential backoff.
+func consumeStream(ctx context.Context, rdb *redis.Client, stream string, handle func(payload string), config config.Config) {
+	feeds.set(stream, false)
+	defer feeds.set(stream, false)
+
+	backoff := reconnectBaseDelay
+	for {
+		consumed, err := consumeStreamOnce(ctx, rdb, stream, handle, config)
+		feeds.set(stream, false)
+		if ctx.Err() != nil {
+			return
+		}
+		if consumed {
+			backoff = reconnectBaseDelay
+		}
+		logging.WarnContext(ctx, "Lost Redis stream %s, reconnecting in %s: %v", stream, backoff, err)
+		if !sleepContext(ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, reconnectMaxDelay)
+	}
+}
+
+// consumeStreamOnce ensures the consumer group exists and reads stream until
+// a Redis error or ctx is done. It reports whether reading ever started.
+func consumeStreamOnce(ctx context.Context, rdb *redis.Client, stream string, handle func(payload string), config config.Config) (bool, error) {
+	group, consumer := config.RedisConsumerGroup, config.RedisConsumerName
+	err := rdb.XGroupCreateMkStream(ctx, stream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return false, fmt.Errorf("failed to create consumer group %s: %w", group, err)
+	}
+	feeds.set(stream, true)
+
+	claimed, _, err := rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  streamClaimIdle,
+		Start:    "0-0",
+		Count:    100,
+	}).Result()
+	if err != nil {
+		logging.WarnContext(ctx, "Error claiming abandoned entries on stream %s: %v", stream, err)
+	} else if len(claimed) > 0 {
+		logging.InfoContext(ctx, "Claimed %d abandoned entries on stream %s", len(claimed), stream)
+	}
+
+	logging.InfoContext(ctx, "Consuming Redis stream %s as %s/%s", stream, group, consumer)
+
+	// "0" re-reads this consumer's pending entries; once they are drained,
+	// ">" reads new ones.
+	id := "0"
+	for {
+		streams, err := rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{stream, id},
+			Count:    streamReadCount,
+			Block:    streamBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return true, fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		var n int
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				n++
+				if payload, ok := msg.Values[streamPayloadField].(string); ok {
+					handleRecovering(ctx, rdb, stream, payload, handle)
+				} else {
+					logging.WarnContext(ctx, "Dropping entry %s on stream %s without a %q field", msg.ID, stream, streamPayloadField)
+				}
+				if err := rdb.XAck(ctx, stream, group, msg.ID).Err(); err != nil {
+					logging.ErrorContext(ctx, "Error acknowledging entry %s on stream %s: %v", msg.ID, stream, err)
+				}
+			}
+		}
+		if id == "0" && n == 0 {
+			id = ">"
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+// maxSuggestionOptions is Slack's limit on options returned for an external select.
+const maxSuggestionOptions = 100
+
+// subscribeToBlockSuggestions subscribes to the Redis block-suggestions channel
+// and answers each options request via handleBlockSuggestion.
+func subscribeToBlockSuggestions(ctx context.Context, rdb *redis.Client, config config.Config) {
+	subscribe(ctx, rdb, config.RedisBlockSuggestionsChannel, func(payload string) {
+		handleBlockSuggestion(ctx, rdb, payload, config)
+	})
+}
+
+// handleBlockSuggestion serves typeahead options for the repo chooser's and
+// PR chooser's external selects, including the reviewer picker. Responses are published to the
+// block-suggestion responses channel for the Slack relay to return.
+func handleBlockSuggestion(ctx context.Context, rdb *redis.Client, payload string, config config.Config) {
+	var suggestion BlockSuggestionPayload
+	if err := envelope.Decode(envelope.SchemaBlockSuggestion, []byte(payload), &suggestion); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling block suggestion: %v", err)
+		deadLetter(ctx, rdb, config.RedisBlockSuggestionsChannel, payload, err)
+		return
+	}
+
+	options, ok := suggestOptions(ctx, rdb, suggestion, config)
+	if !ok {
+		return
+	}
+	resp := BlockSuggestionResponse{
+		ActionTS:        suggestion.ActionTS,
+		ViewID:          suggestion.View.ID,
+		OptionsResponse: slack.OptionsResponse{Options: options},
+	}
+	if err := publishBlockSuggestionResponse(ctx, rdb, resp, config); err != nil {
+		logging.ErrorContext(ctx, "Error publishing suggestions for view_id %s: %v", suggestion.View.ID, err)
+	}
+}
+
+// suggestOptions returns the options for one external select request. It
+// reports false for unknown selects and when no options can be served.
+func suggestOptions(ctx context.Context, rdb *redis.Client, suggestion BlockSuggestionPayload, config config.Config) ([]*slack.OptionBlockObject, bool) {
+	config = withConfigOverrides(ctx, rdb, config)
+	switch suggestion.ActionID {
+	case slackui.SlashVibeIssueActionID:
+		return repoSuggestions(ctx, rdb, suggestion, config), true
+	case slackui.PRSelectActionID:
+		return prSuggestions(ctx, rdb, suggestion)
+	case slackui.ReviewerSelectActionID:
+		return reviewerSuggestions(ctx, rdb, suggestion, config), true
+	}
+	return nil, false
+}
+
+// repoSuggestions answers the repo chooser from the cached repo catalog
+// of the org currently selected in the chooser, with the user's recently
+// used repos listed first.
+func repoSuggestions(ctx context.Context, rdb *redis.Client, suggestion BlockSuggestionPayload, config config.Config) []*slack.OptionBlockObject {
+	org := config.GitHubOrg
+	var meta slackui.RepoChooserMetadata
+	if err := session.Open(suggestion.View.PrivateMetadata, &meta); err == nil && config.HasOrg(meta.Org) {
+		org = meta.Org
+	}
+
+	repos, err := repoCatalog(ctx, rdb, org, config)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading repo catalog for %s: %v", org, err)
+	}
+
+	recent, err := loadRecentRepos(ctx, rdb, suggestion.User.ID, org)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading recent repos for user %s: %v", suggestion.User.ID, err)
+	}
+
+	matches := rankRecentFirst(filterReposByQuery(repos, suggestion.Value), recent)
+	options := make([]*slack.OptionBlockObject, 0, len(matches))
+	for _, repo := range matches {
+		options = append(options, slack.NewOptionBlockObject(repo, slack.NewTextBlockObject(slack.PlainTextType, repo, false, false), nil))
+	}
+
+	logging.DebugContext(ctx, "Serving %d repo suggestions for query %q in org %s", len(options), suggestion.Value, org)
+	return options
+}
+
+// prSuggestions serves the PR chooser. Options come from the PR session
+// stored for the view and are filtered by the chooser's author and label
+// filters and what the user has typed so far. Dates use the user's cached
+// timezone; Slack is not called while the user types.
+func prSuggestions(ctx context.Context, rdb *redis.Client, suggestion BlockSuggestionPayload) ([]*slack.OptionBlockObject, bool) {
+	session, err := loadPRSession(ctx, rdb, suggestion.View.ID)
+	if err != nil {
+		logging.WarnContext(ctx, "No PR session for view_id %s: %v", suggestion.View.ID, err)
+		return nil, false
+	}
+
+	options := prOptions(filterPRsByQuery(session.chooserPRs(), suggestion.Value), userNow(ctx, rdb, nil, suggestion.User.ID))
+	logging.DebugContext(ctx, "Serving %d PR suggestions for query %q (view_id: %s)", len(options), suggestion.Value, suggestion.View.ID)
+	return options, true
+}
+
+// publishBlockSuggestionResponse sends an options response back to the relay.
+func publishBlockSuggestionResponse(ctx context.Context, rdb *redis.Client, resp BlockSuggestionResponse, config config.Config) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal suggestion response: %w", err)
+	}
+	if err := rdb.Publish(ctx, config.RedisBlockSuggestionResponsesChannel, data).Err(); err != nil {
+		return fmt.Errorf("failed to publish suggestion response: %w", err)
+	}
+	return nil
+}
+
+// filterPRsByQuery returns PRs whose title, number, or repository contains query,
+// case-insensitively, capped at maxSuggestionOptions. An empty query matches
+// every PR.
+func filterPRsByQuery(prs []PRItem, query string) []PRItem {
+	query = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(query), "#")))
+
+	matches := make([]PRItem, 0, len(prs))
+	for _, pr := range prs {
+		if len(matches) == maxSuggestionOptions {
+			break
+		}
+		if query == "" ||
+			strings.Contains(strings.ToLower(pr.Title), query) ||
+			strings.Contains(fmt.Sprintf("%d", pr.Number), query) ||
+			strings.Contains(strings.ToLower(pr.Repository.NameWithOwner), query) {
+			matches = append(matches, pr)
+		}
+	}
+	return matches
+}
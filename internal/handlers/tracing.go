@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+)
+
+// tracerName identifies the spans this service creates.
+const tracerName = "github.com/its-the-vibe/SlashVibePR"
+
+// traceField is the Poppit and SlackLiner metadata field carrying the W3C
+// trace context, so spans on either side of a Redis hop join one trace.
+const traceField = "trace_context"
+
+// SetupTracing exports spans over OTLP/HTTP to tracing.otlp_endpoint. With no
+// endpoint the global no-op provider stays in place and nothing is
+// propagated. The returned function flushes pending spans.
+func SetupTracing(ctx context.Context, config config.Config) (func(context.Context) error, error) {
+	if config.TracingEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// Credentials come from OTEL_EXPORTER_OTLP_HEADERS, read by the exporter.
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.TracingEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.TracingServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// startSpan starts a span named name as a child of any span in ctx.
+func startSpan(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceCarrier returns the trace context of ctx in W3C header form, or nil
+// when there is nothing to propagate.
+func traceCarrier(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// withTraceCarrier returns ctx continuing the trace in carrier, if any.
+func withTraceCarrier(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// injectTraceContext adds the trace context of ctx to JSON metadata.
+func injectTraceContext(ctx context.Context, metadata map[string]interface{}) {
+	carrier := traceCarrier(ctx)
+	if carrier == nil {
+		return
+	}
+	m := make(map[string]interface{}, len(carrier))
+	for k, v := range carrier {
+		m[k] = v
+	}
+	metadata[traceField] = m
+}
+
+// traceContextFromMetadata returns ctx continuing the trace carried in JSON
+// metadata by injectTraceContext.
+func traceContextFromMetadata(ctx context.Context, metadata map[string]interface{}) context.Context {
+	m, ok := metadata[traceField].(map[string]interface{})
+	if !ok {
+		return ctx
+	}
+	carrier := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			carrier[k] = s
+		}
+	}
+	return withTraceCarrier(ctx, carrier)
+}
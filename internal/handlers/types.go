@@ -0,0 +1,535 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+)
+
+// SlackCommand represents an incoming Slack slash command payload.
+type SlackCommand struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	ResponseURL string `json:"response_url"`
+	TriggerID   string `json:"trigger_id"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	ChannelID   string `json:"channel_id"`
+	TeamID      string `json:"team_id"`
+}
+
+// ViewSubmission represents a Slack view submission event payload.
+type ViewSubmission struct {
+	Type      string `json:"type"`
+	TriggerID string `json:"trigger_id"`
+	View      struct {
+		ID              string `json:"id"`
+		Hash            string `json:"hash"`
+		CallbackID      string `json:"callback_id"`
+		PrivateMetadata string `json:"private_metadata"`
+		State           struct {
+			Values map[string]map[string]interface{} `json:"values"`
+		} `json:"state"`
+	} `json:"view"`
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// SlackLinerBranding overrides the name and icon SlackLiner posts a message
+// with; empty fields keep the app's own.
+type SlackLinerBranding struct {
+	Username  string `json:"username,omitempty"`
+	IconEmoji string `json:"icon_emoji,omitempty"`
+	IconURL   string `json:"icon_url,omitempty"`
+}
+
+// SlackLinerMessage is the payload pushed to SlackLiner for posting to Slack.
+// When Blocks is set Slack renders them and Text becomes the notification
+// fallback. Key asks SlackLiner to remember the posted message's ts under that key;
+// ThreadKey posts the message as a thread reply to the message stored under
+// that key. Action "delete" asks SlackLiner to delete the message stored
+// under Key in Channel instead of posting anything. The embedded branding
+// sets the bot name and icon the message is posted with.
+type SlackLinerMessage struct {
+	SlackLinerBranding
+
+	Action    string                 `json:"action,omitempty"`
+	Channel   string                 `json:"channel"`
+	Text      string                 `json:"text"`
+	TTL       int                    `json:"ttl,omitempty"`
+	Key       string                 `json:"key,omitempty"`
+	ThreadKey string                 `json:"thread_key,omitempty"`
+	Blocks    []slack.Block          `json:"blocks,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// PRItem represents a single pull request returned by `gh pr list --json`.
+type PRItem struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	URL          string    `json:"url"`
+	HeadRefName  string    `json:"headRefName"`
+	BaseRefName  string    `json:"baseRefName"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Body         string    `json:"body"`
+	Additions    int       `json:"additions"`
+	Deletions    int       `json:"deletions"`
+	ChangedFiles int       `json:"changedFiles"`
+	IsDraft      bool      `json:"isDraft"`
+	// State is OPEN, CLOSED, or MERGED.
+	State  string `json:"state"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	StatusCheckRollup []CheckStatus `json:"statusCheckRollup"`
+	// ReviewDecision is APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED, or empty
+	// when the repository does not require reviews.
+	ReviewDecision string `json:"reviewDecision"`
+	// ReviewRequests are the users and teams asked to review and yet to do so.
+	ReviewRequests []ReviewRequest `json:"reviewRequests"`
+	// Milestone is nil when the PR is in no milestone.
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone,omitempty"`
+	// ProjectItems are the projects the PR has been added to. The REST
+	// API does not expose them, so they stay empty in API mode.
+	ProjectItems []struct {
+		Title string `json:"title"`
+	} `json:"projectItems,omitempty"`
+	// Mergeable and MergeStateStatus are only fetched for single-PR views.
+	Mergeable        string `json:"mergeable,omitempty"`
+	MergeStateStatus string `json:"mergeStateStatus,omitempty"`
+	// Repository is only set by cross-repo searches such as /pr mine.
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+	// Readiness is filled in over GraphQL just before posting, when GitHub
+	// credentials are configured.
+	Readiness *PRReadiness `json:"readiness,omitempty"`
+}
+
+// ReviewRequest is one entry of gh's reviewRequests: a user, identified by
+// Login, or a team, identified by Slug.
+type ReviewRequest struct {
+	TypeName string `json:"__typename"`
+	Login    string `json:"login"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+}
+
+// reviewer returns the requested user's login, or the team's slug.
+func (r ReviewRequest) reviewer() string {
+	if r.Login != "" {
+		return r.Login
+	}
+	return r.Slug
+}
+
+// requestedReviewers returns the logins and team slugs asked to review the PR.
+func (pr PRItem) requestedReviewers() []string {
+	names := make([]string, 0, len(pr.ReviewRequests))
+	for _, r := range pr.ReviewRequests {
+		if name := r.reviewer(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// milestoneTitle returns the title of the PR's milestone, or "".
+func (pr PRItem) milestoneTitle() string {
+	if pr.Milestone == nil {
+		return ""
+	}
+	return pr.Milestone.Title
+}
+
+// projectTitles returns the titles of the projects the PR belongs to.
+func (pr PRItem) projectTitles() []string {
+	titles := make([]string, 0, len(pr.ProjectItems))
+	for _, p := range pr.ProjectItems {
+		titles = append(titles, p.Title)
+	}
+	return titles
+}
+
+// labelNames returns the names of the PR's labels.
+func (pr PRItem) labelNames() []string {
+	names := make([]string, 0, len(pr.Labels))
+	for _, l := range pr.Labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// diffStats returns e.g. "+120 −45 across 7 files", or "" when the size of
+// the change was not fetched.
+func (pr PRItem) diffStats() string {
+	if pr.Additions == 0 && pr.Deletions == 0 && pr.ChangedFiles == 0 {
+		return ""
+	}
+	files := "files"
+	if pr.ChangedFiles == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("+%d −%d across %d %s", pr.Additions, pr.Deletions, pr.ChangedFiles, files)
+}
+
+// repoOr returns the PR's own repository when known, otherwise fallback.
+func (pr PRItem) repoOr(fallback string) string {
+	if pr.Repository.NameWithOwner != "" {
+		return pr.Repository.NameWithOwner
+	}
+	return fallback
+}
+
+// optionValue identifies the PR in chooser options: its number, qualified
+// with the repository for cross-repo lists.
+func (pr PRItem) optionValue() string {
+	if pr.Repository.NameWithOwner != "" {
+		return fmt.Sprintf("%s#%d", pr.Repository.NameWithOwner, pr.Number)
+	}
+	return strconv.Itoa(pr.Number)
+}
+
+// PRListOptions narrows the set of pull requests fetched for a repository.
+type PRListOptions struct {
+	// Base restricts results to PRs targeting this base branch. It may contain
+	// a trailing glob (e.g. "release/*"), in which case filtering happens
+	// locally after the list is fetched.
+	Base string
+	// Author restricts results to PRs opened by this GitHub login.
+	Author string
+	// Labels restricts results to PRs carrying every one of these labels.
+	Labels []string
+	// Milestone restricts results to PRs in the milestone with this title.
+	Milestone string
+	// HideDrafts leaves draft PRs out of the results.
+	HideDrafts bool
+	// Sort is one of prSortOrders, or empty for gh's default order.
+	Sort string
+	// State is one of prStates; empty means open PRs.
+	State string
+}
+
+// baseGlobListLimit is how many PRs are fetched at least when Base is a
+// pattern: it is matched locally, after the fetch limit, so a larger list
+// leaves fewer matching PRs behind.
+const baseGlobListLimit = 500
+
+// basePattern reports whether Base is a glob pattern, which neither gh nor
+// the pulls API accepts as a base branch.
+func (o PRListOptions) basePattern() bool {
+	return strings.ContainsAny(o.Base, "*?[")
+}
+
+// fetchLimit returns how many PRs to fetch for the options, given the
+// configured limit: at least baseGlobListLimit when Base is a pattern.
+func (o PRListOptions) fetchLimit(limit int) int {
+	if o.basePattern() {
+		return max(limit, baseGlobListLimit)
+	}
+	return limit
+}
+
+// prStates are the values --state accepts, as gh pr list spells them.
+var prStates = []string{"open", "closed", "merged"}
+
+// stateLabel returns the PR state the options list, for user-facing text.
+func (o PRListOptions) stateLabel() string {
+	if o.State == "" {
+		return "open"
+	}
+	return o.State
+}
+
+// PRModalPrivateMetadata is stored in the PR-chooser modal's private_metadata field.
+// The PR list itself lives in the Redis PR session; PRs is only populated in
+// session data and in modals opened before sessions were introduced.
+type PRModalPrivateMetadata struct {
+	Repo   string   `json:"repo"`
+	PRs    []PRItem `json:"prs,omitempty"`
+	DryRun bool     `json:"dry_run,omitempty"`
+	Multi  bool     `json:"multi,omitempty"`
+	// Posted holds the option values of PRs already posted from this view.
+	Posted []string `json:"posted,omitempty"`
+	// Base, Author, and Labels record the filters the list was fetched
+	// with; Author and Labels also follow the chooser's author and label
+	// filters, narrowing the options served from the session.
+	Base   string   `json:"base,omitempty"`
+	Author string   `json:"author,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	// State is the --state the list was fetched with, when not open.
+	State string `json:"state,omitempty"`
+	// Truncated is set when Base is a pattern and the list it was matched
+	// against was cut off at the fetch limit.
+	Truncated bool `json:"truncated,omitempty"`
+	// Channel is the channel the chooser started on, and Routed whether
+	// slack.channel_routes chose it; picking another channel overrides it.
+	Channel string `json:"channel,omitempty"`
+	Routed  bool   `json:"routed,omitempty"`
+	// RequestID and Trace continue the request ID and trace of the command
+	// that opened the modal.
+	RequestID string            `json:"request_id,omitempty"`
+	Trace     map[string]string `json:"trace,omitempty"`
+}
+
+// BlockSuggestionPayload represents a Slack block_suggestion request for an
+// external select's options, forwarded by the Slack relay.
+type BlockSuggestionPayload struct {
+	Type     string `json:"type"`
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	Value    string `json:"value"`
+	ActionTS string `json:"action_ts"`
+	View     struct {
+		ID              string `json:"id"`
+		PrivateMetadata string `json:"private_metadata"`
+	} `json:"view"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// BlockSuggestionResponse is published back to the Slack relay with the
+// options for a block_suggestion request, correlated by action_ts.
+type BlockSuggestionResponse struct {
+	ActionTS string `json:"action_ts"`
+	ViewID   string `json:"view_id"`
+	slack.OptionsResponse
+}
+
+// BlockActionPayload represents a Slack block_actions interaction payload.
+// It is published to the Redis block-actions channel by the Slack relay.
+type BlockActionPayload struct {
+	Type      string `json:"type"`
+	TriggerID string `json:"trigger_id"`
+	View      struct {
+		ID              string `json:"id"`
+		PrivateMetadata string `json:"private_metadata"`
+	} `json:"view"`
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	// Channel, ResponseURL and Message are set for actions on messages,
+	// such as posted PRs.
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	ResponseURL string `json:"response_url"`
+	Message     struct {
+		Text   string       `json:"text"`
+		Blocks slack.Blocks `json:"blocks"`
+	} `json:"message"`
+	Actions []struct {
+		ActionID       string `json:"action_id"`
+		BlockID        string `json:"block_id"`
+		Type           string `json:"type"`
+		Value          string `json:"value"`
+		SelectedOption struct {
+			Value string `json:"value"`
+		} `json:"selected_option"`
+		// SelectedOptions is set by multi-selects.
+		SelectedOptions []struct {
+			Value string `json:"value"`
+		} `json:"selected_options"`
+	} `json:"actions"`
+}
+
+// MessageShortcutPayload represents a Slack message_action interaction (a
+// message shortcut), forwarded on the block-actions channel by the Slack
+// relay. Message metadata is only present on messages the app posted.
+type MessageShortcutPayload struct {
+	Type        string `json:"type"`
+	CallbackID  string `json:"callback_id"`
+	TriggerID   string `json:"trigger_id"`
+	ResponseURL string `json:"response_url"`
+	User        struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Message struct {
+		TS       string `json:"ts"`
+		Metadata struct {
+			EventType    string                 `json:"event_type"`
+			EventPayload map[string]interface{} `json:"event_payload"`
+		} `json:"metadata"`
+	} `json:"message"`
+}
+
+// LinkSharedEvent represents a Slack link_shared event forwarded by the Slack
+// relay. Source is "composer" for links typed but not yet sent, in which case
+// MessageTS is not a real message timestamp.
+type LinkSharedEvent struct {
+	Type      string `json:"type"`
+	User      string `json:"user"`
+	Channel   string `json:"channel"`
+	MessageTS string `json:"message_ts"`
+	Source    string `json:"source"`
+	Links     []struct {
+		Domain string `json:"domain"`
+		URL    string `json:"url"`
+	} `json:"links"`
+}
+
+// ReactionAddedEvent represents a Slack reaction_added event forwarded by the
+// Slack relay. Reaction is the emoji name without colons.
+type ReactionAddedEvent struct {
+	Type     string `json:"type"`
+	User     string `json:"user"`
+	Reaction string `json:"reaction"`
+	ItemUser string `json:"item_user"`
+	Item     struct {
+		Type    string `json:"type"`
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	} `json:"item"`
+}
+
+// AppHomeOpenedEvent represents a Slack app_home_opened event forwarded by the
+// Slack relay.
+type AppHomeOpenedEvent struct {
+	Type string `json:"type"`
+	User string `json:"user"`
+	Tab  string `json:"tab"`
+}
+
+// PostOptions carries per-post choices made by the user in the PR chooser,
+// plus details resolved just before the message is built.
+type PostOptions struct {
+	// Note is an optional free-text explanation attached to the post.
+	Note string
+	// AuthorSlackID is the Slack user mapped to the PR author, if any.
+	AuthorSlackID string
+	// ThreadKey identifies the posted message so follow-ups can thread under it.
+	ThreadKey string
+	// PostedByID is the Slack user ID of the poster, recorded so only they
+	// (or an admin) can retract the post.
+	PostedByID string
+	// SuggestedReviewer is the GitHub login picked from the repo's reviewer
+	// pool, and SuggestedReviewerSlackID its mapped Slack user, if any.
+	SuggestedReviewer        string
+	SuggestedReviewerSlackID string
+	// ReviewerSlackIDs maps the PR's requested reviewers to their Slack
+	// users, for those that have one.
+	ReviewerSlackIDs map[string]string
+}
+
+// prArgs is the parsed form of the /pr command text.
+type prArgs struct {
+	// Org is set only when the user supplied an explicit org/repo path.
+	Org  string
+	Repo string
+	// Number is set when the user pasted a pull request URL.
+	Number int
+	List   PRListOptions
+	DryRun bool
+	// Multi requests the multi-select variant of the PR chooser.
+	Multi bool
+}
+
+// fullRepo returns the owner/name path for the parsed repo, falling back to
+// the configured organisation when no org was given.
+func (a prArgs) fullRepo(config config.Config) string {
+	org := a.Org
+	if org == "" {
+		org = config.GitHubOrg
+	}
+	return org + "/" + a.Repo
+}
+
+// Invocation identifies who triggered a flow and how. It is threaded through
+// Poppit metadata so asynchronous handlers can act on the user's behalf.
+type Invocation struct {
+	UserID   string
+	Username string
+	// TeamID is the Slack workspace the request came from, for resolving
+	// feature flags.
+	TeamID string
+	DryRun bool
+	Multi  bool
+	// Status asks for a compact status card (/pr status) instead of the
+	// regular PR post.
+	Status bool
+	// ThreadKey, when set, threads the status card under an earlier post.
+	ThreadKey string
+	// Unfurl, when set, renders the fetched PR as a link unfurl instead of
+	// posting it.
+	Unfurl LinkUnfurl
+	// Channel, when set, is the channel picked in the PR chooser, which
+	// overrides slack.channel_routes and the default channel.
+	Channel string
+}
+
+// LinkUnfurl identifies a PR link shared in a channel message.
+type LinkUnfurl struct {
+	Channel   string
+	MessageTS string
+	URL       string
+}
+
+// metadata returns the invocation as Poppit/SlackLiner metadata fields.
+func (inv Invocation) metadata() map[string]interface{} {
+	m := map[string]interface{}{
+		"user_id":  inv.UserID,
+		"username": inv.Username,
+	}
+	if inv.TeamID != "" {
+		m["team_id"] = inv.TeamID
+	}
+	if inv.DryRun {
+		m["dry_run"] = true
+	}
+	if inv.Multi {
+		m["multi"] = true
+	}
+	if inv.Status {
+		m["status"] = true
+	}
+	if inv.ThreadKey != "" {
+		m["thread_key"] = inv.ThreadKey
+	}
+	if inv.Unfurl.URL != "" {
+		m["unfurl_channel"] = inv.Unfurl.Channel
+		m["unfurl_ts"] = inv.Unfurl.MessageTS
+		m["unfurl_url"] = inv.Unfurl.URL
+	}
+	return m
+}
+
+// invocationFromMetadata is the inverse of Invocation.metadata.
+func invocationFromMetadata(m map[string]interface{}) Invocation {
+	var inv Invocation
+	inv.UserID, _ = m["user_id"].(string)
+	inv.Username, _ = m["username"].(string)
+	inv.TeamID, _ = m["team_id"].(string)
+	inv.DryRun, _ = m["dry_run"].(bool)
+	inv.Multi, _ = m["multi"].(bool)
+	inv.Status, _ = m["status"].(bool)
+	inv.ThreadKey, _ = m["thread_key"].(string)
+	inv.Unfurl.Channel, _ = m["unfurl_channel"].(string)
+	inv.Unfurl.MessageTS, _ = m["unfurl_ts"].(string)
+	inv.Unfurl.URL, _ = m["unfurl_url"].(string)
+	return inv
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+// maxUnfurlLinks caps how many PR links in one message are unfurled.
+const maxUnfurlLinks = 5
+
+// subscribeToLinkShared subscribes to the Redis link-shared channel and
+// dispatches each event to handleLinkShared.
+func subscribeToLinkShared(ctx context.Context, rdb *redis.Client, slackClient SlackClient, config config.Config) {
+	subscribe(ctx, rdb, config.RedisLinkSharedChannel, func(payload string) {
+		handleLinkShared(ctx, rdb, slackClient, payload, config)
+	})
+}
+
+// handleLinkShared fetches each GitHub PR link in a sent message through the
+// PR source and unfurls it as a PR card. Only PRs in the configured orgs are
+// unfurled, so private PR details never reach channels they were not meant
+// for; links still in the composer are ignored.
+func handleLinkShared(ctx context.Context, rdb *redis.Client, slackClient SlackClient, payload string, config config.Config) {
+	config = withConfigOverrides(ctx, rdb, config)
+	var event LinkSharedEvent
+	if err := envelope.Decode(envelope.SchemaLinkShared, []byte(payload), &event); err != nil {
+		logging.ErrorContext(ctx, "Error unmarshaling link_shared event: %v", err)
+		deadLetter(ctx, rdb, config.RedisLinkSharedChannel, payload, err)
+		return
+	}
+	if event.Type != "link_shared" || event.Source == "composer" || event.Channel == "" || event.MessageTS == "" {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, link := range event.Links {
+		if len(seen) == maxUnfurlLinks {
+			break
+		}
+		m := prURLPattern.FindStringSubmatch(link.URL)
+		if m == nil || seen[link.URL] || !config.HasOrg(m[1]) {
+			continue
+		}
+		seen[link.URL] = true
+
+		repo := m[1] + "/" + m[2]
+		number, _ := strconv.Atoi(m[3])
+		inv := Invocation{
+			UserID: event.User,
+			Unfurl: LinkUnfurl{Channel: event.Channel, MessageTS: event.MessageTS, URL: link.URL},
+		}
+		logging.DebugContext(ctx, "Unfurling %s#%d in channel %s", repo, number, event.Channel)
+		if err := newPRSource(rdb, slackClient, config).ViewPR(ctx, repo, number, "", inv); err != nil {
+			logging.ErrorContext(ctx, "Error fetching %s#%d for unfurl: %v", repo, number, err)
+		}
+	}
+}
+
+// presentPRUnfurl attaches the PR card to the message the link was shared in.
+// The card is seen by the whole channel, so its date is in UTC.
+func presentPRUnfurl(ctx context.Context, slackClient SlackClient, repo string, inv Invocation, pr *PRItem, config config.Config) {
+	enrichPRReadiness(ctx, pr, repo, config)
+	unfurls := map[string]slack.Attachment{inv.Unfurl.URL: buildPRUnfurl(pr, repo, time.Now().UTC())}
+
+	if isDryRun(inv, config) {
+		data, _ := json.Marshal(unfurls)
+		logging.InfoContext(ctx, "[dry-run] Unfurl for PR #%d from %s not sent: %s", pr.Number, repo, data)
+		return
+	}
+
+	if _, _, _, err := slackClient.UnfurlMessageContext(ctx, inv.Unfurl.Channel, inv.Unfurl.MessageTS, unfurls); err != nil {
+		logging.ErrorContext(ctx, "Error unfurling PR #%d from %s: %v", pr.Number, repo, err)
+		return
+	}
+	logging.InfoContext(ctx, "Unfurled PR #%d from %s in channel %s", pr.Number, repo, inv.Unfurl.Channel)
+}
+
+// buildPRUnfurl renders the PR as the status card plus a context line with
+// the author, branch, age, and labels.
+func buildPRUnfurl(pr *PRItem, repo string, now time.Time) slack.Attachment {
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, prStatusText(pr, repo), false, false), nil, nil),
+	}
+	if desc := prOptionDescription(*pr, now); desc != "" {
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, escapeSlackText(desc), false, false)))
+	}
+	return slack.Attachment{Blocks: slack.Blocks{BlockSet: blocks}}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const unpostUsage = ":warning: Usage: `/pr unpost <repo> <number>` or `/pr unpost <pull request URL>`"
+
+// canUnpost reports whether userID may retract rec: the original poster or a
+// privileged user.
+func canUnpost(rec *PostedPR, userID string, privileged bool) bool {
+	return (rec.PostedByID != "" && rec.PostedByID == userID) || privileged
+}
+
+// buildUnpostMessage asks SlackLiner to delete the channel message it stored
+// under the post's thread key.
+func buildUnpostMessage(rec *PostedPR) SlackLinerMessage {
+	return SlackLinerMessage{
+		Action:  "delete",
+		Channel: rec.Channel,
+		Key:     rec.ThreadKey,
+	}
+}
+
+// handleUnpostCommand implements /pr unpost: delete the channel message of an
+// earlier post and forget it, so reminders and approvals no longer find it.
+func handleUnpostCommand(ctx context.Context, rdb *redis.Client, slackClient SlackClient, cmd SlackCommand, fields []string, config config.Config) {
+	reply := func(text string) {
+		if err := respondEphemeral(ctx, cmd.ResponseURL, text); err != nil {
+			logging.ErrorContext(ctx, "Error responding to unpost for user %s: %v", cmd.UserName, err)
+		}
+	}
+
+	args, err := parsePRRefArgs(fields, config)
+	if err != nil {
+		reply(fmt.Sprintf(":warning: %s.\n\n%s", capitalize(err.Error()), unpostUsage))
+		return
+	}
+	repo := args.fullRepo(config)
+
+	rec, err := loadPostedPR(ctx, rdb, repo, args.Number)
+	if errors.Is(err, redis.Nil) {
+		reply(fmt.Sprintf(":shrug: No recent post of %s#%d was found.", repo, args.Number))
+		return
+	}
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading post of %s#%d: %v", repo, args.Number, err)
+		reply(":x: Could not look up that post. Please try again.")
+		return
+	}
+	if !canUnpost(rec, cmd.UserID, isPrivileged(ctx, rdb, slackClient, cmd.UserID, config)) {
+		logging.WarnContext(ctx, "User %s tried to unpost %s#%d posted by %s", cmd.UserName, repo, args.Number, rec.PostedBy)
+		reply(fmt.Sprintf(":no_entry: Only @%s or a privileged user can retract this post.", rec.PostedBy))
+		return
+	}
+
+	payload, err := json.Marshal(buildUnpostMessage(rec))
+	if err != nil {
+		logging.ErrorContext(ctx, "Error marshaling unpost message: %v", err)
+		return
+	}
+	if config.DryRun {
+		logging.InfoContext(ctx, "[dry-run] Unpost of %s#%d not pushed: %s", repo, args.Number, payload)
+		reply(fmt.Sprintf(":test_tube: *Dry run* — the post of %s#%d would have been retracted.", repo, args.Number))
+		return
+	}
+
+	if err := pushSlackLiner(ctx, rdb, config, payload); err != nil {
+		logging.ErrorContext(ctx, "Error pushing unpost of %s#%d to SlackLiner: %v", repo, args.Number, err)
+		reply(":x: Could not retract the post. Please try again.")
+		return
+	}
+	if err := deletePostedPR(ctx, rdb, repo, args.Number); err != nil {
+		logging.WarnContext(ctx, "Error forgetting post of %s#%d: %v", repo, args.Number, err)
+	}
+
+	logging.InfoContext(ctx, "User %s retracted the post of %s#%d", cmd.UserName, repo, args.Number)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   auditActionUnpost,
+		UserID:   cmd.UserID,
+		Username: cmd.UserName,
+		Repo:     repo,
+		Number:   args.Number,
+		Channel:  rec.Channel,
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing unpost of %s#%d: %v", repo, args.Number, err)
+	}
+	reply(fmt.Sprintf(":wastebasket: Retracted the post of %s#%d.", repo, args.Number))
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+	// Profiles may name any timezone and the scratch image has no zoneinfo.
+	_ "time/tzdata"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	userInfoKeyPrefix = "slashvibepr:userinfo:"
+	// userInfoTTL bounds how long a profile is cached, so a changed
+	// timezone is picked up within a day.
+	userInfoTTL = 24 * time.Hour
+)
+
+// UserInfo is the part of a Slack user's profile SlashVibePR uses.
+type UserInfo struct {
+	// TZ is the IANA timezone from the profile, e.g. "Europe/London".
+	TZ string
+}
+
+// userInfoKey returns the Redis hash caching a user's profile.
+func userInfoKey(userID string) string {
+	return userInfoKeyPrefix + userID
+}
+
+// loadUserInfo returns userID's profile from the Redis cache, calling
+// users.info (users:read scope) and caching the answer on a miss. With a nil
+// slackClient only the cache is consulted, for latency-sensitive paths such
+// as typeahead suggestions.
+func loadUserInfo(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string) (UserInfo, error) {
+	if userID == "" {
+		return UserInfo{}, nil
+	}
+	if rdb != nil {
+		fields, err := rdb.HGetAll(ctx, userInfoKey(userID)).Result()
+		if err != nil {
+			logging.WarnContext(ctx, "Error reading cached profile of %s: %v", userID, err)
+		} else if len(fields) > 0 {
+			return UserInfo{TZ: fields["tz"]}, nil
+		}
+	}
+	if slackClient == nil {
+		return UserInfo{}, nil
+	}
+
+	user, err := slackClient.GetUserInfoContext(ctx, userID)
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("failed to look up user %s: %w", userID, err)
+	}
+	info := UserInfo{TZ: user.TZ}
+	if rdb != nil {
+		pipe := rdb.TxPipeline()
+		pipe.HSet(ctx, userInfoKey(userID), "tz", info.TZ)
+		pipe.Expire(ctx, userInfoKey(userID), userInfoTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			logging.WarnContext(ctx, "Error caching profile of %s: %v", userID, err)
+		}
+	}
+	return info, nil
+}
+
+// userLocation returns the timezone of userID's Slack profile, or UTC when
+// it is unknown or cannot be looked up.
+func userLocation(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string) *time.Location {
+	info, err := loadUserInfo(ctx, rdb, slackClient, userID)
+	if err != nil {
+		logging.WarnContext(ctx, "Error loading timezone of %s: %v", userID, err)
+		return time.UTC
+	}
+	if info.TZ == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(info.TZ)
+	if err != nil {
+		logging.WarnContext(ctx, "Unknown timezone %q for %s: %v", info.TZ, userID, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// userNow returns the current time in userID's timezone, so dates shown to
+// them fall on their calendar rather than UTC's.
+func userNow(ctx context.Context, rdb *redis.Client, slackClient SlackClient, userID string) time.Time {
+	return time.Now().In(userLocation(ctx, rdb, slackClient, userID))
+}
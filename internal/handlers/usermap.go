@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/poppit"
+)
+
+const (
+	githubToSlackKey = "slashvibepr:users:github_to_slack"
+	slackToGitHubKey = "slashvibepr:users:slack_to_github"
+	// pendingLinkKeyPrefix holds a /pr whoami link waiting for its gist
+	// challenge, keyed by Slack user ID, for pendingLinkTTL.
+	pendingLinkKeyPrefix = "slashvibepr:users:pending_link:"
+	pendingLinkTTL       = time.Hour
+	// poppitLinkVerifyType lists a GitHub user's gists to find the challenge
+	// code of a pending link.
+	poppitLinkVerifyType = "slash-vibe-pr-link-verify"
+	whoamiUsage          = ":warning: Usage: `/pr whoami`, `/pr whoami link <github-login>`, or `/pr whoami verify`"
+)
+
+// pendingLink is a self-service link that becomes active only once the
+// GitHub user proves it is theirs by publishing Code as a gist description.
+type pendingLink struct {
+	Login string `json:"login"`
+	Code  string `json:"code"`
+}
+
+// pendingLinkKey returns the Redis key of a Slack user's pending link.
+func pendingLinkKey(slackUserID string) string {
+	return pendingLinkKeyPrefix + slackUserID
+}
+
+// buildGistDescriptionsCommand returns the gh invocation printing the
+// descriptions of a GitHub user's most recent public gists as a JSON array.
+func buildGistDescriptionsCommand(login string) string {
+	return fmt.Sprintf("gh api users/%s/gists --jq %s", login, shellQuote("[.[].description]"))
+}
+
+// validGitHubLogin matches GitHub usernames: alphanumerics and single hyphens,
+// not starting with a hyphen, at most 39 characters.
+var validGitHubLogin = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9]|-[a-zA-Z0-9]){0,38}$`)
+
+// lookupSlackUserID returns the Slack user ID mapped to a GitHub login.
+// Self-service mappings in Redis take precedence over github.slack_users in
+// config. An empty string means no mapping exists.
+func lookupSlackUserID(ctx context.Context, rdb *redis.Client, login string, config config.Config) string {
+	if login == "" {
+		return ""
+	}
+	key := strings.ToLower(login)
+	id, err := rdb.HGet(ctx, githubToSlackKey, key).Result()
+	if err == nil && id != "" {
+		return id
+	}
+	if err != nil && err != redis.Nil {
+		logging.WarnContext(ctx, "Error looking up Slack user for GitHub login %s: %v", login, err)
+	}
+	for configLogin, configID := range config.GitHubSlackUsers {
+		if strings.EqualFold(configLogin, login) {
+			return configID
+		}
+	}
+	return ""
+}
+
+// lookupGitHubLogin returns the GitHub login mapped to a Slack user ID, from
+// Redis first and then from config. An empty string means no mapping exists.
+func lookupGitHubLogin(ctx context.Context, rdb *redis.Client, slackUserID string, config config.Config) string {
+	login, err := rdb.HGet(ctx, slackToGitHubKey, slackUserID).Result()
+	if err == nil && login != "" {
+		return login
+	}
+	if err != nil && err != redis.Nil {
+		logging.WarnContext(ctx, "Error looking up GitHub login for Slack user %s: %v", slackUserID, err)
+	}
+	for configLogin, configID := range config.GitHubSlackUsers {
+		if configID == slackUserID {
+			return configLogin
+		}
+	}
+	return ""
+}
+
+// loginTakenError is returned when linking a GitHub login that is already
+// linked to another Slack user.
+type loginTakenError struct {
+	Login string
+	Owner string
+	// FromConfig is set when the owner comes from github.slack_users, which
+	// only config.yaml can change.
+	FromConfig bool
+}
+
+func (e *loginTakenError) Error() string {
+	return fmt.Sprintf("GitHub login %s is linked to Slack user %s", e.Login, e.Owner)
+}
+
+// linkGitHubLogin records a two-way mapping between a Slack user and a GitHub
+// login, replacing any login the Slack user previously linked. Logins linked
+// to another Slack user are refused with a *loginTakenError unless force is
+// set, as for /pr admin link, which unlinks the other user first. Logins
+// mapped in github.slack_users are always refused for other users.
+func linkGitHubLogin(ctx context.Context, rdb *redis.Client, slackUserID, login string, force bool, config config.Config) error {
+	key := strings.ToLower(login)
+	if taken := configLoginTaken(slackUserID, login, config); taken != nil {
+		return taken
+	}
+
+	// Both hashes are watched so a concurrent link of the same login cannot
+	// leave the two directions disagreeing.
+	return rdb.Watch(ctx, func(tx *redis.Tx) error {
+		previous, err := tx.HGet(ctx, slackToGitHubKey, slackUserID).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to read existing mapping: %w", err)
+		}
+		owner, err := tx.HGet(ctx, githubToSlackKey, key).Result()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to read existing mapping: %w", err)
+		}
+		if owner != "" && owner != slackUserID && !force {
+			return &loginTakenError{Login: login, Owner: owner}
+		}
+
+		if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if previous != "" {
+				pipe.HDel(ctx, githubToSlackKey, strings.ToLower(previous))
+			}
+			if owner != "" && owner != slackUserID {
+				pipe.HDel(ctx, slackToGitHubKey, owner)
+			}
+			pipe.HSet(ctx, githubToSlackKey, key, slackUserID)
+			pipe.HSet(ctx, slackToGitHubKey, slackUserID, login)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to store user mapping: %w", err)
+		}
+		return nil
+	}, githubToSlackKey, slackToGitHubKey)
+}
+
+// configLoginTaken returns a *loginTakenError when github.slack_users maps
+// login to a Slack user other than slackUserID.
+func configLoginTaken(slackUserID, login string, config config.Config) *loginTakenError {
+	for configLogin, configID := range config.GitHubSlackUsers {
+		if strings.EqualFold(configLogin, login) && configID != slackUserID {
+			return &loginTakenError{Login: login, Owner: configID, FromConfig: true}
+		}
+	}
+	return nil
+}
+
+// loginTakenReply explains to a user why login could not be linked to them.
+func loginTakenReply(taken *loginTakenError) string {
+	if taken.FromConfig {
+		return fmt.Sprintf(":no_entry: GitHub user `%s` is mapped to <@%s> in `github.slack_users`. Ask an admin to change it in config.yaml.", taken.Login, taken.Owner)
+	}
+	return fmt.Sprintf(":no_entry: GitHub user `%s` is already linked to <@%s>. If it is yours, ask an admin to run `/pr admin link @you %s`.", taken.Login, taken.Owner, taken.Login)
+}
+
+// runAdminLink implements /pr admin link, which links a Slack user to a
+// GitHub login even when another user has linked it.
+func runAdminLink(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) string {
+	if len(args) != 2 {
+		return ""
+	}
+	userID, ok := parseSlackUserRef(args[0])
+	if !ok {
+		return fmt.Sprintf(":warning: %q is not a Slack user.\n\n%s", args[0], adminUsage())
+	}
+	login := args[1]
+	if !validGitHubLogin.MatchString(login) {
+		return fmt.Sprintf(":warning: `%s` is not a valid GitHub login.", login)
+	}
+
+	var taken *loginTakenError
+	if err := linkGitHubLogin(ctx, rdb, userID, login, true, config); errors.As(err, &taken) {
+		return loginTakenReply(taken)
+	} else if err != nil {
+		logging.ErrorContext(ctx, "Error linking GitHub login %s for %s: %v", login, userID, err)
+		return ":x: Could not save the GitHub login. Please try again."
+	}
+
+	logging.InfoContext(ctx, "User %s linked GitHub login %s to %s", cmd.UserName, login, userID)
+	if err := recordAudit(ctx, rdb, AuditEntry{
+		Action:   "link",
+		UserID:   cmd.UserID,
+		Username: cmd.UserName,
+		Detail:   userID + "=" + login,
+	}, config); err != nil {
+		logging.WarnContext(ctx, "Error auditing link of %s to %s: %v", login, userID, err)
+	}
+	return fmt.Sprintf(":white_check_mark: <@%s> is now linked to GitHub user `%s`.", userID, login)
+}
+
+// handleWhoamiCommand implements `/pr whoami` (show the caller's linked GitHub
+// login), `/pr whoami link <github-login>` (start linking it), and
+// `/pr whoami verify` (finish linking it). A self-service link only becomes
+// active once verifyPendingLink finds its challenge code in one of the
+// login's public gists; admins can link anyone with /pr admin link.
+func handleWhoamiCommand(ctx context.Context, rdb *redis.Client, cmd SlackCommand, args []string, config config.Config) {
+	var reply string
+	switch {
+	case len(args) == 0:
+		if login := lookupGitHubLogin(ctx, rdb, cmd.UserID, config); login != "" {
+			reply = fmt.Sprintf("You are linked to GitHub user `%s`.", login)
+		} else {
+			reply = "You have not linked a GitHub account yet. Use `/pr whoami link <github-login>`."
+		}
+	case len(args) == 2 && args[0] == "link":
+		reply = startPendingLink(ctx, rdb, cmd, args[1], config)
+	case len(args) == 1 && args[0] == "verify":
+		reply = queueLinkVerification(ctx, rdb, cmd, config)
+	default:
+		reply = whoamiUsage
+	}
+	if reply == "" {
+		return
+	}
+
+	if err := respondEphemeral(ctx, cmd.ResponseURL, reply); err != nil {
+		logging.ErrorContext(ctx, "Error responding to whoami for user %s: %v", cmd.UserName, err)
+	}
+}
+
+// startPendingLink records a pending link of the caller to login and returns
+// the challenge they must publish to verify it.
+func startPendingLink(ctx context.Context, rdb *redis.Client, cmd SlackCommand, login string, config config.Config) string {
+	if !validGitHubLogin.MatchString(login) {
+		return fmt.Sprintf(":warning: `%s` is not a valid GitHub login.", login)
+	}
+	if taken := configLoginTaken(cmd.UserID, login, config); taken != nil {
+		return loginTakenReply(taken)
+	}
+	owner, err := rdb.HGet(ctx, githubToSlackKey, strings.ToLower(login)).Result()
+	if err != nil && err != redis.Nil {
+		logging.ErrorContext(ctx, "Error reading the owner of GitHub login %s: %v", login, err)
+		return ":x: Failed to save your GitHub login. Please try again."
+	}
+	if owner != "" && owner != cmd.UserID {
+		logging.WarnContext(ctx, "Refused link of GitHub login %s for user %s: linked to %s", login, cmd.UserName, owner)
+		return loginTakenReply(&loginTakenError{Login: login, Owner: owner})
+	}
+
+	code, err := randomHex(8)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error generating a link challenge for user %s: %v", cmd.UserName, err)
+		return ":x: Failed to save your GitHub login. Please try again."
+	}
+	pending := pendingLink{Login: login, Code: "slashvibepr-" + code}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return ":x: Failed to save your GitHub login. Please try again."
+	}
+	if err := rdb.Set(ctx, pendingLinkKey(cmd.UserID), data, pendingLinkTTL).Err(); err != nil {
+		logging.ErrorContext(ctx, "Error saving pending link for user %s: %v", cmd.UserName, err)
+		return ":x: Failed to save your GitHub login. Please try again."
+	}
+	logging.InfoContext(ctx, "User %s started linking GitHub login %s", cmd.UserName, login)
+	return fmt.Sprintf(":key: To prove `%s` is yours, create a public gist at https://gist.github.com signed in as `%s`, with the description `%s`. Then run `/pr whoami verify` within an hour. You can delete the gist once you are linked; an admin can also link you with `/pr admin link`.", login, login, pending.Code)
+}
+
+// loadPendingLink returns the caller's pending link, or nil when there is
+// none or it has expired.
+func loadPendingLink(ctx context.Context, rdb *redis.Client, slackUserID string) (*pendingLink, error) {
+	data, err := rdb.Get(ctx, pendingLinkKey(slackUserID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pending pendingLink
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("invalid pending link: %w", err)
+	}
+	return &pending, nil
+}
+
+// queueLinkVerification queues the gist lookup behind /pr whoami verify;
+// verifyPendingLink replies once it returns.
+func queueLinkVerification(ctx context.Context, rdb *redis.Client, cmd SlackCommand, config config.Config) string {
+	pending, err := loadPendingLink(ctx, rdb, cmd.UserID)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading pending link for user %s: %v", cmd.UserName, err)
+		return ":x: Failed to check your GitHub login. Please try again."
+	}
+	if pending == nil {
+		return "You have no GitHub login waiting to be verified. Start with `/pr whoami link <github-login>`."
+	}
+
+	inv := Invocation{UserID: cmd.UserID, Username: cmd.UserName, TeamID: cmd.TeamID}
+	metadata := inv.metadata()
+	metadata["login"] = pending.Login
+	metadata["response_url"] = cmd.ResponseURL
+	if err := pushPoppitCommand(ctx, rdb, poppit.Command{
+		Type:     poppitLinkVerifyType,
+		Dir:      "/tmp",
+		Commands: []string{buildGistDescriptionsCommand(pending.Login)},
+		Metadata: metadata,
+	}, config); err != nil {
+		logging.ErrorContext(ctx, "Error queueing link verification for user %s: %v", cmd.UserName, err)
+		return ":x: Failed to check your GitHub login. Please try again."
+	}
+	return ""
+}
+
+// verifyPendingLink handles the gist lookup queued by queueLinkVerification:
+// when one of the login's gists is described with the pending challenge
+// code, the link becomes active and the pending link is removed.
+func verifyPendingLink(ctx context.Context, rdb *redis.Client, output poppit.Output, config config.Config) {
+	login, _ := output.Metadata["login"].(string)
+	responseURL, _ := output.Metadata["response_url"].(string)
+	inv := invocationFromMetadata(output.Metadata)
+	reply := func(msg string) {
+		if err := respondEphemeral(ctx, responseURL, msg); err != nil {
+			logging.ErrorContext(ctx, "Error responding to whoami verify for user %s: %v", inv.Username, err)
+		}
+	}
+	if inv.UserID == "" || login == "" {
+		logging.WarnContext(ctx, "Missing user or login in Poppit link verification metadata")
+		return
+	}
+
+	// The challenge is read back from Redis rather than trusted from the
+	// metadata, so only the code the user was given can verify the link.
+	pending, err := loadPendingLink(ctx, rdb, inv.UserID)
+	if err != nil {
+		logging.ErrorContext(ctx, "Error loading pending link for user %s: %v", inv.Username, err)
+		reply(":x: Failed to check your GitHub login. Please try again.")
+		return
+	}
+	if pending == nil || !strings.EqualFold(pending.Login, login) {
+		reply("Your link request has expired or changed. Start again with `/pr whoami link <github-login>`.")
+		return
+	}
+	if output.Failed() {
+		logging.WarnContext(ctx, "Listing gists of %s failed: %s", login, output.ErrorText())
+		reply(fmt.Sprintf(":x: Could not read the gists of `%s`:\n```%s```", login, output.ErrorText()))
+		return
+	}
+	var descriptions []string
+	if err := json.Unmarshal([]byte(output.Output), &descriptions); err != nil {
+		logging.ErrorContext(ctx, "Unreadable gists of %s in Poppit output: %v", login, err)
+		reply(fmt.Sprintf(":x: Could not read the gists of `%s`. Please try again.", login))
+		return
+	}
+	found := false
+	for _, d := range descriptions {
+		if strings.TrimSpace(d) == pending.Code {
+			found = true
+			break
+		}
+	}
+	if !found {
+		reply(fmt.Sprintf(":warning: None of the public gists of `%s` is described `%s` yet. Create it and run `/pr whoami verify` again.", login, pending.Code))
+		return
+	}
+
+	var taken *loginTakenError
+	if err := linkGitHubLogin(ctx, rdb, inv.UserID, pending.Login, false, config); errors.As(err, &taken) {
+		logging.WarnContext(ctx, "Refused link of GitHub login %s for user %s: %v", login, inv.Username, err)
+		reply(loginTakenReply(taken))
+		return
+	} else if err != nil {
+		logging.ErrorContext(ctx, "Error linking GitHub login for user %s: %v", inv.Username, err)
+		reply(":x: Failed to save your GitHub login. Please try again.")
+		return
+	}
+	if err := rdb.Del(ctx, pendingLinkKey(inv.UserID)).Err(); err != nil {
+		logging.WarnContext(ctx, "Error removing pending link for user %s: %v", inv.Username, err)
+	}
+	logging.InfoContext(ctx, "User %s verified and linked GitHub login %s", inv.Username, pending.Login)
+	reply(fmt.Sprintf(":white_check_mark: Linked you to GitHub user `%s`. You can delete the gist now.", pending.Login))
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+)
+
+// validateTimeout bounds each connectivity check run by --validate.
+const validateTimeout = 10 * time.Second
+
+// MissingSettings lists the required settings absent from config, in the
+// order startup checks them.
+func MissingSettings(config config.Config) []string {
+	var missing []string
+	if config.SlackBotToken == "" && !config.Simulate {
+		missing = append(missing, "SLACK_BOT_TOKEN environment variable is required")
+	}
+	if config.SlackChannelID == "" {
+		missing = append(missing, "slack.channel_id must be set in config.yaml")
+	}
+	if config.UsesSocketMode() && config.SlackAppToken == "" {
+		missing = append(missing, "SLACK_APP_TOKEN environment variable is required when slack.transport is socket_mode")
+	}
+	if config.HTTPAddr != "" && config.SlackSigningSecret == "" {
+		missing = append(missing, "SLACK_SIGNING_SECRET environment variable is required when http.addr is set")
+	}
+	return missing
+}
+
+// CheckConfig validates the settings config cannot check itself because
+// they are interpreted by the handlers, such as slack.message_template.
+func CheckConfig(config config.Config) error {
+	if _, err := parseMessageTemplate(config.SlackMessageTemplate); err != nil {
+		return fmt.Errorf("invalid slack.message_template: %w", err)
+	}
+	return nil
+}
+
+// validationCheck is one line of the --validate report; Detail describes a
+// passing check and Err a failing one.
+type validationCheck struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+// RunValidation checks the required settings, pings Redis, runs Slack
+// auth.test, and looks up slack.channel_id, writing a report to w. It
+// reports whether every check passed. The config itself was already parsed
+// and validated by load.
+func RunValidation(ctx context.Context, rdb *redis.Client, slackClient *slack.Client, config config.Config, w io.Writer) bool {
+	checks := []validationCheck{{Name: "config", Detail: "parsed"}}
+
+	required := validationCheck{Name: "required settings", Detail: "present"}
+	if missing := MissingSettings(config); len(missing) > 0 {
+		required.Err = errors.New(strings.Join(missing, "; "))
+	}
+	checks = append(checks, required)
+
+	redisCheck := validationCheck{Name: "redis", Detail: config.RedisAddr}
+	pingCtx, cancel := context.WithTimeout(ctx, validateTimeout)
+	redisCheck.Err = rdb.Ping(pingCtx).Err()
+	cancel()
+	checks = append(checks, redisCheck)
+
+	if config.SlackBotToken != "" {
+		checks = append(checks, checkSlackAuth(ctx, slackClient))
+		if config.SlackChannelID != "" {
+			checks = append(checks, checkSlackChannel(ctx, slackClient, config.SlackChannelID))
+		}
+		for _, channel := range routedChannels(config) {
+			checks = append(checks, checkSlackChannel(ctx, slackClient, channel))
+		}
+	}
+
+	ok := true
+	for _, c := range checks {
+		if c.Err != nil {
+			ok = false
+			fmt.Fprintf(w, "FAIL  %s: %v\n", c.Name, c.Err)
+		} else {
+			fmt.Fprintf(w, "ok    %s: %s\n", c.Name, c.Detail)
+		}
+	}
+	return ok
+}
+
+// routedChannels returns the distinct slack.channel_routes channels other
+// than the default one, sorted.
+func routedChannels(config config.Config) []string {
+	var channels []string
+	for _, channel := range config.SlackChannelRoutes {
+		if channel != config.SlackChannelID && !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	slices.Sort(channels)
+	return channels
+}
+
+// checkSlackAuth runs auth.test with the bot token.
+func checkSlackAuth(ctx context.Context, slackClient *slack.Client) validationCheck {
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+	check := validationCheck{Name: "slack auth"}
+	resp, err := slackClient.AuthTestContext(ctx)
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	check.Detail = fmt.Sprintf("%s in %s", resp.User, resp.Team)
+	return check
+}
+
+// checkSlackChannel looks up channelID, which fails when the channel does
+// not exist or the bot cannot see it.
+func checkSlackChannel(ctx context.Context, slackClient *slack.Client, channelID string) validationCheck {
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+	check := validationCheck{Name: "slack channel " + channelID}
+	channel, err := slackClient.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	check.Detail = "#" + channel.Name
+	if channel.IsArchived {
+		check.Err = errors.New("channel is archived")
+	}
+	return check
+}
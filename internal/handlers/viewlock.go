@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+)
+
+const (
+	// viewLockTTL bounds how long one update may hold a view's lock, so a
+	// replica that dies mid-update blocks the view only briefly.
+	viewLockTTL = 10 * time.Second
+	// viewLockWait is how long an update waits for the lock before giving
+	// up; it outlasts viewLockTTL so an abandoned lock expires first.
+	viewLockWait = viewLockTTL + time.Second
+	// viewLockPoll is the delay between attempts to take a held lock.
+	viewLockPoll = 50 * time.Millisecond
+)
+
+// errViewLocked is returned when a view stays locked for viewLockWait.
+var errViewLocked = errors.New("view is locked by another update")
+
+// deleteIfOwnerScript deletes KEYS[1] only while it still holds the caller's
+// token ARGV[1], so a view lock or leadership that expired and was taken by
+// another replica is not released from under it.
+var deleteIfOwnerScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// viewLockKey is the Redis key serializing updates to one modal.
+func viewLockKey(viewID string) string {
+	return "slashvibepr:viewlock:" + viewID
+}
+
+// lockView takes the lock on viewID, so that of two replicas updating the
+// same modal (say the loading watchdog and the Poppit output handler) one
+// waits for the other. The returned func releases it. When Redis cannot be
+// reached the update goes ahead unlocked rather than failing.
+func lockView(ctx context.Context, rdb *redis.Client, viewID string) (func(), error) {
+	if rdb == nil || viewID == "" {
+		return func() {}, nil
+	}
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	key := viewLockKey(viewID)
+	unlock := func() {
+		if err := deleteIfOwnerScript.Run(context.WithoutCancel(ctx), rdb, []string{key}, token).Err(); err != nil {
+			logging.WarnContext(ctx, "Error releasing lock on view_id %s: %v", viewID, err)
+		}
+	}
+
+	deadline := time.Now().Add(viewLockWait)
+	for {
+		ok, err := rdb.SetNX(ctx, key, token, viewLockTTL).Result()
+		if err != nil {
+			logging.WarnContext(ctx, "Error locking view_id %s, updating without the lock: %v", viewID, err)
+			return func() {}, nil
+		}
+		if ok {
+			return unlock, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errViewLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(viewLockPoll):
+		}
+	}
+}
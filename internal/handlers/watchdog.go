@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/slackui"
+)
+
+const (
+	loadingRetryActionID = "loading_retry"
+	loadingRetryBlockID  = "loading_retry_block"
+	loadingTimeoutText   = "This is taking longer than expected. Poppit may be busy or offline."
+)
+
+// loadingPendingKey marks a loading modal still waiting for its Poppit
+// output. Whichever of the output handler and the watchdog deletes it first
+// wins, so a late result and a timeout never both update the modal, even
+// across replicas.
+func loadingPendingKey(viewID string) string {
+	return "slashvibepr:loading:" + viewID
+}
+
+// loadingRequest is what a loading modal is waiting for, kept in the timeout
+// modal's private_metadata so Retry can send it again.
+type loadingRequest struct {
+	Repo   string        `json:"repo,omitempty"`
+	List   PRListOptions `json:"list"`
+	Search *prSearch     `json:"search,omitempty"`
+	Inv    Invocation    `json:"inv"`
+}
+
+// start fetches the request's PRs into the modal identified by viewID.
+func (r loadingRequest) start(ctx context.Context, source PRSource, viewID string) error {
+	if r.Search != nil {
+		return source.SearchPRs(ctx, *r.Search, viewID, r.Inv)
+	}
+	return source.ListPRs(ctx, r.Repo, viewID, r.Inv, r.List)
+}
+
+// armLoadingWatchdog replaces the loading modal with a timeout error and a
+// Retry button if no Poppit output for viewID arrives within
+// poppit.timeout. The timer lives in this process, so a restart drops it.
+func armLoadingWatchdog(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID string, req loadingRequest, config config.Config) {
+	if config.PoppitTimeout <= 0 || viewID == "" {
+		return
+	}
+	if err := rdb.Set(ctx, loadingPendingKey(viewID), 1, 2*config.PoppitTimeout).Err(); err != nil {
+		logging.WarnContext(ctx, "Error arming loading watchdog for view_id %s: %v", viewID, err)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	time.AfterFunc(config.PoppitTimeout, func() {
+		fireLoadingWatchdog(ctx, rdb, slackClient, viewID, req, config)
+	})
+}
+
+// fireLoadingWatchdog shows the timeout modal unless the output arrived.
+func fireLoadingWatchdog(ctx context.Context, rdb *redis.Client, slackClient SlackClient, viewID string, req loadingRequest, config config.Config) {
+	n, err := rdb.Del(ctx, loadingPendingKey(viewID)).Result()
+	if err != nil {
+		logging.WarnContext(ctx, "Error checking loading watchdog for view_id %s: %v", viewID, err)
+		return
+	}
+	if n == 0 {
+		return
+	}
+	logging.WarnContext(ctx, "No Poppit output for view_id %s after %s", viewID, config.PoppitTimeout)
+	if _, err := updateView(ctx, rdb, slackClient, createLoadingTimeoutModal(req), viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error updating modal after loading timeout: %v", err)
+	}
+}
+
+// disarmLoadingWatchdog records that the Poppit output for viewID arrived.
+func disarmLoadingWatchdog(ctx context.Context, store session.Store, viewID string) {
+	if err := store.Del(ctx, loadingPendingKey(viewID)).Err(); err != nil {
+		logging.WarnContext(ctx, "Error disarming loading watchdog for view_id %s: %v", viewID, err)
+	}
+}
+
+// createLoadingTimeoutModal is the error modal with a Retry button that
+// sends req again.
+func createLoadingTimeoutModal(req loadingRequest) slack.ModalViewRequest {
+	modal := slackui.ErrorModal(loadingTimeoutText)
+	if meta, err := session.Seal(req); err == nil {
+		modal.PrivateMetadata = meta
+	}
+	retry := slack.NewButtonBlockElement(loadingRetryActionID, "retry", slack.NewTextBlockObject(slack.PlainTextType, "Retry", false, false))
+	retry.Style = slack.StylePrimary
+	modal.Blocks.BlockSet = append(modal.Blocks.BlockSet, slack.NewActionBlock(loadingRetryBlockID, retry))
+	return modal
+}
+
+// handleLoadingRetry puts the loading modal back and re-sends the request
+// stored in the timeout modal.
+func handleLoadingRetry(ctx context.Context, rdb *redis.Client, slackClient SlackClient, action BlockActionPayload, config config.Config) {
+	var req loadingRequest
+	if err := session.Open(action.View.PrivateMetadata, &req); err != nil {
+		logging.ErrorContext(ctx, "Error decoding loading retry metadata: %v", err)
+		return
+	}
+	if req.Repo == "" && req.Search == nil {
+		logging.WarnContext(ctx, "Ignoring loading retry without a repo or search")
+		return
+	}
+	req.Inv.UserID, req.Inv.Username = action.User.ID, action.User.Username
+
+	viewID := action.View.ID
+	if _, err := updateView(ctx, rdb, slackClient, slackui.LoadingModal(), viewID, config); err != nil {
+		logging.ErrorContext(ctx, "Error restoring loading modal for retry: %v", err)
+		return
+	}
+	logging.InfoContext(ctx, "User %s retried a timed-out fetch (view_id: %s)", action.User.Username, viewID)
+	if err := req.start(ctx, newPRSource(rdb, slackClient, config), viewID); err != nil {
+		logging.ErrorContext(ctx, "Error retrying fetch for view_id %s: %v", viewID, err)
+		updateModalWithErrorByID(ctx, rdb, slackClient, viewID, "Failed to fetch pull requests. Please try again.", config)
+	}
+}
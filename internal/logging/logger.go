@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LogLevel represents the severity of a log message.
+type LogLevel int
+
+const (
+	DEBUG LogLevel = iota
+	INFO
+	WARN
+	ERROR
+)
+
+// Log record formats selectable with logging.format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var (
+	currentLogLevel LogLevel = INFO
+	slogLevels               = map[LogLevel]slog.Level{
+		DEBUG: slog.LevelDebug,
+		INFO:  slog.LevelInfo,
+		WARN:  slog.LevelWarn,
+		ERROR: slog.LevelError,
+	}
+	logger = slog.New(newLogHandler(LogFormatText, os.Stderr))
+)
+
+// SetLogLevel sets the minimum log level for output.
+func SetLogLevel(level string) {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		currentLogLevel = DEBUG
+	case "INFO":
+		currentLogLevel = INFO
+	case "WARN":
+		currentLogLevel = WARN
+	case "ERROR":
+		currentLogLevel = ERROR
+	default:
+		currentLogLevel = INFO
+		logf(WARN, "Unknown log level '%s', defaulting to INFO", level)
+	}
+}
+
+// ValidateLogFormat checks the logging.format value.
+func ValidateLogFormat(format string) error {
+	switch format {
+	case LogFormatText, LogFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (want %q or %q)", format, LogFormatText, LogFormatJSON)
+}
+
+// SetLogFormat switches log records to the given format on stderr.
+func SetLogFormat(format string) {
+	logger = slog.New(newLogHandler(format, os.Stderr))
+}
+
+// newLogHandler returns a slog handler writing JSON or text records to w.
+// Filtering by level happens in logf, so the handler passes everything.
+func newLogHandler(format string, w io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+type logAttrsKey struct{}
+
+// WithLogAttrs returns ctx carrying extra fields, such as user, repo, or
+// view_id, for every record logged with it. Empty values are skipped.
+func WithLogAttrs(ctx context.Context, keyValues ...string) context.Context {
+	attrs := append([]slog.Attr(nil), logAttrsFrom(ctx)...)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if keyValues[i+1] != "" {
+			attrs = append(attrs, slog.String(keyValues[i], keyValues[i+1]))
+		}
+	}
+	return context.WithValue(ctx, logAttrsKey{}, attrs)
+}
+
+func logAttrsFrom(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+func logf(level LogLevel, format string, args ...interface{}) {
+	logContextf(context.Background(), level, format, args...)
+}
+
+// logContextf logs like logf, adding ctx's request ID as correlation_id and
+// any fields attached with withLogAttrs.
+func logContextf(ctx context.Context, level LogLevel, format string, args ...interface{}) {
+	if level < currentLogLevel {
+		return
+	}
+	var attrs []slog.Attr
+	if id := RequestIDFrom(ctx); id != "" {
+		attrs = append(attrs, slog.String("correlation_id", id))
+	}
+	attrs = append(attrs, logAttrsFrom(ctx)...)
+	logger.LogAttrs(ctx, slogLevels[level], fmt.Sprintf(format, args...), attrs...)
+}
+
+// Debug logs a debug message (most verbose).
+func Debug(format string, args ...interface{}) {
+	logf(DEBUG, format, args...)
+}
+
+// Info logs an informational message.
+func Info(format string, args ...interface{}) {
+	logf(INFO, format, args...)
+}
+
+// Warn logs a warning message.
+func Warn(format string, args ...interface{}) {
+	logf(WARN, format, args...)
+}
+
+// Error logs an error message.
+func Error(format string, args ...interface{}) {
+	logf(ERROR, format, args...)
+}
+
+// Fatal logs a fatal error and exits.
+func Fatal(format string, args ...interface{}) {
+	logf(ERROR, format, args...)
+	os.Exit(1)
+}
+
+// DebugContext logs a debug message with ctx's request ID and fields.
+func DebugContext(ctx context.Context, format string, args ...interface{}) {
+	logContextf(ctx, DEBUG, format, args...)
+}
+
+// InfoContext logs an informational message with ctx's request ID and fields.
+func InfoContext(ctx context.Context, format string, args ...interface{}) {
+	logContextf(ctx, INFO, format, args...)
+}
+
+// WarnContext logs a warning with ctx's request ID and fields.
+func WarnContext(ctx context.Context, format string, args ...interface{}) {
+	logContextf(ctx, WARN, format, args...)
+}
+
+// ErrorContext logs an error with ctx's request ID and fields.
+func ErrorContext(ctx context.Context, format string, args ...interface{}) {
+	logContextf(ctx, ERROR, format, args...)
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// ---- Logger tests ----
+
+func TestSetLogLevelCaseInsensitive(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected LogLevel
+	}{
+		{"DEBUG", DEBUG},
+		{"debug", DEBUG},
+		{"Info", INFO},
+		{"INFO", INFO},
+		{"WARN", WARN},
+		{"warn", WARN},
+		{"ERROR", ERROR},
+		{"error", ERROR},
+	}
+	for _, tc := range cases {
+		SetLogLevel(tc.input)
+		if currentLogLevel != tc.expected {
+			t.Errorf("SetLogLevel(%q): expected %v, got %v", tc.input, tc.expected, currentLogLevel)
+		}
+	}
+	// Reset to INFO after the test.
+	SetLogLevel("INFO")
+}
+
+func TestSetLogLevelUnknownDefaultsToInfo(t *testing.T) {
+	SetLogLevel("VERBOSE")
+	if currentLogLevel != INFO {
+		t.Errorf("expected INFO for unknown level, got %v", currentLogLevel)
+	}
+}
+
+// ---- request ID tests ----
+
+func TestRequestIDContext(t *testing.T) {
+	ctx := context.Background()
+	if got := RequestIDFrom(ctx); got != "" {
+		t.Errorf("expected no request ID, got %q", got)
+	}
+	id := NewRequestID()
+	if len(id) != 12 {
+		t.Errorf("expected a 12-character ID, got %q", id)
+	}
+	ctx = WithRequestID(ctx, id)
+	if got := RequestIDFrom(WithRequestID(ctx, "")); got != id {
+		t.Errorf("expected an empty ID to keep %q, got %q", id, got)
+	}
+	if got := RequestIDFrom(WithMetadataRequestID(context.Background(), map[string]interface{}{RequestIDField: "abc"})); got != "abc" {
+		t.Errorf("expected the metadata request ID, got %q", got)
+	}
+}
+
+func TestLogContextAddsCorrelationID(t *testing.T) {
+	var buf strings.Builder
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(newLogHandler(LogFormatText, &buf))
+
+	InfoContext(WithRequestID(context.Background(), "abc123"), "hello %s", "world")
+	if !strings.Contains(buf.String(), `msg="hello world" correlation_id=abc123`) {
+		t.Errorf("unexpected log line: %q", buf.String())
+	}
+}
+
+// ---- structured logging tests ----
+
+func TestLogContextJSONFields(t *testing.T) {
+	var buf strings.Builder
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(newLogHandler(LogFormatJSON, &buf))
+
+	ctx := WithLogAttrs(WithRequestID(context.Background(), "abc123"), "user", "alice", "repo", "org/repo", "view_id", "")
+	WarnContext(ctx, "PR #%d failed", 7)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{"level": "WARN", "msg": "PR #7 failed", "correlation_id": "abc123", "user": "alice", "repo": "org/repo"}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, record[k])
+		}
+	}
+	if _, ok := record["view_id"]; ok {
+		t.Error("expected empty fields to be skipped")
+	}
+}
+
+func TestLogLevelFiltersRecords(t *testing.T) {
+	var buf strings.Builder
+	defer func(l *slog.Logger) { logger = l }(logger)
+	logger = slog.New(newLogHandler(LogFormatJSON, &buf))
+
+	Debug("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("expected debug records to be dropped at INFO, got %q", buf.String())
+	}
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDField is the Poppit, session, and SlackLiner metadata field that
+// carries the request ID, so one interaction can be traced from the slash
+// command through the Poppit output to the submission and the post.
+const RequestIDField = "request_id"
+
+type requestIDKey struct{}
+
+// NewRequestID returns a short random ID for a new interaction.
+func NewRequestID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns ctx carrying id; an empty id leaves ctx unchanged.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID carried by ctx, or "".
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithMetadataRequestID returns ctx carrying the request ID found in
+// metadata, if any.
+func WithMetadataRequestID(ctx context.Context, metadata map[string]interface{}) context.Context {
+	id, _ := metadata[RequestIDField].(string)
+	return WithRequestID(ctx, id)
+}
@@ -0,0 +1,77 @@
+package poppit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sealedEnv is the plaintext of Command.SealedEnv.
+type sealedEnv struct {
+	Env       map[string]string `json:"env"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// envAEAD returns AES-GCM for key, the POPPIT_ENV_KEY shared with Poppit.
+func envAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Poppit env key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealEnv encrypts env for the command with ID commandID, so credentials
+// such as GH_TOKEN never sit in Redis in plaintext. The sealed value is
+// bound to commandID and refused by OpenEnv after expiresAt, so it cannot
+// be moved to another command or replayed later.
+func SealEnv(key []byte, commandID string, env map[string]string, expiresAt time.Time) (string, error) {
+	aead, err := envAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(sealedEnv{Env: env, ExpiresAt: expiresAt.UTC()})
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.RawStdEncoding.EncodeToString(aead.Seal(nonce, nonce, data, []byte(commandID))), nil
+}
+
+// OpenEnv reverses SealEnv as Poppit does before running a command: it
+// returns the environment sealed for commandID, or an error when the value
+// was altered, sealed for another command, or has expired by now.
+func OpenEnv(key []byte, commandID, sealed string, now time.Time) (map[string]string, error) {
+	aead, err := envAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sealed env: %w", err)
+	}
+	n := aead.NonceSize()
+	if len(raw) < n {
+		return nil, errors.New("sealed env is too short")
+	}
+	data, err := aead.Open(nil, raw[:n], raw[n:], []byte(commandID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sealed env: %w", err)
+	}
+	var env sealedEnv
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid sealed env: %w", err)
+	}
+	if !now.Before(env.ExpiresAt) {
+		return nil, fmt.Errorf("sealed env expired at %s", env.ExpiresAt.Format(time.RFC3339))
+	}
+	return env.Env, nil
+}
@@ -0,0 +1,35 @@
+package poppit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ---- Sealed env tests ----
+
+func TestSealEnv(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	now := time.Now()
+	sealed, err := SealEnv(key, "cmd1", map[string]string{"GH_TOKEN": "ghs_secret"}, now.Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "ghs_secret") {
+		t.Fatalf("expected the token to be encrypted, got %q", sealed)
+	}
+
+	env, err := OpenEnv(key, "cmd1", sealed, now)
+	if err != nil || env["GH_TOKEN"] != "ghs_secret" {
+		t.Fatalf("expected the token back, got %v, %v", env, err)
+	}
+	if _, err := OpenEnv(key, "cmd2", sealed, now); err == nil {
+		t.Error("expected the env to be refused for another command")
+	}
+	if _, err := OpenEnv(key, "cmd1", sealed, now.Add(5*time.Minute)); err == nil {
+		t.Error("expected the env to be refused once expired")
+	}
+	if _, err := OpenEnv([]byte(strings.Repeat("x", 32)), "cmd1", sealed, now); err == nil {
+		t.Error("expected the env to be refused with another key")
+	}
+}
@@ -0,0 +1,73 @@
+package poppit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxFailureDetail caps how much of a failed command's error output is
+// quoted back to the user.
+const maxFailureDetail = 500
+
+// ghErrorHints maps fragments of gh error output (lower-cased) to what the
+// user is told. They are checked in order, so rate limiting is recognised
+// before the generic HTTP 403.
+var ghErrorHints = []struct {
+	fragment string
+	message  string
+}{
+	{"could not resolve to a repository", "Repository not found, or the GitHub account Poppit uses cannot see it."},
+	{"could not resolve to a pullrequest", "Pull request not found."},
+	{"no pull requests found", "Pull request not found."},
+	{"gh auth login", "GitHub authentication failed on the Poppit worker (`gh auth status`)."},
+	{"bad credentials", "GitHub authentication failed on the Poppit worker (`gh auth status`)."},
+	{"http 401", "GitHub authentication failed on the Poppit worker (`gh auth status`)."},
+	{"rate limit", "GitHub's API rate limit was reached. Please try again later."},
+	{"http 403", "The GitHub account Poppit uses is not allowed to do that."},
+	{"resource not accessible", "The GitHub account Poppit uses is not allowed to do that."},
+	{"command not found", "gh is not installed on the Poppit worker."},
+	{"executable file not found", "gh is not installed on the Poppit worker."},
+}
+
+// Failed reports whether Poppit says the command exited non-zero. Poppit
+// versions that do not report exit codes are treated as successful.
+func (o Output) Failed() bool {
+	return o.ExitCode != nil && *o.ExitCode != 0
+}
+
+// ErrorText is the command's error output: stderr when Poppit reports it
+// separately, otherwise the combined output.
+func (o Output) ErrorText() string {
+	if s := strings.TrimSpace(o.Stderr); s != "" {
+		return s
+	}
+	return strings.TrimSpace(o.Output)
+}
+
+// DescribeGHError names a recognised gh error in text, or returns fallback.
+func DescribeGHError(text, fallback string) string {
+	lower := strings.ToLower(text)
+	for _, h := range ghErrorHints {
+		if strings.Contains(lower, h.fragment) {
+			return h.message
+		}
+	}
+	return fallback
+}
+
+// Failure reports whether the command failed and, if so, a message
+// for the user: a recognised gh error, or the exit code and error output.
+func Failure(o Output) (string, bool) {
+	if !o.Failed() {
+		return "", false
+	}
+	detail := o.ErrorText()
+	if len(detail) > maxFailureDetail {
+		detail = detail[:maxFailureDetail] + "…"
+	}
+	fallback := fmt.Sprintf("The command failed with exit code %d.", *o.ExitCode)
+	if detail != "" {
+		fallback += fmt.Sprintf("\n```%s```", detail)
+	}
+	return DescribeGHError(o.ErrorText(), fallback), true
+}
@@ -0,0 +1,50 @@
+package poppit
+
+import (
+	"strings"
+	"testing"
+)
+
+// ---- Poppit failure tests ----
+
+func TestFailure(t *testing.T) {
+	code := func(n int) *int { return &n }
+
+	if _, failed := Failure(Output{Output: "[]"}); failed {
+		t.Error("expected output without an exit code to be treated as success")
+	}
+	if _, failed := Failure(Output{Output: "[]", ExitCode: code(0)}); failed {
+		t.Error("expected exit code 0 to be treated as success")
+	}
+
+	msg, failed := Failure(Output{ExitCode: code(1), Stderr: "GraphQL: Could not resolve to a Repository with the name 'org/nope'."})
+	if !failed || !strings.Contains(msg, "Repository not found") {
+		t.Errorf("expected a repository-not-found message, got %q", msg)
+	}
+	msg, _ = Failure(Output{ExitCode: code(4), Output: "To get started with GitHub CLI, please run:  gh auth login"})
+	if !strings.Contains(msg, "authentication failed") {
+		t.Errorf("expected an auth failure message, got %q", msg)
+	}
+	msg, _ = Failure(Output{ExitCode: code(2), Stderr: "something odd"})
+	if !strings.Contains(msg, "exit code 2") || !strings.Contains(msg, "something odd") {
+		t.Errorf("expected the exit code and stderr, got %q", msg)
+	}
+}
+
+func TestOutputErrorText(t *testing.T) {
+	if got := (Output{Output: " out ", Stderr: " err "}).ErrorText(); got != "err" {
+		t.Errorf("expected stderr to win, got %q", got)
+	}
+	if got := (Output{Output: " out "}).ErrorText(); got != "out" {
+		t.Errorf("expected the combined output, got %q", got)
+	}
+}
+
+func TestDescribeGHErrorFallback(t *testing.T) {
+	if got := DescribeGHError("unexpected end of JSON input", "fallback"); got != "fallback" {
+		t.Errorf("expected the fallback, got %q", got)
+	}
+	if got := DescribeGHError("HTTP 403: API rate limit exceeded", "fallback"); !strings.Contains(got, "rate limit") {
+		t.Errorf("expected rate limiting to be recognised before 403, got %q", got)
+	}
+}
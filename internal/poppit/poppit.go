@@ -0,0 +1,55 @@
+// Package poppit describes the commands sent to Poppit, the service that
+// runs gh on SlashVibePR's behalf, and the output it publishes back.
+package poppit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/envelope"
+)
+
+// Command is the payload sent to Poppit via Redis to execute a command.
+type Command struct {
+	Repo     string   `json:"repo"`
+	Branch   string   `json:"branch"`
+	Type     string   `json:"type"`
+	Dir      string   `json:"dir"`
+	Commands []string `json:"commands"`
+	// SealedEnv is extra environment for the commands, such as GH_TOKEN,
+	// sealed with SealEnv so it does not travel in plaintext.
+	SealedEnv string                 `json:"sealed_env,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Output is the payload published by Poppit after command execution.
+// ExitCode and Stderr are only set by Poppit versions that report them;
+// older ones fold stderr into Output.
+type Output struct {
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Type     string                 `json:"type"`
+	Command  string                 `json:"command"`
+	Output   string                 `json:"output"`
+	ExitCode *int                   `json:"exit_code,omitempty"`
+	Stderr   string                 `json:"stderr,omitempty"`
+}
+
+// Queue is the Redis list Poppit takes commands from. *redis.Client
+// implements it.
+type Queue interface {
+	RPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+}
+
+// Push enqueues cmd on the Poppit list, written in format.
+func Push(ctx context.Context, queue Queue, list string, cmd Command, format envelope.Format) error {
+	payload, err := format.Encode(envelope.KindPoppitCommand, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Poppit command: %w", err)
+	}
+	if err := queue.RPush(ctx, list, payload).Err(); err != nil {
+		return fmt.Errorf("failed to push Poppit command to Redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Package session keeps short-lived interaction state: the PR sessions
+// behind chooser modals in Redis, and the sealing that encrypts them and
+// modal private_metadata when SESSION_ENCRYPTION_KEY is set.
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealPrefix marks a value encrypted with the session key, so it is never
+// mistaken for plain JSON.
+const sealPrefix = "sealed:v1:"
+
+// keyAEAD encrypts PR sessions and modal private_metadata when
+// SESSION_ENCRYPTION_KEY is set; nil leaves them as plain JSON.
+var keyAEAD cipher.AEAD
+
+// ParseKey decodes a base64 AES key of 16, 24, or 32 bytes. An empty
+// string means no key.
+func ParseKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("not valid base64: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("key is %d bytes, want 16, 24, or 32", len(key))
+}
+
+// SetKey turns on AES-GCM sealing with key, or turns it off when key
+// is empty.
+func SetKey(key []byte) error {
+	if len(key) == 0 {
+		keyAEAD = nil
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("invalid session key: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("invalid session key: %w", err)
+	}
+	keyAEAD = gcm
+	return nil
+}
+
+// Seal marshals v as JSON and, when a session key is set, encrypts it.
+// The result is safe to keep in Redis or a modal's private_metadata.
+func Seal(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return seal(data)
+}
+
+// seal encrypts data when a session key is set.
+func seal(data []byte) (string, error) {
+	if keyAEAD == nil {
+		return string(data), nil
+	}
+	nonce := make([]byte, keyAEAD.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := keyAEAD.Seal(nonce, nonce, data, nil)
+	return sealPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open reverses Seal into v. With a session key set, anything not
+// sealed with it is rejected, so tampered or plaintext values are not
+// trusted.
+func Open(s string, v interface{}) error {
+	data, err := open(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// open reverses seal.
+func open(s string) ([]byte, error) {
+	data := []byte(s)
+	if keyAEAD != nil {
+		encoded, ok := strings.CutPrefix(s, sealPrefix)
+		if !ok {
+			return nil, errors.New("value is not sealed")
+		}
+		sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode sealed value: %w", err)
+		}
+		n := keyAEAD.NonceSize()
+		if len(sealed) < n {
+			return nil, errors.New("sealed value is too short")
+		}
+		if data, err = keyAEAD.Open(nil, sealed[:n], sealed[n:], nil); err != nil {
+			return nil, fmt.Errorf("failed to open sealed value: %w", err)
+		}
+	} else if strings.HasPrefix(s, sealPrefix) {
+		return nil, errors.New("value is sealed but SESSION_ENCRYPTION_KEY is not set")
+	}
+	return data, nil
+}
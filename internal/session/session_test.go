@@ -0,0 +1,145 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+)
+
+type testValue struct {
+	Repo  string `json:"repo"`
+	Title string `json:"title"`
+}
+
+func withTestKey(t *testing.T) {
+	t.Helper()
+	if err := SetKey([]byte(strings.Repeat("k", 32))); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetKey(nil) })
+}
+
+// ---- Sealing tests ----
+
+func TestParseKey(t *testing.T) {
+	if key, err := ParseKey(""); err != nil || key != nil {
+		t.Errorf("expected no key for an empty value, got %v, %v", key, err)
+	}
+	if key, err := ParseKey(base64.StdEncoding.EncodeToString(make([]byte, 32))); err != nil || len(key) != 32 {
+		t.Errorf("expected a 32-byte key, got %d bytes, %v", len(key), err)
+	}
+	for _, bad := range []string{"not base64!", base64.StdEncoding.EncodeToString(make([]byte, 20))} {
+		if _, err := ParseKey(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSealRoundTrip(t *testing.T) {
+	withTestKey(t)
+	in := testValue{Repo: "org/secret-repo", Title: "Private title"}
+	sealed, err := Seal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, sealPrefix) || strings.Contains(sealed, "secret-repo") || strings.Contains(sealed, "Private title") {
+		t.Errorf("expected an opaque sealed value, got %q", sealed)
+	}
+	var out testValue
+	if err := Open(sealed, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("round trip mismatch: %+v", out)
+	}
+}
+
+func TestOpenRejectsTamperedAndPlainValues(t *testing.T) {
+	withTestKey(t)
+	sealed, err := Seal(testValue{Repo: "acme/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if tampered == sealed {
+		tampered = sealed[:len(sealed)-2] + "BB"
+	}
+	var out testValue
+	for _, s := range []string{tampered, `{"repo":"acme/api"}`} {
+		if err := Open(s, &out); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestOpenWithoutKey(t *testing.T) {
+	var out testValue
+	if err := Open(`{"repo":"acme/api"}`, &out); err != nil || out.Repo != "acme/api" {
+		t.Errorf("expected plain JSON to parse without a key, got %+v, %v", out, err)
+	}
+	if err := Open(sealPrefix+"AAAA", &out); err == nil {
+		t.Error("expected a sealed value to be rejected without a key")
+	}
+}
+
+// ---- Store tests ----
+
+func TestSaveAndLoad(t *testing.T) {
+	withTestKey(t)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	in := testValue{Repo: "acme/api", Title: "Fix bug"}
+	if err := Save(ctx, rdb, "k", in, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("k"); ttl != time.Minute {
+		t.Errorf("expected a 1m TTL, got %s", ttl)
+	}
+	var out testValue
+	if err := Load(ctx, rdb, "k", &out); err != nil || out != in {
+		t.Errorf("expected %+v, got %+v, %v", in, out, err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if err := Load(ctx, rdb, "k", &out); !errors.Is(err, redis.Nil) {
+		t.Errorf("expected redis.Nil for an expired session, got %v", err)
+	}
+}
+
+func TestSaveWithMessagePack(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	in := testValue{Repo: "acme/api", Title: "Fix bug"}
+	if err := Save(ctx, rdb, "json", in, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	SetCodec(codec.MessagePack)
+	t.Cleanup(func() { SetCodec(codec.JSON) })
+	if err := Save(ctx, rdb, "msgpack", in, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := mr.Get("msgpack")
+	if !codec.IsMessagePack([]byte(stored)) {
+		t.Errorf("expected a MessagePack session, got %q", stored)
+	}
+
+	// Sessions saved before the switch stay readable.
+	for _, key := range []string{"json", "msgpack"} {
+		var out testValue
+		if err := Load(ctx, rdb, key, &out); err != nil || out != in {
+			t.Errorf("%s: expected %+v, got %+v, %v", key, in, out, err)
+		}
+	}
+}
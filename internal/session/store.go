@@ -0,0 +1,62 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+)
+
+// Store is the keyed Redis state the interaction handlers read and write,
+// such as PR sessions and loading watchdogs. *redis.Client implements it.
+type Store interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// storeCodec encodes the values Save stores; Load reads either codec, so
+// sessions saved before a switch stay readable.
+var storeCodec = codec.JSON
+
+// SetCodec selects the codec Save encodes values with.
+func SetCodec(c codec.Codec) {
+	storeCodec = c
+}
+
+// Save encodes v with the session codec, seals it, and stores it under key
+// for ttl.
+func Save(ctx context.Context, store Store, key string, v interface{}, ttl time.Duration) error {
+	data, err := storeCodec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	sealed, err := seal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := store.Set(ctx, key, sealed, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+// Load fetches the value under key and opens it into v. It returns
+// redis.Nil (wrapped) when the value has expired or never existed.
+func Load(ctx context.Context, store Store, key string, v interface{}) error {
+	data, err := store.Get(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	opened, err := open(data)
+	if err == nil {
+		err = codec.Unmarshal(opened, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse session: %w", err)
+	}
+	return nil
+}
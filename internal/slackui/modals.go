@@ -0,0 +1,628 @@
+// Package slackui builds the Block Kit modals shared by the /pr flows: the
+// repo and PR choosers, the loading and error modals, and the block and
+// action IDs the handlers read back from their submissions.
+package slackui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+)
+
+// Callback, block, and action IDs of the chooser modals.
+const (
+	repoModalCallbackID    = "select_pr_repo_modal"
+	RepoBlockID            = "repo_block"
+	PRModalCallbackID      = "select_pr_modal"
+	SlashVibeIssueActionID = "SlashVibeIssue"
+	PRBlockID              = "pr_block"
+	PRSelectActionID       = "pr_select"
+	NoteBlockID            = "note_block"
+	NoteInputActionID      = "note_input"
+	OrgBlockID             = "org_block"
+	OrgSelectActionID      = "org_select"
+	ReviewerBlockID        = "reviewer_block"
+	ReviewerSelectActionID = "reviewer_select"
+	ChannelBlockID         = "channel_block"
+	ChannelActionID        = "channel_select"
+	MessageTTLBlockID      = "ttl_block"
+	MessageTTLActionID     = "ttl_select"
+	ScheduleBlockID        = "schedule_block"
+	ScheduleActionID       = "schedule_at"
+	AuthorBlockID          = "author_block"
+	AuthorSelectActionID   = "author_select"
+	LabelBlockID           = "label_block"
+	LabelSelectActionID    = "label_select"
+	DefaultRepoBlockID     = "default_repo_block"
+	DefaultRepoActionID    = "default_repo"
+
+	// AnyAuthorValue is the author filter option that clears the filter;
+	// Slack option values cannot be empty.
+	AnyAuthorValue = "*"
+	// maxAuthorOptions keeps the author filter, plus its "Anyone" option,
+	// within Slack's 100-option limit for static selects.
+	maxAuthorOptions = 99
+	// maxLabelOptions is Slack's option limit for the label filter.
+	maxLabelOptions = 100
+	// maxModalTitleLength is Slack's limit on a modal title, in characters.
+	maxModalTitleLength = 24
+
+	// MaxRequestedReviewers caps the reviewer multi-select in the PR chooser.
+	MaxRequestedReviewers = 10
+)
+
+// RepoChooserMetadata is stored in the repo chooser's private_metadata so the
+// org picked in the org selector reaches handleBlockAction with the repo, and
+// the user's default repo survives re-rendering the chooser for another org.
+type RepoChooserMetadata struct {
+	Org         string `json:"org,omitempty"`
+	DefaultRepo string `json:"default_repo,omitempty"`
+}
+
+// RepoChooserModal returns a modal for the user to select a repository
+// from a dropdown populated by OctoCatalog (external select).
+// The select element is placed in an actions block so that choosing a repo
+// immediately dispatches a block_actions event (no submit button required),
+// which provides a fresh trigger_id and prevents the PR modal from being missed.
+// When more than one org is configured an org selector is shown above the
+// repo select, and the chosen org is carried in the private metadata.
+// A non-empty defaultRepo ("owner/name") is offered as a button below the
+// select, so it can be opened with one click.
+func RepoChooserModal(orgs []string, selectedOrg, defaultRepo string) slack.ModalViewRequest {
+	blocks := []slack.Block{
+		&slack.SectionBlock{
+			Type: slack.MBTSection,
+			Text: &slack.TextBlockObject{
+				Type: slack.MarkdownType,
+				Text: "Select a repository to list its open pull requests.",
+			},
+		},
+	}
+	if len(orgs) > 1 {
+		blocks = append(blocks, slack.NewActionBlock(OrgBlockID, orgSelectElement(orgs, selectedOrg)))
+	}
+	blocks = append(blocks, slack.NewActionBlock(
+		RepoBlockID,
+		&slack.SelectBlockElement{
+			Type:     slack.OptTypeExternal,
+			ActionID: SlashVibeIssueActionID,
+			Placeholder: &slack.TextBlockObject{
+				Type: slack.PlainTextType,
+				Text: "Search for a repo...",
+			},
+		},
+	))
+
+	if defaultRepo != "" {
+		blocks = append(blocks, slack.NewActionBlock(
+			DefaultRepoBlockID,
+			slack.NewButtonBlockElement(DefaultRepoActionID, defaultRepo,
+				slack.NewTextBlockObject(slack.PlainTextType, TruncateOptionText("Open "+defaultRepo), false, false)),
+		))
+	}
+
+	var privateMetadata string
+	if selectedOrg != "" || defaultRepo != "" {
+		privateMetadata, _ = session.Seal(RepoChooserMetadata{Org: selectedOrg, DefaultRepo: defaultRepo})
+	}
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      repoModalCallbackID,
+		PrivateMetadata: privateMetadata,
+		Title: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Select Repository",
+		},
+		Close: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Cancel",
+		},
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// orgSelectElement builds the static org selector with selectedOrg preselected.
+func orgSelectElement(orgs []string, selectedOrg string) *slack.SelectBlockElement {
+	options := make([]*slack.OptionBlockObject, 0, len(orgs))
+	var initial *slack.OptionBlockObject
+	for _, org := range orgs {
+		opt := slack.NewOptionBlockObject(org, slack.NewTextBlockObject(slack.PlainTextType, org, false, false), nil)
+		if org == selectedOrg {
+			initial = opt
+		}
+		options = append(options, opt)
+	}
+	el := slack.NewOptionsSelectBlockElement(
+		slack.OptTypeStatic,
+		slack.NewTextBlockObject(slack.PlainTextType, "Organisation", false, false),
+		OrgSelectActionID,
+		options...,
+	)
+	el.InitialOption = initial
+	return el
+}
+
+// LoadingModal returns a transient modal shown while Poppit fetches PRs.
+func LoadingModal() slack.ModalViewRequest {
+	return slack.ModalViewRequest{
+		Type: slack.VTModal,
+		Title: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Loading PRs...",
+		},
+		Close: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Cancel",
+		},
+		Blocks: slack.Blocks{
+			BlockSet: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{
+						Type: slack.MarkdownType,
+						Text: ":hourglass_flowing_sand: Fetching open pull requests, please wait...",
+					},
+				},
+			},
+		},
+	}
+}
+
+// PRChooserFilters describes the filters a PR chooser's list was narrowed by.
+type PRChooserFilters struct {
+	// State is "closed" or "merged" when the list is not of open PRs.
+	State string
+	// Base, Author, and Labels are the base branch, author, and label
+	// filters in effect; empty when unfiltered.
+	Base   string
+	Author string
+	Labels []string
+	// Authors are offered in the author filter select, which is shown only
+	// when there are at least two to choose between.
+	Authors []string
+	// AvailableLabels are offered in the label filter multi-select, which is
+	// shown whenever the listed PRs carry any labels.
+	AvailableLabels []string
+	// Truncated is set when Base is a pattern matched against a list cut
+	// off at the fetch limit, so matching PRs may be missing.
+	Truncated bool
+}
+
+// PRChooserDestination is the channel a PR chooser posts to unless another
+// is picked. Routed is set when slack.channel_routes chose it for the repo.
+type PRChooserDestination struct {
+	Channel string
+	Routed  bool
+}
+
+// PRChooserModal returns a modal presenting a typeahead of PRs.
+// The select is external: its options are served from the PR session by
+// handleBlockSuggestion, so only the number of PRs, count, is shown.
+// Active filters are shown in the header and the base branch in the title,
+// so the user knows the list is narrowed. Author and label selects let the
+// user narrow it further without leaving the modal. multi switches to a
+// multi-select so several PRs can be posted in one submission. An optional
+// reviewer multi-select, served from the org's member catalog, requests
+// reviews on the posted PRs. A channel select shows where the post goes,
+// starting at destination, and lets the user send it elsewhere. A select
+// sets how long the post is kept, starting at messageTTL, and an optional
+// date and time picker schedules the post for later. privateMetadata is
+// stored in the modal and retrieved on submission.
+func PRChooserModal(count int, repo string, filters PRChooserFilters, multi bool, destination PRChooserDestination, messageTTL time.Duration, privateMetadata string) slack.ModalViewRequest {
+	state := filters.State
+	if state == "" {
+		state = "open"
+	}
+	post := "post one to the channel"
+	if multi {
+		post = "post one or more to the channel"
+	}
+	header := fmt.Sprintf("*%s* — %d %s pull requests. Type to filter by title or number, then %s.", repo, count, state, post)
+	var active []string
+	if filters.Base != "" {
+		active = append(active, fmt.Sprintf("base: `%s`", filters.Base))
+	}
+	if filters.Author != "" {
+		active = append(active, fmt.Sprintf("author: `%s`", filters.Author))
+	}
+	if len(filters.Labels) > 0 {
+		active = append(active, fmt.Sprintf("labels: `%s`", strings.Join(filters.Labels, "`, `")))
+	}
+	if len(active) > 0 {
+		header = fmt.Sprintf("*%s* (%s) — %d %s pull requests. Type to filter by title or number, then %s.", repo, strings.Join(active, ", "), count, state, post)
+	}
+	if filters.Truncated {
+		header += "\n" + TruncatedBaseNote(filters.Base)
+	}
+	minQueryLength := 0
+	maxReviewers := MaxRequestedReviewers
+	placeholder := &slack.TextBlockObject{
+		Type: slack.PlainTextType,
+		Text: "Search pull requests",
+	}
+
+	var element slack.BlockElement = &slack.SelectBlockElement{
+		Type:           slack.OptTypeExternal,
+		ActionID:       PRSelectActionID,
+		Placeholder:    placeholder,
+		MinQueryLength: &minQueryLength,
+	}
+	label := "Pull Request"
+	if multi {
+		element = &slack.MultiSelectBlockElement{
+			Type:           slack.MultiOptTypeExternal,
+			ActionID:       PRSelectActionID,
+			Placeholder:    placeholder,
+			MinQueryLength: &minQueryLength,
+		}
+		label = "Pull Requests"
+	}
+
+	blocks := []slack.Block{
+		&slack.SectionBlock{
+			Type: slack.MBTSection,
+			Text: &slack.TextBlockObject{
+				Type: slack.MarkdownType,
+				Text: header,
+			},
+		},
+	}
+	if len(filters.Authors) > 1 {
+		blocks = append(blocks, slack.NewActionBlock(AuthorBlockID, authorSelectElement(filters.Authors, filters.Author)))
+	}
+	if len(filters.AvailableLabels) > 0 {
+		blocks = append(blocks, slack.NewActionBlock(LabelBlockID, labelSelectElement(filters.AvailableLabels, filters.Labels)))
+	}
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      PRModalCallbackID,
+		PrivateMetadata: privateMetadata,
+		Title: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: prChooserTitle(filters.Base),
+		},
+		Submit: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Post to Channel",
+		},
+		Close: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Cancel",
+		},
+		Blocks: slack.Blocks{
+			BlockSet: append(blocks,
+				&slack.InputBlock{
+					Type:    slack.MBTInput,
+					BlockID: PRBlockID,
+					Label: &slack.TextBlockObject{
+						Type: slack.PlainTextType,
+						Text: label,
+					},
+					Element: element,
+				},
+				&slack.InputBlock{
+					Type:     slack.MBTInput,
+					BlockID:  NoteBlockID,
+					Optional: true,
+					Label: &slack.TextBlockObject{
+						Type: slack.PlainTextType,
+						Text: "Why should people look at this?",
+					},
+					Element: &slack.PlainTextInputBlockElement{
+						Type:      slack.METPlainTextInput,
+						ActionID:  NoteInputActionID,
+						Multiline: true,
+						MaxLength: 500,
+						Placeholder: &slack.TextBlockObject{
+							Type: slack.PlainTextType,
+							Text: "Optional note for the channel",
+						},
+					},
+				},
+				&slack.InputBlock{
+					Type:     slack.MBTInput,
+					BlockID:  ReviewerBlockID,
+					Optional: true,
+					Label: &slack.TextBlockObject{
+						Type: slack.PlainTextType,
+						Text: "Request reviews from",
+					},
+					Element: &slack.MultiSelectBlockElement{
+						Type:             slack.MultiOptTypeExternal,
+						ActionID:         ReviewerSelectActionID,
+						MinQueryLength:   &minQueryLength,
+						MaxSelectedItems: &maxReviewers,
+						Placeholder: &slack.TextBlockObject{
+							Type: slack.PlainTextType,
+							Text: "Optional GitHub reviewers",
+						},
+					},
+				},
+				channelInputBlock(destination),
+				&slack.InputBlock{
+					Type:     slack.MBTInput,
+					BlockID:  MessageTTLBlockID,
+					Optional: true,
+					Label: &slack.TextBlockObject{
+						Type: slack.PlainTextType,
+						Text: "Remove from the channel after",
+					},
+					Element: messageTTLSelectElement(messageTTL),
+				},
+				&slack.InputBlock{
+					Type:     slack.MBTInput,
+					BlockID:  ScheduleBlockID,
+					Optional: true,
+					Label: &slack.TextBlockObject{
+						Type: slack.PlainTextType,
+						Text: "Post later",
+					},
+					Hint: &slack.TextBlockObject{
+						Type: slack.PlainTextType,
+						Text: "Leave empty to post now, or pick a time such as after standup.",
+					},
+					Element: slack.NewDateTimePickerBlockElement(ScheduleActionID),
+				},
+			),
+		},
+	}
+}
+
+// channelInputBlock returns the chooser's "Post to" channel select, starting
+// at destination. A routed destination is explained in the hint.
+func channelInputBlock(destination PRChooserDestination) *slack.InputBlock {
+	block := &slack.InputBlock{
+		Type:     slack.MBTInput,
+		BlockID:  ChannelBlockID,
+		Optional: true,
+		Label: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Post to",
+		},
+		Element: &slack.SelectBlockElement{
+			Type:                slack.OptTypeConversations,
+			ActionID:            ChannelActionID,
+			InitialConversation: destination.Channel,
+			Filter:              &slack.SelectBlockElementFilter{Include: []string{"public", "private"}},
+			Placeholder: &slack.TextBlockObject{
+				Type: slack.PlainTextType,
+				Text: "Pick a channel",
+			},
+		},
+	}
+	if destination.Routed {
+		block.Hint = &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "PRs from this repo are routed here. Pick another channel to post there instead.",
+		}
+	}
+	return block
+}
+
+// prChooserTitle names the base branch in the chooser's title when the list is
+// filtered by one, so teams on release branches see at a glance which branch
+// they are looking at. Long names are cut to fit Slack's title limit; the
+// header always carries the full name.
+func prChooserTitle(base string) string {
+	if base == "" {
+		return "Select a Pull Request"
+	}
+	title := []rune("PRs into " + base)
+	if len(title) > maxModalTitleLength {
+		title = append(title[:maxModalTitleLength-1], '…')
+	}
+	return string(title)
+}
+
+// authorSelectElement builds the PR chooser's author filter with selected
+// preselected, or "Anyone" when no author filter is in effect.
+func authorSelectElement(authors []string, selected string) *slack.SelectBlockElement {
+	if len(authors) > maxAuthorOptions {
+		authors = authors[:maxAuthorOptions]
+	}
+	anyone := slack.NewOptionBlockObject(AnyAuthorValue, slack.NewTextBlockObject(slack.PlainTextType, "Anyone", false, false), nil)
+	options := []*slack.OptionBlockObject{anyone}
+	initial := anyone
+	for _, author := range authors {
+		opt := slack.NewOptionBlockObject(author, slack.NewTextBlockObject(slack.PlainTextType, TruncateOptionText(author), false, false), nil)
+		if author == selected {
+			initial = opt
+		}
+		options = append(options, opt)
+	}
+	el := slack.NewOptionsSelectBlockElement(
+		slack.OptTypeStatic,
+		slack.NewTextBlockObject(slack.PlainTextType, "Filter by author", false, false),
+		AuthorSelectActionID,
+		options...,
+	)
+	el.InitialOption = initial
+	return el
+}
+
+// labelSelectElement builds the PR chooser's label filter with the labels in
+// selected preselected.
+func labelSelectElement(labels, selected []string) *slack.MultiSelectBlockElement {
+	if len(labels) > maxLabelOptions {
+		labels = labels[:maxLabelOptions]
+	}
+	options := make([]*slack.OptionBlockObject, 0, len(labels))
+	var initial []*slack.OptionBlockObject
+	for _, label := range labels {
+		opt := slack.NewOptionBlockObject(label, slack.NewTextBlockObject(slack.PlainTextType, TruncateOptionText(label), false, false), nil)
+		for _, s := range selected {
+			if strings.EqualFold(s, label) {
+				initial = append(initial, opt)
+				break
+			}
+		}
+		options = append(options, opt)
+	}
+	el := slack.NewOptionsMultiSelectBlockElement(
+		slack.MultiOptTypeStatic,
+		slack.NewTextBlockObject(slack.PlainTextType, "Filter by label", false, false),
+		LabelSelectActionID,
+		options...,
+	)
+	el.InitialOptions = initial
+	return el
+}
+
+// messageTTLChoices are the lifetimes offered for a post. A TTL of zero
+// keeps the post until it is deleted.
+var messageTTLChoices = []struct {
+	TTL   time.Duration
+	Label string
+}{
+	{time.Hour, "1 hour"},
+	{24 * time.Hour, "1 day"},
+	{7 * 24 * time.Hour, "1 week"},
+	{0, "No expiry"},
+}
+
+// messageTTLSelectElement returns the post lifetime select with
+// defaultTTL, slack.message_ttl, selected. A default that is not one of the
+// choices is offered first. Option values are the TTL in whole seconds, as
+// SlackLiner takes it.
+func messageTTLSelectElement(defaultTTL time.Duration) *slack.SelectBlockElement {
+	option := func(ttl time.Duration, label string) *slack.OptionBlockObject {
+		if ttl == defaultTTL {
+			label += " (default)"
+		}
+		return slack.NewOptionBlockObject(strconv.Itoa(int(ttl/time.Second)),
+			slack.NewTextBlockObject(slack.PlainTextType, label, false, false), nil)
+	}
+	var options []*slack.OptionBlockObject
+	var initial *slack.OptionBlockObject
+	for _, c := range messageTTLChoices {
+		opt := option(c.TTL, c.Label)
+		if c.TTL == defaultTTL {
+			initial = opt
+		}
+		options = append(options, opt)
+	}
+	if initial == nil {
+		initial = option(defaultTTL, FormatAge(defaultTTL))
+		options = append([]*slack.OptionBlockObject{initial}, options...)
+	}
+	el := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, nil, MessageTTLActionID, options...)
+	el.InitialOption = initial
+	return el
+}
+
+// TruncatedBaseNote warns that base, a branch pattern, was matched against
+// only the newest pull requests, so some that match may be missing.
+func TruncatedBaseNote(base string) string {
+	return fmt.Sprintf("_Only the newest pull requests were matched against `%s`, so some may be missing._", base)
+}
+
+// TruncateOptionText shortens text to Slack's 75-character option limit,
+// counting runes so multi-byte characters are never split.
+func TruncateOptionText(text string) string {
+	runes := []rune(text)
+	if len(runes) > 75 {
+		return string(runes[:72]) + "..."
+	}
+	return text
+}
+
+// ReviewDecisionLabel turns gh's reviewDecision into a short readable label.
+func ReviewDecisionLabel(decision string) string {
+	switch decision {
+	case "APPROVED":
+		return "approved"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "REVIEW_REQUIRED":
+		return "review required"
+	default:
+		return strings.ToLower(strings.ReplaceAll(decision, "_", " "))
+	}
+}
+
+// FormatAge renders a duration in the coarsest sensible unit: minutes, hours,
+// days, or weeks.
+func FormatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	default:
+		return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+	}
+}
+
+// FormatOpened renders when a PR was opened as its date in now's timezone
+// and its age, e.g. "May 1, 3d ago". The year is added when it differs from
+// now's.
+func FormatOpened(created, now time.Time) string {
+	created = created.In(now.Location())
+	layout := "Jan 2"
+	if created.Year() != now.Year() {
+		layout = "Jan 2 2006"
+	}
+	return created.Format(layout) + ", " + FormatAge(now.Sub(created)) + " ago"
+}
+
+// AutoPostedModal returns a modal confirming that PR number of repo was
+// automatically posted to the channel without requiring the user to choose.
+func AutoPostedModal(repo string, number int, title string) slack.ModalViewRequest {
+	return slack.ModalViewRequest{
+		Type: slack.VTModal,
+		Title: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "PR Posted",
+		},
+		Close: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Close",
+		},
+		Blocks: slack.Blocks{
+			BlockSet: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{
+						Type: slack.MarkdownType,
+						Text: fmt.Sprintf(":white_check_mark: Only one pull request was found for `%s`.\n\n*PR #%d: %s* has been posted to the channel.", repo, number, title),
+					},
+				},
+			},
+		},
+	}
+}
+
+// ErrorModal returns a modal displaying an error message.
+func ErrorModal(message string) slack.ModalViewRequest {
+	return slack.ModalViewRequest{
+		Type: slack.VTModal,
+		Title: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Error",
+		},
+		Close: &slack.TextBlockObject{
+			Type: slack.PlainTextType,
+			Text: "Close",
+		},
+		Blocks: slack.Blocks{
+			BlockSet: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{
+						Type: slack.MarkdownType,
+						Text: fmt.Sprintf(":x: %s", message),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,378 @@
+package slackui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// ---- Modal creation tests ----
+
+func TestRepoChooserModalStructure(t *testing.T) {
+	modal := RepoChooserModal(nil, "", "")
+
+	if modal.Type != slack.VTModal {
+		t.Errorf("expected modal type 'modal', got %q", modal.Type)
+	}
+	if modal.CallbackID != repoModalCallbackID {
+		t.Errorf("expected callback_id %q, got %q", repoModalCallbackID, modal.CallbackID)
+	}
+	if modal.Submit != nil {
+		t.Errorf("repo chooser modal must not have a submit button (uses block actions instead)")
+	}
+	if len(modal.Blocks.BlockSet) != 2 {
+		t.Errorf("expected 2 blocks, got %d", len(modal.Blocks.BlockSet))
+	}
+}
+
+func TestRepoChooserModalUsesExternalSelect(t *testing.T) {
+	modal := RepoChooserModal(nil, "", "")
+
+	actionBlock, ok := modal.Blocks.BlockSet[1].(*slack.ActionBlock)
+	if !ok {
+		t.Fatal("expected second block to be an ActionBlock")
+	}
+
+	if len(actionBlock.Elements.ElementSet) != 1 {
+		t.Fatalf("expected 1 element in action block, got %d", len(actionBlock.Elements.ElementSet))
+	}
+
+	selectEl, ok := actionBlock.Elements.ElementSet[0].(*slack.SelectBlockElement)
+	if !ok {
+		t.Fatal("expected element to be SelectBlockElement")
+	}
+	if selectEl.Type != slack.OptTypeExternal {
+		t.Errorf("expected external select type, got %q", selectEl.Type)
+	}
+	if selectEl.ActionID != SlashVibeIssueActionID {
+		t.Errorf("expected action_id %q, got %q", SlashVibeIssueActionID, selectEl.ActionID)
+	}
+}
+
+func TestLoadingModal(t *testing.T) {
+	modal := LoadingModal()
+
+	if modal.Type != slack.VTModal {
+		t.Errorf("expected modal type 'modal', got %q", modal.Type)
+	}
+	if modal.Submit != nil {
+		t.Error("loading modal should not have a submit button")
+	}
+	if len(modal.Blocks.BlockSet) != 1 {
+		t.Errorf("expected 1 block, got %d", len(modal.Blocks.BlockSet))
+	}
+}
+
+func TestErrorModal(t *testing.T) {
+	modal := ErrorModal("something went wrong")
+
+	if modal.Submit != nil {
+		t.Error("error modal should not have a submit button")
+	}
+	if len(modal.Blocks.BlockSet) != 1 {
+		t.Errorf("expected 1 block, got %d", len(modal.Blocks.BlockSet))
+	}
+}
+
+// ---- Base-branch filtering tests ----
+
+func TestPRChooserModalShowsBase(t *testing.T) {
+	modal := PRChooserModal(1, "org/repo", PRChooserFilters{Base: "release/*"}, false, PRChooserDestination{}, 24*time.Hour, "")
+
+	section := modal.Blocks.BlockSet[0].(*slack.SectionBlock)
+	if !strings.Contains(section.Text.Text, "release/*") {
+		t.Errorf("expected base branch in chooser header, got %q", section.Text.Text)
+	}
+	if modal.Title.Text != "PRs into release/*" {
+		t.Errorf("expected base branch in chooser title, got %q", modal.Title.Text)
+	}
+	if strings.Contains(section.Text.Text, "may be missing") {
+		t.Errorf("expected no truncation note, got %q", section.Text.Text)
+	}
+
+	modal = PRChooserModal(1, "org/repo", PRChooserFilters{Base: "release/*", Truncated: true}, false, PRChooserDestination{}, 24*time.Hour, "")
+	section = modal.Blocks.BlockSet[0].(*slack.SectionBlock)
+	if !strings.Contains(section.Text.Text, "may be missing") {
+		t.Errorf("expected a truncation note in chooser header, got %q", section.Text.Text)
+	}
+}
+
+func TestPRChooserTitle(t *testing.T) {
+	cases := map[string]string{
+		"":                       "Select a Pull Request",
+		"release/2.x":            "PRs into release/2.x",
+		"feature/very-long-name": "PRs into feature/very-l…",
+	}
+	for base, want := range cases {
+		if got := prChooserTitle(base); got != want || len([]rune(got)) > maxModalTitleLength {
+			t.Errorf("prChooserTitle(%q) = %q, want %q", base, got, want)
+		}
+	}
+}
+
+func TestPRChooserModalAuthorFilter(t *testing.T) {
+	modal := PRChooserModal(3, "org/repo", PRChooserFilters{Author: "bob"}, false, PRChooserDestination{}, 24*time.Hour, "")
+	if len(modal.Blocks.BlockSet) != 7 {
+		t.Errorf("expected no author filter with a single author, got %d blocks", len(modal.Blocks.BlockSet))
+	}
+
+	modal = PRChooserModal(3, "org/repo", PRChooserFilters{Base: "main", Author: "bob", Authors: []string{"alice", "bob"}}, false, PRChooserDestination{}, 24*time.Hour, "")
+	section := modal.Blocks.BlockSet[0].(*slack.SectionBlock)
+	if !strings.Contains(section.Text.Text, "(base: `main`, author: `bob`)") {
+		t.Errorf("expected both filters in chooser header, got %q", section.Text.Text)
+	}
+	actions, ok := modal.Blocks.BlockSet[1].(*slack.ActionBlock)
+	if !ok || actions.BlockID != AuthorBlockID {
+		t.Fatalf("expected the author filter after the header, got %+v", modal.Blocks.BlockSet[1])
+	}
+	sel := actions.Elements.ElementSet[0].(*slack.SelectBlockElement)
+	if len(sel.Options) != 3 || sel.Options[0].Value != AnyAuthorValue || sel.InitialOption.Value != "bob" {
+		t.Errorf("expected Anyone plus both authors with bob selected, got %+v", sel)
+	}
+}
+
+func TestPRChooserModalState(t *testing.T) {
+	modal := PRChooserModal(4, "org/repo", PRChooserFilters{State: "merged"}, false, PRChooserDestination{}, 24*time.Hour, "")
+	section := modal.Blocks.BlockSet[0].(*slack.SectionBlock)
+	if !strings.Contains(section.Text.Text, "4 merged pull requests") {
+		t.Errorf("expected merged wording in chooser header, got %q", section.Text.Text)
+	}
+}
+
+func TestPRChooserModalLabelFilter(t *testing.T) {
+	modal := PRChooserModal(2, "org/repo", PRChooserFilters{Labels: []string{"ui"}, AvailableLabels: []string{"backend", "ui"}}, false, PRChooserDestination{}, 24*time.Hour, "")
+	section := modal.Blocks.BlockSet[0].(*slack.SectionBlock)
+	if !strings.Contains(section.Text.Text, "(labels: `ui`)") {
+		t.Errorf("expected the label filter in chooser header, got %q", section.Text.Text)
+	}
+	actions, ok := modal.Blocks.BlockSet[1].(*slack.ActionBlock)
+	if !ok || actions.BlockID != LabelBlockID {
+		t.Fatalf("expected the label filter after the header, got %+v", modal.Blocks.BlockSet[1])
+	}
+	sel := actions.Elements.ElementSet[0].(*slack.MultiSelectBlockElement)
+	if len(sel.Options) != 2 || len(sel.InitialOptions) != 1 || sel.InitialOptions[0].Value != "ui" {
+		t.Errorf("expected both labels with ui selected, got %+v", sel)
+	}
+}
+
+// ---- PR option description tests ----
+
+func TestFormatAge(t *testing.T) {
+	cases := map[time.Duration]string{
+		5 * time.Minute:      "5m",
+		3 * time.Hour:        "3h",
+		2 * 24 * time.Hour:   "2d",
+		30 * 24 * time.Hour:  "4w",
+		13 * 24 * time.Hour:  "13d",
+		59 * time.Minute:     "59m",
+		23 * time.Hour:       "23h",
+		100 * 24 * time.Hour: "14w",
+	}
+	for d, want := range cases {
+		if got := FormatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestFormatOpened(t *testing.T) {
+	created := time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	if got := FormatOpened(created, created.Add(3*24*time.Hour)); got != "May 1, 3d ago" {
+		t.Errorf("unexpected UTC rendering: %q", got)
+	}
+	if got := FormatOpened(created, created.Add(3*24*time.Hour).In(tokyo)); got != "May 2, 3d ago" {
+		t.Errorf("expected the date in now's timezone, got %q", got)
+	}
+	if got := FormatOpened(created, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)); got != "May 1 2024, 35w ago" {
+		t.Errorf("expected the year for an earlier year, got %q", got)
+	}
+}
+
+// ---- Multi-select tests ----
+
+func TestPRChooserModalMulti(t *testing.T) {
+	modal := PRChooserModal(2, "org/repo", PRChooserFilters{}, true, PRChooserDestination{}, 24*time.Hour, "")
+
+	inputBlock := modal.Blocks.BlockSet[1].(*slack.InputBlock)
+	multi, ok := inputBlock.Element.(*slack.MultiSelectBlockElement)
+	if !ok {
+		t.Fatalf("expected MultiSelectBlockElement, got %T", inputBlock.Element)
+	}
+	if multi.Type != slack.MultiOptTypeExternal {
+		t.Errorf("expected multi external select, got %q", multi.Type)
+	}
+	if multi.ActionID != PRSelectActionID {
+		t.Errorf("unexpected action_id %q", multi.ActionID)
+	}
+	header := modal.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text
+	if !strings.Contains(header, "post one or more") {
+		t.Errorf("expected the multi-select wording in the header, got %q", header)
+	}
+
+	single := PRChooserModal(2, "org/repo", PRChooserFilters{}, false, PRChooserDestination{}, 24*time.Hour, "")
+	if header := single.Blocks.BlockSet[0].(*slack.SectionBlock).Text.Text; strings.Contains(header, "or more") {
+		t.Errorf("expected the single-select wording in the header, got %q", header)
+	}
+}
+
+// ---- Note field tests ----
+
+func TestPRChooserModalHasOptionalNote(t *testing.T) {
+	modal := PRChooserModal(1, "org/repo", PRChooserFilters{}, false, PRChooserDestination{}, 24*time.Hour, "")
+
+	noteBlock, ok := modal.Blocks.BlockSet[2].(*slack.InputBlock)
+	if !ok {
+		t.Fatal("expected third block to be an InputBlock")
+	}
+	if noteBlock.BlockID != NoteBlockID || !noteBlock.Optional {
+		t.Errorf("expected optional note block, got %+v", noteBlock)
+	}
+	if _, ok := noteBlock.Element.(*slack.PlainTextInputBlockElement); !ok {
+		t.Errorf("expected plain-text input, got %T", noteBlock.Element)
+	}
+}
+
+// ---- Review decision tests ----
+
+func TestReviewDecisionLabel(t *testing.T) {
+	cases := map[string]string{
+		"APPROVED":          "approved",
+		"CHANGES_REQUESTED": "changes requested",
+		"REVIEW_REQUIRED":   "review required",
+		"":                  "",
+	}
+	for in, want := range cases {
+		if got := ReviewDecisionLabel(in); got != want {
+			t.Errorf("reviewDecisionLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// ---- Multi-org tests ----
+
+func TestRepoChooserModalWithOrgSelector(t *testing.T) {
+	modal := RepoChooserModal([]string{"acme", "widgets-inc"}, "widgets-inc", "")
+
+	if len(modal.Blocks.BlockSet) != 3 {
+		t.Fatalf("expected 3 blocks with org selector, got %d", len(modal.Blocks.BlockSet))
+	}
+	orgBlock, ok := modal.Blocks.BlockSet[1].(*slack.ActionBlock)
+	if !ok || orgBlock.BlockID != OrgBlockID {
+		t.Fatalf("expected org action block second, got %T", modal.Blocks.BlockSet[1])
+	}
+	sel := orgBlock.Elements.ElementSet[0].(*slack.SelectBlockElement)
+	if sel.InitialOption == nil || sel.InitialOption.Value != "widgets-inc" {
+		t.Errorf("expected widgets-inc preselected, got %+v", sel.InitialOption)
+	}
+	if modal.PrivateMetadata != `{"org":"widgets-inc"}` {
+		t.Errorf("unexpected private metadata: %q", modal.PrivateMetadata)
+	}
+}
+
+func TestRepoChooserModalSingleOrgHasNoSelector(t *testing.T) {
+	modal := RepoChooserModal([]string{"acme"}, "acme", "")
+	if len(modal.Blocks.BlockSet) != 2 {
+		t.Errorf("expected no org selector for a single org, got %d blocks", len(modal.Blocks.BlockSet))
+	}
+}
+
+func TestRepoChooserModalDefaultRepoButton(t *testing.T) {
+	modal := RepoChooserModal(nil, "", "acme/api")
+	block, ok := modal.Blocks.BlockSet[len(modal.Blocks.BlockSet)-1].(*slack.ActionBlock)
+	if !ok || block.BlockID != DefaultRepoBlockID {
+		t.Fatalf("expected default repo button last, got %+v", modal.Blocks.BlockSet)
+	}
+	if btn := block.Elements.ElementSet[0].(*slack.ButtonBlockElement); btn.ActionID != DefaultRepoActionID || btn.Value != "acme/api" {
+		t.Errorf("unexpected default repo button: %+v", btn)
+	}
+	if modal.PrivateMetadata != `{"default_repo":"acme/api"}` {
+		t.Errorf("unexpected private metadata: %q", modal.PrivateMetadata)
+	}
+}
+
+// ---- Reviewer request tests ----
+
+func TestPRChooserModalHasOptionalReviewers(t *testing.T) {
+	modal := PRChooserModal(1, "org/repo", PRChooserFilters{}, false, PRChooserDestination{}, 24*time.Hour, "")
+
+	block, ok := modal.Blocks.BlockSet[3].(*slack.InputBlock)
+	if !ok || block.BlockID != ReviewerBlockID || !block.Optional {
+		t.Fatalf("expected optional reviewer block after the note, got %+v", modal.Blocks.BlockSet[3])
+	}
+	sel, ok := block.Element.(*slack.MultiSelectBlockElement)
+	if !ok || sel.Type != slack.MultiOptTypeExternal || sel.ActionID != ReviewerSelectActionID {
+		t.Errorf("expected external multi-select for reviewers, got %+v", block.Element)
+	}
+}
+
+func TestPRChooserModalHasOptionalSchedule(t *testing.T) {
+	modal := PRChooserModal(1, "org/repo", PRChooserFilters{}, false, PRChooserDestination{}, 24*time.Hour, "")
+
+	last := modal.Blocks.BlockSet[len(modal.Blocks.BlockSet)-1]
+	block, ok := last.(*slack.InputBlock)
+	if !ok || block.BlockID != ScheduleBlockID || !block.Optional {
+		t.Fatalf("expected optional schedule block last, got %+v", last)
+	}
+	if picker, ok := block.Element.(*slack.DateTimePickerBlockElement); !ok || picker.ActionID != ScheduleActionID {
+		t.Errorf("expected a date and time picker, got %+v", block.Element)
+	}
+}
+
+func TestPRChooserModalMessageTTLSelect(t *testing.T) {
+	ttlSelect := func(modal slack.ModalViewRequest) *slack.SelectBlockElement {
+		t.Helper()
+		for _, b := range modal.Blocks.BlockSet {
+			if in, ok := b.(*slack.InputBlock); ok && in.BlockID == MessageTTLBlockID {
+				return in.Element.(*slack.SelectBlockElement)
+			}
+		}
+		t.Fatal("expected a message TTL select")
+		return nil
+	}
+
+	sel := ttlSelect(PRChooserModal(1, "org/repo", PRChooserFilters{}, false, PRChooserDestination{}, 24*time.Hour, ""))
+	var values []string
+	for _, opt := range sel.Options {
+		values = append(values, opt.Value)
+	}
+	if strings.Join(values, ",") != "3600,86400,604800,0" {
+		t.Errorf("unexpected TTL options: %v", values)
+	}
+	if sel.InitialOption.Value != "86400" || sel.InitialOption.Text.Text != "1 day (default)" {
+		t.Errorf("expected 1 day selected by default, got %+v", sel.InitialOption.Text)
+	}
+
+	sel = ttlSelect(PRChooserModal(1, "org/repo", PRChooserFilters{}, false, PRChooserDestination{}, 12*time.Hour, ""))
+	if len(sel.Options) != 5 || sel.Options[0] != sel.InitialOption || sel.InitialOption.Value != "43200" {
+		t.Errorf("expected a configured 12h TTL to be offered first and selected, got %+v", sel.InitialOption)
+	}
+}
+
+func TestPRChooserModalChannelSelect(t *testing.T) {
+	channelBlock := func(modal slack.ModalViewRequest) *slack.InputBlock {
+		for _, b := range modal.Blocks.BlockSet {
+			if input, ok := b.(*slack.InputBlock); ok && input.BlockID == ChannelBlockID {
+				return input
+			}
+		}
+		t.Fatal("expected a channel select")
+		return nil
+	}
+
+	block := channelBlock(PRChooserModal(1, "org/repo", PRChooserFilters{}, false, PRChooserDestination{Channel: "C1"}, 24*time.Hour, ""))
+	sel, ok := block.Element.(*slack.SelectBlockElement)
+	if !ok || sel.Type != slack.OptTypeConversations || sel.ActionID != ChannelActionID || sel.InitialConversation != "C1" {
+		t.Fatalf("unexpected channel select: %+v", block.Element)
+	}
+	if !block.Optional || block.Hint != nil {
+		t.Errorf("expected an optional select without a routing hint, got %+v", block)
+	}
+
+	block = channelBlock(PRChooserModal(1, "org/payments-api", PRChooserFilters{}, false, PRChooserDestination{Channel: "CPAY", Routed: true}, 24*time.Hour, ""))
+	if block.Hint == nil || !strings.Contains(block.Hint.Text, "routed") {
+		t.Errorf("expected a routing hint, got %+v", block.Hint)
+	}
+}
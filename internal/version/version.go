@@ -0,0 +1,58 @@
+// Package version reports the build of the running binary.
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata, set at link time:
+//
+//	go build -ldflags "-X github.com/its-the-vibe/SlashVibePR/internal/version.version=v1.2.3 \
+//	  -X github.com/its-the-vibe/SlashVibePR/internal/version.commit=abc1234 \
+//	  -X github.com/its-the-vibe/SlashVibePR/internal/version.buildDate=2024-01-02T03:04:05Z"
+//
+// Builds without ldflags fall back to the VCS details Go embeds.
+var (
+	version   = "dev"
+	commit    string
+	buildDate string
+)
+
+// Info identifies the running build in /healthz, /readyz, and the
+// metadata of posted messages.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+}
+
+// Get returns the build metadata, filling a missing commit or date from the
+// binary's embedded VCS stamp.
+func Get() Info {
+	info := Info{Version: version, Commit: commit, BuildDate: buildDate}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	return info
+}
+
+// String formats the build for --version and the startup log.
+func (b Info) String() string {
+	s := "SlashVibePR " + b.Version
+	if b.Commit != "" {
+		s += fmt.Sprintf(" (commit %s", b.Commit)
+		if b.BuildDate != "" {
+			s += ", built " + b.BuildDate
+		}
+		s += ")"
+	}
+	return s
+}
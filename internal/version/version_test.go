@@ -0,0 +1,19 @@
+package version
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "v1.2.3", "abc1234", "2024-01-02T03:04:05Z"
+
+	info := Get()
+	if info != (Info{Version: "v1.2.3", Commit: "abc1234", BuildDate: "2024-01-02T03:04:05Z"}) {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+	if got := info.String(); got != "SlashVibePR v1.2.3 (commit abc1234, built 2024-01-02T03:04:05Z)" {
+		t.Errorf("unexpected version string: %q", got)
+	}
+	if got := (Info{Version: "dev"}).String(); got != "SlashVibePR dev" {
+		t.Errorf("unexpected version string: %q", got)
+	}
+}
@@ -2,7 +2,8 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -10,23 +11,63 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/slack-go/slack"
+
+	"github.com/its-the-vibe/SlashVibePR/internal/codec"
+	"github.com/its-the-vibe/SlashVibePR/internal/config"
+	"github.com/its-the-vibe/SlashVibePR/internal/handlers"
+	"github.com/its-the-vibe/SlashVibePR/internal/logging"
+	"github.com/its-the-vibe/SlashVibePR/internal/session"
+	"github.com/its-the-vibe/SlashVibePR/internal/version"
 )
 
 func main() {
-	config := loadConfig()
+	validate := flag.Bool("validate", false, "check the configuration, Redis, and Slack, print a report, and exit")
+	showVersion := flag.Bool("version", false, "print the version, commit, and build date, and exit")
+	dryRun := flag.Bool("dry-run", false, "print Slack requests, SlackLiner posts, and Poppit commands instead of sending them")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.Get())
+		return
+	}
 
-	SetLogLevel(config.LogLevel)
+	config := config.Load()
+	if *dryRun {
+		config.Simulate = true
+	}
+	if err := handlers.CheckConfig(config); err != nil {
+		logging.Fatal("%v", err)
+	}
+
+	logging.SetLogFormat(config.LogFormat)
+	logging.SetLogLevel(config.LogLevel)
+	logging.Info("Starting %s", version.Get())
+	if err := session.SetKey(config.SessionKey); err != nil {
+		logging.Fatal("%v", err)
+	}
+	session.SetCodec(codec.Named(config.SessionCodec))
 
-	if config.SlackBotToken == "" {
-		Fatal("SLACK_BOT_TOKEN environment variable is required")
+	if *validate {
+		rdb := redis.NewClient(&redis.Options{Addr: config.RedisAddr, Password: config.RedisPassword})
+		defer rdb.Close()
+		if !handlers.RunValidation(context.Background(), rdb, slack.New(config.SlackBotToken), config, os.Stdout) {
+			os.Exit(1)
+		}
+		return
 	}
-	if config.SlackChannelID == "" {
-		Fatal("slack.channel_id must be set in config.yaml")
+
+	if missing := handlers.MissingSettings(config); len(missing) > 0 {
+		logging.Fatal("%s", missing[0])
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := handlers.SetupTracing(ctx, config)
+	if err != nil {
+		logging.Fatal("Failed to set up tracing: %v", err)
+	}
+
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     config.RedisAddr,
 		Password: config.RedisPassword,
@@ -35,24 +76,34 @@ func main() {
 	defer rdb.Close()
 
 	if err := rdb.Ping(ctx).Err(); err != nil {
-		Fatal("Failed to connect to Redis: %v", err)
+		logging.Fatal("Failed to connect to Redis: %v", err)
 	}
-	Info("Connected to Redis at %s", config.RedisAddr)
+	logging.Info("Connected to Redis at %s", config.RedisAddr)
 
-	slackClient := slack.New(config.SlackBotToken)
+	var slackOptions []slack.Option
+	if config.UsesSocketMode() {
+		slackOptions = append(slackOptions, slack.OptionAppLevelToken(config.SlackAppToken))
+	}
+	slackClient := slack.New(config.SlackBotToken, slackOptions...)
 
-	go subscribeToSlashCommands(ctx, rdb, slackClient, config)
-	go subscribeToViewSubmissions(ctx, rdb, slackClient, config)
-	go subscribeToBlockActions(ctx, rdb, slackClient, config)
-	go subscribeToPoppitOutput(ctx, rdb, slackClient, config)
+	if config.Vault != nil {
+		go config.Vault.KeepAlive(ctx)
+	}
+	handlers.NewService(rdb, slackClient, config).Start(ctx)
 
-	log.Println("SlashVibePR service started")
+	logging.Info("SlashVibePR service started")
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
-	Info("Shutting down...")
+	logging.Info("Shutting down...")
 	cancel()
 	time.Sleep(1 * time.Second)
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logging.Warn("Error flushing traces: %v", err)
+	}
 }